@@ -290,6 +290,16 @@ type EventCancelOptions struct{}
 // signatures.
 type EventCancelManyOptions struct{}
 
+// EventSuspendOptions represents useful, optional settings for suspending an
+// Event. It currently has no fields, but exists to preserve the possibility of
+// future expansion without having to change client function signatures.
+type EventSuspendOptions struct{}
+
+// EventResumeOptions represents useful, optional settings for resuming an
+// Event. It currently has no fields, but exists to preserve the possibility of
+// future expansion without having to change client function signatures.
+type EventResumeOptions struct{}
+
 // EventDeleteOptions represents useful, optional settings for deleting an
 // Event. It currently has no fields, but exists to preserve the possibility of
 // future expansion without having to change client function signatures.
@@ -348,6 +358,14 @@ type EventsClient interface {
 		EventsSelector,
 		*EventCancelManyOptions,
 	) (CancelManyEventsResult, error)
+	// Suspend suspends a single running Event specified by its identifier. While
+	// an Event is suspended, its Worker's existing Jobs continue to run, but no
+	// new Jobs are started.
+	Suspend(context.Context, string, *EventSuspendOptions) error
+	// Resume resumes a single suspended Event specified by its identifier. Any
+	// of its Worker's Jobs that were held while the Event was suspended are
+	// released in the order they were created.
+	Resume(context.Context, string, *EventResumeOptions) error
 	// Delete deletes a single Event specified by its identifier.
 	Delete(context.Context, string, *EventDeleteOptions) error
 	// DeleteMany deletes multiple Events specified by the EventListOptions
@@ -368,12 +386,17 @@ type EventsClient interface {
 
 	// Logs returns a specialized client for Log management.
 	Logs() LogsClient
+
+	// Exec returns a specialized client for executing commands within the
+	// containers of running Workers and Jobs.
+	Exec() ExecClient
 }
 
 type eventsClient struct {
 	*rm.BaseClient
 	workersClient WorkersClient
 	logsClient    LogsClient
+	execClient    ExecClient
 }
 
 // NewEventsClient returns a specialized client for managing Events.
@@ -386,6 +409,7 @@ func NewEventsClient(
 		BaseClient:    rm.NewBaseClient(apiAddress, apiToken, opts),
 		workersClient: NewWorkersClient(apiAddress, apiToken, opts),
 		logsClient:    NewLogsClient(apiAddress, apiToken, opts),
+		execClient:    NewExecClient(apiAddress, apiToken, opts),
 	}
 }
 
@@ -509,6 +533,36 @@ func (e *eventsClient) Cancel(
 	)
 }
 
+func (e *eventsClient) Suspend(
+	ctx context.Context,
+	id string,
+	_ *EventSuspendOptions,
+) error {
+	return e.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method:      http.MethodPut,
+			Path:        fmt.Sprintf("v2/events/%s/suspension", id),
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (e *eventsClient) Resume(
+	ctx context.Context,
+	id string,
+	_ *EventResumeOptions,
+) error {
+	return e.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method:      http.MethodDelete,
+			Path:        fmt.Sprintf("v2/events/%s/suspension", id),
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (e *eventsClient) CancelMany(
 	ctx context.Context,
 	selector EventsSelector,
@@ -587,6 +641,10 @@ func (e *eventsClient) Logs() LogsClient {
 	return e.logsClient
 }
 
+func (e *eventsClient) Exec() ExecClient {
+	return e.execClient
+}
+
 func eventsSelectorToQueryParams(selector *EventsSelector) map[string]string {
 	if selector == nil {
 		return nil
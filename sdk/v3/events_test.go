@@ -39,6 +39,8 @@ func TestNewEventsClient(t *testing.T) {
 	require.Equal(t, client.workersClient, client.Workers())
 	require.NotNil(t, client.logsClient)
 	require.Equal(t, client.logsClient, client.Logs())
+	require.NotNil(t, client.execClient)
+	require.Equal(t, client.execClient, client.Exec())
 }
 
 func TestEventsClientCreate(t *testing.T) {
@@ -335,6 +337,48 @@ func TestEventsClientCancel(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestEventsClientSuspend(t *testing.T) {
+	const testEventID = "12345"
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPut, r.Method)
+				require.Equal(
+					t,
+					fmt.Sprintf("/v2/events/%s/suspension", testEventID),
+					r.URL.Path,
+				)
+				w.WriteHeader(http.StatusOK)
+			},
+		),
+	)
+	defer server.Close()
+	client := NewEventsClient(server.URL, rmTesting.TestAPIToken, nil)
+	err := client.Suspend(context.Background(), testEventID, nil)
+	require.NoError(t, err)
+}
+
+func TestEventsClientResume(t *testing.T) {
+	const testEventID = "12345"
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodDelete, r.Method)
+				require.Equal(
+					t,
+					fmt.Sprintf("/v2/events/%s/suspension", testEventID),
+					r.URL.Path,
+				)
+				w.WriteHeader(http.StatusOK)
+			},
+		),
+	)
+	defer server.Close()
+	client := NewEventsClient(server.URL, rmTesting.TestAPIToken, nil)
+	err := client.Resume(context.Background(), testEventID, nil)
+	require.NoError(t, err)
+}
+
 func TestEventsClientCancelMany(t *testing.T) {
 	const testProjectID = "bluebook"
 	const testSource = "foo-gateway"
@@ -0,0 +1,304 @@
+package sdk
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	rm "github.com/brigadecore/brigade/sdk/v3/internal/restmachinery"
+	"github.com/brigadecore/brigade/sdk/v3/restmachinery"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Exec sessions are carried over a WebSocket. Every message exchanged is a
+// binary message whose first byte identifies the channel the remainder of the
+// message belongs to.
+const (
+	execChannelStdin  byte = 0
+	execChannelStdout byte = 1
+	execChannelStderr byte = 2
+	execChannelStatus byte = 3
+	execChannelResize byte = 4
+)
+
+// ExecSelector represents useful criteria for selecting a container belonging
+// to some Worker OR a container belonging to a Job spawned by that Worker in
+// which to execute a command.
+type ExecSelector struct {
+	// Job specifies, by name, a Job spawned by some Worker. If not specified,
+	// exec operations presume a container belonging to the Worker itself is
+	// desired.
+	Job string
+	// Container specifies, by name, a container belonging to some Worker or, if
+	// Job is specified, that Job. If not specified, exec operations presume the
+	// container having the same name as the selected Worker or Job is desired.
+	Container string
+}
+
+// ExecOptions represents useful options for executing a command within some
+// container of a Worker or Job.
+type ExecOptions struct {
+	// Command is the command to execute, including any arguments.
+	Command []string
+	// TTY indicates whether the command should be executed with a pseudo
+	// terminal allocated.
+	TTY bool
+}
+
+// TerminalSize represents the width and height of a terminal.
+type TerminalSize struct {
+	// Width is the width of the terminal, in characters.
+	Width uint16 `json:"width"`
+	// Height is the height of the terminal, in characters.
+	Height uint16 `json:"height"`
+}
+
+// ExecStreams represents the streams to which a command executed within a
+// container is attached.
+type ExecStreams struct {
+	// Stdin is the command's standard input. If nil, the command's standard
+	// input is not attached.
+	Stdin io.Reader
+	// Stdout is the command's standard output.
+	Stdout io.Writer
+	// Stderr is the command's standard error. It is unused if a pseudo terminal
+	// was requested, in which case all output is written to Stdout.
+	Stderr io.Writer
+	// TerminalSizes is an optional channel over which changes to the size of
+	// the client's terminal are communicated. It is only meaningful if a pseudo
+	// terminal was requested.
+	TerminalSizes <-chan TerminalSize
+}
+
+// ExecExitError represents the non-zero exit code of a command executed within
+// a container.
+type ExecExitError struct {
+	// ExitCode is the command's exit code.
+	ExitCode int
+}
+
+func (e *ExecExitError) Error() string {
+	return fmt.Sprintf("command terminated with exit code %d", e.ExitCode)
+}
+
+// execStatus represents the outcome of an exec session as reported by the API
+// server.
+type execStatus struct {
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ExecClient is the specialized client for executing commands within the
+// containers of running Workers and Jobs for debugging purposes.
+type ExecClient interface {
+	// Exec executes a command within a container of an Event's Worker or, using
+	// the ExecSelector parameter, a container of a Job spawned by that Worker,
+	// with the specified streams attached. It blocks until the command exits. If
+	// the command exits with a non-zero exit code, an *ExecExitError is
+	// returned.
+	Exec(
+		ctx context.Context,
+		eventID string,
+		selector *ExecSelector,
+		opts *ExecOptions,
+		streams ExecStreams,
+	) error
+}
+
+type execClient struct {
+	*rm.BaseClient
+	dialer *websocket.Dialer
+}
+
+// NewExecClient returns a specialized client for executing commands within the
+// containers of running Workers and Jobs.
+func NewExecClient(
+	apiAddress string,
+	apiToken string,
+	opts *restmachinery.APIClientOptions,
+) ExecClient {
+	if opts == nil {
+		opts = &restmachinery.APIClientOptions{}
+	}
+	return &execClient{
+		BaseClient: rm.NewBaseClient(apiAddress, apiToken, opts),
+		dialer: &websocket.Dialer{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: opts.AllowInsecureConnections, // nolint: gosec
+			},
+		},
+	}
+}
+
+// nolint: gocyclo
+func (e *execClient) Exec(
+	ctx context.Context,
+	eventID string,
+	selector *ExecSelector,
+	opts *ExecOptions,
+	streams ExecStreams,
+) error {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	u, err := url.Parse(
+		fmt.Sprintf("%s/v2/events/%s/exec", e.APIAddress, eventID),
+	)
+	if err != nil {
+		return errors.Wrap(err, "error parsing API address")
+	}
+	// The API server's address is an HTTP(S) address, but we need a WebSocket
+	// address.
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	queryParams := url.Values{}
+	if selector != nil {
+		if selector.Job != "" {
+			queryParams.Set("job", selector.Job)
+		}
+		if selector.Container != "" {
+			queryParams.Set("container", selector.Container)
+		}
+	}
+	for _, arg := range opts.Command {
+		queryParams.Add("command", arg)
+	}
+	if opts.TTY {
+		queryParams.Set("tty", trueStr)
+	}
+	if streams.Stdin != nil {
+		queryParams.Set("stdin", trueStr)
+	}
+	u.RawQuery = queryParams.Encode()
+
+	header := http.Header{}
+	header.Add("Authorization", fmt.Sprintf("Bearer %s", e.APIToken))
+	conn, resp, err := e.dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				return rm.ErrorFromResponse(resp)
+			}
+		}
+		return errors.Wrap(err, "error opening exec session")
+	}
+	defer conn.Close()
+
+	// Closing the connection unblocks everything below if the context is
+	// canceled.
+	sessionDoneCh := make(chan struct{})
+	defer close(sessionDoneCh)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close() // nolint: errcheck
+		case <-sessionDoneCh:
+		}
+	}()
+
+	writeMu := &sync.Mutex{}
+	write := func(channel byte, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteMessage(
+			websocket.BinaryMessage,
+			append([]byte{channel}, data...),
+		)
+	}
+
+	if streams.Stdin != nil {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := streams.Stdin.Read(buf)
+				if n > 0 {
+					if werr := write(execChannelStdin, buf[:n]); werr != nil {
+						return
+					}
+				}
+				if err != nil {
+					// An empty message signals EOF
+					write(execChannelStdin, nil) // nolint: errcheck
+					return
+				}
+			}
+		}()
+	}
+
+	if opts.TTY && streams.TerminalSizes != nil {
+		go func() {
+			for {
+				select {
+				case size, ok := <-streams.TerminalSizes:
+					if !ok {
+						return
+					}
+					sizeBytes, err := json.Marshal(size)
+					if err != nil {
+						continue
+					}
+					if err = write(execChannelResize, sizeBytes); err != nil {
+						return
+					}
+				case <-sessionDoneCh:
+					return
+				}
+			}
+		}()
+	}
+
+	var status *execStatus
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				break
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Wrap(err, "error reading from exec session")
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		switch msg[0] {
+		case execChannelStdout:
+			if streams.Stdout != nil {
+				if _, err = streams.Stdout.Write(msg[1:]); err != nil {
+					return errors.Wrap(err, "error writing to stdout")
+				}
+			}
+		case execChannelStderr:
+			if streams.Stderr != nil {
+				if _, err = streams.Stderr.Write(msg[1:]); err != nil {
+					return errors.Wrap(err, "error writing to stderr")
+				}
+			}
+		case execChannelStatus:
+			status = &execStatus{}
+			if err = json.Unmarshal(msg[1:], status); err != nil {
+				return errors.Wrap(err, "error unmarshaling exec session status")
+			}
+		}
+	}
+
+	if status == nil {
+		return errors.New("exec session ended without reporting a status")
+	}
+	if status.Error != "" {
+		return errors.New(status.Error)
+	}
+	if status.ExitCode != 0 {
+		return &ExecExitError{ExitCode: status.ExitCode}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rmTesting "github.com/brigadecore/brigade/sdk/v3/internal/restmachinery/testing" // nolint: lll
+	"github.com/brigadecore/brigade/sdk/v3/meta"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecExitError(t *testing.T) {
+	err := &ExecExitError{ExitCode: 42}
+	require.Equal(t, "command terminated with exit code 42", err.Error())
+}
+
+func TestNewExecClient(t *testing.T) {
+	client, ok := NewExecClient(
+		rmTesting.TestAPIAddress,
+		rmTesting.TestAPIToken,
+		nil,
+	).(*execClient)
+	require.True(t, ok)
+	rmTesting.RequireBaseClient(t, client.BaseClient)
+	require.NotNil(t, client.dialer)
+}
+
+func TestExecClientExec(t *testing.T) {
+	const testEventID = "12345"
+	testSelector := &ExecSelector{
+		Job:       "farpoint",
+		Container: "enterprise",
+	}
+	testOpts := &ExecOptions{
+		Command: []string{"cat", "-"},
+	}
+	testCases := []struct {
+		name       string
+		handler    func(*websocket.Conn)
+		stdin      string
+		assertions func(stdout string, stderr string, err error)
+	}{
+		{
+			name: "command exits with non-zero exit code",
+			handler: func(conn *websocket.Conn) {
+				require.NoError(
+					t,
+					conn.WriteMessage(
+						websocket.BinaryMessage,
+						append([]byte{execChannelStderr}, []byte("oops")...),
+					),
+				)
+				require.NoError(
+					t,
+					conn.WriteMessage(
+						websocket.BinaryMessage,
+						append([]byte{execChannelStatus}, []byte(`{"exitCode":3}`)...),
+					),
+				)
+			},
+			assertions: func(stdout string, stderr string, err error) {
+				require.Error(t, err)
+				require.IsType(t, &ExecExitError{}, err)
+				require.Equal(t, 3, err.(*ExecExitError).ExitCode)
+				require.Empty(t, stdout)
+				require.Equal(t, "oops", stderr)
+			},
+		},
+		{
+			name: "command could not be executed",
+			handler: func(conn *websocket.Conn) {
+				require.NoError(
+					t,
+					conn.WriteMessage(
+						websocket.BinaryMessage,
+						append(
+							[]byte{execChannelStatus},
+							[]byte(`{"exitCode":-1,"error":"something went wrong"}`)...,
+						),
+					),
+				)
+			},
+			assertions: func(_ string, _ string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			name:    "success",
+			handler: echoStdin(t),
+			stdin:   "Make it so.",
+			assertions: func(stdout string, stderr string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "Make it so.", stdout)
+				require.Empty(t, stderr)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := httptest.NewServer(
+				http.HandlerFunc(
+					func(w http.ResponseWriter, r *http.Request) {
+						require.Equal(t, http.MethodGet, r.Method)
+						require.Equal(
+							t,
+							fmt.Sprintf("/v2/events/%s/exec", testEventID),
+							r.URL.Path,
+						)
+						require.Equal(
+							t,
+							fmt.Sprintf("Bearer %s", rmTesting.TestAPIToken),
+							r.Header.Get("Authorization"),
+						)
+						require.Equal(t, testSelector.Job, r.URL.Query().Get("job"))
+						require.Equal(
+							t,
+							testSelector.Container,
+							r.URL.Query().Get("container"),
+						)
+						require.Equal(t, testOpts.Command, r.URL.Query()["command"])
+						conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+						require.NoError(t, err)
+						defer conn.Close()
+						testCase.handler(conn)
+						require.NoError(
+							t,
+							conn.WriteMessage(
+								websocket.CloseMessage,
+								websocket.FormatCloseMessage(
+									websocket.CloseNormalClosure,
+									"",
+								),
+							),
+						)
+					},
+				),
+			)
+			defer server.Close()
+			client := NewExecClient(server.URL, rmTesting.TestAPIToken, nil)
+			streams := ExecStreams{
+				Stdout: &bytes.Buffer{},
+				Stderr: &bytes.Buffer{},
+			}
+			if testCase.stdin != "" {
+				streams.Stdin = strings.NewReader(testCase.stdin)
+			}
+			err := client.Exec(
+				context.Background(),
+				testEventID,
+				testSelector,
+				testOpts,
+				streams,
+			)
+			testCase.assertions(
+				streams.Stdout.(*bytes.Buffer).String(),
+				streams.Stderr.(*bytes.Buffer).String(),
+				err,
+			)
+		})
+	}
+
+	t.Run("error opening session", func(t *testing.T) {
+		server := httptest.NewServer(
+			http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"type":"Event","id":"12345"}`)
+				},
+			),
+		)
+		defer server.Close()
+		client := NewExecClient(server.URL, rmTesting.TestAPIToken, nil)
+		err := client.Exec(
+			context.Background(),
+			testEventID,
+			testSelector,
+			testOpts,
+			ExecStreams{},
+		)
+		require.Error(t, err)
+		require.IsType(t, &meta.ErrNotFound{}, err)
+		require.Equal(t, testEventID, err.(*meta.ErrNotFound).ID)
+	})
+}
+
+// echoStdin returns a handler that echoes anything received over the stdin
+// channel back to the client over the stdout channel until EOF is received.
+func echoStdin(t *testing.T) func(*websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		for {
+			_, msg, err := conn.ReadMessage()
+			require.NoError(t, err)
+			require.Equal(t, execChannelStdin, msg[0])
+			if len(msg) == 1 { // EOF
+				break
+			}
+			require.NoError(
+				t,
+				conn.WriteMessage(
+					websocket.BinaryMessage,
+					append([]byte{execChannelStdout}, msg[1:]...),
+				),
+			)
+		}
+		require.NoError(
+			t,
+			conn.WriteMessage(
+				websocket.BinaryMessage,
+				append([]byte{execChannelStatus}, []byte(`{"exitCode":0}`)...),
+			),
+		)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/brigadecore/brigade/sdk/v3
 go 1.18
 
 require (
+	github.com/gorilla/websocket v1.4.2
 	github.com/hashicorp/go-retryablehttp v0.6.7
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.6.1
@@ -164,39 +164,46 @@ func (b *BaseClient) SubmitRequest(
 
 	if (req.SuccessCode == 0 && resp.StatusCode != http.StatusOK) ||
 		(req.SuccessCode != 0 && resp.StatusCode != req.SuccessCode) {
-		// HTTP Response code hints at what sort of error might be in the body
-		// of the response
-		var apiErr error
-		switch resp.StatusCode {
-		case http.StatusUnauthorized:
-			apiErr = &meta.ErrAuthentication{}
-		case http.StatusForbidden:
-			apiErr = &meta.ErrAuthorization{}
-		case http.StatusBadRequest:
-			apiErr = &meta.ErrBadRequest{}
-		case http.StatusNotFound:
-			apiErr = &meta.ErrNotFound{}
-		case http.StatusConflict:
-			apiErr = &meta.ErrConflict{}
-		case http.StatusNotImplemented:
-			apiErr = &meta.ErrNotSupported{}
-		case http.StatusInternalServerError:
-			apiErr = &meta.ErrInternalServer{}
-		default:
-			return resp, errors.Errorf("received %d from API server", resp.StatusCode)
-		}
-		bodyBytes, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return resp, errors.Wrap(err, "error reading error response body")
-		}
-		if err = json.Unmarshal(bodyBytes, apiErr); err != nil {
-			return resp, errors.Wrap(err, "error unmarshaling error response body")
-		}
-		return resp, apiErr
+		return resp, ErrorFromResponse(resp)
 	}
 	return resp, nil
 }
 
+// ErrorFromResponse returns an error appropriate to the status code and body of
+// the provided HTTP response, which is presumed to be an error response from
+// the API server.
+func ErrorFromResponse(resp *http.Response) error {
+	// HTTP Response code hints at what sort of error might be in the body
+	// of the response
+	var apiErr error
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		apiErr = &meta.ErrAuthentication{}
+	case http.StatusForbidden:
+		apiErr = &meta.ErrAuthorization{}
+	case http.StatusBadRequest:
+		apiErr = &meta.ErrBadRequest{}
+	case http.StatusNotFound:
+		apiErr = &meta.ErrNotFound{}
+	case http.StatusConflict:
+		apiErr = &meta.ErrConflict{}
+	case http.StatusNotImplemented:
+		apiErr = &meta.ErrNotSupported{}
+	case http.StatusInternalServerError:
+		apiErr = &meta.ErrInternalServer{}
+	default:
+		return errors.Errorf("received %d from API server", resp.StatusCode)
+	}
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "error reading error response body")
+	}
+	if err = json.Unmarshal(bodyBytes, apiErr); err != nil {
+		return errors.Wrap(err, "error unmarshaling error response body")
+	}
+	return apiErr
+}
+
 // defaultRetryPolicy was pulled from github.com/hashicorp/go-retryablehttp
 // in order to support modifying baseRetryPolicy (defined below) to our needs.
 // It represents the default callback for our baseClient's retryable http
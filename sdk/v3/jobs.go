@@ -93,8 +93,32 @@ type Job struct {
 	// the system. Clients must leave the value of this field set to nil when
 	// using the API to create a Job.
 	Created *time.Time `json:"created,omitempty"`
+	// Template optionally specifies the name of one of the Project's Job
+	// templates. When non-empty, Spec is treated as a set of overrides to be
+	// applied to the template. Only fields present in the Job's JSON
+	// representation override the template. Since fields left set to their zero
+	// values in Spec are omitted from that representation, they do not override
+	// the template. Once the Job is created, Spec reflects the fully resolved
+	// result.
+	Template string `json:"template,omitempty"`
+	// Matrix optionally specifies dimensions across which the Job should be
+	// fanned out. When non-nil, the Job is expanded, upon creation, into one Job
+	// per combination of dimension values and the Job itself is never created.
+	Matrix *JobMatrix `json:"matrix,omitempty"`
+	// Group is the name of the Job from whose matrix this Job was expanded. This
+	// is recorded by the system. Clients must leave the value of this field
+	// empty when using the API to create a Job.
+	Group string `json:"group,omitempty"`
+	// MatrixValues contains the combination of dimension values from which this
+	// Job was expanded. This is recorded by the system. Clients must leave the
+	// value of this field empty when using the API to create a Job.
+	MatrixValues map[string]string `json:"matrixValues,omitempty"`
 	// Spec is the technical blueprint for the Job.
 	Spec JobSpec `json:"spec"`
+	// CacheKey is a digest of the Job's spec and declared inputs. This is
+	// computed by the system for Jobs having a cache policy. Clients must leave
+	// the value of this field empty when using the API to create a Job.
+	CacheKey string `json:"cacheKey,omitempty"`
 	// Status contains details of the Job's current state.
 	Status *JobStatus `json:"status,omitempty"`
 }
@@ -132,6 +156,12 @@ type JobSpec struct {
 	// sidecar container), then logic within those containers must account for
 	// these constraints.
 	SidecarContainers map[string]JobContainerSpec `json:"sidecarContainers,omitempty"` // nolint: lll
+	// InitContainers specifies the details of OCI containers that must each run
+	// to completion, one at a time and in the order specified, before the
+	// PrimaryContainer and any SidecarContainers are started. These are useful
+	// for tasks such as fetching credentials or warming caches. If any of these
+	// fails, the Job fails.
+	InitContainers []JobInitContainerSpec `json:"initContainers,omitempty"`
 	// TimeoutDuration specifies the time duration that must elapse before a
 	// running Job should be considered to have timed out. This duration string
 	// is a sequence of decimal numbers, each with optional fraction and a unit
@@ -143,6 +173,12 @@ type JobSpec struct {
 	// non-default operating system (i.e. Windows) or specific hardware (e.g. a
 	// GPU.)
 	Host *JobHost `json:"host,omitempty"`
+	// ServiceAccount optionally specifies the name of one of the Project's
+	// JobServiceAccounts that the Job should run as instead of the Project's
+	// default. This permits individual Jobs to assume a workload identity of
+	// their own. Any ServiceAccount a Job requests must be explicitly permitted
+	// by the Worker's JobPolicies.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
 	// Fallible specifies whether the job is permitted to fail WITHOUT causing the
 	// worker process to fail. The API server does not use this field directly,
 	// but it is information that may be valuable to gateways that report job
@@ -152,6 +188,53 @@ type JobSpec struct {
 	// schema-based validation will reject the unknown field) as long as it's not
 	// set to true.
 	Fallible bool `json:"fallible,omitempty"`
+	// Cache optionally specifies a policy that permits the results of a
+	// previously succeeded, equivalent Job from any of the Project's Events to
+	// be reused in lieu of executing this Job. Jobs that reference any Project
+	// Secret never reuse results, since changes to the secret's value cannot be
+	// detected.
+	Cache *JobCachePolicy `json:"cache,omitempty"`
+}
+
+// JobCachePolicy represents an opt-in policy for reusing the results of a
+// previously succeeded, equivalent Job.
+type JobCachePolicy struct {
+	// Inputs enumerates key/value pairs that, together with the JobSpec itself,
+	// determine the Job's cache key. These should capture anything the Job's
+	// results depend upon that is not apparent from the JobSpec, for instance
+	// the digest of an upstream Job's outputs.
+	Inputs map[string]string `json:"inputs,omitempty"`
+}
+
+// JobMatrix describes how a single Job should be fanned out into many Jobs--
+// one for each combination of the values of its dimensions. Expanded Jobs are
+// named by appending the values of their combination, in order of dimension
+// name, to the original Job's name.
+type JobMatrix struct {
+	// Dimensions maps the name of each dimension to that dimension's values.
+	// Within each expanded Job's image, command, arguments, and environment
+	// variable values, any occurrence of the placeholder ${matrix.<dimension>}
+	// is substituted with the corresponding value.
+	Dimensions map[string][]string `json:"dimensions"`
+}
+
+// JobGroup summarizes the status of all Jobs expanded from a single Job
+// matrix.
+type JobGroup struct {
+	// Name is the name of the Job from whose matrix the group's Jobs were
+	// expanded.
+	Name string `json:"name"`
+	// Jobs enumerates the names of the group's Jobs.
+	Jobs []string `json:"jobs"`
+	// PhaseCounts maps JobPhases to the number of the group's Jobs that are in
+	// each phase.
+	PhaseCounts map[JobPhase]int `json:"phaseCounts"`
+	// Phase is the aggregate phase of the group's Jobs. While any of the group's
+	// Jobs is not yet in a terminal phase, this is PENDING if none of them have
+	// left the PENDING phase or RUNNING otherwise. Once all of the group's Jobs
+	// are in a terminal phase, this is SUCCEEDED if all of them succeeded or the
+	// phase of the first Job that did not succeed otherwise.
+	Phase JobPhase `json:"phase"`
 }
 
 // JobContainerSpec amends the ContainerSpec type with additional Job-specific
@@ -176,7 +259,22 @@ type JobContainerSpec struct {
 	// must run its own Docker daemon. Note this field REQUESTS privileged status
 	// for the container, but that may be disallowed by Project-level
 	// configuration.
-	Privileged bool `json:"privileged"`
+	Privileged bool `json:"privileged,omitempty"`
+	// SecurityContext specifies security-related settings for the OCI container.
+	// These may be required by Project-level configuration.
+	SecurityContext *ContainerSecurityContext `json:"securityContext,omitempty"`
+	// SecretEnvironment is a map of environment variable names to the keys of
+	// Project Secrets whose values should be assigned to them. Unlike values in
+	// the Environment field, these are referenced directly from the substrate's
+	// Secret storage and are never copied. Only the Project Secrets named here
+	// (or in SecretMounts) are made available to the Job.
+	SecretEnvironment map[string]string `json:"secretEnvironment,omitempty"`
+	// SecretMounts specifies Project Secrets whose values should be mounted as
+	// files into the OCI container's file system.
+	SecretMounts []SecretMount `json:"secretMounts,omitempty"`
+	// CacheVolumeMounts specifies which of the Project's cache volumes should be
+	// mounted into the OCI container's file system and where.
+	CacheVolumeMounts []CacheVolumeMount `json:"cacheVolumeMounts,omitempty"`
 	// UseHostDockerSocket indicates whether the OCI container should mount the
 	// host's Docker socket into its own file system. This is commonly used to
 	// effect "Docker-out-of-Docker" ("DooD") scenarios wherein one of a Job's OCI
@@ -195,6 +293,72 @@ type JobContainerSpec struct {
 	// UseHostDockerSocket bool `json:"useHostDockerSocket"`
 }
 
+// JobInitContainerSpec amends the JobContainerSpec type with a name, since,
+// unlike sidecar containers, init containers are specified as an ordered list
+// instead of being keyed by name.
+type JobInitContainerSpec struct {
+	// Name is the name of the init container. It must be unique among all of a
+	// Job's containers.
+	Name string `json:"name"`
+	// JobContainerSpec encapsulates the specification of the init container.
+	JobContainerSpec `json:",inline"`
+}
+
+// SeccompProfile represents a seccomp profile for an OCI container.
+type SeccompProfile string
+
+const (
+	// SeccompProfileRuntimeDefault represents the container runtime's default
+	// seccomp profile.
+	SeccompProfileRuntimeDefault SeccompProfile = "RuntimeDefault"
+	// SeccompProfileUnconfined represents the absence of any seccomp profile.
+	SeccompProfileUnconfined SeccompProfile = "Unconfined"
+)
+
+// ContainerSecurityContext represents security-related settings for an OCI
+// container.
+type ContainerSecurityContext struct {
+	// RunAsUser specifies the UID with which the OCI container's process should
+	// be run. When nil, the OCI image's default is used.
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// RunAsGroup specifies the GID with which the OCI container's process should
+	// be run. When nil, the OCI image's default is used.
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// RunAsNonRoot indicates that the OCI container's process must not run as
+	// root. If it would, the OCI container will fail to start.
+	RunAsNonRoot bool `json:"runAsNonRoot,omitempty"`
+	// ReadOnlyRootFilesystem indicates that the OCI container's root file system
+	// should be mounted read-only.
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+	// DropCapabilities enumerates Linux capabilities (e.g. "NET_RAW") that
+	// should be dropped from the OCI container's process. The value "ALL" drops
+	// all capabilities.
+	DropCapabilities []string `json:"dropCapabilities,omitempty"`
+	// SeccompProfile specifies the seccomp profile for the OCI container. When
+	// empty, the substrate's default is used.
+	SeccompProfile SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// SecretMount represents a request to mount the value of a Project Secret as a
+// file in an OCI container's file system.
+type SecretMount struct {
+	// Key is the key of a Project Secret.
+	Key string `json:"key"`
+	// MountPath specifies the path in the OCI container's file system of the
+	// file whose content will be the Project Secret's value.
+	MountPath string `json:"mountPath"`
+}
+
+// CacheVolumeMount represents a request to mount one of a Project's cache
+// volumes into an OCI container's file system.
+type CacheVolumeMount struct {
+	// Name is the name of a cache volume defined by the Project.
+	Name string `json:"name"`
+	// MountPath specifies the path in the OCI container's file system where the
+	// cache volume should be mounted.
+	MountPath string `json:"mountPath"`
+}
+
 // JobHost represents criteria for selecting a suitable host (substrate node)
 // for a Job.
 type JobHost struct {
@@ -206,6 +370,15 @@ type JobHost struct {
 	// host a Job. This provides an opaque mechanism for communicating Job needs
 	// such as specific hardware like an SSD or GPU.
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Cluster optionally routes a Job to one of the named Kubernetes clusters
+	// that an administrator has registered with the system, instead of the
+	// cluster that hosts the Job's Worker. A Job so routed cannot use the
+	// Worker's shared workspace.
+	Cluster string `json:"cluster,omitempty"`
+	// SchedulingConstraints encapsulates additional criteria for selecting a
+	// suitable substrate node for a Job. Any of these that a Job requests must be
+	// explicitly permitted by the Worker's JobPolicies.
+	SchedulingConstraints `json:",inline"`
 }
 
 // JobStatus represents the status of a Job.
@@ -217,6 +390,69 @@ type JobStatus struct {
 	Ended *time.Time `json:"ended,omitempty"`
 	// Phase indicates where the Job is in its lifecycle.
 	Phase JobPhase `json:"phase,omitempty"`
+	// Outputs contains small key/value pairs published by the Job's primary
+	// container upon completion. These are readable by the Worker and may be
+	// relayed by the Worker to any subsequent Jobs.
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Cached indicates that the Job was never executed because the results of
+	// an equivalent Job were reused instead.
+	Cached bool `json:"cached,omitempty"`
+	// InitContainers contains details of the current state of each of the Job's
+	// init containers, in the order they are executed.
+	InitContainers []JobInitContainerStatus `json:"initContainers,omitempty"`
+	// Progress contains the most recent progress reported by the Job itself,
+	// if any.
+	Progress *JobProgress `json:"progress,omitempty"`
+	// Reason optionally explains, in human-readable terms, why the Job is in its
+	// current phase; for instance, why it was aborted.
+	Reason string `json:"reason,omitempty"`
+}
+
+// JobProgress represents progress toward completion, as reported by a running
+// Job.
+type JobProgress struct {
+	// Percent indicates the Job's progress toward completion as a percentage
+	// from 0 to 100.
+	Percent int `json:"percent"`
+	// Message is an optional, short, human-readable description of the Job's
+	// progress.
+	Message string `json:"message,omitempty"`
+	// Updated indicates the time at which progress was last reported. This is
+	// recorded by the system. Clients must leave the value of this field set to
+	// nil when using the API to report progress.
+	Updated *time.Time `json:"updated,omitempty"`
+}
+
+// MarshalJSON amends JobProgress instances with type metadata so that clients
+// do not need to be concerned with the tedium of doing so.
+func (j JobProgress) MarshalJSON() ([]byte, error) {
+	type Alias JobProgress
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "JobProgress",
+			},
+			Alias: (Alias)(j),
+		},
+	)
+}
+
+// JobInitContainerStatus represents the current state of one of a Job's init
+// containers.
+type JobInitContainerStatus struct {
+	// Name is the name of the init container.
+	Name string `json:"name"`
+	// Started indicates the time the init container began execution.
+	Started *time.Time `json:"started,omitempty"`
+	// Ended indicates the time the init container concluded execution. It will
+	// be nil for an init container that is not done executing.
+	Ended *time.Time `json:"ended,omitempty"`
+	// Phase indicates where the init container is in its lifecycle.
+	Phase JobPhase `json:"phase,omitempty"`
 }
 
 // MarshalJSON amends JobStatus instances with type metadata so that clients do
@@ -265,11 +501,22 @@ type JobStatusWatchOptions struct{}
 // signatures.
 type JobStatusUpdateOptions struct{}
 
+// JobProgressUpdateOptions represents useful, optional settings for reporting a
+// Job's progress. It currently has no fields, but exists to preserve the
+// possibility of future expansion without having to change client function
+// signatures.
+type JobProgressUpdateOptions struct{}
+
 // JobCleanupOptions represents useful, optional settings for cleaning up after
 // a Job. It currently has no fields, but exists to preserve the possibility of
 // future expansion without having to change client function signatures.
 type JobCleanupOptions struct{}
 
+// JobCancelOptions represents useful, optional settings for canceling a Job.
+// It currently has no fields, but exists to preserve the possibility of future
+// expansion without having to change client function signatures.
+type JobCancelOptions struct{}
+
 // JobTimeoutOptions represents useful, optional settings for timing out a Job.
 // It currently has no fields, but exists to preserve the possibility of future
 // expansion without having to change client function signatures.
@@ -319,12 +566,31 @@ type JobsClient interface {
 		status JobStatus,
 		opts *JobStatusUpdateOptions,
 	) error
+	// UpdateProgress, given an Event identifier and Job name, reports the
+	// progress of that Job. This is intended to be used by the Job itself, with
+	// the Worker's token, while it is running.
+	UpdateProgress(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		progress JobProgress,
+		opts *JobProgressUpdateOptions,
+	) error
 	Cleanup(
 		ctx context.Context,
 		eventID,
 		jobName string,
 		opts *JobCleanupOptions,
 	) error
+	// Cancel, given an Event identifier and Job name, cancels the specified Job
+	// if it is still pending or aborts it if it is already running. Other Jobs
+	// belonging to the same Event are unaffected.
+	Cancel(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		opts *JobCancelOptions,
+	) error
 	// Timeout, given an Event identifier and Job name, executes timeout logic
 	// for a Job that has exceeded its timeout limit.
 	Timeout(
@@ -465,6 +731,28 @@ func (j *jobsClient) UpdateStatus(
 	)
 }
 
+func (j *jobsClient) UpdateProgress(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	progress JobProgress,
+	_ *JobProgressUpdateOptions,
+) error {
+	return j.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method: http.MethodPut,
+			Path: fmt.Sprintf(
+				"v2/events/%s/worker/jobs/%s/progress",
+				eventID,
+				jobName,
+			),
+			ReqBodyObj:  progress,
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (j *jobsClient) Cleanup(
 	ctx context.Context,
 	eventID,
@@ -485,6 +773,26 @@ func (j *jobsClient) Cleanup(
 	)
 }
 
+func (j *jobsClient) Cancel(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	_ *JobCancelOptions,
+) error {
+	return j.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method: http.MethodPut,
+			Path: fmt.Sprintf(
+				"v2/events/%s/worker/jobs/%s/cancel",
+				eventID,
+				jobName,
+			),
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (j *jobsClient) Timeout(
 	ctx context.Context,
 	eventID,
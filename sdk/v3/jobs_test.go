@@ -23,6 +23,10 @@ func TestJobStatusMarshalJSON(t *testing.T) {
 	metaTesting.RequireAPIVersionAndType(t, JobStatus{}, "JobStatus")
 }
 
+func TestJobProgressMarshalJSON(t *testing.T) {
+	metaTesting.RequireAPIVersionAndType(t, JobProgress{}, "JobProgress")
+}
+
 func TestJobsClientCreate(t *testing.T) {
 	const testEventID = "12345"
 	const testJobName = "Italian"
@@ -225,6 +229,50 @@ func TestJobClientUpdateStatus(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestJobClientUpdateProgress(t *testing.T) {
+	const testEventID = "12345"
+	const testJobName = "Italian"
+	testJobProgress := JobProgress{
+		Percent: 50,
+		Message: "halfway there",
+	}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				require.Equal(t, http.MethodPut, r.Method)
+				require.Equal(
+					t,
+					fmt.Sprintf(
+						"/v2/events/%s/worker/jobs/%s/progress",
+						testEventID,
+						testJobName,
+					),
+					r.URL.Path,
+				)
+				bodyBytes, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				jobProgress := JobProgress{}
+				err = json.Unmarshal(bodyBytes, &jobProgress)
+				require.NoError(t, err)
+				require.Equal(t, testJobProgress, jobProgress)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
+	defer server.Close()
+	client := NewJobsClient(server.URL, rmTesting.TestAPIToken, nil)
+	err := client.UpdateProgress(
+		context.Background(),
+		testEventID,
+		testJobName,
+		testJobProgress,
+		nil,
+	)
+	require.NoError(t, err)
+}
+
 func TestJobClientCleanup(t *testing.T) {
 	const testEventID = "12345"
 	const testJobName = "Italian"
@@ -258,6 +306,39 @@ func TestJobClientCleanup(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestJobClientCancel(t *testing.T) {
+	const testEventID = "12345"
+	const testJobName = "Italian"
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				require.Equal(t, http.MethodPut, r.Method)
+				require.Equal(
+					t,
+					fmt.Sprintf(
+						"/v2/events/%s/worker/jobs/%s/cancel",
+						testEventID,
+						testJobName,
+					),
+					r.URL.Path,
+				)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
+	defer server.Close()
+	client := NewJobsClient(server.URL, rmTesting.TestAPIToken, nil)
+	err := client.Cancel(
+		context.Background(),
+		testEventID,
+		testJobName,
+		nil,
+	)
+	require.NoError(t, err)
+}
+
 func TestJobClientTimeout(t *testing.T) {
 	const testEventID = "12345"
 	const testJobName = "Italian"
@@ -100,8 +100,55 @@ type ProjectSpec struct {
 	EventSubscriptions []EventSubscription `json:"eventSubscriptions,omitempty"`
 	// WorkerTemplate is a prototypical WorkerSpec.
 	WorkerTemplate WorkerSpec `json:"workerTemplate"`
+	// CacheVolumes enumerates named, persistent volumes that are provisioned
+	// once for the Project and may be mounted by any of its Jobs. Unlike the
+	// shared workspace, whose lifetime is bound to a single Event, the contents
+	// of a cache volume survive from one Event to the next. This makes them
+	// useful for things like dependency caches.
+	CacheVolumes []CacheVolume `json:"cacheVolumes,omitempty"`
+	// JobTemplates is a map of prototypical JobSpecs indexed by name. A Job
+	// may reference one of these by name and supply only those details that
+	// differ from the template.
+	JobTemplates map[string]JobSpec `json:"jobTemplates,omitempty"`
 }
 
+// CacheVolume represents a named, persistent volume that is shared across all
+// of a Project's Events.
+type CacheVolume struct {
+	// Name is a unique-within-the-Project identifier for the cache volume. Jobs
+	// reference cache volumes by this name.
+	Name string `json:"name"`
+	// Size specifies the size of the volume to be provisioned. The value can be
+	// expressed in bytes (as a plain integer) or as a fixed-point integer using
+	// one of these suffixes: E, P, T, G, M, K. Power-of-two equivalents may also
+	// be used: Ei, Pi, Ti, Gi, Mi, Ki. When empty, a substrate-specific default
+	// is used.
+	Size string `json:"size,omitempty"`
+	// StorageClass optionally specifies a substrate-specific class of storage
+	// for the volume. When empty, the substrate's default is used.
+	StorageClass string `json:"storageClass,omitempty"`
+	// AccessMode specifies how concurrent use of the cache volume by multiple
+	// Jobs is to be arbitrated. When empty, Brigade assumes
+	// CacheVolumeAccessModeExclusive.
+	AccessMode CacheVolumeAccessMode `json:"accessMode,omitempty"`
+}
+
+// CacheVolumeAccessMode represents how concurrent use of a cache volume is
+// arbitrated.
+type CacheVolumeAccessMode string
+
+const (
+	// CacheVolumeAccessModeExclusive represents a cache volume that may be
+	// mounted by only a single Job at a time. Any other Job that requires the
+	// same cache volume will wait until the volume is released. Projects with
+	// exclusive cache volumes are rejected by substrates that cannot guarantee
+	// exclusive access.
+	CacheVolumeAccessModeExclusive CacheVolumeAccessMode = "EXCLUSIVE"
+	// CacheVolumeAccessModeShared represents a cache volume that may be mounted
+	// by many Jobs at once.
+	CacheVolumeAccessModeShared CacheVolumeAccessMode = "SHARED"
+)
+
 // EventSubscription defines a set of Events of interest. ProjectSpecs utilize
 // these in defining the Events that should trigger the execution of a new
 // Worker. An Event matches a subscription if it meets ALL of the specified
@@ -147,11 +194,133 @@ type EventSubscription struct {
 
 // KubernetesDetails represents Kubernetes-specific configuration.
 type KubernetesDetails struct {
-	// Namespace is the dedicated Kubernetes namespace for the Project. This is
-	// NOT specified by clients when creating a new Project. The namespace is
-	// created by / assigned by the system. This detail is a necessity to prevent
-	// clients from naming existing namespaces in an attempt to hijack them.
+	// Namespace is the dedicated Kubernetes namespace for the Project. When
+	// this is left unspecified by clients creating a new Project, a new
+	// namespace is created by / assigned by the system. Clients MAY specify an
+	// existing namespace instead, but only if it is one that an administrator
+	// has configured the system to permit Projects to claim. The namespace
+	// cannot be changed once the Project has been created.
 	Namespace string `json:"namespace,omitempty"`
+	// Cluster optionally pins the Project to one of the named Kubernetes
+	// clusters that an administrator has registered with the system. When
+	// unspecified, the default cluster is used. The cluster cannot be changed
+	// once the Project has been created.
+	Cluster string `json:"cluster,omitempty"`
+	// WorkspaceStorageClass optionally overrides the system's default
+	// Kubernetes StorageClass for the shared workspaces of the Project's
+	// Workers.
+	WorkspaceStorageClass string `json:"workspaceStorageClass,omitempty"`
+	// NodeSelector optionally specifies node labels, in addition to the
+	// system's default node selector, for all of the Project's Worker and Job
+	// pods. The system may restrict which labels are permitted.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations optionally specifies node taints, in addition to the system's
+	// default toleration, that all of the Project's Worker and Job pods
+	// tolerate. The system may restrict which taints are permitted.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+	// ResourceQuota optionally constrains the aggregate resources that may be
+	// consumed within the Project's namespace. Any bounds set by an
+	// administrator apply regardless.
+	ResourceQuota *ResourceQuota `json:"resourceQuota,omitempty"`
+	// LimitRange optionally specifies default and maximum compute resources for
+	// each container of the Project's Worker and Job pods.
+	LimitRange *LimitRange `json:"limitRange,omitempty"`
+	// NetworkPolicy optionally constrains network traffic to and from the
+	// Project's Worker and Job pods. When unspecified, the system's default
+	// applies. A NetworkPolicy less restrictive than the system's default is
+	// not permitted.
+	NetworkPolicy NetworkPolicyMode `json:"networkPolicy,omitempty"`
+	// WorkerPodOverlay optionally specifies a patch to be applied to each of the
+	// Project's Worker pods after the system has built it. The patch may not
+	// modify any pod field that an administrator has denied.
+	WorkerPodOverlay *PodOverlay `json:"workerPodOverlay,omitempty"`
+	// JobPodOverlay optionally specifies a patch to be applied to each of the
+	// Project's Job pods after the system has built it. The patch may not
+	// modify any pod field that an administrator has denied.
+	JobPodOverlay *PodOverlay `json:"jobPodOverlay,omitempty"`
+	// JobServiceAccounts optionally declares Kubernetes ServiceAccounts, in
+	// addition to the default "jobs" ServiceAccount, to be provisioned in the
+	// Project's namespace for use by the Project's Jobs. Annotations on these
+	// can associate them with cloud provider workload identities.
+	JobServiceAccounts []JobServiceAccount `json:"jobServiceAccounts,omitempty"`
+	// DefaultJobServiceAccount optionally names one of the JobServiceAccounts
+	// that the Project's Jobs run as unless they request another. When
+	// unspecified, Jobs run as the "jobs" ServiceAccount.
+	DefaultJobServiceAccount string `json:"defaultJobServiceAccount,omitempty"`
+}
+
+// JobServiceAccount represents a Kubernetes ServiceAccount that is provisioned
+// in a Project's namespace for use by the Project's Jobs.
+type JobServiceAccount struct {
+	// Name is the name of the ServiceAccount. The names "jobs" and "workers" are
+	// reserved.
+	Name string `json:"name"`
+	// Annotations are applied to the ServiceAccount. These are commonly used to
+	// associate the ServiceAccount with a cloud provider workload identity,
+	// e.g. using the "eks.amazonaws.com/role-arn" or
+	// "iam.gke.io/gcp-service-account" annotations.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ResourceQuota represents constraints on the aggregate resources that may be
+// consumed within a Project's namespace.
+type ResourceQuota struct {
+	// Hard maps the names of resources (e.g. "requests.cpu", "limits.memory",
+	// or "pods") to the maximum quantity of each that may be consumed.
+	Hard map[string]string `json:"hard,omitempty"`
+}
+
+// LimitRange represents default and maximum compute resources for each
+// container of a Project's Worker and Job pods. Each field maps the names of
+// resources (e.g. "cpu" or "memory") to quantities.
+type LimitRange struct {
+	// DefaultRequests are the resources requested by any container that does not
+	// explicitly request them.
+	DefaultRequests map[string]string `json:"defaultRequests,omitempty"`
+	// DefaultLimits are the resource limits of any container that does not
+	// explicitly specify them.
+	DefaultLimits map[string]string `json:"defaultLimits,omitempty"`
+	// Max are the greatest resource limits any container may specify.
+	Max map[string]string `json:"max,omitempty"`
+}
+
+// NetworkPolicyMode represents a canned policy governing network traffic to
+// and from a Project's Worker and Job pods.
+type NetworkPolicyMode string
+
+const (
+	// NetworkPolicyModeAllowAll represents a policy that places no constraints
+	// on network traffic.
+	NetworkPolicyModeAllowAll NetworkPolicyMode = "AllowAll"
+	// NetworkPolicyModeDenyNamespaceToNamespace represents a policy that permits
+	// inbound traffic to a Project's pods only from other pods in the same
+	// namespace. Outbound traffic is not constrained.
+	NetworkPolicyModeDenyNamespaceToNamespace NetworkPolicyMode = "DenyNamespaceToNamespace" // nolint: lll
+	// NetworkPolicyModeEgressOnly represents a policy that denies all inbound
+	// traffic to a Project's pods. Outbound traffic is not constrained.
+	NetworkPolicyModeEgressOnly NetworkPolicyMode = "EgressOnly"
+)
+
+// PodOverlayType represents the format of a PodOverlay's patch.
+type PodOverlayType string
+
+const (
+	// PodOverlayTypeStrategicMerge represents a Kubernetes strategic merge
+	// patch.
+	PodOverlayTypeStrategicMerge PodOverlayType = "StrategicMerge"
+	// PodOverlayTypeJSONPatch represents an RFC 6902 JSON patch.
+	PodOverlayTypeJSONPatch PodOverlayType = "JSONPatch"
+)
+
+// PodOverlay represents a patch to be applied to a Worker or Job pod after the
+// system has built it. This accommodates pod fields that Brigade does not
+// otherwise model, such as DNS configuration, host aliases, or annotations
+// used by service meshes.
+type PodOverlay struct {
+	// Type specifies the format of the Patch.
+	Type PodOverlayType `json:"type"`
+	// Patch is the patch itself, expressed in either JSON or YAML.
+	Patch string `json:"patch"`
 }
 
 // ProjectCreateOptions represents useful, optional settings for creating a new
@@ -177,6 +346,12 @@ type ProjectUpdateOptions struct {
 // of future expansion without having to change client function signatures.
 type ProjectDeleteOptions struct{}
 
+// CacheVolumeClearOptions represents useful, optional settings for clearing a
+// Project's cache volume. It currently has no fields, but exists to preserve
+// the possibility of future expansion without having to change client
+// function signatures.
+type CacheVolumeClearOptions struct{}
+
 // ProjectsClient is the specialized client for managing Projects with the
 // Brigade API.
 type ProjectsClient interface {
@@ -220,6 +395,14 @@ type ProjectsClient interface {
 	) (Project, error)
 	// Delete deletes a single Project specified by its identifier.
 	Delete(context.Context, string, *ProjectDeleteOptions) error
+	// ClearCacheVolume, given a Project identifier and the name of one of that
+	// Project's cache volumes, discards the contents of that cache volume.
+	ClearCacheVolume(
+		ctx context.Context,
+		projectID string,
+		name string,
+		opts *CacheVolumeClearOptions,
+	) error
 
 	// Authz returns a specialized client for managing project-level authorization
 	// concerns.
@@ -384,6 +567,26 @@ func (p *projectsClient) Delete(
 	)
 }
 
+func (p *projectsClient) ClearCacheVolume(
+	ctx context.Context,
+	projectID string,
+	name string,
+	_ *CacheVolumeClearOptions,
+) error {
+	return p.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method: http.MethodDelete,
+			Path: fmt.Sprintf(
+				"v2/projects/%s/cache-volumes/%s",
+				projectID,
+				name,
+			),
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (p *projectsClient) Authz() ProjectAuthzClient {
 	return p.authzClient
 }
@@ -262,3 +262,34 @@ func TestProjectsClientDelete(t *testing.T) {
 	err := client.Delete(context.Background(), testProjectID, nil)
 	require.NoError(t, err)
 }
+
+func TestProjectsClientClearCacheVolume(t *testing.T) {
+	const testProjectID = "bluebook"
+	const testCacheVolumeName = "npm"
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodDelete, r.Method)
+				require.Equal(
+					t,
+					fmt.Sprintf(
+						"/v2/projects/%s/cache-volumes/%s",
+						testProjectID,
+						testCacheVolumeName,
+					),
+					r.URL.Path,
+				)
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
+	defer server.Close()
+	client := NewProjectsClient(server.URL, rmTesting.TestAPIToken, nil)
+	err := client.ClearCacheVolume(
+		context.Background(),
+		testProjectID,
+		testCacheVolumeName,
+		nil,
+	)
+	require.NoError(t, err)
+}
@@ -0,0 +1,115 @@
+package sdk
+
+// CPUArch represents a CPU architecture.
+type CPUArch string
+
+const (
+	// CPUArchAMD64 represents the amd64 (x86-64) CPU architecture.
+	CPUArchAMD64 CPUArch = "amd64"
+	// CPUArchARM64 represents the arm64 (AArch64) CPU architecture.
+	CPUArchARM64 CPUArch = "arm64"
+)
+
+// TolerationOperator represents the relationship between a Toleration's key
+// and value.
+type TolerationOperator string
+
+const (
+	// TolerationOperatorExists represents a Toleration that tolerates any taint
+	// having a matching key, regardless of the taint's value.
+	TolerationOperatorExists TolerationOperator = "Exists"
+	// TolerationOperatorEqual represents a Toleration that tolerates only taints
+	// having a matching key AND value.
+	TolerationOperatorEqual TolerationOperator = "Equal"
+)
+
+// AffinityOperator represents the relationship between a label key and a set
+// of values in a NodeAffinityTerm.
+type AffinityOperator string
+
+const (
+	// AffinityOperatorIn represents a requirement that a label's value be one
+	// of a set of values.
+	AffinityOperatorIn AffinityOperator = "In"
+	// AffinityOperatorNotIn represents a requirement that a label's value NOT be
+	// any of a set of values.
+	AffinityOperatorNotIn AffinityOperator = "NotIn"
+	// AffinityOperatorExists represents a requirement that a label be present,
+	// regardless of its value.
+	AffinityOperatorExists AffinityOperator = "Exists"
+	// AffinityOperatorDoesNotExist represents a requirement that a label NOT be
+	// present.
+	AffinityOperatorDoesNotExist AffinityOperator = "DoesNotExist"
+)
+
+// SchedulingConstraints represents criteria, beyond OS family and node
+// labels, that influence which substrate node(s) may host a Worker or Job.
+type SchedulingConstraints struct {
+	// Arch specifies which CPU architecture is required on a substrate node to
+	// host a Worker or Job. When empty, no particular architecture is required.
+	Arch CPUArch `json:"arch,omitempty"`
+	// Tolerations enumerates substrate node taints that a Worker or Job
+	// tolerates. This permits Workers or Jobs to be hosted on dedicated or
+	// otherwise special-purpose nodes, for instance, spot instances.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+	// NodeAffinity enumerates terms that constrain or express preferences for
+	// which substrate nodes host a Worker or Job based on node labels.
+	NodeAffinity []NodeAffinityTerm `json:"nodeAffinity,omitempty"`
+	// PodAffinity enumerates terms that constrain or express preferences for
+	// which substrate nodes host a Worker or Job based on other workloads
+	// already hosted by those nodes.
+	PodAffinity []PodAffinityTerm `json:"podAffinity,omitempty"`
+	// PriorityClass specifies the substrate-specific priority class of a Worker
+	// or Job.
+	PriorityClass string `json:"priorityClass,omitempty"`
+}
+
+// Toleration represents a substrate node taint that a Worker or Job tolerates.
+type Toleration struct {
+	// Key is the key of the taint that is tolerated.
+	Key string `json:"key"`
+	// Operator specifies the relationship between Key and Value. When empty,
+	// Brigade assumes TolerationOperatorEqual.
+	Operator TolerationOperator `json:"operator,omitempty"`
+	// Value is the value of the taint that is tolerated. This is ignored if
+	// Operator is TolerationOperatorExists.
+	Value string `json:"value,omitempty"`
+	// Effect optionally limits the Toleration to taints having the specified
+	// effect. When empty, taints having any effect are tolerated.
+	Effect string `json:"effect,omitempty"`
+}
+
+// NodeAffinityTerm represents a requirement or preference for substrate
+// nodes having labels matching certain criteria.
+type NodeAffinityTerm struct {
+	// Key is the key of a node label.
+	Key string `json:"key"`
+	// Operator specifies the relationship between the label and Values.
+	Operator AffinityOperator `json:"operator"`
+	// Values is a set of label values. This must be empty if Operator is
+	// AffinityOperatorExists or AffinityOperatorDoesNotExist.
+	Values []string `json:"values,omitempty"`
+	// Weight, when non-zero, indicates that this term expresses a preference
+	// rather than a requirement. When multiple preferences are expressed, nodes
+	// satisfying preferences with greater weights are favored. Valid values are
+	// 0 through 100.
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// PodAffinityTerm represents a requirement or preference for Workers or Jobs
+// to be co-located with (or separated from) other workloads.
+type PodAffinityTerm struct {
+	// MatchLabels selects the workloads with which a Worker or Job should be
+	// co-located (or from which it should be separated).
+	MatchLabels map[string]string `json:"matchLabels"`
+	// TopologyKey is the key of a node label. Nodes having the same value for
+	// this label are considered co-located.
+	TopologyKey string `json:"topologyKey"`
+	// Anti indicates that the term expresses anti-affinity; i.e. the Worker or
+	// Job should be separated from, rather than co-located with, the selected
+	// workloads.
+	Anti bool `json:"anti,omitempty"`
+	// Weight, when non-zero, indicates that this term expresses a preference
+	// rather than a requirement. Valid values are 0 through 100.
+	Weight int32 `json:"weight,omitempty"`
+}
@@ -22,6 +22,88 @@ type SubstrateJobCount struct {
 	Count int `json:"count"`
 }
 
+// SubstrateInventory summarizes the Brigade-managed workloads and storage that
+// currently exist on the substrate, as well as the substrate's capacity to
+// execute them. It is intended to help operators understand why Workers and
+// Jobs may be queued.
+type SubstrateInventory struct {
+	// Projects summarizes the Worker and Job pods and the workspaces belonging to
+	// each Project that currently has any on the substrate.
+	Projects []ProjectSubstrateInventory `json:"projects"`
+	// NodePools summarizes the capacity of each pool of nodes in the substrate.
+	// This will be empty if the substrate has no notion of nodes.
+	NodePools []NodePoolCapacity `json:"nodePools"`
+}
+
+// ProjectSubstrateInventory summarizes the Worker and Job pods and the
+// workspaces belonging to a single Project on a single cluster.
+type ProjectSubstrateInventory struct {
+	// ProjectID is the identifier of the Project.
+	ProjectID string `json:"projectID"`
+	// Cluster is the name of the cluster being summarized. It is empty for the
+	// default cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Workers summarizes the Project's Worker pods.
+	Workers PodCounts `json:"workers"`
+	// Jobs summarizes the Project's Job pods.
+	Jobs PodCounts `json:"jobs"`
+	// Workspaces summarizes the Project's shared workspaces.
+	Workspaces WorkspaceUsage `json:"workspaces"`
+}
+
+// PodCounts breaks down a number of pods by their status.
+type PodCounts struct {
+	// Pending is the number of pods that have been accepted by the substrate but
+	// are not yet running. Such pods may be awaiting node capacity.
+	Pending int `json:"pending"`
+	// Running is the number of pods that are running.
+	Running int `json:"running"`
+	// Terminating is the number of pods that are in the process of being
+	// deleted.
+	Terminating int `json:"terminating"`
+}
+
+// WorkspaceUsage summarizes a number of shared workspaces.
+type WorkspaceUsage struct {
+	// Count is the number of workspaces.
+	Count int `json:"count"`
+	// Storage is the total storage requested by the workspaces, expressed as a
+	// Kubernetes resource quantity, e.g. "10Gi".
+	Storage string `json:"storage,omitempty"`
+}
+
+// NodePoolCapacity summarizes the capacity of a pool of nodes.
+type NodePoolCapacity struct {
+	// Cluster is the name of the cluster the pool belongs to. It is empty for
+	// the default cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Name is the name of the pool. It is empty for nodes that do not belong to
+	// any identifiable pool.
+	Name string `json:"name,omitempty"`
+	// Nodes is the number of nodes in the pool.
+	Nodes int `json:"nodes"`
+	// ReadyNodes is the number of nodes in the pool that are ready and
+	// schedulable.
+	ReadyNodes int `json:"readyNodes"`
+	// Allocatable is the CPU, memory, and pods allocatable across all ready and
+	// schedulable nodes in the pool.
+	Allocatable NodePoolResources `json:"allocatable"`
+	// Requested is the CPU and memory requested by, and the number of, all
+	// non-terminated pods bound to ready and schedulable nodes in the pool,
+	// including pods that are not managed by Brigade.
+	Requested NodePoolResources `json:"requested"`
+}
+
+// NodePoolResources represents quantities of resources within a pool of nodes.
+type NodePoolResources struct {
+	// CPU is expressed as a Kubernetes resource quantity, e.g. "3500m".
+	CPU string `json:"cpu"`
+	// Memory is expressed as a Kubernetes resource quantity, e.g. "16Gi".
+	Memory string `json:"memory"`
+	// Pods is a number of pods.
+	Pods int `json:"pods"`
+}
+
 // RunningWorkerCountOptions represents useful, optional criteria for the
 // retrieval of a count of running Workers. It currently has no fields, but
 // exists to preserve the possibility of future expansion without having to
@@ -34,6 +116,12 @@ type RunningWorkerCountOptions struct{}
 // function signatures.
 type RunningJobCountOptions struct{}
 
+// SubstrateInventoryGetOptions represents useful, optional criteria for the
+// retrieval of a SubstrateInventory. It currently has no fields, but exists to
+// preserve the possibility of future expansion without having to change client
+// function signatures.
+type SubstrateInventoryGetOptions struct{}
+
 // SubstrateClient is the specialized client for monitoring the substrate.
 type SubstrateClient interface {
 	// CountRunningWorkers returns a count of Workers currently executing on the
@@ -48,6 +136,13 @@ type SubstrateClient interface {
 		context.Context,
 		*RunningJobCountOptions,
 	) (SubstrateJobCount, error)
+	// GetInventory returns a SubstrateInventory summarizing the Brigade-managed
+	// workloads and storage that currently exist on the substrate and the
+	// substrate's capacity to execute them. This requires the ADMIN role.
+	GetInventory(
+		context.Context,
+		*SubstrateInventoryGetOptions,
+	) (SubstrateInventory, error)
 }
 
 type substrateClient struct {
@@ -96,3 +191,19 @@ func (s *substrateClient) CountRunningJobs(
 		},
 	)
 }
+
+func (s *substrateClient) GetInventory(
+	ctx context.Context,
+	_ *SubstrateInventoryGetOptions,
+) (SubstrateInventory, error) {
+	inventory := SubstrateInventory{}
+	return inventory, s.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method:      http.MethodGet,
+			Path:        "v2/substrate/inventory",
+			SuccessCode: http.StatusOK,
+			RespObj:     &inventory,
+		},
+	)
+}
@@ -67,3 +67,39 @@ func TestSubstrateClientCountRunningJobs(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, testCount, count)
 }
+
+func TestSubstrateClientGetInventory(t *testing.T) {
+	testInventory := SubstrateInventory{
+		Projects: []ProjectSubstrateInventory{
+			{
+				ProjectID: "italian",
+				Workers: PodCounts{
+					Pending: 1,
+				},
+			},
+		},
+		NodePools: []NodePoolCapacity{
+			{
+				Name:  "default",
+				Nodes: 3,
+			},
+		},
+	}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+				require.Equal(t, "/v2/substrate/inventory", r.URL.Path)
+				bodyBytes, err := json.Marshal(testInventory)
+				require.NoError(t, err)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, string(bodyBytes))
+			},
+		),
+	)
+	defer server.Close()
+	client := NewSubstrateClient(server.URL, rmTesting.TestAPIToken, nil)
+	inventory, err := client.GetInventory(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, testInventory, inventory)
+}
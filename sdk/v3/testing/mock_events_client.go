@@ -46,6 +46,8 @@ type MockEventsClient struct {
 		sdk.EventsSelector,
 		*sdk.EventCancelManyOptions,
 	) (sdk.CancelManyEventsResult, error)
+	SuspendFn    func(context.Context, string, *sdk.EventSuspendOptions) error
+	ResumeFn     func(context.Context, string, *sdk.EventResumeOptions) error
 	DeleteFn     func(context.Context, string, *sdk.EventDeleteOptions) error
 	DeleteManyFn func(
 		context.Context,
@@ -59,6 +61,7 @@ type MockEventsClient struct {
 	) (sdk.Event, error)
 	WorkersClient sdk.WorkersClient
 	LogsClient    sdk.LogsClient
+	ExecClient    sdk.ExecClient
 }
 
 func (m *MockEventsClient) Create(
@@ -127,6 +130,22 @@ func (m *MockEventsClient) CancelMany(
 	return m.CancelManyFn(ctx, selector, opts)
 }
 
+func (m *MockEventsClient) Suspend(
+	ctx context.Context,
+	id string,
+	opts *sdk.EventSuspendOptions,
+) error {
+	return m.SuspendFn(ctx, id, opts)
+}
+
+func (m *MockEventsClient) Resume(
+	ctx context.Context,
+	id string,
+	opts *sdk.EventResumeOptions,
+) error {
+	return m.ResumeFn(ctx, id, opts)
+}
+
 func (m *MockEventsClient) Delete(
 	ctx context.Context,
 	id string,
@@ -158,3 +177,7 @@ func (m *MockEventsClient) Workers() sdk.WorkersClient {
 func (m *MockEventsClient) Logs() sdk.LogsClient {
 	return m.LogsClient
 }
+
+func (m *MockEventsClient) Exec() sdk.ExecClient {
+	return m.ExecClient
+}
@@ -0,0 +1,27 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+)
+
+type MockExecClient struct {
+	ExecFn func(
+		ctx context.Context,
+		eventID string,
+		selector *sdk.ExecSelector,
+		opts *sdk.ExecOptions,
+		streams sdk.ExecStreams,
+	) error
+}
+
+func (m *MockExecClient) Exec(
+	ctx context.Context,
+	eventID string,
+	selector *sdk.ExecSelector,
+	opts *sdk.ExecOptions,
+	streams sdk.ExecStreams,
+) error {
+	return m.ExecFn(ctx, eventID, selector, opts, streams)
+}
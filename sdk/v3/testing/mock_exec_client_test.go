@@ -0,0 +1,12 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockExecClient(t *testing.T) {
+	require.Implements(t, (*sdk.ExecClient)(nil), &MockExecClient{})
+}
@@ -38,12 +38,25 @@ type MockJobsClient struct {
 		status sdk.JobStatus,
 		opts *sdk.JobStatusUpdateOptions,
 	) error
+	UpdateProgressFn func(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		progress sdk.JobProgress,
+		opts *sdk.JobProgressUpdateOptions,
+	) error
 	CleanupFn func(
 		ctx context.Context,
 		eventID string,
 		jobName string,
 		opts *sdk.JobCleanupOptions,
 	) error
+	CancelFn func(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		opts *sdk.JobCancelOptions,
+	) error
 	TimeoutFn func(
 		ctx context.Context,
 		eventID string,
@@ -98,6 +111,16 @@ func (m *MockJobsClient) UpdateStatus(
 	return m.UpdateStatusFn(ctx, eventID, jobName, status, opts)
 }
 
+func (m *MockJobsClient) UpdateProgress(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	progress sdk.JobProgress,
+	opts *sdk.JobProgressUpdateOptions,
+) error {
+	return m.UpdateProgressFn(ctx, eventID, jobName, progress, opts)
+}
+
 func (m *MockJobsClient) Cleanup(
 	ctx context.Context,
 	eventID string,
@@ -107,6 +130,15 @@ func (m *MockJobsClient) Cleanup(
 	return m.CleanupFn(ctx, eventID, jobName, opts)
 }
 
+func (m *MockJobsClient) Cancel(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	opts *sdk.JobCancelOptions,
+) error {
+	return m.CancelFn(ctx, eventID, jobName, opts)
+}
+
 func (m *MockJobsClient) Timeout(
 	ctx context.Context,
 	eventID string,
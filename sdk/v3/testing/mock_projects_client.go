@@ -39,6 +39,12 @@ type MockProjectsClient struct {
 		[]byte,
 		*sdk.ProjectUpdateOptions,
 	) (sdk.Project, error)
+	ClearCacheVolumeFn func(
+		ctx context.Context,
+		projectID string,
+		name string,
+		opts *sdk.CacheVolumeClearOptions,
+	) error
 	DeleteFn      func(context.Context, string, *sdk.ProjectDeleteOptions) error
 	AuthzClient   sdk.ProjectAuthzClient
 	SecretsClient sdk.SecretsClient
@@ -101,6 +107,15 @@ func (m *MockProjectsClient) Delete(
 	return m.DeleteFn(ctx, id, opts)
 }
 
+func (m *MockProjectsClient) ClearCacheVolume(
+	ctx context.Context,
+	projectID string,
+	name string,
+	opts *sdk.CacheVolumeClearOptions,
+) error {
+	return m.ClearCacheVolumeFn(ctx, projectID, name, opts)
+}
+
 func (m *MockProjectsClient) Authz() sdk.ProjectAuthzClient {
 	return m.AuthzClient
 }
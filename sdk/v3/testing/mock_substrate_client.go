@@ -15,6 +15,10 @@ type MockSubstrateClient struct {
 		context.Context,
 		*sdk.RunningJobCountOptions,
 	) (sdk.SubstrateJobCount, error)
+	GetInventoryFn func(
+		context.Context,
+		*sdk.SubstrateInventoryGetOptions,
+	) (sdk.SubstrateInventory, error)
 }
 
 func (m *MockSubstrateClient) CountRunningWorkers(
@@ -30,3 +34,10 @@ func (m *MockSubstrateClient) CountRunningJobs(
 ) (sdk.SubstrateJobCount, error) {
 	return m.CountRunningJobsFn(ctx, opts)
 }
+
+func (m *MockSubstrateClient) GetInventory(
+	ctx context.Context,
+	opts *sdk.SubstrateInventoryGetOptions,
+) (sdk.SubstrateInventory, error) {
+	return m.GetInventoryFn(ctx, opts)
+}
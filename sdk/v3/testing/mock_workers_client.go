@@ -28,6 +28,11 @@ type MockWorkersClient struct {
 		status sdk.WorkerStatus,
 		opts *sdk.WorkerStatusUpdateOptions,
 	) error
+	HeartbeatFn func(
+		ctx context.Context,
+		eventID string,
+		opts *sdk.WorkerHeartbeatOptions,
+	) error
 	CleanupFn func(
 		ctx context.Context,
 		eventID string,
@@ -74,6 +79,14 @@ func (m *MockWorkersClient) UpdateStatus(
 	return m.UpdateStatusFn(ctx, eventID, status, opts)
 }
 
+func (m *MockWorkersClient) Heartbeat(
+	ctx context.Context,
+	eventID string,
+	opts *sdk.WorkerHeartbeatOptions,
+) error {
+	return m.HeartbeatFn(ctx, eventID, opts)
+}
+
 func (m *MockWorkersClient) Cleanup(
 	ctx context.Context,
 	eventID string,
@@ -56,6 +56,10 @@ const (
 	// WorkerPhaseSucceeded represents the state where a worker has run to
 	// completion without error.
 	WorkerPhaseSucceeded WorkerPhase = "SUCCEEDED"
+	// WorkerPhaseSuspended represents the state wherein a running worker has been
+	// suspended. While suspended, the worker's existing Jobs continue to run, but
+	// no new Jobs are started until the worker is resumed.
+	WorkerPhaseSuspended WorkerPhase = "SUSPENDED"
 	// WorkerPhaseTimedOut represents the state wherein a worker has has not
 	// completed within a designated timeframe.
 	WorkerPhaseTimedOut WorkerPhase = "TIMED_OUT"
@@ -80,6 +84,7 @@ func WorkerPhasesAll() []WorkerPhase {
 		WorkerPhaseSchedulingFailed,
 		WorkerPhaseStarting,
 		WorkerPhaseSucceeded,
+		WorkerPhaseSuspended,
 		WorkerPhaseTimedOut,
 		WorkerPhaseUnknown,
 	}
@@ -109,6 +114,7 @@ func WorkerPhasesNonTerminal() []WorkerPhase {
 	return []WorkerPhase{
 		WorkerPhasePending,
 		WorkerPhaseRunning,
+		WorkerPhaseSuspended,
 		WorkerPhaseUnknown,
 	}
 }
@@ -155,6 +161,70 @@ func (w *Worker) Job(jobName string) (Job, bool) {
 	return Job{}, false
 }
 
+// JobGroups returns a summary of each group of Jobs that were expanded from a
+// Job matrix, in order of each group's first Job.
+func (w *Worker) JobGroups() []JobGroup {
+	groups := []JobGroup{}
+	groupPhases := [][]JobPhase{}
+	groupIndices := map[string]int{}
+	for _, job := range w.Jobs {
+		if job.Group == "" {
+			continue
+		}
+		i, ok := groupIndices[job.Group]
+		if !ok {
+			i = len(groups)
+			groupIndices[job.Group] = i
+			groups = append(
+				groups,
+				JobGroup{
+					Name:        job.Group,
+					PhaseCounts: map[JobPhase]int{},
+				},
+			)
+			groupPhases = append(groupPhases, []JobPhase{})
+		}
+		phase := JobPhasePending
+		if job.Status != nil && job.Status.Phase != "" {
+			phase = job.Status.Phase
+		}
+		groups[i].Jobs = append(groups[i].Jobs, job.Name)
+		groups[i].PhaseCounts[phase]++
+		groupPhases[i] = append(groupPhases[i], phase)
+	}
+	for i, phases := range groupPhases {
+		groups[i].Phase = aggregateJobPhases(phases)
+	}
+	return groups
+}
+
+// aggregateJobPhases returns a single JobPhase that summarizes the specified
+// JobPhases.
+func aggregateJobPhases(phases []JobPhase) JobPhase {
+	var allTerminal = true
+	var anyNotPending bool
+	aggregatePhase := JobPhaseSucceeded
+	for _, phase := range phases {
+		if phase != JobPhasePending {
+			anyNotPending = true
+		}
+		if !phase.IsTerminal() {
+			allTerminal = false
+		} else if phase != JobPhaseSucceeded &&
+			aggregatePhase == JobPhaseSucceeded {
+			aggregatePhase = phase
+		}
+	}
+	switch {
+	case allTerminal:
+		return aggregatePhase
+	case !anyNotPending:
+		return JobPhasePending
+	default:
+		return JobPhaseRunning
+	}
+}
+
 // WorkerSpec is the technical blueprint for a Worker.
 type WorkerSpec struct {
 	// Container specifies the details of an OCI container that forms the
@@ -174,6 +244,9 @@ type WorkerSpec struct {
 	Git *GitConfig `json:"git,omitempty"`
 	// Kubernetes contains Kubernetes-specific Worker details.
 	Kubernetes *KubernetesConfig `json:"kubernetes,omitempty"`
+	// Scheduling specifies criteria, beyond those configured for the substrate as
+	// a whole, for selecting a suitable substrate node for the Worker.
+	Scheduling *SchedulingConstraints `json:"scheduling,omitempty"`
 	// JobPolicies specifies policies for any Jobs spawned by the Worker.
 	JobPolicies *JobPolicies `json:"jobPolicies,omitempty"`
 	// LogLevel specifies the desired granularity of Worker log output.
@@ -228,6 +301,37 @@ type JobPolicies struct {
 	// AllowPrivileged specifies whether the Worker is permitted to launch Jobs
 	// that utilize privileged containers.
 	AllowPrivileged bool `json:"allowPrivileged"`
+	// Security specifies minimum security requirements that all of a Job's
+	// containers must meet.
+	Security *JobSecurityPolicy `json:"security,omitempty"`
+	// AllowedSecretKeys enumerates the only Project Secrets that Jobs may
+	// reference by key, either as environment variables or as mounted files.
+	// When empty, Jobs may not reference any Project Secret.
+	AllowedSecretKeys []string `json:"allowedSecretKeys,omitempty"`
+	// AllowedArchitectures enumerates the CPU architectures that Jobs may
+	// request. Jobs requesting no particular architecture are always permitted.
+	AllowedArchitectures []CPUArch `json:"allowedArchitectures,omitempty"`
+	// AllowedTolerationKeys enumerates the keys of substrate node taints that
+	// Jobs may tolerate.
+	AllowedTolerationKeys []string `json:"allowedTolerationKeys,omitempty"`
+	// AllowedAffinityKeys enumerates the node label keys that Jobs may use in
+	// node affinity terms or as topology keys in pod affinity terms.
+	AllowedAffinityKeys []string `json:"allowedAffinityKeys,omitempty"`
+	// AllowedPriorityClasses enumerates the substrate-specific priority classes
+	// that Jobs may use.
+	AllowedPriorityClasses []string `json:"allowedPriorityClasses,omitempty"`
+	// AllowedClusters enumerates the names of the substrate's Kubernetes
+	// clusters, other than the one hosting the Worker, that Jobs may be routed
+	// to.
+	AllowedClusters []string `json:"allowedClusters,omitempty"`
+	// AllowedServiceAccounts enumerates the names of the Project's
+	// JobServiceAccounts that Jobs may explicitly request to run as. Jobs that do
+	// not request one are always permitted and run as the Project's default.
+	AllowedServiceAccounts []string `json:"allowedServiceAccounts,omitempty"`
+	// Images specifies restrictions on the OCI images that the Worker and any
+	// Jobs it spawns may use. When not specified, the operator's default
+	// ImagePolicy, if any, applies.
+	Images *ImagePolicy `json:"images,omitempty"`
 	// AllowDockerSocketMount specifies whether the Worker is permitted to launch
 	// Jobs that mount the underlying host's Docker socket into its own file
 	// system.
@@ -242,6 +346,46 @@ type JobPolicies struct {
 	// AllowDockerSocketMount bool `json:"allowDockerSocketMount"`
 }
 
+// ImagePolicy represents restrictions on the OCI images that Workers and Jobs
+// may use.
+type ImagePolicy struct {
+	// AllowedRegistries enumerates registries from which images may be pulled.
+	// Images from Docker Hub belong to the "docker.io" registry.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// AllowedRepositories enumerates repositories, in the form
+	// [REGISTRY/]REPOSITORY, from which images may be pulled. Images from
+	// repositories nested beneath an allowed repository are also permitted. When
+	// both AllowedRegistries and AllowedRepositories are empty, images may be
+	// pulled from anywhere.
+	AllowedRepositories []string `json:"allowedRepositories,omitempty"`
+	// RequireDigest specifies whether images must be pinned by digest, i.e.
+	// referenced in the form IMAGE@sha256:<digest>.
+	RequireDigest bool `json:"requireDigest,omitempty"`
+}
+
+// JobSecurityPolicy represents minimum security requirements for Job
+// containers. Containers that do not explicitly meet these requirements via
+// their own security context are rejected.
+type JobSecurityPolicy struct {
+	// RequireRunAsNonRoot specifies whether Job containers must run as non-root.
+	RequireRunAsNonRoot bool `json:"requireRunAsNonRoot,omitempty"`
+	// RequireReadOnlyRootFilesystem specifies whether Job containers must use a
+	// read-only root file system.
+	RequireReadOnlyRootFilesystem bool `json:"requireReadOnlyRootFilesystem,omitempty"` // nolint: lll
+	// RequiredDroppedCapabilities enumerates Linux capabilities that Job
+	// containers must drop. Containers dropping "ALL" capabilities satisfy this
+	// requirement.
+	RequiredDroppedCapabilities []string `json:"requiredDroppedCapabilities,omitempty"` // nolint: lll
+	// RequireRuntimeDefaultSeccompProfile specifies whether Job containers must
+	// use the container runtime's default seccomp profile.
+	RequireRuntimeDefaultSeccompProfile bool `json:"requireRuntimeDefaultSeccompProfile,omitempty"` // nolint: lll
+	// AllowPrivileged specifies whether Job containers may run privileged
+	// despite this policy. A privileged container can circumvent every other
+	// requirement, so such containers are rejected unless this is set. Note that
+	// JobPolicies must independently permit privileged containers.
+	AllowPrivileged bool `json:"allowPrivileged,omitempty"`
+}
+
 // WorkerStatus represents the status of a Worker.
 type WorkerStatus struct {
 	// Started indicates the time the Worker began execution. It will be nil for
@@ -252,6 +396,16 @@ type WorkerStatus struct {
 	Ended *time.Time `json:"ended,omitempty"`
 	// Phase indicates where the Worker is in its lifecycle.
 	Phase WorkerPhase `json:"phase,omitempty"`
+	// LastHeartbeat indicates the time at which the Worker most recently
+	// reported that it is alive. It will be nil for a Worker that has never
+	// sent a heartbeat.
+	LastHeartbeat *time.Time `json:"lastHeartbeat,omitempty"`
+	// Reason optionally explains, in human-readable terms, why the Worker is in
+	// its current phase; for instance, why it failed.
+	Reason string `json:"reason,omitempty"`
+	// ResumePhase indicates the phase a suspended Worker will return to when it
+	// is resumed. It is empty for a Worker that is not suspended.
+	ResumePhase WorkerPhase `json:"resumePhase,omitempty"`
 }
 
 // MarshalJSON amends WorkerStatus instances with type metadata so that clients
@@ -295,6 +449,12 @@ type WorkerStatusWatchOptions struct{}
 // signatures.
 type WorkerStatusUpdateOptions struct{}
 
+// WorkerHeartbeatOptions represents useful, optional settings for recording a
+// Worker heartbeat. It currently has no fields, but exists to preserve the
+// possibility of future expansion without having to change client function
+// signatures.
+type WorkerHeartbeatOptions struct{}
+
 // WorkerCleanupOptions represents useful, optional settings for cleaning up
 // after a Worker. It currently has no fields, but exists to preserve the
 // possibility of future expansion without having to change client function
@@ -333,6 +493,14 @@ type WorkersClient interface {
 		status WorkerStatus,
 		opts *WorkerStatusUpdateOptions,
 	) error
+	// Heartbeat records that an Event's Worker is alive. Workers should invoke
+	// this periodically so that a Worker that has stopped making progress can
+	// be detected and failed.
+	Heartbeat(
+		ctx context.Context,
+		eventID string,
+		opts *WorkerHeartbeatOptions,
+	) error
 	Cleanup(
 		ctx context.Context,
 		eventID string,
@@ -440,6 +608,21 @@ func (w *workersClient) UpdateStatus(
 	)
 }
 
+func (w *workersClient) Heartbeat(
+	ctx context.Context,
+	eventID string,
+	_ *WorkerHeartbeatOptions,
+) error {
+	return w.ExecuteRequest(
+		ctx,
+		rm.OutboundRequest{
+			Method:      http.MethodPut,
+			Path:        fmt.Sprintf("v2/events/%s/worker/heartbeat", eventID),
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (w *workersClient) Cleanup(
 	ctx context.Context,
 	eventID string,
@@ -19,6 +19,109 @@ func TestWorkerStatusMarshalJSON(t *testing.T) {
 	metaTesting.RequireAPIVersionAndType(t, WorkerStatus{}, "WorkerStatus")
 }
 
+func TestWorkerJobGroups(t *testing.T) {
+	testCases := []struct {
+		name       string
+		jobs       []Job
+		assertions func([]JobGroup)
+	}{
+		{
+			name: "no jobs expanded from a matrix",
+			jobs: []Job{{Name: "foo"}},
+			assertions: func(groups []JobGroup) {
+				require.Empty(t, groups)
+			},
+		},
+		{
+			name: "all jobs pending",
+			jobs: []Job{
+				{
+					Name:   "test-16",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhasePending},
+				},
+				{
+					Name:   "test-18",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhasePending},
+				},
+			},
+			assertions: func(groups []JobGroup) {
+				require.Len(t, groups, 1)
+				require.Equal(t, "test", groups[0].Name)
+				require.Equal(t, []string{"test-16", "test-18"}, groups[0].Jobs)
+				require.Equal(t, JobPhasePending, groups[0].Phase)
+				require.Equal(
+					t,
+					map[JobPhase]int{JobPhasePending: 2},
+					groups[0].PhaseCounts,
+				)
+			},
+		},
+		{
+			name: "some jobs not in a terminal phase",
+			jobs: []Job{
+				{
+					Name:   "test-16",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhaseFailed},
+				},
+				{
+					Name:   "test-18",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhasePending},
+				},
+			},
+			assertions: func(groups []JobGroup) {
+				require.Len(t, groups, 1)
+				require.Equal(t, JobPhaseRunning, groups[0].Phase)
+			},
+		},
+		{
+			name: "all jobs in a terminal phase",
+			jobs: []Job{
+				{
+					Name:   "test-16",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhaseSucceeded},
+				},
+				{
+					Name:   "lint",
+					Status: &JobStatus{Phase: JobPhaseRunning},
+				},
+				{
+					Name:   "build-linux",
+					Group:  "build",
+					Status: &JobStatus{Phase: JobPhaseSucceeded},
+				},
+				{
+					Name:   "test-18",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhaseTimedOut},
+				},
+				{
+					Name:   "test-20",
+					Group:  "test",
+					Status: &JobStatus{Phase: JobPhaseFailed},
+				},
+			},
+			assertions: func(groups []JobGroup) {
+				require.Len(t, groups, 2)
+				require.Equal(t, "test", groups[0].Name)
+				require.Equal(t, JobPhaseTimedOut, groups[0].Phase)
+				require.Equal(t, "build", groups[1].Name)
+				require.Equal(t, JobPhaseSucceeded, groups[1].Phase)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			worker := Worker{Jobs: testCase.jobs}
+			testCase.assertions(worker.JobGroups())
+		})
+	}
+}
+
 func TestNewWorkersClient(t *testing.T) {
 	client, ok := NewWorkersClient(
 		rmTesting.TestAPIAddress,
@@ -182,6 +285,29 @@ func TestWorkersClientCleanup(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestWorkersClientHeartbeat(t *testing.T) {
+	const testEventID = "12345"
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				require.Equal(t, http.MethodPut, r.Method)
+				require.Equal(
+					t,
+					fmt.Sprintf("/v2/events/%s/worker/heartbeat", testEventID),
+					r.URL.Path,
+				)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
+	defer server.Close()
+	client := NewWorkersClient(server.URL, rmTesting.TestAPIToken, nil)
+	err := client.Heartbeat(context.Background(), testEventID, nil)
+	require.NoError(t, err)
+}
+
 func TestWorkersClientTimeout(t *testing.T) {
 	const testEventID = "12345"
 	server := httptest.NewServer(
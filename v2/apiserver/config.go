@@ -12,10 +12,12 @@ import (
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/github"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/kubernetes"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api/local"
 	myOIDC "github.com/brigadecore/brigade/v2/apiserver/internal/api/oidc"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/rest"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue/amqp"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
 	"github.com/coreos/go-oidc"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -23,12 +25,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"golang.org/x/oauth2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	restclient "k8s.io/client-go/rest"
 )
 
 const (
 	thirdPartyAuthStrategyDisabled = "disabled"
 	thirdPartyAuthStrategyOIDC     = "oidc"
 	thirdPartyAuthStrategyGitHub   = "github"
+
+	substrateTypeKubernetes = "kubernetes"
+	substrateTypeLocal      = "local"
 )
 
 // databaseConnection returns a *mongo.Database connection based on
@@ -100,6 +107,19 @@ func writerFactoryConfig() (amqp.WriterFactoryConfig, error) {
 	return config, err
 }
 
+// substrateType returns the type of substrate that the API server should use
+// based on configuration obtained from environment variables.
+func substrateType() (string, error) {
+	substrateType := os.GetEnvVar("SUBSTRATE", substrateTypeKubernetes)
+	log.Println("SUBSTRATE: ", substrateType)
+	switch substrateType {
+	case substrateTypeKubernetes, substrateTypeLocal:
+		return substrateType, nil
+	default:
+		return "", errors.Errorf("unrecognized SUBSTRATE %q", substrateType)
+	}
+}
+
 // substrateConfig returns a kubernetes.SubstrateConfig based on configuration
 // obtained from environment variables.
 func substrateConfig() (kubernetes.SubstrateConfig, error) {
@@ -170,7 +190,299 @@ func substrateConfig() (kubernetes.SubstrateConfig, error) {
 	config.NodeSelectorValue = os.GetEnvVar("NODE_SELECTOR_VALUE", "")
 	config.TolerationKey = os.GetEnvVar("TOLERATION_KEY", "")
 	config.TolerationValue = os.GetEnvVar("TOLERATION_VALUE", "")
-	return config, nil
+	if config.BatchJobsEnabled, err =
+		os.GetBoolFromEnvVar("BATCH_JOBS_ENABLED", false); err != nil {
+		return config, err
+	}
+	log.Println("BATCH_JOBS_ENABLED: ", config.BatchJobsEnabled)
+	if config.BatchJobsEnabled {
+		var backoffLimit int
+		if backoffLimit, err =
+			os.GetIntFromEnvVar("BATCH_JOB_BACKOFF_LIMIT", 0); err != nil {
+			return config, err
+		}
+		config.BatchJobBackoffLimit = int32(backoffLimit)
+		log.Println("BATCH_JOB_BACKOFF_LIMIT: ", config.BatchJobBackoffLimit)
+		if config.BatchJobTTLAfterFinished, err = os.GetDurationFromEnvVar(
+			"BATCH_JOB_TTL_AFTER_FINISHED",
+			0,
+		); err != nil {
+			return config, err
+		}
+		log.Println(
+			"BATCH_JOB_TTL_AFTER_FINISHED: ",
+			config.BatchJobTTLAfterFinished,
+		)
+	}
+	config.ClaimableNamespaces =
+		os.GetStringSliceFromEnvVar("CLAIMABLE_NAMESPACES", nil)
+	log.Println("CLAIMABLE_NAMESPACES: ", config.ClaimableNamespaces)
+	config.ProjectNodeSelectorKeys =
+		os.GetStringSliceFromEnvVar("PROJECT_NODE_SELECTOR_KEYS", nil)
+	log.Println("PROJECT_NODE_SELECTOR_KEYS: ", config.ProjectNodeSelectorKeys)
+	config.ProjectTolerationKeys =
+		os.GetStringSliceFromEnvVar("PROJECT_TOLERATION_KEYS", nil)
+	log.Println("PROJECT_TOLERATION_KEYS: ", config.ProjectTolerationKeys)
+	if config.MaxResourceQuota, err =
+		resourceQuantities("MAX_PROJECT_RESOURCE_QUOTA"); err != nil {
+		return config, err
+	}
+	log.Println("MAX_PROJECT_RESOURCE_QUOTA: ", config.MaxResourceQuota)
+	if config.DefaultContainerRequests, err =
+		resourceQuantities("DEFAULT_PROJECT_CONTAINER_REQUESTS"); err != nil {
+		return config, err
+	}
+	log.Println(
+		"DEFAULT_PROJECT_CONTAINER_REQUESTS: ",
+		config.DefaultContainerRequests,
+	)
+	if config.DefaultContainerLimits, err =
+		resourceQuantities("DEFAULT_PROJECT_CONTAINER_LIMITS"); err != nil {
+		return config, err
+	}
+	log.Println(
+		"DEFAULT_PROJECT_CONTAINER_LIMITS: ",
+		config.DefaultContainerLimits,
+	)
+	if err = config.ValidateComputeDefaults(); err != nil {
+		return config, err
+	}
+	config.DefaultNetworkPolicy = api.NetworkPolicyMode(
+		os.GetEnvVar("DEFAULT_NETWORK_POLICY", ""),
+	)
+	switch config.DefaultNetworkPolicy {
+	case "",
+		api.NetworkPolicyModeAllowAll,
+		api.NetworkPolicyModeDenyNamespaceToNamespace,
+		api.NetworkPolicyModeEgressOnly:
+	default:
+		return config, errors.Errorf(
+			"DEFAULT_NETWORK_POLICY value %q is not a recognized network policy",
+			config.DefaultNetworkPolicy,
+		)
+	}
+	log.Println("DEFAULT_NETWORK_POLICY: ", config.DefaultNetworkPolicy)
+	if config.WorkerPodOverlay, err =
+		podOverlay("WORKER_POD_OVERLAY_TYPE", "WORKER_POD_OVERLAY"); err != nil {
+		return config, err
+	}
+	if config.JobPodOverlay, err =
+		podOverlay("JOB_POD_OVERLAY_TYPE", "JOB_POD_OVERLAY"); err != nil {
+		return config, err
+	}
+	config.PodOverlayDeniedFields =
+		os.GetStringSliceFromEnvVar("POD_OVERLAY_DENIED_FIELDS", nil)
+	log.Println("POD_OVERLAY_DENIED_FIELDS: ", config.PodOverlayDeniedFields)
+	config.NodePoolLabels =
+		os.GetStringSliceFromEnvVar("NODE_POOL_LABELS", nil)
+	log.Println("NODE_POOL_LABELS: ", config.NodePoolLabels)
+	if config.WorkerHeartbeatInterval, err =
+		workerHeartbeatInterval(); err != nil {
+		return config, err
+	}
+	config.DefaultImagePolicy, err = defaultImagePolicy()
+	return config, err
+}
+
+// podOverlay returns the api.PodOverlay, if any, that is specified by the
+// provided pair of environment variables. The type defaults to
+// api.PodOverlayTypeStrategicMerge. If no patch is specified, nil is returned.
+func podOverlay(typeEnvVar, patchEnvVar string) (*api.PodOverlay, error) {
+	patch := os.GetEnvVar(patchEnvVar, "")
+	if patch == "" {
+		return nil, nil
+	}
+	overlay := &api.PodOverlay{
+		Type: api.PodOverlayType(
+			os.GetEnvVar(typeEnvVar, string(api.PodOverlayTypeStrategicMerge)),
+		),
+		Patch: patch,
+	}
+	switch overlay.Type {
+	case api.PodOverlayTypeStrategicMerge, api.PodOverlayTypeJSONPatch:
+	default:
+		return nil, errors.Errorf(
+			"%s value %q is not a recognized pod overlay type",
+			typeEnvVar,
+			overlay.Type,
+		)
+	}
+	log.Printf("%s: %s", typeEnvVar, overlay.Type)
+	return overlay, nil
+}
+
+// resourceQuantities returns a map of resource names to quantities based on
+// configuration obtained from the specified environment variable. Each entry
+// takes the form <resource>=<quantity>. This is used for the maximum quantity
+// of each resource that a Project's ResourceQuota may specify as well as for
+// default container requests and limits.
+func resourceQuantities(envVarName string) (
+	map[string]resource.Quantity,
+	error,
+) {
+	entries := os.GetStringSliceFromEnvVar(envVarName, nil)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	quantities := make(map[string]resource.Quantity, len(entries))
+	for _, entry := range entries {
+		tokens := strings.SplitN(entry, "=", 2)
+		if len(tokens) != 2 || tokens[0] == "" {
+			return nil, errors.Errorf(
+				"%s entry %q is not of the form <resource>=<quantity>",
+				envVarName,
+				entry,
+			)
+		}
+		quantity, err := resource.ParseQuantity(tokens[1])
+		if err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"error parsing quantity in %s entry %q",
+				envVarName,
+				entry,
+			)
+		}
+		quantities[tokens[0]] = quantity
+	}
+	return quantities, nil
+}
+
+// clusterConfigs returns a map of the names of additional Kubernetes clusters
+// to configuration for connecting to each, based on configuration obtained
+// from environment variables. Each entry takes the form <name>=<path>, where
+// <path> is the path to a kubeconfig file. If no additional clusters are
+// configured, nil is returned.
+func clusterConfigs() (map[string]*restclient.Config, error) {
+	entries := os.GetStringSliceFromEnvVar("CLUSTER_KUBE_CONFIGS", nil)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	configs := make(map[string]*restclient.Config, len(entries))
+	for _, entry := range entries {
+		tokens := strings.SplitN(entry, "=", 2)
+		if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+			return nil, errors.Errorf(
+				"CLUSTER_KUBE_CONFIGS entry %q is not of the form <name>=<path>",
+				entry,
+			)
+		}
+		if _, ok := configs[tokens[0]]; ok {
+			return nil, errors.Errorf(
+				"CLUSTER_KUBE_CONFIGS specifies cluster %q more than once",
+				tokens[0],
+			)
+		}
+		config, err := myk8s.ConfigFromFile(tokens[1])
+		if err != nil {
+			return nil, err
+		}
+		configs[tokens[0]] = config
+		log.Printf("CLUSTER_KUBE_CONFIGS: %s=%s", tokens[0], tokens[1])
+	}
+	return configs, nil
+}
+
+// workerHeartbeatInterval returns the interval at which Workers should report
+// that they are alive, based on configuration obtained from environment
+// variables. This should agree with the observer's configuration.
+func workerHeartbeatInterval() (time.Duration, error) {
+	interval, err :=
+		os.GetDurationFromEnvVar("WORKER_HEARTBEAT_INTERVAL", 30*time.Second)
+	if err != nil {
+		return interval, err
+	}
+	log.Println("WORKER_HEARTBEAT_INTERVAL: ", interval)
+	return interval, nil
+}
+
+// defaultImagePolicy returns the *api.ImagePolicy that a substrate should
+// apply to all Workers and Jobs, in addition to any specified by their own
+// configuration, based on configuration obtained from environment variables.
+// If no such policy is configured, nil is returned.
+func defaultImagePolicy() (*api.ImagePolicy, error) {
+	policy := api.ImagePolicy{
+		AllowedRegistries: os.GetStringSliceFromEnvVar(
+			"DEFAULT_IMAGE_POLICY_ALLOWED_REGISTRIES",
+			nil,
+		),
+		AllowedRepositories: os.GetStringSliceFromEnvVar(
+			"DEFAULT_IMAGE_POLICY_ALLOWED_REPOSITORIES",
+			nil,
+		),
+	}
+	var err error
+	policy.RequireDigest, err =
+		os.GetBoolFromEnvVar("DEFAULT_IMAGE_POLICY_REQUIRE_DIGEST", false)
+	if err != nil {
+		return nil, err
+	}
+	if len(policy.AllowedRegistries) > 0 ||
+		len(policy.AllowedRepositories) > 0 ||
+		policy.RequireDigest {
+		return &policy, nil
+	}
+	return nil, nil
+}
+
+// localSubstrateConfig returns a local.SubstrateConfig based on configuration
+// obtained from environment variables.
+func localSubstrateConfig() (local.SubstrateConfig, error) {
+	config := local.SubstrateConfig{}
+	var err error
+	config.BrigadeID, err = os.GetRequiredEnvVar("BRIGADE_ID")
+	if err != nil {
+		return config, err
+	}
+	log.Println("BRIGADE_ID: ", config.BrigadeID)
+	config.APIAddress, err = os.GetRequiredEnvVar("API_ADDRESS")
+	if err != nil {
+		return config, err
+	}
+	log.Println("API_ADDRESS: ", config.APIAddress)
+	config.RootDirectory, err =
+		os.GetRequiredEnvVar("LOCAL_SUBSTRATE_ROOT_DIRECTORY")
+	if err != nil {
+		return config, err
+	}
+	log.Println("LOCAL_SUBSTRATE_ROOT_DIRECTORY: ", config.RootDirectory)
+	config.Network = os.GetEnvVar("LOCAL_SUBSTRATE_NETWORK", "")
+	log.Println("LOCAL_SUBSTRATE_NETWORK: ", config.Network)
+	config.GitInitializerImage, err =
+		os.GetRequiredEnvVar("GIT_INITIALIZER_IMAGE")
+	if err != nil {
+		return config, err
+	}
+	log.Println("GIT_INITIALIZER_IMAGE: ", config.GitInitializerImage)
+	gitInitializerImagePullPolicyStr, err :=
+		os.GetRequiredEnvVar("GIT_INITIALIZER_IMAGE_PULL_POLICY")
+	if err != nil {
+		return config, err
+	}
+	config.GitInitializerImagePullPolicy =
+		api.ImagePullPolicy(gitInitializerImagePullPolicyStr)
+	log.Println("GIT_INITIALIZER_IMAGE_PULL_POLICY: ",
+		config.GitInitializerImagePullPolicy)
+	config.DefaultWorkerImage, err = os.GetRequiredEnvVar("DEFAULT_WORKER_IMAGE")
+	if err != nil {
+		return config, err
+	}
+	log.Println("DEFAULT_WORKER_IMAGE: ", config.DefaultWorkerImage)
+	defaultWorkerImagePullPolicyStr, err :=
+		os.GetRequiredEnvVar("DEFAULT_WORKER_IMAGE_PULL_POLICY")
+	if err != nil {
+		return config, err
+	}
+	config.DefaultWorkerImagePullPolicy =
+		api.ImagePullPolicy(defaultWorkerImagePullPolicyStr)
+	log.Println("DEFAULT_WORKER_IMAGE_PULL_POLICY: ",
+		config.DefaultWorkerImagePullPolicy)
+	if config.WorkerHeartbeatInterval, err =
+		workerHeartbeatInterval(); err != nil {
+		return config, err
+	}
+	config.DefaultImagePolicy, err = defaultImagePolicy()
+	return config, err
 }
 
 // thirdPartyAuthHelper returns an appropriate instance of
@@ -289,6 +601,19 @@ func sessionsServiceConfig() (api.SessionsServiceConfig, error) {
 	return config, nil
 }
 
+// jobsServiceConfig returns an api.JobsServiceConfig based on configuration
+// obtained from environment variables.
+func jobsServiceConfig() (api.JobsServiceConfig, error) {
+	config := api.JobsServiceConfig{}
+	var err error
+	if config.MaxMatrixJobs, err =
+		os.GetIntFromEnvVar("MAX_MATRIX_JOBS", 100); err != nil {
+		return config, err
+	}
+	log.Println("MAX_MATRIX_JOBS: ", config.MaxMatrixJobs)
+	return config, nil
+}
+
 // usersServiceConfig returns an api.UsersServiceConfig based on configuration
 // obtained from environment variables. nolint: gocyclo
 func usersServiceConfig() api.UsersServiceConfig {
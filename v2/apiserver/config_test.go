@@ -6,16 +6,22 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/kubernetes"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api/local"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/rest"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue/amqp"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/mongo"
+	"k8s.io/apimachinery/pkg/api/resource"
+	restclient "k8s.io/client-go/rest"
 )
 
 // Note that unit testing in Go does NOT clear environment variables between
@@ -171,6 +177,50 @@ func TestWriterFactoryConfig(t *testing.T) {
 	}
 }
 
+func TestSubstrateType(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func()
+		assertions func(string, error)
+	}{
+		{
+			name:  "SUBSTRATE not set",
+			setup: func() {},
+			assertions: func(substrateType string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, substrateTypeKubernetes, substrateType)
+			},
+		},
+		{
+			name: "SUBSTRATE unrecognized",
+			setup: func() {
+				t.Setenv("SUBSTRATE", "bogus")
+			},
+			assertions: func(_ string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "unrecognized SUBSTRATE")
+			},
+		},
+		{
+			name: "SUBSTRATE set to local",
+			setup: func() {
+				t.Setenv("SUBSTRATE", substrateTypeLocal)
+			},
+			assertions: func(substrateType string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, substrateTypeLocal, substrateType)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.setup()
+			substrateType, err := substrateType()
+			testCase.assertions(substrateType, err)
+		})
+	}
+}
+
 func TestSubstrateConfig(t *testing.T) {
 	// nolint: lll
 	const (
@@ -338,6 +388,296 @@ func TestSubstrateConfig(t *testing.T) {
 					testWorkspaceStorageClass,
 					config.WorkspaceStorageClass,
 				)
+				require.Nil(t, config.DefaultImagePolicy)
+			},
+		},
+		{
+			name: "BATCH_JOBS_ENABLED not parsable as bool",
+			setup: func() {
+				t.Setenv("BATCH_JOBS_ENABLED", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a bool")
+				require.Contains(t, err.Error(), "BATCH_JOBS_ENABLED")
+			},
+		},
+		{
+			name: "BATCH_JOB_BACKOFF_LIMIT not parsable as int",
+			setup: func() {
+				t.Setenv("BATCH_JOBS_ENABLED", "true")
+				t.Setenv("BATCH_JOB_BACKOFF_LIMIT", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as an int")
+				require.Contains(t, err.Error(), "BATCH_JOB_BACKOFF_LIMIT")
+			},
+		},
+		{
+			name: "BATCH_JOB_TTL_AFTER_FINISHED not parsable as duration",
+			setup: func() {
+				t.Setenv("BATCH_JOB_BACKOFF_LIMIT", "2")
+				t.Setenv("BATCH_JOB_TTL_AFTER_FINISHED", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a duration")
+				require.Contains(t, err.Error(), "BATCH_JOB_TTL_AFTER_FINISHED")
+			},
+		},
+		{
+			name: "success with batch jobs enabled",
+			setup: func() {
+				t.Setenv("BATCH_JOB_TTL_AFTER_FINISHED", "1h")
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.True(t, config.BatchJobsEnabled)
+				require.Equal(t, int32(2), config.BatchJobBackoffLimit)
+				require.Equal(t, time.Hour, config.BatchJobTTLAfterFinished)
+			},
+		},
+		{
+			name: "success with claimable namespaces",
+			setup: func() {
+				t.Setenv("CLAIMABLE_NAMESPACES", "team-*,shared")
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]string{"team-*", "shared"},
+					config.ClaimableNamespaces,
+				)
+			},
+		},
+		{
+			name: "success with project scheduling keys",
+			setup: func() {
+				t.Setenv("PROJECT_NODE_SELECTOR_KEYS", "team,disktype")
+				t.Setenv("PROJECT_TOLERATION_KEYS", "spot")
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]string{"team", "disktype"},
+					config.ProjectNodeSelectorKeys,
+				)
+				require.Equal(t, []string{"spot"}, config.ProjectTolerationKeys)
+			},
+		},
+		{
+			name: "MAX_PROJECT_RESOURCE_QUOTA entry malformed",
+			setup: func() {
+				t.Setenv("MAX_PROJECT_RESOURCE_QUOTA", "pods")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "MAX_PROJECT_RESOURCE_QUOTA")
+				require.Contains(t, err.Error(), "is not of the form")
+			},
+		},
+		{
+			name: "MAX_PROJECT_RESOURCE_QUOTA quantity not parsable",
+			setup: func() {
+				t.Setenv("MAX_PROJECT_RESOURCE_QUOTA", "pods=lots")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "MAX_PROJECT_RESOURCE_QUOTA")
+				require.Contains(t, err.Error(), "error parsing quantity")
+			},
+		},
+		{
+			name: "MAX_PROJECT_RESOURCE_QUOTA compute without defaults",
+			setup: func() {
+				t.Setenv("MAX_PROJECT_RESOURCE_QUOTA", "pods=10,requests.cpu=4")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "requests.cpu")
+				require.Contains(t, err.Error(), "requires a default")
+			},
+		},
+		{
+			name: "DEFAULT_PROJECT_CONTAINER_REQUESTS entry malformed",
+			setup: func() {
+				t.Setenv("DEFAULT_PROJECT_CONTAINER_REQUESTS", "cpu")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "DEFAULT_PROJECT_CONTAINER_REQUESTS")
+				require.Contains(t, err.Error(), "is not of the form")
+			},
+		},
+		{
+			name: "DEFAULT_PROJECT_CONTAINER_LIMITS entry malformed",
+			setup: func() {
+				t.Setenv("DEFAULT_PROJECT_CONTAINER_REQUESTS", "cpu=250m")
+				t.Setenv("DEFAULT_PROJECT_CONTAINER_LIMITS", "cpu=lots")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "DEFAULT_PROJECT_CONTAINER_LIMITS")
+				require.Contains(t, err.Error(), "error parsing quantity")
+			},
+		},
+		{
+			name: "DEFAULT_NETWORK_POLICY not recognized",
+			setup: func() {
+				t.Setenv("DEFAULT_PROJECT_CONTAINER_LIMITS", "cpu=1")
+				t.Setenv("DEFAULT_NETWORK_POLICY", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "DEFAULT_NETWORK_POLICY")
+				require.Contains(t, err.Error(), "not a recognized network policy")
+			},
+		},
+		{
+			name: "success with project policies",
+			setup: func() {
+				t.Setenv("DEFAULT_NETWORK_POLICY", "DenyNamespaceToNamespace")
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					map[string]resource.Quantity{
+						"pods":         resource.MustParse("10"),
+						"requests.cpu": resource.MustParse("4"),
+					},
+					config.MaxResourceQuota,
+				)
+				require.Equal(
+					t,
+					map[string]resource.Quantity{
+						"cpu": resource.MustParse("250m"),
+					},
+					config.DefaultContainerRequests,
+				)
+				require.Equal(
+					t,
+					map[string]resource.Quantity{
+						"cpu": resource.MustParse("1"),
+					},
+					config.DefaultContainerLimits,
+				)
+				require.Equal(
+					t,
+					api.NetworkPolicyModeDenyNamespaceToNamespace,
+					config.DefaultNetworkPolicy,
+				)
+			},
+		},
+		{
+			name: "WORKER_POD_OVERLAY_TYPE not recognized",
+			setup: func() {
+				t.Setenv("WORKER_POD_OVERLAY", `{"spec": {"hostAliases": []}}`)
+				t.Setenv("WORKER_POD_OVERLAY_TYPE", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "WORKER_POD_OVERLAY_TYPE")
+				require.Contains(t, err.Error(), "not a recognized pod overlay type")
+			},
+		},
+		{
+			name: "success with pod overlays",
+			setup: func() {
+				t.Setenv("WORKER_POD_OVERLAY_TYPE", "")
+				t.Setenv(
+					"JOB_POD_OVERLAY",
+					`[{"op": "add", "path": "/spec/hostAliases", "value": []}]`,
+				)
+				t.Setenv("JOB_POD_OVERLAY_TYPE", "JSONPatch")
+				t.Setenv("POD_OVERLAY_DENIED_FIELDS", "spec.hostNetwork,spec.hostPID")
+				t.Setenv("NODE_POOL_LABELS", "example.com/pool,agentpool")
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					&api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"spec": {"hostAliases": []}}`,
+					},
+					config.WorkerPodOverlay,
+				)
+				require.Equal(
+					t,
+					&api.PodOverlay{
+						Type:  api.PodOverlayTypeJSONPatch,
+						Patch: `[{"op": "add", "path": "/spec/hostAliases", "value": []}]`,
+					},
+					config.JobPodOverlay,
+				)
+				require.Equal(
+					t,
+					[]string{"spec.hostNetwork", "spec.hostPID"},
+					config.PodOverlayDeniedFields,
+				)
+				require.Equal(
+					t,
+					[]string{"example.com/pool", "agentpool"},
+					config.NodePoolLabels,
+				)
+				require.Equal(t, 30*time.Second, config.WorkerHeartbeatInterval)
+			},
+		},
+		{
+			name: "WORKER_HEARTBEAT_INTERVAL not parsable as duration",
+			setup: func() {
+				t.Setenv("WORKER_HEARTBEAT_INTERVAL", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a duration")
+				require.Contains(t, err.Error(), "WORKER_HEARTBEAT_INTERVAL")
+			},
+		},
+		{
+			name: "success with worker heartbeat interval",
+			setup: func() {
+				t.Setenv("WORKER_HEARTBEAT_INTERVAL", "5s")
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 5*time.Second, config.WorkerHeartbeatInterval)
+			},
+		},
+		{
+			name: "DEFAULT_IMAGE_POLICY_REQUIRE_DIGEST not parsable as bool",
+			setup: func() {
+				t.Setenv("DEFAULT_IMAGE_POLICY_REQUIRE_DIGEST", "foo")
+			},
+			assertions: func(_ kubernetes.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a bool")
+				require.Contains(t, err.Error(), "DEFAULT_IMAGE_POLICY_REQUIRE_DIGEST")
+			},
+		},
+		{
+			name: "success with default image policy",
+			setup: func() {
+				t.Setenv("DEFAULT_IMAGE_POLICY_REQUIRE_DIGEST", "true")
+				t.Setenv(
+					"DEFAULT_IMAGE_POLICY_ALLOWED_REGISTRIES",
+					"ghcr.io,docker.io",
+				)
+			},
+			assertions: func(config kubernetes.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					&api.ImagePolicy{
+						AllowedRegistries: []string{"ghcr.io", "docker.io"},
+						RequireDigest:     true,
+					},
+					config.DefaultImagePolicy,
+				)
 			},
 		},
 	}
@@ -350,6 +690,244 @@ func TestSubstrateConfig(t *testing.T) {
 	}
 }
 
+func TestClusterConfigs(t *testing.T) {
+	kubeConfigPath := filepath.Join(t.TempDir(), "kubeconfig")
+	err := os.WriteFile(
+		kubeConfigPath,
+		[]byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: gpu
+  cluster:
+    server: https://gpu.example.com
+contexts:
+- name: gpu
+  context:
+    cluster: gpu
+current-context: gpu
+`),
+		0600,
+	)
+	require.NoError(t, err)
+	testCases := []struct {
+		name       string
+		setup      func()
+		assertions func(map[string]*restclient.Config, error)
+	}{
+		{
+			name:  "CLUSTER_KUBE_CONFIGS not set",
+			setup: func() {},
+			assertions: func(configs map[string]*restclient.Config, err error) {
+				require.NoError(t, err)
+				require.Nil(t, configs)
+			},
+		},
+		{
+			name: "CLUSTER_KUBE_CONFIGS entry malformed",
+			setup: func() {
+				t.Setenv("CLUSTER_KUBE_CONFIGS", "gpu")
+			},
+			assertions: func(_ map[string]*restclient.Config, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "is not of the form")
+			},
+		},
+		{
+			name: "CLUSTER_KUBE_CONFIGS specifies a cluster more than once",
+			setup: func() {
+				t.Setenv(
+					"CLUSTER_KUBE_CONFIGS",
+					fmt.Sprintf("gpu=%s,gpu=%s", kubeConfigPath, kubeConfigPath),
+				)
+			},
+			assertions: func(_ map[string]*restclient.Config, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "more than once")
+			},
+		},
+		{
+			name: "kubeconfig file does not exist",
+			setup: func() {
+				t.Setenv("CLUSTER_KUBE_CONFIGS", "gpu=/does/not/exist")
+			},
+			assertions: func(_ map[string]*restclient.Config, err error) {
+				require.Error(t, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"error getting kubernetes configuration",
+				)
+			},
+		},
+		{
+			name: "success",
+			setup: func() {
+				t.Setenv("CLUSTER_KUBE_CONFIGS", fmt.Sprintf("gpu=%s", kubeConfigPath))
+			},
+			assertions: func(configs map[string]*restclient.Config, err error) {
+				require.NoError(t, err)
+				require.Len(t, configs, 1)
+				require.Equal(t, "https://gpu.example.com", configs["gpu"].Host)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.setup()
+			configs, err := clusterConfigs()
+			testCase.assertions(configs, err)
+		})
+	}
+}
+
+func TestLocalSubstrateConfig(t *testing.T) {
+	// nolint: lll
+	const (
+		testBrigadeID                     = "4077th"
+		testAPIAddress                    = "http://localhost"
+		testRootDirectory                 = "/var/lib/brigade"
+		testNetwork                       = "brigade"
+		testGitInitializerImage           = "brigadecore/brigade2-git-initializer:2.0.0"
+		testGitInitializerImagePullPolicy = api.ImagePullPolicy("IfNotPresent")
+		testDefaultWorkerImage            = "brigadecore/brigade2-worker:2.0.0"
+		testDefaultWorkerImagePullPolicy  = api.ImagePullPolicy("IfNotPresent")
+	)
+	testCases := []struct {
+		name       string
+		setup      func()
+		assertions func(local.SubstrateConfig, error)
+	}{
+		{
+			name:  "BRIGADE_ID not set",
+			setup: func() {},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "BRIGADE_ID")
+			},
+		},
+		{
+			name: "API_ADDRESS not set",
+			setup: func() {
+				t.Setenv("BRIGADE_ID", testBrigadeID)
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "API_ADDRESS")
+			},
+		},
+		{
+			name: "LOCAL_SUBSTRATE_ROOT_DIRECTORY not set",
+			setup: func() {
+				t.Setenv("API_ADDRESS", testAPIAddress)
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "LOCAL_SUBSTRATE_ROOT_DIRECTORY")
+			},
+		},
+		{
+			name: "GIT_INITIALIZER_IMAGE not set",
+			setup: func() {
+				t.Setenv("LOCAL_SUBSTRATE_ROOT_DIRECTORY", testRootDirectory)
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "GIT_INITIALIZER_IMAGE")
+			},
+		},
+		{
+			name: "GIT_INITIALIZER_IMAGE_PULL_POLICY not set",
+			setup: func() {
+				t.Setenv("GIT_INITIALIZER_IMAGE", testGitInitializerImage)
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "GIT_INITIALIZER_IMAGE_PULL_POLICY")
+			},
+		},
+		{
+			name: "DEFAULT_WORKER_IMAGE not set",
+			setup: func() {
+				t.Setenv(
+					"GIT_INITIALIZER_IMAGE_PULL_POLICY",
+					string(testGitInitializerImagePullPolicy),
+				)
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "DEFAULT_WORKER_IMAGE")
+			},
+		},
+		{
+			name: "DEFAULT_WORKER_IMAGE_PULL_POLICY not set",
+			setup: func() {
+				t.Setenv("DEFAULT_WORKER_IMAGE", testDefaultWorkerImage)
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "DEFAULT_WORKER_IMAGE_PULL_POLICY")
+			},
+		},
+		{
+			name: "WORKER_HEARTBEAT_INTERVAL not parsable as duration",
+			setup: func() {
+				t.Setenv(
+					"DEFAULT_WORKER_IMAGE_PULL_POLICY",
+					string(testDefaultWorkerImagePullPolicy),
+				)
+				t.Setenv("WORKER_HEARTBEAT_INTERVAL", "foo")
+			},
+			assertions: func(_ local.SubstrateConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a duration")
+				require.Contains(t, err.Error(), "WORKER_HEARTBEAT_INTERVAL")
+			},
+		},
+		{
+			name: "success",
+			setup: func() {
+				t.Setenv("WORKER_HEARTBEAT_INTERVAL", "5s")
+				t.Setenv("LOCAL_SUBSTRATE_NETWORK", testNetwork)
+			},
+			assertions: func(config local.SubstrateConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(t, testBrigadeID, config.BrigadeID)
+				require.Equal(t, testAPIAddress, config.APIAddress)
+				require.Equal(t, testRootDirectory, config.RootDirectory)
+				require.Equal(t, testNetwork, config.Network)
+				require.Equal(t, testGitInitializerImage, config.GitInitializerImage)
+				require.Equal(
+					t,
+					testGitInitializerImagePullPolicy,
+					config.GitInitializerImagePullPolicy,
+				)
+				require.Equal(t, testDefaultWorkerImage, config.DefaultWorkerImage)
+				require.Equal(
+					t,
+					testDefaultWorkerImagePullPolicy,
+					config.DefaultWorkerImagePullPolicy,
+				)
+				require.Equal(t, 5*time.Second, config.WorkerHeartbeatInterval)
+				require.Nil(t, config.DefaultImagePolicy)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.setup()
+			config, err := localSubstrateConfig()
+			testCase.assertions(config, err)
+		})
+	}
+}
+
 func TestThirdPartyAuthHelper(t *testing.T) {
 	// Set up test OIDC auth server
 	server := httptest.NewServer(
@@ -582,6 +1160,49 @@ func TestSessionsServiceConfig(t *testing.T) {
 	}
 }
 
+func TestJobsServiceConfig(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func()
+		assertions func(api.JobsServiceConfig, error)
+	}{
+		{
+			name: "MAX_MATRIX_JOBS not parsable as int",
+			setup: func() {
+				t.Setenv("MAX_MATRIX_JOBS", "lots")
+			},
+			assertions: func(_ api.JobsServiceConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as an int")
+				require.Contains(t, err.Error(), "MAX_MATRIX_JOBS")
+			},
+		},
+		{
+			name: "success",
+			setup: func() {
+				t.Setenv("MAX_MATRIX_JOBS", "50")
+			},
+			assertions: func(config api.JobsServiceConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					api.JobsServiceConfig{
+						MaxMatrixJobs: 50,
+					},
+					config,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.setup()
+			config, err := jobsServiceConfig()
+			testCase.assertions(config, err)
+		})
+	}
+}
+
 func TestUsersServiceConfig(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -260,6 +260,10 @@ type EventsService interface {
 	// If no such event is found, implementations MUST return a *meta.ErrNotFound
 	// error.
 	GetByWorkerToken(context.Context, string) (Event, error)
+	// GetByJobToken retrieves a single Event, and the name of one of its Jobs,
+	// specified by that Job's token. If no such event is found,
+	// implementations MUST return a *meta.ErrNotFound error.
+	GetByJobToken(context.Context, string) (Event, string, error)
 	// Clones an Event and creates a new Event after removing the original's
 	// metadata and Worker configuration
 	Clone(context.Context, string) (Event, error)
@@ -282,6 +286,19 @@ type EventsService interface {
 		context.Context,
 		EventsSelector,
 	) (CancelManyEventsResult, error)
+	// Suspend suspends a single Event specified by its identifier. While an
+	// Event is suspended, its Worker's existing Jobs continue to run, but no new
+	// Jobs are started. If no such event is found, implementations MUST return a
+	// *meta.ErrNotFound error. Implementations MUST only suspend events whose
+	// Workers are starting or running. Otherwise, implementations MUST return a
+	// *meta.ErrConflict.
+	Suspend(context.Context, string) error
+	// Resume resumes a single suspended Event specified by its identifier and
+	// releases, in the order they were created, any of its Worker's Jobs that
+	// are awaiting execution. If no such event is found, implementations MUST
+	// return a *meta.ErrNotFound error. If the specified Event is not
+	// suspended, implementations MUST return a *meta.ErrConflict.
+	Resume(context.Context, string) error
 	// Delete unconditionally deletes a single Event specified by its identifier.
 	// If no such event is found, implementations MUST return a *meta.ErrNotFound
 	// error.
@@ -529,6 +546,28 @@ func (e *eventsService) GetByWorkerToken(
 	return event, nil
 }
 
+func (e *eventsService) GetByJobToken(
+	ctx context.Context,
+	jobToken string,
+) (Event, string, error) {
+	// No authz is required here because this is only ever called by the system
+	// itself.
+
+	hashedToken := crypto.Hash("", jobToken)
+	event, err := e.eventsStore.GetByHashedJobToken(ctx, hashedToken)
+	if err != nil {
+		return event, "", errors.Wrap(err, "error retrieving event from store")
+	}
+	for _, job := range event.Worker.Jobs {
+		if job.HashedToken == hashedToken {
+			return event, job.Name, nil
+		}
+	}
+	return event, "", &meta.ErrNotFound{
+		Type: JobKind,
+	}
+}
+
 func (e *eventsService) Clone(
 	ctx context.Context,
 	id string,
@@ -637,6 +676,78 @@ func (e *eventsService) Cancel(ctx context.Context, id string) error {
 	return nil
 }
 
+func (e *eventsService) Suspend(ctx context.Context, id string) error {
+	event, err := e.eventsStore.Get(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving event %q from store", id)
+	}
+
+	if err =
+		e.projectAuthorize(ctx, event.ProjectID, RoleProjectUser); err != nil {
+		return err
+	}
+
+	return errors.Wrapf(
+		e.eventsStore.Suspend(ctx, id),
+		"error suspending event %q in store",
+		id,
+	)
+}
+
+func (e *eventsService) Resume(ctx context.Context, id string) error {
+	event, err := e.eventsStore.Get(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving event %q from store", id)
+	}
+
+	if err =
+		e.projectAuthorize(ctx, event.ProjectID, RoleProjectUser); err != nil {
+		return err
+	}
+
+	project, err := e.projectsStore.Get(ctx, event.ProjectID)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving project %q from store",
+			event.ProjectID,
+		)
+	}
+
+	if err = e.eventsStore.Resume(ctx, id); err != nil {
+		return errors.Wrapf(err, "error resuming event %q in store", id)
+	}
+
+	// Re-read the Event now that it has been resumed. Jobs created between the
+	// first read and the Event being resumed would otherwise never be
+	// scheduled.
+	if event, err = e.eventsStore.Get(ctx, id); err != nil {
+		return errors.Wrapf(err, "error retrieving event %q from store", id)
+	}
+
+	// Any Job that is still pending was either created while the Event was
+	// suspended or was held back by the scheduler while the Event was
+	// suspended. Either way, it needs to be (re-)scheduled. Jobs are scheduled
+	// in the order they were created. Scheduling a Job more than once is
+	// harmless because the scheduler ignores Jobs that are no longer pending.
+	for _, job := range event.Worker.Jobs {
+		if job.Status == nil || job.Status.Phase != JobPhasePending {
+			continue
+		}
+		if err =
+			e.substrate.ScheduleJob(ctx, project, event, job.Name); err != nil {
+			return errors.Wrapf(
+				err,
+				"error scheduling event %q job %q on the substrate",
+				id,
+				job.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
 func (e *eventsService) CancelMany(
 	ctx context.Context,
 	selector EventsSelector,
@@ -908,6 +1019,10 @@ type EventsStore interface {
 	// store by the provided hashed Worker token. If no such Event exists,
 	// implementations MUST return a *meta.ErrNotFound error.
 	GetByHashedWorkerToken(context.Context, string) (Event, error)
+	// GetByHashedJobToken retrieves a single Event from the underlying data
+	// store by the provided hashed token of one of its Jobs. If no such Event
+	// exists, implementations MUST return a *meta.ErrNotFound error.
+	GetByHashedJobToken(context.Context, string) (Event, error)
 	// UpdateSourceState updates source-specific (e.g. gateway-specific) Event
 	// state. Implementations MAY assume the Event's existence has been
 	// pre-confirmed by the caller.
@@ -927,6 +1042,18 @@ type EventsStore interface {
 	// already reached a terminal state and MUST return the total number of
 	// canceled events.
 	CancelMany(context.Context, EventsSelector) (<-chan Event, int64, error)
+	// Suspend updates the specified Event in the underlying data store to reflect
+	// that it has been suspended. Implementations MAY assume the Event's
+	// existence has been pre-confirmed by the caller. Implementations MUST only
+	// suspend events whose Workers are starting or running. Otherwise,
+	// implementations MUST return a *meta.ErrConflict.
+	Suspend(context.Context, string) error
+	// Resume updates the specified Event in the underlying data store to reflect
+	// that it has been resumed. Implementations MAY assume the Event's existence
+	// has been pre-confirmed by the caller. Implementations MUST only resume
+	// events whose Workers are suspended. Otherwise, implementations MUST return
+	// a *meta.ErrConflict.
+	Resume(context.Context, string) error
 	// Delete unconditionally deletes the specified Event from the underlying data
 	// store. If the specified Event does not exist, implementations MUST
 	// return a *meta.ErrNotFound error.
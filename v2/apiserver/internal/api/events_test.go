@@ -606,6 +606,99 @@ func TestEventsServiceGetByWorkerToken(t *testing.T) {
 	}
 }
 
+func TestEventsServiceGetByJobToken(t *testing.T) {
+	const testToken = "foobar"
+	testCases := []struct {
+		name       string
+		service    EventsService
+		assertions func(jobName string, err error)
+	}{
+		{
+			name: "error getting event from store",
+			service: &eventsService{
+				eventsStore: &mockEventsStore{
+					GetByHashedJobTokenFn: func(
+						context.Context,
+						string,
+					) (Event, error) {
+						return Event{}, errors.New("error getting event")
+					},
+				},
+			},
+			assertions: func(_ string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error getting event")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "job not found",
+			service: &eventsService{
+				eventsStore: &mockEventsStore{
+					GetByHashedJobTokenFn: func(
+						context.Context,
+						string,
+					) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name:        "foo",
+										HashedToken: "not-the-right-hash",
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(_ string, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+		{
+			name: "success",
+			service: &eventsService{
+				eventsStore: &mockEventsStore{
+					GetByHashedJobTokenFn: func(
+						_ context.Context,
+						hashedToken string,
+					) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name:        "foo",
+										HashedToken: "not-the-right-hash",
+									},
+									{
+										Name:        "bar",
+										HashedToken: hashedToken,
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(jobName string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "bar", jobName)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, jobName, err := testCase.service.GetByJobToken(
+				context.Background(),
+				testToken,
+			)
+			testCase.assertions(jobName, err)
+		})
+	}
+}
+
 func TestEventsServiceClone(t *testing.T) {
 	testEventID := "123456789"
 	testCases := []struct {
@@ -1194,6 +1287,337 @@ func TestEventsServiceCancelMany(t *testing.T) {
 	}
 }
 
+func TestEventsServiceSuspend(t *testing.T) {
+	const testEventID = "123456789"
+	testCases := []struct {
+		name       string
+		service    EventsService
+		assertions func(error)
+	}{
+		{
+			name: "error retrieving event from store",
+			service: &eventsService{
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("events store error")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error retrieving event")
+				require.Contains(t, err.Error(), "events store error")
+			},
+		},
+		{
+			name: "unauthorized",
+			service: &eventsService{
+				projectAuthorize: neverProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error suspending event in store",
+			service: &eventsService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+					SuspendFn: func(context.Context, string) error {
+						return errors.New("events store error")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error suspending event")
+				require.Contains(t, err.Error(), "events store error")
+			},
+		},
+		{
+			name: "success",
+			service: &eventsService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+					SuspendFn: func(context.Context, string) error {
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.service.Suspend(context.Background(), testEventID)
+			testCase.assertions(err)
+		})
+	}
+}
+
+func TestEventsServiceResume(t *testing.T) {
+	const testEventID = "123456789"
+	testEvent := Event{
+		Worker: Worker{
+			Jobs: []Job{
+				{
+					Name: "foo",
+					Status: &JobStatus{
+						Phase: JobPhaseRunning,
+					},
+				},
+				{
+					Name: "bar",
+					Status: &JobStatus{
+						Phase: JobPhasePending,
+					},
+				},
+				{
+					Name: "bat",
+					Status: &JobStatus{
+						Phase: JobPhasePending,
+					},
+				},
+			},
+		},
+	}
+	// A Job may be created between the Event first being read and the Event
+	// being resumed. It must be scheduled too.
+	resumedEvent := testEvent
+	resumedEvent.Worker.Jobs = append(
+		append([]Job{}, testEvent.Worker.Jobs...),
+		Job{
+			Name: "baz",
+			Status: &JobStatus{
+				Phase: JobPhasePending,
+			},
+		},
+	)
+	testCases := []struct {
+		name       string
+		service    func(scheduled *[]string) EventsService
+		assertions func(scheduled []string, err error)
+	}{
+		{
+			name: "error retrieving event from store",
+			service: func(*[]string) EventsService {
+				return &eventsService{
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							return Event{}, errors.New("events store error")
+						},
+					},
+				}
+			},
+			assertions: func(_ []string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error retrieving event")
+				require.Contains(t, err.Error(), "events store error")
+			},
+		},
+		{
+			name: "unauthorized",
+			service: func(*[]string) EventsService {
+				return &eventsService{
+					projectAuthorize: neverProjectAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							return Event{}, nil
+						},
+					},
+				}
+			},
+			assertions: func(_ []string, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error retrieving project from store",
+			service: func(*[]string) EventsService {
+				return &eventsService{
+					projectAuthorize: alwaysProjectAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							return Event{}, nil
+						},
+					},
+					projectsStore: &mockProjectsStore{
+						GetFn: func(context.Context, string) (Project, error) {
+							return Project{}, errors.New("projects store error")
+						},
+					},
+				}
+			},
+			assertions: func(_ []string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error retrieving project")
+				require.Contains(t, err.Error(), "projects store error")
+			},
+		},
+		{
+			name: "error resuming event in store",
+			service: func(*[]string) EventsService {
+				return &eventsService{
+					projectAuthorize: alwaysProjectAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							return testEvent, nil
+						},
+						ResumeFn: func(context.Context, string) error {
+							return errors.New("events store error")
+						},
+					},
+					projectsStore: &mockProjectsStore{
+						GetFn: func(context.Context, string) (Project, error) {
+							return Project{}, nil
+						},
+					},
+				}
+			},
+			assertions: func(_ []string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error resuming event")
+				require.Contains(t, err.Error(), "events store error")
+			},
+		},
+		{
+			name: "error retrieving resumed event from store",
+			service: func(*[]string) EventsService {
+				var resumed bool
+				return &eventsService{
+					projectAuthorize: alwaysProjectAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							if resumed {
+								return Event{}, errors.New("events store error")
+							}
+							return testEvent, nil
+						},
+						ResumeFn: func(context.Context, string) error {
+							resumed = true
+							return nil
+						},
+					},
+					projectsStore: &mockProjectsStore{
+						GetFn: func(context.Context, string) (Project, error) {
+							return Project{}, nil
+						},
+					},
+				}
+			},
+			assertions: func(_ []string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error retrieving event")
+				require.Contains(t, err.Error(), "events store error")
+			},
+		},
+		{
+			name: "error scheduling job",
+			service: func(*[]string) EventsService {
+				return &eventsService{
+					projectAuthorize: alwaysProjectAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							return testEvent, nil
+						},
+						ResumeFn: func(context.Context, string) error {
+							return nil
+						},
+					},
+					projectsStore: &mockProjectsStore{
+						GetFn: func(context.Context, string) (Project, error) {
+							return Project{}, nil
+						},
+					},
+					substrate: &mockSubstrate{
+						ScheduleJobFn: func(
+							context.Context,
+							Project,
+							Event,
+							string,
+						) error {
+							return errors.New("substrate error")
+						},
+					},
+				}
+			},
+			assertions: func(_ []string, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error scheduling event")
+				require.Contains(t, err.Error(), "substrate error")
+			},
+		},
+		{
+			name: "success",
+			service: func(scheduled *[]string) EventsService {
+				var resumed bool
+				return &eventsService{
+					projectAuthorize: alwaysProjectAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							if resumed {
+								return resumedEvent, nil
+							}
+							return testEvent, nil
+						},
+						ResumeFn: func(context.Context, string) error {
+							resumed = true
+							return nil
+						},
+					},
+					projectsStore: &mockProjectsStore{
+						GetFn: func(context.Context, string) (Project, error) {
+							return Project{}, nil
+						},
+					},
+					substrate: &mockSubstrate{
+						ScheduleJobFn: func(
+							_ context.Context,
+							_ Project,
+							_ Event,
+							jobName string,
+						) error {
+							*scheduled = append(*scheduled, jobName)
+							return nil
+						},
+					},
+				}
+			},
+			assertions: func(scheduled []string, err error) {
+				require.NoError(t, err)
+				// Only pending jobs should have been scheduled, in order, including
+				// any created before the event was resumed
+				require.Equal(t, []string{"bar", "bat", "baz"}, scheduled)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			scheduled := []string{}
+			err := testCase.service(&scheduled).Resume(
+				context.Background(),
+				testEventID,
+			)
+			testCase.assertions(scheduled, err)
+		})
+	}
+}
+
 func TestEventsServiceDelete(t *testing.T) {
 	const testEventID = "123456789"
 	testCases := []struct {
@@ -1857,6 +2281,7 @@ type mockEventsStore struct {
 	) (meta.List[Event], error)
 	GetFn                    func(context.Context, string) (Event, error)
 	GetByHashedWorkerTokenFn func(context.Context, string) (Event, error)
+	GetByHashedJobTokenFn    func(context.Context, string) (Event, error)
 	UpdateSourceStateFn      func(context.Context, string, SourceState) error
 	UpdateSummaryFn          func(context.Context, string, EventSummary) error
 	CancelFn                 func(context.Context, string) error
@@ -1864,6 +2289,8 @@ type mockEventsStore struct {
 		context.Context,
 		EventsSelector,
 	) (<-chan Event, int64, error)
+	SuspendFn    func(context.Context, string) error
+	ResumeFn     func(context.Context, string) error
 	DeleteFn     func(context.Context, string) error
 	DeleteManyFn func(
 		context.Context,
@@ -1895,6 +2322,13 @@ func (m *mockEventsStore) GetByHashedWorkerToken(
 	return m.GetByHashedWorkerTokenFn(ctx, hashedToken)
 }
 
+func (m *mockEventsStore) GetByHashedJobToken(
+	ctx context.Context,
+	hashedToken string,
+) (Event, error) {
+	return m.GetByHashedJobTokenFn(ctx, hashedToken)
+}
+
 func (m *mockEventsStore) UpdateSourceState(
 	ctx context.Context,
 	id string,
@@ -1922,6 +2356,14 @@ func (m *mockEventsStore) CancelMany(
 	return m.CancelManyFn(ctx, selector)
 }
 
+func (m *mockEventsStore) Suspend(ctx context.Context, id string) error {
+	return m.SuspendFn(ctx, id)
+}
+
+func (m *mockEventsStore) Resume(ctx context.Context, id string) error {
+	return m.ResumeFn(ctx, id)
+}
+
 func (m *mockEventsStore) Delete(ctx context.Context, id string) error {
 	return m.DeleteFn(ctx, id)
 }
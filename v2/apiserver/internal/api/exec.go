@@ -0,0 +1,356 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ExecSelector represents useful criteria for selecting a container belonging
+// to some Worker OR a container belonging to a Job spawned by that Worker in
+// which to execute a command.
+type ExecSelector struct {
+	// Job specifies, by name, a Job spawned by some Worker. If not specified,
+	// exec operations presume a container belonging to the Worker itself is
+	// desired.
+	Job string
+	// Container specifies, by name, a container belonging to some Worker or, if
+	// Job is specified, that Job. If not specified, exec operations presume the
+	// container having the same name as the selected Worker or Job is desired.
+	Container string
+}
+
+// ExecOptions represents useful options for executing a command within some
+// container of a Worker or Job.
+type ExecOptions struct {
+	// Command is the command to execute, including any arguments.
+	Command []string
+	// TTY indicates whether the command should be executed with a pseudo
+	// terminal allocated.
+	TTY bool
+}
+
+// TerminalSize represents the width and height of a terminal.
+type TerminalSize struct {
+	// Width is the width of the terminal, in characters.
+	Width uint16 `json:"width"`
+	// Height is the height of the terminal, in characters.
+	Height uint16 `json:"height"`
+}
+
+// ExecStreams represents the streams to which a command executed within a
+// container is attached.
+type ExecStreams struct {
+	// Stdin is the command's standard input.
+	Stdin io.Reader
+	// Stdout is the command's standard output.
+	Stdout io.Writer
+	// Stderr is the command's standard error. It is unused if a pseudo terminal
+	// was requested, in which case all output is written to Stdout.
+	Stderr io.Writer
+	// TerminalSizes is an optional channel over which changes to the size of
+	// the client's terminal are communicated. It is only meaningful if a pseudo
+	// terminal was requested.
+	TerminalSizes <-chan TerminalSize
+}
+
+// ExecExitError represents the non-zero exit code of a command executed within
+// a container.
+type ExecExitError struct {
+	// ExitCode is the command's exit code.
+	ExitCode int
+}
+
+func (e *ExecExitError) Error() string {
+	return fmt.Sprintf("command terminated with exit code %d", e.ExitCode)
+}
+
+// ExecAuditRecordKind represents the canonical ExecAuditRecord kind string
+const ExecAuditRecordKind = "ExecAuditRecord"
+
+// ExecAuditRecord is a durable record of a single exec session, including who
+// opened it, in which container, which command was executed, and how the
+// session ended.
+type ExecAuditRecord struct {
+	// ID is a unique identifier for the exec session.
+	ID string `json:"id" bson:"id"`
+	// Principal references the principal who opened the exec session.
+	Principal PrincipalReference `json:"principal" bson:"principal"`
+	// ProjectID is the identifier of the Project to which the Event belongs.
+	ProjectID string `json:"projectID" bson:"projectID"`
+	// EventID is the identifier of the Event whose Worker or Job the command
+	// was executed in.
+	EventID string `json:"eventID" bson:"eventID"`
+	// Job is the name of the Job the command was executed in. It is empty if
+	// the command was executed in a container of the Worker.
+	Job string `json:"job,omitempty" bson:"job,omitempty"`
+	// Container is the name of the container the command was executed in.
+	Container string `json:"container" bson:"container"`
+	// Command is the command that was executed, including any arguments.
+	Command []string `json:"command" bson:"command"`
+	// TTY indicates whether a pseudo terminal was allocated.
+	TTY bool `json:"tty" bson:"tty"`
+	// Started is the time at which the exec session began.
+	Started time.Time `json:"started" bson:"started"`
+	// Ended is the time at which the exec session ended. It is nil while the
+	// session is still in progress or if the end of the session could not be
+	// recorded.
+	Ended *time.Time `json:"ended,omitempty" bson:"ended,omitempty"`
+	// ExitCode is the exit code of the command that was executed. It is nil
+	// until the session has ended. It is -1 if the command could not be
+	// executed or its exit code could not be determined.
+	ExitCode *int `json:"exitCode,omitempty" bson:"exitCode,omitempty"`
+	// Error describes why the command could not be executed or its exit code
+	// could not be determined, if applicable.
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// ExecSession represents a command that is ready to be executed within a
+// container. Invoking it executes the command with the specified streams
+// attached and blocks until the command exits. If the command exits with a
+// non-zero exit code, an *ExecExitError is returned.
+type ExecSession func(ctx context.Context, streams ExecStreams) error
+
+// ExecService is the specialized interface for executing commands within the
+// containers of running Workers and Jobs for debugging purposes. It's
+// decoupled from underlying technology choices (e.g. data store, message bus,
+// etc.) to keep business logic reusable and consistent while the underlying
+// tech stack remains free to change.
+type ExecService interface {
+	// Exec, given an Event identifier, ExecSelector, and ExecOptions, returns
+	// an ExecSession that can be invoked to execute a command within a container
+	// of the Event's Worker or of a Job spawned by that Worker. If the specified
+	// Event, Job, or Container thereof does not exist, implementations MUST
+	// return a *meta.ErrNotFound error. If the specified Worker or Job is not
+	// running, implementations MUST return a *meta.ErrConflict error.
+	Exec(
+		ctx context.Context,
+		eventID string,
+		selector ExecSelector,
+		opts ExecOptions,
+	) (ExecSession, error)
+}
+
+type execService struct {
+	projectAuthorize ProjectAuthorizeFn
+	projectsStore    ProjectsStore
+	eventsStore      EventsStore
+	execAuditStore   ExecAuditStore
+	executor         Executor
+}
+
+// NewExecService returns a specialized interface for executing commands within
+// the containers of running Workers and Jobs.
+func NewExecService(
+	projectAuthorize ProjectAuthorizeFn,
+	projectsStore ProjectsStore,
+	eventsStore EventsStore,
+	execAuditStore ExecAuditStore,
+	executor Executor,
+) ExecService {
+	return &execService{
+		projectAuthorize: projectAuthorize,
+		projectsStore:    projectsStore,
+		eventsStore:      eventsStore,
+		execAuditStore:   execAuditStore,
+		executor:         executor,
+	}
+}
+
+// nolint: gocyclo
+func (e *execService) Exec(
+	ctx context.Context,
+	eventID string,
+	selector ExecSelector,
+	opts ExecOptions,
+) (ExecSession, error) {
+	// Set defaults on the selector
+	if selector.Container == "" {
+		if selector.Job == "" {
+			// If a container isn't specified, we want the one named "worker"
+			selector.Container = myk8s.LabelKeyWorker
+		} else {
+			// If a container isn't specified, we want the primary container. The
+			// primary container has the same name as the job itself.
+			selector.Container = selector.Job
+		}
+	}
+
+	if len(opts.Command) == 0 {
+		return nil, &meta.ErrBadRequest{
+			Reason: "No command was specified.",
+		}
+	}
+
+	event, err := e.eventsStore.Get(ctx, eventID)
+	if err != nil {
+		return nil,
+			errors.Wrapf(err, "error retrieving event %q from store", eventID)
+	}
+
+	// Executing arbitrary commands within a Worker or Job's containers grants
+	// access to everything those containers can access, including secrets, so
+	// this requires the principal to be a project developer.
+	if err = e.projectAuthorize(
+		ctx,
+		event.ProjectID,
+		RoleProjectDeveloper,
+	); err != nil {
+		return nil, err
+	}
+
+	if selector.Job == "" {
+		// If we're here, we want a worker container.
+		if selector.Container != myk8s.LabelKeyWorker {
+			return nil, &meta.ErrNotFound{
+				Type: "WorkerContainer",
+				ID:   selector.Container,
+			}
+		}
+		if event.Worker.Status.Phase != WorkerPhaseRunning {
+			return nil, &meta.ErrConflict{
+				Type: "Worker",
+				ID:   event.ID,
+				Reason: fmt.Sprintf(
+					"Event %q worker is not running.",
+					event.ID,
+				),
+			}
+		}
+	} else {
+		// If we're here, we want a container of a specific job. Make sure that job
+		// exists.
+		job, ok := event.Worker.Job(selector.Job)
+		if !ok {
+			return nil, &meta.ErrNotFound{
+				Type: JobKind,
+				ID:   selector.Job,
+			}
+		}
+		// And make sure the container exists. Only the primary container and
+		// sidecar containers can still be running while the job is.
+		_, containerFound := job.Spec.SidecarContainers[selector.Container]
+		if selector.Container != job.Name && !containerFound {
+			return nil, &meta.ErrNotFound{
+				Type: "JobContainer",
+				ID:   selector.Container,
+			}
+		}
+		if job.Status == nil || job.Status.Phase != JobPhaseRunning {
+			return nil, &meta.ErrConflict{
+				Type: JobKind,
+				ID:   job.Name,
+				Reason: fmt.Sprintf(
+					"Event %q job %q is not running.",
+					event.ID,
+					job.Name,
+				),
+			}
+		}
+	}
+
+	project, err := e.projectsStore.Get(ctx, event.ProjectID)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error retrieving project %q from store",
+			event.ProjectID,
+		)
+	}
+
+	principal, _ := principalReferenceFromContext(ctx)
+
+	return func(ctx context.Context, streams ExecStreams) error {
+		// Every exec session is audited. If the beginning of the session cannot
+		// be recorded, the command is not executed.
+		record := ExecAuditRecord{
+			ID:        uuid.NewV4().String(),
+			Principal: principal,
+			ProjectID: event.ProjectID,
+			EventID:   event.ID,
+			Job:       selector.Job,
+			Container: selector.Container,
+			Command:   opts.Command,
+			TTY:       opts.TTY,
+			Started:   time.Now().UTC(),
+		}
+		if err := e.execAuditStore.Create(ctx, record); err != nil {
+			return errors.Wrapf(
+				err,
+				"error recording beginning of exec session for event %q",
+				event.ID,
+			)
+		}
+		err := e.executor.Exec(ctx, project, event, selector, opts, streams)
+		exitCode := 0
+		var errMsg string
+		if exitErr, ok := err.(*ExecExitError); ok {
+			exitCode = exitErr.ExitCode
+		} else if err != nil {
+			exitCode = -1
+			errMsg = err.Error()
+		}
+		// The context may have been canceled by the client disconnecting, but the
+		// end of the session should still be recorded
+		if auditErr := e.execAuditStore.End(
+			context.Background(),
+			record.ID,
+			time.Now().UTC(),
+			exitCode,
+			errMsg,
+		); auditErr != nil {
+			log.Println(
+				errors.Wrapf(
+					auditErr,
+					"error recording end of exec session %q for event %q",
+					record.ID,
+					event.ID,
+				),
+			)
+		}
+		return err
+	}, nil
+}
+
+// ExecAuditStore is an interface for components that implement durable storage
+// of ExecAuditRecords.
+type ExecAuditStore interface {
+	// Create stores the provided ExecAuditRecord.
+	Create(ctx context.Context, record ExecAuditRecord) error
+	// End records the time at which the exec session identified by the provided
+	// identifier ended, along with the exit code of the command and, if
+	// applicable, a description of why the command could not be executed or
+	// its exit code could not be determined. Implementations MUST return a
+	// *meta.ErrNotFound error if no such record exists.
+	End(
+		ctx context.Context,
+		id string,
+		ended time.Time,
+		exitCode int,
+		errMsg string,
+	) error
+}
+
+// Executor is the interface for components that can execute commands within
+// the containers of running Workers and Jobs.
+type Executor interface {
+	// Exec executes a command within the container of the specified Event's
+	// Worker or of a Job spawned by that Worker, as indicated by the specified
+	// ExecSelector. It blocks until the command exits. If the command exits
+	// with a non-zero exit code, implementations MUST return an *ExecExitError.
+	Exec(
+		ctx context.Context,
+		project Project,
+		event Event,
+		selector ExecSelector,
+		opts ExecOptions,
+		streams ExecStreams,
+	) error
+}
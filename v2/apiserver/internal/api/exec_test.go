@@ -0,0 +1,507 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecExitError(t *testing.T) {
+	err := &ExecExitError{ExitCode: 42}
+	require.Equal(t, "command terminated with exit code 42", err.Error())
+}
+
+func TestNewExecService(t *testing.T) {
+	projectsStore := &mockProjectsStore{}
+	eventsStore := &mockEventsStore{}
+	execAuditStore := &mockExecAuditStore{}
+	executor := &mockExecutor{}
+	svc, ok := NewExecService(
+		alwaysProjectAuthorize,
+		projectsStore,
+		eventsStore,
+		execAuditStore,
+		executor,
+	).(*execService)
+	require.True(t, ok)
+	require.NotNil(t, svc.projectAuthorize)
+	require.Same(t, projectsStore, svc.projectsStore)
+	require.Same(t, eventsStore, svc.eventsStore)
+	require.Same(t, execAuditStore, svc.execAuditStore)
+	require.Same(t, executor, svc.executor)
+}
+
+func TestExecServiceExec(t *testing.T) {
+	const testEventID = "123456789"
+	const testProjectID = "italian"
+	testCommand := []string{"ls", "-al"}
+	testEventWithRunningWorker := Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: testEventID,
+		},
+		ProjectID: testProjectID,
+		Worker: Worker{
+			Status: WorkerStatus{
+				Phase: WorkerPhaseRunning,
+			},
+		},
+	}
+	testEventWithRunningJob := Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: testEventID,
+		},
+		ProjectID: testProjectID,
+		Worker: Worker{
+			Status: WorkerStatus{
+				Phase: WorkerPhaseRunning,
+			},
+			Jobs: []Job{
+				{
+					Name: "foo",
+					Spec: JobSpec{
+						SidecarContainers: map[string]JobContainerSpec{
+							"bar": {},
+						},
+					},
+					Status: &JobStatus{
+						Phase: JobPhaseRunning,
+					},
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		name       string
+		selector   ExecSelector
+		opts       ExecOptions
+		service    ExecService
+		assertions func(ExecSession, error)
+	}{
+		{
+			name: "no command specified",
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+			},
+		},
+		{
+			name: "error retrieving event from store",
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "unauthorized",
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: neverProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningWorker, nil
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "invalid worker container name",
+			selector: ExecSelector{
+				Container: "foo",
+			},
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningWorker, nil
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+				require.Equal(t, "WorkerContainer", err.(*meta.ErrNotFound).Type)
+			},
+		},
+		{
+			name: "worker not running",
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							ObjectMeta: meta.ObjectMeta{
+								ID: testEventID,
+							},
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: WorkerPhaseSucceeded,
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+			},
+		},
+		{
+			name: "job not found",
+			selector: ExecSelector{
+				Job: "nonexistent",
+			},
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningJob, nil
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+				require.Equal(t, JobKind, err.(*meta.ErrNotFound).Type)
+			},
+		},
+		{
+			name: "job container not found",
+			selector: ExecSelector{
+				Job:       "foo",
+				Container: "nonexistent",
+			},
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningJob, nil
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+				require.Equal(t, "JobContainer", err.(*meta.ErrNotFound).Type)
+			},
+		},
+		{
+			name: "job not running",
+			selector: ExecSelector{
+				Job: "foo",
+			},
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							ObjectMeta: meta.ObjectMeta{
+								ID: testEventID,
+							},
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: "foo",
+										Status: &JobStatus{
+											Phase: JobPhaseSucceeded,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+			},
+		},
+		{
+			name: "error retrieving project from store",
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningWorker, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(_ ExecSession, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving project")
+			},
+		},
+		{
+			name: "error recording beginning of exec session",
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningWorker, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				execAuditStore: &mockExecAuditStore{
+					CreateFn: func(context.Context, ExecAuditRecord) error {
+						return errors.New("something went wrong")
+					},
+				},
+				executor: &mockExecutor{
+					ExecFn: func(
+						context.Context,
+						Project,
+						Event,
+						ExecSelector,
+						ExecOptions,
+						ExecStreams,
+					) error {
+						require.Fail(t, "command should not have been executed")
+						return nil
+					},
+				},
+			},
+			assertions: func(session ExecSession, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, session)
+				err = session(context.Background(), ExecStreams{})
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(
+					t,
+					err.Error(),
+					"error recording beginning of exec session",
+				)
+			},
+		},
+		{
+			name: "success with worker container",
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningWorker, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{
+							ObjectMeta: meta.ObjectMeta{
+								ID: testProjectID,
+							},
+						}, nil
+					},
+				},
+				execAuditStore: &mockExecAuditStore{
+					CreateFn: func(_ context.Context, record ExecAuditRecord) error {
+						require.NotEmpty(t, record.ID)
+						require.Equal(t, testProjectID, record.ProjectID)
+						require.Equal(t, testEventID, record.EventID)
+						require.Empty(t, record.Job)
+						require.Equal(t, "worker", record.Container)
+						require.Equal(t, testCommand, record.Command)
+						require.False(t, record.Started.IsZero())
+						return nil
+					},
+					EndFn: func(
+						_ context.Context,
+						id string,
+						ended time.Time,
+						exitCode int,
+						errMsg string,
+					) error {
+						require.NotEmpty(t, id)
+						require.False(t, ended.IsZero())
+						require.Equal(t, 1, exitCode)
+						require.Empty(t, errMsg)
+						return nil
+					},
+				},
+				executor: &mockExecutor{
+					ExecFn: func(
+						_ context.Context,
+						project Project,
+						event Event,
+						selector ExecSelector,
+						opts ExecOptions,
+						_ ExecStreams,
+					) error {
+						require.Equal(t, testProjectID, project.ID)
+						require.Equal(t, testEventID, event.ID)
+						require.Equal(t, "worker", selector.Container)
+						require.Equal(t, testCommand, opts.Command)
+						return &ExecExitError{ExitCode: 1}
+					},
+				},
+			},
+			assertions: func(session ExecSession, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, session)
+				err = session(context.Background(), ExecStreams{})
+				require.Error(t, err)
+				require.IsType(t, &ExecExitError{}, err)
+				require.Equal(t, 1, err.(*ExecExitError).ExitCode)
+			},
+		},
+		{
+			name: "success with job sidecar container",
+			selector: ExecSelector{
+				Job:       "foo",
+				Container: "bar",
+			},
+			opts: ExecOptions{Command: testCommand},
+			service: &execService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testEventWithRunningJob, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				execAuditStore: &mockExecAuditStore{
+					CreateFn: func(_ context.Context, record ExecAuditRecord) error {
+						require.Equal(t, "foo", record.Job)
+						require.Equal(t, "bar", record.Container)
+						return nil
+					},
+					EndFn: func(
+						_ context.Context,
+						_ string,
+						_ time.Time,
+						exitCode int,
+						_ string,
+					) error {
+						require.Equal(t, 0, exitCode)
+						// Failing to record the end of the session should not affect the
+						// outcome
+						return errors.New("something went wrong")
+					},
+				},
+				executor: &mockExecutor{
+					ExecFn: func(
+						_ context.Context,
+						_ Project,
+						_ Event,
+						selector ExecSelector,
+						_ ExecOptions,
+						_ ExecStreams,
+					) error {
+						require.Equal(t, "foo", selector.Job)
+						require.Equal(t, "bar", selector.Container)
+						return nil
+					},
+				},
+			},
+			assertions: func(session ExecSession, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, session)
+				err = session(context.Background(), ExecStreams{})
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			session, err := testCase.service.Exec(
+				context.Background(),
+				testEventID,
+				testCase.selector,
+				testCase.opts,
+			)
+			testCase.assertions(session, err)
+		})
+	}
+}
+
+type mockExecutor struct {
+	ExecFn func(
+		ctx context.Context,
+		project Project,
+		event Event,
+		selector ExecSelector,
+		opts ExecOptions,
+		streams ExecStreams,
+	) error
+}
+
+func (m *mockExecutor) Exec(
+	ctx context.Context,
+	project Project,
+	event Event,
+	selector ExecSelector,
+	opts ExecOptions,
+	streams ExecStreams,
+) error {
+	return m.ExecFn(ctx, project, event, selector, opts, streams)
+}
+
+type mockExecAuditStore struct {
+	CreateFn func(context.Context, ExecAuditRecord) error
+	EndFn    func(
+		ctx context.Context,
+		id string,
+		ended time.Time,
+		exitCode int,
+		errMsg string,
+	) error
+}
+
+func (m *mockExecAuditStore) Create(
+	ctx context.Context,
+	record ExecAuditRecord,
+) error {
+	return m.CreateFn(ctx, record)
+}
+
+func (m *mockExecAuditStore) End(
+	ctx context.Context,
+	id string,
+	ended time.Time,
+	exitCode int,
+	errMsg string,
+) error {
+	return m.EndFn(ctx, id, ended, exitCode, errMsg)
+}
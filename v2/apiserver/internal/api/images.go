@@ -0,0 +1,160 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+)
+
+// defaultRegistry is the registry from which images are pulled when an image
+// reference does not explicitly specify one.
+const defaultRegistry = "docker.io"
+
+// imageDigestRegex matches the digest portion of an OCI image reference,
+// e.g. sha256:<hex>.
+var imageDigestRegex = regexp.MustCompile(`^[a-z0-9]+:[a-f0-9]{32,}$`)
+
+// ImagePolicy represents restrictions on the OCI images that Workers and Jobs
+// may use.
+type ImagePolicy struct {
+	// AllowedRegistries enumerates registries from which images may be pulled.
+	// Images from Docker Hub belong to the "docker.io" registry.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty" bson:"allowedRegistries,omitempty"` // nolint: lll
+	// AllowedRepositories enumerates repositories, in the form
+	// [REGISTRY/]REPOSITORY, from which images may be pulled. Images from
+	// repositories nested beneath an allowed repository are also permitted. When
+	// both AllowedRegistries and AllowedRepositories are empty, images may be
+	// pulled from anywhere.
+	AllowedRepositories []string `json:"allowedRepositories,omitempty" bson:"allowedRepositories,omitempty"` // nolint: lll
+	// RequireDigest specifies whether images must be pinned by digest, i.e.
+	// referenced in the form IMAGE@sha256:<digest>.
+	RequireDigest bool `json:"requireDigest,omitempty" bson:"requireDigest,omitempty"` // nolint: lll
+}
+
+// imagePolicies is an ordered list of ImagePolicies, all of which an image
+// must satisfy.
+type imagePolicies []*ImagePolicy
+
+// imagePolicy returns the imagePolicies that apply to a Worker and its Jobs.
+// The provided default ImagePolicy, which may be nil, is specified by the
+// operator and is a floor that no Worker configuration can escape. Any
+// ImagePolicy specified by the provided JobPolicies can only restrict images
+// further.
+func imagePolicy(
+	jobPolicies *JobPolicies,
+	defaultPolicy *ImagePolicy,
+) imagePolicies {
+	policies := imagePolicies{defaultPolicy}
+	if jobPolicies != nil && jobPolicies.Images != nil {
+		policies = append(policies, jobPolicies.Images)
+	}
+	return policies
+}
+
+// authorizeImage returns a *meta.ErrAuthorization error if any of the
+// imagePolicies does not permit the specified container to use the specified
+// image.
+func (i imagePolicies) authorizeImage(containerName, image string) error {
+	for _, policy := range i {
+		if err := policy.authorizeImage(containerName, image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizeImage returns a *meta.ErrAuthorization error if the ImagePolicy
+// does not permit the specified container to use the specified image. A nil
+// ImagePolicy permits any image.
+func (i *ImagePolicy) authorizeImage(containerName, image string) error {
+	if i == nil {
+		return nil
+	}
+	ref := parseImageReference(image)
+	if i.RequireDigest && !imageDigestRegex.MatchString(ref.digest) {
+		return &meta.ErrAuthorization{
+			Reason: fmt.Sprintf(
+				"Image policy forbids container %q from using image %q because it "+
+					"is not pinned by digest.",
+				containerName,
+				image,
+			),
+		}
+	}
+	if len(i.AllowedRegistries) == 0 && len(i.AllowedRepositories) == 0 {
+		return nil
+	}
+	for _, registry := range i.AllowedRegistries {
+		if normalizeRegistry(registry) == ref.registry {
+			return nil
+		}
+	}
+	for _, repository := range i.AllowedRepositories {
+		allowedRef := parseImageReference(repository)
+		if allowedRef.registry == ref.registry &&
+			(allowedRef.repository == ref.repository ||
+				strings.HasPrefix(ref.repository, allowedRef.repository+"/")) {
+			return nil
+		}
+	}
+	return &meta.ErrAuthorization{
+		Reason: fmt.Sprintf(
+			"Image policy forbids container %q from using image %q because it "+
+				"does not belong to an allowed registry or repository.",
+			containerName,
+			image,
+		),
+	}
+}
+
+// imageReference represents the components of an OCI image reference of the
+// form [REGISTRY/]REPOSITORY[:TAG][@DIGEST].
+type imageReference struct {
+	registry   string
+	repository string
+	digest     string
+}
+
+// parseImageReference breaks the provided OCI image reference into its
+// components. Following Docker conventions, the first path component is only
+// treated as a registry if it looks like a host name (i.e. it contains a "." or
+// a ":" or is "localhost"). Otherwise, the registry is "docker.io" and, if the
+// repository has only one path component, it is qualified with "library/".
+func parseImageReference(image string) imageReference {
+	ref := imageReference{
+		registry: defaultRegistry,
+	}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.digest = name[i+1:]
+		name = name[:i]
+	}
+	// Strip the tag, taking care not to mistake a registry's port for one
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		if host := name[:i]; strings.ContainsAny(host, ".:") ||
+			host == "localhost" {
+			ref.registry = normalizeRegistry(host)
+			name = name[i+1:]
+		}
+	}
+	if ref.registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = fmt.Sprintf("library/%s", name)
+	}
+	ref.repository = name
+	return ref
+}
+
+// normalizeRegistry returns a canonical form of the provided registry host
+// name so that equivalent names compare equal.
+func normalizeRegistry(registry string) string {
+	registry = strings.ToLower(registry)
+	if registry == "index.docker.io" || registry == "registry-1.docker.io" {
+		return defaultRegistry
+	}
+	return registry
+}
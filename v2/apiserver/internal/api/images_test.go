@@ -0,0 +1,267 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImagePolicy(t *testing.T) {
+	const testDigest = "sha256:" +
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	defaultPolicy := &ImagePolicy{
+		AllowedRegistries: []string{"ghcr.io"},
+	}
+	testCases := []struct {
+		name        string
+		jobPolicies *JobPolicies
+		image       string
+		allowed     bool
+	}{
+		{
+			name:    "no job policies; image allowed by default policy",
+			image:   "ghcr.io/foo/bar:latest",
+			allowed: true,
+		},
+		{
+			name:  "no job policies; image forbidden by default policy",
+			image: "debian:latest",
+		},
+		{
+			name:        "job policies without image policy",
+			jobPolicies: &JobPolicies{},
+			image:       "debian:latest",
+		},
+		{
+			name: "empty image policy does not override default policy",
+			jobPolicies: &JobPolicies{
+				Images: &ImagePolicy{},
+			},
+			image: "debian:latest",
+		},
+		{
+			name: "image allowed by image policy but not default policy",
+			jobPolicies: &JobPolicies{
+				Images: &ImagePolicy{
+					AllowedRegistries: []string{"docker.io"},
+				},
+			},
+			image: "debian:latest",
+		},
+		{
+			name: "image allowed by default policy but not image policy",
+			jobPolicies: &JobPolicies{
+				Images: &ImagePolicy{
+					AllowedRepositories: []string{"ghcr.io/foo/baz"},
+				},
+			},
+			image: "ghcr.io/foo/bar:latest",
+		},
+		{
+			name: "image policy requires digest",
+			jobPolicies: &JobPolicies{
+				Images: &ImagePolicy{
+					RequireDigest: true,
+				},
+			},
+			image: "ghcr.io/foo/bar:latest",
+		},
+		{
+			name: "image allowed by both policies",
+			jobPolicies: &JobPolicies{
+				Images: &ImagePolicy{
+					AllowedRepositories: []string{"ghcr.io/foo"},
+					RequireDigest:       true,
+				},
+			},
+			image:   "ghcr.io/foo/bar@" + testDigest,
+			allowed: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := imagePolicy(
+				testCase.jobPolicies,
+				defaultPolicy,
+			).authorizeImage("foo", testCase.image)
+			if testCase.allowed {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			}
+		})
+	}
+	t.Run("no default policy", func(t *testing.T) {
+		require.NoError(
+			t,
+			imagePolicy(nil, nil).authorizeImage("foo", "debian:latest"),
+		)
+	})
+}
+
+func TestImagePolicyAuthorizeImage(t *testing.T) {
+	const testDigest = "sha256:" +
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testCases := []struct {
+		name    string
+		policy  *ImagePolicy
+		image   string
+		allowed bool
+	}{
+		{
+			name:    "nil policy",
+			image:   "debian:latest",
+			allowed: true,
+		},
+		{
+			name:    "empty policy",
+			policy:  &ImagePolicy{},
+			image:   "debian:latest",
+			allowed: true,
+		},
+		{
+			name: "digest required but not used",
+			policy: &ImagePolicy{
+				RequireDigest: true,
+			},
+			image: "debian:latest",
+		},
+		{
+			name: "digest required and used",
+			policy: &ImagePolicy{
+				RequireDigest: true,
+			},
+			image:   "debian@" + testDigest,
+			allowed: true,
+		},
+		{
+			name: "registry allowed",
+			policy: &ImagePolicy{
+				AllowedRegistries: []string{"ghcr.io"},
+			},
+			image:   "ghcr.io/brigadecore/brigade2-worker:v2.0.0",
+			allowed: true,
+		},
+		{
+			name: "implicit docker hub registry allowed",
+			policy: &ImagePolicy{
+				AllowedRegistries: []string{"docker.io"},
+			},
+			image:   "debian:latest",
+			allowed: true,
+		},
+		{
+			name: "registry not allowed",
+			policy: &ImagePolicy{
+				AllowedRegistries: []string{"ghcr.io"},
+			},
+			image: "debian:latest",
+		},
+		{
+			name: "repository allowed",
+			policy: &ImagePolicy{
+				AllowedRepositories: []string{"ghcr.io/brigadecore"},
+			},
+			image:   "ghcr.io/brigadecore/brigade2-worker:v2.0.0",
+			allowed: true,
+		},
+		{
+			name: "docker hub official image repository allowed",
+			policy: &ImagePolicy{
+				AllowedRepositories: []string{"debian"},
+			},
+			image:   "docker.io/library/debian:latest",
+			allowed: true,
+		},
+		{
+			name: "repository with common prefix not allowed",
+			policy: &ImagePolicy{
+				AllowedRepositories: []string{"ghcr.io/brigadecore"},
+			},
+			image: "ghcr.io/brigadecore-fork/brigade2-worker:v2.0.0",
+		},
+		{
+			name: "repository in another registry not allowed",
+			policy: &ImagePolicy{
+				AllowedRepositories: []string{"ghcr.io/brigadecore"},
+			},
+			image: "brigadecore/brigade2-worker:v2.0.0",
+		},
+		{
+			name: "allowed repository, but digest required",
+			policy: &ImagePolicy{
+				AllowedRepositories: []string{"ghcr.io/brigadecore"},
+				RequireDigest:       true,
+			},
+			image: "ghcr.io/brigadecore/brigade2-worker:v2.0.0",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.policy.authorizeImage("foo", testCase.image)
+			if testCase.allowed {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.IsType(t, &meta.ErrAuthorization{}, err)
+			require.Contains(t, err.Error(), testCase.image)
+			require.Contains(t, err.Error(), `container "foo"`)
+		})
+	}
+}
+
+func TestParseImageReference(t *testing.T) {
+	testCases := []struct {
+		image       string
+		expectedRef imageReference
+	}{
+		{
+			image: "debian",
+			expectedRef: imageReference{
+				registry:   "docker.io",
+				repository: "library/debian",
+			},
+		},
+		{
+			image: "brigadecore/brigade2-worker:v2.0.0",
+			expectedRef: imageReference{
+				registry:   "docker.io",
+				repository: "brigadecore/brigade2-worker",
+			},
+		},
+		{
+			image: "index.docker.io/brigadecore/brigade2-worker",
+			expectedRef: imageReference{
+				registry:   "docker.io",
+				repository: "brigadecore/brigade2-worker",
+			},
+		},
+		{
+			image: "localhost:5000/brigade2-worker:v2.0.0",
+			expectedRef: imageReference{
+				registry:   "localhost:5000",
+				repository: "brigade2-worker",
+			},
+		},
+		{
+			image: "ghcr.io/brigadecore/brigade2-worker:v2.0.0@sha256:abc",
+			expectedRef: imageReference{
+				registry:   "ghcr.io",
+				repository: "brigadecore/brigade2-worker",
+				digest:     "sha256:abc",
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.image, func(t *testing.T) {
+			require.Equal(
+				t,
+				testCase.expectedRef,
+				parseImageReference(testCase.image),
+			)
+		})
+	}
+}
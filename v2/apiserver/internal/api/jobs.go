@@ -2,11 +2,19 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/brigadecore/brigade-foundations/crypto"
+	libCrypto "github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 )
@@ -14,6 +22,11 @@ import (
 // JobKind represents the canonical Job kind string
 const JobKind = "Job"
 
+var (
+	jobNameRegex             = regexp.MustCompile(`^[a-z][a-z\d-]*[a-z\d]$`)
+	jobNameInvalidCharsRegex = regexp.MustCompile(`[^a-z\d]+`)
+)
+
 // OSFamily represents a type of operating system.
 type OSFamily string
 
@@ -89,10 +102,68 @@ type Job struct {
 	Name string `json:"name" bson:"name"`
 	// Created indicates the time at which a Job was created.
 	Created *time.Time `json:"created,omitempty"`
+	// Template optionally specifies the name of one of the Project's Job
+	// templates. When non-empty, Spec is treated as a set of overrides to be
+	// applied to the template. Only fields actually present in the Job's JSON
+	// representation override the template, but any field that is present does
+	// so, even if its value is a zero value. Once the Job is created, Spec
+	// reflects the fully resolved result.
+	Template string `json:"template,omitempty" bson:"template,omitempty"`
+	// Matrix optionally specifies dimensions across which the Job should be
+	// fanned out. When non-nil, the Job is expanded, upon creation, into one Job
+	// per combination of dimension values and the Job itself is never created.
+	Matrix *JobMatrix `json:"matrix,omitempty" bson:"-"`
+	// Group is the name of the Job from whose matrix this Job was expanded. It
+	// is set by the system and is empty for Jobs that were not expanded from a
+	// matrix.
+	Group string `json:"group,omitempty" bson:"group,omitempty"`
+	// MatrixValues contains the combination of dimension values from which this
+	// Job was expanded. It is set by the system and is empty for Jobs that were
+	// not expanded from a matrix.
+	MatrixValues map[string]string `json:"matrixValues,omitempty" bson:"matrixValues,omitempty"` // nolint: lll
 	// Spec is the technical blueprint for the Job.
 	Spec JobSpec `json:"spec" bson:"spec"`
+	// CacheKey is a digest of the Job's spec and declared inputs. It is computed
+	// by the system only for Jobs having a cache policy and is used for locating
+	// reusable results from equivalent Jobs.
+	CacheKey string `json:"cacheKey,omitempty" bson:"cacheKey,omitempty"`
+	// HashedToken is a secure, one-way hash of the token issued to the Job when
+	// it was started. The token permits the Job to do nothing except report its
+	// own progress.
+	HashedToken string `json:"-" bson:"hashedToken,omitempty"`
 	// Status contains details of the Job's current state.
 	Status *JobStatus `json:"status" bson:"status"`
+
+	// specJSON is the JSON representation of Spec exactly as it was received.
+	// It permits fields that were absent to be distinguished from fields that
+	// were explicitly set to a zero value.
+	specJSON json.RawMessage
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for the Job type. It
+// retains the JSON representation of the Job's Spec exactly as it was
+// received.
+func (j *Job) UnmarshalJSON(data []byte) error {
+	type Alias Job
+	aux := struct {
+		*Alias `json:",inline"`
+		Spec   json.RawMessage `json:"spec"`
+	}{
+		Alias: (*Alias)(j),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	j.Spec = JobSpec{}
+	j.specJSON = json.RawMessage("{}")
+	if len(aux.Spec) == 0 || string(aux.Spec) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(aux.Spec, &j.Spec); err != nil {
+		return err
+	}
+	j.specJSON = aux.Spec
+	return nil
 }
 
 // UsesWorkspace returns a boolean value indicating whether or not the job
@@ -101,6 +172,11 @@ func (j Job) UsesWorkspace() bool {
 	if j.Spec.PrimaryContainer.WorkspaceMountPath != "" {
 		return true
 	}
+	for _, initContainer := range j.Spec.InitContainers {
+		if initContainer.WorkspaceMountPath != "" {
+			return true
+		}
+	}
 	for _, sidecarContainer := range j.Spec.SidecarContainers {
 		if sidecarContainer.WorkspaceMountPath != "" {
 			return true
@@ -124,6 +200,12 @@ type JobSpec struct {
 	// sidecar container), then logic within those containers must account for
 	// these constraints.
 	SidecarContainers map[string]JobContainerSpec `json:"sidecarContainers,omitempty" bson:"sidecarContainers,omitempty"` // nolint: lll
+	// InitContainers specifies the details of OCI containers that must each run
+	// to completion, one at a time and in the order specified, before the
+	// PrimaryContainer and any SidecarContainers are started. These are useful
+	// for tasks such as fetching credentials or warming caches. If any of these
+	// fails, the Job fails.
+	InitContainers []JobInitContainerSpec `json:"initContainers,omitempty" bson:"initContainers,omitempty"` // nolint: lll
 	// TimeoutDuration specifies the time duration that must elapse before a
 	// running Job should be considered to have timed out. This duration string
 	// is a sequence of decimal numbers, each with optional fraction and a unit
@@ -135,11 +217,23 @@ type JobSpec struct {
 	// non-default operating system (i.e. Windows) or specific hardware (e.g. a
 	// GPU.)
 	Host *JobHost `json:"host,omitempty" bson:"host,omitempty"`
+	// ServiceAccount optionally specifies the name of one of the Project's
+	// JobServiceAccounts that the Job should run as instead of the Project's
+	// default. This permits individual Jobs to assume a workload identity of
+	// their own. Any ServiceAccount a Job requests must be explicitly permitted
+	// by the Worker's JobPolicies.
+	ServiceAccount string `json:"serviceAccount,omitempty" bson:"serviceAccount,omitempty"` // nolint: lll
 	// Fallible specifies whether the job is permitted to fail WITHOUT causing the
 	// worker process to fail. The API server does not use this field directly,
 	// but it is information that may be valuable to gateways that report job
 	// success/failure upstream to original event sources.
 	Fallible bool `json:"fallible" bson:"fallible"`
+	// Cache optionally specifies a policy that permits the results of a
+	// previously succeeded, equivalent Job from any of the Project's Events to
+	// be reused in lieu of executing this Job. Jobs that reference any Project
+	// Secret never reuse results, since changes to the secret's value cannot be
+	// detected.
+	Cache *JobCachePolicy `json:"cache,omitempty" bson:"cache,omitempty"`
 }
 
 func (js JobSpec) EqualTo(js2 JobSpec) bool {
@@ -161,11 +255,31 @@ func (js JobSpec) EqualTo(js2 JobSpec) bool {
 	}
 	js.SidecarContainers, js2.SidecarContainers = nil, nil
 
-	// Compare Host; if equivalent, nil out
-	if js.Host == nil || js2.Host == nil {
-		if js.Host == nil && js2.Host == nil {
-			return reflect.DeepEqual(js, js2)
+	// Compare InitContainers slices; if equivalent, nil out
+	if len(js.InitContainers) != len(js2.InitContainers) {
+		return false
+	}
+	for i, initContainer := range js.InitContainers {
+		if initContainer.Name != js2.InitContainers[i].Name ||
+			!initContainer.JobContainerSpec.EqualTo(
+				js2.InitContainers[i].JobContainerSpec,
+			) {
+			return false
 		}
+	}
+	js.InitContainers, js2.InitContainers = nil, nil
+
+	// Compare Cache; if equivalent, nil out
+	if (js.Cache == nil) != (js2.Cache == nil) {
+		return false
+	}
+	if js.Cache != nil && !js.Cache.EqualTo(js2.Cache) {
+		return false
+	}
+	js.Cache, js2.Cache = nil, nil
+
+	// Compare Host; if equivalent, nil out
+	if !js.Host.EqualTo(js2.Host) {
 		return false
 	}
 	js.Host, js2.Host = nil, nil
@@ -196,6 +310,21 @@ type JobContainerSpec struct {
 	// for the container, but that may be disallowed by Project-level
 	// configuration.
 	Privileged bool `json:"privileged" bson:"privileged"`
+	// SecurityContext specifies security-related settings for the OCI container.
+	// These may be required by Project-level configuration.
+	SecurityContext *ContainerSecurityContext `json:"securityContext,omitempty" bson:"securityContext,omitempty"` // nolint: lll
+	// SecretEnvironment is a map of environment variable names to the keys of
+	// Project Secrets whose values should be assigned to them. Unlike values in
+	// the Environment field, these are referenced directly from the substrate's
+	// Secret storage and are never copied. Only the Project Secrets named here
+	// (or in SecretMounts) are made available to the Job.
+	SecretEnvironment map[string]string `json:"secretEnvironment,omitempty" bson:"secretEnvironment,omitempty"` // nolint: lll
+	// SecretMounts specifies Project Secrets whose values should be mounted as
+	// files into the OCI container's file system.
+	SecretMounts []SecretMount `json:"secretMounts,omitempty" bson:"secretMounts,omitempty"` // nolint: lll
+	// CacheVolumeMounts specifies which of the Project's cache volumes should be
+	// mounted into the OCI container's file system and where.
+	CacheVolumeMounts []CacheVolumeMount `json:"cacheVolumeMounts,omitempty" bson:"cacheVolumeMounts,omitempty"` // nolint: lll
 	// UseHostDockerSocket indicates whether the OCI container should mount the
 	// host's Docker socket into its own file system. This is commonly used to
 	// effect "Docker-out-of-Docker" ("DooD") scenarios wherein one of a Job's OCI
@@ -225,6 +354,163 @@ func (jcs JobContainerSpec) EqualTo(jcs2 JobContainerSpec) bool {
 	return reflect.DeepEqual(jcs, jcs2)
 }
 
+// JobInitContainerSpec amends the JobContainerSpec type with a name, since,
+// unlike sidecar containers, init containers are specified as an ordered list
+// instead of being keyed by name.
+type JobInitContainerSpec struct {
+	// Name is the name of the init container. It must be unique among all of a
+	// Job's containers.
+	Name string `json:"name" bson:"name"`
+	// JobContainerSpec encapsulates the specification of the init container.
+	JobContainerSpec `json:",inline" bson:",inline"`
+}
+
+// SeccompProfile represents a seccomp profile for an OCI container.
+type SeccompProfile string
+
+const (
+	// SeccompProfileRuntimeDefault represents the container runtime's default
+	// seccomp profile.
+	SeccompProfileRuntimeDefault SeccompProfile = "RuntimeDefault"
+	// SeccompProfileUnconfined represents the absence of any seccomp profile.
+	SeccompProfileUnconfined SeccompProfile = "Unconfined"
+)
+
+// ContainerSecurityContext represents security-related settings for an OCI
+// container.
+type ContainerSecurityContext struct {
+	// RunAsUser specifies the UID with which the OCI container's process should
+	// be run. When nil, the OCI image's default is used.
+	RunAsUser *int64 `json:"runAsUser,omitempty" bson:"runAsUser,omitempty"`
+	// RunAsGroup specifies the GID with which the OCI container's process should
+	// be run. When nil, the OCI image's default is used.
+	RunAsGroup *int64 `json:"runAsGroup,omitempty" bson:"runAsGroup,omitempty"`
+	// RunAsNonRoot indicates that the OCI container's process must not run as
+	// root. If it would, the OCI container will fail to start.
+	RunAsNonRoot bool `json:"runAsNonRoot,omitempty" bson:"runAsNonRoot,omitempty"` // nolint: lll
+	// ReadOnlyRootFilesystem indicates that the OCI container's root file system
+	// should be mounted read-only.
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty" bson:"readOnlyRootFilesystem,omitempty"` // nolint: lll
+	// DropCapabilities enumerates Linux capabilities (e.g. "NET_RAW") that
+	// should be dropped from the OCI container's process. The value "ALL" drops
+	// all capabilities.
+	DropCapabilities []string `json:"dropCapabilities,omitempty" bson:"dropCapabilities,omitempty"` // nolint: lll
+	// SeccompProfile specifies the seccomp profile for the OCI container. When
+	// empty, the substrate's default is used.
+	SeccompProfile SeccompProfile `json:"seccompProfile,omitempty" bson:"seccompProfile,omitempty"` // nolint: lll
+}
+
+// validateSecurityContext returns a *meta.ErrBadRequest error if the
+// specified ContainerSecurityContext is self-contradictory or if it, or the
+// container's request for privileged status, fails to meet any requirement of
+// the specified JobSecurityPolicy. Either pointer argument may be nil.
+// nolint: gocyclo
+func validateSecurityContext(
+	containerName string,
+	privileged bool,
+	sc *ContainerSecurityContext,
+	policy *JobSecurityPolicy,
+) error {
+	if sc == nil {
+		sc = &ContainerSecurityContext{}
+	}
+	violation := func(reason string) error {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf("Container %q %s.", containerName, reason),
+		}
+	}
+	// A privileged container can trivially circumvent every other requirement,
+	// so any security policy at all forbids it unless explicitly allowed.
+	if policy != nil && privileged && !policy.AllowPrivileged {
+		return violation(
+			"requests privileged status, but worker configuration's security " +
+				"policy does not allow privileged containers",
+		)
+	}
+	if policy == nil {
+		policy = &JobSecurityPolicy{}
+	}
+	runsAsRoot := sc.RunAsUser != nil && *sc.RunAsUser == 0
+	if sc.RunAsNonRoot && runsAsRoot {
+		return violation("requires running as non-root, but specifies UID 0")
+	}
+	if policy.RequireRunAsNonRoot {
+		if runsAsRoot {
+			return violation(
+				"specifies UID 0, but worker configuration requires jobs to run as " +
+					"non-root",
+			)
+		}
+		if !sc.RunAsNonRoot && sc.RunAsUser == nil {
+			return violation(
+				"must specify runAsNonRoot or a non-zero runAsUser because worker " +
+					"configuration requires jobs to run as non-root",
+			)
+		}
+	}
+	if policy.RequireReadOnlyRootFilesystem && !sc.ReadOnlyRootFilesystem {
+		return violation(
+			"must specify readOnlyRootFilesystem because worker configuration " +
+				"requires jobs to use a read-only root file system",
+		)
+	}
+	for _, capability := range policy.RequiredDroppedCapabilities {
+		if !Contains(sc.DropCapabilities, capability) &&
+			!Contains(sc.DropCapabilities, "ALL") {
+			return violation(
+				fmt.Sprintf(
+					"must drop capability %q because worker configuration requires it",
+					capability,
+				),
+			)
+		}
+	}
+	if policy.RequireRuntimeDefaultSeccompProfile &&
+		sc.SeccompProfile != SeccompProfileRuntimeDefault {
+		return violation(
+			fmt.Sprintf(
+				"must specify seccomp profile %q because worker configuration "+
+					"requires it",
+				SeccompProfileRuntimeDefault,
+			),
+		)
+	}
+	return nil
+}
+
+// SecretMount represents a request to mount the value of a Project Secret as a
+// file in an OCI container's file system.
+type SecretMount struct {
+	// Key is the key of a Project Secret.
+	Key string `json:"key" bson:"key"`
+	// MountPath specifies the path in the OCI container's file system of the
+	// file whose content will be the Project Secret's value.
+	MountPath string `json:"mountPath" bson:"mountPath"`
+}
+
+// secretKeys returns the keys of all Project Secrets referenced by the
+// JobContainerSpec.
+func (jcs JobContainerSpec) secretKeys() []string {
+	keys := make([]string, 0, len(jcs.SecretEnvironment)+len(jcs.SecretMounts))
+	for _, key := range jcs.SecretEnvironment {
+		keys = append(keys, key)
+	}
+	for _, secretMount := range jcs.SecretMounts {
+		keys = append(keys, secretMount.Key)
+	}
+	return keys
+}
+
+// CacheVolumeMount represents a request to mount one of a Project's cache
+// volumes into an OCI container's file system.
+type CacheVolumeMount struct {
+	// Name is the name of a cache volume defined by the Project.
+	Name string `json:"name" bson:"name"`
+	// MountPath specifies the path in the OCI container's file system where the
+	// cache volume should be mounted.
+	MountPath string `json:"mountPath" bson:"mountPath"`
+}
+
 // JobHost represents criteria for selecting a suitable host (substrate node)
 // for a Job.
 type JobHost struct {
@@ -236,21 +522,77 @@ type JobHost struct {
 	// host a Job. This provides an opaque mechanism for communicating Job needs
 	// such as specific hardware like an SSD or GPU.
 	NodeSelector map[string]string `json:"nodeSelector,omitempty" bson:"nodeSelector,omitempty"` // nolint: lll
+	// Cluster optionally routes a Job to one of the named Kubernetes clusters
+	// that an administrator has registered with the substrate, instead of the
+	// cluster that hosts the Job's Worker. A Job so routed cannot use the
+	// Worker's shared workspace.
+	Cluster string `json:"cluster,omitempty" bson:"cluster,omitempty"`
+	// SchedulingConstraints encapsulates additional criteria for selecting a
+	// suitable substrate node for a Job. Any of these that a Job requests must be
+	// explicitly permitted by the Worker's JobPolicies.
+	SchedulingConstraints `json:",inline" bson:",inline"`
 }
 
+// EqualTo returns a boolean indicating whether the JobHost is equivalent to
+// the specified JobHost. Either may be nil.
 func (jh *JobHost) EqualTo(jh2 *JobHost) bool {
+	if jh == nil || jh2 == nil {
+		return jh == nil && jh2 == nil
+	}
+	// Work with copies so that niling out fields doesn't modify the originals
+	h, h2 := *jh, *jh2
+
 	// Compare NodeSelector maps; if equivalent, nil out
-	if len(jh.NodeSelector) != len(jh2.NodeSelector) {
+	if len(h.NodeSelector) != len(h2.NodeSelector) {
 		return false
 	}
-	for k, v := range jh.NodeSelector {
-		if v != jh2.NodeSelector[k] {
+	for k, v := range h.NodeSelector {
+		if v2, ok := h2.NodeSelector[k]; !ok || v != v2 {
 			return false
 		}
 	}
-	jh.NodeSelector, jh2.NodeSelector = nil, nil
+	h.NodeSelector, h2.NodeSelector = nil, nil
 
-	return reflect.DeepEqual(jh, jh2)
+	// Compare SchedulingConstraints; if equivalent, zero out
+	if !h.SchedulingConstraints.EqualTo(h2.SchedulingConstraints) {
+		return false
+	}
+	h.SchedulingConstraints, h2.SchedulingConstraints =
+		SchedulingConstraints{}, SchedulingConstraints{}
+
+	return reflect.DeepEqual(h, h2)
+}
+
+// JobCachePolicy represents an opt-in policy for reusing the results of a
+// previously succeeded, equivalent Job.
+type JobCachePolicy struct {
+	// Inputs enumerates key/value pairs that, together with the JobSpec itself,
+	// determine the Job's cache key. These should capture anything the Job's
+	// results depend upon that is not apparent from the JobSpec, for instance
+	// the digest of an upstream Job's outputs.
+	Inputs map[string]string `json:"inputs,omitempty" bson:"inputs,omitempty"`
+}
+
+func (jcp *JobCachePolicy) EqualTo(jcp2 *JobCachePolicy) bool {
+	if len(jcp.Inputs) != len(jcp2.Inputs) {
+		return false
+	}
+	for k, v := range jcp.Inputs {
+		if v2, ok := jcp2.Inputs[k]; !ok || v != v2 {
+			return false
+		}
+	}
+	return true
+}
+
+// JobMatrix describes how a single Job should be fanned out into many Jobs--
+// one for each combination of the values of its dimensions.
+type JobMatrix struct {
+	// Dimensions maps the name of each dimension to that dimension's values.
+	// Within each expanded Job's image, command, arguments, and environment
+	// variable values, any occurrence of the placeholder ${matrix.<dimension>}
+	// is substituted with the corresponding value.
+	Dimensions map[string][]string `json:"dimensions" bson:"dimensions"`
 }
 
 // JobStatus represents the status of a Job.
@@ -266,8 +608,57 @@ type JobStatus struct {
 	// This is useful for looking up logs for an inherited job associated with
 	// retry events.
 	LogsEventID string `json:"logsEventID,omitempty" bson:"logsEventID,omitempty"`
+	// Outputs contains small key/value pairs published by the Job's primary
+	// container upon completion. These are readable by the Worker and may be
+	// relayed by the Worker to any subsequent Jobs.
+	Outputs map[string]string `json:"outputs,omitempty" bson:"outputs,omitempty"`
+	// Cached indicates that the Job was never executed because the results of
+	// an equivalent Job were reused instead.
+	Cached bool `json:"cached,omitempty" bson:"cached,omitempty"`
+	// InitContainers contains details of the current state of each of the Job's
+	// init containers, in the order they are executed.
+	InitContainers []JobInitContainerStatus `json:"initContainers,omitempty" bson:"initContainers,omitempty"` // nolint: lll
+	// Progress contains the most recent progress reported by the Job itself,
+	// if any.
+	Progress *JobProgress `json:"progress,omitempty" bson:"progress,omitempty"`
+	// Reason optionally explains, in human-readable terms, why the Job is in its
+	// current phase; for instance, why it was aborted.
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
 }
 
+// JobProgress represents progress toward completion, as reported by a running
+// Job.
+type JobProgress struct {
+	// Percent indicates the Job's progress toward completion as a percentage
+	// from 0 to 100.
+	Percent int `json:"percent" bson:"percent"`
+	// Message is an optional, short, human-readable description of the Job's
+	// progress.
+	Message string `json:"message,omitempty" bson:"message,omitempty"`
+	// Updated indicates the time at which progress was last reported. This is
+	// recorded by the system.
+	Updated *time.Time `json:"updated,omitempty" bson:"updated,omitempty"`
+}
+
+// JobInitContainerStatus represents the current state of one of a Job's init
+// containers.
+type JobInitContainerStatus struct {
+	// Name is the name of the init container.
+	Name string `json:"name" bson:"name"`
+	// Started indicates the time the init container began execution.
+	Started *time.Time `json:"started,omitempty" bson:"started,omitempty"`
+	// Ended indicates the time the init container concluded execution. It will
+	// be nil for an init container that is not done executing.
+	Ended *time.Time `json:"ended,omitempty" bson:"ended,omitempty"`
+	// Phase indicates where the init container is in its lifecycle.
+	Phase JobPhase `json:"phase,omitempty" bson:"phase,omitempty"`
+}
+
+// JobSpecValidateFn is the signature for any function that can validate a
+// fully resolved JobSpec. Implementations MUST return a *meta.ErrBadRequest
+// error if the JobSpec is invalid.
+type JobSpecValidateFn func(jobSpec JobSpec) error
+
 // JobsService is the specialized interface for managing Jobs. It's
 // decoupled from underlying technology choices (e.g. data store, message bus,
 // etc.) to keep business logic reusable and consistent while the underlying
@@ -277,12 +668,18 @@ type JobsService interface {
 	// it on Brigade's workload execution substrate. If the specified Event does
 	// not exist, implementations MUST return a *meta.ErrNotFound error. If the
 	// specified Event already has a Job with the specified name, implementations
-	// MUST return a *meta.ErrConflict error.
+	// MUST return a *meta.ErrConflict error. If the specified Event is
+	// suspended, implementations MUST create the Job, but MUST NOT schedule it
+	// until the Event is resumed. If the Job specifies a matrix, implementations
+	// MUST instead create and schedule one Job per combination of the matrix's
+	// dimension values and MUST return a *meta.ErrBadRequest error if the
+	// matrix cannot be expanded into uniquely and validly named Jobs.
 	Create(ctx context.Context, eventID string, job Job) error
 	// Start, given an Event identifier and Job name, starts that Job on
 	// Brigade's workload execution substrate. If the specified Event or specified
 	// Job thereof does not exist, implementations MUST return a *meta.ErrNotFound
-	// error.
+	// error. If the specified Event is suspended, implementations MUST return a
+	// *meta.ErrConflict error.
 	Start(ctx context.Context, eventID string, jobName string) error
 	// GetStatus, given an Event identifier and Job name, returns the Job's
 	// status. If the specified Event or specified Job thereof does not exist,
@@ -311,6 +708,25 @@ type JobsService interface {
 		jobName string,
 		status JobStatus,
 	) error
+	// UpdateProgress, given an Event identifier and Job name, records progress
+	// reported by that Job. Only the Job itself, using the token it was issued
+	// when it was started, may report its progress. If the specified Event or
+	// specified Job thereof does not exist, implementations MUST return a
+	// *meta.ErrNotFound error. If the Job is not running, implementations MUST
+	// return a *meta.ErrConflict error.
+	UpdateProgress(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		progress JobProgress,
+	) error
+	// Cancel, given an Event identifier and Job name, cancels that Job if it is
+	// pending or aborts it if it is already starting or running, and removes
+	// Job-related resources from the substrate. If the specified Event or
+	// specified Job thereof does not exist, implementations MUST return a
+	// *meta.ErrNotFound error. If the Job has already reached a terminal phase,
+	// implementations MUST return a *meta.ErrConflict error.
+	Cancel(ctx context.Context, eventID, jobName string) error
 	// Cleanup removes Job-related resources from the substrate, presumably
 	// upon completion, without deleting the Job from the data store.
 	Cleanup(ctx context.Context, eventID, jobName string) error
@@ -319,32 +735,48 @@ type JobsService interface {
 	Timeout(ctx context.Context, eventID, jobName string) error
 }
 
+// JobsServiceConfig encapsulates several configuration options for the
+// JobsService.
+type JobsServiceConfig struct {
+	// MaxMatrixJobs specifies the maximum number of Jobs that a single Job's
+	// matrix may be expanded into. A value of zero or less indicates no limit.
+	MaxMatrixJobs int
+}
+
 type jobsService struct {
-	authorize     AuthorizeFn
-	projectsStore ProjectsStore
-	eventsStore   EventsStore
-	jobsStore     JobsStore
-	substrate     Substrate
+	authorize        AuthorizeFn
+	projectAuthorize ProjectAuthorizeFn
+	validateJobSpec  JobSpecValidateFn
+	projectsStore    ProjectsStore
+	eventsStore      EventsStore
+	jobsStore        JobsStore
+	substrate        Substrate
+	config           JobsServiceConfig
 }
 
 // NewJobsService returns a specialized interface for managing Jobs.
 func NewJobsService(
 	authorizeFn AuthorizeFn,
+	projectAuthorize ProjectAuthorizeFn,
+	validateJobSpec JobSpecValidateFn,
 	projectsStore ProjectsStore,
 	eventsStore EventsStore,
 	jobsStore JobsStore,
 	substrate Substrate,
+	config JobsServiceConfig,
 ) JobsService {
 	return &jobsService{
-		authorize:     authorizeFn,
-		projectsStore: projectsStore,
-		eventsStore:   eventsStore,
-		jobsStore:     jobsStore,
-		substrate:     substrate,
+		authorize:        authorizeFn,
+		projectAuthorize: projectAuthorize,
+		validateJobSpec:  validateJobSpec,
+		projectsStore:    projectsStore,
+		eventsStore:      eventsStore,
+		jobsStore:        jobsStore,
+		substrate:        substrate,
+		config:           config,
 	}
 }
 
-// nolint: gocyclo
 func (j *jobsService) Create(
 	ctx context.Context,
 	eventID string,
@@ -358,15 +790,122 @@ func (j *jobsService) Create(
 	if err != nil {
 		return errors.Wrapf(err, "error retrieving event %q from store", eventID)
 	}
+
+	project, err := j.projectsStore.Get(ctx, event.ProjectID)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving project %q from store",
+			event.ProjectID,
+		)
+	}
+
+	jobs := []Job{job}
+	if job.Matrix != nil {
+		if jobs, err =
+			expandJobMatrix(job, j.config.MaxMatrixJobs); err != nil {
+			return err
+		}
+	}
+
+	// Resolve, validate, and authorize every Job before creating any of them so
+	// that a matrix is never left partially fanned out because one of the Jobs
+	// expanded from it was rejected.
+	preparedJobs := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		var create bool
+		if job, create, err = j.prepare(event, project, job); err != nil {
+			return err
+		}
+		if create {
+			preparedJobs = append(preparedJobs, job)
+		}
+	}
+
+	for _, job := range preparedJobs {
+		if err = j.create(ctx, event, project, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare resolves the specified Job's template and matrix values, then
+// validates the Job and authorizes it against worker configuration without
+// persisting anything. It returns the resolved Job and a bool indicating
+// whether the Job needs to be created. A Job that already exists in a retried
+// Event with an identical spec inherits the original's results instead.
+//
+// nolint: gocyclo
+func (j *jobsService) prepare(
+	event Event,
+	project Project,
+	job Job,
+) (Job, bool, error) {
+	var err error
+
+	// If the job references one of the project's job templates, apply the job's
+	// spec to the template as a set of overrides. Everything that follows,
+	// including comparison to the original job when this is a retry, operates
+	// on the fully resolved spec.
+	if job.Template != "" {
+		template, ok := project.Spec.JobTemplates[job.Template]
+		if !ok {
+			return job, false, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"The job references job template %q, but project %q does not "+
+						"define it.",
+					job.Template,
+					project.ID,
+				),
+			}
+		}
+		overrides := job.specJSON
+		if overrides == nil {
+			// The Job was not unmarshaled from JSON, so there is no telling which
+			// fields were deliberately set. Treat all of them as overrides.
+			if overrides, err = json.Marshal(job.Spec); err != nil {
+				return job, false, errors.Wrapf(
+					err,
+					"error marshaling spec of job %q",
+					job.Name,
+				)
+			}
+		}
+		if job.Spec, err = resolveJobSpec(template, overrides); err != nil {
+			return job, false, errors.Wrapf(
+				err,
+				"error resolving job %q from job template %q",
+				job.Name,
+				job.Template,
+			)
+		}
+	}
+
+	// A Job expanded from a matrix has its matrix values substituted only once
+	// its spec is fully resolved so that placeholders in the project's job
+	// templates are replaced as well.
+	if job.MatrixValues != nil {
+		job.Spec = substituteMatrixValues(job.Spec, job.MatrixValues)
+	}
+
+	// A Job's spec as submitted has already been validated, but resolving its
+	// template or substituting its matrix values may have rendered it invalid.
+	if job.Template != "" || job.MatrixValues != nil {
+		if err = j.validateJobSpec(job.Spec); err != nil {
+			return job, false, err
+		}
+	}
+
 	if originalJob, ok := event.Worker.Job(job.Name); ok {
 		// If this is not a retry event, return ErrConflict.
 		if event.Labels == nil || event.Labels[RetryLabelKey] == "" {
-			return &meta.ErrConflict{
+			return job, false, &meta.ErrConflict{
 				Type: JobKind,
 				ID:   job.Name,
 				Reason: fmt.Sprintf(
 					"Event %q already has a job named %q.",
-					eventID,
+					event.ID,
 					job.Name,
 				),
 			}
@@ -378,15 +917,15 @@ func (j *jobsService) Create(
 		// been inherited previously (LogsEventID field non-empty) -- return
 		// ErrConflict if it hasn't been inherited.
 		if originalJob.Spec.EqualTo(job.Spec) {
-			return nil
+			return job, false, nil
 		} else if originalJob.Status == nil ||
 			originalJob.Status.LogsEventID == "" {
-			return &meta.ErrConflict{
+			return job, false, &meta.ErrConflict{
 				Type: JobKind,
 				ID:   job.Name,
 				Reason: fmt.Sprintf(
 					"Event %q already has a non-inherited job named %q.",
-					eventID,
+					event.ID,
 					job.Name,
 				),
 			}
@@ -413,30 +952,154 @@ func (j *jobsService) Create(
 		// 	useDockerSocket = true
 		// }
 	}
+	for _, initContainer := range job.Spec.InitContainers {
+		if initContainer.WorkspaceMountPath != "" {
+			useWorkspace = true
+		}
+		if initContainer.Privileged {
+			usePrivileged = true
+		}
+	}
 
 	// Fail quickly if any job is trying to run privileged or use the host's
 	// Docker socket, but isn't allowed to per worker configuration.
 	if usePrivileged &&
 		(event.Worker.Spec.JobPolicies == nil ||
 			!event.Worker.Spec.JobPolicies.AllowPrivileged) {
-		return &meta.ErrAuthorization{
+		return job, false, &meta.ErrAuthorization{
 			Reason: "Worker configuration forbids jobs from utilizing privileged " +
 				"containers.",
 		}
 	}
+	// Fail quickly if any of the job's containers references a Project Secret
+	// that worker configuration does not permit jobs to use, if any container's
+	// Project Secrets would collide with its plain environment variables, or if
+	// any container's security context doesn't meet worker configuration's
+	// requirements.
+	containers := map[string]JobContainerSpec{
+		job.Name: job.Spec.PrimaryContainer,
+	}
+	for sidecarName, sidecarContainer := range job.Spec.SidecarContainers {
+		containers[sidecarName] = sidecarContainer
+	}
+	// Fail quickly if any init container's name is ambiguous. Note that "vcs"
+	// is reserved for the init container that retrieves source code.
+	for _, initContainer := range job.Spec.InitContainers {
+		if _, ok := containers[initContainer.Name]; ok ||
+			initContainer.Name == "vcs" {
+			return job, false, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Init container name %q is not unique among the job's containers.",
+					initContainer.Name,
+				),
+			}
+		}
+		containers[initContainer.Name] = initContainer.JobContainerSpec
+	}
+	for containerName, container := range containers {
+		for envVar := range container.SecretEnvironment {
+			if _, ok := container.Environment[envVar]; ok {
+				return job, false, &meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						"Container %q specifies environment variable %q both as a "+
+							"plain value and as a project secret.",
+						containerName,
+						envVar,
+					),
+				}
+			}
+		}
+		var securityPolicy *JobSecurityPolicy
+		if event.Worker.Spec.JobPolicies != nil {
+			securityPolicy = event.Worker.Spec.JobPolicies.Security
+		}
+		if err = validateSecurityContext(
+			containerName,
+			container.Privileged,
+			container.SecurityContext,
+			securityPolicy,
+		); err != nil {
+			return job, false, err
+		}
+		if err = imagePolicy(
+			event.Worker.Spec.JobPolicies,
+			j.substrate.DefaultImagePolicy(),
+		).authorizeImage(containerName, container.Image); err != nil {
+			return job, false, err
+		}
+		jobPolicies := JobPolicies{}
+		if event.Worker.Spec.JobPolicies != nil {
+			jobPolicies = *event.Worker.Spec.JobPolicies
+		}
+		for _, key := range container.secretKeys() {
+			if !jobPolicies.secretKeyAllowed(key) {
+				return job, false, &meta.ErrAuthorization{
+					Reason: fmt.Sprintf(
+						"Worker configuration forbids jobs from utilizing project "+
+							"secret %q.",
+						key,
+					),
+				}
+			}
+		}
+	}
+
 	// if useDockerSocket &&
 	// 	(event.Worker.Spec.JobPolicies == nil ||
 	// 		!event.Worker.Spec.JobPolicies.AllowDockerSocketMount) {
-	// 	return &meta.ErrAuthorization{
+	// 	return job, false, &meta.ErrAuthorization{
 	// 		Reason: "Worker configuration forbids jobs from mounting the Docker " +
 	// 			"socket.",
 	// 	}
 	// }
 
+	// Fail quickly if the job requests any scheduling constraints that aren't
+	// permitted per worker configuration.
+	if job.Spec.Host != nil {
+		if err = event.Worker.Spec.JobPolicies.authorizeScheduling(
+			job.Spec.Host.SchedulingConstraints,
+		); err != nil {
+			return job, false, err
+		}
+		if err = event.Worker.Spec.JobPolicies.authorizeCluster(
+			job.Spec.Host.Cluster,
+		); err != nil {
+			return job, false, err
+		}
+	}
+
+	// Fail quickly if the job requests a service account that isn't permitted
+	// per worker configuration or that the project doesn't define.
+	if job.Spec.ServiceAccount != "" {
+		if event.Worker.Spec.JobPolicies == nil ||
+			!Contains(
+				event.Worker.Spec.JobPolicies.AllowedServiceAccounts,
+				job.Spec.ServiceAccount,
+			) {
+			return job, false, &meta.ErrAuthorization{
+				Reason: fmt.Sprintf(
+					"Worker configuration forbids jobs from running as service "+
+						"account %q.",
+					job.Spec.ServiceAccount,
+				),
+			}
+		}
+		if !project.Kubernetes.hasJobServiceAccount(job.Spec.ServiceAccount) {
+			return job, false, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"The job requests service account %q, but project %q does not "+
+						"define it.",
+					job.Spec.ServiceAccount,
+					project.ID,
+				),
+			}
+		}
+	}
+
 	// Fail quickly if the job needs to use shared workspace, but the worker
 	// doesn't have any shared workspace.
 	if useWorkspace && !event.Worker.Spec.UseWorkspace {
-		return &meta.ErrConflict{
+		return job, false, &meta.ErrConflict{
 			Reason: "The job requested access to the shared workspace, but Worker " +
 				"configuration has not enabled this feature.",
 		}
@@ -450,13 +1113,107 @@ func (j *jobsService) Create(
 		Phase: JobPhasePending,
 	}
 
-	project, err := j.projectsStore.Get(ctx, event.ProjectID)
-	if err != nil {
-		return errors.Wrapf(
-			err,
-			"error retrieving project %q from store",
-			event.ProjectID,
-		)
+	// Fail quickly if any of the job's containers requests a cache volume that
+	// the Project does not define.
+	cacheVolumeMounts := append(
+		[]CacheVolumeMount{},
+		job.Spec.PrimaryContainer.CacheVolumeMounts...,
+	)
+	for _, sidecarContainer := range job.Spec.SidecarContainers {
+		cacheVolumeMounts =
+			append(cacheVolumeMounts, sidecarContainer.CacheVolumeMounts...)
+	}
+	for _, initContainer := range job.Spec.InitContainers {
+		cacheVolumeMounts =
+			append(cacheVolumeMounts, initContainer.CacheVolumeMounts...)
+	}
+	for _, cacheVolumeMount := range cacheVolumeMounts {
+		if _, ok := project.Spec.CacheVolume(cacheVolumeMount.Name); !ok {
+			return job, false, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"The job requested cache volume %q, but project %q does not "+
+						"define it.",
+					cacheVolumeMount.Name,
+					project.ID,
+				),
+			}
+		}
+	}
+
+	if job.Spec.Cache != nil {
+		// The contents of the shared workspace are not reflected in the cache key,
+		// so Jobs that use it can never safely reuse another Job's results.
+		if useWorkspace {
+			return job, false, &meta.ErrBadRequest{
+				Reason: "Jobs that use the shared workspace cannot specify a cache " +
+					"policy.",
+			}
+		}
+		var cacheKey string
+		var cacheable bool
+		if cacheKey, cacheable, err = getJobCacheKey(event, job); err != nil {
+			return job, false, errors.Wrapf(
+				err,
+				"error computing cache key for event %q job %q",
+				event.ID,
+				job.Name,
+			)
+		}
+		if cacheable {
+			job.CacheKey = cacheKey
+		}
+	}
+
+	return job, true, nil
+}
+
+// create creates a single Job that has already been prepared. If the Job opted
+// into caching and an equivalent Job previously succeeded, the Job inherits
+// that Job's results instead of being scheduled.
+//
+// nolint: gocyclo
+func (j *jobsService) create(
+	ctx context.Context,
+	event Event,
+	project Project,
+	job Job,
+) error {
+	var err error
+
+	// If the Job opted into caching and has a cache key, look for reusable
+	// results from an equivalent Job that previously succeeded in any of the
+	// Project's Events.
+	if job.CacheKey != "" {
+		var cachedEvent Event
+		cachedEvent, err =
+			j.jobsStore.GetByCacheKey(ctx, event.ProjectID, job.CacheKey)
+		if err != nil {
+			if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+				return errors.Wrapf(
+					err,
+					"error searching store for cached results of event %q job %q",
+					event.ID,
+					job.Name,
+				)
+			}
+		} else if len(cachedEvent.Worker.Jobs) > 0 &&
+			cachedEvent.Worker.Jobs[0].Status != nil {
+			cachedStatus := cachedEvent.Worker.Jobs[0].Status
+			// Point to the logs of the Job that ACTUALLY executed, which may
+			// itself have been cached or inherited.
+			logsEventID := cachedStatus.LogsEventID
+			if logsEventID == "" {
+				logsEventID = cachedEvent.ID
+			}
+			job.Status = &JobStatus{
+				Started:     job.Created,
+				Ended:       job.Created,
+				Phase:       JobPhaseSucceeded,
+				LogsEventID: logsEventID,
+				Outputs:     cachedStatus.Outputs,
+				Cached:      true,
+			}
+		}
 	}
 
 	// Redact the values of the Job's environment variables in the job we persist
@@ -480,15 +1237,36 @@ func (j *jobsService) Create(
 		}
 		jobCopy.Spec.SidecarContainers[sidecarName] = sidecar
 	}
+	// This needs to be a NEW slice, otherwise as we mess with it, we're messing
+	// with the original since slices are references.
+	jobCopy.Spec.InitContainers = make(
+		[]JobInitContainerSpec,
+		len(job.Spec.InitContainers),
+	)
+	for i, initContainer := range job.Spec.InitContainers {
+		// This needs to be a NEW map, otherwise as we mess with it, we're messing
+		// with the original since maps are references.
+		initContainer.Environment = map[string]string{}
+		for k := range job.Spec.InitContainers[i].Environment {
+			initContainer.Environment[k] = "*** REDACTED ***"
+		}
+		jobCopy.Spec.InitContainers[i] = initContainer
+	}
 
-	if err = j.jobsStore.Create(ctx, eventID, jobCopy); err != nil {
+	if err = j.jobsStore.Create(ctx, event.ID, jobCopy); err != nil {
 		return errors.Wrapf(
 			err, "error saving event %q job %q in store",
-			eventID,
-			eventID,
+			event.ID,
+			job.Name,
 		)
 	}
 
+	// A Job whose results were reused from the cache is already complete and
+	// does not need to be scheduled.
+	if job.Status.Cached {
+		return nil
+	}
+
 	// Securely store the Job's environment variables
 	if err = j.substrate.StoreJobEnvironment(
 		ctx,
@@ -505,6 +1283,12 @@ func (j *jobsService) Create(
 		)
 	}
 
+	// A Job created while its Event is suspended remains pending until the
+	// Event is resumed, at which point it will be scheduled.
+	if event.Worker.Status.Phase == WorkerPhaseSuspended {
+		return nil
+	}
+
 	return errors.Wrapf(
 		j.substrate.ScheduleJob(ctx, project, event, job.Name),
 		"error scheduling event %q job %q on the substrate",
@@ -546,6 +1330,19 @@ func (j *jobsService) Start(
 		}
 	}
 
+	if event.Worker.Status.Phase == WorkerPhaseSuspended {
+		return &meta.ErrConflict{
+			Type: JobKind,
+			ID:   jobName,
+			Reason: fmt.Sprintf(
+				"Event %q job %q was not started because event %q is suspended.",
+				eventID,
+				jobName,
+				eventID,
+			),
+		}
+	}
+
 	project, err := j.projectsStore.Get(ctx, event.ProjectID)
 	if err != nil {
 		return errors.Wrapf(
@@ -571,7 +1368,26 @@ func (j *jobsService) Start(
 		)
 	}
 
-	if err = j.substrate.StartJob(ctx, project, event, jobName); err != nil {
+	// This is a token unique to the Job that the Job can use when communicating
+	// with the API server. Unlike the Worker's token, it permits the Job to do
+	// nothing except report its own progress.
+	token := libCrypto.NewToken(256)
+	if err = j.jobsStore.UpdateHashedToken(
+		ctx,
+		eventID,
+		jobName,
+		crypto.Hash("", token),
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating event %q job %q hashed token in store",
+			eventID,
+			jobName,
+		)
+	}
+
+	if err =
+		j.substrate.StartJob(ctx, project, event, jobName, token); err != nil {
 		return errors.Wrapf(
 			err,
 			"error starting event %q job %q",
@@ -675,6 +1491,98 @@ func (j *jobsService) UpdateStatus(
 	return j.updateStatus(ctx, event, jobName, status)
 }
 
+func (j *jobsService) UpdateProgress(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	progress JobProgress,
+) error {
+	// Only the Job itself may report its progress
+	if err :=
+		j.authorize(ctx, RoleJob, JobRoleScope(eventID, jobName)); err != nil {
+		return err
+	}
+
+	event, err := j.eventsStore.Get(ctx, eventID)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving event %q from store", eventID)
+	}
+	job, ok := event.Worker.Job(jobName)
+	if !ok {
+		return &meta.ErrNotFound{
+			Type: JobKind,
+			ID:   jobName,
+		}
+	}
+
+	// Progress is only meaningful while the job is running
+	if job.Status == nil || job.Status.Phase != JobPhaseRunning {
+		return &meta.ErrConflict{
+			Type: JobKind,
+			ID:   job.Name,
+			Reason: fmt.Sprintf(
+				"Progress of event %q job %q was not updated because the job is "+
+					"not running.",
+				event.ID,
+				job.Name,
+			),
+		}
+	}
+
+	now := time.Now().UTC()
+	progress.Updated = &now
+
+	return errors.Wrapf(
+		j.jobsStore.UpdateProgress(ctx, event.ID, jobName, progress),
+		"error updating progress of event %q job %q in store",
+		event.ID,
+		jobName,
+	)
+}
+
+func (j *jobsService) Cancel(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+) error {
+	event, err := j.eventsStore.Get(ctx, eventID)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving event %q from store", eventID)
+	}
+
+	if err =
+		j.projectAuthorize(ctx, event.ProjectID, RoleProjectUser); err != nil {
+		return err
+	}
+
+	job, ok := event.Worker.Job(jobName)
+	if !ok {
+		return &meta.ErrNotFound{
+			Type: JobKind,
+			ID:   jobName,
+		}
+	}
+
+	// A pending Job never made it onto the substrate, so it is canceled. A Job
+	// in any other non-terminal phase is aborted.
+	now := time.Now().UTC()
+	status := *job.Status
+	status.Ended = &now
+	if status.Phase == JobPhasePending {
+		status.Phase = JobPhaseCanceled
+	} else {
+		status.Phase = JobPhaseAborted
+	}
+
+	// Note that updateStatus will return a *meta.ErrConflict if the Job has
+	// already reached a terminal phase.
+	if err = j.updateStatus(ctx, event, jobName, status); err != nil {
+		return err
+	}
+
+	return j.cleanup(ctx, event, jobName)
+}
+
 func (j *jobsService) Cleanup(
 	ctx context.Context,
 	eventID string,
@@ -760,6 +1668,13 @@ func (j *jobsService) updateStatus(
 		}
 	}
 
+	// Status updates originate from observation of the substrate, which knows
+	// nothing of progress reported by the job itself, so retain any such
+	// progress.
+	if status.Progress == nil {
+		status.Progress = job.Status.Progress
+	}
+
 	return errors.Wrapf(
 		j.jobsStore.UpdateStatus(
 			ctx,
@@ -805,12 +1720,80 @@ func (j *jobsService) cleanup(
 	)
 }
 
+// getJobCacheKey returns a digest of the provided Job's spec, including any
+// declared cache inputs. If the Job mounts source code, the Event's git
+// configuration is folded into the digest as well. The boolean return value
+// indicates whether a key could be derived at all. This is not the case when a
+// Job mounts source code that is not pinned to a specific commit, since that
+// source may differ between otherwise equivalent Jobs. Nor is it the case when
+// a Job references any Project Secret, since only the secret's key, and not its
+// value, is apparent from the JobSpec.
+func getJobCacheKey(event Event, job Job) (string, bool, error) {
+	var useSource = job.Spec.PrimaryContainer.SourceMountPath != ""
+	var useSecrets = len(job.Spec.PrimaryContainer.secretKeys()) > 0
+	for _, sidecarContainer := range job.Spec.SidecarContainers {
+		if sidecarContainer.SourceMountPath != "" {
+			useSource = true
+		}
+		if len(sidecarContainer.secretKeys()) > 0 {
+			useSecrets = true
+		}
+	}
+	for _, initContainer := range job.Spec.InitContainers {
+		if initContainer.SourceMountPath != "" {
+			useSource = true
+		}
+		if len(initContainer.secretKeys()) > 0 {
+			useSecrets = true
+		}
+	}
+	if useSecrets {
+		return "", false, nil
+	}
+	var git *GitConfig
+	if useSource &&
+		event.Worker.Spec.Git != nil &&
+		event.Worker.Spec.Git.CloneURL != "" {
+		if event.Worker.Spec.Git.Commit == "" {
+			return "", false, nil
+		}
+		gitCopy := *event.Worker.Spec.Git
+		// The commit always supersedes the ref, so the ref is irrelevant here
+		gitCopy.Ref = ""
+		git = &gitCopy
+	}
+	// Note that map keys are sorted when marshaling, so the result is stable.
+	keyJSON, err := json.Marshal(
+		struct {
+			Spec JobSpec    `json:"spec"`
+			Git  *GitConfig `json:"git,omitempty"`
+		}{
+			Spec: job.Spec,
+			Git:  git,
+		},
+	)
+	if err != nil {
+		return "", false, err
+	}
+	sum := sha256.Sum256(keyJSON)
+	return hex.EncodeToString(sum[:]), true, nil
+}
+
 // JobsStore is an interface for components that implement Job persistence
 // concerns.
 type JobsStore interface {
 	// Create persists a new Job for the specified Event in the underlying data
 	// store.
 	Create(ctx context.Context, eventID string, job Job) error
+	// GetByCacheKey retrieves the most recently created Event belonging to the
+	// specified Project that has a succeeded Job with the specified cache key.
+	// The Worker of the returned Event includes ONLY that Job. If no such Event
+	// exists, implementations MUST return a *meta.ErrNotFound error.
+	GetByCacheKey(
+		ctx context.Context,
+		projectID string,
+		cacheKey string,
+	) (Event, error)
 	// UpdateStatus updates the status of the specified Job in the underlying data
 	// store. If the specified job is not found, implementations MUST return a
 	// *meta.ErrNotFound error.
@@ -820,4 +1803,241 @@ type JobsStore interface {
 		jobName string,
 		status JobStatus,
 	) error
+	// UpdateHashedToken updates the specified Job's hashed token in the
+	// underlying data store. If the specified job is not found, implementations
+	// MUST return a *meta.ErrNotFound error.
+	UpdateHashedToken(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		hashedToken string,
+	) error
+	// UpdateProgress updates the progress of the specified Job in the underlying
+	// data store. If the specified job is not found, implementations MUST return
+	// a *meta.ErrNotFound error.
+	UpdateProgress(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		progress JobProgress,
+	) error
+}
+
+// resolveJobSpec returns the JobSpec that results from applying the specified
+// overrides, in their JSON representation, to the specified template. Maps,
+// like those of sidecar containers or environment variables, are merged key by
+// key. Any other field that is present in the overrides replaces the
+// corresponding field of the template wholesale, even if its value is a zero
+// value. Fields that are absent from the overrides retain the template's
+// values.
+func resolveJobSpec(
+	template JobSpec,
+	overrides json.RawMessage,
+) (JobSpec, error) {
+	resolved := JobSpec{}
+	templateBytes, err := json.Marshal(template)
+	if err != nil {
+		return resolved, errors.Wrap(err, "error marshaling job template")
+	}
+	templateMap := map[string]interface{}{}
+	if err = json.Unmarshal(templateBytes, &templateMap); err != nil {
+		return resolved, errors.Wrap(err, "error unmarshaling job template")
+	}
+	overridesMap := map[string]interface{}{}
+	if err = json.Unmarshal(overrides, &overridesMap); err != nil {
+		return resolved, errors.Wrap(err, "error unmarshaling job overrides")
+	}
+	mergeJSONMaps(templateMap, overridesMap)
+	resolvedBytes, err := json.Marshal(templateMap)
+	if err != nil {
+		return resolved, errors.Wrap(err, "error marshaling resolved job spec")
+	}
+	if err = json.Unmarshal(resolvedBytes, &resolved); err != nil {
+		return resolved, errors.Wrap(err, "error unmarshaling resolved job spec")
+	}
+	return resolved, nil
+}
+
+// mergeJSONMaps recursively merges the src map into the dst map. Where both
+// maps have a map at a given key, those maps are merged. Otherwise, the value
+// from src replaces the value in dst.
+func mergeJSONMaps(dst, src map[string]interface{}) {
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeJSONMaps(dstMap, srcMap)
+		} else {
+			dst[key] = srcValue
+		}
+	}
+}
+
+// expandJobMatrix returns one Job for each combination of the values of the
+// specified Job's matrix dimensions. Dimensions are ordered by name and the
+// values of each dimension retain their specified order, so expansion is
+// deterministic. Each expanded Job is named by appending the (sanitized)
+// values of its combination to the original Job's name. If maxJobs is greater
+// than zero and the matrix would be expanded into more Jobs than that, a
+// *meta.ErrBadRequest error is returned.
+func expandJobMatrix(job Job, maxJobs int) ([]Job, error) {
+	dimensions := make([]string, 0, len(job.Matrix.Dimensions))
+	for dimension, values := range job.Matrix.Dimensions {
+		if len(values) == 0 {
+			return nil, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Matrix dimension %q of job %q does not specify any values.",
+					dimension,
+					job.Name,
+				),
+			}
+		}
+		dimensions = append(dimensions, dimension)
+	}
+	if len(dimensions) == 0 {
+		return nil, &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"The matrix of job %q does not specify any dimensions.",
+				job.Name,
+			),
+		}
+	}
+	sort.Strings(dimensions)
+
+	if maxJobs > 0 {
+		// Count combinations without expanding them. The count is abandoned as
+		// soon as it exceeds the limit so that it cannot overflow.
+		count := 1
+		for _, dimension := range dimensions {
+			if count *= len(job.Matrix.Dimensions[dimension]); count > maxJobs {
+				return nil, &meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						"The matrix of job %q would be expanded into more than the "+
+							"maximum of %d jobs.",
+						job.Name,
+						maxJobs,
+					),
+				}
+			}
+		}
+	}
+
+	combinations := []map[string]string{{}}
+	for _, dimension := range dimensions {
+		newCombinations := []map[string]string{}
+		for _, combination := range combinations {
+			for _, value := range job.Matrix.Dimensions[dimension] {
+				newCombination := map[string]string{dimension: value}
+				for k, v := range combination {
+					newCombination[k] = v
+				}
+				newCombinations = append(newCombinations, newCombination)
+			}
+		}
+		combinations = newCombinations
+	}
+
+	jobs := make([]Job, len(combinations))
+	jobNames := map[string]struct{}{}
+	for i, combination := range combinations {
+		nameParts := []string{job.Name}
+		for _, dimension := range dimensions {
+			nameParts = append(nameParts, sanitizeMatrixValue(combination[dimension]))
+		}
+		jobName := strings.Join(nameParts, "-")
+		if _, ok := jobNames[jobName]; ok || len(jobName) > 63 ||
+			!jobNameRegex.MatchString(jobName) {
+			return nil, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Expanding the matrix of job %q produced job name %q, which is "+
+						"invalid or is not unique.",
+					job.Name,
+					jobName,
+				),
+			}
+		}
+		jobNames[jobName] = struct{}{}
+		jobs[i] = Job{
+			Name:         jobName,
+			Template:     job.Template,
+			Group:        job.Name,
+			MatrixValues: combination,
+			Spec:         job.Spec,
+			specJSON:     job.specJSON,
+		}
+	}
+	return jobs, nil
+}
+
+// sanitizeMatrixValue returns a representation of the specified matrix
+// dimension value that is suitable for use as part of a Job name.
+func sanitizeMatrixValue(value string) string {
+	return strings.Trim(
+		jobNameInvalidCharsRegex.ReplaceAllString(strings.ToLower(value), "-"),
+		"-",
+	)
+}
+
+// substituteMatrixValues returns a copy of the specified JobSpec wherein every
+// ${matrix.<dimension>} placeholder in the image, command, arguments, and
+// environment variable values of every container has been replaced with the
+// corresponding specified matrix value.
+func substituteMatrixValues(
+	jobSpec JobSpec,
+	matrixValues map[string]string,
+) JobSpec {
+	replacements := make([]string, 0, 2*len(matrixValues))
+	for dimension, value := range matrixValues {
+		replacements = append(
+			replacements,
+			fmt.Sprintf("${matrix.%s}", dimension),
+			value,
+		)
+	}
+	replacer := strings.NewReplacer(replacements...)
+	substitute := func(container JobContainerSpec) JobContainerSpec {
+		container.Image = replacer.Replace(container.Image)
+		if container.Command != nil {
+			command := make([]string, len(container.Command))
+			for i, part := range container.Command {
+				command[i] = replacer.Replace(part)
+			}
+			container.Command = command
+		}
+		if container.Arguments != nil {
+			arguments := make([]string, len(container.Arguments))
+			for i, argument := range container.Arguments {
+				arguments[i] = replacer.Replace(argument)
+			}
+			container.Arguments = arguments
+		}
+		if container.Environment != nil {
+			environment := make(map[string]string, len(container.Environment))
+			for k, v := range container.Environment {
+				environment[k] = replacer.Replace(v)
+			}
+			container.Environment = environment
+		}
+		return container
+	}
+	jobSpec.PrimaryContainer = substitute(jobSpec.PrimaryContainer)
+	if jobSpec.SidecarContainers != nil {
+		sidecarContainers :=
+			make(map[string]JobContainerSpec, len(jobSpec.SidecarContainers))
+		for sidecarName, sidecarContainer := range jobSpec.SidecarContainers {
+			sidecarContainers[sidecarName] = substitute(sidecarContainer)
+		}
+		jobSpec.SidecarContainers = sidecarContainers
+	}
+	if jobSpec.InitContainers != nil {
+		initContainers :=
+			make([]JobInitContainerSpec, len(jobSpec.InitContainers))
+		for i, initContainer := range jobSpec.InitContainers {
+			initContainer.JobContainerSpec =
+				substitute(initContainer.JobContainerSpec)
+			initContainers[i] = initContainer
+		}
+		jobSpec.InitContainers = initContainers
+	}
+	return jobSpec
 }
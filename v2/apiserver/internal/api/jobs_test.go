@@ -2,10 +2,13 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/brigadecore/brigade-foundations/crypto"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,17 +21,23 @@ func TestNewjobsService(t *testing.T) {
 	substrate := &mockSubstrate{}
 	svc, ok := NewJobsService(
 		alwaysAuthorize,
+		alwaysProjectAuthorize,
+		func(JobSpec) error { return nil },
 		projectsStore,
 		eventsStore,
 		jobsStore,
 		substrate,
+		JobsServiceConfig{MaxMatrixJobs: 10},
 	).(*jobsService)
 	require.True(t, ok)
 	require.NotNil(t, svc.authorize)
+	require.NotNil(t, svc.projectAuthorize)
+	require.NotNil(t, svc.validateJobSpec)
 	require.Same(t, projectsStore, svc.projectsStore)
 	require.Same(t, eventsStore, svc.eventsStore)
 	require.Same(t, jobsStore, svc.jobsStore)
 	require.Same(t, substrate, svc.substrate)
+	require.Equal(t, JobsServiceConfig{MaxMatrixJobs: 10}, svc.config)
 }
 
 func TestJobsServiceCreate(t *testing.T) {
@@ -86,6 +95,11 @@ func TestJobsServiceCreate(t *testing.T) {
 						}, nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
@@ -105,6 +119,11 @@ func TestJobsServiceCreate(t *testing.T) {
 						}, nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
@@ -165,6 +184,12 @@ func TestJobsServiceCreate(t *testing.T) {
 						}, nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				substrate: &mockSubstrate{},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
@@ -238,6 +263,7 @@ func TestJobsServiceCreate(t *testing.T) {
 						return errors.New("something went wrong")
 					},
 				},
+				substrate: &mockSubstrate{},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
@@ -342,6 +368,57 @@ func TestJobsServiceCreate(t *testing.T) {
 				require.Contains(t, err.Error(), "error scheduling event")
 			},
 		},
+		{
+			name: "event is suspended",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Spec: WorkerSpec{
+									UseWorkspace: true,
+									JobPolicies: &JobPolicies{
+										AllowPrivileged: true,
+									},
+								},
+								Status: WorkerStatus{
+									Phase: WorkerPhaseSuspended,
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					CreateFn: func(context.Context, string, Job) error {
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
+						context.Context,
+						Project,
+						string,
+						string,
+						JobSpec,
+					) error {
+						return nil
+					},
+					ScheduleJobFn: func(context.Context, Project, Event, string) error {
+						require.Fail(t, "job should not have been scheduled")
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "success",
 			service: &jobsService{
@@ -474,6 +551,11 @@ func TestJobsServiceCreateRetry(t *testing.T) {
 						}, nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
 			},
 			workspaceMountPath: "",
 			assertions: func(err error) {
@@ -502,6 +584,11 @@ func TestJobsServiceCreateRetry(t *testing.T) {
 						}, nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
 			},
 			workspaceMountPath: "",
 			assertions: func(err error) {
@@ -608,6 +695,11 @@ func TestJobsServiceCreateRetry(t *testing.T) {
 						}, nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
 				// No other methods mocked out; they should not be called
 			},
 			workspaceMountPath: "",
@@ -644,190 +736,2569 @@ func TestJobsServiceCreateRetry(t *testing.T) {
 	}
 }
 
-func TestJobsServiceStart(t *testing.T) {
-	const testEventID = "123456789"
-	const testJobName = "foo"
-	testCases := []struct {
-		name       string
-		service    JobsService
-		assertions func(error)
-	}{
-		{
-			name: "unauthorized",
-			service: &jobsService{
-				authorize: neverAuthorize,
+func TestJobsServiceCreateWithUndefinedCacheVolume(t *testing.T) {
+	service := &jobsService{
+		authorize: alwaysAuthorize,
+		eventsStore: &mockEventsStore{
+			GetFn: func(context.Context, string) (Event, error) {
+				return Event{}, nil
 			},
-			assertions: func(err error) {
-				require.Error(t, err)
-				require.IsType(t, &meta.ErrAuthorization{}, err)
+		},
+		projectsStore: &mockProjectsStore{
+			GetFn: func(context.Context, string) (Project, error) {
+				return Project{
+					Spec: ProjectSpec{
+						CacheVolumes: []CacheVolume{
+							{
+								Name: "npm",
+							},
+						},
+					},
+				}, nil
 			},
 		},
-		{
-			name: "error getting event from store",
-			service: &jobsService{
-				authorize: alwaysAuthorize,
-				eventsStore: &mockEventsStore{
-					GetFn: func(context.Context, string) (Event, error) {
-						return Event{}, errors.New("something went wrong")
+		substrate: &mockSubstrate{},
+	}
+	err := service.Create(
+		context.Background(),
+		"123456789",
+		Job{
+			Name: "italian",
+			Spec: JobSpec{
+				SidecarContainers: map[string]JobContainerSpec{
+					"helper": {
+						CacheVolumeMounts: []CacheVolumeMount{
+							{
+								Name:      "maven",
+								MountPath: "/root/.m2",
+							},
+						},
 					},
 				},
 			},
-			assertions: func(err error) {
-				require.Error(t, err)
-				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error retrieving event")
+		},
+	)
+	require.Error(t, err)
+	require.IsType(t, &meta.ErrBadRequest{}, err)
+	require.Contains(t, err.Error(), "maven")
+}
+
+func TestJobsServiceCreateWithServiceAccount(t *testing.T) {
+	testProject := Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "blue-book",
+		},
+		Kubernetes: &KubernetesDetails{
+			JobServiceAccounts: []JobServiceAccount{
+				{
+					Name: "deployer",
+				},
 			},
 		},
-		{
-			name: "event has no such job",
-			service: &jobsService{
-				authorize: alwaysAuthorize,
-				eventsStore: &mockEventsStore{
-					GetFn: func(context.Context, string) (Event, error) {
-						return Event{}, nil
-					},
+	}
+	testEvent := Event{
+		Worker: Worker{
+			Spec: WorkerSpec{
+				JobPolicies: &JobPolicies{
+					AllowedServiceAccounts: []string{"deployer", "publisher"},
 				},
 			},
+		},
+	}
+	testCases := []struct {
+		name           string
+		serviceAccount string
+		event          Event
+		assertions     func(error)
+	}{
+		{
+			name:           "service account not permitted",
+			serviceAccount: "deployer",
+			event:          Event{},
 			assertions: func(err error) {
 				require.Error(t, err)
-				require.IsType(t, &meta.ErrNotFound{}, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "deployer")
 			},
 		},
 		{
-			name: "job is not currently pending",
-			service: &jobsService{
-				authorize: alwaysAuthorize,
-				eventsStore: &mockEventsStore{
-					GetFn: func(context.Context, string) (Event, error) {
-						return Event{
-							Worker: Worker{
-								Jobs: []Job{
-									{
-										Name: testJobName,
-										Status: &JobStatus{
-											Phase: JobPhaseRunning,
-										},
-									},
-								},
-							},
-						}, nil
-					},
-				},
-			},
+			name:           "service account not defined by project",
+			serviceAccount: "publisher",
+			event:          testEvent,
 			assertions: func(err error) {
 				require.Error(t, err)
-				require.IsType(t, &meta.ErrConflict{}, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "publisher")
 			},
 		},
 		{
-			name: "error getting project from store",
-			service: &jobsService{
-				authorize: alwaysAuthorize,
-				eventsStore: &mockEventsStore{
-					GetFn: func(context.Context, string) (Event, error) {
-						return Event{
-							Worker: Worker{
-								Jobs: []Job{
-									{
-										Name: testJobName,
-										Status: &JobStatus{
-											Phase: JobPhasePending,
-										},
-									},
-								},
-							},
-						}, nil
-					},
-				},
-				projectsStore: &mockProjectsStore{
-					GetFn: func(context.Context, string) (Project, error) {
-						return Project{}, errors.New("something went wrong")
-					},
-				},
-			},
+			name:           "success",
+			serviceAccount: "deployer",
+			event:          testEvent,
 			assertions: func(err error) {
-				require.Error(t, err)
-				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error retrieving project")
+				require.NoError(t, err)
 			},
 		},
-		{
-			name: "error updating job status",
-			service: &jobsService{
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := &jobsService{
 				authorize: alwaysAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
-						return Event{
-							Worker: Worker{
-								Jobs: []Job{
-									{
-										Name: testJobName,
-										Status: &JobStatus{
-											Phase: JobPhasePending,
-										},
-									},
-								},
-							},
-						}, nil
+						return testCase.event, nil
 					},
 				},
 				projectsStore: &mockProjectsStore{
 					GetFn: func(context.Context, string) (Project, error) {
-						return Project{}, nil
+						return testProject, nil
 					},
 				},
 				jobsStore: &mockJobsStore{
-					UpdateStatusFn: func(
+					CreateFn: func(context.Context, string, Job) error {
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
 						context.Context,
-						string, string,
-						JobStatus,
+						Project,
+						string,
+						string,
+						JobSpec,
 					) error {
-						return errors.New("something went wrong")
+						return nil
+					},
+					ScheduleJobFn: func(context.Context, Project, Event, string) error {
+						return nil
+					},
+				},
+			}
+			testCase.assertions(
+				service.Create(
+					context.Background(),
+					"123456789",
+					Job{
+						Name: "italian",
+						Spec: JobSpec{
+							ServiceAccount: testCase.serviceAccount,
+						},
 					},
+				),
+			)
+		})
+	}
+}
+
+func TestJobsServiceCreateWithInitContainers(t *testing.T) {
+	const testJobName = "italian"
+	testCases := []struct {
+		name           string
+		initContainers []JobInitContainerSpec
+		assertions     func(createdJob *Job, err error)
+	}{
+		{
+			name: "name collides with primary container",
+			initContainers: []JobInitContainerSpec{
+				{
+					Name: testJobName,
 				},
 			},
-			assertions: func(err error) {
+			assertions: func(_ *Job, err error) {
 				require.Error(t, err)
-				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error updating status of event")
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is not unique")
 			},
 		},
 		{
-			name: "error starting job on substrate",
-			service: &jobsService{
-				authorize: alwaysAuthorize,
-				eventsStore: &mockEventsStore{
-					GetFn: func(context.Context, string) (Event, error) {
-						return Event{
-							Worker: Worker{
-								Jobs: []Job{
-									{
-										Name: testJobName,
-										Status: &JobStatus{
-											Phase: JobPhasePending,
-										},
-									},
+			name: "name collides with sidecar container",
+			initContainers: []JobInitContainerSpec{
+				{
+					Name: "helper",
+				},
+			},
+			assertions: func(_ *Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is not unique")
+			},
+		},
+		{
+			name: "name collides with another init container",
+			initContainers: []JobInitContainerSpec{
+				{
+					Name: "fetch-credentials",
+				},
+				{
+					Name: "fetch-credentials",
+				},
+			},
+			assertions: func(_ *Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is not unique")
+			},
+		},
+		{
+			name: "name collides with vcs init container",
+			initContainers: []JobInitContainerSpec{
+				{
+					Name: "vcs",
+				},
+			},
+			assertions: func(_ *Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is not unique")
+			},
+		},
+		{
+			name: "privileged init container requested but not allowed",
+			initContainers: []JobInitContainerSpec{
+				{
+					Name: "fetch-credentials",
+					JobContainerSpec: JobContainerSpec{
+						Privileged: true,
+					},
+				},
+			},
+			assertions: func(_ *Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "success",
+			initContainers: []JobInitContainerSpec{
+				{
+					Name: "fetch-credentials",
+					JobContainerSpec: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Environment: map[string]string{
+								"FOO": "bar",
+							},
+						},
+					},
+				},
+			},
+			assertions: func(createdJob *Job, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, createdJob)
+				require.Len(t, createdJob.Spec.InitContainers, 1)
+				// Assert that the init container's environment was redacted
+				require.Equal(
+					t,
+					map[string]string{
+						"FOO": "*** REDACTED ***",
+					},
+					createdJob.Spec.InitContainers[0].Environment,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var createdJob *Job
+			service := &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					CreateFn: func(_ context.Context, _ string, job Job) error {
+						createdJob = &job
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
+						_ context.Context,
+						_ Project,
+						_ string,
+						_ string,
+						jobSpec JobSpec,
+					) error {
+						// Assert that an object WITHOUT environment redactions was received
+						for _, initContainer := range jobSpec.InitContainers {
+							for _, v := range initContainer.Environment {
+								require.NotEqual(t, "*** REDACTED ***", v)
+							}
+						}
+						return nil
+					},
+					ScheduleJobFn: func(context.Context, Project, Event, string) error {
+						return nil
+					},
+				},
+			}
+			err := service.Create(
+				context.Background(),
+				"123456789",
+				Job{
+					Name: testJobName,
+					Spec: JobSpec{
+						SidecarContainers: map[string]JobContainerSpec{
+							"helper": {},
+						},
+						InitContainers: testCase.initContainers,
+					},
+				},
+			)
+			testCase.assertions(createdJob, err)
+		})
+	}
+}
+
+func TestJobsServiceCreateWithMatrix(t *testing.T) {
+	testCases := []struct {
+		name            string
+		config          JobsServiceConfig
+		validateJobSpec JobSpecValidateFn
+		event           Event
+		project         Project
+		job             Job
+		assertions      func(createdJobs []Job, scheduledJobs []string, err error)
+	}{
+		{
+			name: "matrix cannot be expanded",
+			job: Job{
+				Name: "test",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {"16.0", "16-0"},
+					},
+				},
+			},
+			assertions: func(createdJobs []Job, _ []string, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is not unique")
+				require.Empty(t, createdJobs)
+			},
+		},
+		{
+			name: "matrix expands into too many jobs",
+			config: JobsServiceConfig{
+				MaxMatrixJobs: 3,
+			},
+			job: Job{
+				Name: "test",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {"16", "18"},
+						"os":   {"linux", "windows"},
+					},
+				},
+			},
+			assertions: func(createdJobs []Job, _ []string, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "maximum of 3 jobs")
+				require.Empty(t, createdJobs)
+			},
+		},
+		{
+			name: "expanded job spec is invalid",
+			validateJobSpec: func(jobSpec JobSpec) error {
+				if jobSpec.PrimaryContainer.Image == "node:bogus tag" {
+					return &meta.ErrBadRequest{
+						Reason: "Invalid image.",
+					}
+				}
+				return nil
+			},
+			job: Job{
+				Name: "test",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image: "node:${matrix.node}",
+						},
+					},
+				},
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {"16", "bogus tag"},
+					},
+				},
+			},
+			assertions: func(
+				createdJobs []Job,
+				scheduledJobs []string,
+				err error,
+			) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "Invalid image")
+				// No part of the matrix should have been created
+				require.Empty(t, createdJobs)
+				require.Empty(t, scheduledJobs)
+			},
+		},
+		{
+			name: "one expanded job is rejected",
+			event: Event{
+				Worker: Worker{
+					Jobs: []Job{
+						{
+							Name: "test-18-linux",
+						},
+					},
+				},
+			},
+			job: Job{
+				Name: "test",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {"16", "18"},
+						"os":   {"linux", "windows"},
+					},
+				},
+			},
+			assertions: func(
+				createdJobs []Job,
+				scheduledJobs []string,
+				err error,
+			) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "test-18-linux")
+				// No part of the matrix should have been created
+				require.Empty(t, createdJobs)
+				require.Empty(t, scheduledJobs)
+			},
+		},
+		{
+			name: "placeholders in job template",
+			project: Project{
+				Spec: ProjectSpec{
+					JobTemplates: map[string]JobSpec{
+						"node": {
+							PrimaryContainer: JobContainerSpec{
+								ContainerSpec: ContainerSpec{
+									Image: "node:${matrix.node}",
+									Environment: map[string]string{
+										"OS": "${matrix.os}",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			job: Job{
+				Name:     "test",
+				Template: "node",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {"16", "18"},
+						"os":   {"linux", "windows"},
+					},
+				},
+			},
+			assertions: func(
+				createdJobs []Job,
+				scheduledJobs []string,
+				err error,
+			) {
+				require.NoError(t, err)
+				require.Len(t, scheduledJobs, 4)
+				require.Len(t, createdJobs, 4)
+				require.Equal(
+					t,
+					"node:18",
+					createdJobs[2].Spec.PrimaryContainer.Image,
+				)
+			},
+		},
+		{
+			name: "success",
+			job: Job{
+				Name: "test",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image: "node:${matrix.node}",
+							Environment: map[string]string{
+								"OS": "${matrix.os}",
+							},
+						},
+					},
+				},
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {"16", "18"},
+						"os":   {"linux", "windows"},
+					},
+				},
+			},
+			assertions: func(
+				createdJobs []Job,
+				scheduledJobs []string,
+				err error,
+			) {
+				require.NoError(t, err)
+				expectedJobNames := []string{
+					"test-16-linux",
+					"test-16-windows",
+					"test-18-linux",
+					"test-18-windows",
+				}
+				require.Equal(t, expectedJobNames, scheduledJobs)
+				require.Len(t, createdJobs, len(expectedJobNames))
+				for i, createdJob := range createdJobs {
+					require.Equal(t, expectedJobNames[i], createdJob.Name)
+					require.Equal(t, "test", createdJob.Group)
+					require.Nil(t, createdJob.Matrix)
+				}
+				require.Equal(
+					t,
+					map[string]string{
+						"node": "18",
+						"os":   "linux",
+					},
+					createdJobs[2].MatrixValues,
+				)
+				require.Equal(
+					t,
+					"node:18",
+					createdJobs[2].Spec.PrimaryContainer.Image,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			createdJobs := []Job{}
+			scheduledJobs := []string{}
+			validateJobSpec := testCase.validateJobSpec
+			if validateJobSpec == nil {
+				validateJobSpec = func(JobSpec) error {
+					return nil
+				}
+			}
+			service := &jobsService{
+				authorize:       alwaysAuthorize,
+				validateJobSpec: validateJobSpec,
+				config:          testCase.config,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testCase.event, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return testCase.project, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					CreateFn: func(_ context.Context, _ string, job Job) error {
+						createdJobs = append(createdJobs, job)
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
+						_ context.Context,
+						_ Project,
+						_ string,
+						_ string,
+						jobSpec JobSpec,
+					) error {
+						// Assert that matrix values were substituted in an object WITHOUT
+						// environment redactions
+						require.Contains(
+							t,
+							[]string{"linux", "windows"},
+							jobSpec.PrimaryContainer.Environment["OS"],
+						)
+						return nil
+					},
+					ScheduleJobFn: func(
+						_ context.Context,
+						_ Project,
+						_ Event,
+						jobName string,
+					) error {
+						scheduledJobs = append(scheduledJobs, jobName)
+						return nil
+					},
+				},
+			}
+			err := service.Create(
+				context.Background(),
+				"123456789",
+				testCase.job,
+			)
+			testCase.assertions(createdJobs, scheduledJobs, err)
+		})
+	}
+}
+
+func TestExpandJobMatrix(t *testing.T) {
+	testCases := []struct {
+		name       string
+		job        Job
+		assertions func([]Job, error)
+	}{
+		{
+			name: "no dimensions",
+			job: Job{
+				Name:   "test",
+				Matrix: &JobMatrix{},
+			},
+			assertions: func(_ []Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "does not specify any dimensions")
+			},
+		},
+		{
+			name: "dimension without values",
+			job: Job{
+				Name: "test",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {},
+					},
+				},
+			},
+			assertions: func(_ []Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "does not specify any values")
+			},
+		},
+		{
+			name: "too many combinations",
+			job: Job{
+				Name: "test",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"a": {"1", "2", "3", "4", "5"},
+						"b": {"1", "2", "3", "4", "5"},
+						"c": {"1", "2", "3", "4", "5"},
+					},
+				},
+			},
+			assertions: func(_ []Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "maximum of 100 jobs")
+			},
+		},
+		{
+			name: "expanded job name too long",
+			job: Job{
+				Name: "test",
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"node": {strings.Repeat("a", 60)},
+					},
+				},
+			},
+			assertions: func(_ []Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is invalid")
+			},
+		},
+		{
+			name: "success",
+			job: Job{
+				Name:     "test",
+				Template: "node",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image:     "node:${matrix.node}",
+							Command:   []string{"npm"},
+							Arguments: []string{"test", "--os=${matrix.os}"},
+						},
+					},
+					SidecarContainers: map[string]JobContainerSpec{
+						"helper": {
+							ContainerSpec: ContainerSpec{
+								Image: "helper:${matrix.os}",
+							},
+						},
+					},
+					InitContainers: []JobInitContainerSpec{
+						{
+							Name: "setup",
+							JobContainerSpec: JobContainerSpec{
+								ContainerSpec: ContainerSpec{
+									Environment: map[string]string{
+										"NODE_VERSION": "${matrix.node}",
+									},
+								},
+							},
+						},
+					},
+				},
+				Matrix: &JobMatrix{
+					Dimensions: map[string][]string{
+						"os":   {"Ubuntu 22.04"},
+						"node": {"20", "16"},
+					},
+				},
+			},
+			assertions: func(jobs []Job, err error) {
+				require.NoError(t, err)
+				require.Len(t, jobs, 2)
+				require.Equal(t, "test-20-ubuntu-22-04", jobs[0].Name)
+				require.Equal(t, "test-16-ubuntu-22-04", jobs[1].Name)
+				job := jobs[1]
+				require.Equal(t, "test", job.Group)
+				require.Equal(t, "node", job.Template)
+				require.Nil(t, job.Matrix)
+				require.Equal(
+					t,
+					map[string]string{
+						"node": "16",
+						"os":   "Ubuntu 22.04",
+					},
+					job.MatrixValues,
+				)
+				// Matrix values are not substituted until the job's template, if
+				// any, has been resolved
+				require.Equal(
+					t,
+					"node:${matrix.node}",
+					job.Spec.PrimaryContainer.Image,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(expandJobMatrix(testCase.job, 100))
+		})
+	}
+}
+
+func TestSubstituteMatrixValues(t *testing.T) {
+	jobSpec := JobSpec{
+		PrimaryContainer: JobContainerSpec{
+			ContainerSpec: ContainerSpec{
+				Image:     "node:${matrix.node}",
+				Command:   []string{"npm"},
+				Arguments: []string{"test", "--os=${matrix.os}"},
+			},
+		},
+		SidecarContainers: map[string]JobContainerSpec{
+			"helper": {
+				ContainerSpec: ContainerSpec{
+					Image: "helper:${matrix.os}",
+				},
+			},
+		},
+		InitContainers: []JobInitContainerSpec{
+			{
+				Name: "setup",
+				JobContainerSpec: JobContainerSpec{
+					ContainerSpec: ContainerSpec{
+						Environment: map[string]string{
+							"NODE_VERSION": "${matrix.node}",
+						},
+					},
+				},
+			},
+		},
+	}
+	substitutedSpec := substituteMatrixValues(
+		jobSpec,
+		map[string]string{
+			"node": "16",
+			"os":   "Ubuntu 22.04",
+		},
+	)
+	require.Equal(t, "node:16", substitutedSpec.PrimaryContainer.Image)
+	require.Equal(t, []string{"npm"}, substitutedSpec.PrimaryContainer.Command)
+	require.Equal(
+		t,
+		[]string{"test", "--os=Ubuntu 22.04"},
+		substitutedSpec.PrimaryContainer.Arguments,
+	)
+	require.Equal(
+		t,
+		"helper:Ubuntu 22.04",
+		substitutedSpec.SidecarContainers["helper"].Image,
+	)
+	require.Equal(
+		t,
+		"16",
+		substitutedSpec.InitContainers[0].Environment["NODE_VERSION"],
+	)
+	// Assert that the original spec's underlying maps were not modified
+	require.Equal(
+		t,
+		"${matrix.node}",
+		jobSpec.InitContainers[0].Environment["NODE_VERSION"],
+	)
+}
+
+func TestJobsServiceCreateWithJobTemplate(t *testing.T) {
+	testTemplate := JobSpec{
+		PrimaryContainer: JobContainerSpec{
+			ContainerSpec: ContainerSpec{
+				Image: "golang:1.18",
+				Environment: map[string]string{
+					"GOFLAGS": "-mod=vendor",
+				},
+			},
+		},
+		TimeoutDuration: "10m",
+	}
+	testProject := Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Spec: ProjectSpec{
+			JobTemplates: map[string]JobSpec{
+				"go": testTemplate,
+			},
+		},
+	}
+	testOverrides := JobSpec{
+		PrimaryContainer: JobContainerSpec{
+			ContainerSpec: ContainerSpec{
+				Command: []string{"go", "test", "./..."},
+			},
+		},
+	}
+	testResolvedSpec := JobSpec{
+		PrimaryContainer: JobContainerSpec{
+			ContainerSpec: ContainerSpec{
+				Image:   "golang:1.18",
+				Command: []string{"go", "test", "./..."},
+				Environment: map[string]string{
+					"GOFLAGS": "-mod=vendor",
+				},
+			},
+		},
+		TimeoutDuration: "10m",
+	}
+	testCases := []struct {
+		name       string
+		template   string
+		event      Event
+		validateFn JobSpecValidateFn
+		assertions func(savedJob *Job, err error)
+	}{
+		{
+			name:     "undefined template",
+			template: "rust",
+			assertions: func(_ *Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "rust")
+			},
+		},
+		{
+			name:     "resolved spec fails validation",
+			template: "go",
+			validateFn: func(JobSpec) error {
+				return &meta.ErrBadRequest{
+					Reason: "Object failed JSON validation",
+				}
+			},
+			assertions: func(_ *Job, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+			},
+		},
+		{
+			name:     "retry of equivalent job",
+			template: "go",
+			event: Event{
+				Labels: map[string]string{
+					RetryLabelKey: "987654321",
+				},
+				Worker: Worker{
+					Jobs: []Job{
+						{
+							Name:     "test",
+							Template: "go",
+							Spec:     testResolvedSpec,
+						},
+					},
+				},
+			},
+			assertions: func(savedJob *Job, err error) {
+				require.NoError(t, err)
+				// The job should have been recognized as equivalent to the original
+				// and not saved again
+				require.Nil(t, savedJob)
+			},
+		},
+		{
+			name:     "success",
+			template: "go",
+			assertions: func(savedJob *Job, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, savedJob)
+				require.Equal(t, "go", savedJob.Template)
+				require.Equal(
+					t,
+					"golang:1.18",
+					savedJob.Spec.PrimaryContainer.Image,
+				)
+				require.Equal(
+					t,
+					[]string{"go", "test", "./..."},
+					savedJob.Spec.PrimaryContainer.Command,
+				)
+				require.Equal(t, "10m", savedJob.Spec.TimeoutDuration)
+				require.Contains(
+					t,
+					savedJob.Spec.PrimaryContainer.Environment,
+					"GOFLAGS",
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var savedJob *Job
+			validateFn := testCase.validateFn
+			if validateFn == nil {
+				validateFn = func(JobSpec) error { return nil }
+			}
+			service := &jobsService{
+				authorize:       alwaysAuthorize,
+				validateJobSpec: validateFn,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return testCase.event, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return testProject, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					CreateFn: func(_ context.Context, _ string, job Job) error {
+						savedJob = &job
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
+						context.Context,
+						Project,
+						string,
+						string,
+						JobSpec,
+					) error {
+						return nil
+					},
+					ScheduleJobFn: func(context.Context, Project, Event, string) error {
+						return nil
+					},
+				},
+			}
+			err := service.Create(
+				context.Background(),
+				"123456789",
+				Job{
+					Name:     "test",
+					Template: testCase.template,
+					Spec:     testOverrides,
+				},
+			)
+			testCase.assertions(savedJob, err)
+		})
+	}
+}
+
+func TestJobUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name       string
+		json       string
+		assertions func(Job, error)
+	}{
+		{
+			name: "spec absent",
+			json: `{"name":"test","template":"go"}`,
+			assertions: func(job Job, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "test", job.Name)
+				require.Equal(t, "go", job.Template)
+				require.Equal(t, JobSpec{}, job.Spec)
+				require.Equal(t, json.RawMessage("{}"), job.specJSON)
+			},
+		},
+		{
+			name: "spec present",
+			json: `{"name":"test","template":"go","spec":{"fallible":false}}`,
+			assertions: func(job Job, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "test", job.Name)
+				require.Equal(t, "go", job.Template)
+				require.Equal(t, JobSpec{}, job.Spec)
+				// The spec is retained exactly as it was received
+				require.Equal(
+					t,
+					json.RawMessage(`{"fallible":false}`),
+					job.specJSON,
+				)
+			},
+		},
+		{
+			name: "spec invalid",
+			json: `{"name":"test","spec":{"fallible":"nope"}}`,
+			assertions: func(_ Job, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			job := Job{}
+			err := json.Unmarshal([]byte(testCase.json), &job)
+			testCase.assertions(job, err)
+		})
+	}
+}
+
+func TestResolveJobSpec(t *testing.T) {
+	template := JobSpec{
+		PrimaryContainer: JobContainerSpec{
+			ContainerSpec: ContainerSpec{
+				Image:     "debian:latest",
+				Command:   []string{"echo"},
+				Arguments: []string{"hello"},
+				Environment: map[string]string{
+					"FOO": "bar",
+					"BAT": "baz",
+				},
+			},
+			WorkspaceMountPath: "/var/workspace",
+			Privileged:         true,
+		},
+		SidecarContainers: map[string]JobContainerSpec{
+			"db": {
+				ContainerSpec: ContainerSpec{
+					Image: "postgres:14",
+				},
+			},
+		},
+		TimeoutDuration: "10m",
+		Fallible:        true,
+	}
+	resolved, err := resolveJobSpec(
+		template,
+		json.RawMessage(`{
+			"primaryContainer": {
+				"arguments": ["goodbye"],
+				"environment": {
+					"FOO": "foo"
+				},
+				"privileged": false
+			},
+			"sidecarContainers": {
+				"cache": {
+					"image": "redis:6"
+				}
+			}
+		}`),
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		JobSpec{
+			PrimaryContainer: JobContainerSpec{
+				ContainerSpec: ContainerSpec{
+					Image: "debian:latest",
+					// Slices from the template are retained unless overridden...
+					Command: []string{"echo"},
+					// ...in which case they are replaced wholesale
+					Arguments: []string{"goodbye"},
+					// Maps are merged key by key
+					Environment: map[string]string{
+						"FOO": "foo",
+						"BAT": "baz",
+					},
+				},
+				WorkspaceMountPath: "/var/workspace",
+				// Zero values that are present in the overrides override the
+				// template
+				Privileged: false,
+			},
+			SidecarContainers: map[string]JobContainerSpec{
+				"db": {
+					ContainerSpec: ContainerSpec{
+						Image: "postgres:14",
+					},
+				},
+				"cache": {
+					ContainerSpec: ContainerSpec{
+						Image: "redis:6",
+					},
+				},
+			},
+			TimeoutDuration: "10m",
+			// Fields that are absent from the overrides never override the template
+			Fallible: true,
+		},
+		resolved,
+	)
+	// The template should not have been modified
+	require.Equal(
+		t,
+		map[string]string{
+			"FOO": "bar",
+			"BAT": "baz",
+		},
+		template.PrimaryContainer.Environment,
+	)
+}
+
+func TestJobsServiceCreateWithProjectSecrets(t *testing.T) {
+	testCases := []struct {
+		name       string
+		policies   *JobPolicies
+		container  JobContainerSpec
+		assertions func(error)
+	}{
+		{
+			name: "environment variable collision",
+			container: JobContainerSpec{
+				ContainerSpec: ContainerSpec{
+					Environment: map[string]string{
+						"TOKEN": "foo",
+					},
+				},
+				SecretEnvironment: map[string]string{
+					"TOKEN": "apiToken",
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "TOKEN")
+			},
+		},
+		{
+			name: "no secret keys allowed",
+			container: JobContainerSpec{
+				SecretEnvironment: map[string]string{
+					"TOKEN": "apiToken",
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "apiToken")
+			},
+		},
+		{
+			name:     "empty secret key allowlist",
+			policies: &JobPolicies{},
+			container: JobContainerSpec{
+				SecretMounts: []SecretMount{
+					{
+						Key:       "sshKey",
+						MountPath: "/root/.ssh/id_rsa",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "sshKey")
+			},
+		},
+		{
+			name: "secret key not allowed",
+			policies: &JobPolicies{
+				AllowedSecretKeys: []string{"apiToken"},
+			},
+			container: JobContainerSpec{
+				SecretMounts: []SecretMount{
+					{
+						Key:       "sshKey",
+						MountPath: "/root/.ssh/id_rsa",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "sshKey")
+			},
+		},
+		{
+			name: "secret key allowed",
+			policies: &JobPolicies{
+				AllowedSecretKeys: []string{"apiToken"},
+			},
+			container: JobContainerSpec{
+				SecretEnvironment: map[string]string{
+					"TOKEN": "apiToken",
+				},
+			},
+			assertions: func(err error) {
+				// We only care that validation passed and we proceeded to save the
+				// job
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error saving event")
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Spec: WorkerSpec{
+									JobPolicies: testCase.policies,
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					CreateFn: func(context.Context, string, Job) error {
+						return errors.New("something went wrong")
+					},
+				},
+				substrate: &mockSubstrate{},
+			}
+			testCase.assertions(
+				service.Create(
+					context.Background(),
+					"123456789",
+					Job{
+						Name: "italian",
+						Spec: JobSpec{
+							PrimaryContainer: testCase.container,
+						},
+					},
+				),
+			)
+		})
+	}
+}
+
+func TestJobsServiceCreateWithImagePolicy(t *testing.T) {
+	testDefaultPolicy := &ImagePolicy{
+		AllowedRegistries: []string{"ghcr.io"},
+	}
+	testCases := []struct {
+		name       string
+		policies   *JobPolicies
+		job        Job
+		assertions func(error)
+	}{
+		{
+			name: "default policy forbids primary container image",
+			job: Job{
+				Name: "italian",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image: "debian:latest",
+						},
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "debian:latest")
+			},
+		},
+		{
+			name: "worker policy forbids init container image",
+			policies: &JobPolicies{
+				Images: &ImagePolicy{
+					RequireDigest: true,
+				},
+			},
+			job: Job{
+				Name: "italian",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image: "ghcr.io/debian@sha256:" +
+								"0123456789abcdef0123456789abcdef" +
+								"0123456789abcdef0123456789abcdef",
+						},
+					},
+					InitContainers: []JobInitContainerSpec{
+						{
+							Name: "setup",
+							JobContainerSpec: JobContainerSpec{
+								ContainerSpec: ContainerSpec{
+									Image: "ghcr.io/debian:latest",
+								},
+							},
+						},
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), `container "setup"`)
+				require.Contains(t, err.Error(), "pinned by digest")
+			},
+		},
+		{
+			name: "worker policy cannot override default policy",
+			policies: &JobPolicies{
+				Images: &ImagePolicy{
+					AllowedRepositories: []string{"debian"},
+				},
+			},
+			job: Job{
+				Name: "italian",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image: "debian:latest",
+						},
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "debian:latest")
+			},
+		},
+		{
+			name: "image permitted by both policies",
+			policies: &JobPolicies{
+				Images: &ImagePolicy{
+					AllowedRepositories: []string{"ghcr.io/debian"},
+				},
+			},
+			job: Job{
+				Name: "italian",
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						ContainerSpec: ContainerSpec{
+							Image: "ghcr.io/debian:latest",
+						},
+					},
+				},
+			},
+			assertions: func(err error) {
+				// We only care that validation passed and we proceeded to save the
+				// job
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error saving event")
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Spec: WorkerSpec{
+									JobPolicies: testCase.policies,
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					CreateFn: func(context.Context, string, Job) error {
+						return errors.New("something went wrong")
+					},
+				},
+				substrate: &mockSubstrate{
+					DefaultImagePolicyFn: func() *ImagePolicy {
+						return testDefaultPolicy
+					},
+				},
+			}
+			testCase.assertions(
+				service.Create(context.Background(), "123456789", testCase.job),
+			)
+		})
+	}
+}
+
+func TestValidateSecurityContext(t *testing.T) {
+	rootUID := int64(0)
+	nonRootUID := int64(1000)
+	testPolicy := &JobSecurityPolicy{
+		RequireRunAsNonRoot:                 true,
+		RequireReadOnlyRootFilesystem:       true,
+		RequiredDroppedCapabilities:         []string{"NET_RAW"},
+		RequireRuntimeDefaultSeccompProfile: true,
+	}
+	testCases := []struct {
+		name       string
+		privileged bool
+		sc         *ContainerSecurityContext
+		policy     *JobSecurityPolicy
+		assertions func(error)
+	}{
+		{
+			name: "no security context and no policy",
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "contradictory security context",
+			sc: &ContainerSecurityContext{
+				RunAsUser:    &rootUID,
+				RunAsNonRoot: true,
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "UID 0")
+			},
+		},
+		{
+			name:   "no security context with policy",
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "non-root")
+			},
+		},
+		{
+			name: "root with policy",
+			sc: &ContainerSecurityContext{
+				RunAsUser: &rootUID,
+			},
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "non-root")
+			},
+		},
+		{
+			name: "writable root file system with policy",
+			sc: &ContainerSecurityContext{
+				RunAsUser: &nonRootUID,
+			},
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "readOnlyRootFilesystem")
+			},
+		},
+		{
+			name: "capability not dropped with policy",
+			sc: &ContainerSecurityContext{
+				RunAsNonRoot:           true,
+				ReadOnlyRootFilesystem: true,
+				DropCapabilities:       []string{"SYS_ADMIN"},
+			},
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "NET_RAW")
+			},
+		},
+		{
+			name: "wrong seccomp profile with policy",
+			sc: &ContainerSecurityContext{
+				RunAsNonRoot:           true,
+				ReadOnlyRootFilesystem: true,
+				DropCapabilities:       []string{"ALL"},
+				SeccompProfile:         SeccompProfileUnconfined,
+			},
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "seccomp")
+			},
+		},
+		{
+			name:       "privileged without policy",
+			privileged: true,
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "privileged with empty policy",
+			privileged: true,
+			policy:     &JobSecurityPolicy{},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "privileged")
+			},
+		},
+		{
+			name:       "privileged with policy that allows it",
+			privileged: true,
+			policy: &JobSecurityPolicy{
+				AllowPrivileged: true,
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "privileged and otherwise compliant with policy",
+			privileged: true,
+			sc: &ContainerSecurityContext{
+				RunAsUser:              &nonRootUID,
+				ReadOnlyRootFilesystem: true,
+				DropCapabilities:       []string{"NET_RAW"},
+				SeccompProfile:         SeccompProfileRuntimeDefault,
+			},
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "privileged")
+			},
+		},
+		{
+			name: "compliant with policy",
+			sc: &ContainerSecurityContext{
+				RunAsUser:              &nonRootUID,
+				ReadOnlyRootFilesystem: true,
+				DropCapabilities:       []string{"NET_RAW"},
+				SeccompProfile:         SeccompProfileRuntimeDefault,
+			},
+			policy: testPolicy,
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				validateSecurityContext(
+					"italian",
+					testCase.privileged,
+					testCase.sc,
+					testCase.policy,
+				),
+			)
+		})
+	}
+}
+
+func TestJobsServiceCreateCached(t *testing.T) {
+	const testEventID = "123456789"
+	const testJobName = "italian"
+	testCases := []struct {
+		name       string
+		job        Job
+		service    JobsService
+		assertions func(error)
+	}{
+		{
+			name: "cache policy with shared workspace",
+			job: Job{
+				Name: testJobName,
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						WorkspaceMountPath: "/var/workspace",
+					},
+					Cache: &JobCachePolicy{},
+				},
+			},
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Spec: WorkerSpec{
+									UseWorkspace: true,
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				substrate: &mockSubstrate{},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+			},
+		},
+		{
+			name: "error searching store for cached results",
+			job: Job{
+				Name: testJobName,
+				Spec: JobSpec{
+					Cache: &JobCachePolicy{},
+				},
+			},
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					GetByCacheKeyFn: func(
+						context.Context,
+						string,
+						string,
+					) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+				substrate: &mockSubstrate{},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error searching store")
+			},
+		},
+		{
+			name: "cache miss",
+			job: Job{
+				Name: testJobName,
+				Spec: JobSpec{
+					Cache: &JobCachePolicy{},
+				},
+			},
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					GetByCacheKeyFn: func(
+						context.Context,
+						string,
+						string,
+					) (Event, error) {
+						return Event{}, &meta.ErrNotFound{}
+					},
+					CreateFn: func(_ context.Context, _ string, job Job) error {
+						require.NotEmpty(t, job.CacheKey)
+						require.Equal(t, JobPhasePending, job.Status.Phase)
+						require.False(t, job.Status.Cached)
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
+						context.Context,
+						Project,
+						string,
+						string,
+						JobSpec,
+					) error {
+						return nil
+					},
+					ScheduleJobFn: func(context.Context, Project, Event, string) error {
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "source not pinned to a commit",
+			job: Job{
+				Name: testJobName,
+				Spec: JobSpec{
+					PrimaryContainer: JobContainerSpec{
+						SourceMountPath: "/var/vcs",
+					},
+					Cache: &JobCachePolicy{},
+				},
+			},
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Spec: WorkerSpec{
+									Git: &GitConfig{
+										CloneURL: "https://github.com/brigadecore/empty-testbed.git", // nolint: lll
+										Ref:      "main",
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					// GetByCacheKeyFn deliberately not mocked; it should not be called
+					CreateFn: func(_ context.Context, _ string, job Job) error {
+						require.Empty(t, job.CacheKey)
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StoreJobEnvironmentFn: func(
+						context.Context,
+						Project,
+						string,
+						string,
+						JobSpec,
+					) error {
+						return nil
+					},
+					ScheduleJobFn: func(context.Context, Project, Event, string) error {
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "cache hit",
+			job: Job{
+				Name: testJobName,
+				Spec: JobSpec{
+					Cache: &JobCachePolicy{},
+				},
+			},
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					GetByCacheKeyFn: func(
+						context.Context,
+						string,
+						string,
+					) (Event, error) {
+						return Event{
+							ObjectMeta: meta.ObjectMeta{
+								ID: "abcdefg",
+							},
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase:   JobPhaseSucceeded,
+											Outputs: map[string]string{"foo": "bar"},
+										},
+									},
+								},
+							},
+						}, nil
+					},
+					CreateFn: func(_ context.Context, _ string, job Job) error {
+						require.Equal(t, JobPhaseSucceeded, job.Status.Phase)
+						require.True(t, job.Status.Cached)
+						require.Equal(t, "abcdefg", job.Status.LogsEventID)
+						require.Equal(
+							t,
+							map[string]string{"foo": "bar"},
+							job.Status.Outputs,
+						)
+						return nil
+					},
+				},
+				// The substrate should not be used at all
+				substrate: &mockSubstrate{},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				testCase.service.Create(
+					context.Background(),
+					testEventID,
+					testCase.job,
+				),
+			)
+		})
+	}
+}
+
+func TestGetJobCacheKey(t *testing.T) {
+	testJob := Job{
+		Spec: JobSpec{
+			PrimaryContainer: JobContainerSpec{
+				ContainerSpec: ContainerSpec{
+					Image: "debian:latest",
+				},
+				SourceMountPath: "/var/vcs",
+			},
+			Cache: &JobCachePolicy{
+				Inputs: map[string]string{
+					"foo": "bar",
+				},
+			},
+		},
+	}
+	testEvent := Event{
+		Worker: Worker{
+			Spec: WorkerSpec{
+				Git: &GitConfig{
+					CloneURL: "https://github.com/brigadecore/empty-testbed.git",
+					Commit:   "1234567",
+				},
+			},
+		},
+	}
+	key, ok, err := getJobCacheKey(testEvent, testJob)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotEmpty(t, key)
+
+	// Same inputs; same key
+	key2, _, err := getJobCacheKey(testEvent, testJob)
+	require.NoError(t, err)
+	require.Equal(t, key, key2)
+
+	// Different declared inputs; different key
+	testJob2 := testJob
+	testJob2.Spec.Cache = &JobCachePolicy{
+		Inputs: map[string]string{
+			"foo": "bat",
+		},
+	}
+	key2, _, err = getJobCacheKey(testEvent, testJob2)
+	require.NoError(t, err)
+	require.NotEqual(t, key, key2)
+
+	// Different commit; different key
+	testEvent2 := testEvent
+	testEvent2.Worker.Spec.Git = &GitConfig{
+		CloneURL: "https://github.com/brigadecore/empty-testbed.git",
+		Commit:   "abcdefg",
+	}
+	key2, _, err = getJobCacheKey(testEvent2, testJob)
+	require.NoError(t, err)
+	require.NotEqual(t, key, key2)
+
+	// No commit; no key
+	testEvent2.Worker.Spec.Git = &GitConfig{
+		CloneURL: "https://github.com/brigadecore/empty-testbed.git",
+		Ref:      "main",
+	}
+	_, ok, err = getJobCacheKey(testEvent2, testJob)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Project secret referenced by a sidecar; no key
+	testJob2 = testJob
+	testJob2.Spec.SidecarContainers = map[string]JobContainerSpec{
+		"helper": {
+			SecretEnvironment: map[string]string{
+				"TOKEN": "apiToken",
+			},
+		},
+	}
+	_, ok, err = getJobCacheKey(testEvent, testJob2)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Project secret mounted by an init container; no key
+	testJob2 = testJob
+	testJob2.Spec.InitContainers = []JobInitContainerSpec{
+		{
+			Name: "setup",
+			JobContainerSpec: JobContainerSpec{
+				SecretMounts: []SecretMount{
+					{
+						Key:       "kubeconfig",
+						MountPath: "/root/.kube/config",
+					},
+				},
+			},
+		},
+	}
+	_, ok, err = getJobCacheKey(testEvent, testJob2)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestJobsServiceStart(t *testing.T) {
+	const testEventID = "123456789"
+	const testJobName = "foo"
+	var hashedJobToken string
+	testCases := []struct {
+		name       string
+		service    JobsService
+		assertions func(error)
+	}{
+		{
+			name: "unauthorized",
+			service: &jobsService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error getting event from store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "event has no such job",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+		{
+			name: "job is not currently pending",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhaseRunning,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+			},
+		},
+		{
+			name: "event is suspended",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: WorkerPhaseSuspended,
+								},
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "is suspended")
+			},
+		},
+		{
+			name: "error getting project from store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving project")
+			},
+		},
+		{
+			name: "error updating job status",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateStatusFn: func(
+						context.Context,
+						string, string,
+						JobStatus,
+					) error {
+						return errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating status of event")
+			},
+		},
+		{
+			name: "error updating hashed token in store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateStatusFn: func(
+						context.Context,
+						string, string,
+						JobStatus,
+					) error {
+						return nil
+					},
+					UpdateHashedTokenFn: func(
+						context.Context,
+						string,
+						string,
+						string,
+					) error {
+						return errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating event")
+			},
+		},
+		{
+			name: "error starting job on substrate",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateStatusFn: func(
+						context.Context,
+						string, string,
+						JobStatus,
+					) error {
+						return nil
+					},
+					UpdateHashedTokenFn: func(
+						context.Context,
+						string,
+						string,
+						string,
+					) error {
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StartJobFn: func(
+						context.Context,
+						Project,
+						Event,
+						string,
+						string,
+					) error {
+						return errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error starting event")
+			},
+		},
+		{
+			name: "success",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateStatusFn: func(
+						context.Context,
+						string, string,
+						JobStatus,
+					) error {
+						return nil
+					},
+					UpdateHashedTokenFn: func(
+						_ context.Context,
+						_ string,
+						_ string,
+						hashedToken string,
+					) error {
+						hashedJobToken = hashedToken
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					StartJobFn: func(
+						_ context.Context,
+						_ Project,
+						_ Event,
+						_ string,
+						token string,
+					) error {
+						// The job must be given the token whose hash was stored
+						require.NotEmpty(t, token)
+						require.Equal(t, crypto.Hash("", token), hashedJobToken)
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err :=
+				testCase.service.Start(context.Background(), testEventID, testJobName)
+			testCase.assertions(err)
+		})
+	}
+}
+
+func TestJobsServiceGetStatus(t *testing.T) {
+	const testEventID = "123456789"
+	const testJobName = "italian"
+	testJobStatus := JobStatus{
+		Phase: JobPhaseRunning,
+	}
+	testCases := []struct {
+		name       string
+		service    JobsService
+		assertions func(JobStatus, error)
+	}{
+		{
+			name: "unauthorized",
+			service: &jobsService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(_ JobStatus, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error getting event from store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(_ JobStatus, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "job not found",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+			},
+			assertions: func(_ JobStatus, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+		{
+			name: "success",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name:   testJobName,
+										Status: &testJobStatus,
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(status JobStatus, err error) {
+				require.NoError(t, err)
+				require.Equal(t, testJobStatus, status)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				testCase.service.GetStatus(
+					context.Background(),
+					testEventID,
+					testJobName,
+				),
+			)
+		})
+	}
+}
+
+func TestJobsServiceWatchStatus(t *testing.T) {
+	const testEventID = "123456789"
+	const testJobName = "italian"
+	testJobStatus := JobStatus{
+		Phase: JobPhaseRunning,
+	}
+	testCases := []struct {
+		name       string
+		service    JobsService
+		assertions func(context.Context, <-chan JobStatus, error)
+	}{
+		{
+			name: "unauthorized",
+			service: &jobsService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(_ context.Context, _ <-chan JobStatus, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error getting event from store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(_ context.Context, _ <-chan JobStatus, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "job not found",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+			},
+			assertions: func(_ context.Context, _ <-chan JobStatus, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+		{
+			name: "success",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name:   testJobName,
+										Status: &testJobStatus,
+									},
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(
+				ctx context.Context,
+				statusCh <-chan JobStatus,
+				err error,
+			) {
+				require.NoError(t, err)
+				select {
+				case status := <-statusCh:
+					require.Equal(t, testJobStatus, status)
+				case <-ctx.Done():
+					require.Fail(t, "didn't receive status update over channel")
+				}
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			statusCh, err :=
+				testCase.service.WatchStatus(ctx, testEventID, testJobName)
+			testCase.assertions(ctx, statusCh, err)
+			cancel()
+		})
+	}
+}
+
+func TestJobsServiceUpdateStatus(t *testing.T) {
+	const testEventID = "123456789"
+	const testJobName = "italian"
+	testCases := []struct {
+		name       string
+		service    JobsService
+		assertions func(error)
+	}{
+		{
+			name: "unauthorized",
+			service: &jobsService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error retrieving event from store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "job not found",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), `Job "italian" not found`)
+			},
+		},
+		{
+			name: "error updating job in store",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhasePending,
+										},
+									},
 								},
 							},
 						}, nil
 					},
 				},
-				projectsStore: &mockProjectsStore{
-					GetFn: func(context.Context, string) (Project, error) {
-						return Project{}, nil
-					},
-				},
 				jobsStore: &mockJobsStore{
 					UpdateStatusFn: func(
 						context.Context,
-						string, string,
+						string,
+						string,
 						JobStatus,
 					) error {
-						return nil
-					},
-				},
-				substrate: &mockSubstrate{
-					StartJobFn: func(context.Context, Project, Event, string) error {
 						return errors.New("something went wrong")
 					},
 				},
@@ -835,11 +3306,11 @@ func TestJobsServiceStart(t *testing.T) {
 			assertions: func(err error) {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error starting event")
+				require.Contains(t, err.Error(), "error updating status of event")
 			},
 		},
 		{
-			name: "success",
+			name: "job's phase already terminal",
 			service: &jobsService{
 				authorize: alwaysAuthorize,
 				eventsStore: &mockEventsStore{
@@ -850,7 +3321,7 @@ func TestJobsServiceStart(t *testing.T) {
 									{
 										Name: testJobName,
 										Status: &JobStatus{
-											Phase: JobPhasePending,
+											Phase: JobPhaseCanceled,
 										},
 									},
 								},
@@ -858,22 +3329,97 @@ func TestJobsServiceStart(t *testing.T) {
 						}, nil
 					},
 				},
-				projectsStore: &mockProjectsStore{
-					GetFn: func(context.Context, string) (Project, error) {
-						return Project{}, nil
-					},
-				},
 				jobsStore: &mockJobsStore{
 					UpdateStatusFn: func(
 						context.Context,
-						string, string,
+						string,
+						string,
 						JobStatus,
 					) error {
+						require.Fail(
+							t,
+							"UpdateStatusFn should not have been called, but was",
+						)
 						return nil
 					},
 				},
-				substrate: &mockSubstrate{
-					StartJobFn: func(context.Context, Project, Event, string) error {
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(
+					t,
+					err.Error(),
+					`job "italian" has already reached a terminal phase`,
+				)
+			},
+		},
+		{
+			name: "progress is preserved",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhaseRunning,
+											Progress: &JobProgress{
+												Percent: 42,
+											},
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateStatusFn: func(
+						_ context.Context,
+						_ string,
+						_ string,
+						status JobStatus,
+					) error {
+						require.NotNil(t, status.Progress)
+						require.Equal(t, 42, status.Progress.Percent)
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "success",
+			service: &jobsService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhaseRunning,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateStatusFn: func(
+						context.Context,
+						string,
+						string,
+						JobStatus,
+					) error {
 						return nil
 					},
 				},
@@ -885,36 +3431,37 @@ func TestJobsServiceStart(t *testing.T) {
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			err :=
-				testCase.service.Start(context.Background(), testEventID, testJobName)
+			err := testCase.service.UpdateStatus(
+				context.Background(),
+				testEventID,
+				testJobName,
+				JobStatus{},
+			)
 			testCase.assertions(err)
 		})
 	}
 }
 
-func TestJobsServiceGetStatus(t *testing.T) {
+func TestJobsServiceUpdateProgress(t *testing.T) {
 	const testEventID = "123456789"
 	const testJobName = "italian"
-	testJobStatus := JobStatus{
-		Phase: JobPhaseRunning,
-	}
 	testCases := []struct {
 		name       string
 		service    JobsService
-		assertions func(JobStatus, error)
+		assertions func(error)
 	}{
 		{
 			name: "unauthorized",
 			service: &jobsService{
 				authorize: neverAuthorize,
 			},
-			assertions: func(_ JobStatus, err error) {
+			assertions: func(err error) {
 				require.Error(t, err)
 				require.IsType(t, &meta.ErrAuthorization{}, err)
 			},
 		},
 		{
-			name: "error getting event from store",
+			name: "error retrieving event from store",
 			service: &jobsService{
 				authorize: alwaysAuthorize,
 				eventsStore: &mockEventsStore{
@@ -923,7 +3470,7 @@ func TestJobsServiceGetStatus(t *testing.T) {
 					},
 				},
 			},
-			assertions: func(_ JobStatus, err error) {
+			assertions: func(err error) {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), "something went wrong")
 				require.Contains(t, err.Error(), "error retrieving event")
@@ -939,13 +3486,13 @@ func TestJobsServiceGetStatus(t *testing.T) {
 					},
 				},
 			},
-			assertions: func(_ JobStatus, err error) {
+			assertions: func(err error) {
 				require.Error(t, err)
 				require.IsType(t, &meta.ErrNotFound{}, err)
 			},
 		},
 		{
-			name: "success",
+			name: "job not running",
 			service: &jobsService{
 				authorize: alwaysAuthorize,
 				eventsStore: &mockEventsStore{
@@ -954,8 +3501,10 @@ func TestJobsServiceGetStatus(t *testing.T) {
 							Worker: Worker{
 								Jobs: []Job{
 									{
-										Name:   testJobName,
-										Status: &testJobStatus,
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhaseSucceeded,
+										},
 									},
 								},
 							},
@@ -963,75 +3512,47 @@ func TestJobsServiceGetStatus(t *testing.T) {
 					},
 				},
 			},
-			assertions: func(status JobStatus, err error) {
-				require.NoError(t, err)
-				require.Equal(t, testJobStatus, status)
-			},
-		},
-	}
-	for _, testCase := range testCases {
-		t.Run(testCase.name, func(t *testing.T) {
-			testCase.assertions(
-				testCase.service.GetStatus(
-					context.Background(),
-					testEventID,
-					testJobName,
-				),
-			)
-		})
-	}
-}
-
-func TestJobsServiceWatchStatus(t *testing.T) {
-	const testEventID = "123456789"
-	const testJobName = "italian"
-	testJobStatus := JobStatus{
-		Phase: JobPhaseRunning,
-	}
-	testCases := []struct {
-		name       string
-		service    JobsService
-		assertions func(context.Context, <-chan JobStatus, error)
-	}{
-		{
-			name: "unauthorized",
-			service: &jobsService{
-				authorize: neverAuthorize,
-			},
-			assertions: func(_ context.Context, _ <-chan JobStatus, err error) {
-				require.Error(t, err)
-				require.IsType(t, &meta.ErrAuthorization{}, err)
-			},
-		},
-		{
-			name: "error getting event from store",
-			service: &jobsService{
-				authorize: alwaysAuthorize,
-				eventsStore: &mockEventsStore{
-					GetFn: func(context.Context, string) (Event, error) {
-						return Event{}, errors.New("something went wrong")
-					},
-				},
-			},
-			assertions: func(_ context.Context, _ <-chan JobStatus, err error) {
+			assertions: func(err error) {
 				require.Error(t, err)
-				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error retrieving event")
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "the job is not running")
 			},
 		},
 		{
-			name: "job not found",
+			name: "error updating progress in store",
 			service: &jobsService{
 				authorize: alwaysAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
-						return Event{}, nil
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhaseRunning,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				jobsStore: &mockJobsStore{
+					UpdateProgressFn: func(
+						context.Context,
+						string,
+						string,
+						JobProgress,
+					) error {
+						return errors.New("something went wrong")
 					},
 				},
 			},
-			assertions: func(_ context.Context, _ <-chan JobStatus, err error) {
+			assertions: func(err error) {
 				require.Error(t, err)
-				require.IsType(t, &meta.ErrNotFound{}, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating progress of event")
 			},
 		},
 		{
@@ -1044,42 +3565,52 @@ func TestJobsServiceWatchStatus(t *testing.T) {
 							Worker: Worker{
 								Jobs: []Job{
 									{
-										Name:   testJobName,
-										Status: &testJobStatus,
+										Name: testJobName,
+										Status: &JobStatus{
+											Phase: JobPhaseRunning,
+										},
 									},
 								},
 							},
 						}, nil
 					},
 				},
+				jobsStore: &mockJobsStore{
+					UpdateProgressFn: func(
+						_ context.Context,
+						_ string,
+						_ string,
+						progress JobProgress,
+					) error {
+						require.Equal(t, 50, progress.Percent)
+						require.Equal(t, "halfway there", progress.Message)
+						require.NotNil(t, progress.Updated)
+						return nil
+					},
+				},
 			},
-			assertions: func(
-				ctx context.Context,
-				statusCh <-chan JobStatus,
-				err error,
-			) {
+			assertions: func(err error) {
 				require.NoError(t, err)
-				select {
-				case status := <-statusCh:
-					require.Equal(t, testJobStatus, status)
-				case <-ctx.Done():
-					require.Fail(t, "didn't receive status update over channel")
-				}
 			},
 		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-			statusCh, err :=
-				testCase.service.WatchStatus(ctx, testEventID, testJobName)
-			testCase.assertions(ctx, statusCh, err)
-			cancel()
+			err := testCase.service.UpdateProgress(
+				context.Background(),
+				testEventID,
+				testJobName,
+				JobProgress{
+					Percent: 50,
+					Message: "halfway there",
+				},
+			)
+			testCase.assertions(err)
 		})
 	}
 }
 
-func TestJobsServiceUpdateStatus(t *testing.T) {
+func TestJobsServiceCancel(t *testing.T) {
 	const testEventID = "123456789"
 	const testJobName = "italian"
 	testCases := []struct {
@@ -1088,35 +3619,39 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 		assertions func(error)
 	}{
 		{
-			name: "unauthorized",
+			name: "error getting event from store",
 			service: &jobsService{
-				authorize: neverAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
-				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
 			},
 		},
 		{
-			name: "error retrieving event from store",
+			name: "unauthorized",
 			service: &jobsService{
-				authorize: alwaysAuthorize,
+				projectAuthorize: neverProjectAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
-						return Event{}, errors.New("something went wrong")
+						return Event{}, nil
 					},
 				},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
-				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error retrieving event")
+				require.IsType(t, &meta.ErrAuthorization{}, err)
 			},
 		},
 		{
-			name: "job not found",
+			name: "event has no such job",
 			service: &jobsService{
-				authorize: alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
 						return Event{}, nil
@@ -1125,13 +3660,13 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
-				require.Contains(t, err.Error(), `Job "italian" not found`)
+				require.IsType(t, &meta.ErrNotFound{}, err)
 			},
 		},
 		{
-			name: "error updating job in store",
+			name: "job already in terminal phase",
 			service: &jobsService{
-				authorize: alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
 						return Event{
@@ -1140,7 +3675,7 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 									{
 										Name: testJobName,
 										Status: &JobStatus{
-											Phase: JobPhasePending,
+											Phase: JobPhaseSucceeded,
 										},
 									},
 								},
@@ -1148,27 +3683,16 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 						}, nil
 					},
 				},
-				jobsStore: &mockJobsStore{
-					UpdateStatusFn: func(
-						context.Context,
-						string,
-						string,
-						JobStatus,
-					) error {
-						return errors.New("something went wrong")
-					},
-				},
 			},
 			assertions: func(err error) {
 				require.Error(t, err)
-				require.Contains(t, err.Error(), "something went wrong")
-				require.Contains(t, err.Error(), "error updating status of event")
+				require.IsType(t, &meta.ErrConflict{}, err)
 			},
 		},
 		{
-			name: "job's phase already terminal",
+			name: "pending job is canceled",
 			service: &jobsService{
-				authorize: alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
 						return Event{
@@ -1177,7 +3701,7 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 									{
 										Name: testJobName,
 										Status: &JobStatus{
-											Phase: JobPhaseCanceled,
+											Phase: JobPhasePending,
 										},
 									},
 								},
@@ -1187,32 +3711,35 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 				},
 				jobsStore: &mockJobsStore{
 					UpdateStatusFn: func(
-						context.Context,
-						string,
-						string,
-						JobStatus,
+						_ context.Context,
+						_ string,
+						_ string,
+						status JobStatus,
 					) error {
-						require.Fail(
-							t,
-							"UpdateStatusFn should not have been called, but was",
-						)
+						require.Equal(t, JobPhaseCanceled, status.Phase)
+						require.NotNil(t, status.Ended)
+						return nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				substrate: &mockSubstrate{
+					DeleteJobFn: func(context.Context, Project, Event, string) error {
 						return nil
 					},
 				},
 			},
 			assertions: func(err error) {
-				require.Error(t, err)
-				require.Contains(
-					t,
-					err.Error(),
-					`job "italian" has already reached a terminal phase`,
-				)
+				require.NoError(t, err)
 			},
 		},
 		{
-			name: "success",
+			name: "running job is aborted",
 			service: &jobsService{
-				authorize: alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
 				eventsStore: &mockEventsStore{
 					GetFn: func(context.Context, string) (Event, error) {
 						return Event{
@@ -1231,29 +3758,42 @@ func TestJobsServiceUpdateStatus(t *testing.T) {
 				},
 				jobsStore: &mockJobsStore{
 					UpdateStatusFn: func(
-						context.Context,
-						string,
-						string,
-						JobStatus,
+						_ context.Context,
+						_ string,
+						_ string,
+						status JobStatus,
 					) error {
+						require.Equal(t, JobPhaseAborted, status.Phase)
 						return nil
 					},
 				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				substrate: &mockSubstrate{
+					DeleteJobFn: func(context.Context, Project, Event, string) error {
+						return errors.New("something went wrong")
+					},
+				},
 			},
 			assertions: func(err error) {
-				require.NoError(t, err)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error deleting event")
 			},
 		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			err := testCase.service.UpdateStatus(
-				context.Background(),
-				testEventID,
-				testJobName,
-				JobStatus{},
+			testCase.assertions(
+				testCase.service.Cancel(
+					context.Background(),
+					testEventID,
+					testJobName,
+				),
 			)
-			testCase.assertions(err)
 		})
 	}
 }
@@ -1573,6 +4113,26 @@ func TestJobSpecEqualTo(t *testing.T) {
 	var testJobHost = JobHost{
 		OS:           "brigOS",
 		NodeSelector: map[string]string{},
+		SchedulingConstraints: SchedulingConstraints{
+			Tolerations: []Toleration{
+				{
+					Key: "spot",
+				},
+			},
+			NodeAffinity: []NodeAffinityTerm{
+				{
+					Key:      "disktype",
+					Operator: AffinityOperatorExists,
+					Values:   []string{},
+				},
+			},
+			PodAffinity: []PodAffinityTerm{
+				{
+					MatchLabels: map[string]string{},
+					TopologyKey: "zone",
+				},
+			},
+		},
 	}
 	var testContainerSpec = ContainerSpec{
 		Image:           "imagine",
@@ -1602,6 +4162,70 @@ func TestJobSpecEqualTo(t *testing.T) {
 		specs      []JobSpec
 		assertions func(equal bool)
 	}{
+		{
+			name: "cache policies not equal",
+			specs: []JobSpec{
+				{
+					PrimaryContainer: testJobContainerSpec,
+					SidecarContainers: map[string]JobContainerSpec{
+						"sidecar": testJobContainerSpec,
+					},
+					TimeoutDuration: "1ms",
+					Host:            &testJobHost,
+					Cache: &JobCachePolicy{
+						Inputs: map[string]string{"foo": "bar"},
+					},
+				},
+				testJobSpec,
+			},
+			assertions: func(equal bool) {
+				require.False(t, equal)
+			},
+		},
+		{
+			name: "hosts not equal",
+			specs: []JobSpec{
+				{
+					PrimaryContainer: testJobContainerSpec,
+					SidecarContainers: map[string]JobContainerSpec{
+						"sidecar": testJobContainerSpec,
+					},
+					TimeoutDuration: "1ms",
+					Host: &JobHost{
+						OS:           "brigOS",
+						NodeSelector: map[string]string{},
+						SchedulingConstraints: SchedulingConstraints{
+							// Different tolerations
+							Tolerations: []Toleration{
+								{
+									Key: "gpu",
+								},
+							},
+						},
+					},
+				},
+				testJobSpec,
+			},
+			assertions: func(equal bool) {
+				require.False(t, equal)
+			},
+		},
+		{
+			name: "one host nil",
+			specs: []JobSpec{
+				{
+					PrimaryContainer: testJobContainerSpec,
+					SidecarContainers: map[string]JobContainerSpec{
+						"sidecar": testJobContainerSpec,
+					},
+					TimeoutDuration: "1ms",
+				},
+				testJobSpec,
+			},
+			assertions: func(equal bool) {
+				require.False(t, equal)
+			},
+		},
 		{
 			name: "not equal",
 			specs: []JobSpec{
@@ -1617,6 +4241,29 @@ func TestJobSpecEqualTo(t *testing.T) {
 				require.False(t, equal)
 			},
 		},
+		{
+			name: "init containers not equal",
+			specs: []JobSpec{
+				{
+					PrimaryContainer: testJobContainerSpec,
+					SidecarContainers: map[string]JobContainerSpec{
+						"sidecar": testJobContainerSpec,
+					},
+					InitContainers: []JobInitContainerSpec{
+						{
+							Name:             "init",
+							JobContainerSpec: testJobContainerSpec,
+						},
+					},
+					TimeoutDuration: "1ms",
+					Host:            &testJobHost,
+				},
+				testJobSpec,
+			},
+			assertions: func(equal bool) {
+				require.False(t, equal)
+			},
+		},
 		{
 			name:  "all fields empty; equal",
 			specs: []JobSpec{{}, {}},
@@ -1653,13 +4300,30 @@ func TestJobSpecEqualTo(t *testing.T) {
 }
 
 type mockJobsStore struct {
-	CreateFn       func(ctx context.Context, eventID string, job Job) error
+	CreateFn        func(ctx context.Context, eventID string, job Job) error
+	GetByCacheKeyFn func(
+		ctx context.Context,
+		projectID string,
+		cacheKey string,
+	) (Event, error)
 	UpdateStatusFn func(
 		ctx context.Context,
 		eventID string,
 		jobName string,
 		status JobStatus,
 	) error
+	UpdateHashedTokenFn func(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		hashedToken string,
+	) error
+	UpdateProgressFn func(
+		ctx context.Context,
+		eventID string,
+		jobName string,
+		progress JobProgress,
+	) error
 }
 
 func (m *mockJobsStore) Create(
@@ -1670,6 +4334,14 @@ func (m *mockJobsStore) Create(
 	return m.CreateFn(ctx, eventID, job)
 }
 
+func (m *mockJobsStore) GetByCacheKey(
+	ctx context.Context,
+	projectID string,
+	cacheKey string,
+) (Event, error) {
+	return m.GetByCacheKeyFn(ctx, projectID, cacheKey)
+}
+
 func (m *mockJobsStore) UpdateStatus(
 	ctx context.Context,
 	eventID string,
@@ -1678,3 +4350,21 @@ func (m *mockJobsStore) UpdateStatus(
 ) error {
 	return m.UpdateStatusFn(ctx, eventID, jobName, status)
 }
+
+func (m *mockJobsStore) UpdateHashedToken(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	hashedToken string,
+) error {
+	return m.UpdateHashedTokenFn(ctx, eventID, jobName, hashedToken)
+}
+
+func (m *mockJobsStore) UpdateProgress(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	progress JobProgress,
+) error {
+	return m.UpdateProgressFn(ctx, eventID, jobName, progress)
+}
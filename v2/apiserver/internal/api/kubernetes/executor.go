@@ -0,0 +1,139 @@
+package kubernetes
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// executor is a Kubernetes-based implementation of the api.Executor interface.
+type executor struct {
+	config     *rest.Config
+	kubeClient kubernetes.Interface
+	// The following behaviors are overridable for test purposes
+	getPodNameFn func(
+		ctx context.Context,
+		kubeClient kubernetes.Interface,
+		namespace string,
+		eventID string,
+		jobName string,
+	) (string, error)
+	newSPDYExecutorFn func(
+		config *rest.Config,
+		method string,
+		url *url.URL,
+	) (remotecommand.Executor, error)
+}
+
+// NewExecutor returns a Kubernetes-based implementation of the api.Executor
+// interface. It executes commands within a Worker or Job's underlying pod using
+// that pod's exec subresource.
+func NewExecutor(
+	config *rest.Config,
+	kubeClient kubernetes.Interface,
+) api.Executor {
+	return &executor{
+		config:            config,
+		kubeClient:        kubeClient,
+		getPodNameFn:      getPodName,
+		newSPDYExecutorFn: remotecommand.NewSPDYExecutor,
+	}
+}
+
+func (e *executor) Exec(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	selector api.ExecSelector,
+	opts api.ExecOptions,
+	streams api.ExecStreams,
+) error {
+	podName, err := e.getPodNameFn(
+		ctx,
+		e.kubeClient,
+		project.Kubernetes.Namespace,
+		event.ID,
+		selector.Job,
+	)
+	if err != nil {
+		return err
+	}
+
+	req := e.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(project.Kubernetes.Namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(
+			&corev1.PodExecOptions{
+				Container: selector.Container,
+				Command:   opts.Command,
+				Stdin:     streams.Stdin != nil,
+				Stdout:    streams.Stdout != nil,
+				Stderr:    streams.Stderr != nil && !opts.TTY,
+				TTY:       opts.TTY,
+			},
+			scheme.ParameterCodec,
+		)
+
+	exec, err := e.newSPDYExecutorFn(e.config, "POST", req.URL())
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error preparing to execute command in pod %q container %q",
+			podName,
+			selector.Container,
+		)
+	}
+
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:  streams.Stdin,
+		Stdout: streams.Stdout,
+		Tty:    opts.TTY,
+	}
+	if !opts.TTY {
+		streamOpts.Stderr = streams.Stderr
+	}
+	if opts.TTY && streams.TerminalSizes != nil {
+		streamOpts.TerminalSizeQueue = terminalSizeQueue(streams.TerminalSizes)
+	}
+
+	// Note that this blocks until the command exits or the streams are closed.
+	if err = exec.Stream(streamOpts); err != nil {
+		if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.Exited() {
+			return &api.ExecExitError{
+				ExitCode: exitErr.ExitStatus(),
+			}
+		}
+		return errors.Wrapf(
+			err,
+			"error executing command in pod %q container %q",
+			podName,
+			selector.Container,
+		)
+	}
+	return nil
+}
+
+// terminalSizeQueue adapts a channel of api.TerminalSizes to the
+// remotecommand.TerminalSizeQueue interface.
+type terminalSizeQueue <-chan api.TerminalSize
+
+func (t terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-t
+	if !ok {
+		return nil
+	}
+	return &remotecommand.TerminalSize{
+		Width:  size.Width,
+		Height: size.Height,
+	}
+}
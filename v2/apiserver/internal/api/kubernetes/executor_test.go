@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func TestNewExecutor(t *testing.T) {
+	config := &rest.Config{}
+	kubeClient := fake.NewSimpleClientset()
+	e, ok := NewExecutor(config, kubeClient).(*executor)
+	require.True(t, ok)
+	require.Same(t, config, e.config)
+	require.Same(t, kubeClient, e.kubeClient)
+	require.NotNil(t, e.getPodNameFn)
+	require.NotNil(t, e.newSPDYExecutorFn)
+}
+
+func TestExecutorExec(t *testing.T) {
+	const testEventID = "123456789"
+	const testNamespace = "foo"
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: testNamespace,
+		},
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: testEventID,
+		},
+	}
+	config := &rest.Config{
+		Host: "https://kubernetes.example.com",
+	}
+	// The fake clientset's REST client is unusable, so we use a real one. No
+	// requests are actually sent to the API server.
+	kubeClient, err := kubernetes.NewForConfig(config)
+	require.NoError(t, err)
+	getPodNameFn := func(
+		_ context.Context,
+		_ kubernetes.Interface,
+		_ string,
+		eventID string,
+		jobName string,
+	) (string, error) {
+		return podNameFromSelector(eventID, api.LogsSelector{Job: jobName}), nil
+	}
+	testCases := []struct {
+		name       string
+		selector   api.ExecSelector
+		executor   *executor
+		assertions func(error)
+	}{
+		{
+			name: "error getting pod name",
+			selector: api.ExecSelector{
+				Container: "worker",
+			},
+			executor: &executor{
+				config:     config,
+				kubeClient: kubeClient,
+				getPodNameFn: func(
+					context.Context,
+					kubernetes.Interface,
+					string,
+					string,
+					string,
+				) (string, error) {
+					return "", errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			name: "error preparing executor",
+			selector: api.ExecSelector{
+				Container: "worker",
+			},
+			executor: &executor{
+				config:       config,
+				kubeClient:   kubeClient,
+				getPodNameFn: getPodNameFn,
+				newSPDYExecutorFn: func(
+					*rest.Config,
+					string,
+					*url.URL,
+				) (remotecommand.Executor, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error preparing to execute command")
+			},
+		},
+		{
+			name: "command exits with non-zero exit code",
+			selector: api.ExecSelector{
+				Container: "worker",
+			},
+			executor: &executor{
+				config:       config,
+				kubeClient:   kubeClient,
+				getPodNameFn: getPodNameFn,
+				newSPDYExecutorFn: func(
+					_ *rest.Config,
+					method string,
+					url *url.URL,
+				) (remotecommand.Executor, error) {
+					require.Equal(t, "POST", method)
+					require.Equal(
+						t,
+						"/api/v1/namespaces/foo/pods/"+
+							myk8s.WorkerPodName(testEventID)+"/exec",
+						url.Path,
+					)
+					require.Equal(t, "worker", url.Query().Get("container"))
+					return &mockSPDYExecutor{
+						err: utilexec.CodeExitError{
+							Err:  errors.New("command terminated"),
+							Code: 2,
+						},
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &api.ExecExitError{}, err)
+				require.Equal(t, 2, err.(*api.ExecExitError).ExitCode)
+			},
+		},
+		{
+			name: "error streaming",
+			selector: api.ExecSelector{
+				Container: "worker",
+			},
+			executor: &executor{
+				config:       config,
+				kubeClient:   kubeClient,
+				getPodNameFn: getPodNameFn,
+				newSPDYExecutorFn: func(
+					*rest.Config,
+					string,
+					*url.URL,
+				) (remotecommand.Executor, error) {
+					return &mockSPDYExecutor{
+						err: errors.New("something went wrong"),
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error executing command")
+			},
+		},
+		{
+			name: "success with job container",
+			selector: api.ExecSelector{
+				Job:       "italian",
+				Container: "italian",
+			},
+			executor: &executor{
+				config:       config,
+				kubeClient:   kubeClient,
+				getPodNameFn: getPodNameFn,
+				newSPDYExecutorFn: func(
+					_ *rest.Config,
+					_ string,
+					url *url.URL,
+				) (remotecommand.Executor, error) {
+					require.Equal(
+						t,
+						"/api/v1/namespaces/foo/pods/"+
+							myk8s.JobPodName(testEventID, "italian")+"/exec",
+						url.Path,
+					)
+					return &mockSPDYExecutor{}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.executor.Exec(
+				context.Background(),
+				testProject,
+				testEvent,
+				testCase.selector,
+				api.ExecOptions{
+					Command: []string{"ls"},
+				},
+				api.ExecStreams{},
+			)
+			testCase.assertions(err)
+		})
+	}
+}
+
+type mockSPDYExecutor struct {
+	err error
+}
+
+func (m *mockSPDYExecutor) Stream(remotecommand.StreamOptions) error {
+	return m.err
+}
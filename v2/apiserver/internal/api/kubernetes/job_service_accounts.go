@@ -0,0 +1,250 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// defaultJobServiceAccount is the name of the ServiceAccount that the
+// substrate provisions in every Project's namespace for use by the Project's
+// Jobs.
+const defaultJobServiceAccount = "jobs"
+
+// validateJobServiceAccounts returns a *meta.ErrBadRequest error if the
+// provided Project declares any JobServiceAccount with an invalid or reserved
+// name, declares any JobServiceAccount more than once, or names a default
+// JobServiceAccount that it does not declare.
+func validateJobServiceAccounts(project api.Project) error {
+	if project.Kubernetes == nil {
+		return nil
+	}
+	names := map[string]struct{}{}
+	for _, serviceAccount := range project.Kubernetes.JobServiceAccounts {
+		var reason string
+		if errs :=
+			validation.IsDNS1123Subdomain(serviceAccount.Name); len(errs) > 0 {
+			reason = fmt.Sprintf(
+				"Job service account name %q is invalid: %s",
+				serviceAccount.Name,
+				strings.Join(errs, "; "),
+			)
+		} else if serviceAccount.Name == defaultJobServiceAccount ||
+			serviceAccount.Name == "workers" {
+			reason = fmt.Sprintf(
+				"Job service account name %q is reserved.",
+				serviceAccount.Name,
+			)
+		} else if _, ok := names[serviceAccount.Name]; ok {
+			reason = fmt.Sprintf(
+				"Job service account %q is declared more than once.",
+				serviceAccount.Name,
+			)
+		}
+		if reason != "" {
+			return &meta.ErrBadRequest{
+				Reason: reason,
+			}
+		}
+		names[serviceAccount.Name] = struct{}{}
+	}
+	defaultName := project.Kubernetes.DefaultJobServiceAccount
+	if defaultName != "" {
+		if _, ok := names[defaultName]; !ok {
+			return &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Default job service account %q is not declared by project %q.",
+					defaultName,
+					project.ID,
+				),
+			}
+		}
+	}
+	return nil
+}
+
+// reconcileJobServiceAccounts creates or updates the ServiceAccounts declared
+// by the provided Project, in the specified namespace, and deletes any that
+// the substrate previously provisioned for the Project but that it no longer
+// declares. The Jobs' RoleBinding is updated so that every ServiceAccount
+// available to the Project's Jobs is bound to the Jobs' RBAC Role.
+func (s *substrate) reconcileJobServiceAccounts(
+	ctx context.Context,
+	namespace string,
+	project api.Project,
+) error {
+	var declared []api.JobServiceAccount
+	if project.Kubernetes != nil {
+		declared = project.Kubernetes.JobServiceAccounts
+	}
+	saLabels := map[string]string{
+		myk8s.LabelBrigadeID: s.config.BrigadeID,
+		myk8s.LabelComponent: myk8s.LabelKeyJobServiceAccount,
+		myk8s.LabelProject:   project.ID,
+	}
+	serviceAccountsClient := s.kubeClient.CoreV1().ServiceAccounts(namespace)
+
+	subjects := []rbacv1.Subject{
+		{
+			Kind:      "ServiceAccount",
+			Name:      defaultJobServiceAccount,
+			Namespace: namespace,
+		},
+	}
+	names := make(map[string]struct{}, len(declared))
+	for _, serviceAccount := range declared {
+		names[serviceAccount.Name] = struct{}{}
+		subjects = append(
+			subjects,
+			rbacv1.Subject{
+				Kind:      "ServiceAccount",
+				Name:      serviceAccount.Name,
+				Namespace: namespace,
+			},
+		)
+		existing, err :=
+			serviceAccountsClient.Get(ctx, serviceAccount.Name, metav1.GetOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error retrieving service account %q in namespace %q",
+				serviceAccount.Name,
+				namespace,
+			)
+		}
+		if err != nil {
+			if _, err = serviceAccountsClient.Create(
+				ctx,
+				&corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        serviceAccount.Name,
+						Labels:      saLabels,
+						Annotations: serviceAccount.Annotations,
+					},
+				},
+				metav1.CreateOptions{},
+			); err != nil {
+				return errors.Wrapf(
+					err,
+					"error creating service account %q in namespace %q",
+					serviceAccount.Name,
+					namespace,
+				)
+			}
+			continue
+		}
+		// Never commandeer a ServiceAccount that the substrate did not provision
+		// for this Project, e.g. one that pre-existed in a claimed namespace
+		if !labels.SelectorFromSet(saLabels).Matches(labels.Set(existing.Labels)) {
+			return &meta.ErrConflict{
+				Type: "ServiceAccount",
+				ID:   serviceAccount.Name,
+				Reason: fmt.Sprintf(
+					"Service account %q already exists in namespace %q and is not "+
+						"managed by Brigade.",
+					serviceAccount.Name,
+					namespace,
+				),
+			}
+		}
+		existing = existing.DeepCopy()
+		existing.Annotations = serviceAccount.Annotations
+		if _, err = serviceAccountsClient.Update(
+			ctx,
+			existing,
+			metav1.UpdateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error updating service account %q in namespace %q",
+				serviceAccount.Name,
+				namespace,
+			)
+		}
+	}
+
+	serviceAccounts, err := serviceAccountsClient.List(
+		ctx,
+		metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(saLabels).String(),
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error listing job service accounts in namespace %q",
+			namespace,
+		)
+	}
+	for _, serviceAccount := range serviceAccounts.Items {
+		if _, ok := names[serviceAccount.Name]; ok {
+			continue
+		}
+		if err = serviceAccountsClient.Delete(
+			ctx,
+			serviceAccount.Name,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting service account %q in namespace %q",
+				serviceAccount.Name,
+				namespace,
+			)
+		}
+	}
+
+	roleBinding, err := s.kubeClient.RbacV1().RoleBindings(namespace).Get(
+		ctx,
+		defaultJobServiceAccount,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving role binding %q in namespace %q",
+			defaultJobServiceAccount,
+			namespace,
+		)
+	}
+	roleBinding = roleBinding.DeepCopy()
+	roleBinding.Subjects = subjects
+	if _, err = s.kubeClient.RbacV1().RoleBindings(namespace).Update(
+		ctx,
+		roleBinding,
+		metav1.UpdateOptions{},
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating role binding %q in namespace %q",
+			defaultJobServiceAccount,
+			namespace,
+		)
+	}
+	return nil
+}
+
+// jobServiceAccount returns the name of the ServiceAccount that the specified
+// Job should run as. This is the ServiceAccount requested by the Job itself,
+// if any, or else the Project's default.
+func jobServiceAccount(project api.Project, jobSpec api.JobSpec) string {
+	if jobSpec.ServiceAccount != "" {
+		return jobSpec.ServiceAccount
+	}
+	if project.Kubernetes != nil &&
+		project.Kubernetes.DefaultJobServiceAccount != "" {
+		return project.Kubernetes.DefaultJobServiceAccount
+	}
+	return defaultJobServiceAccount
+}
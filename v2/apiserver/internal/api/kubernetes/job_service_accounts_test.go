@@ -0,0 +1,282 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateJobServiceAccounts(t *testing.T) {
+	testCases := []struct {
+		name       string
+		kubernetes *api.KubernetesDetails
+		assertions func(error)
+	}{
+		{
+			name: "nothing declared",
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "invalid name",
+			kubernetes: &api.KubernetesDetails{
+				JobServiceAccounts: []api.JobServiceAccount{
+					{
+						Name: "Not_Valid",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is invalid")
+			},
+		},
+		{
+			name: "reserved name",
+			kubernetes: &api.KubernetesDetails{
+				JobServiceAccounts: []api.JobServiceAccount{
+					{
+						Name: "workers",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is reserved")
+			},
+		},
+		{
+			name: "duplicate name",
+			kubernetes: &api.KubernetesDetails{
+				JobServiceAccounts: []api.JobServiceAccount{
+					{
+						Name: "deployer",
+					},
+					{
+						Name: "deployer",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "more than once")
+			},
+		},
+		{
+			name: "undeclared default",
+			kubernetes: &api.KubernetesDetails{
+				DefaultJobServiceAccount: "deployer",
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "is not declared")
+			},
+		},
+		{
+			name: "success",
+			kubernetes: &api.KubernetesDetails{
+				JobServiceAccounts: []api.JobServiceAccount{
+					{
+						Name: "deployer",
+					},
+				},
+				DefaultJobServiceAccount: "deployer",
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				validateJobServiceAccounts(
+					api.Project{
+						Kubernetes: testCase.kubernetes,
+					},
+				),
+			)
+		})
+	}
+}
+
+func TestSubstrateReconcileJobServiceAccounts(t *testing.T) {
+	const testNamespace = "foo"
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Kubernetes: &api.KubernetesDetails{
+			JobServiceAccounts: []api.JobServiceAccount{
+				{
+					Name: "deployer",
+					Annotations: map[string]string{
+						"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/deployer", // nolint: lll
+					},
+				},
+				{
+					Name: "publisher",
+				},
+			},
+		},
+	}
+	testLabels := map[string]string{
+		myk8s.LabelBrigadeID: "4077th",
+		myk8s.LabelComponent: myk8s.LabelKeyJobServiceAccount,
+		myk8s.LabelProject:   "italian",
+	}
+	getJobsRoleBinding := func() *rbacv1.RoleBinding {
+		return &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "jobs",
+				Namespace: testNamespace,
+			},
+		}
+	}
+	testCases := []struct {
+		name       string
+		objects    []runtime.Object
+		assertions func(error, *fake.Clientset)
+	}{
+		{
+			name: "service account exists and is not managed by brigade",
+			objects: []runtime.Object{
+				getJobsRoleBinding(),
+				&corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "publisher",
+						Namespace: testNamespace,
+					},
+				},
+			},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "not managed by Brigade")
+			},
+		},
+		{
+			name:    "jobs role binding does not exist",
+			objects: []runtime.Object{},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error retrieving role binding")
+			},
+		},
+		{
+			name: "success",
+			objects: []runtime.Object{
+				getJobsRoleBinding(),
+				// This one should be updated
+				&corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deployer",
+						Namespace: testNamespace,
+						Labels:    testLabels,
+					},
+				},
+				// This one is no longer declared and should be deleted
+				&corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "tester",
+						Namespace: testNamespace,
+						Labels:    testLabels,
+					},
+				},
+				// This one isn't managed by Brigade and should be left alone
+				&corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "default",
+						Namespace: testNamespace,
+					},
+				},
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				serviceAccounts, err :=
+					kubeClient.CoreV1().ServiceAccounts(testNamespace).List(
+						context.Background(),
+						metav1.ListOptions{},
+					)
+				require.NoError(t, err)
+				names := []string{}
+				for _, serviceAccount := range serviceAccounts.Items {
+					names = append(names, serviceAccount.Name)
+					if serviceAccount.Name == "deployer" {
+						require.Equal(
+							t,
+							testProject.Kubernetes.JobServiceAccounts[0].Annotations,
+							serviceAccount.Annotations,
+						)
+					}
+				}
+				require.ElementsMatch(
+					t,
+					[]string{"default", "deployer", "publisher"},
+					names,
+				)
+				roleBinding, err :=
+					kubeClient.RbacV1().RoleBindings(testNamespace).Get(
+						context.Background(),
+						"jobs",
+						metav1.GetOptions{},
+					)
+				require.NoError(t, err)
+				require.Len(t, roleBinding.Subjects, 3)
+				require.Equal(t, "jobs", roleBinding.Subjects[0].Name)
+				require.Equal(t, "deployer", roleBinding.Subjects[1].Name)
+				require.Equal(t, "publisher", roleBinding.Subjects[2].Name)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(testCase.objects...)
+			s := &substrate{
+				kubeClient: kubeClient,
+				config: SubstrateConfig{
+					BrigadeID: "4077th",
+				},
+			}
+			err := s.reconcileJobServiceAccounts(
+				context.Background(),
+				testNamespace,
+				testProject,
+			)
+			testCase.assertions(err, kubeClient)
+		})
+	}
+}
+
+func TestJobServiceAccount(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			DefaultJobServiceAccount: "deployer",
+		},
+	}
+	require.Equal(t, "jobs", jobServiceAccount(api.Project{}, api.JobSpec{}))
+	require.Equal(
+		t,
+		"deployer",
+		jobServiceAccount(testProject, api.JobSpec{}),
+	)
+	require.Equal(
+		t,
+		"publisher",
+		jobServiceAccount(testProject, api.JobSpec{ServiceAccount: "publisher"}),
+	)
+}
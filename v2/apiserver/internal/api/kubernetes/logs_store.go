@@ -15,6 +15,8 @@ import (
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -46,7 +48,16 @@ func (l *logsStore) StreamLogs(
 	selector api.LogsSelector,
 	opts api.LogStreamOptions,
 ) (<-chan api.LogEntry, error) {
-	podName := podNameFromSelector(event.ID, selector)
+	podName, err := getPodName(
+		ctx,
+		l.kubeClient,
+		project.Kubernetes.Namespace,
+		event.ID,
+		selector.Job,
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	req := l.kubeClient.CoreV1().Pods(project.Kubernetes.Namespace).GetLogs(
 		podName,
@@ -65,7 +76,6 @@ func (l *logsStore) StreamLogs(
 	// logs. If it exists, but the target container is still initializing, we
 	// retry.
 	var podLogs io.ReadCloser
-	var err error
 	if err = retries.ManageRetries(
 		ctx,
 		"waiting for container to be initialized",
@@ -146,6 +156,68 @@ func (l *logsStore) StreamLogs(
 	return logEntryCh, nil
 }
 
+// getPodName returns the name of the pod underlying the specified Event's
+// Worker or, if a Job name is specified, the specified Job. Pods created
+// directly by the substrate have predictable names, but pods created on the
+// substrate's behalf by batch/v1 Jobs do not, so if no pod bearing the
+// predictable name exists, the most recently created pod bearing the Worker or
+// Job's labels is used instead. If there is no such pod either, the
+// predictable name is returned.
+func getPodName(
+	ctx context.Context,
+	kubeClient kubernetes.Interface,
+	namespace string,
+	eventID string,
+	jobName string,
+) (string, error) {
+	podName := podNameFromSelector(eventID, api.LogsSelector{Job: jobName})
+	podsClient := kubeClient.CoreV1().Pods(namespace)
+	_, err := podsClient.Get(ctx, podName, metav1.GetOptions{})
+	if err == nil {
+		return podName, nil
+	}
+	if !k8sErrors.IsNotFound(err) {
+		return "", errors.Wrapf(
+			err,
+			"error retrieving pod %q in namespace %q",
+			podName,
+			namespace,
+		)
+	}
+	podLabels := map[string]string{
+		myk8s.LabelComponent: myk8s.LabelKeyWorker,
+		myk8s.LabelEvent:     eventID,
+	}
+	if jobName != "" {
+		podLabels[myk8s.LabelComponent] = myk8s.LabelKeyJob
+		podLabels[myk8s.LabelJob] = jobName
+	}
+	pods, err := podsClient.List(
+		ctx,
+		metav1.ListOptions{
+			LabelSelector: labels.Set(podLabels).AsSelector().String(),
+		},
+	)
+	if err != nil {
+		return "", errors.Wrapf(
+			err,
+			"error listing pods in namespace %q",
+			namespace,
+		)
+	}
+	var newestPod *v1.Pod
+	for i, pod := range pods.Items {
+		if newestPod == nil ||
+			newestPod.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			newestPod = &pods.Items[i]
+		}
+	}
+	if newestPod == nil {
+		return podName, nil
+	}
+	return newestPod.Name, nil
+}
+
 func podNameFromSelector(eventID string, selector api.LogsSelector) string {
 	if selector.Job == "" { // We want worker logs
 		return myk8s.WorkerPodName(eventID)
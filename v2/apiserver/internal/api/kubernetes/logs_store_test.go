@@ -1,11 +1,15 @@
 package kubernetes
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -53,3 +57,99 @@ func TestPodNameFromSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPodName(t *testing.T) {
+	const testNamespace = "foo"
+	const testEventID = "123456789"
+	const testJobName = "italian"
+	testCases := []struct {
+		name            string
+		jobName         string
+		pods            []corev1.Pod
+		expectedPodName string
+	}{
+		{
+			name:            "no pods exist",
+			expectedPodName: myk8s.WorkerPodName(testEventID),
+		},
+		{
+			name:    "pod with predictable name exists",
+			jobName: testJobName,
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      myk8s.JobPodName(testEventID, testJobName),
+						Namespace: testNamespace,
+					},
+				},
+			},
+			expectedPodName: myk8s.JobPodName(testEventID, testJobName),
+		},
+		{
+			name:    "pods created by batch job exist",
+			jobName: testJobName,
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "older",
+						Namespace: testNamespace,
+						Labels: map[string]string{
+							myk8s.LabelComponent: myk8s.LabelKeyJob,
+							myk8s.LabelEvent:     testEventID,
+							myk8s.LabelJob:       testJobName,
+						},
+						CreationTimestamp: metav1.NewTime(
+							time.Now().Add(-time.Minute),
+						),
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "newer",
+						Namespace: testNamespace,
+						Labels: map[string]string{
+							myk8s.LabelComponent: myk8s.LabelKeyJob,
+							myk8s.LabelEvent:     testEventID,
+							myk8s.LabelJob:       testJobName,
+						},
+						CreationTimestamp: metav1.Now(),
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "unrelated",
+						Namespace: testNamespace,
+						Labels: map[string]string{
+							myk8s.LabelComponent: myk8s.LabelKeyWorker,
+							myk8s.LabelEvent:     testEventID,
+						},
+						CreationTimestamp: metav1.Now(),
+					},
+				},
+			},
+			expectedPodName: "newer",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			for i := range testCase.pods {
+				_, err := kubeClient.CoreV1().Pods(testNamespace).Create(
+					context.Background(),
+					&testCase.pods[i],
+					metav1.CreateOptions{},
+				)
+				require.NoError(t, err)
+			}
+			podName, err := getPodName(
+				context.Background(),
+				kubeClient,
+				testNamespace,
+				testEventID,
+				testCase.jobName,
+			)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedPodName, podName)
+		})
+	}
+}
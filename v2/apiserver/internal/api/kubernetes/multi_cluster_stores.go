@@ -0,0 +1,170 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// multiClusterSecretsStore is a Kubernetes-based implementation of the
+// api.SecretsStore interface that spans several named clusters. Each Project's
+// Secrets are stored on the cluster that the Project is pinned to.
+type multiClusterSecretsStore struct {
+	// secretsStores maps cluster names to an api.SecretsStore for each cluster.
+	// The default cluster is keyed by the empty string.
+	secretsStores map[string]api.SecretsStore
+}
+
+// NewMultiClusterSecretsStore returns a Kubernetes-based implementation of the
+// api.SecretsStore interface that spans the clusters for which clients are
+// provided. The provided map of clients MUST include a client for the default
+// cluster, keyed by the empty string.
+func NewMultiClusterSecretsStore(
+	kubeClients map[string]kubernetes.Interface,
+) api.SecretsStore {
+	m := &multiClusterSecretsStore{
+		secretsStores: make(map[string]api.SecretsStore, len(kubeClients)),
+	}
+	for name, kubeClient := range kubeClients {
+		m.secretsStores[name] = NewSecretsStore(kubeClient)
+	}
+	return m
+}
+
+func (m *multiClusterSecretsStore) List(
+	ctx context.Context,
+	project api.Project,
+	opts meta.ListOptions,
+) (meta.List[api.Secret], error) {
+	secretsStore, ok := m.secretsStores[projectCluster(project)]
+	if !ok {
+		return meta.List[api.Secret]{},
+			errUnknownCluster(projectCluster(project))
+	}
+	return secretsStore.List(ctx, project, opts)
+}
+
+func (m *multiClusterSecretsStore) Set(
+	ctx context.Context,
+	project api.Project,
+	secret api.Secret,
+) error {
+	secretsStore, ok := m.secretsStores[projectCluster(project)]
+	if !ok {
+		return errUnknownCluster(projectCluster(project))
+	}
+	return secretsStore.Set(ctx, project, secret)
+}
+
+func (m *multiClusterSecretsStore) Unset(
+	ctx context.Context,
+	project api.Project,
+	key string,
+) error {
+	secretsStore, ok := m.secretsStores[projectCluster(project)]
+	if !ok {
+		return errUnknownCluster(projectCluster(project))
+	}
+	return secretsStore.Unset(ctx, project, key)
+}
+
+// multiClusterLogsStore is a Kubernetes-based implementation of the
+// api.LogsStore interface that spans several named clusters. Logs are streamed
+// from whichever cluster the selected Worker or Job is running on.
+type multiClusterLogsStore struct {
+	// logsStores maps cluster names to an api.LogsStore for each cluster. The
+	// default cluster is keyed by the empty string.
+	logsStores map[string]api.LogsStore
+}
+
+// NewMultiClusterLogsStore returns a Kubernetes-based implementation of the
+// api.LogsStore interface that spans the clusters for which clients are
+// provided. The provided map of clients MUST include a client for the default
+// cluster, keyed by the empty string.
+func NewMultiClusterLogsStore(
+	kubeClients map[string]kubernetes.Interface,
+) api.LogsStore {
+	m := &multiClusterLogsStore{
+		logsStores: make(map[string]api.LogsStore, len(kubeClients)),
+	}
+	for name, kubeClient := range kubeClients {
+		m.logsStores[name] = NewLogsStore(kubeClient)
+	}
+	return m
+}
+
+func (m *multiClusterLogsStore) StreamLogs(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	selector api.LogsSelector,
+	opts api.LogStreamOptions,
+) (<-chan api.LogEntry, error) {
+	cluster := eventJobCluster(project, event, selector.Job)
+	logsStore, ok := m.logsStores[cluster]
+	if !ok {
+		return nil, errUnknownCluster(cluster)
+	}
+	return logsStore.StreamLogs(ctx, project, event, selector, opts)
+}
+
+// multiClusterExecutor is a Kubernetes-based implementation of the
+// api.Executor interface that spans several named clusters. Commands are
+// executed on whichever cluster the selected Worker or Job is running on.
+type multiClusterExecutor struct {
+	// executors maps cluster names to an api.Executor for each cluster. The
+	// default cluster is keyed by the empty string.
+	executors map[string]api.Executor
+}
+
+// NewMultiClusterExecutor returns a Kubernetes-based implementation of the
+// api.Executor interface that spans the clusters for which configuration and
+// clients are provided. The provided maps MUST include configuration and a
+// client for the default cluster, keyed by the empty string.
+func NewMultiClusterExecutor(
+	configs map[string]*rest.Config,
+	kubeClients map[string]kubernetes.Interface,
+) api.Executor {
+	m := &multiClusterExecutor{
+		executors: make(map[string]api.Executor, len(kubeClients)),
+	}
+	for name, kubeClient := range kubeClients {
+		m.executors[name] = NewExecutor(configs[name], kubeClient)
+	}
+	return m
+}
+
+func (m *multiClusterExecutor) Exec(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	selector api.ExecSelector,
+	opts api.ExecOptions,
+	streams api.ExecStreams,
+) error {
+	cluster := eventJobCluster(project, event, selector.Job)
+	executor, ok := m.executors[cluster]
+	if !ok {
+		return errUnknownCluster(cluster)
+	}
+	return executor.Exec(ctx, project, event, selector, opts, streams)
+}
+
+// eventJobCluster returns the name of the cluster that the specified Job,
+// spawned by the provided Event's Worker, was routed to. If no Job is
+// specified, the name of the cluster hosting the Worker itself is returned.
+func eventJobCluster(
+	project api.Project,
+	event api.Event,
+	jobName string,
+) string {
+	if jobName != "" {
+		if job, ok := event.Worker.Job(jobName); ok {
+			return jobCluster(project, job.Spec)
+		}
+	}
+	return projectCluster(project)
+}
@@ -0,0 +1,128 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestMultiClusterSecretsStore(t *testing.T) {
+	getProjectSecrets := func() *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "project-secrets",
+				Namespace: "foo",
+			},
+		}
+	}
+	defaultClient := fake.NewSimpleClientset(getProjectSecrets())
+	gpuClient := fake.NewSimpleClientset(getProjectSecrets())
+	store := NewMultiClusterSecretsStore(
+		map[string]kubernetes.Interface{
+			"":    defaultClient,
+			"gpu": gpuClient,
+		},
+	)
+
+	// A Project pinned to an unknown cluster
+	err := store.Set(
+		context.Background(),
+		api.Project{
+			Kubernetes: &api.KubernetesDetails{
+				Namespace: "foo",
+				Cluster:   "bogus",
+			},
+		},
+		api.Secret{Key: "soylentgreen", Value: "people"},
+	)
+	require.Error(t, err)
+	require.IsType(t, &meta.ErrBadRequest{}, err)
+
+	// A Project pinned to the gpu cluster
+	err = store.Set(
+		context.Background(),
+		api.Project{
+			Kubernetes: &api.KubernetesDetails{
+				Namespace: "foo",
+				Cluster:   "gpu",
+			},
+		},
+		api.Secret{Key: "soylentgreen", Value: "people"},
+	)
+	require.NoError(t, err)
+	secret, err := gpuClient.CoreV1().Secrets("foo").Get(
+		context.Background(),
+		"project-secrets",
+		metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []byte("people"), secret.Data["soylentgreen"])
+	secret, err = defaultClient.CoreV1().Secrets("foo").Get(
+		context.Background(),
+		"project-secrets",
+		metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	require.Empty(t, secret.Data)
+}
+
+func TestNewMultiClusterLogsStore(t *testing.T) {
+	store, ok := NewMultiClusterLogsStore(
+		map[string]kubernetes.Interface{
+			"":    fake.NewSimpleClientset(),
+			"gpu": fake.NewSimpleClientset(),
+		},
+	).(*multiClusterLogsStore)
+	require.True(t, ok)
+	require.Len(t, store.logsStores, 2)
+}
+
+func TestNewMultiClusterExecutor(t *testing.T) {
+	gpuConfig := &rest.Config{}
+	m, ok := NewMultiClusterExecutor(
+		map[string]*rest.Config{
+			"":    {},
+			"gpu": gpuConfig,
+		},
+		map[string]kubernetes.Interface{
+			"":    fake.NewSimpleClientset(),
+			"gpu": fake.NewSimpleClientset(),
+		},
+	).(*multiClusterExecutor)
+	require.True(t, ok)
+	require.Len(t, m.executors, 2)
+	require.Same(t, gpuConfig, m.executors["gpu"].(*executor).config)
+}
+
+func TestEventJobCluster(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Cluster: "arm",
+		},
+	}
+	testEvent := api.Event{
+		Worker: api.Worker{
+			Jobs: []api.Job{
+				{
+					Name: "italian",
+					Spec: api.JobSpec{
+						Host: &api.JobHost{
+							Cluster: "gpu",
+						},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, "arm", eventJobCluster(testProject, testEvent, ""))
+	require.Equal(t, "gpu", eventJobCluster(testProject, testEvent, "italian"))
+	require.Equal(t, "arm", eventJobCluster(testProject, testEvent, "french"))
+}
@@ -0,0 +1,609 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// multiClusterSubstrate is a Kubernetes-based implementation of the
+// api.Substrate interface that spans several named clusters. Each Project
+// resides on a single cluster, but any of its Jobs may be routed to another
+// cluster, where a "satellite" namespace is provisioned on demand.
+type multiClusterSubstrate struct {
+	// substrates maps cluster names to a substrate for each cluster. The
+	// default cluster is keyed by the empty string.
+	substrates map[string]*substrate
+}
+
+// NewMultiClusterSubstrate returns a Kubernetes-based implementation of the
+// api.Substrate interface that spans the clusters for which clients are
+// provided. The provided map of clients MUST include a client for the default
+// cluster, keyed by the empty string.
+func NewMultiClusterSubstrate(
+	kubeClients map[string]kubernetes.Interface,
+	queueWriterFactory queue.WriterFactory,
+	config SubstrateConfig,
+) api.Substrate {
+	m := &multiClusterSubstrate{
+		substrates: make(map[string]*substrate, len(kubeClients)),
+	}
+	for name, kubeClient := range kubeClients {
+		m.substrates[name] =
+			NewSubstrate(kubeClient, queueWriterFactory, config).(*substrate)
+	}
+	return m
+}
+
+func (m *multiClusterSubstrate) CountRunningWorkers(
+	ctx context.Context,
+) (api.SubstrateWorkerCount, error) {
+	total := api.SubstrateWorkerCount{}
+	for name, s := range m.substrates {
+		count, err := s.CountRunningWorkers(ctx)
+		if err != nil {
+			return total, errors.Wrapf(
+				err,
+				"error counting workers on %s",
+				clusterDisplayName(name),
+			)
+		}
+		total.Count += count.Count
+	}
+	return total, nil
+}
+
+func (m *multiClusterSubstrate) CountRunningJobs(
+	ctx context.Context,
+) (api.SubstrateJobCount, error) {
+	total := api.SubstrateJobCount{}
+	for name, s := range m.substrates {
+		count, err := s.CountRunningJobs(ctx)
+		if err != nil {
+			return total, errors.Wrapf(
+				err,
+				"error counting jobs on %s",
+				clusterDisplayName(name),
+			)
+		}
+		total.Count += count.Count
+	}
+	return total, nil
+}
+
+func (m *multiClusterSubstrate) GetInventory(
+	ctx context.Context,
+) (api.SubstrateInventory, error) {
+	names := make([]string, 0, len(m.substrates))
+	for name := range m.substrates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	total := api.SubstrateInventory{
+		Projects:  []api.ProjectSubstrateInventory{},
+		NodePools: []api.NodePoolCapacity{},
+	}
+	for _, name := range names {
+		inventory, err := m.substrates[name].GetInventory(ctx)
+		if err != nil {
+			return total, errors.Wrapf(
+				err,
+				"error retrieving inventory from %s",
+				clusterDisplayName(name),
+			)
+		}
+		for _, project := range inventory.Projects {
+			project.Cluster = name
+			total.Projects = append(total.Projects, project)
+		}
+		for _, nodePool := range inventory.NodePools {
+			nodePool.Cluster = name
+			total.NodePools = append(total.NodePools, nodePool)
+		}
+	}
+	// Group each Project's entries for all clusters together
+	sort.SliceStable(total.Projects, func(i, j int) bool {
+		return total.Projects[i].ProjectID < total.Projects[j].ProjectID
+	})
+	return total, nil
+}
+
+func (m *multiClusterSubstrate) DefaultImagePolicy() *api.ImagePolicy {
+	return m.substrates[""].DefaultImagePolicy()
+}
+
+func (m *multiClusterSubstrate) CreateProject(
+	ctx context.Context,
+	project api.Project,
+) (api.Project, error) {
+	s, err := m.getSubstrate(projectCluster(project))
+	if err != nil {
+		return project, err
+	}
+	return s.CreateProject(ctx, project)
+}
+
+func (m *multiClusterSubstrate) UpdateProject(
+	ctx context.Context,
+	project api.Project,
+) error {
+	home := projectCluster(project)
+	s, err := m.getSubstrate(home)
+	if err != nil {
+		return err
+	}
+	if err = s.UpdateProject(ctx, project); err != nil {
+		return err
+	}
+	// Keep the policies in any satellite namespaces in sync as well
+	for name, s := range m.substrates {
+		if name == home {
+			continue
+		}
+		namespace, err := s.getSatelliteNamespace(ctx, project)
+		if err != nil {
+			return err
+		}
+		if namespace == nil {
+			continue
+		}
+		if err = s.UpdateProject(ctx, project); err != nil {
+			return errors.Wrapf(
+				err,
+				"error updating satellite namespace %q on %s",
+				namespace.Name,
+				clusterDisplayName(name),
+			)
+		}
+	}
+	return nil
+}
+
+func (m *multiClusterSubstrate) DeleteProject(
+	ctx context.Context,
+	project api.Project,
+) error {
+	home := projectCluster(project)
+	s, err := m.getSubstrate(home)
+	if err != nil {
+		return err
+	}
+	if err = s.DeleteProject(ctx, project); err != nil {
+		return err
+	}
+	for name, s := range m.substrates {
+		if name == home {
+			continue
+		}
+		if err = s.deleteSatelliteNamespace(ctx, project); err != nil {
+			return errors.Wrapf(
+				err,
+				"error deleting satellite namespace on %s",
+				clusterDisplayName(name),
+			)
+		}
+	}
+	return nil
+}
+
+func (m *multiClusterSubstrate) DeleteCacheVolume(
+	ctx context.Context,
+	project api.Project,
+	name string,
+) error {
+	// Jobs routed to other clusters may have provisioned the cache volume there
+	// as well
+	for _, s := range m.substrates {
+		if err := s.DeleteCacheVolume(ctx, project, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiClusterSubstrate) ScheduleWorker(
+	ctx context.Context,
+	event api.Event,
+) error {
+	return m.substrates[""].ScheduleWorker(ctx, event)
+}
+
+func (m *multiClusterSubstrate) StartWorker(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	token string,
+) error {
+	s, err := m.getSubstrate(projectCluster(project))
+	if err != nil {
+		return err
+	}
+	return s.StartWorker(ctx, project, event, token)
+}
+
+func (m *multiClusterSubstrate) StoreJobEnvironment(
+	ctx context.Context,
+	project api.Project,
+	eventID string,
+	jobName string,
+	jobSpec api.JobSpec,
+) error {
+	cluster := jobCluster(project, jobSpec)
+	s, err := m.getSubstrate(cluster)
+	if err != nil {
+		return err
+	}
+	if cluster != projectCluster(project) {
+		if (api.Job{Spec: jobSpec}).UsesWorkspace() {
+			return &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Job %q cannot use the shared workspace because it is routed "+
+						"to a different cluster than its worker.",
+					jobName,
+				),
+			}
+		}
+		if err = s.ensureSatelliteNamespace(ctx, project); err != nil {
+			return err
+		}
+	}
+	return s.StoreJobEnvironment(ctx, project, eventID, jobName, jobSpec)
+}
+
+func (m *multiClusterSubstrate) ScheduleJob(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	jobName string,
+) error {
+	return m.substrates[""].ScheduleJob(ctx, project, event, jobName)
+}
+
+func (m *multiClusterSubstrate) StartJob(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	jobName string,
+	token string,
+) error {
+	home := projectCluster(project)
+	job, _ := event.Worker.Job(jobName)
+	cluster := jobCluster(project, job.Spec)
+	s, err := m.getSubstrate(cluster)
+	if err != nil {
+		return err
+	}
+	if cluster != home {
+		homeSubstrate, err := m.getSubstrate(home)
+		if err != nil {
+			return err
+		}
+		if err = s.ensureSatelliteNamespace(ctx, project); err != nil {
+			return err
+		}
+		// The Job's pod references the Event's secret and the Project's secrets,
+		// so copies of both must exist on the Job's cluster. The Project's
+		// secrets are copied anew for each Job so that changes are picked up.
+		for _, secretName := range []string{
+			myk8s.EventSecretName(event.ID),
+			"project-secrets",
+		} {
+			if err = s.mirrorSecret(
+				ctx,
+				homeSubstrate.kubeClient,
+				project.Kubernetes.Namespace,
+				secretName,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return s.StartJob(ctx, project, event, jobName, token)
+}
+
+func (m *multiClusterSubstrate) DeleteJob(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	jobName string,
+) error {
+	job, _ := event.Worker.Job(jobName)
+	s, err := m.getSubstrate(jobCluster(project, job.Spec))
+	if err != nil {
+		return err
+	}
+	return s.DeleteJob(ctx, project, event, jobName)
+}
+
+func (m *multiClusterSubstrate) DeleteWorkerAndJobs(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+) error {
+	// Clean up on the Project's own cluster as well as on every cluster that
+	// any of the Event's Jobs were routed to
+	clusters := map[string]struct{}{
+		projectCluster(project): {},
+	}
+	for _, job := range event.Worker.Jobs {
+		clusters[jobCluster(project, job.Spec)] = struct{}{}
+	}
+	for cluster := range clusters {
+		s, err := m.getSubstrate(cluster)
+		if err != nil {
+			return err
+		}
+		if err = s.DeleteWorkerAndJobs(ctx, project, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSubstrate returns the substrate for the named cluster. A
+// *meta.ErrBadRequest is returned if no such cluster is registered.
+func (m *multiClusterSubstrate) getSubstrate(
+	cluster string,
+) (*substrate, error) {
+	s, ok := m.substrates[cluster]
+	if !ok {
+		return nil, errUnknownCluster(cluster)
+	}
+	return s, nil
+}
+
+// projectCluster returns the name of the cluster that the provided Project is
+// pinned to. The empty string denotes the default cluster.
+func projectCluster(project api.Project) string {
+	if project.Kubernetes == nil {
+		return ""
+	}
+	return project.Kubernetes.Cluster
+}
+
+// jobCluster returns the name of the cluster that a Job having the provided
+// JobSpec should be routed to. Unless the JobSpec's JobHost specifies a
+// cluster, this is the cluster that the Project is pinned to.
+func jobCluster(project api.Project, jobSpec api.JobSpec) string {
+	if jobSpec.Host != nil && jobSpec.Host.Cluster != "" {
+		return jobSpec.Host.Cluster
+	}
+	return projectCluster(project)
+}
+
+// clusterDisplayName returns a human-friendly name for the named cluster for
+// use in error messages.
+func clusterDisplayName(cluster string) string {
+	if cluster == "" {
+		return "the default cluster"
+	}
+	return fmt.Sprintf("cluster %q", cluster)
+}
+
+// errUnknownCluster returns a *meta.ErrBadRequest indicating that the named
+// cluster is not registered.
+func errUnknownCluster(cluster string) error {
+	return &meta.ErrBadRequest{
+		Reason: fmt.Sprintf("Kubernetes cluster %q is not registered.", cluster),
+	}
+}
+
+// getSatelliteNamespace returns the satellite namespace for the provided
+// Project on this substrate's cluster. If no namespace of the same name as the
+// Project's exists, nil is returned. If one exists, but does not belong to the
+// Project, a *meta.ErrConflict is returned.
+func (s *substrate) getSatelliteNamespace(
+	ctx context.Context,
+	project api.Project,
+) (*corev1.Namespace, error) {
+	namespaceName := project.Kubernetes.Namespace
+	namespace, err := s.kubeClient.CoreV1().Namespaces().Get(
+		ctx,
+		namespaceName,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil,
+			errors.Wrapf(err, "error retrieving namespace %q", namespaceName)
+	}
+	if namespace.Labels[myk8s.LabelBrigadeID] != s.config.BrigadeID ||
+		namespace.Labels[myk8s.LabelProject] != project.ID {
+		return nil, &meta.ErrConflict{
+			Type: "Namespace",
+			ID:   namespaceName,
+			Reason: fmt.Sprintf(
+				"Namespace %q already exists and does not belong to project %q.",
+				namespaceName,
+				project.ID,
+			),
+		}
+	}
+	return namespace, nil
+}
+
+// ensureSatelliteNamespace creates, if it does not already exist, a namespace
+// on this substrate's cluster having the same name as the provided Project's
+// namespace and containing everything that the Project's Jobs require. The
+// Project's resource constraints and network policy are applied to it as
+// well.
+func (s *substrate) ensureSatelliteNamespace(
+	ctx context.Context,
+	project api.Project,
+) error {
+	namespace, err := s.getSatelliteNamespace(ctx, project)
+	if err != nil {
+		return err
+	}
+	if namespace != nil {
+		return nil
+	}
+	namespaceName := project.Kubernetes.Namespace
+	if _, err = s.kubeClient.CoreV1().Namespaces().Create(
+		ctx,
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespaceName,
+				Labels: map[string]string{
+					myk8s.LabelBrigadeID: s.config.BrigadeID,
+					myk8s.LabelProject:   project.ID,
+				},
+			},
+		},
+		metav1.CreateOptions{},
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrapf(
+			err,
+			"error creating satellite namespace %q for project %q",
+			namespaceName,
+			project.ID,
+		)
+	}
+	// Each of the following tolerates the object already existing so that a
+	// partially provisioned satellite namespace is completed on a later attempt
+	if _, err = s.kubeClient.RbacV1().Roles(namespaceName).Create(
+		ctx,
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "jobs",
+			},
+			Rules: []rbacv1.PolicyRule{},
+		},
+		metav1.CreateOptions{},
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrapf(
+			err,
+			"error creating role \"jobs\" in namespace %q",
+			namespaceName,
+		)
+	}
+	if _, err = s.kubeClient.CoreV1().ServiceAccounts(namespaceName).Create(
+		ctx,
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "jobs",
+			},
+		},
+		metav1.CreateOptions{},
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrapf(
+			err,
+			"error creating service account \"jobs\" in namespace %q",
+			namespaceName,
+		)
+	}
+	if _, err = s.kubeClient.RbacV1().RoleBindings(namespaceName).Create(
+		ctx,
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "jobs",
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      "ServiceAccount",
+					Name:      "jobs",
+					Namespace: namespaceName,
+				},
+			},
+			RoleRef: rbacv1.RoleRef{
+				Kind: "Role",
+				Name: "jobs",
+			},
+		},
+		metav1.CreateOptions{},
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrapf(
+			err,
+			"error creating role binding \"jobs\" in namespace %q",
+			namespaceName,
+		)
+	}
+	return s.UpdateProject(ctx, project)
+}
+
+// deleteSatelliteNamespace deletes the provided Project's satellite namespace
+// from this substrate's cluster, if one exists.
+func (s *substrate) deleteSatelliteNamespace(
+	ctx context.Context,
+	project api.Project,
+) error {
+	namespace, err := s.getSatelliteNamespace(ctx, project)
+	if err != nil {
+		// A namespace of the same name that belongs to something else is none of
+		// our concern
+		if _, ok := errors.Cause(err).(*meta.ErrConflict); ok {
+			return nil
+		}
+		return err
+	}
+	if namespace == nil {
+		return nil
+	}
+	if err = s.kubeClient.CoreV1().Namespaces().Delete(
+		ctx,
+		namespace.Name,
+		metav1.DeleteOptions{},
+	); err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting namespace %q", namespace.Name)
+	}
+	return nil
+}
+
+// mirrorSecret copies the specified secret from the cluster of the provided
+// client to the same namespace on this substrate's cluster, creating or
+// updating the copy as required.
+func (s *substrate) mirrorSecret(
+	ctx context.Context,
+	sourceClient kubernetes.Interface,
+	namespace string,
+	name string,
+) error {
+	secret, err := sourceClient.CoreV1().Secrets(namespace).Get(
+		ctx,
+		name,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving secret %q in namespace %q",
+			name,
+			namespace,
+		)
+	}
+	mirror := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   secret.Name,
+			Labels: secret.Labels,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	secretsClient := s.kubeClient.CoreV1().Secrets(namespace)
+	if _, err = secretsClient.Create(
+		ctx,
+		mirror,
+		metav1.CreateOptions{},
+	); k8sErrors.IsAlreadyExists(err) {
+		_, err = secretsClient.Update(ctx, mirror, metav1.UpdateOptions{})
+	}
+	return errors.Wrapf(
+		err,
+		"error mirroring secret %q in namespace %q",
+		name,
+		namespace,
+	)
+}
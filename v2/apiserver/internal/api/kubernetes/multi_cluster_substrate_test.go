@@ -0,0 +1,553 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testMultiClusterBrigadeID = "4077th"
+
+// getTestMultiClusterSubstrate returns a *multiClusterSubstrate spanning a
+// default cluster and a cluster named "gpu", along with the fake clients for
+// each.
+func getTestMultiClusterSubstrate(
+	defaultObjects []runtime.Object,
+	gpuObjects []runtime.Object,
+) (*multiClusterSubstrate, *fake.Clientset, *fake.Clientset) {
+	defaultClient := fake.NewSimpleClientset(defaultObjects...)
+	gpuClient := fake.NewSimpleClientset(gpuObjects...)
+	m := NewMultiClusterSubstrate(
+		map[string]kubernetes.Interface{
+			"":    defaultClient,
+			"gpu": gpuClient,
+		},
+		nil,
+		SubstrateConfig{
+			BrigadeID: testMultiClusterBrigadeID,
+		},
+	).(*multiClusterSubstrate)
+	return m, defaultClient, gpuClient
+}
+
+func getTestSatelliteNamespace(projectID string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: testMultiClusterBrigadeID,
+				myk8s.LabelProject:   projectID,
+			},
+		},
+	}
+}
+
+func TestNewMultiClusterSubstrate(t *testing.T) {
+	m, defaultClient, gpuClient := getTestMultiClusterSubstrate(nil, nil)
+	require.Len(t, m.substrates, 2)
+	require.Same(t, defaultClient, m.substrates[""].kubeClient)
+	require.Same(t, gpuClient, m.substrates["gpu"].kubeClient)
+	require.Equal(
+		t,
+		testMultiClusterBrigadeID,
+		m.substrates["gpu"].config.BrigadeID,
+	)
+}
+
+func TestMultiClusterSubstrateCountRunningWorkers(t *testing.T) {
+	getWorkerPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bar",
+				Namespace: "foo",
+				Labels: map[string]string{
+					myk8s.LabelBrigadeID: testMultiClusterBrigadeID,
+					myk8s.LabelComponent: myk8s.LabelKeyWorker,
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+			},
+		}
+	}
+	m, _, _ := getTestMultiClusterSubstrate(
+		[]runtime.Object{getWorkerPod()},
+		[]runtime.Object{getWorkerPod()},
+	)
+	count, err := m.CountRunningWorkers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count.Count)
+}
+
+func TestMultiClusterSubstrateGetInventory(t *testing.T) {
+	getJobPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bar",
+				Namespace: "foo",
+				Labels: map[string]string{
+					myk8s.LabelBrigadeID: testMultiClusterBrigadeID,
+					myk8s.LabelComponent: myk8s.LabelKeyJob,
+					myk8s.LabelProject:   "italian",
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+			},
+		}
+	}
+	getNode := func() *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node",
+			},
+		}
+	}
+	m, _, _ := getTestMultiClusterSubstrate(
+		[]runtime.Object{getJobPod(), getNode()},
+		[]runtime.Object{getJobPod(), getNode()},
+	)
+	inventory, err := m.GetInventory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, inventory.Projects, 2)
+	require.Equal(t, "", inventory.Projects[0].Cluster)
+	require.Equal(t, "gpu", inventory.Projects[1].Cluster)
+	for _, project := range inventory.Projects {
+		require.Equal(t, "italian", project.ProjectID)
+		require.Equal(t, 1, project.Jobs.Pending)
+	}
+	require.Len(t, inventory.NodePools, 2)
+	require.Equal(t, "", inventory.NodePools[0].Cluster)
+	require.Equal(t, "gpu", inventory.NodePools[1].Cluster)
+}
+
+func TestMultiClusterSubstrateCreateProject(t *testing.T) {
+	testCases := []struct {
+		name       string
+		project    api.Project
+		assertions func(api.Project, error, *fake.Clientset, *fake.Clientset)
+	}{
+		{
+			name: "unknown cluster",
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					Cluster: "bogus",
+				},
+			},
+			assertions: func(
+				_ api.Project,
+				err error,
+				_ *fake.Clientset,
+				_ *fake.Clientset,
+			) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "not registered")
+			},
+		},
+		{
+			name: "project pinned to a cluster",
+			project: api.Project{
+				ObjectMeta: meta.ObjectMeta{
+					ID: "italian",
+				},
+				Kubernetes: &api.KubernetesDetails{
+					Cluster: "gpu",
+				},
+			},
+			assertions: func(
+				project api.Project,
+				err error,
+				defaultClient *fake.Clientset,
+				gpuClient *fake.Clientset,
+			) {
+				require.NoError(t, err)
+				_, err = gpuClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					project.Kubernetes.Namespace,
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				_, err = defaultClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					project.Kubernetes.Namespace,
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			m, defaultClient, gpuClient := getTestMultiClusterSubstrate(nil, nil)
+			project, err := m.CreateProject(context.Background(), testCase.project)
+			testCase.assertions(project, err, defaultClient, gpuClient)
+		})
+	}
+}
+
+func TestMultiClusterSubstrateDeleteProject(t *testing.T) {
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: "foo",
+		},
+	}
+	testCases := []struct {
+		name       string
+		gpuObjects []runtime.Object
+		assertions func(error, *fake.Clientset)
+	}{
+		{
+			name: "satellite namespace is deleted",
+			gpuObjects: []runtime.Object{
+				getTestSatelliteNamespace(testProject.ID),
+			},
+			assertions: func(err error, gpuClient *fake.Clientset) {
+				require.NoError(t, err)
+				_, err = gpuClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					"foo",
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+			},
+		},
+		{
+			name: "namespace belonging to something else is left intact",
+			gpuObjects: []runtime.Object{
+				getTestSatelliteNamespace("unrelated"),
+			},
+			assertions: func(err error, gpuClient *fake.Clientset) {
+				require.NoError(t, err)
+				_, err = gpuClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					"foo",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			m, _, gpuClient := getTestMultiClusterSubstrate(
+				[]runtime.Object{getTestSatelliteNamespace(testProject.ID)},
+				testCase.gpuObjects,
+			)
+			err := m.DeleteProject(context.Background(), testProject)
+			testCase.assertions(err, gpuClient)
+		})
+	}
+}
+
+func TestMultiClusterSubstrateStoreJobEnvironment(t *testing.T) {
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: "foo",
+		},
+	}
+	testCases := []struct {
+		name       string
+		jobSpec    api.JobSpec
+		gpuObjects []runtime.Object
+		assertions func(error, *fake.Clientset, *fake.Clientset)
+	}{
+		{
+			name: "job routed to unknown cluster",
+			jobSpec: api.JobSpec{
+				Host: &api.JobHost{
+					Cluster: "bogus",
+				},
+			},
+			assertions: func(err error, _, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "not registered")
+			},
+		},
+		{
+			name: "job routed to another cluster uses workspace",
+			jobSpec: api.JobSpec{
+				PrimaryContainer: api.JobContainerSpec{
+					WorkspaceMountPath: "/workspace",
+				},
+				Host: &api.JobHost{
+					Cluster: "gpu",
+				},
+			},
+			assertions: func(err error, _, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "shared workspace")
+			},
+		},
+		{
+			name: "namespace on other cluster belongs to something else",
+			jobSpec: api.JobSpec{
+				Host: &api.JobHost{
+					Cluster: "gpu",
+				},
+			},
+			gpuObjects: []runtime.Object{
+				getTestSatelliteNamespace("unrelated"),
+			},
+			assertions: func(err error, _, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+			},
+		},
+		{
+			name: "job not routed",
+			jobSpec: api.JobSpec{
+				Host: &api.JobHost{},
+			},
+			assertions: func(err error, defaultClient, gpuClient *fake.Clientset) {
+				require.NoError(t, err)
+				_, err = defaultClient.CoreV1().Secrets("foo").Get(
+					context.Background(),
+					myk8s.JobSecretName("tunguska", "bar"),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				_, err = gpuClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					"foo",
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+			},
+		},
+		{
+			name: "job routed to another cluster",
+			jobSpec: api.JobSpec{
+				Host: &api.JobHost{
+					Cluster: "gpu",
+				},
+			},
+			assertions: func(err error, _, gpuClient *fake.Clientset) {
+				require.NoError(t, err)
+				namespace, err := gpuClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					"foo",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(t, "italian", namespace.Labels[myk8s.LabelProject])
+				_, err = gpuClient.CoreV1().ServiceAccounts("foo").Get(
+					context.Background(),
+					"jobs",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				_, err = gpuClient.CoreV1().Secrets("foo").Get(
+					context.Background(),
+					myk8s.JobSecretName("tunguska", "bar"),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			m, defaultClient, gpuClient :=
+				getTestMultiClusterSubstrate(nil, testCase.gpuObjects)
+			err := m.StoreJobEnvironment(
+				context.Background(),
+				testProject,
+				"tunguska",
+				"bar",
+				testCase.jobSpec,
+			)
+			testCase.assertions(err, defaultClient, gpuClient)
+		})
+	}
+}
+
+func TestMultiClusterSubstrateStartJob(t *testing.T) {
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: "foo",
+		},
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "tunguska",
+		},
+		Worker: api.Worker{
+			Jobs: []api.Job{
+				{
+					Name: "bar",
+					Spec: api.JobSpec{
+						Host: &api.JobHost{
+							Cluster: "gpu",
+						},
+					},
+				},
+			},
+		},
+	}
+	m, _, gpuClient := getTestMultiClusterSubstrate(
+		[]runtime.Object{
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      myk8s.EventSecretName(testEvent.ID),
+					Namespace: "foo",
+					Labels: map[string]string{
+						myk8s.LabelEvent: testEvent.ID,
+					},
+				},
+				Data: map[string][]byte{
+					"event.json": []byte("{}"),
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "project-secrets",
+					Namespace: "foo",
+				},
+			},
+		},
+		nil,
+	)
+	var startedOn kubernetes.Interface
+	for _, s := range m.substrates {
+		s := s
+		s.createJobPodFn = func(
+			context.Context,
+			api.Project,
+			api.Event,
+			string,
+			api.JobSpec,
+		) error {
+			startedOn = s.kubeClient
+			return nil
+		}
+	}
+	err := m.StartJob(
+		context.Background(),
+		testProject,
+		testEvent,
+		"bar",
+		"opensesame",
+	)
+	require.NoError(t, err)
+	require.Same(t, gpuClient, startedOn)
+	eventSecret, err := gpuClient.CoreV1().Secrets("foo").Get(
+		context.Background(),
+		myk8s.EventSecretName(testEvent.ID),
+		metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	require.Equal(t, testEvent.ID, eventSecret.Labels[myk8s.LabelEvent])
+	require.Equal(t, []byte("{}"), eventSecret.Data["event.json"])
+	tokenSecret, err := gpuClient.CoreV1().Secrets("foo").Get(
+		context.Background(),
+		myk8s.JobTokenSecretName(testEvent.ID, "bar"),
+		metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "opensesame", tokenSecret.StringData["apiToken"])
+	_, err = gpuClient.CoreV1().Secrets("foo").Get(
+		context.Background(),
+		"project-secrets",
+		metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+}
+
+func TestSubstrateMirrorSecret(t *testing.T) {
+	sourceClient := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bar",
+				Namespace: "foo",
+			},
+			Data: map[string][]byte{
+				"key": []byte("new"),
+			},
+		},
+	)
+	testCases := []struct {
+		name          string
+		targetObjects []runtime.Object
+	}{
+		{
+			name: "mirror does not exist yet",
+		},
+		{
+			name: "mirror already exists",
+			targetObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "bar",
+						Namespace: "foo",
+					},
+					Data: map[string][]byte{
+						"key": []byte("old"),
+					},
+				},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			targetClient := fake.NewSimpleClientset(testCase.targetObjects...)
+			s := &substrate{
+				kubeClient: targetClient,
+			}
+			err := s.mirrorSecret(context.Background(), sourceClient, "foo", "bar")
+			require.NoError(t, err)
+			secret, err := targetClient.CoreV1().Secrets("foo").Get(
+				context.Background(),
+				"bar",
+				metav1.GetOptions{},
+			)
+			require.NoError(t, err)
+			require.Equal(t, []byte("new"), secret.Data["key"])
+		})
+	}
+}
+
+func TestJobCluster(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Cluster: "arm",
+		},
+	}
+	require.Equal(t, "", jobCluster(api.Project{}, api.JobSpec{}))
+	require.Equal(t, "arm", jobCluster(testProject, api.JobSpec{}))
+	require.Equal(
+		t,
+		"gpu",
+		jobCluster(
+			testProject,
+			api.JobSpec{
+				Host: &api.JobHost{
+					Cluster: "gpu",
+				},
+			},
+		),
+	)
+}
@@ -0,0 +1,402 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// DefaultPodOverlayDeniedFields enumerates the pod fields that Projects' pod
+// overlays may not modify when an administrator has not specified any. Each is
+// a path of field names separated by dots. Lists are traversed implicitly, so,
+// for instance, "spec.containers.image" denotes the image of every container.
+// Everything following "metadata.annotations." or "metadata.labels." is a
+// single annotation or label key, which may itself contain dots. A field name
+// or key ending in "*" denotes every field name or key beginning with what
+// precedes the "*".
+var DefaultPodOverlayDeniedFields = []string{
+	"metadata.annotations.container.apparmor.security.beta.kubernetes.io/*",
+	"spec.affinity",
+	"spec.automountServiceAccountToken",
+	"spec.containers.image",
+	"spec.containers.ports.hostPort",
+	"spec.containers.securityContext",
+	"spec.ephemeralContainers",
+	"spec.hostIPC",
+	"spec.hostNetwork",
+	"spec.hostPID",
+	"spec.hostUsers",
+	"spec.initContainers.image",
+	"spec.initContainers.ports.hostPort",
+	"spec.initContainers.securityContext",
+	"spec.nodeName",
+	"spec.nodeSelector",
+	"spec.priority",
+	"spec.priorityClassName",
+	"spec.runtimeClassName",
+	"spec.securityContext",
+	"spec.serviceAccount",
+	"spec.serviceAccountName",
+	"spec.shareProcessNamespace",
+	"spec.tolerations",
+	"spec.volumes.hostPath",
+}
+
+// strategicMergeDirectivePrefixes enumerates the prefixes of keys in a
+// strategic merge patch that are directives pertaining to the field named by
+// the remainder of the key.
+var strategicMergeDirectivePrefixes = []string{
+	"$deleteFromPrimitiveList/",
+	"$setElementOrder/",
+}
+
+// validatePodOverlays returns a *meta.ErrBadRequest error if either of the
+// provided Project's pod overlays is malformed or modifies a pod field that an
+// administrator has denied.
+func (s *substrate) validatePodOverlays(project api.Project) error {
+	if project.Kubernetes == nil {
+		return nil
+	}
+	if err := s.validatePodOverlay(
+		project.Kubernetes.WorkerPodOverlay,
+	); err != nil {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"Invalid worker pod overlay for project %q: %s",
+				project.ID,
+				err,
+			),
+		}
+	}
+	if err := s.validatePodOverlay(project.Kubernetes.JobPodOverlay); err != nil {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"Invalid job pod overlay for project %q: %s",
+				project.ID,
+				err,
+			),
+		}
+	}
+	return nil
+}
+
+// validatePodOverlay returns an error if the provided pod overlay is malformed
+// or modifies a pod field that an administrator has denied.
+func (s *substrate) validatePodOverlay(overlay *api.PodOverlay) error {
+	if overlay == nil {
+		return nil
+	}
+	deniedFields := s.config.PodOverlayDeniedFields
+	if deniedFields == nil {
+		deniedFields = DefaultPodOverlayDeniedFields
+	}
+	deniedPaths := make([][]string, len(deniedFields))
+	for i, deniedField := range deniedFields {
+		deniedPaths[i] = fieldPath(deniedField)
+	}
+	return checkPodOverlay(*overlay, deniedPaths)
+}
+
+// applyPodOverlays applies the operator's pod overlay, if any, and then the
+// Project's pod overlay, if any, to the provided pod. The Project's overlay is
+// re-validated first, since an administrator may have denied additional pod
+// fields since the Project was last updated.
+func (s *substrate) applyPodOverlays(
+	pod *corev1.Pod,
+	operatorOverlay *api.PodOverlay,
+	projectOverlay *api.PodOverlay,
+) error {
+	if operatorOverlay != nil {
+		if err := checkPodOverlay(*operatorOverlay, nil); err != nil {
+			return errors.Wrap(err, "error validating operator's pod overlay")
+		}
+		if err := applyPodOverlay(pod, *operatorOverlay); err != nil {
+			return errors.Wrap(err, "error applying operator's pod overlay")
+		}
+	}
+	if projectOverlay != nil {
+		if err := s.validatePodOverlay(projectOverlay); err != nil {
+			return errors.Wrap(err, "error validating project's pod overlay")
+		}
+		if err := applyPodOverlay(pod, *projectOverlay); err != nil {
+			return errors.Wrap(err, "error applying project's pod overlay")
+		}
+	}
+	return nil
+}
+
+// applyPodOverlay applies the provided pod overlay to the provided pod.
+func applyPodOverlay(pod *corev1.Pod, overlay api.PodOverlay) error {
+	patchJSON, err := yaml.YAMLToJSON([]byte(overlay.Patch))
+	if err != nil {
+		return errors.Wrap(err, "error parsing patch")
+	}
+	podJSON, err := json.Marshal(pod)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling pod")
+	}
+	switch overlay.Type {
+	case api.PodOverlayTypeStrategicMerge:
+		podJSON, err =
+			strategicpatch.StrategicMergePatch(podJSON, patchJSON, corev1.Pod{})
+	case api.PodOverlayTypeJSONPatch:
+		var patch jsonpatch.Patch
+		if patch, err = jsonpatch.DecodePatch(patchJSON); err == nil {
+			podJSON, err = patch.Apply(podJSON)
+		}
+	default:
+		err = errors.Errorf("unrecognized patch type %q", overlay.Type)
+	}
+	if err != nil {
+		return errors.Wrap(err, "error applying patch")
+	}
+	patchedPod := corev1.Pod{}
+	if err = json.Unmarshal(podJSON, &patchedPod); err != nil {
+		return errors.Wrap(err, "error unmarshaling patched pod")
+	}
+	*pod = patchedPod
+	return nil
+}
+
+// checkPodOverlay returns an error if the provided pod overlay is malformed,
+// modifies any of the specified denied paths, or modifies any pod field that
+// Brigade itself depends upon. The check is a static one. It conservatively
+// treats any operation that may replace or remove a field as modifying every
+// field beneath it.
+func checkPodOverlay(overlay api.PodOverlay, deniedPaths [][]string) error {
+	patchJSON, err := yaml.YAMLToJSON([]byte(overlay.Patch))
+	if err != nil {
+		return errors.Wrap(err, "error parsing patch")
+	}
+	var touchedPaths [][]string
+	switch overlay.Type {
+	case api.PodOverlayTypeStrategicMerge:
+		patch := map[string]interface{}{}
+		if err = json.Unmarshal(patchJSON, &patch); err != nil {
+			return errors.Wrap(err, "strategic merge patch is not an object")
+		}
+		touchedPaths = strategicMergePatchPaths(nil, patch)
+	case api.PodOverlayTypeJSONPatch:
+		var patch jsonpatch.Patch
+		if patch, err = jsonpatch.DecodePatch(patchJSON); err != nil {
+			return errors.Wrap(err, "error decoding JSON patch")
+		}
+		if touchedPaths, err = jsonPatchPaths(patch); err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("unrecognized patch type %q", overlay.Type)
+	}
+	for _, touchedPath := range touchedPaths {
+		if isBrigadeManagedPodPath(touchedPath) {
+			return errors.Errorf(
+				"field %q is managed by Brigade",
+				strings.Join(touchedPath, "."),
+			)
+		}
+		for _, deniedPath := range deniedPaths {
+			if pathsOverlap(touchedPath, deniedPath) {
+				return errors.Errorf(
+					"field %q may not be modified",
+					strings.Join(deniedPath, "."),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// strategicMergePatchPaths returns the paths of all fields that the provided
+// strategic merge patch (or portion thereof, found at the specified path) may
+// replace or remove.
+func strategicMergePatchPaths(
+	path []string,
+	patch map[string]interface{},
+) [][]string {
+	paths := [][]string{}
+	for key, value := range patch {
+		if key == "$patch" || key == "$retainKeys" {
+			// These directives may replace or remove the object itself
+			paths = append(paths, path)
+			continue
+		}
+		if strings.HasPrefix(key, "$") {
+			for _, prefix := range strategicMergeDirectivePrefixes {
+				if strings.HasPrefix(key, prefix) {
+					paths = append(
+						paths,
+						appendPath(path, strings.TrimPrefix(key, prefix)),
+					)
+				}
+			}
+			continue
+		}
+		paths = append(paths, valuePaths(appendPath(path, key), value)...)
+	}
+	return paths
+}
+
+// valuePaths returns the paths of all fields that the provided value, found at
+// the specified path of a strategic merge patch or of a value being added by a
+// JSON patch, may replace or remove. Objects, and objects within lists, are
+// merged field by field. Anything else replaces whatever exists at the path.
+func valuePaths(path []string, value interface{}) [][]string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return strategicMergePatchPaths(path, v)
+	case []interface{}:
+		paths := [][]string{}
+		for _, element := range v {
+			if elementMap, ok := element.(map[string]interface{}); ok {
+				paths = append(paths, strategicMergePatchPaths(path, elementMap)...)
+			} else {
+				return [][]string{path}
+			}
+		}
+		return paths
+	default:
+		return [][]string{path}
+	}
+}
+
+// jsonPatchPaths returns the paths of all fields that the provided JSON patch
+// may replace or remove.
+func jsonPatchPaths(patch jsonpatch.Patch) ([][]string, error) {
+	paths := [][]string{}
+	for _, operation := range patch {
+		kind := operation.Kind()
+		if kind == "test" {
+			continue
+		}
+		pointer, err := operation.Path()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %q operation", kind)
+		}
+		path, isListInsertion := jsonPointerPath(pointer)
+		switch kind {
+		case "add":
+			if !isListInsertion {
+				paths = append(paths, path)
+				continue
+			}
+			// Inserting into a list replaces nothing, but the inserted value must
+			// itself be checked
+			value, err := operation.ValueInterface()
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading \"add\" operation value")
+			}
+			paths = append(paths, valuePaths(path, value)...)
+		case "copy", "remove", "replace":
+			paths = append(paths, path)
+		case "move":
+			from, err := operation.From()
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading \"move\" operation")
+			}
+			fromPath, _ := jsonPointerPath(from)
+			paths = append(paths, fromPath, path)
+		default:
+			return nil, errors.Errorf("unrecognized operation %q", kind)
+		}
+	}
+	return paths, nil
+}
+
+// jsonPointerPath converts the provided JSON pointer to a path of field names,
+// omitting any list indices. It also returns a boolean indicating whether the
+// pointer's final token is a list index, as it would be when inserting into a
+// list.
+func jsonPointerPath(pointer string) ([]string, bool) {
+	path := []string{}
+	var lastIsIndex bool
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" && pointer == "" {
+			break
+		}
+		_, err := strconv.Atoi(token)
+		if lastIsIndex = token == "-" || err == nil; lastIsIndex {
+			continue
+		}
+		token = strings.ReplaceAll(token, "~1", "/")
+		path = append(path, strings.ReplaceAll(token, "~0", "~"))
+	}
+	return path, lastIsIndex
+}
+
+// isBrigadeManagedPodPath returns a boolean indicating whether modifying the
+// pod field at the specified path could interfere with Brigade's management
+// of the pod. This includes the pod's name and namespace and any labels or
+// annotations in Brigade's own domain.
+func isBrigadeManagedPodPath(path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	if path[0] != "metadata" {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	switch path[1] {
+	case "generateName", "name", "namespace":
+		return true
+	case "annotations", "labels":
+		return len(path) == 2 || strings.HasPrefix(path[2], "brigade.sh/")
+	}
+	return false
+}
+
+// fieldPath converts the provided dot-separated field path to a path of field
+// names. Everything following "metadata.annotations." or "metadata.labels." is
+// treated as a single annotation or label key, since those commonly contain
+// dots themselves.
+func fieldPath(field string) []string {
+	for _, prefix := range []string{
+		"metadata.annotations.",
+		"metadata.labels.",
+	} {
+		if strings.HasPrefix(field, prefix) {
+			return append(
+				strings.Split(strings.TrimSuffix(prefix, "."), "."),
+				strings.TrimPrefix(field, prefix),
+			)
+		}
+	}
+	return strings.Split(field, ".")
+}
+
+// pathsOverlap returns a boolean indicating whether modifying the field at the
+// touched path could modify the field at the denied path; i.e. whether either
+// path begins with all of the fields of the other. A field name in the denied
+// path ending in "*" matches any field name beginning with what precedes the
+// "*".
+func pathsOverlap(touchedPath []string, deniedPath []string) bool {
+	for i := 0; i < len(touchedPath) && i < len(deniedPath); i++ {
+		if strings.HasSuffix(deniedPath[i], "*") {
+			if !strings.HasPrefix(
+				touchedPath[i],
+				strings.TrimSuffix(deniedPath[i], "*"),
+			) {
+				return false
+			}
+		} else if touchedPath[i] != deniedPath[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendPath returns a new path consisting of the provided path with the
+// provided field appended.
+func appendPath(path []string, field string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), field)
+}
@@ -0,0 +1,549 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSubstrateValidatePodOverlays(t *testing.T) {
+	testCases := []struct {
+		name       string
+		substrate  *substrate
+		project    api.Project
+		assertions func(error)
+	}{
+		{
+			name:      "no overlays",
+			substrate: &substrate{},
+			project:   api.Project{},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "worker pod overlay denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"spec": {"hostNetwork": true}}`,
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "worker pod overlay")
+				require.Contains(t, err.Error(), "spec.hostNetwork")
+			},
+		},
+		{
+			name:      "container host port denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"spec": {"containers": [{"name": "sidecar", "ports": [{"containerPort": 80, "hostPort": 80}]}]}}`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "spec.containers.ports.hostPort")
+			},
+		},
+		{
+			name:      "init container host port denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeJSONPatch,
+						Patch: `[{"op": "add", "path": "/spec/initContainers/0/ports", "value": [{"containerPort": 80, "hostPort": 80}]}]`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "spec.initContainers.ports.hostPort")
+			},
+		},
+		{
+			name:      "process namespace sharing denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"spec": {"shareProcessNamespace": true}}`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "spec.shareProcessNamespace")
+			},
+		},
+		{
+			name:      "host user namespace denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"spec": {"hostUsers": true}}`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "spec.hostUsers")
+			},
+		},
+		{
+			name:      "apparmor annotation denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"metadata": {"annotations": {"container.apparmor.security.beta.kubernetes.io/worker": "unconfined"}}}`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"container.apparmor.security.beta.kubernetes.io/*",
+				)
+			},
+		},
+		{
+			name:      "apparmor annotation added by JSON patch denied by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeJSONPatch,
+						Patch: `[{"op": "add", "path": "/metadata/annotations/container.apparmor.security.beta.kubernetes.io~1worker", "value": "unconfined"}]`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"container.apparmor.security.beta.kubernetes.io/*",
+				)
+			},
+		},
+		{
+			name:      "other annotation permitted by default",
+			substrate: &substrate{},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: `{"metadata": {"annotations": {"sidecar.istio.io/inject": "false"}}}`, // nolint: lll
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "job pod overlay denied by administrator",
+			substrate: &substrate{
+				config: SubstrateConfig{
+					PodOverlayDeniedFields: []string{"spec.dnsConfig"},
+				},
+			},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					JobPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeJSONPatch,
+						Patch: `[{"op": "add", "path": "/spec/dnsConfig", "value": {}}]`,
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "job pod overlay")
+				require.Contains(t, err.Error(), "spec.dnsConfig")
+			},
+		},
+		{
+			name: "success",
+			substrate: &substrate{
+				config: SubstrateConfig{
+					PodOverlayDeniedFields: []string{"spec.dnsConfig"},
+				},
+			},
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					WorkerPodOverlay: &api.PodOverlay{
+						Type:  api.PodOverlayTypeStrategicMerge,
+						Patch: "spec:\n  hostNetwork: true",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				testCase.substrate.validatePodOverlays(testCase.project),
+			)
+		})
+	}
+}
+
+func TestSubstrateApplyPodOverlays(t *testing.T) {
+	getTestPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "worker",
+				Namespace: "foo",
+				Labels: map[string]string{
+					myk8s.LabelComponent: myk8s.LabelKeyWorker,
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "worker",
+						Image: "brigadecore/brigade2-worker:v2.0.0",
+					},
+				},
+			},
+		}
+	}
+	testCases := []struct {
+		name            string
+		operatorOverlay *api.PodOverlay
+		projectOverlay  *api.PodOverlay
+		assertions      func(*corev1.Pod, error)
+	}{
+		{
+			name: "operator overlay modifies a brigade-managed field",
+			operatorOverlay: &api.PodOverlay{
+				Type:  api.PodOverlayTypeStrategicMerge,
+				Patch: `{"metadata": {"labels": {"brigade.sh/component": "job"}}}`,
+			},
+			assertions: func(_ *corev1.Pod, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "operator's pod overlay")
+				require.Contains(t, err.Error(), "managed by Brigade")
+			},
+		},
+		{
+			name: "project overlay modifies a denied field",
+			projectOverlay: &api.PodOverlay{
+				Type:  api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "replace", "path": "/spec/containers/0/image", "value": "evil"}]`, // nolint: lll
+			},
+			assertions: func(_ *corev1.Pod, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "project's pod overlay")
+				require.Contains(t, err.Error(), "spec.containers.image")
+			},
+		},
+		{
+			name: "project overlay cannot be applied",
+			projectOverlay: &api.PodOverlay{
+				Type:  api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "remove", "path": "/spec/hostAliases"}]`,
+			},
+			assertions: func(_ *corev1.Pod, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error applying project's pod overlay")
+			},
+		},
+		{
+			name: "success",
+			operatorOverlay: &api.PodOverlay{
+				Type: api.PodOverlayTypeStrategicMerge,
+				Patch: "metadata:\n" +
+					"  annotations:\n" +
+					"    sidecar.istio.io/inject: \"false\"\n" +
+					"spec:\n" +
+					"  containers:\n" +
+					"  - name: worker\n" +
+					"    env:\n" +
+					"    - name: FOO\n" +
+					"      value: bar\n",
+			},
+			projectOverlay: &api.PodOverlay{
+				Type: api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "add", "path": "/spec/hostAliases", "value": ` +
+					`[{"ip": "10.0.0.1", "hostnames": ["foo"]}]}]`,
+			},
+			assertions: func(pod *corev1.Pod, err error) {
+				require.NoError(t, err)
+				// Fields the substrate set are intact
+				require.Equal(t, "worker", pod.Name)
+				require.Equal(
+					t,
+					myk8s.LabelKeyWorker,
+					pod.Labels[myk8s.LabelComponent],
+				)
+				require.Len(t, pod.Spec.Containers, 1)
+				require.Equal(
+					t,
+					"brigadecore/brigade2-worker:v2.0.0",
+					pod.Spec.Containers[0].Image,
+				)
+				// Both overlays were applied
+				require.Equal(
+					t,
+					"false",
+					pod.Annotations["sidecar.istio.io/inject"],
+				)
+				require.Equal(
+					t,
+					[]corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+					pod.Spec.Containers[0].Env,
+				)
+				require.Equal(
+					t,
+					[]corev1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"foo"}}},
+					pod.Spec.HostAliases,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pod := getTestPod()
+			err := (&substrate{}).applyPodOverlays(
+				pod,
+				testCase.operatorOverlay,
+				testCase.projectOverlay,
+			)
+			testCase.assertions(pod, err)
+		})
+	}
+}
+
+func TestCheckPodOverlay(t *testing.T) {
+	testDeniedPaths := [][]string{
+		{"spec", "hostNetwork"},
+		{"spec", "containers", "securityContext"},
+	}
+	testCases := []struct {
+		name       string
+		overlay    api.PodOverlay
+		assertions func(error)
+	}{
+		{
+			name: "unrecognized type",
+			overlay: api.PodOverlay{
+				Type:  "bogus",
+				Patch: "{}",
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "unrecognized patch type")
+			},
+		},
+		{
+			name: "unparseable patch",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeStrategicMerge,
+				Patch: "{",
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error parsing patch")
+			},
+		},
+		{
+			name: "strategic merge patch is not an object",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeStrategicMerge,
+				Patch: "[]",
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not an object")
+			},
+		},
+		{
+			name: "strategic merge patch sets denied field",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeStrategicMerge,
+				Patch: `{"spec": {"hostNetwork": true}}`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "spec.hostNetwork")
+			},
+		},
+		{
+			name: "strategic merge patch sets field within denied field",
+			overlay: api.PodOverlay{
+				Type: api.PodOverlayTypeStrategicMerge,
+				Patch: `{"spec": {"containers": [` +
+					`{"name": "sidecar", "securityContext": {"privileged": true}}` +
+					`]}}`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "spec.containers.securityContext")
+			},
+		},
+		{
+			name: "strategic merge patch replaces parent of denied field",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeStrategicMerge,
+				Patch: `{"spec": {"containers": [{"$patch": "replace"}]}}`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "spec.containers.securityContext")
+			},
+		},
+		{
+			name: "strategic merge patch removes brigade label",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeStrategicMerge,
+				Patch: `{"metadata": {"labels": {"brigade.sh/id": null}}}`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "managed by Brigade")
+			},
+		},
+		{
+			name: "strategic merge patch allowed",
+			overlay: api.PodOverlay{
+				Type: api.PodOverlayTypeStrategicMerge,
+				Patch: `{"metadata": {"labels": {"foo": "bar"}}, "spec": ` +
+					`{"containers": [{"name": "worker", "env": [{"name": "FOO"}]}]}}`,
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "JSON patch is malformed",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeJSONPatch,
+				Patch: `{"op": "add"}`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error decoding JSON patch")
+			},
+		},
+		{
+			name: "JSON patch removes parent of denied field",
+			overlay: api.PodOverlay{
+				Type:  api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "remove", "path": "/spec/containers/0"}]`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "spec.containers.securityContext")
+			},
+		},
+		{
+			name: "JSON patch moves into denied field",
+			overlay: api.PodOverlay{
+				Type: api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "move", "from": "/spec/dnsPolicy", ` +
+					`"path": "/spec/hostNetwork"}]`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "spec.hostNetwork")
+			},
+		},
+		{
+			name: "JSON patch inserts container with denied field",
+			overlay: api.PodOverlay{
+				Type: api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "add", "path": "/spec/containers/-", "value": ` +
+					`{"name": "sidecar", "securityContext": {"privileged": true}}}]`,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "spec.containers.securityContext")
+			},
+		},
+		{
+			name: "JSON patch allowed",
+			overlay: api.PodOverlay{
+				Type: api.PodOverlayTypeJSONPatch,
+				Patch: `[{"op": "test", "path": "/spec/hostNetwork", "value": false}, ` +
+					`{"op": "add", "path": "/spec/containers/-", ` +
+					`"value": {"name": "sidecar", "image": "envoy"}}, ` +
+					`{"op": "add", "path": "/metadata/annotations/foo~1bar", ` +
+					`"value": "bat"}]`,
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(checkPodOverlay(testCase.overlay, testDeniedPaths))
+		})
+	}
+}
+
+func TestJSONPointerPath(t *testing.T) {
+	testCases := []struct {
+		pointer                 string
+		expectedPath            []string
+		expectedIsListInsertion bool
+	}{
+		{
+			pointer:      "",
+			expectedPath: []string{},
+		},
+		{
+			pointer:      "/spec/containers/0/image",
+			expectedPath: []string{"spec", "containers", "image"},
+		},
+		{
+			pointer:                 "/spec/containers/-",
+			expectedPath:            []string{"spec", "containers"},
+			expectedIsListInsertion: true,
+		},
+		{
+			pointer:      "/metadata/annotations/foo~1bar~0bat",
+			expectedPath: []string{"metadata", "annotations", "foo/bar~bat"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.pointer, func(t *testing.T) {
+			path, isListInsertion := jsonPointerPath(testCase.pointer)
+			require.Equal(t, testCase.expectedPath, path)
+			require.Equal(t, testCase.expectedIsListInsertion, isListInsertion)
+		})
+	}
+}
@@ -0,0 +1,615 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// projectPoliciesName is the name shared by the ResourceQuota, LimitRange, and
+// NetworkPolicy that the substrate manages in each Project's namespace.
+const projectPoliciesName = "brigade-project"
+
+// networkPolicyRestrictiveness ranks each api.NetworkPolicyMode from least to
+// most restrictive.
+var networkPolicyRestrictiveness = map[api.NetworkPolicyMode]int{
+	api.NetworkPolicyModeAllowAll:                 0,
+	api.NetworkPolicyModeDenyNamespaceToNamespace: 1,
+	api.NetworkPolicyModeEgressOnly:               2,
+}
+
+// projectPolicies encapsulates the desired state of the ResourceQuota,
+// LimitRange, and NetworkPolicy in a Project's namespace. Any nil field
+// indicates that the corresponding resource should not exist.
+type projectPolicies struct {
+	resourceQuota *corev1.ResourceQuota
+	limitRange    *corev1.LimitRange
+	networkPolicy *networkingv1.NetworkPolicy
+}
+
+func (s *substrate) UpdateProject(
+	ctx context.Context,
+	project api.Project,
+) error {
+	policies, err := s.getProjectPolicies(project)
+	if err != nil {
+		return err
+	}
+	if err = s.validatePodOverlays(project); err != nil {
+		return err
+	}
+	if err = s.validateSchedulingOverrides(project); err != nil {
+		return err
+	}
+	if err = s.validateCacheVolumes(ctx, project); err != nil {
+		return err
+	}
+	if err = validateJobServiceAccounts(project); err != nil {
+		return err
+	}
+	if err = s.applyProjectPolicies(
+		ctx,
+		project.Kubernetes.Namespace,
+		policies,
+	); err != nil {
+		return err
+	}
+	return s.reconcileJobServiceAccounts(
+		ctx,
+		project.Kubernetes.Namespace,
+		project,
+	)
+}
+
+// getProjectPolicies validates the ResourceQuota, LimitRange, and
+// NetworkPolicy declared by the provided Project against the bounds set by an
+// administrator and returns the desired state of the corresponding resources.
+// A *meta.ErrBadRequest is returned if the Project's declarations are invalid
+// or exceed those bounds.
+func (s *substrate) getProjectPolicies(
+	project api.Project,
+) (projectPolicies, error) {
+	policies := projectPolicies{}
+	kubernetes := project.Kubernetes
+	if kubernetes == nil {
+		kubernetes = &api.KubernetesDetails{}
+	}
+	var err error
+	if policies.resourceQuota, err =
+		s.getResourceQuota(project.ID, kubernetes.ResourceQuota); err != nil {
+		return policies, err
+	}
+	if policies.limitRange, err = s.getLimitRange(
+		project.ID,
+		kubernetes.LimitRange,
+		policies.resourceQuota,
+	); err != nil {
+		return policies, err
+	}
+	policies.networkPolicy, err =
+		s.getNetworkPolicy(project.ID, kubernetes.NetworkPolicy)
+	return policies, err
+}
+
+// getResourceQuota returns the desired ResourceQuota for the specified
+// Project. The administrator's maximum for each resource applies to any
+// resource the Project does not constrain itself.
+func (s *substrate) getResourceQuota(
+	projectID string,
+	quota *api.ResourceQuota,
+) (*corev1.ResourceQuota, error) {
+	hard := corev1.ResourceList{}
+	for name, max := range s.config.MaxResourceQuota {
+		hard[corev1.ResourceName(name)] = max
+	}
+	if quota != nil {
+		declared, err := getResourceList("resourceQuota.hard", quota.Hard)
+		if err != nil {
+			return nil, err
+		}
+		for name, quantity := range declared {
+			if max, ok := s.config.MaxResourceQuota[string(name)]; ok &&
+				quantity.Cmp(max) > 0 {
+				return nil, &meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						"Resource quota for %q may not exceed %s.",
+						name,
+						max.String(),
+					),
+				}
+			}
+			hard[name] = quantity
+		}
+	}
+	if len(hard) == 0 {
+		return nil, nil
+	}
+	return &corev1.ResourceQuota{
+		ObjectMeta: s.getProjectPolicyObjectMeta(projectID),
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}, nil
+}
+
+// getLimitRange returns the desired LimitRange for the specified Project. If
+// the provided ResourceQuota, which may be nil, constrains compute resources,
+// the administrator's default container requests and limits are included for
+// any of those resources the Project's own LimitRange does not provide a
+// default for. A *meta.ErrBadRequest is returned if any such resource still
+// lacks a default, since Kubernetes would then reject every Worker and Job pod.
+func (s *substrate) getLimitRange(
+	projectID string,
+	limitRange *api.LimitRange,
+	quota *corev1.ResourceQuota,
+) (*corev1.LimitRange, error) {
+	if limitRange == nil {
+		limitRange = &api.LimitRange{}
+	}
+	item := corev1.LimitRangeItem{
+		Type: corev1.LimitTypeContainer,
+	}
+	var err error
+	if item.DefaultRequest, err = getResourceList(
+		"limitRange.defaultRequests",
+		limitRange.DefaultRequests,
+	); err != nil {
+		return nil, err
+	}
+	if item.Default, err = getResourceList(
+		"limitRange.defaultLimits",
+		limitRange.DefaultLimits,
+	); err != nil {
+		return nil, err
+	}
+	if item.Max, err =
+		getResourceList("limitRange.max", limitRange.Max); err != nil {
+		return nil, err
+	}
+	// With no defaults at all, every compute resource the quota constrains is
+	// reported as missing
+	if quota != nil &&
+		len(missingComputeDefaults(quota.Spec.Hard, nil, nil)) > 0 {
+		// The defaults are included only for resources for which the Project
+		// specified no default at all. Kubernetes defaults a container's request
+		// to its limit, so a default limit also serves as a default request.
+		for name, quantity := range s.config.DefaultContainerRequests {
+			name := corev1.ResourceName(name)
+			_, hasRequest := item.DefaultRequest[name]
+			_, hasLimit := item.Default[name]
+			if !hasRequest && !hasLimit {
+				if item.DefaultRequest == nil {
+					item.DefaultRequest = corev1.ResourceList{}
+				}
+				item.DefaultRequest[name] = quantity
+			}
+		}
+		for name, quantity := range s.config.DefaultContainerLimits {
+			name := corev1.ResourceName(name)
+			if _, hasLimit := item.Default[name]; !hasLimit {
+				if item.Default == nil {
+					item.Default = corev1.ResourceList{}
+				}
+				item.Default[name] = quantity
+			}
+		}
+		if missing := missingComputeDefaults(
+			quota.Spec.Hard,
+			item.DefaultRequest,
+			item.Default,
+		); len(missing) > 0 {
+			return nil, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Resource quota for %q requires limitRange to specify a default.",
+					missing[0],
+				),
+			}
+		}
+	}
+	// Kubernetes would reject a LimitRange that is inconsistent with itself,
+	// but it's friendlier to catch that here
+	for name, request := range item.DefaultRequest {
+		if limit, ok := item.Default[name]; ok && request.Cmp(limit) > 0 {
+			return nil, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Default request for %q may not exceed its default limit.",
+					name,
+				),
+			}
+		}
+	}
+	for name, limit := range item.Default {
+		if max, ok := item.Max[name]; ok && limit.Cmp(max) > 0 {
+			return nil, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Default limit for %q may not exceed its max.",
+					name,
+				),
+			}
+		}
+	}
+	if len(item.DefaultRequest) == 0 && len(item.Default) == 0 &&
+		len(item.Max) == 0 {
+		return nil, nil
+	}
+	return &corev1.LimitRange{
+		ObjectMeta: s.getProjectPolicyObjectMeta(projectID),
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{item},
+		},
+	}, nil
+}
+
+// getNetworkPolicy returns the desired NetworkPolicy for the specified
+// Project. The administrator's default applies if the Project does not specify
+// a mode of its own. The Project may not specify a mode that is less
+// restrictive than the administrator's default.
+func (s *substrate) getNetworkPolicy(
+	projectID string,
+	mode api.NetworkPolicyMode,
+) (*networkingv1.NetworkPolicy, error) {
+	defaultMode := s.config.DefaultNetworkPolicy
+	if defaultMode == "" {
+		defaultMode = api.NetworkPolicyModeAllowAll
+	}
+	if mode == "" {
+		mode = defaultMode
+	}
+	restrictiveness, ok := networkPolicyRestrictiveness[mode]
+	if !ok {
+		return nil, &meta.ErrBadRequest{
+			Reason: fmt.Sprintf("Unrecognized network policy %q.", mode),
+		}
+	}
+	if restrictiveness < networkPolicyRestrictiveness[defaultMode] {
+		return nil, &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"Network policy %q is less restrictive than the minimum, %q.",
+				mode,
+				defaultMode,
+			),
+		}
+	}
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: s.getProjectPolicyObjectMeta(projectID),
+		Spec: networkingv1.NetworkPolicySpec{
+			// Applies to all pods in the namespace
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+			},
+		},
+	}
+	switch mode {
+	case api.NetworkPolicyModeDenyNamespaceToNamespace:
+		networkPolicy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{
+			{
+				From: []networkingv1.NetworkPolicyPeer{
+					{
+						// Any pod in the same namespace
+						PodSelector: &metav1.LabelSelector{},
+					},
+				},
+			},
+		}
+	case api.NetworkPolicyModeEgressOnly:
+		// An empty set of ingress rules denies all inbound traffic
+	default:
+		return nil, nil
+	}
+	return networkPolicy, nil
+}
+
+// getProjectPolicyObjectMeta returns the ObjectMeta common to the
+// ResourceQuota, LimitRange, and NetworkPolicy in a Project's namespace.
+func (s *substrate) getProjectPolicyObjectMeta(
+	projectID string,
+) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name: projectPoliciesName,
+		Labels: map[string]string{
+			myk8s.LabelBrigadeID: s.config.BrigadeID,
+			myk8s.LabelProject:   projectID,
+		},
+	}
+}
+
+// applyProjectPolicies creates, updates, or deletes the ResourceQuota,
+// LimitRange, and NetworkPolicy in the specified namespace as required to
+// achieve the desired state.
+func (s *substrate) applyProjectPolicies(
+	ctx context.Context,
+	namespace string,
+	policies projectPolicies,
+) error {
+	resourceQuotasClient := s.kubeClient.CoreV1().ResourceQuotas(namespace)
+	if policies.resourceQuota == nil {
+		if err := resourceQuotasClient.Delete(
+			ctx,
+			projectPoliciesName,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting resource quota %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	} else if existing, err := resourceQuotasClient.Get(
+		ctx,
+		projectPoliciesName,
+		metav1.GetOptions{},
+	); k8sErrors.IsNotFound(err) {
+		if _, err = resourceQuotasClient.Create(
+			ctx,
+			policies.resourceQuota,
+			metav1.CreateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error creating resource quota %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	} else if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving resource quota %q in namespace %q",
+			projectPoliciesName,
+			namespace,
+		)
+	} else {
+		existing.Labels = policies.resourceQuota.Labels
+		existing.Spec = policies.resourceQuota.Spec
+		if _, err = resourceQuotasClient.Update(
+			ctx,
+			existing,
+			metav1.UpdateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error updating resource quota %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	}
+
+	limitRangesClient := s.kubeClient.CoreV1().LimitRanges(namespace)
+	if policies.limitRange == nil {
+		if err := limitRangesClient.Delete(
+			ctx,
+			projectPoliciesName,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting limit range %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	} else if existing, err := limitRangesClient.Get(
+		ctx,
+		projectPoliciesName,
+		metav1.GetOptions{},
+	); k8sErrors.IsNotFound(err) {
+		if _, err = limitRangesClient.Create(
+			ctx,
+			policies.limitRange,
+			metav1.CreateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error creating limit range %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	} else if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving limit range %q in namespace %q",
+			projectPoliciesName,
+			namespace,
+		)
+	} else {
+		existing.Labels = policies.limitRange.Labels
+		existing.Spec = policies.limitRange.Spec
+		if _, err = limitRangesClient.Update(
+			ctx,
+			existing,
+			metav1.UpdateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error updating limit range %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	}
+
+	networkPoliciesClient :=
+		s.kubeClient.NetworkingV1().NetworkPolicies(namespace)
+	if policies.networkPolicy == nil {
+		if err := networkPoliciesClient.Delete(
+			ctx,
+			projectPoliciesName,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting network policy %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	} else if existing, err := networkPoliciesClient.Get(
+		ctx,
+		projectPoliciesName,
+		metav1.GetOptions{},
+	); k8sErrors.IsNotFound(err) {
+		if _, err = networkPoliciesClient.Create(
+			ctx,
+			policies.networkPolicy,
+			metav1.CreateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error creating network policy %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	} else if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving network policy %q in namespace %q",
+			projectPoliciesName,
+			namespace,
+		)
+	} else {
+		existing.Labels = policies.networkPolicy.Labels
+		existing.Spec = policies.networkPolicy.Spec
+		if _, err = networkPoliciesClient.Update(
+			ctx,
+			existing,
+			metav1.UpdateOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error updating network policy %q in namespace %q",
+				projectPoliciesName,
+				namespace,
+			)
+		}
+	}
+
+	return nil
+}
+
+// missingComputeDefaults returns the names, in sorted order, of any compute
+// resources constrained by the provided ResourceQuota limits for which the
+// provided default requests and limits are insufficient for Kubernetes to
+// admit a pod that does not specify them. A quota on requests of a resource
+// is satisfied by a default request or a default limit, since Kubernetes
+// defaults a container's request to its limit. A quota on limits of a resource
+// is only satisfied by a default limit.
+func missingComputeDefaults(
+	hard corev1.ResourceList,
+	defaultRequests corev1.ResourceList,
+	defaultLimits corev1.ResourceList,
+) []string {
+	missing := []string{}
+	for name := range hard {
+		var resourceName corev1.ResourceName
+		var limitRequired bool
+		switch name {
+		case corev1.ResourceCPU,
+			corev1.ResourceMemory,
+			corev1.ResourceEphemeralStorage:
+			resourceName = name
+		case corev1.ResourceRequestsCPU,
+			corev1.ResourceRequestsMemory,
+			corev1.ResourceRequestsEphemeralStorage:
+			resourceName = corev1.ResourceName(
+				strings.TrimPrefix(string(name), "requests."),
+			)
+		case corev1.ResourceLimitsCPU,
+			corev1.ResourceLimitsMemory,
+			corev1.ResourceLimitsEphemeralStorage:
+			resourceName = corev1.ResourceName(
+				strings.TrimPrefix(string(name), "limits."),
+			)
+			limitRequired = true
+		default:
+			continue
+		}
+		if _, ok := defaultLimits[resourceName]; ok {
+			continue
+		}
+		if _, ok := defaultRequests[resourceName]; ok && !limitRequired {
+			continue
+		}
+		missing = append(missing, string(name))
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// ValidateComputeDefaults returns an error if the MaxResourceQuota, which
+// applies to every Project, constrains any compute resource for which the
+// DefaultContainerRequests and DefaultContainerLimits provide no default.
+// Kubernetes would otherwise reject every Worker and Job pod of any Project
+// that did not specify suitable defaults of its own.
+func (s SubstrateConfig) ValidateComputeDefaults() error {
+	hard := corev1.ResourceList{}
+	for name, quantity := range s.MaxResourceQuota {
+		hard[corev1.ResourceName(name)] = quantity
+	}
+	defaultRequests := corev1.ResourceList{}
+	for name, quantity := range s.DefaultContainerRequests {
+		defaultRequests[corev1.ResourceName(name)] = quantity
+	}
+	defaultLimits := corev1.ResourceList{}
+	for name, quantity := range s.DefaultContainerLimits {
+		defaultLimits[corev1.ResourceName(name)] = quantity
+	}
+	missing := missingComputeDefaults(hard, defaultRequests, defaultLimits)
+	if len(missing) > 0 {
+		return errors.Errorf(
+			"maximum resource quota for %q requires a default container request "+
+				"or limit",
+			missing[0],
+		)
+	}
+	return nil
+}
+
+// getResourceList parses the provided map of resource names to quantities into
+// a corev1.ResourceList. The provided field name is used only to produce a
+// helpful *meta.ErrBadRequest if any quantity cannot be parsed.
+func getResourceList(
+	field string,
+	quantities map[string]string,
+) (corev1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+	// Iterate in a stable order so the same error is always reported first
+	names := make([]string, 0, len(quantities))
+	for name := range quantities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	resourceList := corev1.ResourceList{}
+	for _, name := range names {
+		quantity, err := resource.ParseQuantity(quantities[name])
+		if err != nil {
+			return nil, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Invalid quantity %q for %q in %s.",
+					quantities[name],
+					name,
+					field,
+				),
+			}
+		}
+		resourceList[corev1.ResourceName(name)] = quantity
+	}
+	return resourceList, nil
+}
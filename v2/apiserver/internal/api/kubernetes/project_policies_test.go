@@ -0,0 +1,591 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSubstrateUpdateProject(t *testing.T) {
+	const testNamespace = "foo"
+	testCases := []struct {
+		name       string
+		project    api.Project
+		setup      func() *fake.Clientset
+		assertions func(error, *fake.Clientset)
+	}{
+		{
+			name: "invalid declarations",
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					Namespace:     testNamespace,
+					NetworkPolicy: "bogus",
+				},
+			},
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset()
+			},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+			},
+		},
+		{
+			name: "policies are created",
+			project: api.Project{
+				ObjectMeta: meta.ObjectMeta{
+					ID: "italian",
+				},
+				Kubernetes: &api.KubernetesDetails{
+					Namespace: testNamespace,
+					ResourceQuota: &api.ResourceQuota{
+						Hard: map[string]string{
+							"pods": "10",
+						},
+					},
+					LimitRange: &api.LimitRange{
+						DefaultLimits: map[string]string{
+							"cpu": "500m",
+						},
+					},
+					NetworkPolicy: api.NetworkPolicyModeEgressOnly,
+				},
+			},
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset()
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				quota, err := kubeClient.CoreV1().ResourceQuotas(testNamespace).Get(
+					context.Background(),
+					projectPoliciesName,
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(t, "italian", quota.Labels[myk8s.LabelProject])
+				require.Equal(
+					t,
+					resource.MustParse("10"),
+					quota.Spec.Hard[corev1.ResourcePods],
+				)
+				limitRange, err :=
+					kubeClient.CoreV1().LimitRanges(testNamespace).Get(
+						context.Background(),
+						projectPoliciesName,
+						metav1.GetOptions{},
+					)
+				require.NoError(t, err)
+				require.Len(t, limitRange.Spec.Limits, 1)
+				require.Equal(
+					t,
+					resource.MustParse("500m"),
+					limitRange.Spec.Limits[0].Default[corev1.ResourceCPU],
+				)
+				networkPolicy, err :=
+					kubeClient.NetworkingV1().NetworkPolicies(testNamespace).Get(
+						context.Background(),
+						projectPoliciesName,
+						metav1.GetOptions{},
+					)
+				require.NoError(t, err)
+				require.Empty(t, networkPolicy.Spec.Ingress)
+			},
+		},
+		{
+			name: "policies are updated",
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					Namespace: testNamespace,
+					ResourceQuota: &api.ResourceQuota{
+						Hard: map[string]string{
+							"pods": "20",
+						},
+					},
+				},
+			},
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset(
+					&corev1.ResourceQuota{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      projectPoliciesName,
+							Namespace: testNamespace,
+						},
+						Spec: corev1.ResourceQuotaSpec{
+							Hard: corev1.ResourceList{
+								corev1.ResourcePods: resource.MustParse("10"),
+							},
+						},
+					},
+				)
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				quota, err := kubeClient.CoreV1().ResourceQuotas(testNamespace).Get(
+					context.Background(),
+					projectPoliciesName,
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					resource.MustParse("20"),
+					quota.Spec.Hard[corev1.ResourcePods],
+				)
+			},
+		},
+		{
+			name: "policies are deleted",
+			project: api.Project{
+				Kubernetes: &api.KubernetesDetails{
+					Namespace: testNamespace,
+				},
+			},
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset(
+					&corev1.ResourceQuota{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      projectPoliciesName,
+							Namespace: testNamespace,
+						},
+					},
+					&corev1.LimitRange{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      projectPoliciesName,
+							Namespace: testNamespace,
+						},
+					},
+					&networkingv1.NetworkPolicy{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      projectPoliciesName,
+							Namespace: testNamespace,
+						},
+					},
+				)
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				_, err = kubeClient.CoreV1().ResourceQuotas(testNamespace).Get(
+					context.Background(),
+					projectPoliciesName,
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+				_, err = kubeClient.CoreV1().LimitRanges(testNamespace).Get(
+					context.Background(),
+					projectPoliciesName,
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+				_, err = kubeClient.NetworkingV1().NetworkPolicies(testNamespace).Get(
+					context.Background(),
+					projectPoliciesName,
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := testCase.setup()
+			// Every Project's namespace has a RoleBinding for the Project's Jobs
+			_, err := kubeClient.RbacV1().RoleBindings(testNamespace).Create(
+				context.Background(),
+				&rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "jobs",
+					},
+				},
+				metav1.CreateOptions{},
+			)
+			require.NoError(t, err)
+			s := &substrate{
+				kubeClient: kubeClient,
+			}
+			err = s.UpdateProject(context.Background(), testCase.project)
+			testCase.assertions(err, kubeClient)
+		})
+	}
+}
+
+func TestSubstrateGetResourceQuota(t *testing.T) {
+	testCases := []struct {
+		name       string
+		max        map[string]resource.Quantity
+		quota      *api.ResourceQuota
+		assertions func(*corev1.ResourceQuota, error)
+	}{
+		{
+			name: "no max and nothing declared",
+			assertions: func(quota *corev1.ResourceQuota, err error) {
+				require.NoError(t, err)
+				require.Nil(t, quota)
+			},
+		},
+		{
+			name: "invalid quantity",
+			quota: &api.ResourceQuota{
+				Hard: map[string]string{
+					"pods": "lots",
+				},
+			},
+			assertions: func(_ *corev1.ResourceQuota, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "Invalid quantity")
+			},
+		},
+		{
+			name: "declared quantity exceeds max",
+			max: map[string]resource.Quantity{
+				"pods": resource.MustParse("10"),
+			},
+			quota: &api.ResourceQuota{
+				Hard: map[string]string{
+					"pods": "20",
+				},
+			},
+			assertions: func(_ *corev1.ResourceQuota, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "may not exceed")
+			},
+		},
+		{
+			name: "max applies where nothing is declared",
+			max: map[string]resource.Quantity{
+				"pods":         resource.MustParse("10"),
+				"requests.cpu": resource.MustParse("4"),
+			},
+			quota: &api.ResourceQuota{
+				Hard: map[string]string{
+					"pods": "5",
+				},
+			},
+			assertions: func(quota *corev1.ResourceQuota, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					corev1.ResourceList{
+						corev1.ResourcePods:        resource.MustParse("5"),
+						corev1.ResourceRequestsCPU: resource.MustParse("4"),
+					},
+					quota.Spec.Hard,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := &substrate{
+				config: SubstrateConfig{
+					MaxResourceQuota: testCase.max,
+				},
+			}
+			quota, err := s.getResourceQuota("italian", testCase.quota)
+			testCase.assertions(quota, err)
+		})
+	}
+}
+
+func TestSubstrateGetLimitRange(t *testing.T) {
+	testConfig := SubstrateConfig{
+		DefaultContainerRequests: map[string]resource.Quantity{
+			"cpu":    resource.MustParse("250m"),
+			"memory": resource.MustParse("256Mi"),
+		},
+		DefaultContainerLimits: map[string]resource.Quantity{
+			"cpu": resource.MustParse("1"),
+		},
+	}
+	testCases := []struct {
+		name       string
+		config     SubstrateConfig
+		limitRange *api.LimitRange
+		quota      *corev1.ResourceQuota
+		assertions func(*corev1.LimitRange, error)
+	}{
+		{
+			name: "nothing declared",
+			assertions: func(limitRange *corev1.LimitRange, err error) {
+				require.NoError(t, err)
+				require.Nil(t, limitRange)
+			},
+		},
+		{
+			name: "default request exceeds default limit",
+			limitRange: &api.LimitRange{
+				DefaultRequests: map[string]string{
+					"cpu": "2",
+				},
+				DefaultLimits: map[string]string{
+					"cpu": "1",
+				},
+			},
+			assertions: func(_ *corev1.LimitRange, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "default limit")
+			},
+		},
+		{
+			name: "default limit exceeds max",
+			limitRange: &api.LimitRange{
+				DefaultLimits: map[string]string{
+					"memory": "2Gi",
+				},
+				Max: map[string]string{
+					"memory": "1Gi",
+				},
+			},
+			assertions: func(_ *corev1.LimitRange, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "its max")
+			},
+		},
+		{
+			name: "success",
+			limitRange: &api.LimitRange{
+				DefaultRequests: map[string]string{
+					"cpu": "100m",
+				},
+				DefaultLimits: map[string]string{
+					"cpu": "500m",
+				},
+				Max: map[string]string{
+					"cpu": "1",
+				},
+			},
+			assertions: func(limitRange *corev1.LimitRange, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]corev1.LimitRangeItem{
+						{
+							Type: corev1.LimitTypeContainer,
+							DefaultRequest: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("100m"),
+							},
+							Default: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("500m"),
+							},
+							Max: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("1"),
+							},
+						},
+					},
+					limitRange.Spec.Limits,
+				)
+			},
+		},
+		{
+			name:   "compute quota with no defaults",
+			config: SubstrateConfig{},
+			quota: &corev1.ResourceQuota{
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourceRequestsCPU: resource.MustParse("4"),
+					},
+				},
+			},
+			assertions: func(_ *corev1.LimitRange, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "requests.cpu")
+			},
+		},
+		{
+			name:   "limits quota with only a default request",
+			config: testConfig,
+			quota: &corev1.ResourceQuota{
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourceLimitsMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+			assertions: func(_ *corev1.LimitRange, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "limits.memory")
+			},
+		},
+		{
+			name:   "non-compute quota",
+			config: SubstrateConfig{},
+			quota: &corev1.ResourceQuota{
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourcePods: resource.MustParse("10"),
+					},
+				},
+			},
+			assertions: func(limitRange *corev1.LimitRange, err error) {
+				require.NoError(t, err)
+				require.Nil(t, limitRange)
+			},
+		},
+		{
+			name:   "compute quota with administrator defaults",
+			config: testConfig,
+			quota: &corev1.ResourceQuota{
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourceRequestsCPU:    resource.MustParse("4"),
+						corev1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+			assertions: func(limitRange *corev1.LimitRange, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]corev1.LimitRangeItem{
+						{
+							Type: corev1.LimitTypeContainer,
+							DefaultRequest: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("250m"),
+								corev1.ResourceMemory: resource.MustParse("256Mi"),
+							},
+							Default: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("1"),
+							},
+						},
+					},
+					limitRange.Spec.Limits,
+				)
+			},
+		},
+		{
+			name:   "compute quota with project defaults",
+			config: testConfig,
+			limitRange: &api.LimitRange{
+				DefaultLimits: map[string]string{
+					"memory": "512Mi",
+				},
+			},
+			quota: &corev1.ResourceQuota{
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+			assertions: func(limitRange *corev1.LimitRange, err error) {
+				require.NoError(t, err)
+				// The project's default memory limit also serves as its default
+				// memory request, so the administrator's is not applied
+				require.Equal(
+					t,
+					[]corev1.LimitRangeItem{
+						{
+							Type: corev1.LimitTypeContainer,
+							DefaultRequest: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("250m"),
+							},
+							Default: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1"),
+								corev1.ResourceMemory: resource.MustParse("512Mi"),
+							},
+						},
+					},
+					limitRange.Spec.Limits,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := &substrate{config: testCase.config}
+			limitRange, err := s.getLimitRange(
+				"italian",
+				testCase.limitRange,
+				testCase.quota,
+			)
+			testCase.assertions(limitRange, err)
+		})
+	}
+}
+
+func TestSubstrateGetNetworkPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		defaultMode api.NetworkPolicyMode
+		mode        api.NetworkPolicyMode
+		assertions  func(*networkingv1.NetworkPolicy, error)
+	}{
+		{
+			name: "unrecognized mode",
+			mode: "bogus",
+			assertions: func(_ *networkingv1.NetworkPolicy, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "Unrecognized")
+			},
+		},
+		{
+			name:        "mode less restrictive than default",
+			defaultMode: api.NetworkPolicyModeDenyNamespaceToNamespace,
+			mode:        api.NetworkPolicyModeAllowAll,
+			assertions: func(_ *networkingv1.NetworkPolicy, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "less restrictive")
+			},
+		},
+		{
+			name: "allow all",
+			assertions: func(networkPolicy *networkingv1.NetworkPolicy, err error) {
+				require.NoError(t, err)
+				require.Nil(t, networkPolicy)
+			},
+		},
+		{
+			name:        "default applies",
+			defaultMode: api.NetworkPolicyModeDenyNamespaceToNamespace,
+			assertions: func(networkPolicy *networkingv1.NetworkPolicy, err error) {
+				require.NoError(t, err)
+				require.Len(t, networkPolicy.Spec.Ingress, 1)
+			},
+		},
+		{
+			name:        "egress only",
+			defaultMode: api.NetworkPolicyModeDenyNamespaceToNamespace,
+			mode:        api.NetworkPolicyModeEgressOnly,
+			assertions: func(networkPolicy *networkingv1.NetworkPolicy, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+					networkPolicy.Spec.PolicyTypes,
+				)
+				require.Empty(t, networkPolicy.Spec.Ingress)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := &substrate{
+				config: SubstrateConfig{
+					DefaultNetworkPolicy: testCase.defaultMode,
+				},
+			}
+			networkPolicy, err := s.getNetworkPolicy("italian", testCase.mode)
+			testCase.assertions(networkPolicy, err)
+		})
+	}
+}
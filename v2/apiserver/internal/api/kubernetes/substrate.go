@@ -2,24 +2,43 @@ package kubernetes
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/kubernetes"
 )
 
+// readWriteOncePodMinVersion is the earliest version of Kubernetes in which
+// the ReadWriteOncePod access mode is enabled by default.
+var readWriteOncePodMinVersion = version.MustParseGeneric("1.27.0")
+
+const (
+	// annotationDefaultStorageClass is the annotation that marks a cluster's
+	// default StorageClass.
+	annotationDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+)
+
 var runningPodsSelector = fields.Set(
 	map[string]string{
 		"status.phase": string(corev1.PodRunning),
@@ -38,6 +57,10 @@ var unknownPhasePodsSelector = fields.Set(
 	},
 ).AsSelector().String()
 
+// deletePropagationBackground is used when deleting batch/v1 Jobs to ensure
+// the pods they own are garbage collected.
+var deletePropagationBackground = metav1.DeletePropagationBackground
+
 // SubstrateConfig encapsulates several configuration options for the
 // Kubernetes-based Substrate.
 type SubstrateConfig struct {
@@ -73,20 +96,95 @@ type SubstrateConfig struct {
 	// configuration.
 	DefaultWorkerImagePullPolicy api.ImagePullPolicy
 	// WorkspaceStorageClass is the Kubernetes StorageClass that should be used
-	// for a Worker's shared storage.
+	// for a Worker's shared storage, unless overridden by the Project.
 	WorkspaceStorageClass string
 	// NodeSelectorKey is the key to use for the optional nodeSelector
-	// configuration for worker and jobs.
+	// configuration for worker and jobs, unless overridden by the Project.
 	NodeSelectorKey string
 	// NodeSelectorValue is the value to use for the optional nodeSelector
 	// configuration for worker and jobs.
 	NodeSelectorValue string
 	// TolerationKey is the key to use for the optional tolerations
-	// configuration for worker and jobs.
+	// configuration for worker and jobs, unless overridden by the Project.
 	TolerationKey string
 	// TolerationValue is the value to use for the optional tolerations
 	// configuration for worker and jobs.
 	TolerationValue string
+	// DefaultImagePolicy optionally restricts the OCI images that all Workers
+	// and Jobs may use, regardless of any ImagePolicy specified by Worker
+	// configuration.
+	DefaultImagePolicy *api.ImagePolicy
+	// BatchJobsEnabled indicates whether Worker and Job pods should be wrapped
+	// in batch/v1 Jobs instead of being created directly. This permits
+	// Kubernetes to replace pods lost to node failure.
+	BatchJobsEnabled bool
+	// BatchJobBackoffLimit is the number of times Kubernetes will retry a Job
+	// pod that has failed before considering the Job failed. Worker pods are
+	// never retried. It is only applicable when BatchJobsEnabled is true.
+	BatchJobBackoffLimit int32
+	// BatchJobTTLAfterFinished optionally specifies how long after completion
+	// Kubernetes itself should delete a Worker or Job's batch/v1 Job and pod if
+	// Brigade has not already cleaned them up. A value of zero disables this. It
+	// is only applicable when BatchJobsEnabled is true.
+	BatchJobTTLAfterFinished time.Duration
+	// ClaimableNamespaces enumerates patterns (in the format understood by
+	// path.Match) for the names of existing namespaces that Projects are
+	// permitted to claim instead of having a new namespace created for them.
+	// When empty, Projects may not claim existing namespaces at all.
+	ClaimableNamespaces []string
+	// ProjectNodeSelectorKeys enumerates the keys of node labels that Projects
+	// are permitted to include in node selectors of their own. When empty,
+	// Projects may not specify node selectors at all. The default node selector,
+	// if any, always applies in addition to a Project's node selector.
+	ProjectNodeSelectorKeys []string
+	// ProjectTolerationKeys enumerates the keys of node taints that Projects are
+	// permitted to tolerate. When empty, Projects may not specify tolerations at
+	// all. The default toleration, if any, always applies in addition to a
+	// Project's tolerations.
+	ProjectTolerationKeys []string
+	// MaxResourceQuota optionally maps the names of resources (e.g.
+	// "requests.cpu" or "pods") to the maximum quantity of each that may be
+	// consumed within any one Project's namespace. These maximums apply to every
+	// Project, including those that do not declare a ResourceQuota of their own.
+	MaxResourceQuota map[string]resource.Quantity
+	// DefaultContainerRequests optionally maps the names of compute resources
+	// (e.g. "cpu" or "memory") to the quantity of each requested by any
+	// container that does not request it explicitly. DefaultContainerLimits
+	// does likewise for limits. These apply, by way of a LimitRange, to any
+	// Project whose ResourceQuota constrains compute resources for which the
+	// Project's own LimitRange provides no default. Without such defaults,
+	// Kubernetes would reject every Worker and Job pod, since Brigade does not
+	// itself set compute requests or limits.
+	DefaultContainerRequests map[string]resource.Quantity
+	// DefaultContainerLimits optionally maps the names of compute resources to
+	// the limit on each for any container that does not specify one explicitly.
+	// See DefaultContainerRequests.
+	DefaultContainerLimits map[string]resource.Quantity
+	// DefaultNetworkPolicy is the NetworkPolicyMode that applies to Projects
+	// that do not specify one. It is also the least restrictive
+	// NetworkPolicyMode that any Project may specify. When empty,
+	// api.NetworkPolicyModeAllowAll is assumed.
+	DefaultNetworkPolicy api.NetworkPolicyMode
+	// WorkerPodOverlay optionally specifies a patch to be applied to every
+	// Worker pod after the substrate has built it and before any Project's own
+	// Worker pod overlay is applied.
+	WorkerPodOverlay *api.PodOverlay
+	// JobPodOverlay optionally specifies a patch to be applied to every Job pod
+	// after the substrate has built it and before any Project's own Job pod
+	// overlay is applied.
+	JobPodOverlay *api.PodOverlay
+	// PodOverlayDeniedFields enumerates the pod fields that Projects' pod
+	// overlays may not modify. Each is a path of field names separated by dots.
+	// When nil, DefaultPodOverlayDeniedFields applies.
+	PodOverlayDeniedFields []string
+	// NodePoolLabels enumerates the node labels, in order of precedence, used to
+	// determine which pool each node belongs to when summarizing the substrate's
+	// capacity. When nil, DefaultNodePoolLabels applies.
+	NodePoolLabels []string
+	// WorkerHeartbeatInterval is the interval at which Workers should report
+	// that they are alive. This should agree with the interval at which the
+	// observer expects heartbeats.
+	WorkerHeartbeatInterval time.Duration
 }
 
 // substrate is a Kubernetes-based implementation of the api.Substrate
@@ -159,36 +257,68 @@ func (s *substrate) CountRunningJobs(
 	return count, err
 }
 
+func (s *substrate) DefaultImagePolicy() *api.ImagePolicy {
+	return s.config.DefaultImagePolicy
+}
+
 func (s *substrate) CreateProject(
 	ctx context.Context,
 	project api.Project,
 ) (api.Project, error) {
-	// Generate and assign a unique Kubernetes namespace name for the Project,
-	// but don't create it yet
-	project.Kubernetes = &api.KubernetesDetails{
-		Namespace: s.generateNewNamespaceFn(),
+	if project.Kubernetes == nil {
+		project.Kubernetes = &api.KubernetesDetails{}
 	}
 
-	// Create the Project's Kubernetes namespace
-	if _, err := s.kubeClient.CoreV1().Namespaces().Create(
-		ctx,
-		&corev1.Namespace{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: project.Kubernetes.Namespace,
-				Labels: map[string]string{
-					myk8s.LabelBrigadeID: s.config.BrigadeID,
-					myk8s.LabelProject:   project.ID,
+	// Validate the Project's resource constraints, network policy, pod overlays,
+	// and scheduling overrides before creating anything
+	policies, err := s.getProjectPolicies(project)
+	if err != nil {
+		return project, err
+	}
+	if err = s.validatePodOverlays(project); err != nil {
+		return project, err
+	}
+	if err = s.validateSchedulingOverrides(project); err != nil {
+		return project, err
+	}
+	if err = s.validateCacheVolumes(ctx, project); err != nil {
+		return project, err
+	}
+	if err = validateJobServiceAccounts(project); err != nil {
+		return project, err
+	}
+
+	if project.Kubernetes.Namespace != "" {
+		// Claim the existing namespace specified by the client
+		if err := s.claimNamespace(ctx, project); err != nil {
+			return project, err
+		}
+	} else {
+		// Generate and assign a unique Kubernetes namespace name for the
+		// Project, but don't create it yet
+		project.Kubernetes.Namespace = s.generateNewNamespaceFn()
+
+		// Create the Project's Kubernetes namespace
+		if _, err := s.kubeClient.CoreV1().Namespaces().Create(
+			ctx,
+			&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: project.Kubernetes.Namespace,
+					Labels: map[string]string{
+						myk8s.LabelBrigadeID: s.config.BrigadeID,
+						myk8s.LabelProject:   project.ID,
+					},
 				},
 			},
-		},
-		metav1.CreateOptions{},
-	); err != nil {
-		return project, errors.Wrapf(
-			err,
-			"error creating namespace %q for project %q",
-			project.Kubernetes.Namespace,
-			project.ID,
-		)
+			metav1.CreateOptions{},
+		); err != nil {
+			return project, errors.Wrapf(
+				err,
+				"error creating namespace %q for project %q",
+				project.Kubernetes.Namespace,
+				project.ID,
+			)
+		}
 	}
 
 	// Create an RBAC Role for use by all the Project's Workers
@@ -327,6 +457,16 @@ func (s *substrate) CreateProject(
 		)
 	}
 
+	// Create any additional service accounts the Project declares for its Jobs
+	// and bind them to the jobs RBAC role as well
+	if err := s.reconcileJobServiceAccounts(
+		ctx,
+		project.Kubernetes.Namespace,
+		project,
+	); err != nil {
+		return project, err
+	}
+
 	// Create a Kubernetes Secret to store the Project's Secrets. Note that the
 	// Kubernetes-based implementation of the SecretStore interface will assume
 	// this Kubernetes secret exists.
@@ -354,6 +494,24 @@ func (s *substrate) CreateProject(
 		)
 	}
 
+	// Constrain the Project's resource usage and network reachability
+	if err := s.applyProjectPolicies(
+		ctx,
+		project.Kubernetes.Namespace,
+		policies,
+	); err != nil {
+		return project, err
+	}
+
+	// Provision the Project's cache volumes up front. Any that are subsequently
+	// added to the Project or cleared will be provisioned lazily, when a Job
+	// first requires them.
+	for _, cacheVolume := range project.Spec.CacheVolumes {
+		if err := s.createCacheVolumePVC(ctx, project, cacheVolume); err != nil {
+			return project, err
+		}
+	}
+
 	return project, nil
 }
 
@@ -361,6 +519,26 @@ func (s *substrate) DeleteProject(
 	ctx context.Context,
 	project api.Project,
 ) error {
+	namespace, err := s.kubeClient.CoreV1().Namespaces().Get(
+		ctx,
+		project.Kubernetes.Namespace,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving namespace %q",
+			project.Kubernetes.Namespace,
+		)
+	}
+
+	// A namespace that the Project claimed was not Brigade's to begin with, so
+	// rather than deleting it, just clean up everything that was created in it.
+	if _, claimed :=
+		namespace.Annotations[myk8s.AnnotationClaimedNamespace]; claimed {
+		return s.releaseNamespace(ctx, project, namespace)
+	}
+
 	// Just delete the Project's entire Kubernetes namespace and it should take
 	// all other Project resources along with it.
 	if err := s.kubeClient.CoreV1().Namespaces().Delete(
@@ -377,6 +555,63 @@ func (s *substrate) DeleteProject(
 	return nil
 }
 
+func (s *substrate) DeleteCacheVolume(
+	ctx context.Context,
+	project api.Project,
+	name string,
+) error {
+	// A PVC that is deleted while in use is not actually removed until every pod
+	// using it has terminated. In the meantime, it cannot be provisioned anew,
+	// so refuse to clear a cache volume that is in use.
+	pods, err := s.kubeClient.CoreV1().Pods(project.Kubernetes.Namespace).List(
+		ctx,
+		metav1.ListOptions{},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error listing pods in namespace %q",
+			project.Kubernetes.Namespace,
+		)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded ||
+			pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil &&
+				volume.PersistentVolumeClaim.ClaimName ==
+					myk8s.CacheVolumePVCName(name) {
+				return &meta.ErrConflict{
+					Type: api.CacheVolumeKind,
+					ID:   name,
+					Reason: fmt.Sprintf(
+						"Cache volume %q cannot be cleared while it is in use by pod %q.",
+						name,
+						pod.Name,
+					),
+				}
+			}
+		}
+	}
+	if err := s.kubeClient.CoreV1().PersistentVolumeClaims(
+		project.Kubernetes.Namespace,
+	).Delete(
+		ctx,
+		myk8s.CacheVolumePVCName(name),
+		metav1.DeleteOptions{},
+	); err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrapf(
+			err,
+			"error deleting cache volume PVC %q in namespace %q",
+			myk8s.CacheVolumePVCName(name),
+			project.Kubernetes.Namespace,
+		)
+	}
+	return nil
+}
+
 func (s *substrate) ScheduleWorker(ctx context.Context, event api.Event) error {
 	queueWriter, err := s.queueWriterFactory.NewWriter(
 		fmt.Sprintf("workers.%s", event.ProjectID),
@@ -446,6 +681,9 @@ func (s *substrate) StartWorker(
 		ConfigFilesDirectory string            `json:"configFilesDirectory"`
 		DefaultConfigFiles   map[string]string `json:"defaultConfigFiles"`
 		Git                  *api.GitConfig    `json:"git"`
+		// HeartbeatIntervalSeconds is how often the Worker should report that it
+		// is alive
+		HeartbeatIntervalSeconds float64 `json:"heartbeatIntervalSeconds"`
 	}
 
 	// Create a secret with event details
@@ -476,12 +714,13 @@ func (s *substrate) StartWorker(
 			LongTitle:  event.LongTitle,
 			Payload:    event.Payload,
 			Worker: worker{
-				APIAddress:           s.config.APIAddress,
-				APIToken:             token,
-				LogLevel:             event.Worker.Spec.LogLevel,
-				ConfigFilesDirectory: event.Worker.Spec.ConfigFilesDirectory,
-				DefaultConfigFiles:   event.Worker.Spec.DefaultConfigFiles,
-				Git:                  event.Worker.Spec.Git,
+				APIAddress:               s.config.APIAddress,
+				APIToken:                 token,
+				LogLevel:                 event.Worker.Spec.LogLevel,
+				ConfigFilesDirectory:     event.Worker.Spec.ConfigFilesDirectory,
+				DefaultConfigFiles:       event.Worker.Spec.DefaultConfigFiles,
+				Git:                      event.Worker.Spec.Git,
+				HeartbeatIntervalSeconds: s.config.WorkerHeartbeatInterval.Seconds(),
 			},
 		},
 		"",
@@ -605,7 +844,43 @@ func (s *substrate) StartJob(
 	project api.Project,
 	event api.Event,
 	jobName string,
+	token string,
 ) error {
+	// The Job's token is stored in a secret of its own, on the cluster where
+	// the Job's pod will run, so that it is exposed only to the Job's primary
+	// container.
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      myk8s.JobTokenSecretName(event.ID, jobName),
+			Namespace: project.Kubernetes.Namespace,
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: s.config.BrigadeID,
+				myk8s.LabelComponent: myk8s.LabelKeyJob,
+				myk8s.LabelProject:   project.ID,
+				myk8s.LabelEvent:     event.ID,
+				myk8s.LabelJob:       jobName,
+			},
+		},
+		Type: myk8s.SecretTypeJobToken,
+		StringData: map[string]string{
+			"apiToken": token,
+		},
+	}
+	secretsClient := s.kubeClient.CoreV1().Secrets(project.Kubernetes.Namespace)
+	_, err := secretsClient.Create(ctx, tokenSecret, metav1.CreateOptions{})
+	if k8sErrors.IsAlreadyExists(err) {
+		// A previous attempt to start the Job got this far; replace its token.
+		_, err = secretsClient.Update(ctx, tokenSecret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating token secret for event %q job %q in namespace %q",
+			event.ID,
+			jobName,
+			project.Kubernetes.Namespace,
+		)
+	}
 	job, _ := event.Worker.Job(jobName)
 	if err :=
 		s.createJobPodFn(ctx, project, event, jobName, job.Spec); err != nil {
@@ -633,6 +908,31 @@ func (s *substrate) DeleteJob(
 		},
 	).AsSelector().String()
 
+	// Delete any batch/v1 Job related to this Job. This must precede deletion
+	// of pods so that Kubernetes does not replace them. No such Jobs exist
+	// unless batch jobs are enabled.
+	if s.config.BatchJobsEnabled {
+		if err := s.kubeClient.BatchV1().Jobs(
+			project.Kubernetes.Namespace,
+		).DeleteCollection(
+			ctx,
+			metav1.DeleteOptions{
+				PropagationPolicy: &deletePropagationBackground,
+			},
+			metav1.ListOptions{
+				LabelSelector: labelSelector,
+			},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error deleting event %q job %q batch jobs in namespace %q",
+				event.ID,
+				jobName,
+				project.Kubernetes.Namespace,
+			)
+		}
+	}
+
 	// Delete all pods related to this Job
 	if err := s.kubeClient.CoreV1().Pods(
 		project.Kubernetes.Namespace,
@@ -690,6 +990,30 @@ func (s *substrate) DeleteWorkerAndJobs(
 	// created. Therefore, we just skip to cleaning up the event secret(s) below.
 	if event.Worker.Status.Phase != api.WorkerPhaseCanceled &&
 		event.Worker.Status.Phase != api.WorkerPhasePending {
+		// Delete all batch/v1 Jobs related to this Event. This must precede
+		// deletion of pods so that Kubernetes does not replace them. No such Jobs
+		// exist unless batch jobs are enabled.
+		if s.config.BatchJobsEnabled {
+			if err := s.kubeClient.BatchV1().Jobs(
+				project.Kubernetes.Namespace,
+			).DeleteCollection(
+				ctx,
+				metav1.DeleteOptions{
+					PropagationPolicy: &deletePropagationBackground,
+				},
+				metav1.ListOptions{
+					LabelSelector: labelSelector,
+				},
+			); err != nil {
+				return errors.Wrapf(
+					err,
+					"error deleting event %q batch jobs in namespace %q",
+					event.ID,
+					project.Kubernetes.Namespace,
+				)
+			}
+		}
+
 		// Delete all pods related to this Event
 		if err := s.kubeClient.CoreV1().Pods(
 			project.Kubernetes.Namespace,
@@ -809,6 +1133,11 @@ func (s *substrate) createWorkspacePVC(
 		)
 	}
 
+	storageClass := s.config.WorkspaceStorageClass
+	if project.Kubernetes.WorkspaceStorageClass != "" {
+		storageClass = project.Kubernetes.WorkspaceStorageClass
+	}
+
 	workspacePVC := corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      myk8s.WorkspacePVCName(event.ID),
@@ -821,7 +1150,7 @@ func (s *substrate) createWorkspacePVC(
 			},
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
-			StorageClassName: &s.config.WorkspaceStorageClass,
+			StorageClassName: &storageClass,
 			AccessModes: []corev1.PersistentVolumeAccessMode{
 				corev1.ReadWriteMany,
 			},
@@ -850,6 +1179,215 @@ func (s *substrate) createWorkspacePVC(
 	return nil
 }
 
+// createCacheVolumePVC creates a PVC for the specified Project cache volume if
+// one does not already exist.
+func (s *substrate) createCacheVolumePVC(
+	ctx context.Context,
+	project api.Project,
+	cacheVolume api.CacheVolume,
+) error {
+	storageQuantityStr := cacheVolume.Size
+	if storageQuantityStr == "" {
+		storageQuantityStr = "1G"
+	}
+	storageQuantity, err := resource.ParseQuantity(storageQuantityStr)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error parsing storage quantity %q for project %q cache volume %q",
+			storageQuantityStr,
+			project.ID,
+			cacheVolume.Name,
+		)
+	}
+
+	accessMode := corev1.ReadWriteMany
+	if cacheVolume.AccessMode != api.CacheVolumeAccessModeShared {
+		if accessMode, err =
+			s.getExclusiveAccessMode(ctx, cacheVolume); err != nil {
+			return err
+		}
+	}
+
+	cacheVolumePVC := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      myk8s.CacheVolumePVCName(cacheVolume.Name),
+			Namespace: project.Kubernetes.Namespace,
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: s.config.BrigadeID,
+				myk8s.LabelComponent: myk8s.LabelKeyCacheVolume,
+				myk8s.LabelProject:   project.ID,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{accessMode},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					"storage": storageQuantity,
+				},
+			},
+		},
+	}
+	if cacheVolume.StorageClass != "" {
+		cacheVolumePVC.Spec.StorageClassName = &cacheVolume.StorageClass
+	}
+
+	pvcClient :=
+		s.kubeClient.CoreV1().PersistentVolumeClaims(project.Kubernetes.Namespace)
+	if _, err = pvcClient.Create(
+		ctx,
+		&cacheVolumePVC,
+		metav1.CreateOptions{},
+	); err == nil {
+		return nil
+	} else if !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrapf(
+			err,
+			"error creating PVC for project %q cache volume %q",
+			project.ID,
+			cacheVolume.Name,
+		)
+	}
+
+	// The PVC already exists. Make sure it isn't one that is still being deleted
+	// after the cache volume was cleared.
+	existingPVC, err := pvcClient.Get(
+		ctx,
+		cacheVolumePVC.Name,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving PVC for project %q cache volume %q",
+			project.ID,
+			cacheVolume.Name,
+		)
+	}
+	if existingPVC.DeletionTimestamp != nil {
+		return errors.Errorf(
+			"PVC for project %q cache volume %q is still being deleted",
+			project.ID,
+			cacheVolume.Name,
+		)
+	}
+	return nil
+}
+
+// validateCacheVolumes returns a *meta.ErrBadRequest error if any of the
+// specified Project's exclusive cache volumes cannot be provisioned in a manner
+// that prevents concurrent use.
+func (s *substrate) validateCacheVolumes(
+	ctx context.Context,
+	project api.Project,
+) error {
+	for _, cacheVolume := range project.Spec.CacheVolumes {
+		if cacheVolume.AccessMode == api.CacheVolumeAccessModeShared {
+			continue
+		}
+		if _, err := s.getExclusiveAccessMode(ctx, cacheVolume); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getExclusiveAccessMode returns the access mode with which to prevent
+// concurrent use of the specified exclusive cache volume. A pod requiring a
+// ReadWriteOncePod volume that is already in use remains unschedulable until
+// the volume is released. However, ReadWriteOncePod is enabled by default only
+// as of Kubernetes 1.27 and is supported only by CSI drivers. ReadWriteOnce
+// would not prevent concurrent use of the volume by pods on the same node, so
+// if either requirement is not met, a *meta.ErrBadRequest error is returned
+// instead.
+func (s *substrate) getExclusiveAccessMode(
+	ctx context.Context,
+	cacheVolume api.CacheVolume,
+) (corev1.PersistentVolumeAccessMode, error) {
+	unsupported := func(reason string) error {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"Exclusive cache volume %q is not supported because %s. Use a "+
+					"shared cache volume instead.",
+				cacheVolume.Name,
+				reason,
+			),
+		}
+	}
+	serverVersion, err := s.kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return "", errors.Wrap(err, "error retrieving Kubernetes version")
+	}
+	parsedVersion, err := version.ParseGeneric(serverVersion.GitVersion)
+	if err != nil {
+		return "", errors.Wrapf(
+			err,
+			"error parsing Kubernetes version %q",
+			serverVersion.GitVersion,
+		)
+	}
+	if !parsedVersion.AtLeast(readWriteOncePodMinVersion) {
+		return "", unsupported(
+			fmt.Sprintf(
+				"Kubernetes %s does not enable the ReadWriteOncePod access mode by "+
+					"default",
+				serverVersion.GitVersion,
+			),
+		)
+	}
+	var provisioner string
+	if cacheVolume.StorageClass != "" {
+		storageClass, err := s.kubeClient.StorageV1().StorageClasses().Get(
+			ctx,
+			cacheVolume.StorageClass,
+			metav1.GetOptions{},
+		)
+		if k8sErrors.IsNotFound(err) {
+			return "", unsupported(
+				fmt.Sprintf(
+					"storage class %q does not exist",
+					cacheVolume.StorageClass,
+				),
+			)
+		}
+		if err != nil {
+			return "", errors.Wrapf(
+				err,
+				"error retrieving storage class %q",
+				cacheVolume.StorageClass,
+			)
+		}
+		provisioner = storageClass.Provisioner
+	} else {
+		storageClasses, err :=
+			s.kubeClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return "", errors.Wrap(err, "error listing storage classes")
+		}
+		for _, storageClass := range storageClasses.Items {
+			if storageClass.Annotations[annotationDefaultStorageClass] == "true" {
+				provisioner = storageClass.Provisioner
+				break
+			}
+		}
+		if provisioner == "" {
+			return "", unsupported("there is no default storage class")
+		}
+	}
+	// Provisioners for in-tree volume plugins, which do not support
+	// ReadWriteOncePod, are all prefixed with "kubernetes.io/"
+	if strings.HasPrefix(provisioner, "kubernetes.io/") {
+		return "", unsupported(
+			fmt.Sprintf(
+				"storage provisioner %q does not support the ReadWriteOncePod "+
+					"access mode",
+				provisioner,
+			),
+		)
+	}
+	return corev1.ReadWriteOncePod, nil
+}
+
 func (s *substrate) createWorkerPod(
 	ctx context.Context,
 	project api.Project,
@@ -996,31 +1534,26 @@ func (s *substrate) createWorkerPod(
 		},
 	}
 
-	if s.config.NodeSelectorKey != "" && s.config.NodeSelectorValue != "" {
-		workerPod.Spec.NodeSelector = map[string]string{
-			s.config.NodeSelectorKey: s.config.NodeSelectorValue,
-		}
-	}
+	workerPod.Spec.NodeSelector = s.nodeSelector(project)
+	workerPod.Spec.Tolerations = s.tolerations(project)
 
-	if s.config.TolerationKey != "" {
-		workerPod.Spec.Tolerations = []corev1.Toleration{
-			{
-				Key:      s.config.TolerationKey,
-				Operator: corev1.TolerationOpExists,
-			},
-		}
-		if s.config.TolerationValue != "" {
-			workerPod.Spec.Tolerations[0].Value = s.config.TolerationValue
-			workerPod.Spec.Tolerations[0].Operator = corev1.TolerationOpEqual
-		}
+	if event.Worker.Spec.Scheduling != nil {
+		applySchedulingConstraints(&workerPod.Spec, *event.Worker.Spec.Scheduling)
 	}
 
-	podClient := s.kubeClient.CoreV1().Pods(project.Kubernetes.Namespace)
-	if _, err := podClient.Create(
-		ctx,
+	var projectOverlay *api.PodOverlay
+	if project.Kubernetes != nil {
+		projectOverlay = project.Kubernetes.WorkerPodOverlay
+	}
+	if err := s.applyPodOverlays(
 		&workerPod,
-		metav1.CreateOptions{},
+		s.config.WorkerPodOverlay,
+		projectOverlay,
 	); err != nil {
+		return errors.Wrapf(err, "error building pod for event %q worker", event.ID)
+	}
+
+	if err := s.createPodOrBatchJob(ctx, &workerPod); err != nil {
 		return errors.Wrapf(
 			err,
 			"error creating pod for event %q worker",
@@ -1063,6 +1596,11 @@ func (s *substrate) createJobSecret(
 			jobSecret.StringData[fmt.Sprintf("%s.%s", sidecarName, k)] = v
 		}
 	}
+	for _, initContainer := range jobSpec.InitContainers {
+		for k, v := range initContainer.Environment {
+			jobSecret.StringData[fmt.Sprintf("%s.%s", initContainer.Name, k)] = v
+		}
+	}
 
 	secretsClient := s.kubeClient.CoreV1().Secrets(project.Kubernetes.Namespace)
 	if _, err := secretsClient.Create(
@@ -1107,10 +1645,18 @@ func (s *substrate) createJobPod(
 		// 	useDockerSocket = true
 		// }
 	}
-
-	imagePullSecrets := []corev1.LocalObjectReference{}
-	if event.Worker.Spec.Kubernetes != nil {
-		imagePullSecrets = make(
+	for _, initContainer := range jobSpec.InitContainers {
+		if initContainer.WorkspaceMountPath != "" {
+			useWorkspace = true
+		}
+		if initContainer.SourceMountPath != "" {
+			useSource = true
+		}
+	}
+
+	imagePullSecrets := []corev1.LocalObjectReference{}
+	if event.Worker.Spec.Kubernetes != nil {
+		imagePullSecrets = make(
 			[]corev1.LocalObjectReference,
 			len(event.Worker.Spec.Kubernetes.ImagePullSecrets),
 		)
@@ -1155,6 +1701,88 @@ func (s *substrate) createJobPod(
 			},
 		)
 	}
+	// If any of the job's containers mounts Project Secrets as files, add a
+	// volume that projects ONLY the referenced keys from the Project's Secrets.
+	secretKeys := map[string]struct{}{}
+	for _, secretMount := range jobSpec.PrimaryContainer.SecretMounts {
+		secretKeys[secretMount.Key] = struct{}{}
+	}
+	for _, sidecarContainer := range jobSpec.SidecarContainers {
+		for _, secretMount := range sidecarContainer.SecretMounts {
+			secretKeys[secretMount.Key] = struct{}{}
+		}
+	}
+	for _, initContainer := range jobSpec.InitContainers {
+		for _, secretMount := range initContainer.SecretMounts {
+			secretKeys[secretMount.Key] = struct{}{}
+		}
+	}
+	if len(secretKeys) > 0 {
+		items := make([]corev1.KeyToPath, 0, len(secretKeys))
+		for key := range secretKeys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: key})
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Key < items[j].Key
+		})
+		volumes = append(
+			volumes,
+			corev1.Volume{
+				Name: "project-secrets",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: "project-secrets",
+						Items:      items,
+					},
+				},
+			},
+		)
+	}
+
+	// Provision (if necessary) and add a volume for each distinct cache volume
+	// that is mounted by any of the job's containers.
+	cacheVolumeMounts := append(
+		[]api.CacheVolumeMount{},
+		jobSpec.PrimaryContainer.CacheVolumeMounts...,
+	)
+	for _, sidecarContainer := range jobSpec.SidecarContainers {
+		cacheVolumeMounts =
+			append(cacheVolumeMounts, sidecarContainer.CacheVolumeMounts...)
+	}
+	for _, initContainer := range jobSpec.InitContainers {
+		cacheVolumeMounts =
+			append(cacheVolumeMounts, initContainer.CacheVolumeMounts...)
+	}
+	cacheVolumeNames := map[string]struct{}{}
+	for _, cacheVolumeMount := range cacheVolumeMounts {
+		if _, ok := cacheVolumeNames[cacheVolumeMount.Name]; ok {
+			continue
+		}
+		cacheVolumeNames[cacheVolumeMount.Name] = struct{}{}
+		cacheVolume, ok := project.Spec.CacheVolume(cacheVolumeMount.Name)
+		if !ok {
+			return errors.Errorf(
+				"project %q does not define cache volume %q",
+				project.ID,
+				cacheVolumeMount.Name,
+			)
+		}
+		if err := s.createCacheVolumePVC(ctx, project, cacheVolume); err != nil {
+			return err
+		}
+		volumes = append(
+			volumes,
+			corev1.Volume{
+				Name: myk8s.CacheVolumePVCName(cacheVolume.Name),
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: myk8s.CacheVolumePVCName(cacheVolume.Name),
+					},
+				},
+			},
+		)
+	}
+
 	// if useDockerSocket {
 	// 	volumes = append(
 	// 		volumes,
@@ -1200,6 +1828,20 @@ func (s *substrate) createJobPod(
 		}
 	}
 
+	// The job's own init containers, if any, run in order AFTER the vcs init
+	// container so they may make use of source code retrieved from git.
+	for _, initContainer := range jobSpec.InitContainers {
+		initContainers = append(
+			initContainers,
+			getContainerFromSpec(
+				event.ID,
+				jobName,
+				initContainer.Name,
+				initContainer.JobContainerSpec,
+			),
+		)
+	}
+
 	// This slice is big enough to hold the primary container AND all (if any)
 	// sidecar containers.
 	containers := make([]corev1.Container, len(jobSpec.SidecarContainers)+1)
@@ -1211,6 +1853,37 @@ func (s *substrate) createJobPod(
 		jobName,
 		jobSpec.PrimaryContainer,
 	)
+	// The primary container is given what it needs to report the job's progress
+	// to the API server. These precede any environment variables from the
+	// job's spec so the latter take precedence.
+	containers[0].Env = append(
+		[]corev1.EnvVar{
+			{
+				Name:  "BRIGADE_API_ADDRESS",
+				Value: s.config.APIAddress,
+			},
+			{
+				Name: "BRIGADE_API_TOKEN",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: myk8s.JobTokenSecretName(event.ID, jobName),
+						},
+						Key: "apiToken",
+					},
+				},
+			},
+			{
+				Name:  "BRIGADE_EVENT_ID",
+				Value: event.ID,
+			},
+			{
+				Name:  "BRIGADE_JOB_NAME",
+				Value: jobName,
+			},
+		},
+		containers[0].Env...,
+	)
 
 	// Now add all the sidecars...
 	i := 1
@@ -1240,7 +1913,7 @@ func (s *substrate) createJobPod(
 			},
 		},
 		Spec: corev1.PodSpec{
-			ServiceAccountName: "jobs",
+			ServiceAccountName: jobServiceAccount(project, jobSpec),
 			ImagePullSecrets:   imagePullSecrets,
 			RestartPolicy:      corev1.RestartPolicyNever,
 			InitContainers:     initContainers,
@@ -1253,9 +1926,8 @@ func (s *substrate) createJobPod(
 	if jobSpec.Host != nil && jobSpec.Host.OS == api.OSFamilyWindows {
 		jobPod.Spec.NodeSelector[corev1.LabelOSStable] = "windows"
 	}
-	if s.config.NodeSelectorKey != "" && s.config.NodeSelectorValue != "" {
-		jobPod.Spec.NodeSelector[s.config.NodeSelectorKey] =
-			s.config.NodeSelectorValue
+	for key, value := range s.nodeSelector(project) {
+		jobPod.Spec.NodeSelector[key] = value
 	}
 
 	jobPod.Spec.Tolerations = []corev1.Toleration{}
@@ -1269,24 +1941,31 @@ func (s *substrate) createJobPod(
 			},
 		}
 	}
-	if s.config.TolerationKey != "" {
-		toleration := corev1.Toleration{
-			Key:      s.config.TolerationKey,
-			Operator: corev1.TolerationOpExists,
-		}
-		if s.config.TolerationValue != "" {
-			toleration.Value = s.config.TolerationValue
-			toleration.Operator = corev1.TolerationOpEqual
-		}
-		jobPod.Spec.Tolerations = append(jobPod.Spec.Tolerations, toleration)
+	jobPod.Spec.Tolerations =
+		append(jobPod.Spec.Tolerations, s.tolerations(project)...)
+
+	if jobSpec.Host != nil {
+		applySchedulingConstraints(&jobPod.Spec, jobSpec.Host.SchedulingConstraints)
 	}
 
-	podClient := s.kubeClient.CoreV1().Pods(project.Kubernetes.Namespace)
-	if _, err := podClient.Create(
-		ctx,
+	var projectOverlay *api.PodOverlay
+	if project.Kubernetes != nil {
+		projectOverlay = project.Kubernetes.JobPodOverlay
+	}
+	if err := s.applyPodOverlays(
 		&jobPod,
-		metav1.CreateOptions{},
+		s.config.JobPodOverlay,
+		projectOverlay,
 	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error building pod for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+
+	if err := s.createPodOrBatchJob(ctx, &jobPod); err != nil {
 		return errors.Wrapf(
 			err,
 			"error creating pod for event %q job %q",
@@ -1298,6 +1977,77 @@ func (s *substrate) createJobPod(
 	return nil
 }
 
+// createPodOrBatchJob creates the provided pod or, if the substrate is
+// configured to use batch/v1 Jobs, a batch/v1 Job having the provided pod as
+// its template. In the latter case, Kubernetes generates the names of the
+// pods it creates, but they bear the same labels and annotations as the
+// provided pod. Only Job pods are retried in accordance with the configured
+// backoff limit; Worker pods are never retried.
+func (s *substrate) createPodOrBatchJob(
+	ctx context.Context,
+	pod *corev1.Pod,
+) error {
+	if !s.config.BatchJobsEnabled {
+		_, err := s.kubeClient.CoreV1().Pods(pod.Namespace).Create(
+			ctx,
+			pod,
+			metav1.CreateOptions{},
+		)
+		return err
+	}
+	backoffLimit := s.config.BatchJobBackoffLimit
+	// A Worker is never retried. A replacement Worker would execute the
+	// Worker's script from the beginning and fail as soon as it attempted to
+	// create any Job that the original Worker had already created.
+	if pod.Labels[myk8s.LabelComponent] == myk8s.LabelKeyWorker {
+		backoffLimit = 0
+	}
+	batchJob := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        batchJobName(pod.Name),
+			Namespace:   pod.Namespace,
+			Annotations: pod.Annotations,
+			Labels:      pod.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: pod.Annotations,
+					Labels:      pod.Labels,
+				},
+				Spec: pod.Spec,
+			},
+		},
+	}
+	if s.config.BatchJobTTLAfterFinished > 0 {
+		ttl := int32(s.config.BatchJobTTLAfterFinished.Seconds())
+		batchJob.Spec.TTLSecondsAfterFinished = &ttl
+	}
+	_, err := s.kubeClient.BatchV1().Jobs(pod.Namespace).Create(
+		ctx,
+		&batchJob,
+		metav1.CreateOptions{},
+	)
+	return err
+}
+
+// batchJobName returns the name of the batch/v1 Job that wraps a pod that
+// would otherwise have been given the specified name. Kubernetes labels every
+// pod it creates for a batch/v1 Job with the Job's name, so the name is
+// truncated, if necessary, to the maximum length of a label value. A hash of
+// the full name is appended to truncated names to keep them unique.
+func batchJobName(podName string) string {
+	if len(podName) <= validation.LabelValueMaxLength {
+		return podName
+	}
+	return fmt.Sprintf(
+		"%s-%x",
+		podName[:validation.LabelValueMaxLength-9],
+		sha256.Sum256([]byte(podName)),
+	)[:validation.LabelValueMaxLength]
+}
+
 func getContainerFromSpec(
 	eventID string,
 	jobName string,
@@ -1329,6 +2079,22 @@ func getContainerFromSpec(
 		}
 		i++
 	}
+	for envVar, key := range spec.SecretEnvironment {
+		container.Env = append(
+			container.Env,
+			corev1.EnvVar{
+				Name: envVar,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "project-secrets",
+						},
+						Key: key,
+					},
+				},
+			},
+		)
+	}
 	if spec.WorkspaceMountPath != "" {
 		container.VolumeMounts = []corev1.VolumeMount{
 			{
@@ -1346,6 +2112,26 @@ func getContainerFromSpec(
 			},
 		)
 	}
+	for _, secretMount := range spec.SecretMounts {
+		container.VolumeMounts = append(
+			container.VolumeMounts,
+			corev1.VolumeMount{
+				Name:      "project-secrets",
+				MountPath: secretMount.MountPath,
+				SubPath:   secretMount.Key,
+				ReadOnly:  true,
+			},
+		)
+	}
+	for _, cacheVolumeMount := range spec.CacheVolumeMounts {
+		container.VolumeMounts = append(
+			container.VolumeMounts,
+			corev1.VolumeMount{
+				Name:      myk8s.CacheVolumePVCName(cacheVolumeMount.Name),
+				MountPath: cacheVolumeMount.MountPath,
+			},
+		)
+	}
 	// if spec.UseHostDockerSocket {
 	// 	container.VolumeMounts = append(
 	// 		container.VolumeMounts,
@@ -1361,5 +2147,454 @@ func getContainerFromSpec(
 			Privileged: &tru,
 		}
 	}
+	if spec.SecurityContext != nil {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &corev1.SecurityContext{}
+		}
+		applySecurityContext(container.SecurityContext, *spec.SecurityContext)
+	}
 	return container
 }
+
+// applySecurityContext amends the provided Kubernetes SecurityContext to
+// reflect the provided ContainerSecurityContext.
+func applySecurityContext(
+	securityContext *corev1.SecurityContext,
+	sc api.ContainerSecurityContext,
+) {
+	securityContext.RunAsUser = sc.RunAsUser
+	securityContext.RunAsGroup = sc.RunAsGroup
+	if sc.RunAsNonRoot {
+		tru := true
+		securityContext.RunAsNonRoot = &tru
+	}
+	if sc.ReadOnlyRootFilesystem {
+		tru := true
+		securityContext.ReadOnlyRootFilesystem = &tru
+	}
+	if len(sc.DropCapabilities) > 0 {
+		securityContext.Capabilities = &corev1.Capabilities{
+			Drop: make([]corev1.Capability, len(sc.DropCapabilities)),
+		}
+		for i, capability := range sc.DropCapabilities {
+			securityContext.Capabilities.Drop[i] = corev1.Capability(capability)
+		}
+	}
+	if sc.SeccompProfile != "" {
+		securityContext.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileType(sc.SeccompProfile),
+		}
+	}
+}
+
+// getK8sToleration translates the provided Toleration into a
+// corev1.Toleration.
+func getK8sToleration(toleration api.Toleration) corev1.Toleration {
+	operator := corev1.TolerationOpEqual
+	if toleration.Operator == api.TolerationOperatorExists {
+		operator = corev1.TolerationOpExists
+	}
+	return corev1.Toleration{
+		Key:      toleration.Key,
+		Operator: operator,
+		Value:    toleration.Value,
+		Effect:   corev1.TaintEffect(toleration.Effect),
+	}
+}
+
+// applySchedulingConstraints amends the provided PodSpec to reflect the
+// provided SchedulingConstraints. Any node selector or tolerations already
+// present in the PodSpec are preserved.
+func applySchedulingConstraints(
+	podSpec *corev1.PodSpec,
+	sc api.SchedulingConstraints,
+) {
+	if sc.Arch != "" {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = map[string]string{}
+		}
+		podSpec.NodeSelector[corev1.LabelArchStable] = string(sc.Arch)
+	}
+
+	for _, toleration := range sc.Tolerations {
+		podSpec.Tolerations =
+			append(podSpec.Tolerations, getK8sToleration(toleration))
+	}
+
+	if len(sc.NodeAffinity) > 0 || len(sc.PodAffinity) > 0 {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+
+	if len(sc.NodeAffinity) > 0 {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+		// All requirements are ANDed together in a single term.
+		required := []corev1.NodeSelectorRequirement{}
+		for _, term := range sc.NodeAffinity {
+			requirement := corev1.NodeSelectorRequirement{
+				Key:      term.Key,
+				Operator: corev1.NodeSelectorOperator(term.Operator),
+				Values:   term.Values,
+			}
+			if term.Weight == 0 {
+				required = append(required, requirement)
+				continue
+			}
+			podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append( // nolint: lll
+				podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, // nolint: lll
+				corev1.PreferredSchedulingTerm{
+					Weight: term.Weight,
+					Preference: corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{requirement},
+					},
+				},
+			)
+		}
+		if len(required) > 0 {
+			podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = // nolint: lll
+				&corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{
+							MatchExpressions: required,
+						},
+					},
+				}
+		}
+	}
+
+	for _, term := range sc.PodAffinity {
+		podAffinityTerm := corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: term.MatchLabels,
+			},
+			TopologyKey: term.TopologyKey,
+		}
+		var required *[]corev1.PodAffinityTerm
+		var preferred *[]corev1.WeightedPodAffinityTerm
+		if term.Anti {
+			if podSpec.Affinity.PodAntiAffinity == nil {
+				podSpec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+			}
+			required = &podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution   // nolint: lll
+			preferred = &podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution // nolint: lll
+		} else {
+			if podSpec.Affinity.PodAffinity == nil {
+				podSpec.Affinity.PodAffinity = &corev1.PodAffinity{}
+			}
+			required = &podSpec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution   // nolint: lll
+			preferred = &podSpec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution // nolint: lll
+		}
+		if term.Weight == 0 {
+			*required = append(*required, podAffinityTerm)
+		} else {
+			*preferred = append(
+				*preferred,
+				corev1.WeightedPodAffinityTerm{
+					Weight:          term.Weight,
+					PodAffinityTerm: podAffinityTerm,
+				},
+			)
+		}
+	}
+
+	podSpec.PriorityClassName = sc.PriorityClass
+}
+
+// nodeSelector returns the node selector that applies to all of the specified
+// Project's Worker and Job pods. A node selector specified by the Project
+// itself takes precedence over the substrate's default.
+func (s *substrate) nodeSelector(project api.Project) map[string]string {
+	nodeSelector := map[string]string{}
+	if project.Kubernetes != nil {
+		// Projects were validated against the permitted keys when they were
+		// created or updated, but the permitted keys may have changed since.
+		for key, value := range project.Kubernetes.NodeSelector {
+			if api.Contains(s.config.ProjectNodeSelectorKeys, key) {
+				nodeSelector[key] = value
+			}
+		}
+	}
+	// The default node selector takes precedence over the Project's
+	if s.config.NodeSelectorKey != "" && s.config.NodeSelectorValue != "" {
+		nodeSelector[s.config.NodeSelectorKey] = s.config.NodeSelectorValue
+	}
+	if len(nodeSelector) == 0 {
+		return nil
+	}
+	return nodeSelector
+}
+
+func (s *substrate) tolerations(project api.Project) []corev1.Toleration {
+	tolerations := []corev1.Toleration{}
+	if s.config.TolerationKey != "" {
+		toleration := corev1.Toleration{
+			Key:      s.config.TolerationKey,
+			Operator: corev1.TolerationOpExists,
+		}
+		if s.config.TolerationValue != "" {
+			toleration.Value = s.config.TolerationValue
+			toleration.Operator = corev1.TolerationOpEqual
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	if project.Kubernetes != nil {
+		// Projects were validated against the permitted keys when they were
+		// created or updated, but the permitted keys may have changed since.
+		for _, toleration := range project.Kubernetes.Tolerations {
+			if api.Contains(s.config.ProjectTolerationKeys, toleration.Key) {
+				tolerations = append(tolerations, getK8sToleration(toleration))
+			}
+		}
+	}
+	if len(tolerations) == 0 {
+		return nil
+	}
+	return tolerations
+}
+
+// validateSchedulingOverrides returns a *meta.ErrAuthorization error if the
+// provided Project's node selector or tolerations use any key that the
+// substrate has not been configured to permit.
+func (s *substrate) validateSchedulingOverrides(project api.Project) error {
+	if project.Kubernetes == nil {
+		return nil
+	}
+	for key := range project.Kubernetes.NodeSelector {
+		if !api.Contains(s.config.ProjectNodeSelectorKeys, key) {
+			return &meta.ErrAuthorization{
+				Reason: fmt.Sprintf(
+					"Projects may not select nodes using label %q.",
+					key,
+				),
+			}
+		}
+	}
+	for _, toleration := range project.Kubernetes.Tolerations {
+		if !api.Contains(s.config.ProjectTolerationKeys, toleration.Key) {
+			return &meta.ErrAuthorization{
+				Reason: fmt.Sprintf(
+					"Projects may not tolerate taints with key %q.",
+					toleration.Key,
+				),
+			}
+		}
+	}
+	return nil
+}
+
+// namespaceClaimable returns a bool indicating whether the substrate has been
+// configured to permit Projects to claim the specified, existing namespace.
+func (s *substrate) namespaceClaimable(namespace string) bool {
+	for _, pattern := range s.config.ClaimableNamespaces {
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// claimNamespace validates that the existing namespace specified by the
+// provided Project may be claimed by it and, if so, labels and annotates the
+// namespace to reflect its new ownership.
+func (s *substrate) claimNamespace(
+	ctx context.Context,
+	project api.Project,
+) error {
+	namespaceName := project.Kubernetes.Namespace
+	if !s.namespaceClaimable(namespaceName) {
+		return &meta.ErrAuthorization{
+			Reason: fmt.Sprintf(
+				"Namespace %q may not be claimed by projects.",
+				namespaceName,
+			),
+		}
+	}
+	namespace, err := s.kubeClient.CoreV1().Namespaces().Get(
+		ctx,
+		namespaceName,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return &meta.ErrBadRequest{
+				Reason: fmt.Sprintf("Namespace %q does not exist.", namespaceName),
+			}
+		}
+		return errors.Wrapf(err, "error retrieving namespace %q", namespaceName)
+	}
+	if owner, ok := namespace.Labels[myk8s.LabelProject]; ok {
+		return &meta.ErrConflict{
+			Type: "Namespace",
+			ID:   namespaceName,
+			Reason: fmt.Sprintf(
+				"Namespace %q already belongs to project %q.",
+				namespaceName,
+				owner,
+			),
+		}
+	}
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+	namespace.Labels[myk8s.LabelBrigadeID] = s.config.BrigadeID
+	namespace.Labels[myk8s.LabelProject] = project.ID
+	if namespace.Annotations == nil {
+		namespace.Annotations = map[string]string{}
+	}
+	namespace.Annotations[myk8s.AnnotationClaimedNamespace] = "true"
+	if _, err = s.kubeClient.CoreV1().Namespaces().Update(
+		ctx,
+		namespace,
+		metav1.UpdateOptions{},
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error claiming namespace %q for project %q",
+			namespaceName,
+			project.ID,
+		)
+	}
+	return nil
+}
+
+// releaseNamespace deletes everything that was created on behalf of the
+// provided Project within the provided namespace, which the Project had
+// claimed, and then removes the labels and annotation that marked the
+// namespace as belonging to the Project. The namespace itself is left intact.
+func (s *substrate) releaseNamespace(
+	ctx context.Context,
+	project api.Project,
+	namespace *corev1.Namespace,
+) error {
+	listOpts := metav1.ListOptions{
+		LabelSelector: labels.Set(
+			map[string]string{
+				myk8s.LabelBrigadeID: s.config.BrigadeID,
+				myk8s.LabelProject:   project.ID,
+			},
+		).AsSelector().String(),
+	}
+	if s.config.BatchJobsEnabled {
+		if err := s.kubeClient.BatchV1().Jobs(namespace.Name).DeleteCollection(
+			ctx,
+			metav1.DeleteOptions{
+				PropagationPolicy: &deletePropagationBackground,
+			},
+			listOpts,
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error deleting batch jobs in namespace %q",
+				namespace.Name,
+			)
+		}
+	}
+	if err := s.kubeClient.CoreV1().Pods(namespace.Name).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting pods in namespace %q",
+			namespace.Name,
+		)
+	}
+	if err := s.kubeClient.CoreV1().Secrets(namespace.Name).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting secrets in namespace %q",
+			namespace.Name,
+		)
+	}
+	if err := s.kubeClient.CoreV1().PersistentVolumeClaims(
+		namespace.Name,
+	).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting persistent volume claims in namespace %q",
+			namespace.Name,
+		)
+	}
+	if err := s.applyProjectPolicies(
+		ctx,
+		namespace.Name,
+		projectPolicies{},
+	); err != nil {
+		return err
+	}
+	if err := s.kubeClient.CoreV1().ServiceAccounts(
+		namespace.Name,
+	).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting service accounts in namespace %q",
+			namespace.Name,
+		)
+	}
+	for _, name := range []string{"workers", "jobs"} {
+		if err := s.kubeClient.RbacV1().RoleBindings(namespace.Name).Delete(
+			ctx,
+			name,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting role binding %q in namespace %q",
+				name,
+				namespace.Name,
+			)
+		}
+		if err := s.kubeClient.RbacV1().Roles(namespace.Name).Delete(
+			ctx,
+			name,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting role %q in namespace %q",
+				name,
+				namespace.Name,
+			)
+		}
+		if err := s.kubeClient.CoreV1().ServiceAccounts(namespace.Name).Delete(
+			ctx,
+			name,
+			metav1.DeleteOptions{},
+		); err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(
+				err,
+				"error deleting service account %q in namespace %q",
+				name,
+				namespace.Name,
+			)
+		}
+	}
+	namespace = namespace.DeepCopy()
+	delete(namespace.Labels, myk8s.LabelBrigadeID)
+	delete(namespace.Labels, myk8s.LabelProject)
+	delete(namespace.Annotations, myk8s.AnnotationClaimedNamespace)
+	if _, err := s.kubeClient.CoreV1().Namespaces().Update(
+		ctx,
+		namespace,
+		metav1.UpdateOptions{},
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error releasing namespace %q",
+			namespace.Name,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultNodePoolLabels enumerates the node labels, in order of precedence,
+// that the substrate uses to determine which pool a node belongs to when an
+// administrator has not specified any. These are the labels applied by several
+// popular managed Kubernetes offerings.
+var DefaultNodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+}
+
+// nonTerminatedPodsSelector selects pods that may still occupy node capacity.
+var nonTerminatedPodsSelector = fields.AndSelectors(
+	fields.OneTermNotEqualSelector(
+		"status.phase",
+		string(corev1.PodSucceeded),
+	),
+	fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+).String()
+
+func (s *substrate) GetInventory(
+	ctx context.Context,
+) (api.SubstrateInventory, error) {
+	inventory := api.SubstrateInventory{
+		Projects:  []api.ProjectSubstrateInventory{},
+		NodePools: []api.NodePoolCapacity{},
+	}
+
+	projects := map[string]*api.ProjectSubstrateInventory{}
+	getProject := func(projectID string) *api.ProjectSubstrateInventory {
+		project, ok := projects[projectID]
+		if !ok {
+			project = &api.ProjectSubstrateInventory{
+				ProjectID: projectID,
+			}
+			projects[projectID] = project
+		}
+		return project
+	}
+
+	if err := s.forEachPod(
+		ctx,
+		metav1.ListOptions{
+			LabelSelector: myk8s.WorkerPodsSelector(s.config.BrigadeID),
+			FieldSelector: nonTerminatedPodsSelector,
+		},
+		func(pod *corev1.Pod) {
+			if !isPodFinished(pod) {
+				countPod(&getProject(pod.Labels[myk8s.LabelProject]).Workers, pod)
+			}
+		},
+	); err != nil {
+		return inventory, errors.Wrap(err, "error listing worker pods")
+	}
+	if err := s.forEachPod(
+		ctx,
+		metav1.ListOptions{
+			LabelSelector: myk8s.JobPodsSelector(s.config.BrigadeID),
+			FieldSelector: nonTerminatedPodsSelector,
+		},
+		func(pod *corev1.Pod) {
+			if !isPodFinished(pod) {
+				countPod(&getProject(pod.Labels[myk8s.LabelProject]).Jobs, pod)
+			}
+		},
+	); err != nil {
+		return inventory, errors.Wrap(err, "error listing job pods")
+	}
+
+	storage := map[string]*resource.Quantity{}
+	workspacesSelector := labels.SelectorFromSet(
+		map[string]string{
+			myk8s.LabelBrigadeID: s.config.BrigadeID,
+			myk8s.LabelComponent: myk8s.LabelKeyWorkspace,
+		},
+	).String()
+	var cont string
+	for {
+		pvcs, err := s.kubeClient.CoreV1().PersistentVolumeClaims("").List(
+			ctx,
+			metav1.ListOptions{
+				LabelSelector: workspacesSelector,
+				Continue:      cont,
+			},
+		)
+		if err != nil {
+			return inventory, errors.Wrap(err, "error listing workspace PVCs")
+		}
+		for _, pvc := range pvcs.Items {
+			projectID := pvc.Labels[myk8s.LabelProject]
+			getProject(projectID).Workspaces.Count++
+			if _, ok := storage[projectID]; !ok {
+				storage[projectID] = resource.NewQuantity(0, resource.BinarySI)
+			}
+			storage[projectID].Add(
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage],
+			)
+		}
+		if cont = pvcs.Continue; cont == "" {
+			break
+		}
+	}
+
+	for projectID, project := range projects {
+		if quantity, ok := storage[projectID]; ok {
+			project.Workspaces.Storage = quantity.String()
+		}
+		inventory.Projects = append(inventory.Projects, *project)
+	}
+	sort.Slice(inventory.Projects, func(i, j int) bool {
+		return inventory.Projects[i].ProjectID < inventory.Projects[j].ProjectID
+	})
+
+	var err error
+	inventory.NodePools, err = s.getNodePoolCapacities(ctx)
+	return inventory, err
+}
+
+// getNodePoolCapacities summarizes the capacity of each pool of nodes in the
+// cluster, sorted by pool name.
+func (s *substrate) getNodePoolCapacities(
+	ctx context.Context,
+) ([]api.NodePoolCapacity, error) {
+	nodePoolLabels := s.config.NodePoolLabels
+	if nodePoolLabels == nil {
+		nodePoolLabels = DefaultNodePoolLabels
+	}
+
+	type nodePool struct {
+		capacity          api.NodePoolCapacity
+		allocatableCPU    resource.Quantity
+		allocatableMemory resource.Quantity
+		allocatablePods   resource.Quantity
+		requestedCPU      resource.Quantity
+		requestedMemory   resource.Quantity
+		requestedPods     int
+	}
+	pools := map[string]*nodePool{}
+	// readyNodePools maps the names of ready nodes to the pool they belong to
+	readyNodePools := map[string]*nodePool{}
+
+	var cont string
+	for {
+		nodes, err := s.kubeClient.CoreV1().Nodes().List(
+			ctx,
+			metav1.ListOptions{
+				Continue: cont,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "error listing nodes")
+		}
+		for _, node := range nodes.Items {
+			poolName := nodePoolName(node, nodePoolLabels)
+			pool, ok := pools[poolName]
+			if !ok {
+				pool = &nodePool{
+					capacity: api.NodePoolCapacity{
+						Name: poolName,
+					},
+				}
+				pools[poolName] = pool
+			}
+			pool.capacity.Nodes++
+			if !isNodeReady(node) {
+				continue
+			}
+			pool.capacity.ReadyNodes++
+			pool.allocatableCPU.Add(node.Status.Allocatable[corev1.ResourceCPU])
+			pool.allocatableMemory.Add(
+				node.Status.Allocatable[corev1.ResourceMemory],
+			)
+			pool.allocatablePods.Add(node.Status.Allocatable[corev1.ResourcePods])
+			readyNodePools[node.Name] = pool
+		}
+		if cont = nodes.Continue; cont == "" {
+			break
+		}
+	}
+
+	if err := s.forEachPod(
+		ctx,
+		metav1.ListOptions{
+			FieldSelector: nonTerminatedPodsSelector,
+		},
+		func(pod *corev1.Pod) {
+			pool, ok := readyNodePools[pod.Spec.NodeName]
+			if !ok {
+				return
+			}
+			requests := podRequests(pod)
+			pool.requestedCPU.Add(requests[corev1.ResourceCPU])
+			pool.requestedMemory.Add(requests[corev1.ResourceMemory])
+			pool.requestedPods++
+		},
+	); err != nil {
+		return nil, errors.Wrap(err, "error listing scheduled pods")
+	}
+
+	capacities := make([]api.NodePoolCapacity, 0, len(pools))
+	for _, pool := range pools {
+		pool.capacity.Allocatable = api.NodePoolResources{
+			CPU:    pool.allocatableCPU.String(),
+			Memory: pool.allocatableMemory.String(),
+			Pods:   int(pool.allocatablePods.Value()),
+		}
+		pool.capacity.Requested = api.NodePoolResources{
+			CPU:    pool.requestedCPU.String(),
+			Memory: pool.requestedMemory.String(),
+			Pods:   pool.requestedPods,
+		}
+		capacities = append(capacities, pool.capacity)
+	}
+	sort.Slice(capacities, func(i, j int) bool {
+		return capacities[i].Name < capacities[j].Name
+	})
+	return capacities, nil
+}
+
+// forEachPod invokes the provided function for every pod, across all
+// namespaces, that matches the provided list options, retrieving the pods one
+// page at a time.
+func (s *substrate) forEachPod(
+	ctx context.Context,
+	listOpts metav1.ListOptions,
+	fn func(*corev1.Pod),
+) error {
+	for {
+		pods, err := s.kubeClient.CoreV1().Pods("").List(ctx, listOpts)
+		if err != nil {
+			return err
+		}
+		for i := range pods.Items {
+			fn(&pods.Items[i])
+		}
+		if listOpts.Continue = pods.Continue; listOpts.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// isPodFinished returns a boolean indicating whether the provided pod has
+// already succeeded or failed and is not being deleted.
+func isPodFinished(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil &&
+		(pod.Status.Phase == corev1.PodSucceeded ||
+			pod.Status.Phase == corev1.PodFailed)
+}
+
+// countPod increments the count in the provided api.PodCounts that corresponds
+// to the status of the provided pod. Pods in an unknown phase are counted as
+// running, consistent with how the substrate counts running Workers and Jobs.
+func countPod(counts *api.PodCounts, pod *corev1.Pod) {
+	switch {
+	case pod.DeletionTimestamp != nil:
+		counts.Terminating++
+	case pod.Status.Phase == corev1.PodPending:
+		counts.Pending++
+	case pod.Status.Phase == corev1.PodRunning ||
+		pod.Status.Phase == corev1.PodUnknown:
+		counts.Running++
+	}
+}
+
+// nodePoolName returns the value of the first of the provided labels that the
+// provided node bears. If the node bears none of them, the empty string is
+// returned.
+func nodePoolName(node corev1.Node, nodePoolLabels []string) string {
+	for _, label := range nodePoolLabels {
+		if name, ok := node.Labels[label]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// isNodeReady returns a boolean indicating whether the provided node is both
+// ready and schedulable.
+func isNodeReady(node corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podRequests returns the resources requested by the provided pod, calculated
+// the same way the Kubernetes scheduler does. That is, the greater of the sum
+// of all containers' requests and the largest of any init container's
+// requests, plus any pod overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if total, ok := requests[name]; !ok || quantity.Cmp(total) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	for name, quantity := range pod.Spec.Overhead {
+		total := requests[name]
+		total.Add(quantity)
+		requests[name] = total
+	}
+	return requests
+}
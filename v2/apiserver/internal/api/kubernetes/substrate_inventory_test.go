@@ -0,0 +1,263 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSubstrateGetInventory(t *testing.T) {
+	now := metav1.Now()
+	getPod := func(
+		name string,
+		component string,
+		projectID string,
+		phase corev1.PodPhase,
+	) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: projectID,
+				Labels: map[string]string{
+					myk8s.LabelBrigadeID: "4077th",
+					myk8s.LabelComponent: component,
+					myk8s.LabelProject:   projectID,
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+			},
+		}
+	}
+	getWorkspacePVC := func(
+		name string,
+		projectID string,
+		storage string,
+	) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: projectID,
+				Labels: map[string]string{
+					myk8s.LabelBrigadeID: "4077th",
+					myk8s.LabelComponent: myk8s.LabelKeyWorkspace,
+					myk8s.LabelProject:   projectID,
+				},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(storage),
+					},
+				},
+			},
+		}
+	}
+	getNode := func(
+		name string,
+		pool string,
+		ready bool,
+	) *corev1.Node {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+				Conditions: []corev1.NodeCondition{
+					{
+						Type:   corev1.NodeReady,
+						Status: status,
+					},
+				},
+			},
+		}
+		if pool != "" {
+			node.Labels["agentpool"] = pool
+		}
+		return node
+	}
+
+	terminatingWorkerPod :=
+		getPod("terminating-worker", myk8s.LabelKeyWorker, "french", "Running")
+	terminatingWorkerPod.DeletionTimestamp = &now
+	scheduledPod := getPod("scheduled", "", "kube-system", corev1.PodRunning)
+	scheduledPod.Spec = corev1.PodSpec{
+		NodeName: "node-a",
+		InitContainers: []corev1.Container{
+			{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("750m"),
+					},
+				},
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("250m"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			},
+			{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("250m"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			},
+		},
+	}
+	// This pod is bound to a node that is not ready and should not be counted
+	unreadyNodePod := getPod("unready", "", "kube-system", corev1.PodRunning)
+	unreadyNodePod.Spec.NodeName = "node-c"
+
+	kubeClient := fake.NewSimpleClientset(
+		[]runtime.Object{
+			getPod("pending-worker", myk8s.LabelKeyWorker, "italian", "Pending"),
+			getPod("running-worker", myk8s.LabelKeyWorker, "italian", "Running"),
+			getPod("running-job-1", myk8s.LabelKeyJob, "italian", "Running"),
+			getPod("running-job-2", myk8s.LabelKeyJob, "italian", "Unknown"),
+			// This one is finished and should not be counted
+			getPod("finished-job", myk8s.LabelKeyJob, "french", "Succeeded"),
+			terminatingWorkerPod,
+			scheduledPod,
+			unreadyNodePod,
+			getWorkspacePVC("workspace-1", "italian", "1Gi"),
+			getWorkspacePVC("workspace-2", "italian", "512Mi"),
+			getNode("node-a", "", true),
+			getNode("node-b", "", true),
+			getNode("node-c", "gpu", false),
+		}...,
+	)
+	s := &substrate{
+		kubeClient: kubeClient,
+		config: SubstrateConfig{
+			BrigadeID: "4077th",
+		},
+	}
+	inventory, err := s.GetInventory(context.Background())
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]api.ProjectSubstrateInventory{
+			{
+				ProjectID: "french",
+				Workers: api.PodCounts{
+					Terminating: 1,
+				},
+			},
+			{
+				ProjectID: "italian",
+				Workers: api.PodCounts{
+					Pending: 1,
+					Running: 1,
+				},
+				Jobs: api.PodCounts{
+					Running: 2,
+				},
+				Workspaces: api.WorkspaceUsage{
+					Count:   2,
+					Storage: "1536Mi",
+				},
+			},
+		},
+		inventory.Projects,
+	)
+	require.Len(t, inventory.NodePools, 2)
+	require.Equal(t, "", inventory.NodePools[0].Name)
+	require.Equal(t, 2, inventory.NodePools[0].Nodes)
+	require.Equal(t, 2, inventory.NodePools[0].ReadyNodes)
+	require.Equal(
+		t,
+		api.NodePoolResources{
+			CPU:    "4",
+			Memory: "16Gi",
+			Pods:   220,
+		},
+		inventory.NodePools[0].Allocatable,
+	)
+	require.Equal(
+		t,
+		api.NodePoolResources{
+			CPU:    "750m",
+			Memory: "2Gi",
+			Pods:   1,
+		},
+		inventory.NodePools[0].Requested,
+	)
+	require.Equal(t, "gpu", inventory.NodePools[1].Name)
+	require.Equal(t, 1, inventory.NodePools[1].Nodes)
+	require.Equal(t, 0, inventory.NodePools[1].ReadyNodes)
+	require.Equal(t, 0, inventory.NodePools[1].Requested.Pods)
+}
+
+func TestNodePoolName(t *testing.T) {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"agentpool":                  "foo",
+				"example.com/node-pool-name": "bar",
+			},
+		},
+	}
+	require.Equal(t, "foo", nodePoolName(node, DefaultNodePoolLabels))
+	require.Equal(
+		t,
+		"bar",
+		nodePoolName(node, []string{"example.com/node-pool-name", "agentpool"}),
+	)
+	require.Equal(t, "", nodePoolName(corev1.Node{}, DefaultNodePoolLabels))
+}
+
+func TestIsNodeReady(t *testing.T) {
+	readyCondition := corev1.NodeCondition{
+		Type:   corev1.NodeReady,
+		Status: corev1.ConditionTrue,
+	}
+	require.False(t, isNodeReady(corev1.Node{}))
+	require.True(
+		t,
+		isNodeReady(
+			corev1.Node{
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{readyCondition},
+				},
+			},
+		),
+	)
+	require.False(
+		t,
+		isNodeReady(
+			corev1.Node{
+				Spec: corev1.NodeSpec{
+					Unschedulable: true,
+				},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{readyCondition},
+				},
+			},
+		),
+	)
+}
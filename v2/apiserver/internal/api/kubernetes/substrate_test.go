@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue"
@@ -14,9 +15,16 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sVersion "k8s.io/apimachinery/pkg/version"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	clientTesting "k8s.io/client-go/testing"
 )
 
 func TestNewSubstrate(t *testing.T) {
@@ -135,6 +143,18 @@ func TestSubstrateCountRunningJobs(t *testing.T) {
 	require.Equal(t, 1, count.Count)
 }
 
+func TestSubstrateDefaultImagePolicy(t *testing.T) {
+	testPolicy := &api.ImagePolicy{
+		AllowedRegistries: []string{"ghcr.io"},
+	}
+	s := &substrate{
+		config: SubstrateConfig{
+			DefaultImagePolicy: testPolicy,
+		},
+	}
+	require.Same(t, testPolicy, s.DefaultImagePolicy())
+}
+
 func TestSubstrateCreateProject(t *testing.T) {
 	const testNamespace = "foo"
 	testCases := []struct {
@@ -459,14 +479,40 @@ func TestSubstrateDeleteProject(t *testing.T) {
 		assertions func(error, *fake.Clientset)
 	}{
 		{
-			name: "error deleting namespace",
+			name: "error retrieving namespace",
 			setup: func() *fake.Clientset {
 				return fake.NewSimpleClientset()
 			},
 			assertions: func(err error, kubeClient *fake.Clientset) {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), "not found")
-				require.Contains(t, err.Error(), "error deleting namespace")
+				require.Contains(t, err.Error(), "error retrieving namespace")
+			},
+		},
+
+		{
+			name: "claimed namespace is released instead of deleted",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset(
+					&corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: testNamespace,
+							Annotations: map[string]string{
+								myk8s.AnnotationClaimedNamespace: "true",
+							},
+						},
+					},
+				)
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				// Check that the Namespace still exists
+				_, err = kubeClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					testNamespace,
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
 			},
 		},
 
@@ -523,6 +569,121 @@ func TestSubstrateDeleteProject(t *testing.T) {
 	}
 }
 
+func TestSubstrateDeleteCacheVolume(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: "foo",
+		},
+	}
+	const testCacheVolumeName = "npm"
+	testCases := []struct {
+		name       string
+		setup      func() *fake.Clientset
+		assertions func(error, *fake.Clientset)
+	}{
+		{
+			name: "pvc does not exist",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset()
+			},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "pvc in use",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset(
+					&corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      myk8s.CacheVolumePVCName(testCacheVolumeName),
+							Namespace: testProject.Kubernetes.Namespace,
+						},
+					},
+					&corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "job",
+							Namespace: testProject.Kubernetes.Namespace,
+						},
+						Spec: corev1.PodSpec{
+							Volumes: []corev1.Volume{
+								{
+									Name: "cache-volume-0",
+									VolumeSource: corev1.VolumeSource{
+										PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ // nolint: lll
+											ClaimName: myk8s.CacheVolumePVCName(testCacheVolumeName),
+										},
+									},
+								},
+							},
+						},
+						Status: corev1.PodStatus{
+							Phase: corev1.PodRunning,
+						},
+					},
+				)
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				_, err = kubeClient.CoreV1().PersistentVolumeClaims(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.CacheVolumePVCName(testCacheVolumeName),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "success",
+			setup: func() *fake.Clientset {
+				kubeClient := fake.NewSimpleClientset()
+				_, err := kubeClient.CoreV1().PersistentVolumeClaims(
+					testProject.Kubernetes.Namespace,
+				).Create(
+					context.Background(),
+					&corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: myk8s.CacheVolumePVCName(testCacheVolumeName),
+						},
+					},
+					metav1.CreateOptions{},
+				)
+				require.NoError(t, err)
+				return kubeClient
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				_, err = kubeClient.CoreV1().PersistentVolumeClaims(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.CacheVolumePVCName(testCacheVolumeName),
+					metav1.GetOptions{},
+				)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not found")
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := testCase.setup()
+			s := &substrate{
+				kubeClient: kubeClient,
+			}
+			err := s.DeleteCacheVolume(
+				context.Background(),
+				testProject,
+				testCacheVolumeName,
+			)
+			testCase.assertions(err, kubeClient)
+		})
+	}
+}
+
 func TestSubstrateScheduleWorker(t *testing.T) {
 	const testEventID = "12345"
 	testCases := []struct {
@@ -973,15 +1134,19 @@ func TestSubstrateScheduleJob(t *testing.T) {
 }
 
 func TestSubstrateStartJob(t *testing.T) {
-	const testJobName = "foo"
+	const testEventID = "123456789"
+	const testNamespace = "foo"
+	const testJobName = "bar"
+	const testJobToken = "opensesame"
 	testCases := []struct {
 		name       string
-		substrate  api.Substrate
-		assertions func(error)
+		substrate  *substrate
+		assertions func(kubernetes.Interface, error)
 	}{
 		{
 			name: "error creating job pod",
 			substrate: &substrate{
+				kubeClient: fake.NewSimpleClientset(),
 				createJobPodFn: func(
 					context.Context,
 					api.Project,
@@ -992,15 +1157,54 @@ func TestSubstrateStartJob(t *testing.T) {
 					return errors.New("something went wrong")
 				},
 			},
-			assertions: func(err error) {
+			assertions: func(_ kubernetes.Interface, err error) {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), "something went wrong")
 				require.Contains(t, err.Error(), "error creating pod for event")
 			},
 		},
+		{
+			name: "token secret already exists",
+			substrate: &substrate{
+				kubeClient: fake.NewSimpleClientset(
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: myk8s.JobTokenSecretName(
+								testEventID,
+								testJobName,
+							),
+							Namespace: testNamespace,
+						},
+						StringData: map[string]string{
+							"apiToken": "stale",
+						},
+					},
+				),
+				createJobPodFn: func(
+					context.Context,
+					api.Project,
+					api.Event,
+					string,
+					api.JobSpec,
+				) error {
+					return nil
+				},
+			},
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(
+					context.Background(),
+					myk8s.JobTokenSecretName(testEventID, testJobName),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(t, testJobToken, secret.StringData["apiToken"])
+			},
+		},
 		{
 			name: "success",
 			substrate: &substrate{
+				kubeClient: fake.NewSimpleClientset(),
 				createJobPodFn: func(
 					context.Context,
 					api.Project,
@@ -1011,8 +1215,15 @@ func TestSubstrateStartJob(t *testing.T) {
 					return nil
 				},
 			},
-			assertions: func(err error) {
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(
+					context.Background(),
+					myk8s.JobTokenSecretName(testEventID, testJobName),
+					metav1.GetOptions{},
+				)
 				require.NoError(t, err)
+				require.Equal(t, testJobToken, secret.StringData["apiToken"])
 			},
 		},
 	}
@@ -1020,8 +1231,15 @@ func TestSubstrateStartJob(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			err := testCase.substrate.StartJob(
 				context.Background(),
-				api.Project{},
+				api.Project{
+					Kubernetes: &api.KubernetesDetails{
+						Namespace: testNamespace,
+					},
+				},
 				api.Event{
+					ObjectMeta: meta.ObjectMeta{
+						ID: testEventID,
+					},
 					Worker: api.Worker{
 						Spec: api.WorkerSpec{
 							UseWorkspace: true,
@@ -1029,8 +1247,9 @@ func TestSubstrateStartJob(t *testing.T) {
 					},
 				},
 				testJobName,
+				testJobToken,
 			)
-			testCase.assertions(err)
+			testCase.assertions(testCase.substrate.kubeClient, err)
 		})
 	}
 }
@@ -1050,24 +1269,63 @@ func TestSubstrateStartJob(t *testing.T) {
 func TestSubstrateDeleteJob(t *testing.T) {
 	const testEventID = "123456789"
 	const testJobName = "italian"
-	s := &substrate{
-		kubeClient: fake.NewSimpleClientset(),
-	}
-	err := s.DeleteJob(
-		context.Background(),
-		api.Project{
-			Kubernetes: &api.KubernetesDetails{
-				Namespace: "foo",
-			},
+	testCases := []struct {
+		name             string
+		batchJobsEnabled bool
+	}{
+		{
+			name:             "batch jobs disabled",
+			batchJobsEnabled: false,
 		},
-		api.Event{
-			ObjectMeta: meta.ObjectMeta{
-				ID: testEventID,
-			},
+		{
+			name:             "batch jobs enabled",
+			batchJobsEnabled: true,
 		},
-		testJobName,
-	)
-	require.NoError(t, err)
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			s := &substrate{
+				config: SubstrateConfig{
+					BatchJobsEnabled: testCase.batchJobsEnabled,
+				},
+				kubeClient: kubeClient,
+			}
+			err := s.DeleteJob(
+				context.Background(),
+				api.Project{
+					Kubernetes: &api.KubernetesDetails{
+						Namespace: "foo",
+					},
+				},
+				api.Event{
+					ObjectMeta: meta.ObjectMeta{
+						ID: testEventID,
+					},
+				},
+				testJobName,
+			)
+			require.NoError(t, err)
+			// batch/v1 Jobs should only have been deleted if batch jobs are enabled
+			require.Equal(
+				t,
+				testCase.batchJobsEnabled,
+				deletedBatchJobs(kubeClient),
+			)
+		})
+	}
+}
+
+// deletedBatchJobs returns a bool indicating whether the provided fake
+// clientset was used to delete any collection of batch/v1 Jobs.
+func deletedBatchJobs(kubeClient *fake.Clientset) bool {
+	for _, action := range kubeClient.Actions() {
+		if action.Matches("delete-collection", "jobs") &&
+			action.GetResource().Group == "batch" {
+			return true
+		}
+	}
+	return false
 }
 
 // TODO: Find a better way to test this. Unfortunately, the DeleteCollection
@@ -1083,23 +1341,50 @@ func TestSubstrateDeleteJob(t *testing.T) {
 // this function. We'll have to make sure this behavior is well-covered by
 // integration or e2e tests in the future.
 func TestSubstrateDeleteWorkerAndJobs(t *testing.T) {
-	s := &substrate{
-		kubeClient: fake.NewSimpleClientset(),
-	}
-	err := s.DeleteWorkerAndJobs(
-		context.Background(),
-		api.Project{
-			Kubernetes: &api.KubernetesDetails{
-				Namespace: "foo",
-			},
+	testCases := []struct {
+		name             string
+		batchJobsEnabled bool
+	}{
+		{
+			name:             "batch jobs disabled",
+			batchJobsEnabled: false,
 		},
-		api.Event{
-			ObjectMeta: meta.ObjectMeta{
-				ID: "bar",
-			},
+		{
+			name:             "batch jobs enabled",
+			batchJobsEnabled: true,
 		},
-	)
-	require.NoError(t, err)
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			s := &substrate{
+				config: SubstrateConfig{
+					BatchJobsEnabled: testCase.batchJobsEnabled,
+				},
+				kubeClient: kubeClient,
+			}
+			err := s.DeleteWorkerAndJobs(
+				context.Background(),
+				api.Project{
+					Kubernetes: &api.KubernetesDetails{
+						Namespace: "foo",
+					},
+				},
+				api.Event{
+					ObjectMeta: meta.ObjectMeta{
+						ID: "bar",
+					},
+				},
+			)
+			require.NoError(t, err)
+			// batch/v1 Jobs should only have been deleted if batch jobs are enabled
+			require.Equal(
+				t,
+				testCase.batchJobsEnabled,
+				deletedBatchJobs(kubeClient),
+			)
+		})
+	}
 }
 
 func TestSubstrateCreateWorkspacePVC(t *testing.T) {
@@ -1206,19 +1491,276 @@ func TestSubstrateCreateWorkspacePVC(t *testing.T) {
 	}
 }
 
-func TestSubstrateCreateWorkerPod(t *testing.T) {
+func TestSubstrateCreateCacheVolumePVC(t *testing.T) {
 	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
 		Kubernetes: &api.KubernetesDetails{
 			Namespace: "foo",
 		},
 	}
-	testEvent := api.Event{
-		ObjectMeta: meta.ObjectMeta{
-			ID: "123456789",
+	testCases := []struct {
+		name        string
+		cacheVolume api.CacheVolume
+		setup       func() *substrate
+		assertions  func(kubernetes.Interface, error)
+	}{
+		{
+			name: "unparsable storage quantity",
+			cacheVolume: api.CacheVolume{
+				Name: "npm",
+				Size: "10ZillionBytes",
+			},
+			setup: func() *substrate {
+				return &substrate{}
+			},
+			assertions: func(_ kubernetes.Interface, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error parsing storage quantity")
+			},
 		},
-		Worker: api.Worker{
-			Spec: api.WorkerSpec{
-				Kubernetes: &api.KubernetesConfig{
+		{
+			name: "exclusive access mode not supported",
+			cacheVolume: api.CacheVolume{
+				Name: "npm",
+			},
+			setup: func() *substrate {
+				kubeClient := fake.NewSimpleClientset()
+				// nolint: forcetypeassert
+				discovery := kubeClient.Discovery().(*fakeDiscovery.FakeDiscovery)
+				discovery.FakedServerVersion = &k8sVersion.Info{GitVersion: "v1.26.3"}
+				return &substrate{
+					kubeClient: kubeClient,
+				}
+			},
+			assertions: func(_ kubernetes.Interface, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+			},
+		},
+		{
+			name: "pvc already exists",
+			cacheVolume: api.CacheVolume{
+				Name:       "npm",
+				AccessMode: api.CacheVolumeAccessModeShared,
+			},
+			setup: func() *substrate {
+				kubeClient := fake.NewSimpleClientset(
+					&corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      myk8s.CacheVolumePVCName("npm"),
+							Namespace: testProject.Kubernetes.Namespace,
+						},
+					},
+				)
+				return &substrate{
+					kubeClient: kubeClient,
+				}
+			},
+			assertions: func(_ kubernetes.Interface, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "pvc still being deleted",
+			cacheVolume: api.CacheVolume{
+				Name:       "npm",
+				AccessMode: api.CacheVolumeAccessModeShared,
+			},
+			setup: func() *substrate {
+				now := metav1.Now()
+				kubeClient := fake.NewSimpleClientset(
+					&corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:              myk8s.CacheVolumePVCName("npm"),
+							Namespace:         testProject.Kubernetes.Namespace,
+							DeletionTimestamp: &now,
+						},
+					},
+				)
+				return &substrate{
+					kubeClient: kubeClient,
+				}
+			},
+			assertions: func(_ kubernetes.Interface, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "is still being deleted")
+			},
+		},
+		{
+			name: "success",
+			cacheVolume: api.CacheVolume{
+				Name:         "npm",
+				Size:         "5Gi",
+				StorageClass: "fast",
+				AccessMode:   api.CacheVolumeAccessModeShared,
+			},
+			setup: func() *substrate {
+				return &substrate{
+					kubeClient: fake.NewSimpleClientset(),
+				}
+			},
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				pvc, err := kubeClient.CoreV1().PersistentVolumeClaims(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.CacheVolumePVCName("npm"),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(t, "fast", *pvc.Spec.StorageClassName)
+				require.Equal(
+					t,
+					[]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+					pvc.Spec.AccessModes,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			substrate := testCase.setup()
+			err := substrate.createCacheVolumePVC(
+				context.Background(),
+				testProject,
+				testCase.cacheVolume,
+			)
+			testCase.assertions(substrate.kubeClient, err)
+		})
+	}
+}
+
+func TestSubstrateGetExclusiveAccessMode(t *testing.T) {
+	csiStorageClass := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "fast",
+		},
+		Provisioner: "disk.csi.example.com",
+	}
+	inTreeStorageClass := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "slow",
+		},
+		Provisioner: "kubernetes.io/azure-disk",
+	}
+	defaultStorageClass := csiStorageClass.DeepCopy()
+	defaultStorageClass.Name = "default"
+	defaultStorageClass.Annotations = map[string]string{
+		annotationDefaultStorageClass: "true",
+	}
+	testCases := []struct {
+		name             string
+		serverVersion    string
+		storageClasses   []runtime.Object
+		storageClassName string
+		assertions       func(corev1.PersistentVolumeAccessMode, error)
+	}{
+		{
+			name:             "kubernetes version too old",
+			serverVersion:    "v1.26.3",
+			storageClasses:   []runtime.Object{csiStorageClass},
+			storageClassName: "fast",
+			assertions: func(_ corev1.PersistentVolumeAccessMode, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "does not enable")
+			},
+		},
+		{
+			name:             "storage class not found",
+			serverVersion:    "v1.27.0",
+			storageClassName: "fast",
+			assertions: func(_ corev1.PersistentVolumeAccessMode, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "does not exist")
+			},
+		},
+		{
+			name:             "in-tree provisioner",
+			serverVersion:    "v1.27.0",
+			storageClasses:   []runtime.Object{inTreeStorageClass},
+			storageClassName: "slow",
+			assertions: func(_ corev1.PersistentVolumeAccessMode, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "does not support")
+			},
+		},
+		{
+			name:             "csi provisioner",
+			serverVersion:    "v1.27.0",
+			storageClasses:   []runtime.Object{csiStorageClass},
+			storageClassName: "fast",
+			assertions: func(
+				accessMode corev1.PersistentVolumeAccessMode,
+				err error,
+			) {
+				require.NoError(t, err)
+				require.Equal(t, corev1.ReadWriteOncePod, accessMode)
+			},
+		},
+		{
+			name:           "no default storage class",
+			serverVersion:  "v1.27.0",
+			storageClasses: []runtime.Object{csiStorageClass},
+			assertions: func(_ corev1.PersistentVolumeAccessMode, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "no default storage class")
+			},
+		},
+		{
+			name:           "default storage class with csi provisioner",
+			serverVersion:  "v1.29.1",
+			storageClasses: []runtime.Object{defaultStorageClass},
+			assertions: func(
+				accessMode corev1.PersistentVolumeAccessMode,
+				err error,
+			) {
+				require.NoError(t, err)
+				require.Equal(t, corev1.ReadWriteOncePod, accessMode)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(testCase.storageClasses...)
+			// nolint: forcetypeassert
+			kubeClient.Discovery().(*fakeDiscovery.FakeDiscovery).FakedServerVersion =
+				&k8sVersion.Info{GitVersion: testCase.serverVersion}
+			s := &substrate{
+				kubeClient: kubeClient,
+			}
+			testCase.assertions(
+				s.getExclusiveAccessMode(
+					context.Background(),
+					api.CacheVolume{
+						Name:         "foo",
+						StorageClass: testCase.storageClassName,
+					},
+				),
+			)
+		})
+	}
+}
+
+func TestSubstrateCreateWorkerPod(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: "foo",
+		},
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "123456789",
+		},
+		Worker: api.Worker{
+			Spec: api.WorkerSpec{
+				Kubernetes: &api.KubernetesConfig{
 					ImagePullSecrets: []string{"foo", "bar"},
 				},
 				UseWorkspace: true,
@@ -1264,11 +1806,35 @@ func TestSubstrateCreateWorkerPod(t *testing.T) {
 				require.Contains(t, err.Error(), "error creating pod for event")
 			},
 		},
+		{
+			name: "error applying pod overlay",
+			setup: func() *substrate {
+				return &substrate{
+					kubeClient: fake.NewSimpleClientset(),
+					config: SubstrateConfig{
+						WorkerPodOverlay: &api.PodOverlay{
+							Type:  api.PodOverlayTypeJSONPatch,
+							Patch: `[{"op": "remove", "path": "/spec/bogus"}]`,
+						},
+					},
+				}
+			},
+			assertions: func(_ kubernetes.Interface, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error building pod for event")
+			},
+		},
 		{
 			name: "success",
 			setup: func() *substrate {
 				return &substrate{
 					kubeClient: fake.NewSimpleClientset(),
+					config: SubstrateConfig{
+						WorkerPodOverlay: &api.PodOverlay{
+							Type:  api.PodOverlayTypeStrategicMerge,
+							Patch: "spec:\n  hostAliases:\n  - ip: 10.0.0.1",
+						},
+					},
 				}
 			},
 			assertions: func(kubeClient kubernetes.Interface, err error) {
@@ -1282,6 +1848,7 @@ func TestSubstrateCreateWorkerPod(t *testing.T) {
 				)
 				require.NoError(t, err)
 				require.NotNil(t, pod)
+				require.Len(t, pod.Spec.HostAliases, 1)
 			},
 		},
 	}
@@ -1405,6 +1972,13 @@ func TestSubstrateCreateJobPod(t *testing.T) {
 		GitInitializerWindowsImagePullPolicy: "IfNotPresent",
 	}
 	testProject := api.Project{
+		Spec: api.ProjectSpec{
+			CacheVolumes: []api.CacheVolume{
+				{
+					Name: "npm",
+				},
+			},
+		},
 		Kubernetes: &api.KubernetesDetails{
 			Namespace: "foo",
 		},
@@ -1539,8 +2113,28 @@ func TestSubstrateCreateJobPod(t *testing.T) {
 				require.Len(t, pod.Spec.Containers, 2)
 				// Primary container:
 				require.Equal(t, testJobName, pod.Spec.Containers[0].Name)
-				require.Len(t, pod.Spec.Containers[0].Env, 1)
-				require.Equal(t, "FOO", pod.Spec.Containers[0].Env[0].Name)
+				require.Len(t, pod.Spec.Containers[0].Env, 5)
+				require.Equal(
+					t,
+					"BRIGADE_API_TOKEN",
+					pod.Spec.Containers[0].Env[1].Name,
+				)
+				require.Equal(
+					t,
+					"apiToken",
+					pod.Spec.Containers[0].Env[1].ValueFrom.SecretKeyRef.Key,
+				)
+				require.Equal(
+					t,
+					myk8s.JobTokenSecretName(testEvent.ID, testJobName),
+					pod.Spec.Containers[0].Env[1].ValueFrom.SecretKeyRef.Name,
+				)
+				require.Equal(
+					t,
+					testJobName,
+					pod.Spec.Containers[0].Env[3].Value,
+				)
+				require.Equal(t, "FOO", pod.Spec.Containers[0].Env[4].Name)
 				require.Len(t, pod.Spec.Containers[0].VolumeMounts, 2)
 				require.Equal(
 					t,
@@ -1572,7 +2166,7 @@ func TestSubstrateCreateJobPod(t *testing.T) {
 			},
 		},
 		{
-			name: "success with windows",
+			name: "error with undefined cache volume",
 			setup: func() *substrate {
 				return &substrate{
 					config:     testSubstrateConfig,
@@ -1581,12 +2175,63 @@ func TestSubstrateCreateJobPod(t *testing.T) {
 			},
 			jobSpec: func() api.JobSpec {
 				jobSpecCopy := testJobSpec
-				jobSpecCopy.Host = &api.JobHost{
-					OS: api.OSFamilyWindows,
+				jobSpecCopy.PrimaryContainer.CacheVolumeMounts =
+					[]api.CacheVolumeMount{
+						{
+							Name:      "maven",
+							MountPath: "/root/.m2",
+						},
+					}
+				return jobSpecCopy
+			},
+			assertions: func(_ kubernetes.Interface, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "does not define cache volume")
+			},
+		},
+		{
+			name: "success with cache volume",
+			setup: func() *substrate {
+				kubeClient := fake.NewSimpleClientset(
+					&storagev1.StorageClass{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "default",
+							Annotations: map[string]string{
+								annotationDefaultStorageClass: "true",
+							},
+						},
+						Provisioner: "disk.csi.example.com",
+					},
+				)
+				// nolint: forcetypeassert
+				discovery := kubeClient.Discovery().(*fakeDiscovery.FakeDiscovery)
+				discovery.FakedServerVersion = &k8sVersion.Info{GitVersion: "v1.27.0"}
+				return &substrate{
+					config:     testSubstrateConfig,
+					kubeClient: kubeClient,
 				}
+			},
+			jobSpec: func() api.JobSpec {
+				jobSpecCopy := testJobSpec
+				jobSpecCopy.PrimaryContainer.CacheVolumeMounts =
+					[]api.CacheVolumeMount{
+						{
+							Name:      "npm",
+							MountPath: "/root/.npm",
+						},
+					}
 				return jobSpecCopy
 			},
 			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				// The cache volume's PVC should have been provisioned
+				_, err = kubeClient.CoreV1().PersistentVolumeClaims(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.CacheVolumePVCName("npm"),
+					metav1.GetOptions{},
+				)
 				require.NoError(t, err)
 				pod, err := kubeClient.CoreV1().Pods(
 					testProject.Kubernetes.Namespace,
@@ -1596,73 +2241,241 @@ func TestSubstrateCreateJobPod(t *testing.T) {
 					metav1.GetOptions{},
 				)
 				require.NoError(t, err)
-				require.NotNil(t, pod)
-				// These should be the only real differences from the previous test case
-				//
-				// Make sure the pod is assigned to a Windows node
-				require.Equal(t, "windows", pod.Spec.NodeSelector[corev1.LabelOSStable])
-				// Make sure we use the Windows variant of the git initializer image
+				require.Len(t, pod.Spec.Volumes, 4)
 				require.Equal(
 					t,
-					testSubstrateConfig.GitInitializerWindowsImage,
-					pod.Spec.InitContainers[0].Image,
+					myk8s.CacheVolumePVCName("npm"),
+					pod.Spec.Volumes[3].Name,
 				)
+				require.Len(t, pod.Spec.Containers[0].VolumeMounts, 3)
 				require.Equal(
 					t,
-					corev1.PullPolicy(testSubstrateConfig.GitInitializerWindowsImagePullPolicy), // nolint: lll
-					pod.Spec.InitContainers[0].ImagePullPolicy,
+					"/root/.npm",
+					pod.Spec.Containers[0].VolumeMounts[2].MountPath,
 				)
 			},
 		},
-	}
-	for _, testCase := range testCases {
-		t.Run(testCase.name, func(t *testing.T) {
-			substrate := testCase.setup()
-			err := substrate.createJobPod(
-				context.Background(),
-				testProject,
-				testEvent,
-				testJobName,
-				testCase.jobSpec(),
-			)
-			testCase.assertions(substrate.kubeClient, err)
-		})
-	}
-}
-
-func TestSubstrateCreatePodWithNodeSelectorAndToleration(t *testing.T) {
-	testProject := api.Project{
-		Kubernetes: &api.KubernetesDetails{
-			Namespace: "foo",
-		},
-	}
-	testEvent := api.Event{
-		ObjectMeta: meta.ObjectMeta{
-			ID: "123456789",
-		},
-		Worker: api.Worker{},
-	}
-	testJobName := "italian"
-	testCases := []struct {
-		name       string
-		setup      func() *substrate
-		assertions func(kubernetes.Interface)
-	}{
 		{
-			name: "empty node selector, empty toleration",
+			name: "success with init containers",
 			setup: func() *substrate {
 				return &substrate{
+					config:     testSubstrateConfig,
 					kubeClient: fake.NewSimpleClientset(),
-					config:     SubstrateConfig{},
 				}
 			},
-			assertions: func(kubeClient kubernetes.Interface) {
-				workerPod, err := kubeClient.CoreV1().Pods(
-					testProject.Kubernetes.Namespace,
-				).Get(
-					context.Background(),
-					myk8s.WorkerPodName(testEvent.ID),
-					metav1.GetOptions{},
+			jobSpec: func() api.JobSpec {
+				jobSpecCopy := testJobSpec
+				jobSpecCopy.InitContainers = []api.JobInitContainerSpec{
+					{
+						Name: "fetch-credentials",
+						JobContainerSpec: api.JobContainerSpec{
+							ContainerSpec: api.ContainerSpec{
+								Image: "credentials-fetcher",
+								Environment: map[string]string{
+									"FOO": "bar",
+								},
+							},
+							WorkspaceMountPath: "/var/workspace",
+						},
+					},
+					{
+						Name: "warm-cache",
+						JobContainerSpec: api.JobContainerSpec{
+							ContainerSpec: api.ContainerSpec{
+								Image: "cache-warmer",
+							},
+						},
+					},
+				}
+				return jobSpecCopy
+			},
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				pod, err := kubeClient.CoreV1().Pods(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.JobPodName(testEvent.ID, testJobName),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				// The job's init containers should follow the vcs init container, in
+				// order
+				require.Len(t, pod.Spec.InitContainers, 3)
+				require.Equal(t, "vcs", pod.Spec.InitContainers[0].Name)
+				initContainer := pod.Spec.InitContainers[1]
+				require.Equal(t, "fetch-credentials", initContainer.Name)
+				require.Equal(t, "credentials-fetcher", initContainer.Image)
+				require.Len(t, initContainer.Env, 1)
+				require.Equal(
+					t,
+					"fetch-credentials.FOO",
+					initContainer.Env[0].ValueFrom.SecretKeyRef.Key,
+				)
+				require.Len(t, initContainer.VolumeMounts, 1)
+				require.Equal(t, "workspace", initContainer.VolumeMounts[0].Name)
+				require.Equal(t, "warm-cache", pod.Spec.InitContainers[2].Name)
+				require.Equal(t, "cache-warmer", pod.Spec.InitContainers[2].Image)
+			},
+		},
+		{
+			name: "success with project secrets",
+			setup: func() *substrate {
+				return &substrate{
+					config:     testSubstrateConfig,
+					kubeClient: fake.NewSimpleClientset(),
+				}
+			},
+			jobSpec: func() api.JobSpec {
+				jobSpecCopy := testJobSpec
+				jobSpecCopy.PrimaryContainer.SecretEnvironment = map[string]string{
+					"TOKEN": "apiToken",
+				}
+				jobSpecCopy.PrimaryContainer.SecretMounts = []api.SecretMount{
+					{
+						Key:       "sshKey",
+						MountPath: "/root/.ssh/id_rsa",
+					},
+				}
+				return jobSpecCopy
+			},
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				pod, err := kubeClient.CoreV1().Pods(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.JobPodName(testEvent.ID, testJobName),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				// Only the referenced key should be projected into the volume
+				require.Len(t, pod.Spec.Volumes, 4)
+				require.Equal(t, "project-secrets", pod.Spec.Volumes[3].Name)
+				require.Equal(
+					t,
+					[]corev1.KeyToPath{
+						{
+							Key:  "sshKey",
+							Path: "sshKey",
+						},
+					},
+					pod.Spec.Volumes[3].Secret.Items,
+				)
+				require.Len(t, pod.Spec.Containers[0].Env, 6)
+				require.Equal(t, "TOKEN", pod.Spec.Containers[0].Env[5].Name)
+				require.Equal(
+					t,
+					"apiToken",
+					pod.Spec.Containers[0].Env[5].ValueFrom.SecretKeyRef.Key,
+				)
+				require.Len(t, pod.Spec.Containers[0].VolumeMounts, 3)
+				require.Equal(
+					t,
+					corev1.VolumeMount{
+						Name:      "project-secrets",
+						MountPath: "/root/.ssh/id_rsa",
+						SubPath:   "sshKey",
+						ReadOnly:  true,
+					},
+					pod.Spec.Containers[0].VolumeMounts[2],
+				)
+				// The sidecar references no project secrets
+				require.Len(t, pod.Spec.Containers[1].Env, 1)
+			},
+		},
+		{
+			name: "success with windows",
+			setup: func() *substrate {
+				return &substrate{
+					config:     testSubstrateConfig,
+					kubeClient: fake.NewSimpleClientset(),
+				}
+			},
+			jobSpec: func() api.JobSpec {
+				jobSpecCopy := testJobSpec
+				jobSpecCopy.Host = &api.JobHost{
+					OS: api.OSFamilyWindows,
+				}
+				return jobSpecCopy
+			},
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				pod, err := kubeClient.CoreV1().Pods(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.JobPodName(testEvent.ID, testJobName),
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.NotNil(t, pod)
+				// These should be the only real differences from the previous test case
+				//
+				// Make sure the pod is assigned to a Windows node
+				require.Equal(t, "windows", pod.Spec.NodeSelector[corev1.LabelOSStable])
+				// Make sure we use the Windows variant of the git initializer image
+				require.Equal(
+					t,
+					testSubstrateConfig.GitInitializerWindowsImage,
+					pod.Spec.InitContainers[0].Image,
+				)
+				require.Equal(
+					t,
+					corev1.PullPolicy(testSubstrateConfig.GitInitializerWindowsImagePullPolicy), // nolint: lll
+					pod.Spec.InitContainers[0].ImagePullPolicy,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			substrate := testCase.setup()
+			err := substrate.createJobPod(
+				context.Background(),
+				testProject,
+				testEvent,
+				testJobName,
+				testCase.jobSpec(),
+			)
+			testCase.assertions(substrate.kubeClient, err)
+		})
+	}
+}
+
+func TestSubstrateCreatePodWithNodeSelectorAndToleration(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: "foo",
+		},
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "123456789",
+		},
+		Worker: api.Worker{},
+	}
+	testJobName := "italian"
+	testCases := []struct {
+		name       string
+		setup      func() *substrate
+		assertions func(kubernetes.Interface)
+	}{
+		{
+			name: "empty node selector, empty toleration",
+			setup: func() *substrate {
+				return &substrate{
+					kubeClient: fake.NewSimpleClientset(),
+					config:     SubstrateConfig{},
+				}
+			},
+			assertions: func(kubeClient kubernetes.Interface) {
+				workerPod, err := kubeClient.CoreV1().Pods(
+					testProject.Kubernetes.Namespace,
+				).Get(
+					context.Background(),
+					myk8s.WorkerPodName(testEvent.ID),
+					metav1.GetOptions{},
 				)
 				require.NoError(t, err)
 				require.NotNil(t, workerPod)
@@ -1865,6 +2678,257 @@ func TestSubstrateCreatePodWithNodeSelectorAndToleration(t *testing.T) {
 	}
 }
 
+func TestSubstrateCreatePodOrBatchJob(t *testing.T) {
+	testPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bar",
+			Namespace: "foo",
+			Annotations: map[string]string{
+				myk8s.AnnotationTimeoutDuration: "5m",
+			},
+			Labels: map[string]string{
+				myk8s.LabelComponent: myk8s.LabelKeyWorker,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	testCases := []struct {
+		name       string
+		config     SubstrateConfig
+		component  string
+		assertions func(kubernetes.Interface, error)
+	}{
+		{
+			name:      "batch jobs disabled",
+			component: myk8s.LabelKeyWorker,
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				pod, err := kubeClient.CoreV1().Pods("foo").Get(
+					context.Background(),
+					"bar",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(t, testPod.Labels, pod.Labels)
+				batchJobs, err := kubeClient.BatchV1().Jobs("foo").List(
+					context.Background(),
+					metav1.ListOptions{},
+				)
+				require.NoError(t, err)
+				require.Empty(t, batchJobs.Items)
+			},
+		},
+		{
+			name: "batch jobs enabled for job",
+			config: SubstrateConfig{
+				BatchJobsEnabled:         true,
+				BatchJobBackoffLimit:     2,
+				BatchJobTTLAfterFinished: time.Hour,
+			},
+			component: myk8s.LabelKeyJob,
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				batchJob, err := kubeClient.BatchV1().Jobs("foo").Get(
+					context.Background(),
+					"bar",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					myk8s.LabelKeyJob,
+					batchJob.Labels[myk8s.LabelComponent],
+				)
+				require.Equal(t, int32(2), *batchJob.Spec.BackoffLimit)
+				require.Equal(t, int32(3600), *batchJob.Spec.TTLSecondsAfterFinished)
+				require.Equal(t, batchJob.Labels, batchJob.Spec.Template.Labels)
+				require.Equal(
+					t,
+					testPod.Annotations,
+					batchJob.Spec.Template.Annotations,
+				)
+				require.Equal(t, testPod.Spec, batchJob.Spec.Template.Spec)
+				pods, err := kubeClient.CoreV1().Pods("foo").List(
+					context.Background(),
+					metav1.ListOptions{},
+				)
+				require.NoError(t, err)
+				require.Empty(t, pods.Items)
+			},
+		},
+		{
+			name: "batch jobs enabled for worker",
+			config: SubstrateConfig{
+				BatchJobsEnabled:     true,
+				BatchJobBackoffLimit: 2,
+			},
+			component: myk8s.LabelKeyWorker,
+			assertions: func(kubeClient kubernetes.Interface, err error) {
+				require.NoError(t, err)
+				batchJob, err := kubeClient.BatchV1().Jobs("foo").Get(
+					context.Background(),
+					"bar",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				// Workers are never retried
+				require.Equal(t, int32(0), *batchJob.Spec.BackoffLimit)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := &substrate{
+				kubeClient: fake.NewSimpleClientset(),
+				config:     testCase.config,
+			}
+			pod := *testPod.DeepCopy()
+			pod.Labels[myk8s.LabelComponent] = testCase.component
+			err := s.createPodOrBatchJob(context.Background(), &pod)
+			testCase.assertions(s.kubeClient, err)
+		})
+	}
+}
+
+func TestBatchJobName(t *testing.T) {
+	const testEventID = "2c5c6d6e-4a83-4b5a-9e30-1c4a4a5d7b1c"
+	podName := myk8s.JobPodName(testEventID, "italian")
+	require.Equal(t, podName, batchJobName(podName))
+	longPodName := myk8s.JobPodName(testEventID, strings.Repeat("a", 63))
+	name := batchJobName(longPodName)
+	require.Len(t, name, 63)
+	require.True(t, strings.HasPrefix(name, longPodName[:54]+"-"))
+	require.NotEqual(
+		t,
+		name,
+		batchJobName(myk8s.JobPodName(testEventID, strings.Repeat("a", 62))),
+	)
+}
+
+func TestGetContainerFromSpecWithSecurityContext(t *testing.T) {
+	uid := int64(1000)
+	container := getContainerFromSpec(
+		"123456789",
+		"italian",
+		"italian",
+		api.JobContainerSpec{
+			SecurityContext: &api.ContainerSecurityContext{
+				RunAsUser:              &uid,
+				RunAsNonRoot:           true,
+				ReadOnlyRootFilesystem: true,
+				DropCapabilities:       []string{"ALL"},
+				SeccompProfile:         api.SeccompProfileRuntimeDefault,
+			},
+		},
+	)
+	require.NotNil(t, container.SecurityContext)
+	require.Nil(t, container.SecurityContext.Privileged)
+	require.Equal(t, uid, *container.SecurityContext.RunAsUser)
+	require.Nil(t, container.SecurityContext.RunAsGroup)
+	require.True(t, *container.SecurityContext.RunAsNonRoot)
+	require.True(t, *container.SecurityContext.ReadOnlyRootFilesystem)
+	require.Equal(
+		t,
+		[]corev1.Capability{"ALL"},
+		container.SecurityContext.Capabilities.Drop,
+	)
+	require.Equal(
+		t,
+		corev1.SeccompProfileTypeRuntimeDefault,
+		container.SecurityContext.SeccompProfile.Type,
+	)
+}
+
+func TestApplySchedulingConstraints(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		Tolerations: []corev1.Toleration{
+			{
+				Key:      "existing",
+				Operator: corev1.TolerationOpExists,
+			},
+		},
+	}
+	applySchedulingConstraints(
+		&podSpec,
+		api.SchedulingConstraints{
+			Arch: api.CPUArchARM64,
+			Tolerations: []api.Toleration{
+				{
+					Key:    "spot",
+					Value:  "true",
+					Effect: "NoSchedule",
+				},
+			},
+			NodeAffinity: []api.NodeAffinityTerm{
+				{
+					Key:      "disktype",
+					Operator: api.AffinityOperatorIn,
+					Values:   []string{"ssd"},
+				},
+				{
+					Key:      "zone",
+					Operator: api.AffinityOperatorExists,
+					Weight:   50,
+				},
+			},
+			PodAffinity: []api.PodAffinityTerm{
+				{
+					MatchLabels: map[string]string{"app": "cache"},
+					TopologyKey: "zone",
+				},
+				{
+					MatchLabels: map[string]string{"app": "db"},
+					TopologyKey: "kubernetes.io/hostname",
+					Anti:        true,
+					Weight:      10,
+				},
+			},
+			PriorityClass: "low",
+		},
+	)
+	require.Equal(t, "arm64", podSpec.NodeSelector[corev1.LabelArchStable])
+	require.Len(t, podSpec.Tolerations, 2)
+	require.Equal(
+		t,
+		corev1.Toleration{
+			Key:      "spot",
+			Operator: corev1.TolerationOpEqual,
+			Value:    "true",
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+		podSpec.Tolerations[1],
+	)
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	require.Len(
+		t,
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms, // nolint: lll
+		1,
+	)
+	require.Len(
+		t,
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		1,
+	)
+	require.Equal(
+		t,
+		int32(50),
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight,
+	)
+	require.Len(
+		t,
+		podSpec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		1,
+	)
+	require.Len(
+		t,
+		podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, // nolint: lll
+		1,
+	)
+	require.Equal(t, "low", podSpec.PriorityClassName)
+}
+
 func TestGenerateNewNamespace(t *testing.T) {
 	namespace := generateNewNamespace()
 	tokens := strings.SplitN(namespace, "-", 2)
@@ -1905,3 +2969,387 @@ func (m *mockQueueWriter) Write(
 func (m *mockQueueWriter) Close(ctx context.Context) error {
 	return m.CloseFn(ctx)
 }
+
+func TestSubstrateClaimNamespace(t *testing.T) {
+	const testNamespace = "team-foo"
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Kubernetes: &api.KubernetesDetails{
+			Namespace: testNamespace,
+		},
+	}
+	testCases := []struct {
+		name       string
+		setup      func() *fake.Clientset
+		assertions func(error, *fake.Clientset)
+	}{
+		{
+			name: "namespace not claimable",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset()
+			},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "namespace does not exist",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset()
+			},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+			},
+		},
+		{
+			name: "namespace already belongs to a project",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset(
+					&corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: testNamespace,
+							Labels: map[string]string{
+								myk8s.LabelProject: "blue-book",
+							},
+						},
+					},
+				)
+			},
+			assertions: func(err error, _ *fake.Clientset) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "blue-book")
+			},
+		},
+		{
+			name: "success",
+			setup: func() *fake.Clientset {
+				return fake.NewSimpleClientset(
+					&corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: testNamespace,
+						},
+					},
+				)
+			},
+			assertions: func(err error, kubeClient *fake.Clientset) {
+				require.NoError(t, err)
+				namespace, err := kubeClient.CoreV1().Namespaces().Get(
+					context.Background(),
+					testNamespace,
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(t, "italian", namespace.Labels[myk8s.LabelProject])
+				require.Equal(t, "4077th", namespace.Labels[myk8s.LabelBrigadeID])
+				require.Contains(
+					t,
+					namespace.Annotations,
+					myk8s.AnnotationClaimedNamespace,
+				)
+			},
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := testCase.setup()
+			s := &substrate{
+				kubeClient: kubeClient,
+				config: SubstrateConfig{
+					BrigadeID: "4077th",
+				},
+			}
+			// Only the first test case is for a namespace that isn't claimable
+			if i > 0 {
+				s.config.ClaimableNamespaces = []string{"team-*"}
+			}
+			err := s.claimNamespace(context.Background(), testProject)
+			testCase.assertions(err, kubeClient)
+		})
+	}
+}
+
+func TestSubstrateReleaseNamespace(t *testing.T) {
+	const testNamespace = "team-foo"
+	testLabels := map[string]string{
+		myk8s.LabelBrigadeID: "4077th",
+		myk8s.LabelProject:   "italian",
+	}
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testNamespace,
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: "4077th",
+				myk8s.LabelProject:   "italian",
+				"team":               "foo",
+			},
+			Annotations: map[string]string{
+				myk8s.AnnotationClaimedNamespace: "true",
+			},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(
+		namespace,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "tunguska",
+				Labels:    testLabels,
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "not-brigade",
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "project-secrets",
+				Labels:    testLabels,
+			},
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "workers",
+			},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "jobs",
+			},
+		},
+	)
+	s := &substrate{
+		kubeClient: kubeClient,
+		config: SubstrateConfig{
+			BrigadeID:        "4077th",
+			BatchJobsEnabled: true,
+		},
+	}
+	err := s.releaseNamespace(
+		context.Background(),
+		api.Project{
+			ObjectMeta: meta.ObjectMeta{
+				ID: "italian",
+			},
+		},
+		namespace,
+	)
+	require.NoError(t, err)
+
+	// Check that everything Brigade labeled was deleted
+	deletedCollections := map[string]string{}
+	for _, action := range kubeClient.Actions() {
+		if deleteAction, ok :=
+			action.(clientTesting.DeleteCollectionAction); ok {
+			deletedCollections[deleteAction.GetResource().Resource] =
+				deleteAction.GetListRestrictions().Labels.String()
+		}
+	}
+	selector := labels.Set(testLabels).AsSelector().String()
+	require.Equal(
+		t,
+		map[string]string{
+			"jobs":                   selector,
+			"pods":                   selector,
+			"secrets":                selector,
+			"persistentvolumeclaims": selector,
+			"serviceaccounts":        selector,
+		},
+		deletedCollections,
+	)
+
+	_, err = kubeClient.CoreV1().ServiceAccounts(testNamespace).Get(
+		context.Background(),
+		"workers",
+		metav1.GetOptions{},
+	)
+	require.True(t, k8sErrors.IsNotFound(err))
+
+	_, err = kubeClient.RbacV1().Roles(testNamespace).Get(
+		context.Background(),
+		"jobs",
+		metav1.GetOptions{},
+	)
+	require.True(t, k8sErrors.IsNotFound(err))
+
+	namespace, err = kubeClient.CoreV1().Namespaces().Get(
+		context.Background(),
+		testNamespace,
+		metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "foo"}, namespace.Labels)
+	require.Empty(t, namespace.Annotations)
+}
+
+func TestSubstrateNodeSelector(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			NodeSelector: map[string]string{
+				"team":        "foo",
+				"brigadehost": "false",
+				"secret":      "bar",
+			},
+		},
+	}
+	s := &substrate{}
+	require.Nil(t, s.nodeSelector(api.Project{}))
+	// No keys are permitted to projects by default
+	require.Nil(t, s.nodeSelector(testProject))
+	s.config.NodeSelectorKey = "brigadehost"
+	s.config.NodeSelectorValue = "true"
+	require.Equal(
+		t,
+		map[string]string{"brigadehost": "true"},
+		s.nodeSelector(api.Project{}),
+	)
+	// The project's node selector is merged with the default, which takes
+	// precedence, and keys that aren't permitted are ignored
+	s.config.ProjectNodeSelectorKeys = []string{"team", "brigadehost"}
+	require.Equal(
+		t,
+		map[string]string{
+			"team":        "foo",
+			"brigadehost": "true",
+		},
+		s.nodeSelector(testProject),
+	)
+}
+
+func TestSubstrateTolerations(t *testing.T) {
+	testProject := api.Project{
+		Kubernetes: &api.KubernetesDetails{
+			Tolerations: []api.Toleration{
+				{
+					Key:    "team",
+					Value:  "foo",
+					Effect: "NoSchedule",
+				},
+				{
+					Key:      "node.kubernetes.io/unschedulable",
+					Operator: api.TolerationOperatorExists,
+				},
+			},
+		},
+	}
+	s := &substrate{}
+	require.Nil(t, s.tolerations(api.Project{}))
+	// No keys are permitted to projects by default
+	require.Nil(t, s.tolerations(testProject))
+	s.config.TolerationKey = "brigadehost"
+	require.Equal(
+		t,
+		[]corev1.Toleration{
+			{
+				Key:      "brigadehost",
+				Operator: corev1.TolerationOpExists,
+			},
+		},
+		s.tolerations(api.Project{}),
+	)
+	// The project's tolerations are added to the default and keys that aren't
+	// permitted are ignored
+	s.config.ProjectTolerationKeys = []string{"team"}
+	require.Equal(
+		t,
+		[]corev1.Toleration{
+			{
+				Key:      "brigadehost",
+				Operator: corev1.TolerationOpExists,
+			},
+			{
+				Key:      "team",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "foo",
+				Effect:   corev1.TaintEffectNoSchedule,
+			},
+		},
+		s.tolerations(testProject),
+	)
+}
+
+func TestSubstrateValidateSchedulingOverrides(t *testing.T) {
+	s := &substrate{
+		config: SubstrateConfig{
+			ProjectNodeSelectorKeys: []string{"team"},
+			ProjectTolerationKeys:   []string{"spot"},
+		},
+	}
+	testCases := []struct {
+		name       string
+		kubernetes *api.KubernetesDetails
+		assertions func(error)
+	}{
+		{
+			name: "no kubernetes details",
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "node selector key not permitted",
+			kubernetes: &api.KubernetesDetails{
+				NodeSelector: map[string]string{
+					"kubernetes.io/hostname": "control-plane",
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "kubernetes.io/hostname")
+			},
+		},
+		{
+			name: "toleration key not permitted",
+			kubernetes: &api.KubernetesDetails{
+				Tolerations: []api.Toleration{
+					{
+						Operator: api.TolerationOperatorExists,
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "taints with key")
+			},
+		},
+		{
+			name: "success",
+			kubernetes: &api.KubernetesDetails{
+				NodeSelector: map[string]string{
+					"team": "foo",
+				},
+				Tolerations: []api.Toleration{
+					{
+						Key:      "spot",
+						Operator: api.TolerationOperatorExists,
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				s.validateSchedulingOverrides(
+					api.Project{
+						Kubernetes: testCase.kubernetes,
+					},
+				),
+			)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package local
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/pkg/errors"
+)
+
+// executor is a local, Docker-based implementation of the api.Executor
+// interface.
+type executor struct {
+	// The following behaviors are overridable for test purposes
+	dockerCommandFn local.DockerCommandFn
+}
+
+// NewExecutor returns a local, Docker-based implementation of the api.Executor
+// interface. It executes commands within a Worker or Job's underlying container
+// using docker exec. Changes to the size of the client's terminal are not
+// propagated.
+func NewExecutor() api.Executor {
+	return &executor{
+		dockerCommandFn: local.DockerCommand,
+	}
+}
+
+func (e *executor) Exec(
+	ctx context.Context,
+	_ api.Project,
+	event api.Event,
+	selector api.ExecSelector,
+	opts api.ExecOptions,
+	streams api.ExecStreams,
+) error {
+	dockerName := local.WorkerContainerName(event.ID, selector.Container)
+	if selector.Job != "" {
+		dockerName =
+			local.JobContainerName(event.ID, selector.Job, selector.Container)
+	}
+
+	args := []string{"exec"}
+	if streams.Stdin != nil {
+		args = append(args, "--interactive")
+	}
+	if opts.TTY {
+		args = append(args, "--tty")
+	}
+	args = append(args, dockerName)
+	args = append(args, opts.Command...)
+
+	cmd := e.dockerCommandFn(ctx, args...)
+	cmd.Stdin = streams.Stdin
+	cmd.Stdout = streams.Stdout
+	if !opts.TTY {
+		cmd.Stderr = streams.Stderr
+	}
+
+	if streams.TerminalSizes != nil {
+		// Terminal sizes cannot be communicated to docker exec, but the channel
+		// must still be drained so the sender never blocks.
+		go func() {
+			for range streams.TerminalSizes {
+			}
+		}()
+	}
+
+	// Note that this blocks until the command exits or the context is canceled.
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.Exited() {
+			return &api.ExecExitError{
+				ExitCode: exitErr.ExitCode(),
+			}
+		}
+		return errors.Wrapf(
+			err,
+			"error executing command in container %q",
+			dockerName,
+		)
+	}
+	return nil
+}
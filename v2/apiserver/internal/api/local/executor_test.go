@@ -0,0 +1,104 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExecutor(t *testing.T) {
+	e, ok := NewExecutor().(*executor)
+	require.True(t, ok)
+	require.NotNil(t, e.dockerCommandFn)
+}
+
+func TestExecutorExec(t *testing.T) {
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "tunguska",
+		},
+	}
+	testCases := []struct {
+		name       string
+		selector   api.ExecSelector
+		opts       api.ExecOptions
+		script     string
+		assertions func(args []string, stdout string, stderr string, err error)
+	}{
+		{
+			name: "worker container; command succeeds",
+			selector: api.ExecSelector{
+				Container: "worker",
+			},
+			opts: api.ExecOptions{
+				Command: []string{"ls", "-l"},
+			},
+			script: "cat; echo oops >&2",
+			assertions: func(args []string, stdout, stderr string, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]string{"exec", "--interactive", "tunguska.worker", "ls", "-l"},
+					args,
+				)
+				require.Equal(t, "input", stdout)
+				require.Equal(t, "oops\n", stderr)
+			},
+		},
+		{
+			name: "job container with tty; command fails",
+			selector: api.ExecSelector{
+				Job:       "foo",
+				Container: "bar",
+			},
+			opts: api.ExecOptions{
+				Command: []string{"sh"},
+				TTY:     true,
+			},
+			script: "exit 42",
+			assertions: func(args []string, _, _ string, err error) {
+				require.Equal(
+					t,
+					[]string{"exec", "--interactive", "--tty", "tunguska.foo.bar", "sh"},
+					args,
+				)
+				require.Error(t, err)
+				exitErr, ok := err.(*api.ExecExitError)
+				require.True(t, ok)
+				require.Equal(t, 42, exitErr.ExitCode)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var args []string
+			e := &executor{
+				dockerCommandFn: func(_ context.Context, a ...string) *exec.Cmd {
+					args = a
+					return exec.Command("sh", "-c", testCase.script)
+				},
+			}
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			err := e.Exec(
+				context.Background(),
+				api.Project{},
+				testEvent,
+				testCase.selector,
+				testCase.opts,
+				api.ExecStreams{
+					Stdin:  strings.NewReader("input"),
+					Stdout: stdout,
+					Stderr: stderr,
+				},
+			)
+			testCase.assertions(args, stdout.String(), stderr.String(), err)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/pkg/errors"
+)
+
+// logsStore is a local, Docker-based implementation of the api.LogsStore
+// interface.
+type logsStore struct {
+	rootDirectory string
+	// The following behaviors are overridable for test purposes
+	dockerCommandFn local.DockerCommandFn
+}
+
+// NewLogsStore returns a local, Docker-based implementation of the
+// api.LogsStore interface. It streams logs directly from a Worker or Job's
+// underlying container or, once that container has been removed, from the
+// archive of its logs that the local implementation of the api.Substrate
+// interface writes beneath the specified root directory prior to removal.
+func NewLogsStore(rootDirectory string) api.LogsStore {
+	return &logsStore{
+		rootDirectory:   rootDirectory,
+		dockerCommandFn: local.DockerCommand,
+	}
+}
+
+func (l *logsStore) StreamLogs(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	selector api.LogsSelector,
+	opts api.LogStreamOptions,
+) (<-chan api.LogEntry, error) {
+	dockerName := local.WorkerContainerName(event.ID, selector.Container)
+	if selector.Job != "" {
+		dockerName =
+			local.JobContainerName(event.ID, selector.Job, selector.Container)
+	}
+
+	var logs io.ReadCloser
+	if _, err := local.RunDockerCommand(
+		l.dockerCommandFn(ctx, "inspect", "--type", "container", dockerName),
+	); err == nil {
+		// The container exists, so we stream its logs
+		args := []string{"logs", "--timestamps"}
+		if opts.Follow {
+			args = append(args, "--follow")
+		}
+		cmd := l.dockerCommandFn(ctx, append(args, dockerName)...)
+		reader, writer := io.Pipe()
+		// The container's stdout and stderr are interleaved, as they would be on
+		// Kubernetes.
+		cmd.Stdout = writer
+		cmd.Stderr = writer
+		if err = cmd.Start(); err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"error opening log stream for container %q",
+				dockerName,
+			)
+		}
+		go func() {
+			writer.CloseWithError(cmd.Wait())
+		}()
+		logs = reader
+	} else {
+		// The container no longer exists, so we fall back to its archived logs
+		path := logsArchivePath(l.rootDirectory, project.ID, dockerName)
+		if logs, err = os.Open(path); err != nil {
+			if os.IsNotExist(err) {
+				return nil, &meta.ErrNotFound{
+					Type: "Container",
+					ID:   dockerName,
+				}
+			}
+			return nil, errors.Wrapf(err, "error opening log file %q", path)
+		}
+	}
+
+	logEntryCh := make(chan api.LogEntry)
+
+	go func() {
+		defer logs.Close()
+		defer close(logEntryCh)
+		buffer := bufio.NewReader(logs)
+		for {
+			logEntry := api.LogEntry{}
+			logLine, err := buffer.ReadString('\n')
+			if err != nil && len(logLine) == 0 {
+				break
+			}
+			// The last character should be a newline that we don't want, so let's
+			// remove that
+			logLine = strings.TrimSuffix(logLine, "\n")
+			logLineParts := strings.SplitN(logLine, " ", 2)
+			if len(logLineParts) == 2 {
+				t, err := time.Parse(time.RFC3339, logLineParts[0])
+				if err == nil {
+					logEntry.Time = &t
+				}
+				logEntry.Message = logLineParts[1]
+			} else {
+				logEntry.Message = logLine
+			}
+			select {
+			case logEntryCh <- logEntry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return logEntryCh, nil
+}
+
+// logsArchiveDirectory returns the path to the directory in which the logs of
+// the specified Project's removed containers are archived.
+func logsArchiveDirectory(rootDirectory, projectID string) string {
+	return filepath.Join(rootDirectory, "logs", projectID)
+}
+
+// logsArchivePath returns the path to the file in which the logs of the
+// specified container are archived once it has been removed.
+func logsArchivePath(rootDirectory, projectID, dockerName string) string {
+	return filepath.Join(
+		logsArchiveDirectory(rootDirectory, projectID),
+		dockerName+".log",
+	)
+}
@@ -0,0 +1,148 @@
+package local
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogsStore(t *testing.T) {
+	l, ok := NewLogsStore("/var/lib/brigade").(*logsStore)
+	require.True(t, ok)
+	require.Equal(t, "/var/lib/brigade", l.rootDirectory)
+	require.NotNil(t, l.dockerCommandFn)
+}
+
+func TestLogsStoreStreamLogs(t *testing.T) {
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "tunguska",
+		},
+	}
+	testSelector := api.LogsSelector{
+		Job:       "foo",
+		Container: "bar",
+	}
+	testCases := []struct {
+		name       string
+		setup      func(rootDir string) *fakeDocker
+		assertions func(docker *fakeDocker, logsCh <-chan api.LogEntry, err error)
+	}{
+		{
+			name: "container and archive do not exist",
+			setup: func(string) *fakeDocker {
+				return &fakeDocker{
+					outputFn: func([]string) (string, bool) {
+						return "No such container", false
+					},
+				}
+			},
+			assertions: func(_ *fakeDocker, _ <-chan api.LogEntry, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+		{
+			name: "container exists",
+			setup: func(string) *fakeDocker {
+				return &fakeDocker{
+					outputFn: func(args []string) (string, bool) {
+						if args[0] == "logs" {
+							return "2021-01-01T00:00:00.123456789Z hello\nworld", true
+						}
+						return "", true
+					},
+				}
+			},
+			assertions: func(
+				docker *fakeDocker,
+				logsCh <-chan api.LogEntry,
+				err error,
+			) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]string{"inspect", "--type", "container", "tunguska.foo.bar"},
+					docker.args(0),
+				)
+				require.Equal(
+					t,
+					[]string{"logs", "--timestamps", "--follow", "tunguska.foo.bar"},
+					docker.args(1),
+				)
+				entries := []api.LogEntry{}
+				for entry := range logsCh {
+					entries = append(entries, entry)
+				}
+				require.Len(t, entries, 2)
+				require.NotNil(t, entries[0].Time)
+				require.Equal(t, "hello", entries[0].Message)
+				require.Nil(t, entries[1].Time)
+				require.Equal(t, "world", entries[1].Message)
+			},
+		},
+		{
+			name: "container was archived",
+			setup: func(rootDir string) *fakeDocker {
+				require.NoError(
+					t,
+					os.MkdirAll(logsArchiveDirectory(rootDir, "italian"), 0700),
+				)
+				require.NoError(
+					t,
+					os.WriteFile(
+						logsArchivePath(rootDir, "italian", "tunguska.foo.bar"),
+						[]byte("2021-01-01T00:00:00Z hello\n"),
+						0600,
+					),
+				)
+				return &fakeDocker{
+					outputFn: func([]string) (string, bool) {
+						return "No such container", false
+					},
+				}
+			},
+			assertions: func(
+				_ *fakeDocker,
+				logsCh <-chan api.LogEntry,
+				err error,
+			) {
+				require.NoError(t, err)
+				entry, ok := <-logsCh
+				require.True(t, ok)
+				require.Equal(t, "hello", entry.Message)
+				_, ok = <-logsCh
+				require.False(t, ok)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			docker := testCase.setup(rootDir)
+			l := &logsStore{
+				rootDirectory:   rootDir,
+				dockerCommandFn: docker.command,
+			}
+			logsCh, err := l.StreamLogs(
+				context.Background(),
+				testProject,
+				testEvent,
+				testSelector,
+				api.LogStreamOptions{
+					Follow: true,
+				},
+			)
+			testCase.assertions(docker, logsCh, err)
+		})
+	}
+}
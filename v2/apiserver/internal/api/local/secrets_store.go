@@ -0,0 +1,176 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/pkg/errors"
+)
+
+// projectSecretsMu serializes all reads and writes of Project Secrets files
+// within this process.
+var projectSecretsMu sync.Mutex
+
+// secretsStore is a local-filesystem-based implementation of the
+// api.SecretsStore interface.
+type secretsStore struct {
+	rootDirectory string
+}
+
+// NewSecretsStore returns a local-filesystem-based implementation of the
+// api.SecretsStore interface. Each Project's Secrets are stored, unencrypted,
+// in a file readable only by the current user beneath the specified root
+// directory. This is suitable for development and testing only.
+func NewSecretsStore(rootDirectory string) api.SecretsStore {
+	return &secretsStore{
+		rootDirectory: rootDirectory,
+	}
+}
+
+func (s *secretsStore) List(
+	_ context.Context,
+	project api.Project,
+	opts meta.ListOptions,
+) (meta.List[api.Secret], error) {
+	secrets := meta.List[api.Secret]{}
+
+	projectSecretsMu.Lock()
+	values, err := readProjectSecrets(s.rootDirectory, project.ID)
+	projectSecretsMu.Unlock()
+	if err != nil {
+		return secrets, err
+	}
+	secrets.Items = make([]api.Secret, 0, len(values))
+	for key := range values {
+		secrets.Items = append(secrets.Items, api.Secret{Key: key})
+	}
+
+	secrets.Sort(func(lhs, rhs api.Secret) int {
+		if lhs.Key < rhs.Key {
+			return -1
+		}
+		if lhs.Key == rhs.Key {
+			return 0
+		}
+		return 1
+	})
+
+	// Paginate for consistency with all other list operations
+	if opts.Continue != "" {
+		for i := int64(0); i < secrets.Len(); i++ {
+			if secrets.Items[i].Key == opts.Continue {
+				secrets.Items = secrets.Items[i+1:]
+				break
+			}
+		}
+	}
+	if secrets.Len() > opts.Limit {
+		secrets.RemainingItemCount = secrets.Len() - opts.Limit
+		secrets.Items = secrets.Items[:opts.Limit]
+		secrets.Continue = secrets.Items[opts.Limit-1].Key
+	}
+
+	return secrets, nil
+}
+
+func (s *secretsStore) Set(
+	_ context.Context,
+	project api.Project,
+	secret api.Secret,
+) error {
+	projectSecretsMu.Lock()
+	defer projectSecretsMu.Unlock()
+	values, err := readProjectSecrets(s.rootDirectory, project.ID)
+	if err != nil {
+		return err
+	}
+	values[secret.Key] = secret.Value
+	return writeProjectSecrets(s.rootDirectory, project.ID, values)
+}
+
+func (s *secretsStore) Unset(
+	_ context.Context,
+	project api.Project,
+	key string,
+) error {
+	projectSecretsMu.Lock()
+	defer projectSecretsMu.Unlock()
+	values, err := readProjectSecrets(s.rootDirectory, project.ID)
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return nil
+	}
+	delete(values, key)
+	return writeProjectSecrets(s.rootDirectory, project.ID, values)
+}
+
+// projectSecretsPath returns the path to the file in which the specified
+// Project's Secrets are stored.
+func projectSecretsPath(rootDirectory, projectID string) string {
+	return filepath.Join(
+		local.ProjectDirectory(rootDirectory, projectID),
+		"secrets.json",
+	)
+}
+
+// readProjectSecrets returns the specified Project's Secrets as a map of keys
+// to values. Callers should hold projectSecretsMu.
+func readProjectSecrets(
+	rootDirectory string,
+	projectID string,
+) (map[string]string, error) {
+	path := projectSecretsPath(rootDirectory, projectID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error reading secrets for project %q from %q",
+			projectID,
+			path,
+		)
+	}
+	values := map[string]string{}
+	if err = json.Unmarshal(data, &values); err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error unmarshaling secrets for project %q",
+			projectID,
+		)
+	}
+	return values, nil
+}
+
+// writeProjectSecrets overwrites the specified Project's Secrets with the
+// provided map of keys to values. Callers should hold projectSecretsMu.
+func writeProjectSecrets(
+	rootDirectory string,
+	projectID string,
+	values map[string]string,
+) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error marshaling secrets for project %q",
+			projectID,
+		)
+	}
+	path := projectSecretsPath(rootDirectory, projectID)
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		return errors.Wrapf(
+			err,
+			"error writing secrets for project %q to %q",
+			projectID,
+			path,
+		)
+	}
+	return nil
+}
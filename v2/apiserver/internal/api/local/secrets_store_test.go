@@ -0,0 +1,95 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecretsStore(t *testing.T) {
+	s, ok := NewSecretsStore("/var/lib/brigade").(*secretsStore)
+	require.True(t, ok)
+	require.Equal(t, "/var/lib/brigade", s.rootDirectory)
+}
+
+func TestSecretsStore(t *testing.T) {
+	rootDir := t.TempDir()
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+	}
+	s := NewSecretsStore(rootDir)
+
+	_, err := s.List(
+		context.Background(),
+		testProject,
+		meta.ListOptions{Limit: 10},
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error reading secrets for project")
+
+	_, err = (&substrate{
+		config: SubstrateConfig{
+			RootDirectory: rootDir,
+		},
+	}).CreateProject(context.Background(), testProject)
+	require.NoError(t, err)
+
+	// These keys are deliberately out of order, because we will want to test
+	// that the order is corrected when they are retrieved.
+	for _, key := range []string{"jkl", "abc", "ghi", "def"} {
+		err = s.Set(
+			context.Background(),
+			testProject,
+			api.Secret{
+				Key:   key,
+				Value: "foo",
+			},
+		)
+		require.NoError(t, err)
+	}
+	err = s.Unset(context.Background(), testProject, "ghi")
+	require.NoError(t, err)
+	// Unsetting a key that isn't set is not an error
+	err = s.Unset(context.Background(), testProject, "ghi")
+	require.NoError(t, err)
+
+	secrets, err := s.List(
+		context.Background(),
+		testProject,
+		meta.ListOptions{Limit: 2},
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]api.Secret{{Key: "abc"}, {Key: "def"}},
+		secrets.Items,
+	)
+	require.Equal(t, "def", secrets.Continue)
+	require.Equal(t, int64(1), secrets.RemainingItemCount)
+
+	secrets, err = s.List(
+		context.Background(),
+		testProject,
+		meta.ListOptions{
+			Continue: secrets.Continue,
+			Limit:    2,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []api.Secret{{Key: "jkl"}}, secrets.Items)
+	require.Empty(t, secrets.Continue)
+	require.Zero(t, secrets.RemainingItemCount)
+
+	values, err := readProjectSecrets(rootDir, testProject.ID)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		map[string]string{"abc": "foo", "def": "foo", "jkl": "foo"},
+		values,
+	)
+}
@@ -0,0 +1,1563 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/pkg/errors"
+)
+
+// SubstrateConfig encapsulates several configuration options for the local
+// Docker-based Substrate.
+type SubstrateConfig struct {
+	// BrigadeID is a unique-within-the-host identifier for an instance of
+	// Brigade. This helps the substrate constrain any operation it performs on
+	// the Docker daemon to only containers that are created and managed by THIS
+	// instance of Brigade.
+	BrigadeID string
+	// APIAddress is the address of the Brigade API server. The substrate will use
+	// this information whenever it needs to tell another component where to find
+	// the API server. It must be reachable from within containers.
+	APIAddress string
+	// RootDirectory is the directory beneath which the substrate stores Project
+	// Secrets, Event details, workspaces, source code, and cache volumes. It
+	// must be accessible at the same path to both the API server and the Docker
+	// daemon, since subdirectories are bind mounted into containers.
+	RootDirectory string
+	// Network is the optional name of the Docker network to which all Worker and
+	// Job containers are attached.
+	Network string
+	// GitInitializerImage is the name of the OCI image that will be used (when
+	// applicable) for the git initializer. The expected format is
+	// [REGISTRY/][ORG/]IMAGE_NAME[:TAG].
+	GitInitializerImage string
+	// GitInitializerImagePullPolicy is the ImagePullPolicy that will be used
+	// (when applicable) for the git initializer.
+	GitInitializerImagePullPolicy api.ImagePullPolicy
+	// DefaultWorkerImage is the name of the OCI image that will be used for the
+	// Worker's container if none is specified in a Project's configuration. The
+	// expected format is [REGISTRY/][ORG/]IMAGE_NAME[:TAG].
+	DefaultWorkerImage string
+	// DefaultWorkerImagePullPolicy is the ImagePullPolicy that will be used for
+	// the Worker's container if none is specified in a Project's configuration.
+	DefaultWorkerImagePullPolicy api.ImagePullPolicy
+	// DefaultImagePolicy optionally restricts the OCI images that all Workers
+	// and Jobs may use, regardless of any ImagePolicy specified by Worker
+	// configuration.
+	DefaultImagePolicy *api.ImagePolicy
+	// WorkerHeartbeatInterval is the interval at which Workers should report
+	// that they are alive. This should agree with the interval at which the
+	// observer expects heartbeats.
+	WorkerHeartbeatInterval time.Duration
+}
+
+// substrate is a local, Docker-based implementation of the api.Substrate
+// interface.
+type substrate struct {
+	queueWriterFactory queue.WriterFactory
+	config             SubstrateConfig
+	// The following behaviors are overridable for test purposes
+	dockerCommandFn   local.DockerCommandFn
+	startContainersFn func(initContainers []string, containers []string)
+}
+
+// NewSubstrate returns a local, Docker-based implementation of the
+// api.Substrate interface. Workers and Jobs are run as containers by the local
+// Docker daemon, with directories beneath the configured root directory taking
+// the place of persistent volumes. This is suitable for development and
+// testing only. Scheduling constraints, resource limits, and exclusive access
+// to cache volumes are not enforced. Any Workers or Jobs that a previous
+// instance of the API server created but did not finish starting are started.
+func NewSubstrate(
+	queueWriterFactory queue.WriterFactory,
+	config SubstrateConfig,
+) api.Substrate {
+	s := &substrate{
+		queueWriterFactory: queueWriterFactory,
+		config:             config,
+		dockerCommandFn:    local.DockerCommand,
+	}
+	s.startContainersFn = s.startContainers
+	if err := s.resumeContainers(context.Background()); err != nil {
+		log.Printf("error resuming containers: %s", err)
+	}
+	return s
+}
+
+func (s *substrate) CountRunningWorkers(
+	ctx context.Context,
+) (api.SubstrateWorkerCount, error) {
+	count := api.SubstrateWorkerCount{}
+	var err error
+	count.Count, err = s.countRunning(
+		ctx,
+		myk8s.LabelKeyWorker,
+		fmt.Sprintf(`{{.Label %q}}`, myk8s.LabelEvent),
+	)
+	return count, err
+}
+
+func (s *substrate) CountRunningJobs(
+	ctx context.Context,
+) (api.SubstrateJobCount, error) {
+	count := api.SubstrateJobCount{}
+	var err error
+	count.Count, err = s.countRunning(
+		ctx,
+		myk8s.LabelKeyJob,
+		fmt.Sprintf(
+			`{{.Label %q}}:{{.Label %q}}`,
+			myk8s.LabelEvent,
+			myk8s.LabelJob,
+		),
+	)
+	return count, err
+}
+
+// GetInventory summarizes the Worker and Job containers belonging to each
+// Project. Since workspaces are simply directories and the Docker daemon has no
+// notion of node pools, neither workspace usage nor node pool capacity is
+// reported.
+func (s *substrate) GetInventory(
+	ctx context.Context,
+) (api.SubstrateInventory, error) {
+	inventory := api.SubstrateInventory{
+		Projects:  []api.ProjectSubstrateInventory{},
+		NodePools: []api.NodePoolCapacity{},
+	}
+	out, err := local.RunDockerCommand(
+		s.dockerCommandFn(
+			ctx,
+			"ps",
+			"--all",
+			"--filter", fmt.Sprintf(
+				"label=%s=%s",
+				myk8s.LabelBrigadeID,
+				s.config.BrigadeID,
+			),
+			"--format", fmt.Sprintf(
+				`{{.Label %q}}|{{.Label %q}}|{{.Label %q}}|{{.Label %q}}|{{.State}}`,
+				myk8s.LabelProject,
+				myk8s.LabelComponent,
+				myk8s.LabelEvent,
+				myk8s.LabelJob,
+			),
+		),
+	)
+	if err != nil {
+		return inventory, errors.Wrap(err, "error listing containers")
+	}
+	// A Worker or Job may be comprised of several containers, so first gather
+	// the states of all containers belonging to each one
+	workloads := map[string]*workload{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) != 5 {
+			continue
+		}
+		key := strings.Join(fields[1:4], "|")
+		w, ok := workloads[key]
+		if !ok {
+			w = &workload{
+				projectID: fields[0],
+				component: fields[1],
+				states:    map[string]struct{}{},
+			}
+			workloads[key] = w
+		}
+		w.states[fields[4]] = struct{}{}
+	}
+	projects := map[string]*api.ProjectSubstrateInventory{}
+	for _, w := range workloads {
+		var counts *api.PodCounts
+		project, ok := projects[w.projectID]
+		if !ok {
+			project = &api.ProjectSubstrateInventory{
+				ProjectID: w.projectID,
+			}
+		}
+		switch w.component {
+		case myk8s.LabelKeyWorker:
+			counts = &project.Workers
+		case myk8s.LabelKeyJob:
+			counts = &project.Jobs
+		default:
+			continue
+		}
+		switch {
+		case w.hasState("removing"):
+			counts.Terminating++
+		case w.hasState("running", "restarting", "paused"):
+			counts.Running++
+		case w.hasState("created"):
+			counts.Pending++
+		default:
+			// All of the Worker's or Job's containers have exited
+			continue
+		}
+		projects[w.projectID] = project
+	}
+	for _, project := range projects {
+		inventory.Projects = append(inventory.Projects, *project)
+	}
+	sort.Slice(inventory.Projects, func(i, j int) bool {
+		return inventory.Projects[i].ProjectID < inventory.Projects[j].ProjectID
+	})
+	return inventory, nil
+}
+
+func (s *substrate) DefaultImagePolicy() *api.ImagePolicy {
+	return s.config.DefaultImagePolicy
+}
+
+func (s *substrate) CreateProject(
+	_ context.Context,
+	project api.Project,
+) (api.Project, error) {
+	// There are no namespaces to claim or clusters to pin to and the remaining
+	// Kubernetes-specific overrides are simply inapplicable
+	if project.Kubernetes != nil && project.Kubernetes.Namespace != "" {
+		return project, &meta.ErrNotSupported{
+			Details: "Claiming an existing namespace is not supported by the " +
+				"local substrate.",
+		}
+	}
+	if project.Kubernetes != nil && project.Kubernetes.Cluster != "" {
+		return project, &meta.ErrNotSupported{
+			Details: "Pinning a project to a cluster is not supported by the " +
+				"local substrate.",
+		}
+	}
+
+	projectDir := local.ProjectDirectory(s.config.RootDirectory, project.ID)
+	if err := os.MkdirAll(projectDir, 0700); err != nil {
+		return project, errors.Wrapf(
+			err,
+			"error creating directory %q for project %q",
+			projectDir,
+			project.ID,
+		)
+	}
+
+	// Create an empty file to store the Project's Secrets. Note that the local
+	// implementation of the SecretStore interface will assume this file exists.
+	projectSecretsMu.Lock()
+	err := writeProjectSecrets(
+		s.config.RootDirectory,
+		project.ID,
+		map[string]string{},
+	)
+	projectSecretsMu.Unlock()
+	if err != nil {
+		return project, err
+	}
+
+	for _, cacheVolume := range project.Spec.CacheVolumes {
+		if err := makeSharedDirectory(
+			s.cacheVolumeDirectory(project.ID, cacheVolume.Name),
+		); err != nil {
+			return project, errors.Wrapf(
+				err,
+				"error creating project %q cache volume %q",
+				project.ID,
+				cacheVolume.Name,
+			)
+		}
+	}
+
+	return project, nil
+}
+
+func (s *substrate) UpdateProject(context.Context, api.Project) error {
+	// None of the Project-related resources the local substrate manages depend
+	// on mutable Project details
+	return nil
+}
+
+func (s *substrate) DeleteProject(
+	ctx context.Context,
+	project api.Project,
+) error {
+	if err := s.removeContainers(
+		ctx,
+		project.ID,
+		map[string]string{
+			myk8s.LabelBrigadeID: s.config.BrigadeID,
+			myk8s.LabelProject:   project.ID,
+		},
+		false,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing containers for project %q",
+			project.ID,
+		)
+	}
+	for _, dir := range []string{
+		local.ProjectDirectory(s.config.RootDirectory, project.ID),
+		logsArchiveDirectory(s.config.RootDirectory, project.ID),
+	} {
+		if err := os.RemoveAll(dir); err != nil {
+			return errors.Wrapf(
+				err,
+				"error removing directory %q for project %q",
+				dir,
+				project.ID,
+			)
+		}
+	}
+	return nil
+}
+
+func (s *substrate) DeleteCacheVolume(
+	_ context.Context,
+	project api.Project,
+	name string,
+) error {
+	dir := s.cacheVolumeDirectory(project.ID, name)
+	if err := os.RemoveAll(dir); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing cache volume directory %q for project %q",
+			dir,
+			project.ID,
+		)
+	}
+	return nil
+}
+
+func (s *substrate) ScheduleWorker(ctx context.Context, event api.Event) error {
+	queueWriter, err := s.queueWriterFactory.NewWriter(
+		fmt.Sprintf("workers.%s", event.ProjectID),
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating queue writer for project %q workers",
+			event.ProjectID,
+		)
+	}
+	defer func() {
+		closeCtx, cancelCloseCtx :=
+			context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelCloseCtx()
+		queueWriter.Close(closeCtx)
+	}()
+
+	if err := queueWriter.Write(
+		ctx,
+		event.ID,
+		&queue.MessageOptions{
+			Durable: true,
+		},
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error submitting execution task for event %q worker",
+			event.ID,
+		)
+	}
+
+	return nil
+}
+
+// nolint: gocyclo
+func (s *substrate) StartWorker(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	token string,
+) error {
+	projectSecretsMu.Lock()
+	secrets, err := readProjectSecrets(s.config.RootDirectory, project.ID)
+	projectSecretsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	type proj struct {
+		ID         string                `json:"id"`
+		Kubernetes api.KubernetesDetails `json:"kubernetes"`
+		Secrets    map[string]string     `json:"secrets"`
+	}
+
+	type worker struct {
+		APIAddress           string            `json:"apiAddress"`
+		APIToken             string            `json:"apiToken"`
+		LogLevel             api.LogLevel      `json:"logLevel"`
+		ConfigFilesDirectory string            `json:"configFilesDirectory"`
+		DefaultConfigFiles   map[string]string `json:"defaultConfigFiles"`
+		Git                  *api.GitConfig    `json:"git"`
+		// HeartbeatIntervalSeconds is how often the Worker should report that it
+		// is alive
+		HeartbeatIntervalSeconds float64 `json:"heartbeatIntervalSeconds"`
+	}
+
+	eventJSON, err := json.MarshalIndent(
+		struct {
+			ID         string            `json:"id"`
+			Project    proj              `json:"project"`
+			Source     string            `json:"source"`
+			Type       string            `json:"type"`
+			Qualifiers api.Qualifiers    `json:"qualifiers"`
+			Labels     map[string]string `json:"labels"`
+			ShortTitle string            `json:"shortTitle"`
+			LongTitle  string            `json:"longTitle"`
+			Payload    string            `json:"payload"`
+			Worker     worker            `json:"worker"`
+		}{
+			ID: event.ID,
+			Project: proj{
+				ID:      event.ProjectID,
+				Secrets: secrets,
+			},
+			Source:     event.Source,
+			Type:       event.Type,
+			Qualifiers: event.Qualifiers,
+			Labels:     event.Labels,
+			ShortTitle: event.ShortTitle,
+			LongTitle:  event.LongTitle,
+			Payload:    event.Payload,
+			Worker: worker{
+				APIAddress:               s.config.APIAddress,
+				APIToken:                 token,
+				LogLevel:                 event.Worker.Spec.LogLevel,
+				ConfigFilesDirectory:     event.Worker.Spec.ConfigFilesDirectory,
+				DefaultConfigFiles:       event.Worker.Spec.DefaultConfigFiles,
+				Git:                      event.Worker.Spec.Git,
+				HeartbeatIntervalSeconds: s.config.WorkerHeartbeatInterval.Seconds(),
+			},
+		},
+		"",
+		"  ",
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error marshaling event %q", event.ID)
+	}
+
+	// Event details, which include the Project's Secrets and the Worker's token,
+	// are written to a file that is mounted to the Worker's containers at
+	// /var/event/event.json.
+	if err =
+		writeSecretFile(s.eventDetailsPath(event.ID), eventJSON); err != nil {
+		return errors.Wrapf(err, "error writing details for event %q", event.ID)
+	}
+
+	mounts := []mount{
+		{
+			source:   s.eventDetailsPath(event.ID),
+			target:   "/var/event/event.json",
+			readOnly: true,
+		},
+		{
+			source: filepath.Join(
+				local.EventDirectory(s.config.RootDirectory, event.ID),
+				"vcs",
+			),
+			target: "/var/vcs",
+		},
+	}
+	if event.Worker.Spec.UseWorkspace {
+		mounts = append(
+			mounts,
+			mount{
+				source: s.workspaceDirectory(event.ID),
+				target: "/var/workspace",
+			},
+		)
+	}
+	for _, m := range mounts[1:] {
+		if err = makeSharedDirectory(m.source); err != nil {
+			return errors.Wrapf(
+				err,
+				"error creating directory %q for event %q worker",
+				m.source,
+				event.ID,
+			)
+		}
+	}
+
+	if event.Worker.Spec.Container == nil {
+		event.Worker.Spec.Container = &api.ContainerSpec{}
+	}
+	image := event.Worker.Spec.Container.Image
+	if image == "" {
+		image = s.config.DefaultWorkerImage
+	}
+	imagePullPolicy := event.Worker.Spec.Container.ImagePullPolicy
+	if imagePullPolicy == "" {
+		imagePullPolicy = s.config.DefaultWorkerImagePullPolicy
+	}
+
+	initContainers := []container{}
+	if event.Worker.Spec.Git != nil && event.Worker.Spec.Git.CloneURL != "" {
+		initContainers = append(
+			initContainers,
+			container{
+				name:            "vcs",
+				image:           s.config.GitInitializerImage,
+				imagePullPolicy: s.config.GitInitializerImagePullPolicy,
+				mounts:          mounts,
+			},
+		)
+	}
+	workerContainer := container{
+		name:            myk8s.LabelKeyWorker,
+		image:           image,
+		imagePullPolicy: imagePullPolicy,
+		command:         event.Worker.Spec.Container.Command,
+		arguments:       event.Worker.Spec.Container.Arguments,
+		environment:     event.Worker.Spec.Container.Environment,
+		mounts:          mounts,
+	}
+
+	labels := map[string]string{
+		myk8s.LabelBrigadeID:            s.config.BrigadeID,
+		myk8s.LabelComponent:            myk8s.LabelKeyWorker,
+		myk8s.LabelProject:              event.ProjectID,
+		myk8s.LabelEvent:                event.ID,
+		myk8s.AnnotationTimeoutDuration: event.Worker.Spec.TimeoutDuration,
+	}
+	initContainerNames, containerNames, err := s.createContainers(
+		ctx,
+		labels,
+		func(containerName string) string {
+			return local.WorkerContainerName(event.ID, containerName)
+		},
+		initContainers,
+		[]container{workerContainer},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating containers for event %q worker",
+			event.ID,
+		)
+	}
+
+	go s.startContainersFn(initContainerNames, containerNames)
+
+	return nil
+}
+
+func (s *substrate) StoreJobEnvironment(
+	_ context.Context,
+	_ api.Project,
+	eventID string,
+	jobName string,
+	jobSpec api.JobSpec,
+) error {
+	env := map[string]string{}
+	for k, v := range jobSpec.PrimaryContainer.Environment {
+		env[fmt.Sprintf("%s.%s", jobName, k)] = v
+	}
+	for sidecarName, sidecarSpec := range jobSpec.SidecarContainers {
+		for k, v := range sidecarSpec.Environment {
+			env[fmt.Sprintf("%s.%s", sidecarName, k)] = v
+		}
+	}
+	for _, initContainer := range jobSpec.InitContainers {
+		for k, v := range initContainer.Environment {
+			env[fmt.Sprintf("%s.%s", initContainer.Name, k)] = v
+		}
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error marshaling environment for event %q job %q",
+			eventID,
+			jobName,
+		)
+	}
+	jobDir := local.JobDirectory(s.config.RootDirectory, eventID, jobName)
+	if err = os.MkdirAll(jobDir, 0755); err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating directory %q for event %q job %q",
+			jobDir,
+			eventID,
+			jobName,
+		)
+	}
+	if err = os.WriteFile(
+		s.jobEnvironmentPath(eventID, jobName),
+		data,
+		0600,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error writing environment for event %q job %q",
+			eventID,
+			jobName,
+		)
+	}
+	return nil
+}
+
+func (s *substrate) ScheduleJob(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	jobName string,
+) error {
+	// Schedule job for asynchronous execution
+	queueWriter, err := s.queueWriterFactory.NewWriter(
+		fmt.Sprintf("jobs.%s", event.ProjectID),
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating queue writer for project %q jobs",
+			event.ProjectID,
+		)
+	}
+	defer func() {
+		closeCtx, cancelCloseCtx :=
+			context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelCloseCtx()
+		queueWriter.Close(closeCtx)
+	}()
+
+	if err := queueWriter.Write(
+		ctx,
+		fmt.Sprintf("%s:%s", event.ID, jobName),
+		&queue.MessageOptions{
+			Durable: true,
+		},
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error submitting execution task for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+	return nil
+}
+
+// nolint: gocyclo
+func (s *substrate) StartJob(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	jobName string,
+	token string,
+) error {
+	job, _ := event.Worker.Job(jobName)
+	jobSpec := job.Spec
+	jobDir := local.JobDirectory(s.config.RootDirectory, event.ID, jobName)
+
+	data, err := os.ReadFile(s.jobEnvironmentPath(event.ID, jobName))
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error reading environment for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+	env := map[string]string{}
+	if err = json.Unmarshal(data, &env); err != nil {
+		return errors.Wrapf(
+			err,
+			"error unmarshaling environment for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+
+	projectSecretsMu.Lock()
+	secrets, err := readProjectSecrets(s.config.RootDirectory, project.ID)
+	projectSecretsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	useSource := event.Worker.Spec.Git != nil &&
+		event.Worker.Spec.Git.CloneURL != ""
+
+	// mountsFn returns the bind mounts for one of the Job's containers,
+	// creating any directories and files they require along the way.
+	mountsFn := func(spec api.JobContainerSpec) ([]mount, error) {
+		mounts := []mount{}
+		if spec.WorkspaceMountPath != "" {
+			source := s.workspaceDirectory(event.ID)
+			if err := makeSharedDirectory(source); err != nil {
+				return nil, err
+			}
+			mounts = append(
+				mounts,
+				mount{
+					source: source,
+					target: spec.WorkspaceMountPath,
+				},
+			)
+		}
+		if spec.SourceMountPath != "" && useSource {
+			source := filepath.Join(jobDir, "vcs")
+			if err := makeSharedDirectory(source); err != nil {
+				return nil, err
+			}
+			mounts = append(
+				mounts,
+				mount{
+					source: source,
+					target: spec.SourceMountPath,
+				},
+			)
+		}
+		for _, secretMount := range spec.SecretMounts {
+			value, ok := secrets[secretMount.Key]
+			if !ok {
+				return nil, errors.Errorf(
+					"project %q does not define secret %q",
+					project.ID,
+					secretMount.Key,
+				)
+			}
+			source := filepath.Join(jobDir, "secrets", secretMount.Key)
+			if err := writeSecretFile(source, []byte(value)); err != nil {
+				return nil, err
+			}
+			mounts = append(
+				mounts,
+				mount{
+					source:   source,
+					target:   secretMount.MountPath,
+					readOnly: true,
+				},
+			)
+		}
+		for _, cacheVolumeMount := range spec.CacheVolumeMounts {
+			if _, ok :=
+				project.Spec.CacheVolume(cacheVolumeMount.Name); !ok {
+				return nil, errors.Errorf(
+					"project %q does not define cache volume %q",
+					project.ID,
+					cacheVolumeMount.Name,
+				)
+			}
+			source := s.cacheVolumeDirectory(project.ID, cacheVolumeMount.Name)
+			if err := makeSharedDirectory(source); err != nil {
+				return nil, err
+			}
+			mounts = append(
+				mounts,
+				mount{
+					source: source,
+					target: cacheVolumeMount.MountPath,
+				},
+			)
+		}
+		return mounts, nil
+	}
+
+	// containerFn returns the container for one of the Job's container specs,
+	// resolving its environment, which may reference Project Secrets.
+	containerFn := func(
+		containerName string,
+		spec api.JobContainerSpec,
+	) (container, error) {
+		c := container{
+			name:             containerName,
+			image:            spec.Image,
+			imagePullPolicy:  spec.ImagePullPolicy,
+			command:          spec.Command,
+			arguments:        spec.Arguments,
+			workingDirectory: spec.WorkingDirectory,
+			environment:      map[string]string{},
+			privileged:       spec.Privileged,
+			securityContext:  spec.SecurityContext,
+		}
+		for key := range spec.Environment {
+			c.environment[key] = env[fmt.Sprintf("%s.%s", containerName, key)]
+		}
+		for envVar, key := range spec.SecretEnvironment {
+			value, ok := secrets[key]
+			if !ok {
+				return c, errors.Errorf(
+					"project %q does not define secret %q",
+					project.ID,
+					key,
+				)
+			}
+			c.environment[envVar] = value
+		}
+		var err error
+		if c.mounts, err = mountsFn(spec); err != nil {
+			return c, errors.Wrapf(
+				err,
+				"error preparing mounts for container %q",
+				containerName,
+			)
+		}
+		return c, nil
+	}
+
+	initContainers := []container{}
+	if useSource && jobUsesSource(jobSpec) {
+		vcsDir := filepath.Join(jobDir, "vcs")
+		if err = makeSharedDirectory(vcsDir); err != nil {
+			return errors.Wrapf(
+				err,
+				"error creating directory %q for event %q job %q",
+				vcsDir,
+				event.ID,
+				jobName,
+			)
+		}
+		initContainers = append(
+			initContainers,
+			container{
+				name:            "vcs",
+				image:           s.config.GitInitializerImage,
+				imagePullPolicy: s.config.GitInitializerImagePullPolicy,
+				mounts: []mount{
+					{
+						source:   s.eventDetailsPath(event.ID),
+						target:   "/var/event/event.json",
+						readOnly: true,
+					},
+					{
+						source: vcsDir,
+						target: "/var/vcs",
+					},
+				},
+			},
+		)
+	}
+
+	// The job's own init containers, if any, run in order AFTER the vcs init
+	// container so they may make use of source code retrieved from git.
+	for _, initContainer := range jobSpec.InitContainers {
+		c, err := containerFn(initContainer.Name, initContainer.JobContainerSpec)
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error preparing container for event %q job %q",
+				event.ID,
+				jobName,
+			)
+		}
+		initContainers = append(initContainers, c)
+	}
+
+	primaryContainer, err := containerFn(jobName, jobSpec.PrimaryContainer)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error preparing container for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+	// The primary container is given what it needs to report the job's progress
+	// to the API server. Environment variables from the job's spec take
+	// precedence.
+	for key, value := range map[string]string{
+		"BRIGADE_API_ADDRESS": s.config.APIAddress,
+		"BRIGADE_API_TOKEN":   token,
+		"BRIGADE_EVENT_ID":    event.ID,
+		"BRIGADE_JOB_NAME":    jobName,
+	} {
+		if _, ok := primaryContainer.environment[key]; !ok {
+			primaryContainer.environment[key] = value
+		}
+	}
+	// The primary container publishes outputs by writing to
+	// /dev/termination-log, as it would on Kubernetes.
+	terminationLogPath :=
+		local.JobTerminationLogPath(s.config.RootDirectory, event.ID, jobName)
+	if err = os.WriteFile(terminationLogPath, nil, 0666); err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating termination log for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+	if err = os.Chmod(terminationLogPath, 0666); err != nil { // nolint: gosec
+		return errors.Wrapf(
+			err,
+			"error setting permissions on termination log for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+	primaryContainer.mounts = append(
+		primaryContainer.mounts,
+		mount{
+			source: terminationLogPath,
+			target: "/dev/termination-log",
+		},
+	)
+
+	containers := []container{primaryContainer}
+	sidecarNames := make([]string, 0, len(jobSpec.SidecarContainers))
+	for sidecarName := range jobSpec.SidecarContainers {
+		sidecarNames = append(sidecarNames, sidecarName)
+	}
+	sort.Strings(sidecarNames)
+	for _, sidecarName := range sidecarNames {
+		c, err :=
+			containerFn(sidecarName, jobSpec.SidecarContainers[sidecarName])
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error preparing container for event %q job %q",
+				event.ID,
+				jobName,
+			)
+		}
+		containers = append(containers, c)
+	}
+
+	labels := map[string]string{
+		myk8s.LabelBrigadeID:            s.config.BrigadeID,
+		myk8s.LabelComponent:            myk8s.LabelKeyJob,
+		myk8s.LabelProject:              event.ProjectID,
+		myk8s.LabelEvent:                event.ID,
+		myk8s.LabelJob:                  jobName,
+		myk8s.AnnotationTimeoutDuration: jobSpec.TimeoutDuration,
+	}
+	initContainerNames, containerNames, err := s.createContainers(
+		ctx,
+		labels,
+		func(containerName string) string {
+			return local.JobContainerName(event.ID, jobName, containerName)
+		},
+		initContainers,
+		containers,
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error creating containers for event %q job %q",
+			event.ID,
+			jobName,
+		)
+	}
+
+	go s.startContainersFn(initContainerNames, containerNames)
+
+	return nil
+}
+
+func (s *substrate) DeleteJob(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+	jobName string,
+) error {
+	if err := s.removeContainers(
+		ctx,
+		project.ID,
+		map[string]string{
+			myk8s.LabelBrigadeID: s.config.BrigadeID,
+			myk8s.LabelEvent:     event.ID,
+			myk8s.LabelJob:       jobName,
+		},
+		true,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing event %q job %q containers",
+			event.ID,
+			jobName,
+		)
+	}
+	jobDir := local.JobDirectory(s.config.RootDirectory, event.ID, jobName)
+	if err := os.RemoveAll(jobDir); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing directory %q for event %q job %q",
+			jobDir,
+			event.ID,
+			jobName,
+		)
+	}
+	return nil
+}
+
+func (s *substrate) DeleteWorkerAndJobs(
+	ctx context.Context,
+	project api.Project,
+	event api.Event,
+) error {
+	if err := s.removeContainers(
+		ctx,
+		project.ID,
+		map[string]string{
+			myk8s.LabelBrigadeID: s.config.BrigadeID,
+			myk8s.LabelEvent:     event.ID,
+		},
+		true,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing event %q containers",
+			event.ID,
+		)
+	}
+	eventDir := local.EventDirectory(s.config.RootDirectory, event.ID)
+	if err := os.RemoveAll(eventDir); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing directory %q for event %q",
+			eventDir,
+			event.ID,
+		)
+	}
+	return nil
+}
+
+// countRunning returns the number of distinct Workers or Jobs, as indicated by
+// the specified component, having at least one running container. The
+// provided Go template is used to derive, from each running container, a key
+// that uniquely identifies the Worker or Job it belongs to.
+func (s *substrate) countRunning(
+	ctx context.Context,
+	component string,
+	keyTemplate string,
+) (int, error) {
+	out, err := local.RunDockerCommand(
+		s.dockerCommandFn(
+			ctx,
+			"ps",
+			"--filter", fmt.Sprintf(
+				"label=%s=%s",
+				myk8s.LabelBrigadeID,
+				s.config.BrigadeID,
+			),
+			"--filter", fmt.Sprintf("label=%s=%s", myk8s.LabelComponent, component),
+			"--filter", "status=running",
+			"--format", keyTemplate,
+		),
+	)
+	if err != nil {
+		return 0, errors.Wrapf(
+			err,
+			"error counting running %s containers",
+			component,
+		)
+	}
+	keys := map[string]struct{}{}
+	for _, key := range strings.Fields(string(out)) {
+		keys[key] = struct{}{}
+	}
+	return len(keys), nil
+}
+
+// workload describes the states of all Docker containers belonging to a single
+// Worker or Job.
+type workload struct {
+	projectID string
+	component string
+	states    map[string]struct{}
+}
+
+// hasState returns a boolean indicating whether any of the workload's
+// containers is in any of the specified states.
+func (w *workload) hasState(states ...string) bool {
+	for _, state := range states {
+		if _, ok := w.states[state]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// container describes a Docker container to be created on behalf of a Worker
+// or Job.
+type container struct {
+	name             string
+	image            string
+	imagePullPolicy  api.ImagePullPolicy
+	command          []string
+	arguments        []string
+	workingDirectory string
+	environment      map[string]string
+	mounts           []mount
+	privileged       bool
+	securityContext  *api.ContainerSecurityContext
+}
+
+// mount describes a bind mount of a file or directory on the host into a
+// Docker container.
+type mount struct {
+	source   string
+	target   string
+	readOnly bool
+}
+
+// createContainers creates, but does not start, a Docker container for each
+// of the provided init containers and containers belonging to a single Worker
+// or Job. Every container is labeled with the provided labels as well as with
+// details of all of its siblings so the observer can reason about the Worker
+// or Job as a whole. The provided function maps container names to Docker
+// container names. The Docker container names of the init containers and of
+// the remaining containers are returned.
+func (s *substrate) createContainers(
+	ctx context.Context,
+	labels map[string]string,
+	dockerNameFn func(string) string,
+	initContainers []container,
+	containers []container,
+) ([]string, []string, error) {
+	initContainerNames := make([]string, len(initContainers))
+	for i, initContainer := range initContainers {
+		initContainerNames[i] = initContainer.name
+	}
+	labels[local.LabelPrimaryContainer] = containers[0].name
+	labels[local.LabelInitContainers] = strings.Join(initContainerNames, ",")
+
+	// If any container cannot be created, those that were are removed so that
+	// their names are free the next time creation is attempted.
+	created := []string{}
+	createFn := func(dockerName string, c container) error {
+		if err := s.createContainer(ctx, dockerName, labels, c); err != nil {
+			if len(created) > 0 {
+				if _, rmErr := local.RunDockerCommand(
+					s.dockerCommandFn(
+						ctx,
+						append([]string{"rm", "--force", "--volumes"}, created...)...,
+					),
+				); rmErr != nil {
+					log.Printf("error removing containers %q: %s", created, rmErr)
+				}
+			}
+			return err
+		}
+		created = append(created, dockerName)
+		return nil
+	}
+	initDockerNames := make([]string, len(initContainers))
+	for i, initContainer := range initContainers {
+		initDockerNames[i] = dockerNameFn(initContainer.name)
+		if err := createFn(initDockerNames[i], initContainer); err != nil {
+			return nil, nil, err
+		}
+	}
+	dockerNames := make([]string, len(containers))
+	for i, c := range containers {
+		dockerNames[i] = dockerNameFn(c.name)
+		if err := createFn(dockerNames[i], c); err != nil {
+			return nil, nil, err
+		}
+	}
+	return initDockerNames, dockerNames, nil
+}
+
+// createContainer creates, but does not start, a Docker container with the
+// specified name and labels based on the provided container description.
+// Environment variables are passed to the Docker CLI through its own
+// environment so that their values, which may be secrets, never appear on its
+// command line.
+// nolint: gocyclo
+func (s *substrate) createContainer(
+	ctx context.Context,
+	dockerName string,
+	labels map[string]string,
+	c container,
+) error {
+	args := []string{"create", "--name", dockerName}
+	labelKeys := make([]string, 0, len(labels)+1)
+	for key := range labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	args = append(
+		args,
+		"--label", fmt.Sprintf("%s=%s", local.LabelContainer, c.name),
+		"--pull", dockerPullPolicy(c.imagePullPolicy),
+	)
+	if s.config.Network != "" {
+		args = append(args, "--network", s.config.Network)
+	}
+	if c.workingDirectory != "" {
+		args = append(args, "--workdir", c.workingDirectory)
+	}
+	envKeys := make([]string, 0, len(c.environment))
+	for key := range c.environment {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+	env := make([]string, len(envKeys))
+	for i, key := range envKeys {
+		args = append(args, "--env", key)
+		env[i] = fmt.Sprintf("%s=%s", key, c.environment[key])
+	}
+	for _, m := range c.mounts {
+		mountStr := fmt.Sprintf("type=bind,source=%s,target=%s", m.source, m.target)
+		if m.readOnly {
+			mountStr = fmt.Sprintf("%s,readonly", mountStr)
+		}
+		args = append(args, "--mount", mountStr)
+	}
+	if c.privileged {
+		args = append(args, "--privileged")
+	}
+	if sc := c.securityContext; sc != nil {
+		if sc.RunAsUser != nil {
+			user := fmt.Sprint(*sc.RunAsUser)
+			if sc.RunAsGroup != nil {
+				user = fmt.Sprintf("%s:%d", user, *sc.RunAsGroup)
+			}
+			args = append(args, "--user", user)
+		}
+		if sc.ReadOnlyRootFilesystem {
+			args = append(args, "--read-only")
+		}
+		for _, capability := range sc.DropCapabilities {
+			args = append(args, "--cap-drop", capability)
+		}
+		if sc.SeccompProfile == api.SeccompProfileUnconfined {
+			args = append(args, "--security-opt", "seccomp=unconfined")
+		}
+	}
+	// Docker only permits a single entrypoint, so any additional elements of
+	// the command precede the arguments.
+	if len(c.command) > 0 {
+		args = append(args, "--entrypoint", c.command[0], c.image)
+		args = append(args, c.command[1:]...)
+	} else {
+		args = append(args, c.image)
+	}
+	args = append(args, c.arguments...)
+
+	cmd := s.dockerCommandFn(ctx, args...)
+	cmd.Env = append(os.Environ(), env...)
+	if _, err := local.RunDockerCommand(cmd); err != nil {
+		return errors.Wrapf(err, "error creating container %q", dockerName)
+	}
+	return nil
+}
+
+// startContainers starts the specified init containers one at a time, in
+// order, waiting for each to exit successfully before starting the next. It
+// then starts all of the remaining containers. It is meant to be called
+// asynchronously. If any init container fails, the remaining containers are
+// never started and the observer will report the failure. Containers that were
+// already started are never started again, so this is safe to call for
+// containers that a previous call only partially started.
+func (s *substrate) startContainers(
+	initContainers []string,
+	containers []string,
+) {
+	ctx := context.Background()
+	for _, name := range initContainers {
+		status, err := s.containerStatus(ctx, name)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if status == "created" {
+			if _, err = local.RunDockerCommand(
+				s.dockerCommandFn(ctx, "start", name),
+			); err != nil {
+				log.Printf("error starting container %q: %s", name, err)
+				return
+			}
+		}
+		out, err := local.RunDockerCommand(s.dockerCommandFn(ctx, "wait", name))
+		if err != nil {
+			log.Printf("error waiting for container %q: %s", name, err)
+			return
+		}
+		if exitCode := strings.TrimSpace(string(out)); exitCode != "0" {
+			return
+		}
+	}
+	unstarted := []string{}
+	for _, name := range containers {
+		status, err := s.containerStatus(ctx, name)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if status == "created" {
+			unstarted = append(unstarted, name)
+		}
+	}
+	if len(unstarted) == 0 {
+		return
+	}
+	if _, err := local.RunDockerCommand(
+		s.dockerCommandFn(ctx, append([]string{"start"}, unstarted...)...),
+	); err != nil {
+		log.Printf("error starting containers %q: %s", unstarted, err)
+	}
+}
+
+// containerStatus returns the status of the specified container, e.g.
+// "created", "running", or "exited".
+func (s *substrate) containerStatus(
+	ctx context.Context,
+	dockerName string,
+) (string, error) {
+	out, err := local.RunDockerCommand(
+		s.dockerCommandFn(
+			ctx,
+			"inspect",
+			"--format", "{{.State.Status}}",
+			dockerName,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(
+			err,
+			"error inspecting container %q",
+			dockerName,
+		)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// resumeContainers finds every Worker or Job having containers that were
+// created but never started and resumes starting them. Containers are started
+// asynchronously, so without this, a restart of the API server could leave a
+// Worker or Job that would never run and never be reported as failed. Workers
+// and Jobs whose primary container was never created are left alone, since
+// their creation did not succeed and will be retried.
+func (s *substrate) resumeContainers(ctx context.Context) error {
+	containers, err := local.ListContainers(
+		ctx,
+		s.dockerCommandFn,
+		map[string]string{
+			myk8s.LabelBrigadeID: s.config.BrigadeID,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	// Group the containers by the Worker or Job they belong to
+	keys := []string{}
+	containersByKey := map[string][]local.Container{}
+	for _, c := range containers {
+		key := fmt.Sprintf(
+			"%s/%s",
+			c.Config.Labels[myk8s.LabelEvent],
+			c.Config.Labels[myk8s.LabelJob],
+		)
+		if _, ok := containersByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		containersByKey[key] = append(containersByKey[key], c)
+	}
+	for _, key := range keys {
+		// Every container bears the names of the primary container and of the
+		// init containers, in the order they are executed
+		labels := containersByKey[key][0].Config.Labels
+		initNames := []string{}
+		if names := labels[local.LabelInitContainers]; names != "" {
+			initNames = strings.Split(names, ",")
+		}
+		dockerNames := map[string]string{}
+		var hasUnstarted bool
+		for _, c := range containersByKey[key] {
+			dockerNames[c.Config.Labels[local.LabelContainer]] = c.Name
+			if c.State.Status == "created" {
+				hasUnstarted = true
+			}
+		}
+		if _, ok := dockerNames[labels[local.LabelPrimaryContainer]]; !ok ||
+			!hasUnstarted {
+			continue
+		}
+		initDockerNames := []string{}
+		for _, name := range initNames {
+			if dockerName, ok := dockerNames[name]; ok {
+				initDockerNames = append(initDockerNames, dockerName)
+				delete(dockerNames, name)
+			}
+		}
+		otherDockerNames := []string{}
+		for _, dockerName := range dockerNames {
+			otherDockerNames = append(otherDockerNames, dockerName)
+		}
+		sort.Strings(otherDockerNames)
+		go s.startContainersFn(initDockerNames, otherDockerNames)
+	}
+	return nil
+}
+
+// removeContainers forcefully removes all containers bearing ALL of the
+// specified labels. If archiveLogs is true, the logs of any container that
+// was ever started are first archived so they remain available after the
+// container is gone.
+func (s *substrate) removeContainers(
+	ctx context.Context,
+	projectID string,
+	labels map[string]string,
+	archiveLogs bool,
+) error {
+	containers, err := local.ListContainers(ctx, s.dockerCommandFn, labels)
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		return nil
+	}
+	ids := make([]string, len(containers))
+	for i, c := range containers {
+		ids[i] = c.ID
+		if archiveLogs && !c.State.StartedAt.IsZero() {
+			if err = s.archiveLogs(ctx, projectID, c.Name); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err = local.RunDockerCommand(
+		s.dockerCommandFn(
+			ctx,
+			append([]string{"rm", "--force", "--volumes"}, ids...)...,
+		),
+	); err != nil {
+		return errors.Wrap(err, "error removing containers")
+	}
+	return nil
+}
+
+// archiveLogs writes the logs of the specified container to a file from which
+// the local implementation of the api.LogsStore interface can retrieve them
+// after the container has been removed.
+func (s *substrate) archiveLogs(
+	ctx context.Context,
+	projectID string,
+	dockerName string,
+) error {
+	dir := logsArchiveDirectory(s.config.RootDirectory, projectID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "error creating directory %q", dir)
+	}
+	path := logsArchivePath(s.config.RootDirectory, projectID, dockerName)
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "error creating file %q", path)
+	}
+	defer file.Close()
+	cmd := s.dockerCommandFn(ctx, "logs", "--timestamps", dockerName)
+	cmd.Stdout = file
+	cmd.Stderr = file
+	if err = cmd.Run(); err != nil {
+		return errors.Wrapf(
+			err,
+			"error archiving logs for container %q",
+			dockerName,
+		)
+	}
+	return nil
+}
+
+func (s *substrate) eventDetailsPath(eventID string) string {
+	return filepath.Join(
+		local.EventDirectory(s.config.RootDirectory, eventID),
+		"event",
+		"event.json",
+	)
+}
+
+func (s *substrate) workspaceDirectory(eventID string) string {
+	return filepath.Join(
+		local.EventDirectory(s.config.RootDirectory, eventID),
+		"workspace",
+	)
+}
+
+func (s *substrate) jobEnvironmentPath(eventID, jobName string) string {
+	return filepath.Join(
+		local.JobDirectory(s.config.RootDirectory, eventID, jobName),
+		"env.json",
+	)
+}
+
+func (s *substrate) cacheVolumeDirectory(projectID, name string) string {
+	return filepath.Join(
+		local.ProjectDirectory(s.config.RootDirectory, projectID),
+		"cache",
+		name,
+	)
+}
+
+// jobUsesSource returns true if ANY of the Job's containers mounts source code
+// from git.
+func jobUsesSource(jobSpec api.JobSpec) bool {
+	if jobSpec.PrimaryContainer.SourceMountPath != "" {
+		return true
+	}
+	for _, sidecarContainer := range jobSpec.SidecarContainers {
+		if sidecarContainer.SourceMountPath != "" {
+			return true
+		}
+	}
+	for _, initContainer := range jobSpec.InitContainers {
+		if initContainer.SourceMountPath != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// makeSharedDirectory creates the specified directory, if it does not already
+// exist, and makes it writable by all users. This is necessary because it will
+// be bind mounted into containers that may run as any user.
+func makeSharedDirectory(path string) error {
+	if err := os.MkdirAll(path, 0777); err != nil {
+		return err
+	}
+	// Permissions are set explicitly since MkdirAll is subject to the umask
+	return os.Chmod(path, 0777) // nolint: gosec
+}
+
+// writeSecretFile writes the provided data, which may be secret, to the
+// specified path. The file's parent directory is created, if it does not
+// already exist, and made accessible only to the API server's own user so that
+// no other user of the host can read the file. The file itself is
+// world-readable because it is bind mounted into containers that may run as
+// any user, and a bind mount bypasses the permissions of the file's parent
+// directories.
+func writeSecretFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// Permissions are set explicitly since neither MkdirAll nor WriteFile
+	// changes the permissions of an existing directory or file and WriteFile is
+	// subject to the umask
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil { // nolint: gosec
+		return err
+	}
+	return os.Chmod(path, 0644) // nolint: gosec
+}
+
+// dockerPullPolicy maps the provided api.ImagePullPolicy to the corresponding
+// value of the Docker CLI's --pull flag.
+func dockerPullPolicy(imagePullPolicy api.ImagePullPolicy) string {
+	switch imagePullPolicy {
+	case "Always":
+		return "always"
+	case "Never":
+		return "never"
+	default:
+		return "missing"
+	}
+}
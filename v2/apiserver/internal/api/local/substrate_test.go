@@ -0,0 +1,917 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSubstrate(t *testing.T) {
+	testConfig := SubstrateConfig{
+		RootDirectory: "/var/lib/brigade",
+	}
+	s, ok := NewSubstrate(nil, testConfig).(*substrate)
+	require.True(t, ok)
+	require.Equal(t, testConfig, s.config)
+	require.NotNil(t, s.dockerCommandFn)
+	require.NotNil(t, s.startContainersFn)
+}
+
+func TestSubstrateCountRunningWorkers(t *testing.T) {
+	docker := &fakeDocker{
+		outputFn: func([]string) (string, bool) {
+			// Two containers belong to the same worker
+			return "abc\nabc\ndef\n", true
+		},
+	}
+	s := &substrate{
+		config: SubstrateConfig{
+			BrigadeID: "4077th",
+		},
+		dockerCommandFn: docker.command,
+	}
+	count, err := s.CountRunningWorkers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count.Count)
+	require.Contains(t, docker.args(0), "label=brigade.sh/id=4077th")
+	require.Contains(t, docker.args(0), "label=brigade.sh/component=worker")
+	require.Contains(t, docker.args(0), "status=running")
+}
+
+func TestSubstrateCountRunningJobs(t *testing.T) {
+	testCases := []struct {
+		name       string
+		outputFn   func([]string) (string, bool)
+		assertions func(api.SubstrateJobCount, error)
+	}{
+		{
+			name: "error listing containers",
+			outputFn: func([]string) (string, bool) {
+				return "", false
+			},
+			assertions: func(_ api.SubstrateJobCount, err error) {
+				require.Error(t, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"error counting running job containers",
+				)
+			},
+		},
+		{
+			name: "success",
+			outputFn: func([]string) (string, bool) {
+				return "abc:foo\nabc:bar\nabc:foo\n", true
+			},
+			assertions: func(count api.SubstrateJobCount, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, count.Count)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := &substrate{
+				dockerCommandFn: (&fakeDocker{outputFn: testCase.outputFn}).command,
+			}
+			testCase.assertions(s.CountRunningJobs(context.Background()))
+		})
+	}
+}
+
+func TestSubstrateGetInventory(t *testing.T) {
+	testCases := []struct {
+		name       string
+		outputFn   func([]string) (string, bool)
+		assertions func(api.SubstrateInventory, error)
+	}{
+		{
+			name: "error listing containers",
+			outputFn: func([]string) (string, bool) {
+				return "", false
+			},
+			assertions: func(_ api.SubstrateInventory, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error listing containers")
+			},
+		},
+		{
+			name: "success",
+			outputFn: func([]string) (string, bool) {
+				return strings.Join(
+					[]string{
+						// A running worker with an exited init container
+						"italian|worker|abc||exited",
+						"italian|worker|abc||running",
+						// A job that hasn't started yet
+						"italian|job|abc|foo|created",
+						// A job that is being removed
+						"italian|job|abc|bar|removing",
+						// A job that has finished
+						"french|job|def|foo|exited",
+					},
+					"\n",
+				), true
+			},
+			assertions: func(inventory api.SubstrateInventory, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]api.ProjectSubstrateInventory{
+						{
+							ProjectID: "italian",
+							Workers: api.PodCounts{
+								Running: 1,
+							},
+							Jobs: api.PodCounts{
+								Pending:     1,
+								Terminating: 1,
+							},
+						},
+					},
+					inventory.Projects,
+				)
+				require.Empty(t, inventory.NodePools)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			docker := &fakeDocker{outputFn: testCase.outputFn}
+			s := &substrate{
+				config: SubstrateConfig{
+					BrigadeID: "4077th",
+				},
+				dockerCommandFn: docker.command,
+			}
+			inventory, err := s.GetInventory(context.Background())
+			testCase.assertions(inventory, err)
+			require.Contains(t, docker.args(0), "--all")
+			require.Contains(t, docker.args(0), "label=brigade.sh/id=4077th")
+		})
+	}
+}
+
+func TestSubstrateDefaultImagePolicy(t *testing.T) {
+	testPolicy := &api.ImagePolicy{
+		RequireDigest: true,
+	}
+	s := &substrate{
+		config: SubstrateConfig{
+			DefaultImagePolicy: testPolicy,
+		},
+	}
+	require.Same(t, testPolicy, s.DefaultImagePolicy())
+}
+
+func TestSubstrateCreateAndDeleteProject(t *testing.T) {
+	rootDir := t.TempDir()
+	docker := &fakeDocker{
+		outputFn: func([]string) (string, bool) {
+			return "", true
+		},
+	}
+	s := &substrate{
+		config: SubstrateConfig{
+			BrigadeID:     "4077th",
+			RootDirectory: rootDir,
+		},
+		dockerCommandFn: docker.command,
+	}
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+		Spec: api.ProjectSpec{
+			CacheVolumes: []api.CacheVolume{
+				{
+					Name: "deps",
+				},
+			},
+		},
+	}
+
+	_, err := s.CreateProject(
+		context.Background(),
+		api.Project{
+			Kubernetes: &api.KubernetesDetails{
+				Namespace: "foo",
+			},
+		},
+	)
+	require.IsType(t, &meta.ErrNotSupported{}, err)
+
+	_, err = s.CreateProject(
+		context.Background(),
+		api.Project{
+			Kubernetes: &api.KubernetesDetails{
+				Cluster: "gpu",
+			},
+		},
+	)
+	require.IsType(t, &meta.ErrNotSupported{}, err)
+
+	project, err := s.CreateProject(context.Background(), testProject)
+	require.NoError(t, err)
+	require.Nil(t, project.Kubernetes)
+	projectDir := local.ProjectDirectory(rootDir, testProject.ID)
+	data, err := os.ReadFile(filepath.Join(projectDir, "secrets.json"))
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(data))
+	info, err := os.Stat(filepath.Join(projectDir, "cache", "deps"))
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+
+	err = s.DeleteProject(context.Background(), testProject)
+	require.NoError(t, err)
+	require.Contains(t, docker.args(0), "label=brigade.sh/project=italian")
+	_, err = os.Stat(projectDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSubstrateDeleteCacheVolume(t *testing.T) {
+	rootDir := t.TempDir()
+	s := &substrate{
+		config: SubstrateConfig{
+			RootDirectory: rootDir,
+		},
+	}
+	cacheVolumeDir := s.cacheVolumeDirectory("italian", "deps")
+	require.NoError(t, os.MkdirAll(cacheVolumeDir, 0700))
+	err := s.DeleteCacheVolume(
+		context.Background(),
+		api.Project{
+			ObjectMeta: meta.ObjectMeta{
+				ID: "italian",
+			},
+		},
+		"deps",
+	)
+	require.NoError(t, err)
+	_, err = os.Stat(cacheVolumeDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSubstrateStartWorker(t *testing.T) {
+	const testToken = "secret-token"
+	rootDir := t.TempDir()
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "tunguska",
+		},
+		ProjectID: "italian",
+		Worker: api.Worker{
+			Spec: api.WorkerSpec{
+				Container: &api.ContainerSpec{
+					Command:   []string{"node", "--trace-warnings"},
+					Arguments: []string{"index.js"},
+					Environment: map[string]string{
+						"FOO": "bar",
+					},
+				},
+				Git: &api.GitConfig{
+					CloneURL: "https://github.com/brigadecore/brigade.git",
+				},
+				UseWorkspace:    true,
+				TimeoutDuration: "1h",
+			},
+		},
+	}
+
+	t.Run("error reading project secrets", func(t *testing.T) {
+		s := &substrate{
+			config: SubstrateConfig{
+				RootDirectory: rootDir,
+			},
+		}
+		err := s.StartWorker(
+			context.Background(),
+			testProject,
+			testEvent,
+			testToken,
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading secrets for project")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		docker := &fakeDocker{
+			outputFn: func([]string) (string, bool) {
+				return "", true
+			},
+		}
+		startedCh := make(chan [][]string, 1)
+		s := &substrate{
+			config: SubstrateConfig{
+				BrigadeID:           "4077th",
+				RootDirectory:       rootDir,
+				Network:             "brigade",
+				GitInitializerImage: "git-initializer",
+				DefaultWorkerImage:  "worker",
+			},
+			dockerCommandFn: docker.command,
+		}
+		s.startContainersFn = func(initContainers, containers []string) {
+			startedCh <- [][]string{initContainers, containers}
+		}
+		_, err := s.CreateProject(context.Background(), testProject)
+		require.NoError(t, err)
+
+		err = s.StartWorker(
+			context.Background(),
+			testProject,
+			testEvent,
+			testToken,
+		)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(s.eventDetailsPath(testEvent.ID))
+		require.NoError(t, err)
+		require.Contains(t, string(data), testToken)
+		info, err := os.Stat(filepath.Dir(s.eventDetailsPath(testEvent.ID)))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+		_, err = os.Stat(
+			filepath.Join(filepath.Dir(s.eventDetailsPath(testEvent.ID)), "apiToken"),
+		)
+		require.True(t, os.IsNotExist(err))
+		info, err = os.Stat(s.workspaceDirectory(testEvent.ID))
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+
+		// One container for the git initializer and one for the worker
+		require.Len(t, docker.cmds, 2)
+		vcsArgs := docker.args(0)
+		require.Contains(t, vcsArgs, "tunguska.vcs")
+		require.Contains(t, vcsArgs, "brigade.sh/container=vcs")
+		require.Contains(t, vcsArgs, "brigade.sh/init-containers=vcs")
+		require.Equal(t, "git-initializer", vcsArgs[len(vcsArgs)-1])
+		workerArgs := docker.args(1)
+		require.Contains(t, workerArgs, "tunguska.worker")
+		require.Contains(t, workerArgs, "brigade.sh/container=worker")
+		require.Contains(t, workerArgs, "brigade.sh/primary-container=worker")
+		require.Contains(t, workerArgs, "brigade.sh/timeoutDuration=1h")
+		require.Contains(t, workerArgs, "brigade")
+		require.Contains(
+			t,
+			workerArgs,
+			"type=bind,source="+s.eventDetailsPath(testEvent.ID)+
+				",target=/var/event/event.json,readonly",
+		)
+		require.Equal(
+			t,
+			[]string{
+				"--entrypoint", "node", "worker", "--trace-warnings", "index.js",
+			},
+			workerArgs[len(workerArgs)-5:],
+		)
+		// Environment variable values are passed only through the environment
+		require.Contains(t, workerArgs, "FOO")
+		require.NotContains(t, strings.Join(workerArgs, " "), "bar")
+		require.Contains(t, docker.cmds[1].Env, "FOO=bar")
+
+		started := <-startedCh
+		require.Equal(t, []string{"tunguska.vcs"}, started[0])
+		require.Equal(t, []string{"tunguska.worker"}, started[1])
+	})
+}
+
+func TestSubstrateStartJob(t *testing.T) {
+	rootDir := t.TempDir()
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+	}
+	testJobSpec := api.JobSpec{
+		PrimaryContainer: api.JobContainerSpec{
+			ContainerSpec: api.ContainerSpec{
+				Image: "debian",
+				Environment: map[string]string{
+					"FOO": "bar",
+				},
+			},
+			WorkspaceMountPath: "/var/workspace",
+			SecretEnvironment: map[string]string{
+				"PASSWORD": "password",
+			},
+		},
+		SidecarContainers: map[string]api.JobContainerSpec{
+			"helper": {
+				ContainerSpec: api.ContainerSpec{
+					Image: "redis",
+				},
+				SecretMounts: []api.SecretMount{
+					{
+						Key:       "password",
+						MountPath: "/var/secrets/password",
+					},
+				},
+			},
+		},
+		TimeoutDuration: "10m",
+	}
+	testEvent := api.Event{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "tunguska",
+		},
+		ProjectID: "italian",
+		Worker: api.Worker{
+			Jobs: []api.Job{
+				{
+					Name: "foo",
+					Spec: testJobSpec,
+				},
+			},
+		},
+	}
+	docker := &fakeDocker{
+		outputFn: func([]string) (string, bool) {
+			return "", true
+		},
+	}
+	startedCh := make(chan [][]string, 1)
+	s := &substrate{
+		config: SubstrateConfig{
+			BrigadeID:     "4077th",
+			APIAddress:    "http://localhost:8080",
+			RootDirectory: rootDir,
+		},
+		dockerCommandFn: docker.command,
+		startContainersFn: func(initContainers, containers []string) {
+			startedCh <- [][]string{initContainers, containers}
+		},
+	}
+	_, err := s.CreateProject(context.Background(), testProject)
+	require.NoError(t, err)
+	err = s.StoreJobEnvironment(
+		context.Background(),
+		testProject,
+		testEvent.ID,
+		"foo",
+		testJobSpec,
+	)
+	require.NoError(t, err)
+
+	t.Run("missing project secret", func(t *testing.T) {
+		err = s.StartJob(
+			context.Background(),
+			testProject,
+			testEvent,
+			"foo",
+			"secret-token",
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `does not define secret "password"`)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		err = NewSecretsStore(rootDir).Set(
+			context.Background(),
+			testProject,
+			api.Secret{
+				Key:   "password",
+				Value: "hunter2",
+			},
+		)
+		require.NoError(t, err)
+		err = s.StartJob(
+			context.Background(),
+			testProject,
+			testEvent,
+			"foo",
+			"secret-token",
+		)
+		require.NoError(t, err)
+
+		// One container for the primary container and one for the sidecar
+		require.Len(t, docker.cmds, 2)
+		primaryArgs := docker.args(0)
+		require.Contains(t, primaryArgs, "tunguska.foo.foo")
+		require.Contains(t, primaryArgs, "brigade.sh/job=foo")
+		require.Contains(t, primaryArgs, "brigade.sh/primary-container=foo")
+		require.Contains(t, primaryArgs, "brigade.sh/timeoutDuration=10m")
+		require.Contains(
+			t,
+			primaryArgs,
+			"type=bind,source="+s.workspaceDirectory(testEvent.ID)+
+				",target=/var/workspace",
+		)
+		require.Contains(
+			t,
+			primaryArgs,
+			"type=bind,source="+
+				local.JobTerminationLogPath(rootDir, testEvent.ID, "foo")+
+				",target=/dev/termination-log",
+		)
+		env := docker.cmds[0].Env
+		require.Contains(t, env, "FOO=bar")
+		require.Contains(t, env, "PASSWORD=hunter2")
+		require.Contains(t, env, "BRIGADE_API_ADDRESS=http://localhost:8080")
+		require.Contains(t, env, "BRIGADE_API_TOKEN=secret-token")
+		require.Contains(t, env, "BRIGADE_EVENT_ID=tunguska")
+		require.Contains(t, env, "BRIGADE_JOB_NAME=foo")
+		sidecarArgs := docker.args(1)
+		require.Contains(t, sidecarArgs, "tunguska.foo.helper")
+		require.Contains(t, sidecarArgs, "brigade.sh/container=helper")
+		secretPath := filepath.Join(
+			local.JobDirectory(rootDir, testEvent.ID, "foo"),
+			"secrets",
+			"password",
+		)
+		require.Contains(
+			t,
+			sidecarArgs,
+			"type=bind,source="+secretPath+",target=/var/secrets/password,readonly",
+		)
+		info, err := os.Stat(filepath.Dir(secretPath))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+		started := <-startedCh
+		require.Empty(t, started[0])
+		require.Equal(
+			t,
+			[]string{"tunguska.foo.foo", "tunguska.foo.helper"},
+			started[1],
+		)
+	})
+}
+
+func TestSubstrateDeleteJob(t *testing.T) {
+	rootDir := t.TempDir()
+	docker := &fakeDocker{
+		outputFn: func(args []string) (string, bool) {
+			switch args[0] {
+			case "ps":
+				return "abc\ndef\n", true
+			case "inspect":
+				return `[
+					{
+						"Id": "abc",
+						"Name": "/tunguska.foo.foo",
+						"State": {"StartedAt": "2021-01-01T00:00:00Z"}
+					},
+					{
+						"Id": "def",
+						"Name": "/tunguska.foo.helper"
+					}
+				]`, true
+			case "logs":
+				return "2021-01-01T00:00:00Z hello\n", true
+			}
+			return "", true
+		},
+	}
+	s := &substrate{
+		config: SubstrateConfig{
+			BrigadeID:     "4077th",
+			RootDirectory: rootDir,
+		},
+		dockerCommandFn: docker.command,
+	}
+	jobDir := local.JobDirectory(rootDir, "tunguska", "foo")
+	require.NoError(t, os.MkdirAll(jobDir, 0700))
+	testProject := api.Project{
+		ObjectMeta: meta.ObjectMeta{
+			ID: "italian",
+		},
+	}
+	err := s.DeleteJob(
+		context.Background(),
+		testProject,
+		api.Event{
+			ObjectMeta: meta.ObjectMeta{
+				ID: "tunguska",
+			},
+		},
+		"foo",
+	)
+	require.NoError(t, err)
+	require.Contains(t, docker.args(0), "label=brigade.sh/job=foo")
+	// Only logs from the container that was started are archived
+	data, err := os.ReadFile(
+		logsArchivePath(rootDir, testProject.ID, "tunguska.foo.foo"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "2021-01-01T00:00:00Z hello\n", string(data))
+	_, err = os.Stat(
+		logsArchivePath(rootDir, testProject.ID, "tunguska.foo.helper"),
+	)
+	require.True(t, os.IsNotExist(err))
+	require.Equal(
+		t,
+		[]string{"rm", "--force", "--volumes", "abc", "def"},
+		docker.args(len(docker.cmds)-1),
+	)
+	_, err = os.Stat(jobDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSubstrateDeleteWorkerAndJobs(t *testing.T) {
+	rootDir := t.TempDir()
+	docker := &fakeDocker{
+		outputFn: func([]string) (string, bool) {
+			return "", true
+		},
+	}
+	s := &substrate{
+		config: SubstrateConfig{
+			BrigadeID:     "4077th",
+			RootDirectory: rootDir,
+		},
+		dockerCommandFn: docker.command,
+	}
+	eventDir := local.EventDirectory(rootDir, "tunguska")
+	require.NoError(t, os.MkdirAll(eventDir, 0700))
+	err := s.DeleteWorkerAndJobs(
+		context.Background(),
+		api.Project{},
+		api.Event{
+			ObjectMeta: meta.ObjectMeta{
+				ID: "tunguska",
+			},
+		},
+	)
+	require.NoError(t, err)
+	require.Contains(t, docker.args(0), "label=brigade.sh/event=tunguska")
+	_, err = os.Stat(eventDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSubstrateCreateContainers(t *testing.T) {
+	docker := &fakeDocker{
+		outputFn: func(args []string) (string, bool) {
+			// Fail to create the second container
+			return "", args[0] != "create" || args[2] != "tunguska.bar"
+		},
+	}
+	s := &substrate{
+		dockerCommandFn: docker.command,
+	}
+	_, _, err := s.createContainers(
+		context.Background(),
+		map[string]string{},
+		func(containerName string) string {
+			return local.WorkerContainerName("tunguska", containerName)
+		},
+		[]container{{name: "foo"}},
+		[]container{{name: "bar"}, {name: "bat"}},
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `error creating container "tunguska.bar"`)
+	// The container that was created is removed and no others are created
+	require.Len(t, docker.cmds, 3)
+	require.Equal(
+		t,
+		[]string{"rm", "--force", "--volumes", "tunguska.foo"},
+		docker.args(2),
+	)
+}
+
+func TestSubstrateStartContainers(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statuses   map[string]string
+		exitCode   string
+		assertions func(*fakeDocker)
+	}{
+		{
+			name: "init container fails",
+			statuses: map[string]string{
+				"foo": "created",
+			},
+			exitCode: "1",
+			assertions: func(docker *fakeDocker) {
+				require.Len(t, docker.cmds, 3)
+				require.Equal(t, []string{"start", "foo"}, docker.args(1))
+				require.Equal(t, []string{"wait", "foo"}, docker.args(2))
+			},
+		},
+		{
+			name: "init container succeeds",
+			statuses: map[string]string{
+				"foo": "created",
+				"bar": "created",
+				"bat": "created",
+			},
+			exitCode: "0",
+			assertions: func(docker *fakeDocker) {
+				require.Len(t, docker.cmds, 6)
+				require.Equal(t, []string{"start", "foo"}, docker.args(1))
+				require.Equal(t, []string{"wait", "foo"}, docker.args(2))
+				require.Equal(t, []string{"start", "bar", "bat"}, docker.args(5))
+			},
+		},
+		{
+			name: "containers partially started",
+			statuses: map[string]string{
+				"foo": "exited",
+				"bar": "running",
+				"bat": "created",
+			},
+			exitCode: "0",
+			assertions: func(docker *fakeDocker) {
+				// The init container is not started again
+				require.Len(t, docker.cmds, 5)
+				require.Equal(t, []string{"wait", "foo"}, docker.args(1))
+				require.Equal(t, []string{"start", "bat"}, docker.args(4))
+			},
+		},
+		{
+			name: "containers already started",
+			statuses: map[string]string{
+				"foo": "exited",
+				"bar": "running",
+				"bat": "exited",
+			},
+			exitCode: "0",
+			assertions: func(docker *fakeDocker) {
+				require.Len(t, docker.cmds, 4)
+				require.Equal(t, "inspect", docker.args(3)[0])
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			docker := &fakeDocker{
+				outputFn: func(args []string) (string, bool) {
+					switch args[0] {
+					case "inspect":
+						return testCase.statuses[args[len(args)-1]] + "\n", true
+					case "wait":
+						return testCase.exitCode + "\n", true
+					}
+					return "", true
+				},
+			}
+			s := &substrate{
+				dockerCommandFn: docker.command,
+			}
+			s.startContainers([]string{"foo"}, []string{"bar", "bat"})
+			require.Equal(
+				t,
+				[]string{"inspect", "--format", "{{.State.Status}}", "foo"},
+				docker.args(0),
+			)
+			testCase.assertions(docker)
+		})
+	}
+}
+
+func TestSubstrateResumeContainers(t *testing.T) {
+	docker := &fakeDocker{
+		outputFn: func(args []string) (string, bool) {
+			switch args[0] {
+			case "ps":
+				return "a\nb\nc\nd\ne\nf\n", true
+			case "inspect":
+				labels := func(job, container string) string {
+					return fmt.Sprintf(
+						`{
+							"brigade.sh/event": "tunguska",
+							"brigade.sh/job": %q,
+							"brigade.sh/container": %q,
+							"brigade.sh/primary-container": %q,
+							"brigade.sh/init-containers": "vcs,setup"
+						}`,
+						job,
+						container,
+						job,
+					)
+				}
+				return fmt.Sprintf(
+					`[
+						{
+							"Id": "a",
+							"Name": "/tunguska.foo.foo",
+							"Config": {"Labels": %s},
+							"State": {"Status": "created"}
+						},
+						{
+							"Id": "b",
+							"Name": "/tunguska.foo.setup",
+							"Config": {"Labels": %s},
+							"State": {"Status": "running"}
+						},
+						{
+							"Id": "c",
+							"Name": "/tunguska.foo.vcs",
+							"Config": {"Labels": %s},
+							"State": {"Status": "exited"}
+						},
+						{
+							"Id": "d",
+							"Name": "/tunguska.bar.bar",
+							"Config": {"Labels": %s},
+							"State": {"Status": "running"}
+						},
+						{
+							"Id": "e",
+							"Name": "/tunguska.baz.vcs",
+							"Config": {"Labels": %s},
+							"State": {"Status": "created"}
+						},
+						{
+							"Id": "f",
+							"Name": "/tunguska.foo.helper",
+							"Config": {"Labels": %s},
+							"State": {"Status": "created"}
+						}
+					]`,
+					labels("foo", "foo"),
+					labels("foo", "setup"),
+					labels("foo", "vcs"),
+					labels("bar", "bar"),
+					labels("baz", "vcs"),
+					labels("foo", "helper"),
+				), true
+			}
+			return "", true
+		},
+	}
+	startedCh := make(chan [][]string, 3)
+	s := &substrate{
+		config: SubstrateConfig{
+			BrigadeID: "4077th",
+		},
+		dockerCommandFn: docker.command,
+		startContainersFn: func(initContainers, containers []string) {
+			startedCh <- [][]string{initContainers, containers}
+		},
+	}
+	err := s.resumeContainers(context.Background())
+	require.NoError(t, err)
+	// Job "bar" was fully started and job "baz" was never fully created, so only
+	// job "foo" is resumed
+	started := <-startedCh
+	require.Equal(
+		t,
+		[]string{"tunguska.foo.vcs", "tunguska.foo.setup"},
+		started[0],
+	)
+	require.Equal(
+		t,
+		[]string{"tunguska.foo.foo", "tunguska.foo.helper"},
+		started[1],
+	)
+	select {
+	case started = <-startedCh:
+		require.Fail(t, "unexpected containers started", started)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDockerPullPolicy(t *testing.T) {
+	require.Equal(t, "always", dockerPullPolicy("Always"))
+	require.Equal(t, "never", dockerPullPolicy("Never"))
+	require.Equal(t, "missing", dockerPullPolicy("IfNotPresent"))
+	require.Equal(t, "missing", dockerPullPolicy(""))
+}
+
+// fakeDocker stands in for the Docker CLI. It records every command that is
+// prepared and, instead of invoking docker, runs a command that simply writes
+// the output returned by outputFn for the same arguments and then exits
+// successfully or not.
+type fakeDocker struct {
+	mu       sync.Mutex
+	cmds     []*exec.Cmd
+	argsList [][]string
+	outputFn func(args []string) (string, bool)
+}
+
+func (f *fakeDocker) command(_ context.Context, args ...string) *exec.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	output, ok := f.outputFn(args)
+	script := `printf '%s' "$0"`
+	if !ok {
+		script = `printf '%s' "$0" >&2; exit 1`
+	}
+	cmd := exec.Command("sh", "-c", script, output)
+	f.cmds = append(f.cmds, cmd)
+	f.argsList = append(f.argsList, args)
+	return cmd
+}
+
+func (f *fakeDocker) args(i int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.argsList[i]
+}
@@ -196,16 +196,32 @@ func (l *logsService) Stream(
 					}
 				}
 			}
+			if !containerFound {
+				// Lastly, check if any of the init containers do.
+				for _, containerSpec := range job.Spec.InitContainers {
+					if containerSpec.SourceMountPath != "" {
+						containerFound = true
+						break
+					}
+				}
+			}
 		} else {
 			// If we get to here, the container name didn't match the job name (which
 			// is also the name of the primary container) and it wasn't "vcs" either.
-			// Just loop through the sidecars to see if such a container exists.
+			// Just loop through the sidecars and init containers to see if such a
+			// container exists.
 			for containerName := range job.Spec.SidecarContainers {
 				if containerName == selector.Container {
 					containerFound = true
 					break
 				}
 			}
+			for _, initContainer := range job.Spec.InitContainers {
+				if initContainer.Name == selector.Container {
+					containerFound = true
+					break
+				}
+			}
 		}
 		if !containerFound {
 			return nil, &meta.ErrNotFound{
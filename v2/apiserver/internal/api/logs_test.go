@@ -293,6 +293,60 @@ func TestLogsServiceStream(t *testing.T) {
 				require.NotNil(t, logCh)
 			},
 		},
+		{
+			name: "init container logs succeed",
+			selector: LogsSelector{
+				Job:       "foo",
+				Container: "bar",
+			},
+			service: &logsService{
+				projectAuthorize: alwaysProjectAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Jobs: []Job{
+									{
+										Name: "foo",
+										Spec: JobSpec{
+											InitContainers: []JobInitContainerSpec{
+												{
+													Name: "bar",
+												},
+											},
+										},
+										Status: &JobStatus{
+											Phase: JobPhaseRunning,
+										},
+									},
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				warmLogsStore: &mockLogsStore{
+					StreamLogsFn: func(
+						_ context.Context,
+						_ Project,
+						_ Event,
+						selector LogsSelector,
+						_ LogStreamOptions,
+					) (<-chan LogEntry, error) {
+						require.Equal(t, "bar", selector.Container)
+						return make(chan LogEntry), nil
+					},
+				},
+			},
+			assertions: func(logCh <-chan LogEntry, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, logCh)
+			},
+		},
 		{
 			name:     "warm logs store has unexpected error",
 			selector: LogsSelector{},
@@ -30,6 +30,7 @@ func NewEventsStore(database *mongo.Database) (api.EventsStore, error) {
 		context.WithTimeout(context.Background(), createIndexTimeout)
 	defer cancel()
 	unique := true
+	sparse := true
 	collection := database.Collection("events")
 	if _, err := collection.Indexes().CreateMany(
 		ctx,
@@ -42,6 +43,17 @@ func NewEventsStore(database *mongo.Database) (api.EventsStore, error) {
 					Unique: &unique,
 				},
 			},
+			// Jobs' tokens are looked up whenever a request is authenticated, so
+			// this lookup needs to be fast. Only Jobs that have been started have
+			// a token.
+			{
+				Keys: bson.M{
+					"worker.jobs.hashedToken": 1,
+				},
+				Options: &options.IndexOptions{
+					Sparse: &sparse,
+				},
+			},
 		},
 	); err != nil {
 		return nil, errors.Wrap(err, "error adding indexes to events collection")
@@ -207,6 +219,32 @@ func (e *eventsStore) GetByHashedWorkerToken(
 	return event, nil
 }
 
+func (e *eventsStore) GetByHashedJobToken(
+	ctx context.Context,
+	hashedJobToken string,
+) (api.Event, error) {
+	event := api.Event{}
+	res := e.collection.FindOne(
+		ctx,
+		bson.M{
+			"worker.jobs.hashedToken": hashedJobToken,
+			"deleted": bson.M{
+				"$exists": false, // Don't grab logically deleted events
+			},
+		},
+	)
+	err := res.Decode(&event)
+	if res.Err() == mongo.ErrNoDocuments {
+		return event, &meta.ErrNotFound{
+			Type: api.EventKind,
+		}
+	}
+	if err != nil {
+		return event, errors.Wrap(err, "error finding/decoding event")
+	}
+	return event, nil
+}
+
 func (e *eventsStore) UpdateSourceState(
 	ctx context.Context,
 	id string,
@@ -311,6 +349,7 @@ func (e *eventsStore) Cancel(ctx context.Context, id string) error {
 				"$in": []api.WorkerPhase{
 					api.WorkerPhaseStarting,
 					api.WorkerPhaseRunning,
+					api.WorkerPhaseSuspended,
 				},
 			},
 		},
@@ -357,31 +396,117 @@ func (e *eventsStore) Cancel(ctx context.Context, id string) error {
 	return nil
 }
 
+func (e *eventsStore) Suspend(ctx context.Context, id string) error {
+	// Only a starting or running Worker can be suspended. The phase the Worker
+	// is suspended from is recorded so that it can be restored when the Worker
+	// is resumed. Each update is conditional on the Worker's exact phase, so a
+	// phase change that races with the suspension cannot be lost.
+	for _, phase := range []api.WorkerPhase{
+		api.WorkerPhaseStarting,
+		api.WorkerPhaseRunning,
+	} {
+		res, err := e.collection.UpdateOne(
+			ctx,
+			bson.M{
+				"id":                  id,
+				"worker.status.phase": phase,
+			},
+			bson.M{
+				"$set": bson.M{
+					"worker.status.phase":       api.WorkerPhaseSuspended,
+					"worker.status.resumePhase": phase,
+				},
+			},
+		)
+		if err != nil {
+			return errors.Wrapf(err, "error updating status of event %q worker", id)
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
+	}
+
+	return &meta.ErrConflict{
+		Type: api.EventKind,
+		ID:   id,
+		Reason: fmt.Sprintf(
+			"Event %q was not suspended because its worker was not starting "+
+				"or running.",
+			id,
+		),
+	}
+}
+
+func (e *eventsStore) Resume(ctx context.Context, id string) error {
+	// Restore the phase the Worker was suspended from. Events suspended before
+	// that phase was recorded have no resumePhase and are resumed as running.
+	for _, phase := range []api.WorkerPhase{
+		api.WorkerPhaseStarting,
+		api.WorkerPhaseRunning,
+	} {
+		resumePhases := []interface{}{phase}
+		if phase == api.WorkerPhaseRunning {
+			resumePhases = append(resumePhases, nil)
+		}
+		res, err := e.collection.UpdateOne(
+			ctx,
+			bson.M{
+				"id":                  id,
+				"worker.status.phase": api.WorkerPhaseSuspended,
+				"worker.status.resumePhase": bson.M{
+					"$in": resumePhases,
+				},
+			},
+			bson.M{
+				"$set": bson.M{
+					"worker.status.phase": phase,
+				},
+				"$unset": bson.M{
+					"worker.status.resumePhase": "",
+				},
+			},
+		)
+		if err != nil {
+			return errors.Wrapf(err, "error updating status of event %q worker", id)
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
+	}
+
+	return &meta.ErrConflict{
+		Type: api.EventKind,
+		ID:   id,
+		Reason: fmt.Sprintf(
+			"Event %q was not resumed because it was not suspended.",
+			id,
+		),
+	}
+}
+
 // nolint: gocyclo
 func (e *eventsStore) CancelMany(
 	ctx context.Context,
 	selector api.EventsSelector,
 ) (<-chan api.Event, int64, error) {
 	var affectedCount int64
-	// It only makes sense to cancel events that are in a pending, starting, or
-	// running state. We can ignore anything else.
+	// It only makes sense to cancel events that are in a pending, starting,
+	// running, or suspended state. We can ignore anything else.
 	var cancelPending bool
-	var cancelStarting bool
-	var cancelRunning bool
+	abortPhases := []api.WorkerPhase{}
 	for _, workerPhase := range selector.WorkerPhases {
-		if workerPhase == api.WorkerPhasePending {
+		switch workerPhase {
+		case api.WorkerPhasePending:
 			cancelPending = true
-		}
-		if workerPhase == api.WorkerPhaseStarting {
-			cancelStarting = true
-		}
-		if workerPhase == api.WorkerPhaseRunning {
-			cancelRunning = true
+		case api.WorkerPhaseStarting, api.WorkerPhaseRunning,
+			api.WorkerPhaseSuspended:
+			abortPhases = append(abortPhases, workerPhase)
 		}
 	}
 
-	// Bail if we're not canceling pending, starting, or running events
-	if !cancelPending && !cancelStarting && !cancelRunning {
+	// Bail if we're not canceling pending, starting, running, or suspended
+	// events
+	if !cancelPending && len(abortPhases) == 0 {
 		return nil, 0, nil
 	}
 
@@ -425,20 +550,10 @@ func (e *eventsStore) CancelMany(
 		affectedCount = affectedCount + result.ModifiedCount
 	}
 
-	if cancelStarting && cancelRunning {
+	if len(abortPhases) > 0 {
 		criteria["worker.status.phase"] = bson.M{
-			"$in": []api.WorkerPhase{
-				api.WorkerPhaseStarting,
-				api.WorkerPhaseRunning,
-			},
+			"$in": abortPhases,
 		}
-	} else if cancelStarting {
-		criteria["worker.status.phase"] = api.WorkerPhaseStarting
-	} else if cancelRunning {
-		criteria["worker.status.phase"] = api.WorkerPhaseRunning
-	}
-
-	if cancelStarting || cancelRunning {
 		result, err := e.collection.UpdateMany(
 			ctx,
 			criteria,
@@ -13,6 +13,7 @@ import (
 	mongoTesting "github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb/testing" // nolint: lll
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -710,6 +711,216 @@ func TestEventsStoreCancel(t *testing.T) {
 	}
 }
 
+func TestEventsStoreSuspend(t *testing.T) {
+	const testEventID = "abcedfg"
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+		{
+			name: "error updating event",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error updating status of event")
+				require.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			name: "event not suspended",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 0,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "was not suspended")
+			},
+		},
+		{
+			name: "event suspended",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 1,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "running event suspended",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					_ context.Context,
+					filter interface{},
+					update interface{},
+					_ ...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					phase := filter.(bson.M)["worker.status.phase"]
+					if phase != api.WorkerPhaseRunning {
+						return &mongo.UpdateResult{MatchedCount: 0}, nil
+					}
+					set := update.(bson.M)["$set"].(bson.M)
+					require.Equal(
+						t,
+						api.WorkerPhaseSuspended,
+						set["worker.status.phase"],
+					)
+					require.Equal(
+						t,
+						api.WorkerPhaseRunning,
+						set["worker.status.resumePhase"],
+					)
+					return &mongo.UpdateResult{MatchedCount: 1}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &eventsStore{
+				collection: testCase.collection,
+			}
+			err := store.Suspend(context.Background(), testEventID)
+			testCase.assertions(err)
+		})
+	}
+}
+
+func TestEventsStoreResume(t *testing.T) {
+	const testEventID = "abcedfg"
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+		{
+			name: "error updating event",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error updating status of event")
+				require.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			name: "event not resumed",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 0,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+				require.Contains(t, err.Error(), "was not resumed")
+			},
+		},
+		{
+			name: "event resumed",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 1,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "event resumed to starting",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					_ context.Context,
+					filter interface{},
+					update interface{},
+					_ ...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					resumePhases :=
+						filter.(bson.M)["worker.status.resumePhase"].(bson.M)["$in"]
+					require.Equal(
+						t,
+						[]interface{}{api.WorkerPhaseStarting},
+						resumePhases,
+					)
+					set := update.(bson.M)["$set"].(bson.M)
+					require.Equal(
+						t,
+						api.WorkerPhaseStarting,
+						set["worker.status.phase"],
+					)
+					return &mongo.UpdateResult{MatchedCount: 1}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &eventsStore{
+				collection: testCase.collection,
+			}
+			err := store.Resume(context.Background(), testEventID)
+			testCase.assertions(err)
+		})
+	}
+}
+
 func TestEventsStoreCancelMany(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -0,0 +1,102 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// execAuditStore is a MongoDB-based implementation of the api.ExecAuditStore
+// interface.
+type execAuditStore struct {
+	collection mongodb.Collection
+}
+
+// NewExecAuditStore returns a MongoDB-based implementation of the
+// api.ExecAuditStore interface.
+func NewExecAuditStore(database *mongo.Database) (api.ExecAuditStore, error) {
+	ctx, cancel :=
+		context.WithTimeout(context.Background(), createIndexTimeout)
+	defer cancel()
+	unique := true
+	collection := database.Collection("execAuditRecords")
+	if _, err := collection.Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{
+				Keys: bson.M{
+					"id": 1,
+				},
+				Options: &options.IndexOptions{
+					Unique: &unique,
+				},
+			},
+			// Audit records are most likely to be looked up by event
+			{
+				Keys: bson.M{
+					"eventID": 1,
+				},
+			},
+		},
+	); err != nil {
+		return nil, errors.Wrap(
+			err,
+			"error adding indexes to exec audit records collection",
+		)
+	}
+	return &execAuditStore{
+		collection: collection,
+	}, nil
+}
+
+func (e *execAuditStore) Create(
+	ctx context.Context,
+	record api.ExecAuditRecord,
+) error {
+	if _, err := e.collection.InsertOne(ctx, record); err != nil {
+		return errors.Wrapf(
+			err,
+			"error inserting new exec audit record %q",
+			record.ID,
+		)
+	}
+	return nil
+}
+
+func (e *execAuditStore) End(
+	ctx context.Context,
+	id string,
+	ended time.Time,
+	exitCode int,
+	errMsg string,
+) error {
+	set := bson.M{
+		"ended":    ended,
+		"exitCode": exitCode,
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+	res, err := e.collection.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error updating exec audit record %q", id)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: api.ExecAuditRecordKind,
+			ID:   id,
+		}
+	}
+	return nil
+}
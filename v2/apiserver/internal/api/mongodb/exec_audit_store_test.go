@@ -0,0 +1,175 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
+	mongoTesting "github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb/testing" // nolint: lll
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestExecAuditStoreCreate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+
+		{
+			name: "unanticipated error",
+			collection: &mongoTesting.MockCollection{
+				InsertOneFn: func(
+					ctx context.Context,
+					document interface{},
+					opts ...*options.InsertOneOptions,
+				) (*mongo.InsertOneResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(
+					t,
+					err.Error(),
+					"error inserting new exec audit record",
+				)
+			},
+		},
+
+		{
+			name: "successful creation",
+			collection: &mongoTesting.MockCollection{
+				InsertOneFn: func(
+					ctx context.Context,
+					document interface{},
+					opts ...*options.InsertOneOptions,
+				) (*mongo.InsertOneResult, error) {
+					return nil, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &execAuditStore{
+				collection: testCase.collection,
+			}
+			err := store.Create(context.Background(), api.ExecAuditRecord{})
+			testCase.assertions(err)
+		})
+	}
+}
+
+func TestExecAuditStoreEnd(t *testing.T) {
+	const testRecordID = "12345"
+	testEnded := time.Now().UTC()
+
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+
+		{
+			name: "record not found",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					ctx context.Context,
+					filter interface{},
+					update interface{},
+					opts ...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 0,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				enf, ok := err.(*meta.ErrNotFound)
+				require.True(t, ok)
+				require.Equal(t, api.ExecAuditRecordKind, enf.Type)
+				require.Equal(t, testRecordID, enf.ID)
+			},
+		},
+
+		{
+			name: "unanticipated error",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					ctx context.Context,
+					filter interface{},
+					update interface{},
+					opts ...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating exec audit record")
+			},
+		},
+
+		{
+			name: "record found",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					ctx context.Context,
+					filter interface{},
+					update interface{},
+					opts ...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					require.Equal(t, bson.M{"id": testRecordID}, filter)
+					require.Equal(
+						t,
+						bson.M{
+							"$set": bson.M{
+								"ended":    testEnded,
+								"exitCode": 42,
+								"error":    "something went wrong",
+							},
+						},
+						update,
+					)
+					return &mongo.UpdateResult{
+						MatchedCount: 1,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &execAuditStore{
+				collection: testCase.collection,
+			}
+			err := store.End(
+				context.Background(),
+				testRecordID,
+				testEnded,
+				42,
+				"something went wrong",
+			)
+			testCase.assertions(err)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // jobsStore is a MongoDB-based implementation of the api.JobsStore interface.
@@ -20,8 +21,28 @@ type jobsStore struct {
 // NewJobsStore returns a MongoDB-based implementation of the api.JobsStore
 // interface.
 func NewJobsStore(database *mongo.Database) (api.JobsStore, error) {
+	ctx, cancel :=
+		context.WithTimeout(context.Background(), createIndexTimeout)
+	defer cancel()
+	sparse := true
+	collection := database.Collection("events")
+	if _, err := collection.Indexes().CreateOne(
+		ctx,
+		// Cached results are looked up whenever a Job having a cache policy is
+		// created. Only such Jobs have a cache key.
+		mongo.IndexModel{
+			Keys: bson.M{
+				"worker.jobs.cacheKey": 1,
+			},
+			Options: &options.IndexOptions{
+				Sparse: &sparse,
+			},
+		},
+	); err != nil {
+		return nil, errors.Wrap(err, "error adding indexes to events collection")
+	}
 	return &jobsStore{
-		collection: database.Collection("events"),
+		collection: collection,
 	}, nil
 }
 
@@ -56,6 +77,56 @@ func (j *jobsStore) Create(
 	return nil
 }
 
+func (j *jobsStore) GetByCacheKey(
+	ctx context.Context,
+	projectID string,
+	cacheKey string,
+) (api.Event, error) {
+	event := api.Event{}
+	findOptions := options.FindOne()
+	findOptions.SetSort(bson.M{"created": -1})
+	// Only project the first Job matching the $elemMatch criteria below
+	findOptions.SetProjection(
+		bson.M{
+			"id":            1,
+			"projectID":     1,
+			"created":       1,
+			"worker.jobs.$": 1,
+		},
+	)
+	res := j.collection.FindOne(
+		ctx,
+		bson.M{
+			"projectID": projectID,
+			"deleted": bson.M{
+				"$exists": false, // Don't grab logically deleted events
+			},
+			"worker.jobs": bson.M{
+				"$elemMatch": bson.M{
+					"cacheKey":     cacheKey,
+					"status.phase": api.JobPhaseSucceeded,
+				},
+			},
+		},
+		findOptions,
+	)
+	err := res.Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return event, &meta.ErrNotFound{
+			Type: api.JobKind,
+			ID:   cacheKey,
+		}
+	}
+	if err != nil {
+		return event, errors.Wrapf(
+			err,
+			"error finding/decoding event with cached job %q",
+			cacheKey,
+		)
+	}
+	return event, nil
+}
+
 func (j *jobsStore) UpdateStatus(
 	ctx context.Context,
 	eventID string,
@@ -90,3 +161,73 @@ func (j *jobsStore) UpdateStatus(
 	}
 	return nil
 }
+
+func (j *jobsStore) UpdateHashedToken(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	hashedToken string,
+) error {
+	res, err := j.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"id":               eventID,
+			"worker.jobs.name": jobName,
+		},
+		bson.M{
+			"$set": bson.M{
+				"worker.jobs.$.hashedToken": hashedToken,
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating event %q job %q hashed token",
+			eventID,
+			jobName,
+		)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: api.JobKind,
+			ID:   fmt.Sprintf("%s:%s", eventID, jobName),
+		}
+	}
+	return nil
+}
+
+func (j *jobsStore) UpdateProgress(
+	ctx context.Context,
+	eventID string,
+	jobName string,
+	progress api.JobProgress,
+) error {
+	res, err := j.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"id":               eventID,
+			"worker.jobs.name": jobName,
+		},
+		bson.M{
+			"$set": bson.M{
+				"worker.jobs.$.status.progress": progress,
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating progress of event %q job %q",
+			eventID,
+			jobName,
+		)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: api.JobKind,
+			ID:   fmt.Sprintf("%s:%s", eventID, jobName),
+		}
+	}
+	return nil
+}
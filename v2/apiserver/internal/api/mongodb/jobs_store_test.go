@@ -96,6 +96,107 @@ func TestJobsStoreCreate(t *testing.T) {
 	}
 }
 
+func TestJobsStoreGetByCacheKey(t *testing.T) {
+	const testProjectID = "blue-book"
+	const testCacheKey = "abcdefg"
+	const testEventID = "123456789"
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(api.Event, error)
+	}{
+		{
+			name: "no cached job found",
+			collection: &mongoTesting.MockCollection{
+				FindOneFn: func(
+					ctx context.Context,
+					filter interface{},
+					opts ...*options.FindOneOptions,
+				) *mongo.SingleResult {
+					res, err := mongoTesting.MockSingleResult(mongo.ErrNoDocuments)
+					require.NoError(t, err)
+					return res
+				},
+			},
+			assertions: func(_ api.Event, err error) {
+				require.Error(t, err)
+				enf, ok := err.(*meta.ErrNotFound)
+				require.True(t, ok)
+				require.Equal(t, api.JobKind, enf.Type)
+				require.Equal(t, testCacheKey, enf.ID)
+			},
+		},
+		{
+			name: "unanticipated error",
+			collection: &mongoTesting.MockCollection{
+				FindOneFn: func(
+					ctx context.Context,
+					filter interface{},
+					opts ...*options.FindOneOptions,
+				) *mongo.SingleResult {
+					res, err := mongoTesting.MockSingleResult(
+						errors.New("something went wrong"),
+					)
+					require.NoError(t, err)
+					return res
+				},
+			},
+			assertions: func(_ api.Event, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error finding/decoding event")
+			},
+		},
+		{
+			name: "cached job found",
+			collection: &mongoTesting.MockCollection{
+				FindOneFn: func(
+					ctx context.Context,
+					filter interface{},
+					opts ...*options.FindOneOptions,
+				) *mongo.SingleResult {
+					res, err := mongoTesting.MockSingleResult(
+						api.Event{
+							ObjectMeta: meta.ObjectMeta{
+								ID: testEventID,
+							},
+							Worker: api.Worker{
+								Jobs: []api.Job{
+									{
+										CacheKey: testCacheKey,
+									},
+								},
+							},
+						},
+					)
+					require.NoError(t, err)
+					return res
+				},
+			},
+			assertions: func(event api.Event, err error) {
+				require.NoError(t, err)
+				require.Equal(t, testEventID, event.ID)
+				require.Len(t, event.Worker.Jobs, 1)
+				require.Equal(t, testCacheKey, event.Worker.Jobs[0].CacheKey)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &jobsStore{
+				collection: testCase.collection,
+			}
+			testCase.assertions(
+				store.GetByCacheKey(
+					context.Background(),
+					testProjectID,
+					testCacheKey,
+				),
+			)
+		})
+	}
+}
+
 func TestJobsStoreUpdateStatus(t *testing.T) {
 	const testEvent = "123456789"
 	const testJobName = "italian"
@@ -177,3 +278,85 @@ func TestJobsStoreUpdateStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestJobsStoreUpdateProgress(t *testing.T) {
+	const testEvent = "123456789"
+	const testJobName = "italian"
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+		{
+			name: "unanticipated error",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating progress of event")
+			},
+		},
+
+		{
+			name: "event not found",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 0,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+
+		{
+			name: "success",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 1,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &jobsStore{
+				collection: testCase.collection,
+			}
+			err := store.UpdateProgress(
+				context.Background(),
+				testEvent,
+				testJobName,
+				api.JobProgress{},
+			)
+			testCase.assertions(err)
+		})
+	}
+}
@@ -247,16 +247,34 @@ func (p *projectsStore) Get(
 func (p *projectsStore) Update(
 	ctx context.Context, project api.Project,
 ) error {
+	set := bson.M{
+		"description": project.Description,
+		"spec":        project.Spec,
+	}
+	// The Project's namespace is never updated, but its substrate-specific
+	// overrides are
+	kubernetes := project.Kubernetes
+	if kubernetes == nil {
+		kubernetes = &api.KubernetesDetails{}
+	}
+	set["kubernetes.workspaceStorageClass"] = kubernetes.WorkspaceStorageClass
+	set["kubernetes.nodeSelector"] = kubernetes.NodeSelector
+	set["kubernetes.tolerations"] = kubernetes.Tolerations
+	set["kubernetes.resourceQuota"] = kubernetes.ResourceQuota
+	set["kubernetes.limitRange"] = kubernetes.LimitRange
+	set["kubernetes.networkPolicy"] = kubernetes.NetworkPolicy
+	set["kubernetes.workerPodOverlay"] = kubernetes.WorkerPodOverlay
+	set["kubernetes.jobPodOverlay"] = kubernetes.JobPodOverlay
+	set["kubernetes.jobServiceAccounts"] = kubernetes.JobServiceAccounts
+	set["kubernetes.defaultJobServiceAccount"] =
+		kubernetes.DefaultJobServiceAccount
 	res, err := p.collection.UpdateOne(
 		ctx,
 		bson.M{
 			"id": project.ID,
 		},
 		bson.M{
-			"$set": bson.M{
-				"description": project.Description,
-				"spec":        project.Spec,
-			},
+			"$set": set,
 		},
 	)
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	mongoTesting "github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb/testing" // nolint: lll
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -442,6 +443,15 @@ func TestProjectsStoreUpdate(t *testing.T) {
 					update interface{},
 					opts ...*options.UpdateOptions,
 				) (*mongo.UpdateResult, error) {
+					set, ok := update.(bson.M)["$set"].(bson.M)
+					require.True(t, ok)
+					// Substrate-specific overrides are updated, but the namespace
+					// never is
+					require.Contains(t, set, "kubernetes.nodeSelector")
+					require.Contains(t, set, "kubernetes.networkPolicy")
+					require.Contains(t, set, "kubernetes.jobPodOverlay")
+					require.Contains(t, set, "kubernetes.jobServiceAccounts")
+					require.NotContains(t, set, "kubernetes.namespace")
 					return &mongo.UpdateResult{
 						MatchedCount: 1,
 					}, nil
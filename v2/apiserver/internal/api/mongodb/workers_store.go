@@ -58,6 +58,74 @@ func (w *workersStore) UpdateStatus(
 	return nil
 }
 
+func (w *workersStore) UpdateStatusFromPhase(
+	ctx context.Context,
+	eventID string,
+	fromPhase api.WorkerPhase,
+	status api.WorkerStatus,
+) error {
+	// Fields are set individually rather than replacing the whole status so
+	// that a heartbeat recorded concurrently is not lost
+	set := bson.M{
+		"worker.status.phase": status.Phase,
+	}
+	unset := bson.M{}
+	if status.Started != nil {
+		set["worker.status.started"] = status.Started
+	} else {
+		unset["worker.status.started"] = ""
+	}
+	if status.Ended != nil {
+		set["worker.status.ended"] = status.Ended
+	} else {
+		unset["worker.status.ended"] = ""
+	}
+	if status.LastHeartbeat != nil {
+		set["worker.status.lastHeartbeat"] = status.LastHeartbeat
+	}
+	if status.Reason != "" {
+		set["worker.status.reason"] = status.Reason
+	} else {
+		unset["worker.status.reason"] = ""
+	}
+	if status.ResumePhase != "" {
+		set["worker.status.resumePhase"] = status.ResumePhase
+	} else {
+		unset["worker.status.resumePhase"] = ""
+	}
+	res, err := w.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"id":                  eventID,
+			"worker.status.phase": fromPhase,
+		},
+		bson.M{
+			"$set":   set,
+			"$unset": unset,
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating status of event %q worker",
+			eventID,
+		)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrConflict{
+			Type: api.EventKind,
+			ID:   eventID,
+			Reason: fmt.Sprintf(
+				"Event %q worker status was not updated because its phase is no "+
+					"longer %s.",
+				eventID,
+				fromPhase,
+			),
+		}
+	}
+	return nil
+}
+
 func (w *workersStore) UpdateHashedToken(
 	ctx context.Context,
 	eventID string,
@@ -88,6 +156,36 @@ func (w *workersStore) UpdateHashedToken(
 	return nil
 }
 
+func (w *workersStore) UpdateHeartbeat(
+	ctx context.Context,
+	eventID string,
+	heartbeat time.Time,
+) error {
+	res, err := w.collection.UpdateOne(
+		ctx,
+		bson.M{"id": eventID},
+		bson.M{
+			"$set": bson.M{
+				"worker.status.lastHeartbeat": heartbeat,
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating event %q worker heartbeat",
+			eventID,
+		)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: api.EventKind,
+			ID:   eventID,
+		}
+	}
+	return nil
+}
+
 func (w *workersStore) Timeout(
 	ctx context.Context,
 	eventID string,
@@ -102,6 +200,7 @@ func (w *workersStore) Timeout(
 				"$in": []api.WorkerPhase{
 					api.WorkerPhaseStarting,
 					api.WorkerPhaseRunning,
+					api.WorkerPhaseSuspended,
 				},
 			},
 		},
@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
@@ -10,6 +11,7 @@ import (
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -91,6 +93,114 @@ func TestWorkersStoreUpdateStatus(t *testing.T) {
 	}
 }
 
+func TestWorkersStoreUpdateStatusFromPhase(t *testing.T) {
+	const testEvent = "123456789"
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+		{
+			name: "unanticipated error",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating status of event")
+			},
+		},
+
+		{
+			name: "phase has changed",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 0,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+			},
+		},
+
+		{
+			name: "success",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					_ context.Context,
+					filter interface{},
+					update interface{},
+					_ ...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					require.Equal(
+						t,
+						bson.M{
+							"id":                  testEvent,
+							"worker.status.phase": api.WorkerPhaseSuspended,
+						},
+						filter,
+					)
+					require.Equal(
+						t,
+						bson.M{
+							"$set": bson.M{
+								"worker.status.phase":       api.WorkerPhaseSuspended,
+								"worker.status.resumePhase": api.WorkerPhaseRunning,
+							},
+							// The last heartbeat is not touched
+							"$unset": bson.M{
+								"worker.status.started": "",
+								"worker.status.ended":   "",
+								"worker.status.reason":  "",
+							},
+						},
+						update,
+					)
+					return &mongo.UpdateResult{
+						MatchedCount: 1,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &workersStore{
+				collection: testCase.collection,
+			}
+			err := store.UpdateStatusFromPhase(
+				context.Background(),
+				testEvent,
+				api.WorkerPhaseSuspended,
+				api.WorkerStatus{
+					Phase:       api.WorkerPhaseSuspended,
+					ResumePhase: api.WorkerPhaseRunning,
+				},
+			)
+			testCase.assertions(err)
+		})
+	}
+}
+
 func TestWorkersStoreUpdateHashedToken(t *testing.T) {
 	const testEvent = "123456789"
 	const testHashedToken = "a fake hashed token" // nolint: gosec
@@ -172,6 +282,86 @@ func TestWorkersStoreUpdateHashedToken(t *testing.T) {
 	}
 }
 
+func TestWorkersStoreUpdateHeartbeat(t *testing.T) {
+	const testEvent = "123456789"
+	testCases := []struct {
+		name       string
+		collection mongodb.Collection
+		assertions func(err error)
+	}{
+		{
+			name: "unanticipated error",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return nil, errors.New("something went wrong")
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating event")
+			},
+		},
+
+		{
+			name: "event not found",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 0,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrNotFound{}, err)
+			},
+		},
+
+		{
+			name: "success",
+			collection: &mongoTesting.MockCollection{
+				UpdateOneFn: func(
+					context.Context,
+					interface{},
+					interface{},
+					...*options.UpdateOptions,
+				) (*mongo.UpdateResult, error) {
+					return &mongo.UpdateResult{
+						MatchedCount: 1,
+					}, nil
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := &workersStore{
+				collection: testCase.collection,
+			}
+			err := store.UpdateHeartbeat(
+				context.Background(),
+				testEvent,
+				time.Now().UTC(),
+			)
+			testCase.assertions(err)
+		})
+	}
+}
+
 func TestWorkersStoreTimeout(t *testing.T) {
 	const testEvent = "123456789"
 	testCases := []struct {
@@ -83,3 +83,29 @@ func GetWorkerPrincipal(eventID string) *WorkerPrincipal {
 		eventID: eventID,
 	}
 }
+
+// JobPrincipal is an implementation of the Principal interface that represents
+// one of an Event's Jobs, which is a special class of user because it cannot
+// do anything except report its own progress.
+type JobPrincipal struct {
+	eventID string
+	jobName string
+}
+
+func (j *JobPrincipal) RoleAssignments() []RoleAssignment {
+	return []RoleAssignment{
+		{
+			Role:  RoleJob,
+			Scope: JobRoleScope(j.eventID, j.jobName),
+		},
+	}
+}
+
+// GetJobPrincipal returns a Principal that represents the specified Event's
+// specified Job.
+func GetJobPrincipal(eventID string, jobName string) *JobPrincipal {
+	return &JobPrincipal{
+		eventID: eventID,
+		jobName: jobName,
+	}
+}
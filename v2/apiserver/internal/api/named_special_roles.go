@@ -1,5 +1,7 @@
 package api
 
+import "fmt"
+
 const (
 	// These are reserved for use by system components and are NOT assignable to
 	// Users and ServiceAccounts.
@@ -20,4 +22,15 @@ const (
 	// new Jobs, monitor the status of those Jobs, and access their logs. This
 	// Role is exclusively for the use of Brigade Workers.
 	RoleWorker Role = "WORKER"
+
+	// RoleJob represents a job-level Role that enables principals to report the
+	// progress of a single Job. This Role is exclusively for the use of Brigade
+	// Jobs. Its scope is constructed using JobRoleScope.
+	RoleJob Role = "JOB"
 )
+
+// JobRoleScope returns the scope of a RoleJob RoleAssignment for the specified
+// Event's specified Job.
+func JobRoleScope(eventID string, jobName string) string {
+	return fmt.Sprintf("%s:%s", eventID, jobName)
+}
@@ -103,6 +103,19 @@ func NewPrincipalsService(authorizeFn AuthorizeFn) PrincipalsService {
 func (p *principalsService) WhoAmI(
 	ctx context.Context,
 ) (PrincipalReference, error) {
+	ref, ok := principalReferenceFromContext(ctx)
+	if !ok { // What kind of principal is this??? This shouldn't happen.
+		return ref, &meta.ErrAuthorization{}
+	}
+	return ref, nil
+}
+
+// principalReferenceFromContext returns a reference to the root user, User, or
+// ServiceAccount principal found in the provided context.Context. The boolean
+// return value indicates whether such a principal was found.
+func principalReferenceFromContext(
+	ctx context.Context,
+) (PrincipalReference, bool) {
 	ref := PrincipalReference{}
 	switch principal := PrincipalFromContext(ctx).(type) {
 	case *RootPrincipal:
@@ -114,8 +127,8 @@ func (p *principalsService) WhoAmI(
 	case *User:
 		ref.Type = PrincipalTypeUser
 		ref.ID = principal.ID
-	default: // What kind of principal is this??? This shouldn't happen.
-		return ref, &meta.ErrAuthorization{}
+	default:
+		return ref, false
 	}
-	return ref, nil
+	return ref, true
 }
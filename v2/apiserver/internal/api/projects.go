@@ -13,6 +13,9 @@ import (
 // ProjectKind represents the canonical Project kind string
 const ProjectKind = "Project"
 
+// CacheVolumeKind represents the canonical CacheVolume kind string
+const CacheVolumeKind = "CacheVolume"
+
 // Project is Brigade's fundamental configuration, management, and isolation
 // construct.
 // - Configuration: Users define Projects to pair EventSubscriptions with
@@ -73,6 +76,67 @@ type ProjectSpec struct {
 	EventSubscriptions []EventSubscription `json:"eventSubscriptions,omitempty" bson:"eventSubscriptions,omitempty"` // nolint: lll
 	// WorkerTemplate is a prototypical WorkerSpec.
 	WorkerTemplate WorkerSpec `json:"workerTemplate" bson:"workerTemplate"`
+	// CacheVolumes enumerates named, persistent volumes that are provisioned
+	// once for the Project and may be mounted by any of its Jobs. Unlike the
+	// shared workspace, whose lifetime is bound to a single Event, the contents
+	// of a cache volume survive from one Event to the next. This makes them
+	// useful for things like dependency caches.
+	CacheVolumes []CacheVolume `json:"cacheVolumes,omitempty" bson:"cacheVolumes,omitempty"` // nolint: lll
+	// JobTemplates is a map of prototypical JobSpecs indexed by name. A Job
+	// may reference one of these by name and supply only those details that
+	// differ from the template.
+	JobTemplates map[string]JobSpec `json:"jobTemplates,omitempty" bson:"jobTemplates,omitempty"` // nolint: lll
+}
+
+// CacheVolume represents a named, persistent volume that is shared across all
+// of a Project's Events.
+type CacheVolume struct {
+	// Name is a unique-within-the-Project identifier for the cache volume. Jobs
+	// reference cache volumes by this name.
+	Name string `json:"name" bson:"name"`
+	// Size specifies the size of the volume to be provisioned. The value can be
+	// expressed in bytes (as a plain integer) or as a fixed-point integer using
+	// one of these suffixes: E, P, T, G, M, K. Power-of-two equivalents may also
+	// be used: Ei, Pi, Ti, Gi, Mi, Ki. When empty, a substrate-specific default
+	// is used.
+	Size string `json:"size,omitempty" bson:"size,omitempty"`
+	// StorageClass optionally specifies a substrate-specific class of storage
+	// for the volume. When empty, the substrate's default is used.
+	StorageClass string `json:"storageClass,omitempty" bson:"storageClass,omitempty"` // nolint: lll
+	// AccessMode specifies how concurrent use of the cache volume by multiple
+	// Jobs is to be arbitrated. When empty, Brigade assumes
+	// CacheVolumeAccessModeExclusive.
+	AccessMode CacheVolumeAccessMode `json:"accessMode,omitempty" bson:"accessMode,omitempty"` // nolint: lll
+}
+
+// CacheVolumeAccessMode represents how concurrent use of a cache volume is
+// arbitrated.
+type CacheVolumeAccessMode string
+
+const (
+	// CacheVolumeAccessModeExclusive represents a cache volume that may be
+	// mounted by only a single Job at a time. Any other Job that requires the
+	// same cache volume will wait until the volume is released. This is the
+	// safest choice for tools that do not tolerate concurrent writers. Projects
+	// with exclusive cache volumes are rejected by substrates that cannot
+	// guarantee exclusive access.
+	CacheVolumeAccessModeExclusive CacheVolumeAccessMode = "EXCLUSIVE"
+	// CacheVolumeAccessModeShared represents a cache volume that may be mounted
+	// by many Jobs at once. This is only appropriate for tools whose caches
+	// tolerate concurrent writers and requires storage that supports such
+	// access.
+	CacheVolumeAccessModeShared CacheVolumeAccessMode = "SHARED"
+)
+
+// CacheVolume returns the CacheVolume having the specified name, along with
+// a boolean indicating whether such a CacheVolume was found.
+func (p ProjectSpec) CacheVolume(name string) (CacheVolume, bool) {
+	for _, cacheVolume := range p.CacheVolumes {
+		if cacheVolume.Name == name {
+			return cacheVolume, true
+		}
+	}
+	return CacheVolume{}, false
 }
 
 // EventSubscription defines a set of Events of interest. ProjectSpecs utilize
@@ -120,11 +184,151 @@ type EventSubscription struct {
 
 // KubernetesDetails represents Kubernetes-specific configuration.
 type KubernetesDetails struct {
-	// Namespace is the dedicated Kubernetes namespace for the Project. This is
-	// NOT specified by clients when creating a new Project. The namespace is
-	// created by / assigned by the system. This detail is a necessity to prevent
-	// clients from naming existing namespaces in an attempt to hijack them.
+	// Namespace is the dedicated Kubernetes namespace for the Project. When
+	// this is left unspecified by clients creating a new Project, a new
+	// namespace is created by / assigned by the system. Clients MAY specify an
+	// existing namespace instead, but only if it is one that an administrator
+	// has configured the substrate to permit Projects to claim. This is a
+	// necessity to prevent clients from naming arbitrary existing namespaces in
+	// an attempt to hijack them. The namespace cannot be changed once the
+	// Project has been created.
 	Namespace string `json:"namespace,omitempty" bson:"namespace,omitempty"`
+	// Cluster optionally pins the Project to one of the named Kubernetes
+	// clusters that an administrator has registered with the substrate. The
+	// Project's namespace and Workers reside on that cluster, as do its Jobs,
+	// unless a Job's JobHost routes it elsewhere. When unspecified, the
+	// substrate's default cluster is used. The cluster cannot be changed once
+	// the Project has been created.
+	Cluster string `json:"cluster,omitempty" bson:"cluster,omitempty"`
+	// WorkspaceStorageClass optionally overrides the substrate's default
+	// Kubernetes StorageClass for the shared workspaces of the Project's
+	// Workers.
+	WorkspaceStorageClass string `json:"workspaceStorageClass,omitempty" bson:"workspaceStorageClass,omitempty"` // nolint: lll
+	// NodeSelector optionally specifies node labels, in addition to the
+	// substrate's default node selector, for all of the Project's Worker and Job
+	// pods. The substrate may restrict which labels are permitted.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty" bson:"nodeSelector,omitempty"` // nolint: lll
+	// Tolerations optionally specifies node taints, in addition to the
+	// substrate's default toleration, that all of the Project's Worker and Job
+	// pods tolerate. The substrate may restrict which taints are permitted.
+	Tolerations []Toleration `json:"tolerations,omitempty" bson:"tolerations,omitempty"` // nolint: lll
+	// ResourceQuota optionally constrains the aggregate resources that may be
+	// consumed within the Project's namespace. Any bounds set by an
+	// administrator apply regardless.
+	ResourceQuota *ResourceQuota `json:"resourceQuota,omitempty" bson:"resourceQuota,omitempty"` // nolint: lll
+	// LimitRange optionally specifies default and maximum compute resources for
+	// each container of the Project's Worker and Job pods.
+	LimitRange *LimitRange `json:"limitRange,omitempty" bson:"limitRange,omitempty"` // nolint: lll
+	// NetworkPolicy optionally constrains network traffic to and from the
+	// Project's Worker and Job pods. When unspecified, the substrate's default
+	// applies. A NetworkPolicy less restrictive than the substrate's default is
+	// not permitted.
+	NetworkPolicy NetworkPolicyMode `json:"networkPolicy,omitempty" bson:"networkPolicy,omitempty"` // nolint: lll
+	// WorkerPodOverlay optionally specifies a patch to be applied to each of the
+	// Project's Worker pods after the substrate has built it. The patch may not
+	// modify any pod field that an administrator has denied.
+	WorkerPodOverlay *PodOverlay `json:"workerPodOverlay,omitempty" bson:"workerPodOverlay,omitempty"` // nolint: lll
+	// JobPodOverlay optionally specifies a patch to be applied to each of the
+	// Project's Job pods after the substrate has built it. The patch may not
+	// modify any pod field that an administrator has denied.
+	JobPodOverlay *PodOverlay `json:"jobPodOverlay,omitempty" bson:"jobPodOverlay,omitempty"` // nolint: lll
+	// JobServiceAccounts optionally declares Kubernetes ServiceAccounts, in
+	// addition to the default "jobs" ServiceAccount, to be provisioned in the
+	// Project's namespace for use by the Project's Jobs. Annotations on these
+	// can associate them with cloud provider workload identities.
+	JobServiceAccounts []JobServiceAccount `json:"jobServiceAccounts,omitempty" bson:"jobServiceAccounts,omitempty"` // nolint: lll
+	// DefaultJobServiceAccount optionally names one of the JobServiceAccounts
+	// that the Project's Jobs run as unless they request another. When
+	// unspecified, Jobs run as the "jobs" ServiceAccount.
+	DefaultJobServiceAccount string `json:"defaultJobServiceAccount,omitempty" bson:"defaultJobServiceAccount,omitempty"` // nolint: lll
+}
+
+// hasJobServiceAccount returns a boolean indicating whether the specified name
+// is that of one of the declared JobServiceAccounts.
+func (k *KubernetesDetails) hasJobServiceAccount(name string) bool {
+	if k == nil {
+		return false
+	}
+	for _, serviceAccount := range k.JobServiceAccounts {
+		if serviceAccount.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// JobServiceAccount represents a Kubernetes ServiceAccount that the substrate
+// provisions in a Project's namespace for use by the Project's Jobs.
+type JobServiceAccount struct {
+	// Name is the name of the ServiceAccount. The names "jobs" and "workers" are
+	// reserved.
+	Name string `json:"name" bson:"name"`
+	// Annotations are applied to the ServiceAccount. These are commonly used to
+	// associate the ServiceAccount with a cloud provider workload identity,
+	// e.g. using the "eks.amazonaws.com/role-arn" or
+	// "iam.gke.io/gcp-service-account" annotations.
+	Annotations map[string]string `json:"annotations,omitempty" bson:"annotations,omitempty"` // nolint: lll
+}
+
+// ResourceQuota represents constraints on the aggregate resources that may be
+// consumed within a Project's namespace.
+type ResourceQuota struct {
+	// Hard maps the names of resources (e.g. "requests.cpu", "limits.memory",
+	// or "pods") to the maximum quantity of each that may be consumed.
+	Hard map[string]string `json:"hard,omitempty" bson:"hard,omitempty"`
+}
+
+// LimitRange represents default and maximum compute resources for each
+// container of a Project's Worker and Job pods. Each field maps the names of
+// resources (e.g. "cpu" or "memory") to quantities.
+type LimitRange struct {
+	// DefaultRequests are the resources requested by any container that does not
+	// explicitly request them.
+	DefaultRequests map[string]string `json:"defaultRequests,omitempty" bson:"defaultRequests,omitempty"` // nolint: lll
+	// DefaultLimits are the resource limits of any container that does not
+	// explicitly specify them.
+	DefaultLimits map[string]string `json:"defaultLimits,omitempty" bson:"defaultLimits,omitempty"` // nolint: lll
+	// Max are the greatest resource limits any container may specify.
+	Max map[string]string `json:"max,omitempty" bson:"max,omitempty"`
+}
+
+// NetworkPolicyMode represents a canned policy governing network traffic to
+// and from a Project's Worker and Job pods.
+type NetworkPolicyMode string
+
+const (
+	// NetworkPolicyModeAllowAll represents a policy that places no constraints
+	// on network traffic.
+	NetworkPolicyModeAllowAll NetworkPolicyMode = "AllowAll"
+	// NetworkPolicyModeDenyNamespaceToNamespace represents a policy that permits
+	// inbound traffic to a Project's pods only from other pods in the same
+	// namespace. Outbound traffic is not constrained.
+	NetworkPolicyModeDenyNamespaceToNamespace NetworkPolicyMode = "DenyNamespaceToNamespace" // nolint: lll
+	// NetworkPolicyModeEgressOnly represents a policy that denies all inbound
+	// traffic to a Project's pods. Outbound traffic is not constrained.
+	NetworkPolicyModeEgressOnly NetworkPolicyMode = "EgressOnly"
+)
+
+// PodOverlayType represents the format of a PodOverlay's patch.
+type PodOverlayType string
+
+const (
+	// PodOverlayTypeStrategicMerge represents a Kubernetes strategic merge
+	// patch.
+	PodOverlayTypeStrategicMerge PodOverlayType = "StrategicMerge"
+	// PodOverlayTypeJSONPatch represents an RFC 6902 JSON patch.
+	PodOverlayTypeJSONPatch PodOverlayType = "JSONPatch"
+)
+
+// PodOverlay represents a patch to be applied to a Worker or Job pod after the
+// substrate has built it. This accommodates pod fields that Brigade does not
+// otherwise model, such as DNS configuration, host aliases, or annotations
+// used by service meshes.
+type PodOverlay struct {
+	// Type specifies the format of the Patch.
+	Type PodOverlayType `json:"type" bson:"type"`
+	// Patch is the patch itself, expressed in either JSON or YAML.
+	Patch string `json:"patch" bson:"patch"`
 }
 
 // ProjectsService is the specialized interface for managing Projects. It's
@@ -153,6 +357,13 @@ type ProjectsService interface {
 	// specified Project does not exist, implementations MUST return a
 	// *meta.ErrNotFound error.
 	Delete(context.Context, string) error
+	// ClearCacheVolume discards the contents of the specified Project's
+	// specified cache volume. If the specified Project does not exist or does
+	// not define the specified cache volume, implementations MUST return a
+	// *meta.ErrNotFound error.
+	// If the cache volume cannot be discarded because it is still in use,
+	// implementations MUST return a *meta.ErrConflict error.
+	ClearCacheVolume(ctx context.Context, projectID string, name string) error
 }
 
 type projectsService struct {
@@ -349,7 +560,8 @@ func (p *projectsService) Update(
 		return err
 	}
 
-	if _, err := p.projectsStore.Get(ctx, project.ID); err != nil {
+	existingProject, err := p.projectsStore.Get(ctx, project.ID)
+	if err != nil {
 		_, isErrNotFound := errors.Cause(err).(*meta.ErrNotFound)
 		if !isErrNotFound || !opts.CreateIfNotFound {
 			return errors.Wrapf(err, "error retrieving project %q from store",
@@ -365,6 +577,49 @@ func (p *projectsService) Update(
 		return err
 	}
 
+	// A Project's namespace is fixed at creation time. Clients may echo it back
+	// when updating the Project, but may not change it.
+	if project.Kubernetes != nil && project.Kubernetes.Namespace != "" &&
+		(existingProject.Kubernetes == nil ||
+			project.Kubernetes.Namespace != existingProject.Kubernetes.Namespace) {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"The Kubernetes namespace of project %q cannot be changed.",
+				project.ID,
+			),
+		}
+	}
+
+	// Likewise for the Project's cluster
+	if project.Kubernetes != nil && project.Kubernetes.Cluster != "" &&
+		(existingProject.Kubernetes == nil ||
+			project.Kubernetes.Cluster != existingProject.Kubernetes.Cluster) {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"The Kubernetes cluster of project %q cannot be changed.",
+				project.ID,
+			),
+		}
+	}
+
+	// The substrate needs to know the Project's namespace and cluster, even if
+	// the client didn't echo them back
+	if existingProject.Kubernetes != nil {
+		if project.Kubernetes == nil {
+			project.Kubernetes = &KubernetesDetails{}
+		}
+		project.Kubernetes.Namespace = existingProject.Kubernetes.Namespace
+		project.Kubernetes.Cluster = existingProject.Kubernetes.Cluster
+	}
+
+	if err := p.substrate.UpdateProject(ctx, project); err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating project %q on the substrate",
+			project.ID,
+		)
+	}
+
 	if err := p.projectsStore.Update(ctx, project); err != nil {
 		return errors.Wrapf(
 			err,
@@ -434,6 +689,47 @@ func (p *projectsService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (p *projectsService) ClearCacheVolume(
+	ctx context.Context,
+	projectID string,
+	name string,
+) error {
+	if err := p.authorize(ctx, RoleReader, ""); err != nil {
+		return err
+	}
+
+	project, err := p.projectsStore.Get(ctx, projectID)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving project %q from store",
+			projectID,
+		)
+	}
+
+	if err =
+		p.projectAuthorize(ctx, projectID, RoleProjectDeveloper); err != nil {
+		return err
+	}
+
+	if _, ok := project.Spec.CacheVolume(name); !ok {
+		return &meta.ErrNotFound{
+			Type: CacheVolumeKind,
+			ID:   name,
+		}
+	}
+
+	if err = p.substrate.DeleteCacheVolume(ctx, project, name); err != nil {
+		return errors.Wrapf(
+			err,
+			"error clearing project %q cache volume %q on the substrate",
+			projectID,
+			name,
+		)
+	}
+	return nil
+}
+
 // ProjectsStore is an interface for components that implement Project
 // persistence concerns.
 type ProjectsStore interface {
@@ -306,6 +306,7 @@ func TestProjectServiceGet(t *testing.T) {
 func TestProjectServiceUpdate(t *testing.T) {
 	testCases := []struct {
 		name       string
+		project    Project
 		opts       ProjectUpdateOptions
 		service    ProjectsService
 		assertions func(error)
@@ -352,11 +353,91 @@ func TestProjectServiceUpdate(t *testing.T) {
 				require.IsType(t, &meta.ErrAuthorization{}, err)
 			},
 		},
+		{
+			name: "attempt to change namespace",
+			project: Project{
+				Kubernetes: &KubernetesDetails{
+					Namespace: "bar",
+				},
+			},
+			service: &projectsService{
+				authorize:        alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
+				projectsStore: &mockProjectsStore{
+					GetFn: func(ctx context.Context, s string) (Project, error) {
+						return Project{
+							Kubernetes: &KubernetesDetails{
+								Namespace: "foo",
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "cannot be changed")
+			},
+		},
+		{
+			name: "attempt to change cluster",
+			project: Project{
+				Kubernetes: &KubernetesDetails{
+					Cluster: "gpu",
+				},
+			},
+			service: &projectsService{
+				authorize:        alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
+				projectsStore: &mockProjectsStore{
+					GetFn: func(ctx context.Context, s string) (Project, error) {
+						return Project{
+							Kubernetes: &KubernetesDetails{
+								Namespace: "foo",
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				require.Contains(t, err.Error(), "cluster")
+				require.Contains(t, err.Error(), "cannot be changed")
+			},
+		},
+		{
+			name: "error updating project on substrate",
+			service: &projectsService{
+				authorize:        alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
+				substrate: &mockSubstrate{
+					UpdateProjectFn: func(context.Context, Project) error {
+						return errors.New("substrate error")
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(ctx context.Context, s string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "substrate error")
+				require.Contains(t, err.Error(), "error updating project")
+			},
+		},
 		{
 			name: "error updating project in store",
 			service: &projectsService{
 				authorize:        alwaysAuthorize,
 				projectAuthorize: alwaysProjectAuthorize,
+				substrate: &mockSubstrate{
+					UpdateProjectFn: func(context.Context, Project) error {
+						return nil
+					},
+				},
 				projectsStore: &mockProjectsStore{
 					GetFn: func(ctx context.Context, s string) (Project, error) {
 						return Project{}, nil
@@ -377,6 +458,11 @@ func TestProjectServiceUpdate(t *testing.T) {
 			service: &projectsService{
 				authorize:        alwaysAuthorize,
 				projectAuthorize: alwaysProjectAuthorize,
+				substrate: &mockSubstrate{
+					UpdateProjectFn: func(context.Context, Project) error {
+						return nil
+					},
+				},
 				projectsStore: &mockProjectsStore{
 					GetFn: func(ctx context.Context, s string) (Project, error) {
 						return Project{}, nil
@@ -426,12 +512,26 @@ func TestProjectServiceUpdate(t *testing.T) {
 		},
 		{
 			name: "success",
+			project: Project{
+				Kubernetes: &KubernetesDetails{
+					Namespace: "foo",
+				},
+			},
 			service: &projectsService{
 				authorize:        alwaysAuthorize,
 				projectAuthorize: alwaysProjectAuthorize,
+				substrate: &mockSubstrate{
+					UpdateProjectFn: func(context.Context, Project) error {
+						return nil
+					},
+				},
 				projectsStore: &mockProjectsStore{
 					GetFn: func(ctx context.Context, s string) (Project, error) {
-						return Project{}, nil
+						return Project{
+							Kubernetes: &KubernetesDetails{
+								Namespace: "foo",
+							},
+						}, nil
 					},
 					UpdateFn: func(context.Context, Project) error {
 						return nil
@@ -447,7 +547,7 @@ func TestProjectServiceUpdate(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			err := testCase.service.Update(
 				context.Background(),
-				Project{},
+				testCase.project,
 				testCase.opts,
 			)
 			testCase.assertions(err)
@@ -746,6 +846,124 @@ func TestProjectServiceDelete(t *testing.T) {
 	}
 }
 
+func TestProjectServiceClearCacheVolume(t *testing.T) {
+	const testCacheVolumeName = "npm"
+	testProject := Project{
+		Spec: ProjectSpec{
+			CacheVolumes: []CacheVolume{
+				{
+					Name: testCacheVolumeName,
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		name       string
+		volumeName string
+		service    ProjectsService
+		assertions func(error)
+	}{
+		{
+			name:       "unauthorized",
+			volumeName: testCacheVolumeName,
+			service: &projectsService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name:       "error getting project from store",
+			volumeName: testCacheVolumeName,
+			service: &projectsService{
+				authorize: alwaysAuthorize,
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, errors.New("store error")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "store error")
+				require.Contains(t, err.Error(), "error retrieving project")
+			},
+		},
+		{
+			name:       "cache volume not defined",
+			volumeName: "maven",
+			service: &projectsService{
+				authorize:        alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return testProject, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrNotFound{}, err)
+				require.Equal(t, CacheVolumeKind, err.(*meta.ErrNotFound).Type)
+			},
+		},
+		{
+			name:       "error deleting cache volume from substrate",
+			volumeName: testCacheVolumeName,
+			service: &projectsService{
+				authorize:        alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return testProject, nil
+					},
+				},
+				substrate: &mockSubstrate{
+					DeleteCacheVolumeFn: func(context.Context, Project, string) error {
+						return errors.New("substrate error")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "substrate error")
+				require.Contains(t, err.Error(), "error clearing project")
+			},
+		},
+		{
+			name:       "success",
+			volumeName: testCacheVolumeName,
+			service: &projectsService{
+				authorize:        alwaysAuthorize,
+				projectAuthorize: alwaysProjectAuthorize,
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return testProject, nil
+					},
+				},
+				substrate: &mockSubstrate{
+					DeleteCacheVolumeFn: func(context.Context, Project, string) error {
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.service.ClearCacheVolume(
+				context.Background(),
+				"foo",
+				testCase.volumeName,
+			)
+			testCase.assertions(err)
+		})
+	}
+}
+
 type mockProjectsStore struct {
 	CreateFn func(context.Context, Project) error
 	ListFn   func(
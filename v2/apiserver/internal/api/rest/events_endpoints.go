@@ -71,6 +71,18 @@ func (e *EventsEndpoints) Register(router *mux.Router) {
 		e.AuthFilter.Decorate(e.cancelMany),
 	).Methods(http.MethodPost)
 
+	// Suspend event
+	router.HandleFunc(
+		"/v2/events/{id}/suspension",
+		e.AuthFilter.Decorate(e.suspend),
+	).Methods(http.MethodPut)
+
+	// Resume event
+	router.HandleFunc(
+		"/v2/events/{id}/suspension",
+		e.AuthFilter.Decorate(e.resume),
+	).Methods(http.MethodDelete)
+
 	// Delete event
 	router.HandleFunc(
 		"/v2/events/{id}",
@@ -240,6 +252,32 @@ func (e *EventsEndpoints) cancel(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (e *EventsEndpoints) suspend(w http.ResponseWriter, r *http.Request) {
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, e.Service.Suspend(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (e *EventsEndpoints) resume(w http.ResponseWriter, r *http.Request) {
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, e.Service.Resume(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (e *EventsEndpoints) cancelMany(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -0,0 +1,239 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Exec sessions are carried over a WebSocket. Every message exchanged is a
+// binary message whose first byte identifies the channel the remainder of the
+// message belongs to.
+const (
+	// execChannelStdin carries the command's standard input from the client to
+	// the server. An empty message on this channel signals EOF.
+	execChannelStdin byte = 0
+	// execChannelStdout carries the command's standard output from the server to
+	// the client.
+	execChannelStdout byte = 1
+	// execChannelStderr carries the command's standard error from the server to
+	// the client.
+	execChannelStderr byte = 2
+	// execChannelStatus carries a JSON-encoded execStatus from the server to the
+	// client exactly once, after the command has exited.
+	execChannelStatus byte = 3
+	// execChannelResize carries JSON-encoded api.TerminalSizes from the client to
+	// the server.
+	execChannelResize byte = 4
+)
+
+// execStatus represents the outcome of an exec session.
+type execStatus struct {
+	// ExitCode is the exit code of the command that was executed.
+	ExitCode int `json:"exitCode"`
+	// Error is populated if the command could not be executed or its exit code
+	// could not be determined.
+	Error string `json:"error,omitempty"`
+}
+
+// ExecEndpoints implements restmachinery.Endpoints to provide exec-related URL
+// --> action mappings to a restmachinery.Server.
+type ExecEndpoints struct {
+	AuthFilter restmachinery.Filter
+	Service    api.ExecService
+}
+
+// Register is invoked by restmachinery.Server to register exec-related URL
+// --> action mappings to a restmachinery.Server.
+func (e *ExecEndpoints) Register(router *mux.Router) {
+	// Exec
+	router.HandleFunc(
+		"/v2/events/{id}/exec",
+		e.AuthFilter.Decorate(e.exec),
+	).Methods(http.MethodGet)
+}
+
+func (e *ExecEndpoints) exec(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	// nolint: errcheck
+	tty, _ := strconv.ParseBool(r.URL.Query().Get("tty"))
+	// nolint: errcheck
+	stdin, _ := strconv.ParseBool(r.URL.Query().Get("stdin"))
+
+	selector := api.ExecSelector{
+		Job:       r.URL.Query().Get("job"),
+		Container: r.URL.Query().Get("container"),
+	}
+	opts := api.ExecOptions{
+		Command: r.URL.Query()["command"],
+		TTY:     tty,
+	}
+
+	// All validation and authorization happens here, BEFORE the connection is
+	// upgraded, so that any failure can be reported with an ordinary HTTP
+	// response.
+	session, err := e.Service.Exec(r.Context(), id, selector, opts)
+	if err != nil {
+		restmachinery.WriteAPIErrorResponse(w, err)
+		return
+	}
+
+	// The upgrader's default origin check rejects cross-origin requests from
+	// browsers. Clients other than browsers, such as brig, send no Origin header
+	// and are unaffected.
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already responded to the client
+		log.Println(errors.Wrap(err, "error upgrading exec connection"))
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	writeMu := &sync.Mutex{}
+	streams := api.ExecStreams{
+		Stdout: &execStreamWriter{
+			conn:    conn,
+			mu:      writeMu,
+			channel: execChannelStdout,
+		},
+		Stderr: &execStreamWriter{
+			conn:    conn,
+			mu:      writeMu,
+			channel: execChannelStderr,
+		},
+	}
+	var stdinWriter *io.PipeWriter
+	if stdin {
+		streams.Stdin, stdinWriter = io.Pipe()
+	}
+	var terminalSizeCh chan api.TerminalSize
+	if tty {
+		terminalSizeCh = make(chan api.TerminalSize, 1)
+		streams.TerminalSizes = terminalSizeCh
+	}
+
+	go e.readClientMessages(ctx, cancel, conn, stdinWriter, terminalSizeCh)
+
+	status := execStatus{}
+	if err = session(ctx, streams); err != nil {
+		if exitErr, ok := err.(*api.ExecExitError); ok {
+			status.ExitCode = exitErr.ExitCode
+		} else {
+			log.Println(
+				errors.Wrapf(err, "error executing command for event %q", id),
+			)
+			status.ExitCode = -1
+			status.Error = "The command could not be executed."
+		}
+	}
+
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		log.Println(errors.Wrap(err, "error marshaling exec status"))
+		return
+	}
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if err = conn.WriteMessage(
+		websocket.BinaryMessage,
+		append([]byte{execChannelStatus}, statusBytes...),
+	); err != nil {
+		return
+	}
+	// nolint: errcheck
+	conn.WriteMessage(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+	)
+}
+
+// readClientMessages reads messages sent by the client and routes them to the
+// command's stdin or to the terminal size channel, as appropriate. It returns
+// when the connection is closed, at which point it cancels the provided
+// context and closes stdin and the terminal size channel, since it is the only
+// sender on each.
+func (e *ExecEndpoints) readClientMessages(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	conn *websocket.Conn,
+	stdinWriter *io.PipeWriter,
+	terminalSizeCh chan api.TerminalSize,
+) {
+	defer cancel()
+	if stdinWriter != nil {
+		defer stdinWriter.Close()
+	}
+	if terminalSizeCh != nil {
+		defer close(terminalSizeCh)
+	}
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if msgType != websocket.BinaryMessage || len(msg) == 0 {
+			continue
+		}
+		switch msg[0] {
+		case execChannelStdin:
+			if stdinWriter == nil {
+				continue
+			}
+			if len(msg) == 1 { // EOF
+				stdinWriter.Close() // nolint: errcheck
+				stdinWriter = nil
+				continue
+			}
+			if _, err = stdinWriter.Write(msg[1:]); err != nil {
+				stdinWriter = nil
+			}
+		case execChannelResize:
+			if terminalSizeCh == nil {
+				continue
+			}
+			size := api.TerminalSize{}
+			if err = json.Unmarshal(msg[1:], &size); err != nil {
+				continue
+			}
+			select {
+			case terminalSizeCh <- size:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// execStreamWriter is an io.Writer that writes to a specific channel of a
+// WebSocket connection.
+type execStreamWriter struct {
+	conn    *websocket.Conn
+	mu      *sync.Mutex
+	channel byte
+}
+
+func (e *execStreamWriter) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.conn.WriteMessage(
+		websocket.BinaryMessage,
+		append([]byte{e.channel}, p...),
+	); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -18,10 +18,11 @@ import (
 // JobsEndpoints implements restmachinery.Endpoints to provide Job-related URL
 // --> action mappings to a restmachinery.Server.
 type JobsEndpoints struct {
-	AuthFilter            restmachinery.Filter
-	JobSchemaLoader       gojsonschema.JSONLoader
-	JobStatusSchemaLoader gojsonschema.JSONLoader
-	Service               api.JobsService
+	AuthFilter              restmachinery.Filter
+	JobSchemaLoader         gojsonschema.JSONLoader
+	JobStatusSchemaLoader   gojsonschema.JSONLoader
+	JobProgressSchemaLoader gojsonschema.JSONLoader
+	Service                 api.JobsService
 }
 
 // Register is invoked by restmachinery.Server to register Job-related URL
@@ -51,12 +52,24 @@ func (j *JobsEndpoints) Register(router *mux.Router) {
 		j.AuthFilter.Decorate(j.updateStatus),
 	).Methods(http.MethodPut)
 
+	// Update job progress
+	router.HandleFunc(
+		"/v2/events/{eventID}/worker/jobs/{jobName}/progress",
+		j.AuthFilter.Decorate(j.updateProgress),
+	).Methods(http.MethodPut)
+
 	// Clean up a job
 	router.HandleFunc(
 		"/v2/events/{eventID}/worker/jobs/{jobName}/cleanup",
 		j.AuthFilter.Decorate(j.cleanup),
 	).Methods(http.MethodPut)
 
+	// Cancel a job
+	router.HandleFunc(
+		"/v2/events/{eventID}/worker/jobs/{jobName}/cancel",
+		j.AuthFilter.Decorate(j.cancel),
+	).Methods(http.MethodPut)
+
 	// Timeout a job
 	router.HandleFunc(
 		"/v2/events/{eventID}/worker/jobs/{jobName}/timeout",
@@ -199,6 +212,30 @@ func (j *JobsEndpoints) updateStatus(
 	)
 }
 
+func (j *JobsEndpoints) updateProgress(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	progress := api.JobProgress{}
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: j.JobProgressSchemaLoader,
+			ReqBodyObj:          &progress,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, j.Service.UpdateProgress(
+					r.Context(),
+					mux.Vars(r)["eventID"],
+					mux.Vars(r)["jobName"],
+					progress,
+				)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (j *JobsEndpoints) cleanup(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -219,6 +256,26 @@ func (j *JobsEndpoints) cleanup(
 	)
 }
 
+func (j *JobsEndpoints) cancel(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, j.Service.Cancel(
+					r.Context(),
+					mux.Vars(r)["eventID"],
+					mux.Vars(r)["jobName"],
+				)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (j *JobsEndpoints) timeout(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -52,6 +52,12 @@ func (p *ProjectsEndpoints) Register(router *mux.Router) {
 		"/v2/projects/{id}",
 		p.AuthFilter.Decorate(p.delete),
 	).Methods(http.MethodDelete)
+
+	// Clear Project cache volume
+	router.HandleFunc(
+		"/v2/projects/{id}/cache-volumes/{name}",
+		p.AuthFilter.Decorate(p.clearCacheVolume),
+	).Methods(http.MethodDelete)
 }
 
 func (p *ProjectsEndpoints) create(w http.ResponseWriter, r *http.Request) {
@@ -158,3 +164,23 @@ func (p *ProjectsEndpoints) delete(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 }
+
+func (p *ProjectsEndpoints) clearCacheVolume(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, p.Service.ClearCacheVolume(
+					r.Context(),
+					mux.Vars(r)["id"],
+					mux.Vars(r)["name"],
+				)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
@@ -29,6 +29,12 @@ func (s *SubstrateEndpoints) Register(router *mux.Router) {
 		"/v2/substrate/running-jobs",
 		s.AuthFilter.Decorate(s.countRunningJobs),
 	).Methods(http.MethodGet)
+
+	// Get inventory
+	router.HandleFunc(
+		"/v2/substrate/inventory",
+		s.AuthFilter.Decorate(s.getInventory),
+	).Methods(http.MethodGet)
 }
 
 func (s *SubstrateEndpoints) countRunningWorkers(
@@ -62,3 +68,19 @@ func (s *SubstrateEndpoints) countRunningJobs(
 		},
 	)
 }
+
+func (s *SubstrateEndpoints) getInventory(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return s.Service.GetInventory(r.Context())
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
@@ -61,6 +61,10 @@ type tokenAuthFilter struct {
 		ctx context.Context,
 		token string,
 	) (api.Event, error)
+	findEventByJobTokenFn func(
+		ctx context.Context,
+		token string,
+	) (api.Event, string, error)
 	config TokenAuthFilterConfig
 }
 
@@ -78,6 +82,10 @@ func NewTokenAuthFilter(
 		ctx context.Context,
 		token string,
 	) (api.Event, error),
+	findEventByJobTokenFn func(
+		ctx context.Context,
+		token string,
+	) (api.Event, string, error),
 	config *TokenAuthFilterConfig,
 ) restmachinery.Filter {
 	if config == nil {
@@ -87,6 +95,7 @@ func NewTokenAuthFilter(
 		findServiceAccountByTokenFn: findServiceAccountByTokenFn,
 		findSessionByTokenFn:        findSessionFn,
 		findEventByTokenFn:          findEventByTokenFn,
+		findEventByJobTokenFn:       findEventByJobTokenFn,
 		config:                      *config,
 	}
 }
@@ -156,6 +165,27 @@ func (t *tokenAuthFilter) Decorate(handle http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Is it a Job's token?
+		if event, jobName, err :=
+			t.findEventByJobTokenFn(r.Context(), token); err != nil {
+			if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+				log.Println(err)
+				t.writeResponse(
+					w,
+					http.StatusInternalServerError,
+					&meta.ErrInternalServer{},
+				)
+				return
+			}
+		} else {
+			ctx := api.ContextWithPrincipal(
+				r.Context(),
+				api.GetJobPrincipal(event.ID, jobName),
+			)
+			handle(w, r.WithContext(ctx))
+			return
+		}
+
 		// Is it a ServiceAccount's token?
 		if serviceAccount, err :=
 			t.findServiceAccountByTokenFn(r.Context(), token); err != nil {
@@ -146,12 +146,74 @@ func TestFilter(t *testing.T) {
 			},
 		},
 
+		{
+			name: "error finding event by job token",
+			filter: &tokenAuthFilter{
+				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
+					return api.Event{}, &meta.ErrNotFound{}
+				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", errors.New("something went wrong")
+				},
+			},
+			setup: func() *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "/", nil)
+				require.NoError(t, err)
+				req.Header.Add("Authorization", "Bearer foo")
+				return req
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			assertions: func(handlerCalled bool, r *http.Response) {
+				require.Equal(t, http.StatusInternalServerError, r.StatusCode)
+				assert.False(t, handlerCalled)
+			},
+		},
+
+		{
+			name: "token belongs to a job",
+			filter: &tokenAuthFilter{
+				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
+					return api.Event{}, &meta.ErrNotFound{}
+				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "italian", nil
+				},
+			},
+			setup: func() *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "/", nil)
+				require.NoError(t, err)
+				req.Header.Add("Authorization", "Bearer foo")
+				return req
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				principal := api.PrincipalFromContext(r.Context())
+				require.NotNil(t, principal)
+				require.Equal(t, api.GetJobPrincipal("", "italian"), principal)
+			},
+			assertions: func(handlerCalled bool, r *http.Response) {
+				require.Equal(t, http.StatusOK, r.StatusCode)
+				assert.True(t, handlerCalled)
+			},
+		},
+
 		{
 			name: "error finding service account",
 			filter: &tokenAuthFilter{
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -177,6 +239,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -205,6 +273,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -235,6 +309,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -266,6 +346,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -297,6 +383,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -333,6 +425,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -375,6 +473,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -409,6 +513,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -443,6 +553,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -485,6 +601,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -530,6 +652,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
@@ -572,6 +700,12 @@ func TestFilter(t *testing.T) {
 				findEventByTokenFn: func(context.Context, string) (api.Event, error) {
 					return api.Event{}, &meta.ErrNotFound{}
 				},
+				findEventByJobTokenFn: func(
+					context.Context,
+					string,
+				) (api.Event, string, error) {
+					return api.Event{}, "", &meta.ErrNotFound{}
+				},
 				findServiceAccountByTokenFn: func(
 					context.Context,
 					string,
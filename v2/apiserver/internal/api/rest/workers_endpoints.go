@@ -44,6 +44,12 @@ func (w *WorkersEndpoints) Register(router *mux.Router) {
 		w.AuthFilter.Decorate(w.updateStatus),
 	).Methods(http.MethodPut)
 
+	// Record a worker heartbeat
+	router.HandleFunc(
+		"/v2/events/{eventID}/worker/heartbeat",
+		w.AuthFilter.Decorate(w.heartbeat),
+	).Methods(http.MethodPut)
+
 	// Clean up a worker
 	router.HandleFunc(
 		"/v2/events/{eventID}/worker/cleanup",
@@ -181,6 +187,23 @@ func (w *WorkersEndpoints) cleanup(
 	)
 }
 
+func (w *WorkersEndpoints) heartbeat(
+	wr http.ResponseWriter,
+	r *http.Request,
+) {
+	restmachinery.ServeRequest(
+		restmachinery.InboundRequest{
+			W: wr,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil,
+					w.Service.Heartbeat(r.Context(), mux.Vars(r)["eventID"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (w *WorkersEndpoints) timeout(
 	wr http.ResponseWriter,
 	r *http.Request,
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+)
+
+// CPUArch represents a CPU architecture.
+type CPUArch string
+
+const (
+	// CPUArchAMD64 represents the amd64 (x86-64) CPU architecture.
+	CPUArchAMD64 CPUArch = "amd64"
+	// CPUArchARM64 represents the arm64 (AArch64) CPU architecture.
+	CPUArchARM64 CPUArch = "arm64"
+)
+
+// TolerationOperator represents the relationship between a Toleration's key
+// and value.
+type TolerationOperator string
+
+const (
+	// TolerationOperatorExists represents a Toleration that tolerates any taint
+	// having a matching key, regardless of the taint's value.
+	TolerationOperatorExists TolerationOperator = "Exists"
+	// TolerationOperatorEqual represents a Toleration that tolerates only taints
+	// having a matching key AND value.
+	TolerationOperatorEqual TolerationOperator = "Equal"
+)
+
+// AffinityOperator represents the relationship between a label key and a set
+// of values in a NodeAffinityTerm.
+type AffinityOperator string
+
+const (
+	// AffinityOperatorIn represents a requirement that a label's value be one
+	// of a set of values.
+	AffinityOperatorIn AffinityOperator = "In"
+	// AffinityOperatorNotIn represents a requirement that a label's value NOT be
+	// any of a set of values.
+	AffinityOperatorNotIn AffinityOperator = "NotIn"
+	// AffinityOperatorExists represents a requirement that a label be present,
+	// regardless of its value.
+	AffinityOperatorExists AffinityOperator = "Exists"
+	// AffinityOperatorDoesNotExist represents a requirement that a label NOT be
+	// present.
+	AffinityOperatorDoesNotExist AffinityOperator = "DoesNotExist"
+)
+
+// SchedulingConstraints represents criteria, beyond OS family and node
+// labels, that influence which substrate node(s) may host a Worker or Job.
+type SchedulingConstraints struct {
+	// Arch specifies which CPU architecture is required on a substrate node to
+	// host a Worker or Job. When empty, no particular architecture is required.
+	Arch CPUArch `json:"arch,omitempty" bson:"arch,omitempty"`
+	// Tolerations enumerates substrate node taints that a Worker or Job
+	// tolerates. This permits Workers or Jobs to be hosted on dedicated or
+	// otherwise special-purpose nodes, for instance, spot instances.
+	Tolerations []Toleration `json:"tolerations,omitempty" bson:"tolerations,omitempty"` // nolint: lll
+	// NodeAffinity enumerates terms that constrain or express preferences for
+	// which substrate nodes host a Worker or Job based on node labels.
+	NodeAffinity []NodeAffinityTerm `json:"nodeAffinity,omitempty" bson:"nodeAffinity,omitempty"` // nolint: lll
+	// PodAffinity enumerates terms that constrain or express preferences for
+	// which substrate nodes host a Worker or Job based on other workloads
+	// already hosted by those nodes.
+	PodAffinity []PodAffinityTerm `json:"podAffinity,omitempty" bson:"podAffinity,omitempty"` // nolint: lll
+	// PriorityClass specifies the substrate-specific priority class of a Worker
+	// or Job.
+	PriorityClass string `json:"priorityClass,omitempty" bson:"priorityClass,omitempty"` // nolint: lll
+}
+
+// EqualTo returns a boolean indicating whether the SchedulingConstraints are
+// equivalent to the specified SchedulingConstraints.
+func (sc SchedulingConstraints) EqualTo(sc2 SchedulingConstraints) bool {
+	// Compare Tolerations slices; if equivalent, nil out
+	if len(sc.Tolerations) != len(sc2.Tolerations) {
+		return false
+	}
+	for i, toleration := range sc.Tolerations {
+		if toleration != sc2.Tolerations[i] {
+			return false
+		}
+	}
+	sc.Tolerations, sc2.Tolerations = nil, nil
+
+	// Compare NodeAffinity slices; if equivalent, nil out
+	if len(sc.NodeAffinity) != len(sc2.NodeAffinity) {
+		return false
+	}
+	for i, term := range sc.NodeAffinity {
+		if !term.EqualTo(sc2.NodeAffinity[i]) {
+			return false
+		}
+	}
+	sc.NodeAffinity, sc2.NodeAffinity = nil, nil
+
+	// Compare PodAffinity slices; if equivalent, nil out
+	if len(sc.PodAffinity) != len(sc2.PodAffinity) {
+		return false
+	}
+	for i, term := range sc.PodAffinity {
+		if !term.EqualTo(sc2.PodAffinity[i]) {
+			return false
+		}
+	}
+	sc.PodAffinity, sc2.PodAffinity = nil, nil
+
+	return reflect.DeepEqual(sc, sc2)
+}
+
+// Toleration represents a substrate node taint that a Worker or Job tolerates.
+type Toleration struct {
+	// Key is the key of the taint that is tolerated.
+	Key string `json:"key" bson:"key"`
+	// Operator specifies the relationship between Key and Value. When empty,
+	// Brigade assumes TolerationOperatorEqual.
+	Operator TolerationOperator `json:"operator,omitempty" bson:"operator,omitempty"` // nolint: lll
+	// Value is the value of the taint that is tolerated. This is ignored if
+	// Operator is TolerationOperatorExists.
+	Value string `json:"value,omitempty" bson:"value,omitempty"`
+	// Effect optionally limits the Toleration to taints having the specified
+	// effect. When empty, taints having any effect are tolerated.
+	Effect string `json:"effect,omitempty" bson:"effect,omitempty"`
+}
+
+// NodeAffinityTerm represents a requirement or preference for substrate
+// nodes having labels matching certain criteria.
+type NodeAffinityTerm struct {
+	// Key is the key of a node label.
+	Key string `json:"key" bson:"key"`
+	// Operator specifies the relationship between the label and Values.
+	Operator AffinityOperator `json:"operator" bson:"operator"`
+	// Values is a set of label values. This must be empty if Operator is
+	// AffinityOperatorExists or AffinityOperatorDoesNotExist.
+	Values []string `json:"values,omitempty" bson:"values,omitempty"`
+	// Weight, when non-zero, indicates that this term expresses a preference
+	// rather than a requirement. When multiple preferences are expressed, nodes
+	// satisfying preferences with greater weights are favored. Valid values are
+	// 0 through 100.
+	Weight int32 `json:"weight,omitempty" bson:"weight,omitempty"`
+}
+
+// EqualTo returns a boolean indicating whether the NodeAffinityTerm is
+// equivalent to the specified NodeAffinityTerm.
+func (n NodeAffinityTerm) EqualTo(n2 NodeAffinityTerm) bool {
+	// Compare Values slices; if equivalent, nil out
+	if len(n.Values) != len(n2.Values) {
+		return false
+	}
+	for i, value := range n.Values {
+		if value != n2.Values[i] {
+			return false
+		}
+	}
+	n.Values, n2.Values = nil, nil
+
+	return reflect.DeepEqual(n, n2)
+}
+
+// PodAffinityTerm represents a requirement or preference for Workers or Jobs
+// to be co-located with (or separated from) other workloads.
+type PodAffinityTerm struct {
+	// MatchLabels selects the workloads with which a Worker or Job should be
+	// co-located (or from which it should be separated).
+	MatchLabels map[string]string `json:"matchLabels" bson:"matchLabels"`
+	// TopologyKey is the key of a node label. Nodes having the same value for
+	// this label are considered co-located.
+	TopologyKey string `json:"topologyKey" bson:"topologyKey"`
+	// Anti indicates that the term expresses anti-affinity; i.e. the Worker or
+	// Job should be separated from, rather than co-located with, the selected
+	// workloads.
+	Anti bool `json:"anti,omitempty" bson:"anti,omitempty"`
+	// Weight, when non-zero, indicates that this term expresses a preference
+	// rather than a requirement. Valid values are 0 through 100.
+	Weight int32 `json:"weight,omitempty" bson:"weight,omitempty"`
+}
+
+// EqualTo returns a boolean indicating whether the PodAffinityTerm is
+// equivalent to the specified PodAffinityTerm.
+func (p PodAffinityTerm) EqualTo(p2 PodAffinityTerm) bool {
+	// Compare MatchLabels maps; if equivalent, nil out
+	if len(p.MatchLabels) != len(p2.MatchLabels) {
+		return false
+	}
+	for k, v := range p.MatchLabels {
+		if v2, ok := p2.MatchLabels[k]; !ok || v != v2 {
+			return false
+		}
+	}
+	p.MatchLabels, p2.MatchLabels = nil, nil
+
+	return reflect.DeepEqual(p, p2)
+}
+
+// authorizeScheduling returns a *meta.ErrAuthorization error if the specified
+// SchedulingConstraints request anything not explicitly permitted by the
+// JobPolicies.
+func (j *JobPolicies) authorizeScheduling(sc SchedulingConstraints) error {
+	var policies JobPolicies
+	if j != nil {
+		policies = *j
+	}
+	if sc.Arch != "" && !Contains(policies.AllowedArchitectures, sc.Arch) {
+		return &meta.ErrAuthorization{
+			Reason: fmt.Sprintf(
+				"Worker configuration forbids jobs from requesting the %q CPU "+
+					"architecture.",
+				sc.Arch,
+			),
+		}
+	}
+	for _, toleration := range sc.Tolerations {
+		if !Contains(policies.AllowedTolerationKeys, toleration.Key) {
+			return &meta.ErrAuthorization{
+				Reason: fmt.Sprintf(
+					"Worker configuration forbids jobs from tolerating taints with "+
+						"key %q.",
+					toleration.Key,
+				),
+			}
+		}
+	}
+	for _, term := range sc.NodeAffinity {
+		if !Contains(policies.AllowedAffinityKeys, term.Key) {
+			return &meta.ErrAuthorization{
+				Reason: fmt.Sprintf(
+					"Worker configuration forbids jobs from expressing affinity for "+
+						"node label %q.",
+					term.Key,
+				),
+			}
+		}
+	}
+	for _, term := range sc.PodAffinity {
+		if !Contains(policies.AllowedAffinityKeys, term.TopologyKey) {
+			return &meta.ErrAuthorization{
+				Reason: fmt.Sprintf(
+					"Worker configuration forbids jobs from expressing affinity using "+
+						"topology key %q.",
+					term.TopologyKey,
+				),
+			}
+		}
+	}
+	if sc.PriorityClass != "" &&
+		!Contains(policies.AllowedPriorityClasses, sc.PriorityClass) {
+		return &meta.ErrAuthorization{
+			Reason: fmt.Sprintf(
+				"Worker configuration forbids jobs from using priority class %q.",
+				sc.PriorityClass,
+			),
+		}
+	}
+	return nil
+}
+
+// authorizeCluster returns a *meta.ErrAuthorization error if the specified
+// cluster is not explicitly permitted by the JobPolicies. An empty cluster
+// name, denoting the cluster that hosts the Worker, is always permitted.
+func (j *JobPolicies) authorizeCluster(cluster string) error {
+	if cluster == "" || (j != nil && Contains(j.AllowedClusters, cluster)) {
+		return nil
+	}
+	return &meta.ErrAuthorization{
+		Reason: fmt.Sprintf(
+			"Worker configuration forbids jobs from being routed to cluster %q.",
+			cluster,
+		),
+	}
+}
+
+// Contains returns a boolean indicating whether the specified slice contains
+// the specified value.
+func Contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
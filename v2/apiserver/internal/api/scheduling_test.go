@@ -0,0 +1,196 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobPoliciesAuthorizeScheduling(t *testing.T) {
+	testPolicies := &JobPolicies{
+		AllowedArchitectures:   []CPUArch{CPUArchARM64},
+		AllowedTolerationKeys:  []string{"spot"},
+		AllowedAffinityKeys:    []string{"topology.kubernetes.io/zone"},
+		AllowedPriorityClasses: []string{"low"},
+	}
+	testCases := []struct {
+		name        string
+		policies    *JobPolicies
+		constraints SchedulingConstraints
+		assertions  func(error)
+	}{
+		{
+			name:     "nil policies with no constraints",
+			policies: nil,
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "nil policies with constraints",
+			policies: nil,
+			constraints: SchedulingConstraints{
+				Arch: CPUArchARM64,
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name:     "architecture not allowed",
+			policies: testPolicies,
+			constraints: SchedulingConstraints{
+				Arch: CPUArchAMD64,
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "amd64")
+			},
+		},
+		{
+			name:     "toleration not allowed",
+			policies: testPolicies,
+			constraints: SchedulingConstraints{
+				Tolerations: []Toleration{
+					{
+						Key: "gpu",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "gpu")
+			},
+		},
+		{
+			name:     "node affinity not allowed",
+			policies: testPolicies,
+			constraints: SchedulingConstraints{
+				NodeAffinity: []NodeAffinityTerm{
+					{
+						Key:      "disktype",
+						Operator: AffinityOperatorExists,
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "disktype")
+			},
+		},
+		{
+			name:     "pod affinity not allowed",
+			policies: testPolicies,
+			constraints: SchedulingConstraints{
+				PodAffinity: []PodAffinityTerm{
+					{
+						TopologyKey: "kubernetes.io/hostname",
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "kubernetes.io/hostname")
+			},
+		},
+		{
+			name:     "priority class not allowed",
+			policies: testPolicies,
+			constraints: SchedulingConstraints{
+				PriorityClass: "high",
+			},
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "high")
+			},
+		},
+		{
+			name:     "all allowed",
+			policies: testPolicies,
+			constraints: SchedulingConstraints{
+				Arch: CPUArchARM64,
+				Tolerations: []Toleration{
+					{
+						Key:      "spot",
+						Operator: TolerationOperatorExists,
+					},
+				},
+				NodeAffinity: []NodeAffinityTerm{
+					{
+						Key:      "topology.kubernetes.io/zone",
+						Operator: AffinityOperatorIn,
+						Values:   []string{"us-east-1a"},
+					},
+				},
+				PodAffinity: []PodAffinityTerm{
+					{
+						MatchLabels: map[string]string{"app": "cache"},
+						TopologyKey: "topology.kubernetes.io/zone",
+					},
+				},
+				PriorityClass: "low",
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				testCase.policies.authorizeScheduling(testCase.constraints),
+			)
+		})
+	}
+}
+
+func TestJobPoliciesAuthorizeCluster(t *testing.T) {
+	testPolicies := &JobPolicies{
+		AllowedClusters: []string{"gpu"},
+	}
+	testCases := []struct {
+		name       string
+		policies   *JobPolicies
+		cluster    string
+		assertions func(error)
+	}{
+		{
+			name:     "nil policies with no cluster",
+			policies: nil,
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "nil policies with cluster",
+			policies: nil,
+			cluster:  "gpu",
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name:     "cluster not allowed",
+			policies: testPolicies,
+			cluster:  "arm",
+			assertions: func(err error) {
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "arm")
+			},
+		},
+		{
+			name:     "cluster allowed",
+			policies: testPolicies,
+			cluster:  "gpu",
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(testCase.policies.authorizeCluster(testCase.cluster))
+		})
+	}
+}
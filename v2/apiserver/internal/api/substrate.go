@@ -56,6 +56,105 @@ func (s SubstrateJobCount) MarshalJSON() ([]byte, error) {
 	)
 }
 
+// SubstrateInventory summarizes the Brigade-managed workloads and storage that
+// currently exist on the substrate, as well as the substrate's capacity to
+// execute them. It is intended to help operators understand why Workers and
+// Jobs may be queued.
+type SubstrateInventory struct {
+	// Projects summarizes the Worker and Job pods and the workspaces belonging to
+	// each Project that currently has any on the substrate.
+	Projects []ProjectSubstrateInventory `json:"projects"`
+	// NodePools summarizes the capacity of each pool of nodes in the substrate.
+	// This will be empty if the substrate has no notion of nodes.
+	NodePools []NodePoolCapacity `json:"nodePools"`
+}
+
+// MarshalJSON amends SubstrateInventory instances with type metadata.
+func (s SubstrateInventory) MarshalJSON() ([]byte, error) {
+	type Alias SubstrateInventory
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "SubstrateInventory",
+			},
+			Alias: (Alias)(s),
+		},
+	)
+}
+
+// ProjectSubstrateInventory summarizes the Worker and Job pods and the
+// workspaces belonging to a single Project on a single cluster.
+type ProjectSubstrateInventory struct {
+	// ProjectID is the identifier of the Project.
+	ProjectID string `json:"projectID"`
+	// Cluster is the name of the cluster being summarized. It is empty for the
+	// default cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Workers summarizes the Project's Worker pods.
+	Workers PodCounts `json:"workers"`
+	// Jobs summarizes the Project's Job pods.
+	Jobs PodCounts `json:"jobs"`
+	// Workspaces summarizes the Project's shared workspaces.
+	Workspaces WorkspaceUsage `json:"workspaces"`
+}
+
+// PodCounts breaks down a number of pods by their status.
+type PodCounts struct {
+	// Pending is the number of pods that have been accepted by the substrate but
+	// are not yet running. Such pods may be awaiting node capacity.
+	Pending int `json:"pending"`
+	// Running is the number of pods that are running.
+	Running int `json:"running"`
+	// Terminating is the number of pods that are in the process of being
+	// deleted.
+	Terminating int `json:"terminating"`
+}
+
+// WorkspaceUsage summarizes a number of shared workspaces.
+type WorkspaceUsage struct {
+	// Count is the number of workspaces.
+	Count int `json:"count"`
+	// Storage is the total storage requested by the workspaces, expressed as a
+	// Kubernetes resource quantity, e.g. "10Gi".
+	Storage string `json:"storage,omitempty"`
+}
+
+// NodePoolCapacity summarizes the capacity of a pool of nodes.
+type NodePoolCapacity struct {
+	// Cluster is the name of the cluster the pool belongs to. It is empty for
+	// the default cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Name is the name of the pool. It is empty for nodes that do not belong to
+	// any identifiable pool.
+	Name string `json:"name,omitempty"`
+	// Nodes is the number of nodes in the pool.
+	Nodes int `json:"nodes"`
+	// ReadyNodes is the number of nodes in the pool that are ready and
+	// schedulable.
+	ReadyNodes int `json:"readyNodes"`
+	// Allocatable is the CPU, memory, and pods allocatable across all ready and
+	// schedulable nodes in the pool.
+	Allocatable NodePoolResources `json:"allocatable"`
+	// Requested is the CPU and memory requested by, and the number of, all
+	// non-terminated pods bound to ready and schedulable nodes in the pool,
+	// including pods that are not managed by Brigade.
+	Requested NodePoolResources `json:"requested"`
+}
+
+// NodePoolResources represents quantities of resources within a pool of nodes.
+type NodePoolResources struct {
+	// CPU is expressed as a Kubernetes resource quantity, e.g. "3500m".
+	CPU string `json:"cpu"`
+	// Memory is expressed as a Kubernetes resource quantity, e.g. "16Gi".
+	Memory string `json:"memory"`
+	// Pods is a number of pods.
+	Pods int `json:"pods"`
+}
+
 // SubstrateService is the specialized interface for monitoring the state of the
 // substrate.
 type SubstrateService interface {
@@ -65,6 +164,10 @@ type SubstrateService interface {
 	// CountRunningJobs returns a count of Jobs currently executing on the
 	// substrate.
 	CountRunningJobs(context.Context) (SubstrateJobCount, error)
+	// GetInventory returns a SubstrateInventory summarizing the Brigade-managed
+	// workloads and storage that currently exist on the substrate and the
+	// substrate's capacity to execute them.
+	GetInventory(context.Context) (SubstrateInventory, error)
 }
 
 type substrateService struct {
@@ -124,6 +227,25 @@ func (s *substrateService) CountRunningJobs(
 	return count, nil
 }
 
+func (s *substrateService) GetInventory(
+	ctx context.Context,
+) (SubstrateInventory, error) {
+	// Unlike the running Worker and Job counts, the inventory reveals details
+	// of every Project and of the underlying nodes, so we require RoleAdmin.
+	if err := s.authorize(ctx, RoleAdmin, ""); err != nil {
+		return SubstrateInventory{}, err
+	}
+
+	inventory, err := s.substrate.GetInventory(ctx)
+	if err != nil {
+		return inventory, errors.Wrap(
+			err,
+			"error retrieving inventory from substrate",
+		)
+	}
+	return inventory, nil
+}
+
 // Substrate is an interface for components that permit services to coordinate
 // with Brigade's underlying workload execution substrate, i.e. Kubernetes.
 type Substrate interface {
@@ -133,14 +255,34 @@ type Substrate interface {
 	// CountRunningJobs returns a count of Jobs currently executing on the
 	// substrate.
 	CountRunningJobs(context.Context) (SubstrateJobCount, error)
+	// GetInventory returns a SubstrateInventory summarizing the Brigade-managed
+	// workloads and storage that currently exist on the substrate and the
+	// substrate's capacity to execute them.
+	GetInventory(context.Context) (SubstrateInventory, error)
+
+	// DefaultImagePolicy returns the operator-specified ImagePolicy that applies
+	// to all Workers and Jobs in addition to any ImagePolicy specified by Worker
+	// configuration. It returns nil if no such policy exists.
+	DefaultImagePolicy() *ImagePolicy
 
 	// CreateProject prepares the substrate to host Project workloads. The
 	// provided Project argument may be amended with substrate-specific details
 	// and returned, so this function should be called prior to a Project being
 	// initially persisted so that substrate-specific details will be included.
 	CreateProject(context.Context, Project) (Project, error)
+	// UpdateProject brings the substrate's Project-related resources into sync
+	// with the provided, updated Project. This should be called prior to the
+	// updated Project being persisted so that a Project the substrate cannot
+	// accommodate is never persisted.
+	UpdateProject(context.Context, Project) error
 	// DeleteProject removes all Project-related resources from the substrate.
 	DeleteProject(context.Context, Project) error
+	// DeleteCacheVolume removes the specified Project cache volume from the
+	// substrate, thereby discarding its contents. The cache volume will be
+	// provisioned anew, and empty, the next time a Job requires it.
+	// Implementations that cannot discard a cache volume that is still in use
+	// MUST return a *meta.ErrConflict error in that case.
+	DeleteCacheVolume(ctx context.Context, project Project, name string) error
 
 	// ScheduleWorker prepares the substrate for the Event's worker and schedules
 	// the Worker for async / eventual execution.
@@ -171,12 +313,15 @@ type Substrate interface {
 		event Event,
 		jobName string,
 	) error
-	// StartJob starts a Job on the substrate.
+	// StartJob starts a Job on the substrate. The specified token MUST be made
+	// available to the Job's primary container so that it can report the Job's
+	// progress.
 	StartJob(
 		ctx context.Context,
 		project Project,
 		event Event,
 		jobName string,
+		token string,
 	) error
 
 	// DeleteJob deletes all substrate resources pertaining to the specified Job.
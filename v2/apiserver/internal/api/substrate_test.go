@@ -26,6 +26,14 @@ func TestSubstrateJobCountMarshalJSON(t *testing.T) {
 	)
 }
 
+func TestSubstrateInventoryMarshalJSON(t *testing.T) {
+	metaTesting.RequireAPIVersionAndType(
+		t,
+		&SubstrateInventory{},
+		"SubstrateInventory",
+	)
+}
+
 func TestNewSubstrateService(t *testing.T) {
 	substrate := &mockSubstrate{}
 	svc, ok := NewSubstrateService(alwaysAuthorize, substrate).(*substrateService)
@@ -168,14 +176,89 @@ func TestSubstrateServiceCountRunningJobs(t *testing.T) {
 	}
 }
 
+func TestSubstrateServiceGetInventory(t *testing.T) {
+	testCases := []struct {
+		name       string
+		service    SubstrateService
+		assertions func(SubstrateInventory, error)
+	}{
+		{
+			name: "unauthorized",
+			service: &substrateService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(_ SubstrateInventory, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error retrieving inventory from substrate",
+			service: &substrateService{
+				authorize: alwaysAuthorize,
+				substrate: &mockSubstrate{
+					GetInventoryFn: func(context.Context) (SubstrateInventory, error) {
+						return SubstrateInventory{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(_ SubstrateInventory, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(
+					t,
+					err.Error(),
+					"error retrieving inventory from substrate",
+				)
+			},
+		},
+		{
+			name: "success",
+			service: &substrateService{
+				authorize: alwaysAuthorize,
+				substrate: &mockSubstrate{
+					GetInventoryFn: func(context.Context) (SubstrateInventory, error) {
+						return SubstrateInventory{
+							Projects: []ProjectSubstrateInventory{
+								{
+									ProjectID: "italian",
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(inventory SubstrateInventory, err error) {
+				require.NoError(t, err)
+				require.Len(t, inventory.Projects, 1)
+				require.Equal(t, "italian", inventory.Projects[0].ProjectID)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			inventory, err := testCase.service.GetInventory(context.Background())
+			testCase.assertions(inventory, err)
+		})
+	}
+}
+
 type mockSubstrate struct {
 	CountRunningWorkersFn func(context.Context) (SubstrateWorkerCount, error)
 	CountRunningJobsFn    func(context.Context) (SubstrateJobCount, error)
+	GetInventoryFn        func(context.Context) (SubstrateInventory, error)
+	DefaultImagePolicyFn  func() *ImagePolicy
 	CreateProjectFn       func(
 		ctx context.Context,
 		project Project,
 	) (Project, error)
-	DeleteProjectFn       func(context.Context, Project) error
+	UpdateProjectFn     func(context.Context, Project) error
+	DeleteProjectFn     func(context.Context, Project) error
+	DeleteCacheVolumeFn func(
+		ctx context.Context,
+		project Project,
+		name string,
+	) error
 	ScheduleWorkerFn      func(context.Context, Event) error
 	StartWorkerFn         func(context.Context, Project, Event, string) error
 	StoreJobEnvironmentFn func(
@@ -196,6 +279,7 @@ type mockSubstrate struct {
 		project Project,
 		event Event,
 		jobName string,
+		token string,
 	) error
 	DeleteJobFn func(
 		ctx context.Context,
@@ -218,6 +302,19 @@ func (m *mockSubstrate) CountRunningJobs(
 	return m.CountRunningJobsFn(ctx)
 }
 
+func (m *mockSubstrate) GetInventory(
+	ctx context.Context,
+) (SubstrateInventory, error) {
+	return m.GetInventoryFn(ctx)
+}
+
+func (m *mockSubstrate) DefaultImagePolicy() *ImagePolicy {
+	if m.DefaultImagePolicyFn == nil {
+		return nil
+	}
+	return m.DefaultImagePolicyFn()
+}
+
 func (m *mockSubstrate) CreateProject(
 	ctx context.Context,
 	project Project,
@@ -225,6 +322,13 @@ func (m *mockSubstrate) CreateProject(
 	return m.CreateProjectFn(ctx, project)
 }
 
+func (m *mockSubstrate) UpdateProject(
+	ctx context.Context,
+	project Project,
+) error {
+	return m.UpdateProjectFn(ctx, project)
+}
+
 func (m *mockSubstrate) DeleteProject(
 	ctx context.Context,
 	project Project,
@@ -232,6 +336,14 @@ func (m *mockSubstrate) DeleteProject(
 	return m.DeleteProjectFn(ctx, project)
 }
 
+func (m *mockSubstrate) DeleteCacheVolume(
+	ctx context.Context,
+	project Project,
+	name string,
+) error {
+	return m.DeleteCacheVolumeFn(ctx, project, name)
+}
+
 func (m *mockSubstrate) ScheduleWorker(
 	ctx context.Context,
 	event Event,
@@ -272,8 +384,9 @@ func (m *mockSubstrate) StartJob(
 	project Project,
 	event Event,
 	jobName string,
+	token string,
 ) error {
-	return m.StartJobFn(ctx, project, event, jobName)
+	return m.StartJobFn(ctx, project, event, jobName, token)
 }
 
 func (m *mockSubstrate) DeleteJob(
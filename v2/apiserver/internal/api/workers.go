@@ -47,6 +47,10 @@ const (
 	// WorkerPhaseSucceeded represents the state where a Worker has run to
 	// completion without error.
 	WorkerPhaseSucceeded WorkerPhase = "SUCCEEDED"
+	// WorkerPhaseSuspended represents the state wherein a running Worker has been
+	// suspended. While suspended, the Worker's existing Jobs continue to run, but
+	// no new Jobs are started until the Worker is resumed.
+	WorkerPhaseSuspended WorkerPhase = "SUSPENDED"
 	// WorkerPhaseTimedOut represents the state wherein a Worker has has not
 	// completed within a designated timeframe.
 	WorkerPhaseTimedOut WorkerPhase = "TIMED_OUT"
@@ -71,6 +75,7 @@ func WorkerPhasesAll() []WorkerPhase {
 		WorkerPhaseSchedulingFailed,
 		WorkerPhaseStarting,
 		WorkerPhaseSucceeded,
+		WorkerPhaseSuspended,
 		WorkerPhaseTimedOut,
 		WorkerPhaseUnknown,
 	}
@@ -141,6 +146,9 @@ type WorkerSpec struct {
 	Git *GitConfig `json:"git,omitempty"`
 	// Kubernetes contains Kubernetes-specific Worker details.
 	Kubernetes *KubernetesConfig `json:"kubernetes,omitempty" bson:"kubernetes,omitempty"` // nolint: lll
+	// Scheduling specifies criteria, beyond those configured for the substrate as
+	// a whole, for selecting a suitable substrate node for the Worker.
+	Scheduling *SchedulingConstraints `json:"scheduling,omitempty" bson:"scheduling,omitempty"` // nolint: lll
 	// JobPolicies specifies policies for any Jobs spawned by the Worker.
 	JobPolicies *JobPolicies `json:"jobPolicies,omitempty" bson:"jobPolicies,omitempty"` // nolint: lll
 	// LogLevel specifies the desired granularity of Worker log output.
@@ -195,6 +203,37 @@ type JobPolicies struct {
 	// AllowPrivileged specifies whether the Worker is permitted to launch Jobs
 	// that utilize privileged containers.
 	AllowPrivileged bool `json:"allowPrivileged" bson:"allowPrivileged"`
+	// Security specifies minimum security requirements that all of a Job's
+	// containers must meet.
+	Security *JobSecurityPolicy `json:"security,omitempty" bson:"security,omitempty"` // nolint: lll
+	// AllowedSecretKeys enumerates the only Project Secrets that Jobs may
+	// reference by key, either as environment variables or as mounted files.
+	// When empty, Jobs may not reference any Project Secret.
+	AllowedSecretKeys []string `json:"allowedSecretKeys,omitempty" bson:"allowedSecretKeys,omitempty"` // nolint: lll
+	// AllowedArchitectures enumerates the CPU architectures that Jobs may
+	// request. Jobs requesting no particular architecture are always permitted.
+	AllowedArchitectures []CPUArch `json:"allowedArchitectures,omitempty" bson:"allowedArchitectures,omitempty"` // nolint: lll
+	// AllowedTolerationKeys enumerates the keys of substrate node taints that
+	// Jobs may tolerate.
+	AllowedTolerationKeys []string `json:"allowedTolerationKeys,omitempty" bson:"allowedTolerationKeys,omitempty"` // nolint: lll
+	// AllowedAffinityKeys enumerates the node label keys that Jobs may use in
+	// node affinity terms or as topology keys in pod affinity terms.
+	AllowedAffinityKeys []string `json:"allowedAffinityKeys,omitempty" bson:"allowedAffinityKeys,omitempty"` // nolint: lll
+	// AllowedPriorityClasses enumerates the substrate-specific priority classes
+	// that Jobs may use.
+	AllowedPriorityClasses []string `json:"allowedPriorityClasses,omitempty" bson:"allowedPriorityClasses,omitempty"` // nolint: lll
+	// AllowedClusters enumerates the names of the substrate's Kubernetes
+	// clusters, other than the one hosting the Worker, that Jobs may be routed
+	// to.
+	AllowedClusters []string `json:"allowedClusters,omitempty" bson:"allowedClusters,omitempty"` // nolint: lll
+	// AllowedServiceAccounts enumerates the names of the Project's
+	// JobServiceAccounts that Jobs may explicitly request to run as. Jobs that do
+	// not request one are always permitted and run as the Project's default.
+	AllowedServiceAccounts []string `json:"allowedServiceAccounts,omitempty" bson:"allowedServiceAccounts,omitempty"` // nolint: lll
+	// Images specifies restrictions on the OCI images that the Worker and any
+	// Jobs it spawns may use. When not specified, the substrate's default
+	// ImagePolicy, if any, applies.
+	Images *ImagePolicy `json:"images,omitempty" bson:"images,omitempty"`
 	// AllowDockerSocketMount specifies whether the Worker is permitted to launch
 	// Jobs that mount the underlying host's Docker socket into its own file
 	// system.
@@ -210,6 +249,40 @@ type JobPolicies struct {
 	// AllowDockerSocketMount bool `json:"allowDockerSocketMount" bson:"allowDockerSocketMount"`
 }
 
+// JobSecurityPolicy represents minimum security requirements for Job
+// containers. Containers that do not explicitly meet these requirements via
+// their own security context are rejected.
+type JobSecurityPolicy struct {
+	// RequireRunAsNonRoot specifies whether Job containers must run as non-root.
+	RequireRunAsNonRoot bool `json:"requireRunAsNonRoot,omitempty" bson:"requireRunAsNonRoot,omitempty"` // nolint: lll
+	// RequireReadOnlyRootFilesystem specifies whether Job containers must use a
+	// read-only root file system.
+	RequireReadOnlyRootFilesystem bool `json:"requireReadOnlyRootFilesystem,omitempty" bson:"requireReadOnlyRootFilesystem,omitempty"` // nolint: lll
+	// RequiredDroppedCapabilities enumerates Linux capabilities that Job
+	// containers must drop. Containers dropping "ALL" capabilities satisfy this
+	// requirement.
+	RequiredDroppedCapabilities []string `json:"requiredDroppedCapabilities,omitempty" bson:"requiredDroppedCapabilities,omitempty"` // nolint: lll
+	// RequireRuntimeDefaultSeccompProfile specifies whether Job containers must
+	// use the container runtime's default seccomp profile.
+	RequireRuntimeDefaultSeccompProfile bool `json:"requireRuntimeDefaultSeccompProfile,omitempty" bson:"requireRuntimeDefaultSeccompProfile,omitempty"` // nolint: lll
+	// AllowPrivileged specifies whether Job containers may run privileged
+	// despite this policy. A privileged container can circumvent every other
+	// requirement, so such containers are rejected unless this is set. Note that
+	// JobPolicies must independently permit privileged containers.
+	AllowPrivileged bool `json:"allowPrivileged,omitempty" bson:"allowPrivileged,omitempty"` // nolint: lll
+}
+
+// secretKeyAllowed returns a boolean indicating whether Jobs are permitted to
+// reference the Project Secret having the specified key.
+func (j JobPolicies) secretKeyAllowed(key string) bool {
+	for _, allowedKey := range j.AllowedSecretKeys {
+		if allowedKey == key {
+			return true
+		}
+	}
+	return false
+}
+
 // WorkerStatus represents the status of a Worker.
 type WorkerStatus struct {
 	// Started indicates the time the Worker began execution. It will be nil for
@@ -220,6 +293,16 @@ type WorkerStatus struct {
 	Ended *time.Time `json:"ended,omitempty" bson:"ended,omitempty"`
 	// Phase indicates where the Worker is in its lifecycle.
 	Phase WorkerPhase `json:"phase,omitempty" bson:"phase,omitempty"`
+	// LastHeartbeat indicates the time at which the Worker most recently
+	// reported that it is alive. It will be nil for a Worker that has never
+	// sent a heartbeat.
+	LastHeartbeat *time.Time `json:"lastHeartbeat,omitempty" bson:"lastHeartbeat,omitempty"` // nolint: lll
+	// Reason optionally explains, in human-readable terms, why the Worker is in
+	// its current phase; for instance, why it failed.
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+	// ResumePhase indicates the phase a suspended Worker will return to when it
+	// is resumed. It is empty for a Worker that is not suspended.
+	ResumePhase WorkerPhase `json:"resumePhase,omitempty" bson:"resumePhase,omitempty"` // nolint: lll
 }
 
 // WorkersService is the specialized interface for managing Workers. It's
@@ -229,7 +312,9 @@ type WorkerStatus struct {
 type WorkersService interface {
 	// Start starts the indicated Event's Worker on Brigade's workload
 	// execution substrate. If the specified Event does not exist, implementations
-	// MUST return a *meta.ErrNotFound.
+	// MUST return a *meta.ErrNotFound. If the Worker's image is not permitted by
+	// the applicable ImagePolicy, implementations MUST fail the Worker and return
+	// a *meta.ErrAuthorization.
 	Start(ctx context.Context, eventID string) error
 	// GetStatus returns an Event's Worker's status. If the specified Event does
 	// not exist, implementations MUST return a *meta.ErrNotFound.
@@ -246,12 +331,20 @@ type WorkersService interface {
 		eventID string,
 	) (<-chan WorkerStatus, error)
 	// UpdateStatus updates the status of an Event's Worker. If the specified
-	// Event does not exist, implementations MUST return a *meta.ErrNotFound.
+	// Event does not exist, implementations MUST return a *meta.ErrNotFound. If
+	// the Worker has already reached a terminal phase or its phase is changed
+	// concurrently too many times, implementations MUST return a
+	// *meta.ErrConflict.
 	UpdateStatus(
 		ctx context.Context,
 		eventID string,
 		status WorkerStatus,
 	) error
+	// Heartbeat records that an Event's Worker is alive. If the specified Event
+	// does not exist, implementations MUST return a *meta.ErrNotFound. If the
+	// Worker has already reached a terminal phase, implementations MUST return
+	// a *meta.ErrConflict.
+	Heartbeat(ctx context.Context, eventID string) error
 	// Cleanup removes Worker-related resources from the substrate, presumably
 	// upon completion, without deleting the Worker from the data store.
 	Cleanup(ctx context.Context, eventID string) error
@@ -315,6 +408,37 @@ func (w *workersService) Start(ctx context.Context, eventID string) error {
 		)
 	}
 
+	// Fail the Worker if it uses an image that isn't permitted. Images are only
+	// checked if they were explicitly specified, since the default Worker image
+	// is chosen by the operator.
+	if event.Worker.Spec.Container != nil &&
+		event.Worker.Spec.Container.Image != "" {
+		if err = imagePolicy(
+			event.Worker.Spec.JobPolicies,
+			w.substrate.DefaultImagePolicy(),
+		).authorizeImage("worker", event.Worker.Spec.Container.Image); err != nil {
+			reason := err.Error()
+			if authzErr, ok := err.(*meta.ErrAuthorization); ok {
+				reason = authzErr.Reason
+			}
+			if updateErr := w.workersStore.UpdateStatus(
+				ctx,
+				eventID,
+				WorkerStatus{
+					Phase:  WorkerPhaseFailed,
+					Reason: reason,
+				},
+			); updateErr != nil {
+				return errors.Wrapf(
+					updateErr,
+					"error updating status of event %q worker in store",
+					eventID,
+				)
+			}
+			return err
+		}
+	}
+
 	// This is a token unique to the Event so that the Event's Worker can use when
 	// communicating with the API server to do things like spawn a new Job. i.e.
 	// Only THIS event's worker can create new Jobs for THIS event.
@@ -423,6 +547,37 @@ func (w *workersService) UpdateStatus(
 	return w.updateStatus(ctx, event, status)
 }
 
+func (w *workersService) Heartbeat(
+	ctx context.Context,
+	eventID string,
+) error {
+	if err := w.authorize(ctx, RoleWorker, eventID); err != nil {
+		return err
+	}
+
+	event, err := w.eventsStore.Get(ctx, eventID)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving event %q from store", eventID)
+	}
+
+	if event.Worker.Status.Phase.IsTerminal() {
+		return &meta.ErrConflict{
+			Type: EventKind,
+			ID:   event.ID,
+			Reason: fmt.Sprintf(
+				"Event %q worker has already reached a terminal phase.",
+				event.ID,
+			),
+		}
+	}
+
+	return errors.Wrapf(
+		w.workersStore.UpdateHeartbeat(ctx, eventID, time.Now().UTC()),
+		"error recording heartbeat of event %q worker in store",
+		eventID,
+	)
+}
+
 func (w *workersService) Cleanup(
 	ctx context.Context,
 	eventID string,
@@ -459,6 +614,12 @@ func (w *workersService) Timeout(
 	return w.cleanup(ctx, event)
 }
 
+// maxWorkerStatusUpdateAttempts is the number of times an update to a
+// Worker's status is attempted before giving up because the Worker's phase
+// keeps changing concurrently; for instance, because it is being suspended
+// or resumed.
+const maxWorkerStatusUpdateAttempts = 3
+
 // updateStatus is an internal helper func created so that multiple exported
 // functions can share this logic after they've retrieved specified events.
 func (w *workersService) updateStatus(
@@ -466,27 +627,56 @@ func (w *workersService) updateStatus(
 	event Event,
 	status WorkerStatus,
 ) error {
-	// We have a conflict if the worker's phase is already terminal
-	if event.Worker.Status.Phase.IsTerminal() {
-		return &meta.ErrConflict{
-			Type: EventKind,
-			ID:   event.ID,
-			Reason: fmt.Sprintf(
-				"Event %q worker has already reached a terminal phase.",
-				event.ID,
-			),
+	reportedPhase := status.Phase
+	for attempt := 1; ; attempt++ {
+		// We have a conflict if the worker's phase is already terminal
+		if event.Worker.Status.Phase.IsTerminal() {
+			return &meta.ErrConflict{
+				Type: EventKind,
+				ID:   event.ID,
+				Reason: fmt.Sprintf(
+					"Event %q worker has already reached a terminal phase.",
+					event.ID,
+				),
+			}
 		}
-	}
 
-	return errors.Wrapf(
-		w.workersStore.UpdateStatus(
+		// Whoever is updating the status (usually the observer) has no knowledge
+		// of whether the Worker has been suspended, so unless the Worker has
+		// reached a terminal phase, don't let it be inadvertently resumed.
+		// Instead, remember the reported phase so the Worker returns to it when it
+		// is resumed.
+		status.Phase = reportedPhase
+		status.ResumePhase = ""
+		if event.Worker.Status.Phase == WorkerPhaseSuspended &&
+			!reportedPhase.IsTerminal() {
+			status.ResumePhase = reportedPhase
+			status.Phase = WorkerPhaseSuspended
+		}
+
+		// The update only succeeds if the Worker is still in the phase that was
+		// read above. Otherwise, the Worker was, for instance, suspended or
+		// resumed in the meantime, so the Event is read again and the update is
+		// retried.
+		err := w.workersStore.UpdateStatusFromPhase(
 			ctx,
 			event.ID,
+			event.Worker.Status.Phase,
 			status,
-		),
-		"error updating status of event %q worker in store",
-		event.ID,
-	)
+		)
+		if _, ok := errors.Cause(err).(*meta.ErrConflict); !ok ||
+			attempt == maxWorkerStatusUpdateAttempts {
+			return errors.Wrapf(
+				err,
+				"error updating status of event %q worker in store",
+				event.ID,
+			)
+		}
+		eventID := event.ID
+		if event, err = w.eventsStore.Get(ctx, eventID); err != nil {
+			return errors.Wrapf(err, "error retrieving event %q from store", eventID)
+		}
+	}
 }
 
 // cleanup is an internal helper func created so that multiple exported
@@ -517,11 +707,29 @@ type WorkersStore interface {
 		status WorkerStatus,
 	) error
 
+	// UpdateStatusFromPhase updates the status of an Event's Worker, but only if
+	// the Worker is still in the specified phase. The Worker's last heartbeat is
+	// left untouched unless the specified status includes one. If the Worker is
+	// not in the specified phase, implementations MUST return a
+	// *meta.ErrConflict.
+	UpdateStatusFromPhase(
+		ctx context.Context,
+		eventID string,
+		fromPhase WorkerPhase,
+		status WorkerStatus,
+	) error
+
 	UpdateHashedToken(
 		ctx context.Context,
 		eventID string,
 		hashedToken string,
 	) error
 
+	UpdateHeartbeat(
+		ctx context.Context,
+		eventID string,
+		heartbeat time.Time,
+	) error
+
 	Timeout(ctx context.Context, eventID string) error
 }
@@ -111,6 +111,56 @@ func TestWorkersServiceStart(t *testing.T) {
 				require.Contains(t, err.Error(), "something went wrong")
 			},
 		},
+		{
+			name: "image not allowed",
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Spec: WorkerSpec{
+									Container: &ContainerSpec{
+										Image: "debian:latest",
+									},
+								},
+								Status: WorkerStatus{
+									Phase: WorkerPhasePending,
+								},
+							},
+						}, nil
+					},
+				},
+				projectsStore: &mockProjectsStore{
+					GetFn: func(context.Context, string) (Project, error) {
+						return Project{}, nil
+					},
+				},
+				workersStore: &mockWorkersStore{
+					UpdateStatusFn: func(
+						_ context.Context,
+						_ string,
+						status WorkerStatus,
+					) error {
+						require.Equal(t, WorkerPhaseFailed, status.Phase)
+						require.Contains(t, status.Reason, "debian:latest")
+						return nil
+					},
+				},
+				substrate: &mockSubstrate{
+					DefaultImagePolicyFn: func() *ImagePolicy {
+						return &ImagePolicy{
+							AllowedRegistries: []string{"ghcr.io"},
+						}
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+				require.Contains(t, err.Error(), "debian:latest")
+			},
+		},
 		{
 			name: "error updating hashed token",
 			service: &workersService{
@@ -409,6 +459,7 @@ func TestWorkersServiceUpdateStatus(t *testing.T) {
 	testEventID := "123456789"
 	testCases := []struct {
 		name       string
+		status     WorkerStatus
 		service    WorkersService
 		assertions func(error)
 	}{
@@ -448,7 +499,12 @@ func TestWorkersServiceUpdateStatus(t *testing.T) {
 					},
 				},
 				workersStore: &mockWorkersStore{
-					UpdateStatusFn: func(context.Context, string, WorkerStatus) error {
+					UpdateStatusFromPhaseFn: func(
+						context.Context,
+						string,
+						WorkerPhase,
+						WorkerStatus,
+					) error {
 						return errors.New("something went wrong")
 					},
 				},
@@ -475,10 +531,15 @@ func TestWorkersServiceUpdateStatus(t *testing.T) {
 					},
 				},
 				workersStore: &mockWorkersStore{
-					UpdateStatusFn: func(context.Context, string, WorkerStatus) error {
+					UpdateStatusFromPhaseFn: func(
+						context.Context,
+						string,
+						WorkerPhase,
+						WorkerStatus,
+					) error {
 						require.Fail(
 							t,
-							"UpdateStatusFn should not have been called, but was",
+							"UpdateStatusFromPhaseFn should not have been called, but was",
 						)
 						return nil
 					},
@@ -493,6 +554,117 @@ func TestWorkersServiceUpdateStatus(t *testing.T) {
 				)
 			},
 		},
+		{
+			name: "phase changes concurrently",
+			status: WorkerStatus{
+				Phase: WorkerPhaseRunning,
+			},
+			service: func() WorkersService {
+				reads := 0
+				return &workersService{
+					authorize: alwaysAuthorize,
+					eventsStore: &mockEventsStore{
+						GetFn: func(context.Context, string) (Event, error) {
+							reads++
+							phase := WorkerPhaseStarting
+							if reads > 1 {
+								// The worker was suspended after the first read
+								phase = WorkerPhaseSuspended
+							}
+							return Event{
+								Worker: Worker{
+									Status: WorkerStatus{
+										Phase: phase,
+									},
+								},
+							}, nil
+						},
+					},
+					workersStore: &mockWorkersStore{
+						UpdateStatusFromPhaseFn: func(
+							_ context.Context,
+							_ string,
+							fromPhase WorkerPhase,
+							status WorkerStatus,
+						) error {
+							if fromPhase == WorkerPhaseStarting {
+								return &meta.ErrConflict{}
+							}
+							require.Equal(t, WorkerPhaseSuspended, fromPhase)
+							require.Equal(t, WorkerPhaseSuspended, status.Phase)
+							require.Equal(t, WorkerPhaseRunning, status.ResumePhase)
+							return nil
+						},
+					},
+				}
+			}(),
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "phase keeps changing concurrently",
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, nil
+					},
+				},
+				workersStore: &mockWorkersStore{
+					UpdateStatusFromPhaseFn: func(
+						context.Context,
+						string,
+						WorkerPhase,
+						WorkerStatus,
+					) error {
+						return &meta.ErrConflict{}
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				conflictErr := &meta.ErrConflict{}
+				require.ErrorAs(t, err, &conflictErr)
+			},
+		},
+		{
+			name: "suspended phase is preserved",
+			status: WorkerStatus{
+				Phase: WorkerPhaseRunning,
+			},
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: WorkerPhaseSuspended,
+								},
+							},
+						}, nil
+					},
+				},
+				workersStore: &mockWorkersStore{
+					UpdateStatusFromPhaseFn: func(
+						_ context.Context,
+						_ string,
+						fromPhase WorkerPhase,
+						status WorkerStatus,
+					) error {
+						require.Equal(t, WorkerPhaseSuspended, fromPhase)
+						require.Equal(t, WorkerPhaseSuspended, status.Phase)
+						// The reported phase is restored when the worker is resumed
+						require.Equal(t, WorkerPhaseRunning, status.ResumePhase)
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "success",
 			service: &workersService{
@@ -503,7 +675,12 @@ func TestWorkersServiceUpdateStatus(t *testing.T) {
 					},
 				},
 				workersStore: &mockWorkersStore{
-					UpdateStatusFn: func(context.Context, string, WorkerStatus) error {
+					UpdateStatusFromPhaseFn: func(
+						context.Context,
+						string,
+						WorkerPhase,
+						WorkerStatus,
+					) error {
 						return nil
 					},
 				},
@@ -518,13 +695,128 @@ func TestWorkersServiceUpdateStatus(t *testing.T) {
 			err := testCase.service.UpdateStatus(
 				context.Background(),
 				testEventID,
-				WorkerStatus{},
+				testCase.status,
 			)
 			testCase.assertions(err)
 		})
 	}
 }
 
+func TestWorkersServiceHeartbeat(t *testing.T) {
+	const testEventID = "123456789"
+	testCases := []struct {
+		name       string
+		service    WorkersService
+		assertions func(error)
+	}{
+		{
+			name: "unauthorized",
+			service: &workersService{
+				authorize: neverAuthorize,
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrAuthorization{}, err)
+			},
+		},
+		{
+			name: "error retrieving event from store",
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{}, errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error retrieving event")
+			},
+		},
+		{
+			name: "worker's phase already terminal",
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: WorkerPhaseFailed,
+								},
+							},
+						}, nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrConflict{}, err)
+			},
+		},
+		{
+			name: "error updating heartbeat in store",
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: WorkerPhaseRunning,
+								},
+							},
+						}, nil
+					},
+				},
+				workersStore: &mockWorkersStore{
+					UpdateHeartbeatFn: func(context.Context, string, time.Time) error {
+						return errors.New("something went wrong")
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error recording heartbeat")
+			},
+		},
+		{
+			name: "success",
+			service: &workersService{
+				authorize: alwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(context.Context, string) (Event, error) {
+						return Event{
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: WorkerPhaseRunning,
+								},
+							},
+						}, nil
+					},
+				},
+				workersStore: &mockWorkersStore{
+					UpdateHeartbeatFn: func(context.Context, string, time.Time) error {
+						return nil
+					},
+				},
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.service.Heartbeat(context.Background(), testEventID)
+			testCase.assertions(err)
+		})
+	}
+}
+
 func TestWorkersServiceCleanup(t *testing.T) {
 	const testEventID = "123456789"
 	testCases := []struct {
@@ -784,12 +1076,25 @@ type mockWorkersStore struct {
 		status WorkerStatus,
 	) error
 
+	UpdateStatusFromPhaseFn func(
+		ctx context.Context,
+		eventID string,
+		fromPhase WorkerPhase,
+		status WorkerStatus,
+	) error
+
 	UpdateHashedTokenFn func(
 		ctx context.Context,
 		eventID string,
 		hashedToken string,
 	) error
 
+	UpdateHeartbeatFn func(
+		ctx context.Context,
+		eventID string,
+		heartbeat time.Time,
+	) error
+
 	TimeoutFn func(ctx context.Context, eventID string) error
 }
 
@@ -801,6 +1106,15 @@ func (m *mockWorkersStore) UpdateStatus(
 	return m.UpdateStatusFn(ctx, eventID, status)
 }
 
+func (m *mockWorkersStore) UpdateStatusFromPhase(
+	ctx context.Context,
+	eventID string,
+	fromPhase WorkerPhase,
+	status WorkerStatus,
+) error {
+	return m.UpdateStatusFromPhaseFn(ctx, eventID, fromPhase, status)
+}
+
 func (m *mockWorkersStore) UpdateHashedToken(
 	ctx context.Context,
 	eventID string,
@@ -809,6 +1123,14 @@ func (m *mockWorkersStore) UpdateHashedToken(
 	return m.UpdateHashedTokenFn(ctx, eventID, hashedToken)
 }
 
+func (m *mockWorkersStore) UpdateHeartbeat(
+	ctx context.Context,
+	eventID string,
+	heartbeat time.Time,
+) error {
+	return m.UpdateHeartbeatFn(ctx, eventID, heartbeat)
+}
+
 func (m *mockWorkersStore) Timeout(ctx context.Context, eventID string) error {
 	return m.TimeoutFn(ctx, eventID)
 }
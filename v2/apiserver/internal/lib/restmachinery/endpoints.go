@@ -104,6 +104,35 @@ func ReadAndValidateRequestBody(
 	return true
 }
 
+// ValidateObject validates the JSON representation of the provided object
+// against the provided gojsonschema.JSONLoader. If the object fails validation,
+// a *meta.ErrBadRequest error enumerating the validation errors is returned.
+// This is useful for validating objects that the API server constructs or
+// modifies on the basis of a request body that was already validated.
+func ValidateObject(
+	schemaLoader gojsonschema.JSONLoader,
+	obj interface{},
+) error {
+	validationResult, err := gojsonschema.Validate(
+		schemaLoader,
+		gojsonschema.NewGoLoader(obj),
+	)
+	if err != nil {
+		return errors.Wrap(err, "error validating object")
+	}
+	if !validationResult.Valid() {
+		verrStrs := make([]string, len(validationResult.Errors()))
+		for i, verr := range validationResult.Errors() {
+			verrStrs[i] = verr.String()
+		}
+		return &meta.ErrBadRequest{
+			Reason:  "Object failed JSON validation",
+			Details: verrStrs,
+		}
+	}
+	return nil
+}
+
 // ServeRequest handles an inbound REST API request as specified by the given
 // InboundRequest. Handling includes, if applicable, request body validation,
 // unmarshaling, execution of endpoint-specific logic, and response marshaling.
@@ -122,34 +151,40 @@ func ServeRequest(req InboundRequest) {
 	}
 	respBodyObj, err := req.EndpointLogic()
 	if err != nil {
-		switch e := errors.Cause(err).(type) {
-		case *meta.ErrAuthentication:
-			WriteAPIResponse(req.W, http.StatusUnauthorized, e)
-		case *meta.ErrAuthorization:
-			WriteAPIResponse(req.W, http.StatusForbidden, e)
-		case *meta.ErrBadRequest:
-			WriteAPIResponse(req.W, http.StatusBadRequest, e)
-		case *meta.ErrNotFound:
-			WriteAPIResponse(req.W, http.StatusNotFound, e)
-		case *meta.ErrConflict:
-			WriteAPIResponse(req.W, http.StatusConflict, e)
-		case *meta.ErrNotSupported:
-			WriteAPIResponse(req.W, http.StatusNotImplemented, e)
-		case *meta.ErrInternalServer:
-			WriteAPIResponse(req.W, http.StatusInternalServerError, e)
-		default:
-			log.Println(err)
-			WriteAPIResponse(
-				req.W,
-				http.StatusInternalServerError,
-				&meta.ErrInternalServer{},
-			)
-		}
+		WriteAPIErrorResponse(req.W, err)
 		return
 	}
 	WriteAPIResponse(req.W, req.SuccessCode, respBodyObj)
 }
 
+// WriteAPIErrorResponse sends a response to the client with an HTTP status code
+// and response body appropriate to the specified error.
+func WriteAPIErrorResponse(w http.ResponseWriter, err error) {
+	switch e := errors.Cause(err).(type) {
+	case *meta.ErrAuthentication:
+		WriteAPIResponse(w, http.StatusUnauthorized, e)
+	case *meta.ErrAuthorization:
+		WriteAPIResponse(w, http.StatusForbidden, e)
+	case *meta.ErrBadRequest:
+		WriteAPIResponse(w, http.StatusBadRequest, e)
+	case *meta.ErrNotFound:
+		WriteAPIResponse(w, http.StatusNotFound, e)
+	case *meta.ErrConflict:
+		WriteAPIResponse(w, http.StatusConflict, e)
+	case *meta.ErrNotSupported:
+		WriteAPIResponse(w, http.StatusNotImplemented, e)
+	case *meta.ErrInternalServer:
+		WriteAPIResponse(w, http.StatusInternalServerError, e)
+	default:
+		log.Println(err)
+		WriteAPIResponse(
+			w,
+			http.StatusInternalServerError,
+			&meta.ErrInternalServer{},
+		)
+	}
+}
+
 // WriteAPIResponse sends a response to the client with the specified HTTP
 // status code and response body. The response body may be specified as raw
 // bytes or as an object which will be marshaled to obtain response body bytes.
@@ -417,6 +417,57 @@ func TestReadAndValidateRequestBody(t *testing.T) {
 	}
 }
 
+func TestValidateObject(t *testing.T) {
+	testCases := []struct {
+		name       string
+		obj        interface{}
+		assertions func(*testing.T, error)
+	}{
+		{
+			name: "object is invalid",
+			obj:  &testType{},
+			assertions: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.IsType(t, &meta.ErrBadRequest{}, err)
+				badReqErr, ok := err.(*meta.ErrBadRequest)
+				require.True(t, ok)
+				require.Equal(t, "Object failed JSON validation", badReqErr.Reason)
+				require.NotEmpty(t, badReqErr.Details)
+			},
+		},
+		{
+			name: "object is valid",
+			obj:  &testType{Foo: "bar"},
+			assertions: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := ValidateObject(
+				gojsonschema.NewBytesLoader(
+					[]byte(`
+						{
+							"$schema": "http://json-schema.org/draft-07/schema#",
+							"type": "object",
+							"required": ["foo"],
+							"properties": {
+								"foo": {
+									"type": "string",
+									"minLength": 1
+								}
+							}
+						}
+					`),
+				),
+				testCase.obj,
+			)
+			testCase.assertions(t, err)
+		})
+	}
+}
+
 func TestServeRequest(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -11,6 +11,7 @@ import (
 	"github.com/brigadecore/brigade-foundations/version"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api"
 	apiKubernetes "github.com/brigadecore/brigade/v2/apiserver/internal/api/kubernetes"
+	apiLocal "github.com/brigadecore/brigade/v2/apiserver/internal/api/local"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/api/rest"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/assets"
@@ -20,6 +21,7 @@ import (
 	"github.com/brigadecore/brigade/v2/internal/kubernetes"
 	"github.com/xeipuuv/gojsonschema"
 	"go.mongodb.org/mongo-driver/mongo"
+	k8s "k8s.io/client-go/kubernetes"
 )
 
 // main wires up the dependency graph for the API server, then runs the API
@@ -33,7 +35,7 @@ func main() {
 
 	ctx := signals.Context()
 
-	kubeClient, err := kubernetes.Client()
+	substrateType, err := substrateType()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -56,15 +58,14 @@ func main() {
 
 	var coolLogsStore api.CoolLogsStore
 	var eventsStore api.EventsStore
+	var execAuditStore api.ExecAuditStore
 	var jobsStore api.JobsStore
 	var projectsStore api.ProjectsStore
 	var projectRoleAssignmentsStore api.ProjectRoleAssignmentsStore
 	var roleAssignmentsStore api.RoleAssignmentsStore
-	var secretsStore api.SecretsStore
 	var serviceAccountsStore api.ServiceAccountsStore
 	var sessionsStore api.SessionsStore
 	var usersStore api.UsersStore
-	var warmLogsStore api.LogsStore
 	var workersStore api.WorkersStore
 	{
 		coolLogsStore = mongodb.NewLogsStore(database)
@@ -72,6 +73,10 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
+		execAuditStore, err = mongodb.NewExecAuditStore(database)
+		if err != nil {
+			log.Fatal(err)
+		}
 		jobsStore, err = mongodb.NewJobsStore(database)
 		if err != nil {
 			log.Fatal(err)
@@ -83,7 +88,6 @@ func main() {
 		projectRoleAssignmentsStore =
 			mongodb.NewProjectRoleAssignmentsStore(database)
 		roleAssignmentsStore = mongodb.NewRoleAssignmentsStore(database)
-		secretsStore = apiKubernetes.NewSecretsStore(kubeClient)
 		serviceAccountsStore, err = mongodb.NewServiceAccountsStore(database)
 		if err != nil {
 			log.Fatal(err)
@@ -96,7 +100,6 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		warmLogsStore = apiKubernetes.NewLogsStore(kubeClient)
 		workersStore, err = mongodb.NewWorkersStore(database)
 		if err != nil {
 			log.Fatal(err)
@@ -123,18 +126,70 @@ func main() {
 		}
 	}
 
-	// Substrate
+	// Substrate and substrate-specific stores
 	var substrate api.Substrate
-	{
+	var secretsStore api.SecretsStore
+	var warmLogsStore api.LogsStore
+	var executor api.Executor
+	switch substrateType {
+	case substrateTypeLocal:
+		config, err := localSubstrateConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+		substrate = apiLocal.NewSubstrate(queueWriterFactory, config)
+		secretsStore = apiLocal.NewSecretsStore(config.RootDirectory)
+		warmLogsStore = apiLocal.NewLogsStore(config.RootDirectory)
+		executor = apiLocal.NewExecutor()
+	default:
+		kubeConfig, err := kubernetes.Config()
+		if err != nil {
+			log.Fatal(err)
+		}
+		kubeClient, err := kubernetes.Client()
+		if err != nil {
+			log.Fatal(err)
+		}
 		config, err := substrateConfig()
 		if err != nil {
 			log.Fatal(err)
 		}
-		substrate = apiKubernetes.NewSubstrate(
-			kubeClient,
-			queueWriterFactory,
-			config,
-		)
+		clusterConfigs, err := clusterConfigs()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(clusterConfigs) == 0 {
+			substrate = apiKubernetes.NewSubstrate(
+				kubeClient,
+				queueWriterFactory,
+				config,
+			)
+			secretsStore = apiKubernetes.NewSecretsStore(kubeClient)
+			warmLogsStore = apiKubernetes.NewLogsStore(kubeClient)
+			executor = apiKubernetes.NewExecutor(kubeConfig, kubeClient)
+		} else {
+			// Additional clusters are registered alongside the default cluster,
+			// which is keyed by the empty string
+			kubeClients := map[string]k8s.Interface{
+				"": kubeClient,
+			}
+			for name, clusterConfig := range clusterConfigs {
+				kubeClients[name], err = k8s.NewForConfig(clusterConfig)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			clusterConfigs[""] = kubeConfig
+			substrate = apiKubernetes.NewMultiClusterSubstrate(
+				kubeClients,
+				queueWriterFactory,
+				config,
+			)
+			secretsStore = apiKubernetes.NewMultiClusterSecretsStore(kubeClients)
+			warmLogsStore = apiKubernetes.NewMultiClusterLogsStore(kubeClients)
+			executor =
+				apiKubernetes.NewMultiClusterExecutor(clusterConfigs, kubeClients)
+		}
 	}
 
 	// Authorizers
@@ -152,12 +207,33 @@ func main() {
 	)
 
 	// Jobs service
+	jobsServiceConfig, err := jobsServiceConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	jobSpecSchemaLoader := gojsonschema.NewReferenceLoader(
+		"file:///brigade/schemas/job-spec.json",
+	)
 	jobsService := api.NewJobsService(
 		authorizer.Authorize,
+		projectAuthorizer.Authorize,
+		func(jobSpec api.JobSpec) error {
+			return restmachinery.ValidateObject(jobSpecSchemaLoader, jobSpec)
+		},
 		projectsStore,
 		eventsStore,
 		jobsStore,
 		substrate,
+		jobsServiceConfig,
+	)
+
+	// Exec service
+	execService := api.NewExecService(
+		projectAuthorizer.Authorize,
+		projectsStore,
+		eventsStore,
+		execAuditStore,
+		executor,
 	)
 
 	// Logs service
@@ -271,6 +347,7 @@ func main() {
 			serviceAccountsService.GetByToken,
 			sessionsService.GetByToken,
 			eventsService.GetByWorkerToken,
+			eventsService.GetByJobToken,
 			&authFilterConfig,
 		)
 		apiServerConfig, err := serverConfig()
@@ -296,6 +373,10 @@ func main() {
 					),
 					Service: eventsService,
 				},
+				&rest.ExecEndpoints{
+					AuthFilter: authFilter,
+					Service:    execService,
+				},
 				&rest.JobsEndpoints{
 					AuthFilter: authFilter,
 					JobSchemaLoader: gojsonschema.NewReferenceLoader(
@@ -304,6 +385,9 @@ func main() {
 					JobStatusSchemaLoader: gojsonschema.NewReferenceLoader(
 						"file:///brigade/schemas/job-status.json",
 					),
+					JobProgressSchemaLoader: gojsonschema.NewReferenceLoader(
+						"file:///brigade/schemas/job-progress.json",
+					),
 					Service: jobsService,
 				},
 				&rest.LogsEndpoints{
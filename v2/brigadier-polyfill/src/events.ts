@@ -5,6 +5,7 @@ import {
 
 import { core } from "@brigadecore/brigade-sdk"
 
+import { startHeartbeat } from "./heartbeat"
 import { logger } from "./logger"
 
 class EventRegistry extends BrigadierEventRegistry {
@@ -29,6 +30,10 @@ class EventRegistry extends BrigadierEventRegistry {
       }
     })
 
+    // Heartbeats continue for as long as the worker process is alive. They
+    // won't, by themselves, keep it alive.
+    startHeartbeat(event)
+
     const summary = await this.fire(event)
     if (summary) {
       const eventsClient = new core.EventsClient(
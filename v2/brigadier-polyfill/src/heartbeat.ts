@@ -0,0 +1,54 @@
+import * as http from "http"
+import * as https from "https"
+
+import { Event } from "@brigadecore/brigadier"
+
+import { logger } from "./logger"
+
+// defaultHeartbeatIntervalSeconds is the interval at which the worker reports
+// to the Brigade API server that it is alive if the event does not specify one.
+const defaultHeartbeatIntervalSeconds = 30
+
+// startHeartbeat immediately, and then periodically, reports to the Brigade
+// API server that the worker is alive. The interval is dictated by the event
+// so that it agrees with the interval at which the observer expects
+// heartbeats.
+//
+// Heartbeats are sent from the same process as the script, so they stop if
+// that process crashes, is frozen, or is kept busy by a script that never
+// yields. They do NOT stop while the script awaits a promise that never
+// settles, since the process is otherwise idle. Such a script is only stopped
+// by the worker's timeout.
+export function startHeartbeat(event: Event): void {
+  const heartbeatIntervalSeconds =
+    event.worker.heartbeatIntervalSeconds || defaultHeartbeatIntervalSeconds
+  const url = new URL(
+    `v2/events/${event.id}/worker/heartbeat`,
+    event.worker.apiAddress
+  )
+  const request = url.protocol === "https:" ? https.request : http.request
+  const options: https.RequestOptions = {
+    method: "PUT",
+    headers: {
+      Authorization: `Bearer ${event.worker.apiToken}`
+    },
+    // This is consistent with how the worker's other API clients are
+    // configured
+    rejectUnauthorized: false
+  }
+  const sendHeartbeat = () => {
+    const req = request(url, options, (res: http.IncomingMessage) => {
+      res.resume()
+      if (res.statusCode != 200) {
+        logger.warn(`heartbeat rejected with status code ${res.statusCode}`)
+      }
+    })
+    req.on("error", (e: Error) => {
+      logger.warn(`error sending heartbeat: ${e.message}`)
+    })
+    req.end()
+  }
+  sendHeartbeat()
+  // The heartbeat alone should never keep the worker process alive
+  setInterval(sendHeartbeat, heartbeatIntervalSeconds * 1000).unref()
+}
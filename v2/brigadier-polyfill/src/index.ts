@@ -10,6 +10,7 @@ export {
   ConcurrentGroup,
   Event,
   JobHost,
+  JobMatrix,
   SerialGroup
 } from "@brigadecore/brigadier"
 
@@ -6,6 +6,21 @@ import { core } from "@brigadecore/brigade-sdk"
 
 import { logger } from "./logger"
 
+// JobStatus describes fields of a job's status that are reported by the
+// Brigade API server, but not yet described by the SDK.
+interface JobStatus extends core.JobStatus {
+  outputs?: { [key: string]: string }
+}
+
+// SDKJob describes fields of a job that are understood by the Brigade API
+// server, but not yet described by the SDK.
+interface SDKJob extends core.Job {
+  group?: string
+  matrix?: {
+    dimensions: { [key: string]: string[] }
+  }
+}
+
 export class Job extends BrigadierJob {
   logger: Logger
 
@@ -23,7 +38,7 @@ export class Job extends BrigadierJob {
         { allowInsecureConnections: true }
       )
 
-      const sdkJob: core.Job = {
+      const sdkJob: SDKJob = {
         name: this.name,
         spec: {
           primaryContainer: this.primaryContainer,
@@ -31,16 +46,47 @@ export class Job extends BrigadierJob {
           timeoutDuration: this.timeoutSeconds + "s",
           host: this.host,
           fallible: this.fallible
-        }
+        },
+        matrix: this.matrix
       }
       await jobsClient.create(this.event.id, sdkJob)
     } catch (e) {
       throw new Error(`Error creating job "${this.name}": ${e.message}`)
     }
-    return this.wait()
+    if (!this.matrix) {
+      return this.wait(this.name)
+    }
+    // The API server fanned the job out into one job per combination of the
+    // matrix's values. Wait for all of them.
+    const jobNames = await this.getMatrixJobNames()
+    this.logger.info(
+      `Job ${this.name} was expanded into jobs ${jobNames.join(", ")}`
+    )
+    await Promise.all(jobNames.map((jobName) => this.wait(jobName)))
   }
 
-  private async wait(): Promise<void> {
+  // getMatrixJobNames returns the names of all jobs that the API server
+  // expanded from this job's matrix.
+  private async getMatrixJobNames(): Promise<string[]> {
+    const eventsClient = new core.EventsClient(
+      this.event.worker.apiAddress,
+      this.event.worker.apiToken,
+      { allowInsecureConnections: true }
+    )
+    try {
+      const event = await eventsClient.get(this.event.id)
+      const jobs: SDKJob[] = (event.worker && event.worker.jobs) || []
+      return jobs
+        .filter((job: SDKJob) => job.group === this.name)
+        .map((job: SDKJob) => job.name)
+    } catch (e) {
+      throw new Error(
+        `Error retrieving jobs expanded from job "${this.name}": ${e.message}`
+      )
+    }
+  }
+
+  private async wait(jobName: string): Promise<void> {
     return new Promise<void>((resolve, reject) => {
       const jobsClient = new core.JobsClient(
         this.event.worker.apiAddress,
@@ -48,30 +94,32 @@ export class Job extends BrigadierJob {
         { allowInsecureConnections: true }
       )
 
-      const statusStream = jobsClient.watchStatus(this.event.id, this.name)
-      statusStream.onData((status: core.JobStatus) => {
-        this.logger.debug(`Current job phase is ${status.phase}`)
+      const statusStream = jobsClient.watchStatus(this.event.id, jobName)
+      statusStream.onData((status: JobStatus) => {
+        this.logger.debug(`Current job ${jobName} phase is ${status.phase}`)
+        // Outputs are only meaningful for a job that was not fanned out
+        if (status.outputs && !this.matrix) {
+          this.outputs = status.outputs
+        }
         if (!this.fallible) {
           switch (status.phase) {
             case core.JobPhase.Aborted:
-              reject(new Error(`Job "${this.name}" was aborted`))
+              reject(new Error(`Job "${jobName}" was aborted`))
               break
             case core.JobPhase.Canceled:
-              reject(
-                new Error(`Job "${this.name}" was canceled before starting`)
-              )
+              reject(new Error(`Job "${jobName}" was canceled before starting`))
               break
             case core.JobPhase.Failed:
-              reject(new Error(`Job "${this.name}" failed`))
+              reject(new Error(`Job "${jobName}" failed`))
               break
             case core.JobPhase.SchedulingFailed:
-              reject(new Error(`Job "${this.name}" scheduling failed`))
+              reject(new Error(`Job "${jobName}" scheduling failed`))
               break
             case core.JobPhase.Succeeded:
               resolve()
               break
             case core.JobPhase.TimedOut:
-              reject(new Error(`Job "${this.name}" timed out`))
+              reject(new Error(`Job "${jobName}" timed out`))
               break
           }
         }
@@ -89,7 +137,7 @@ export class Job extends BrigadierJob {
         }
       })
       statusStream.onError((e: Error) => {
-        const msg = `Error watching status for job "${this.name}": ${e.message}`
+        const msg = `Error watching status for job "${jobName}": ${e.message}`
         if (this.fallible) {
           this.logger.warn(msg)
           resolve()
@@ -1,6 +1,12 @@
 export { Event, EventHandler, EventRegistry, events } from "./events"
 export { ConcurrentGroup, SerialGroup } from "./groups"
-export { Container, ImagePullPolicy, Job, JobHost } from "./jobs"
+export {
+  Container,
+  ImagePullPolicy,
+  Job,
+  JobHost,
+  JobMatrix
+} from "./jobs"
 export { Logger, logger } from "./logger"
 export { Project } from "./projects"
 export { Runnable } from "./runnables"
@@ -47,6 +47,24 @@ export class Job implements Runnable {
    */
   public fallible = false
 
+  /**
+   * Key/value pairs published by the job's primary container by writing
+   * KEY=VALUE lines to /dev/termination-log. These are populated once the job
+   * has completed and may be used to pass small values, such as a computed
+   * image tag, to later jobs.
+   */
+  public outputs: { [key: string]: string } = {}
+
+  /**
+   * Optionally fans the job out into one job per combination of the values of
+   * the matrix's dimensions. Any occurrence of ${matrix.<dimension>} in the
+   * image, command, arguments, or environment variable values of the job's
+   * containers is replaced with the corresponding value. Each resulting job is
+   * named by appending its values to this job's name. Running the job runs
+   * every resulting job and completes once all of them have.
+   */
+  public matrix?: JobMatrix
+
   /** The event that triggered the job. */
   protected event: Event
 
@@ -273,6 +291,18 @@ export class Container {
   }
 }
 
+/**
+ * Describes how a single Job should be fanned out into many Jobs.
+ */
+export class JobMatrix {
+  /**
+   * Maps the name of each dimension to the values that dimension may take.
+   * For example, { node: ["16", "18"], os: ["linux", "windows"] } produces
+   * four jobs.
+   */
+  public dimensions: { [key: string]: string[] } = {}
+}
+
 /**
  * The execution environment required by a Job.
  */
@@ -288,4 +318,10 @@ export class JobHost {
    * such as specific hardware like an SSD or GPU.
    */
   public nodeSelector: { [key: string]: string } = {}
+  /**
+   * Optionally routes the Job to a named Kubernetes cluster, registered by an
+   * administrator, other than the cluster that hosts the Worker. A Job so
+   * routed cannot use the Worker's shared workspace.
+   */
+  public cluster?: string
 }
@@ -29,6 +29,11 @@ export interface Worker {
    * If applicable, contains git-specific Worker details.
    */
   git?: GitConfig
+  /**
+   * The interval, in seconds, at which the worker should report to the API
+   * server that it is alive.
+   */
+  heartbeatIntervalSeconds?: number
 }
 
 /**
@@ -2,7 +2,13 @@ import "mocha"
 import { assert } from "chai"
 
 import { Event } from "../src/events"
-import { Job, Container, JobHost, ImagePullPolicy } from "../src/jobs"
+import {
+  Job,
+  Container,
+  JobHost,
+  JobMatrix,
+  ImagePullPolicy
+} from "../src/jobs"
 
 describe("jobs", () => {
   describe("Job", () => {
@@ -32,6 +38,8 @@ describe("jobs", () => {
         )
         assert.deepEqual(job.sidecarContainers, {})
         assert.equal(job.timeoutSeconds, 60 * 15)
+        assert.deepEqual(job.outputs, {})
+        assert.isUndefined(job.matrix)
         assert.deepEqual(job.host, new JobHost())
       })
     })
@@ -54,6 +62,15 @@ describe("jobs", () => {
     })
   })
 
+  describe("JobMatrix", () => {
+    describe("#constructor", () => {
+      const jobMatrix = new JobMatrix()
+      it("initializes fields properly", () => {
+        assert.deepEqual(jobMatrix.dimensions, {})
+      })
+    })
+  })
+
   describe("JobHost", () => {
     describe("#constructor", () => {
       const jobHost = new JobHost()
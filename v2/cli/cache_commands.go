@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var cacheCommand = &cli.Command{
+	Name:    "cache",
+	Aliases: []string{"caches"},
+	Usage:   "Manage project cache volumes",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "clear",
+			Usage: "Discard the contents of a project cache volume",
+			Description: "Discards the contents of a project cache volume. The " +
+				"cache volume will be provisioned anew, and empty, the next time a " +
+				"job requires it.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagProject,
+					Aliases:  []string{"p"},
+					Usage:    "Clear a cache volume of the specified project (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     flagName,
+					Aliases:  []string{"n"},
+					Usage:    "Clear the specified cache volume (required)",
+					Required: true,
+				},
+				nonInteractiveFlag,
+				&cli.BoolFlag{
+					Name:    flagYes,
+					Aliases: []string{"y"},
+					Usage:   "Non-interactively confirm clearing the cache volume",
+				},
+			},
+			Action: cacheClear,
+		},
+	},
+}
+
+func cacheClear(c *cli.Context) error {
+	projectID := c.String(flagProject)
+	name := c.String(flagName)
+
+	confirmed, err := confirmed(c)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+
+	client, err := getClient(false)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Core().Projects().ClearCacheVolume(
+		c.Context,
+		projectID,
+		name,
+		nil,
+	); err != nil {
+		return err
+	}
+	fmt.Printf("Cache volume %q of project %q cleared.\n", name, projectID)
+
+	return nil
+}
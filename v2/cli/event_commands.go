@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/gosuri/uitable"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	goterm "golang.org/x/term"
 	"k8s.io/apimachinery/pkg/util/duration"
 )
 
@@ -69,6 +72,11 @@ var eventCommand = &cli.Command{
 					Usage: "If set, will additionally abort and cancel events with " +
 						"their worker in a STARTING phase",
 				},
+				&cli.BoolFlag{
+					Name: flagSuspended,
+					Usage: "If set, will additionally abort and cancel events with " +
+						"their worker in a SUSPENDED phase",
+				},
 				nonInteractiveFlag,
 				&cli.BoolFlag{
 					Name:    flagYes,
@@ -219,6 +227,12 @@ var eventCommand = &cli.Command{
 						"SUCCEEDED phase; mutually exclusive with --any-phase and " +
 						"--terminal",
 				},
+				&cli.BoolFlag{
+					Name: flagSuspended,
+					Usage: "If set, will abort and delete events with their worker in " +
+						"a SUSPENDED phase; mutually exclusive with --any-phase and " +
+						"--terminal",
+				},
 				&cli.BoolFlag{
 					Name: flagTerminal,
 					Usage: "If set, will delete events with their worker in any " +
@@ -244,6 +258,46 @@ var eventCommand = &cli.Command{
 			},
 			Action: eventDeleteMany,
 		},
+		{
+			Name:      "exec",
+			Usage:     "Execute a command in a running worker or job container",
+			ArgsUsage: "-- COMMAND [ARGS...]",
+			Description: "Executes a command within a container of a running " +
+				"worker or job for debugging purposes. Requires the " +
+				"PROJECT_DEVELOPER role for the event's project. All exec sessions " +
+				"are audited.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    flagContainer,
+					Aliases: []string{"c"},
+					Usage: "Execute the command in the specified container; if not " +
+						"set, uses the worker or job's \"primary\" container",
+				},
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i", flagEvent, "e"},
+					Usage:    "Execute the command for the specified event (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagJob,
+					Aliases: []string{"j"},
+					Usage: "Execute the command in a container of the specified job; " +
+						"if not set, uses a container of the worker",
+				},
+				&cli.BoolFlag{
+					Name:  flagStdin,
+					Usage: "If set, will attach local stdin to the command",
+				},
+				&cli.BoolFlag{
+					Name:    flagTTY,
+					Aliases: []string{"t"},
+					Usage: "If set, will allocate a pseudo terminal for the command " +
+						"and attach local stdin to it",
+				},
+			},
+			Action: eventExec,
+		},
 		{
 			Name:  "get",
 			Usage: "Retrieve an event",
@@ -329,6 +383,12 @@ var eventCommand = &cli.Command{
 						"SUCCEEDED phase; mutually exclusive with --terminal and " +
 						"--non-terminal",
 				},
+				&cli.BoolFlag{
+					Name: flagSuspended,
+					Usage: "If set, will retrieve events with their worker in a " +
+						"SUSPENDED phase; mutually exclusive with --terminal and " +
+						"--non-terminal",
+				},
 				&cli.BoolFlag{
 					Name: flagTerminal,
 					Usage: "If set, will retrieve events with their worker in any " +
@@ -355,6 +415,22 @@ var eventCommand = &cli.Command{
 			},
 			Action: eventList,
 		},
+		{
+			Name:  "resume",
+			Usage: "Resume a suspended event",
+			Description: "Resumes a single suspended event. Any of its jobs that " +
+				"were held while the event was suspended are released in the " +
+				"order they were created.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i", flagEvent, "e"},
+					Usage:    "Resume the specified event (required)",
+					Required: true,
+				},
+			},
+			Action: eventResume,
+		},
 		{
 			Name:  "retry",
 			Usage: "Retry an event",
@@ -380,6 +456,23 @@ var eventCommand = &cli.Command{
 			},
 			Action: eventRetry,
 		},
+		{
+			Name:  "suspend",
+			Usage: "Suspend a running event",
+			Description: "Suspends a single event whose worker is in a STARTING " +
+				"or RUNNING phase. Jobs that are already running will continue to " +
+				"run, but no new jobs will be started until the event is resumed.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i", flagEvent, "e"},
+					Usage:    "Suspend the specified event (required)",
+					Required: true,
+				},
+			},
+			Action: eventSuspend,
+		},
+		jobCommand,
 		logsCommand,
 	},
 }
@@ -516,6 +609,9 @@ func eventList(c *cli.Context) error {
 	if c.Bool(flagSucceeded) {
 		workerPhases = append(workerPhases, sdk.WorkerPhaseSucceeded)
 	}
+	if c.Bool(flagSuspended) {
+		workerPhases = append(workerPhases, sdk.WorkerPhaseSuspended)
+	}
 	if c.Bool(flagTimedOut) {
 		workerPhases = append(workerPhases, sdk.WorkerPhaseTimedOut)
 	}
@@ -666,7 +762,7 @@ func eventGet(c *cli.Context) error {
 		if len(event.Worker.Jobs) > 0 {
 			fmt.Printf("\nEvent %q jobs:\n\n", event.ID)
 			table = uitable.New()
-			table.AddRow("NAME", "STARTED", "ENDED", "PHASE")
+			table.AddRow("NAME", "STARTED", "ENDED", "PHASE", "PROGRESS")
 			for _, job := range event.Worker.Jobs {
 				jobStatus := job.Status
 				var started, ended string
@@ -678,14 +774,60 @@ func eventGet(c *cli.Context) error {
 					ended =
 						duration.ShortHumanDuration(time.Since(*jobStatus.Ended))
 				}
+				phase := string(jobStatus.Phase)
+				if jobStatus.Cached {
+					phase = fmt.Sprintf("%s (CACHED)", phase)
+				}
+				var progress string
+				if jobStatus.Progress != nil {
+					progress = fmt.Sprintf("%d%%", jobStatus.Progress.Percent)
+					if jobStatus.Progress.Message != "" {
+						progress =
+							fmt.Sprintf("%s %s", progress, jobStatus.Progress.Message)
+					}
+				}
 				table.AddRow(
 					job.Name,
 					started,
 					ended,
-					jobStatus.Phase,
+					phase,
+					progress,
 				)
 			}
 			fmt.Println(table)
+
+			if jobGroups := event.Worker.JobGroups(); len(jobGroups) > 0 {
+				fmt.Printf("\nEvent %q job matrices:\n\n", event.ID)
+				table = uitable.New()
+				table.AddRow("GROUP", "JOBS", "SUCCEEDED", "PHASE")
+				for _, jobGroup := range jobGroups {
+					table.AddRow(
+						jobGroup.Name,
+						len(jobGroup.Jobs),
+						jobGroup.PhaseCounts[sdk.JobPhaseSucceeded],
+						jobGroup.Phase,
+					)
+				}
+				fmt.Println(table)
+			}
+
+			for _, job := range event.Worker.Jobs {
+				if job.Status == nil || len(job.Status.Outputs) == 0 {
+					continue
+				}
+				fmt.Printf("\nEvent %q job %q outputs:\n\n", event.ID, job.Name)
+				table = uitable.New()
+				table.AddRow("KEY", "VALUE")
+				keys := make([]string, 0, len(job.Status.Outputs))
+				for key := range job.Status.Outputs {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					table.AddRow(key, job.Status.Outputs[key])
+				}
+				fmt.Println(table)
+			}
 		}
 
 	case flagOutputYAML:
@@ -749,6 +891,9 @@ func eventCancelMany(c *cli.Context) error {
 	if c.Bool(flagStarting) {
 		workerPhases = append(workerPhases, sdk.WorkerPhaseStarting)
 	}
+	if c.Bool(flagSuspended) {
+		workerPhases = append(workerPhases, sdk.WorkerPhaseSuspended)
+	}
 
 	confirmed, err := confirmed(c)
 	if err != nil {
@@ -826,6 +971,9 @@ func eventDeleteMany(c *cli.Context) error {
 	if c.Bool(flagSucceeded) {
 		workerPhases = append(workerPhases, sdk.WorkerPhaseSucceeded)
 	}
+	if c.Bool(flagSuspended) {
+		workerPhases = append(workerPhases, sdk.WorkerPhaseSuspended)
+	}
 	if c.Bool(flagTimedOut) {
 		workerPhases = append(workerPhases, sdk.WorkerPhaseTimedOut)
 	}
@@ -878,6 +1026,71 @@ func eventDeleteMany(c *cli.Context) error {
 	return nil
 }
 
+func eventExec(c *cli.Context) error {
+	id := c.String(flagID)
+	tty := c.Bool(flagTTY)
+	command := c.Args().Slice()
+	if len(command) == 0 {
+		return errors.New(
+			"no command was specified; specify one following \"--\"",
+		)
+	}
+
+	client, err := getClient(false)
+	if err != nil {
+		return err
+	}
+
+	selector := &sdk.ExecSelector{
+		Job:       c.String(flagJob),
+		Container: c.String(flagContainer),
+	}
+	opts := &sdk.ExecOptions{
+		Command: command,
+		TTY:     tty,
+	}
+	streams := sdk.ExecStreams{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if c.Bool(flagStdin) || tty {
+		streams.Stdin = os.Stdin
+	}
+	if tty {
+		fd := int(os.Stdin.Fd())
+		if !goterm.IsTerminal(fd) {
+			return errors.New("--tty was set, but stdin is not a terminal")
+		}
+		var state *goterm.State
+		if state, err = goterm.MakeRaw(fd); err != nil {
+			return errors.Wrap(err, "error putting terminal into raw mode")
+		}
+		defer goterm.Restore(fd, state) // nolint: errcheck
+		if width, height, err := goterm.GetSize(fd); err == nil {
+			terminalSizeCh := make(chan sdk.TerminalSize, 1)
+			terminalSizeCh <- sdk.TerminalSize{
+				Width:  uint16(width),
+				Height: uint16(height),
+			}
+			close(terminalSizeCh)
+			streams.TerminalSizes = terminalSizeCh
+		}
+	}
+
+	err = client.Core().Events().Exec().Exec(
+		c.Context,
+		id,
+		selector,
+		opts,
+		streams,
+	)
+	if exitErr, ok := err.(*sdk.ExecExitError); ok {
+		// Exit with the same exit code as the command did
+		return cli.Exit("", exitErr.ExitCode)
+	}
+	return err
+}
+
 func eventClone(c *cli.Context) error {
 	id := c.String(flagID)
 	follow := c.Bool(flagFollow)
@@ -950,3 +1163,35 @@ func eventRetry(c *cli.Context) error {
 		},
 	)
 }
+
+func eventSuspend(c *cli.Context) error {
+	id := c.String(flagID)
+
+	client, err := getClient(false)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Core().Events().Suspend(c.Context, id, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Event %q suspended.\n", id)
+
+	return nil
+}
+
+func eventResume(c *cli.Context) error {
+	id := c.String(flagID)
+
+	client, err := getClient(false)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Core().Events().Resume(c.Context, id, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Event %q resumed.\n", id)
+
+	return nil
+}
@@ -22,6 +22,7 @@ const (
 	flagJob            = "job"
 	flagLabel          = "label"
 	flagLanguage       = "language"
+	flagName           = "name"
 	flagNonInteractive = "non-interactive"
 	flagNonTerminal    = "non-terminal"
 	flagOutput         = "output"
@@ -39,9 +40,12 @@ const (
 	flagSet            = "set"
 	flagSource         = "source"
 	flagStarting       = "starting"
+	flagStdin          = "stdin"
 	flagSucceeded      = "succeeded"
+	flagSuspended      = "suspended"
 	flagTerminal       = "terminal"
 	flagTimedOut       = "timedout"
+	flagTTY            = "tty"
 	flagType           = "type"
 	flagUnknown        = "unknown"
 	flagUnset          = "unset"
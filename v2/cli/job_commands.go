@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var jobCommand = &cli.Command{
+	Name:    "job",
+	Aliases: []string{"jobs"},
+	Usage:   "Manage an event's jobs",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "cancel",
+			Usage: "Cancel a single job without canceling its event",
+			Description: "Cancels a single pending job or aborts a single running " +
+				"job. The event's worker and any other jobs are unaffected.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagEvent,
+					Aliases:  []string{"e"},
+					Usage:    "Cancel a job belonging to the specified event (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     flagJob,
+					Aliases:  []string{"j"},
+					Usage:    "Cancel (or abort) the specified job (required)",
+					Required: true,
+				},
+				nonInteractiveFlag,
+				&cli.BoolFlag{
+					Name:    flagYes,
+					Aliases: []string{"y"},
+					Usage:   "Non-interactively confirm cancellation",
+				},
+			},
+			Action: jobCancel,
+		},
+	},
+}
+
+func jobCancel(c *cli.Context) error {
+	eventID := c.String(flagEvent)
+	jobName := c.String(flagJob)
+
+	confirmed, err := confirmed(c)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+
+	client, err := getClient(false)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Core().Events().Workers().Jobs().Cancel(
+		c.Context,
+		eventID,
+		jobName,
+		nil,
+	); err != nil {
+		return err
+	}
+	fmt.Printf("Job %q of event %q canceled.\n", jobName, eventID)
+
+	return nil
+}
@@ -21,6 +21,7 @@ func main() {
 		projectCommand,
 		rolesCommands,
 		serviceAccountCommand,
+		systemCommand,
 		userCommand,
 		termCommand,
 		versionCommand,
@@ -21,6 +21,7 @@ var projectCommand = &cli.Command{
 	Usage:   "Manage projects",
 	Aliases: []string{"projects"},
 	Subcommands: []*cli.Command{
+		cacheCommand,
 		{
 			Name:  "create",
 			Usage: "Create a new project",
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var systemCommand = &cli.Command{
+	Name:  "system",
+	Usage: "Inspect the Brigade system",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "substrate",
+			Usage: "Summarize workloads on and capacity of the substrate",
+			Description: "Summarizes the pending, running, and terminating " +
+				"worker and job pods and the workspaces belonging to each project, " +
+				"as well as the capacity of each pool of nodes. This is useful for " +
+				"determining why workers and jobs may be queued. Requires the " +
+				"ADMIN role.",
+			Flags:  []cli.Flag{cliFlagOutput},
+			Action: systemSubstrate,
+		},
+	},
+}
+
+func systemSubstrate(c *cli.Context) error {
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	client, err := getClient(false)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := client.Core().Substrate().GetInventory(c.Context, nil)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(output) {
+	case flagOutputTable:
+		// Only bother displaying clusters if there's more than one
+		var multiCluster bool
+		for _, project := range inventory.Projects {
+			multiCluster = multiCluster || project.Cluster != ""
+		}
+		for _, nodePool := range inventory.NodePools {
+			multiCluster = multiCluster || nodePool.Cluster != ""
+		}
+
+		if len(inventory.Projects) == 0 {
+			fmt.Println("No project workloads found.")
+		} else {
+			table := uitable.New()
+			table.AddRow(
+				withCluster(
+					multiCluster,
+					"CLUSTER",
+					"PROJECT",
+					"WORKERS (PEND/RUN/TERM)",
+					"JOBS (PEND/RUN/TERM)",
+					"WORKSPACES",
+					"STORAGE",
+				)...,
+			)
+			for _, project := range inventory.Projects {
+				table.AddRow(
+					withCluster(
+						multiCluster,
+						clusterName(project.Cluster),
+						project.ProjectID,
+						formatPodCounts(project.Workers),
+						formatPodCounts(project.Jobs),
+						project.Workspaces.Count,
+						project.Workspaces.Storage,
+					)...,
+				)
+			}
+			fmt.Println(table)
+		}
+
+		if len(inventory.NodePools) == 0 {
+			break
+		}
+		fmt.Println()
+		table := uitable.New()
+		table.AddRow(
+			withCluster(
+				multiCluster,
+				"CLUSTER",
+				"NODE POOL",
+				"NODES (READY/TOTAL)",
+				"CPU (REQ/ALLOC)",
+				"MEMORY (REQ/ALLOC)",
+				"PODS (REQ/ALLOC)",
+			)...,
+		)
+		for _, nodePool := range inventory.NodePools {
+			name := nodePool.Name
+			if name == "" {
+				name = "<none>"
+			}
+			table.AddRow(
+				withCluster(
+					multiCluster,
+					clusterName(nodePool.Cluster),
+					name,
+					fmt.Sprintf("%d/%d", nodePool.ReadyNodes, nodePool.Nodes),
+					fmt.Sprintf(
+						"%s/%s",
+						nodePool.Requested.CPU,
+						nodePool.Allocatable.CPU,
+					),
+					fmt.Sprintf(
+						"%s/%s",
+						nodePool.Requested.Memory,
+						nodePool.Allocatable.Memory,
+					),
+					fmt.Sprintf(
+						"%d/%d",
+						nodePool.Requested.Pods,
+						nodePool.Allocatable.Pods,
+					),
+				)...,
+			)
+		}
+		fmt.Println(table)
+
+	case flagOutputYAML:
+		yamlBytes, err := yaml.Marshal(inventory)
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from get substrate inventory operation",
+			)
+		}
+		fmt.Println(string(yamlBytes))
+
+	case flagOutputJSON:
+		prettyJSON, err := json.MarshalIndent(inventory, "", "  ")
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from get substrate inventory operation",
+			)
+		}
+		fmt.Println(string(prettyJSON))
+	}
+
+	return nil
+}
+
+// withCluster returns the provided table cells, omitting the first, which is
+// expected to pertain to a cluster, if clusters are not to be displayed.
+func withCluster(multiCluster bool, cells ...interface{}) []interface{} {
+	if multiCluster {
+		return cells
+	}
+	return cells[1:]
+}
+
+// clusterName returns the display name of the specified cluster.
+func clusterName(cluster string) string {
+	if cluster == "" {
+		return "<default>"
+	}
+	return cluster
+}
+
+// formatPodCounts formats the provided sdk.PodCounts as
+// pending/running/terminating.
+func formatPodCounts(counts sdk.PodCounts) string {
+	return fmt.Sprintf(
+		"%d/%d/%d",
+		counts.Pending,
+		counts.Running,
+		counts.Terminating,
+	)
+}
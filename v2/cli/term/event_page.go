@@ -217,6 +217,20 @@ func (e *eventPage) fillWorkerInfo(event sdk.Event) {
 		getTextColorFromWorkerPhase(event.Worker.Status.Phase),
 		event.Worker.Status.Phase,
 	)
+	if jobGroups := event.Worker.JobGroups(); len(jobGroups) > 0 {
+		infoText = fmt.Sprintf("%s\n[grey]Job Matrices:", infoText)
+		for _, jobGroup := range jobGroups {
+			infoText = fmt.Sprintf(
+				"%s\n  [grey]%s: %s%s [white](%d/%d succeeded)",
+				infoText,
+				jobGroup.Name,
+				getTextColorFromJobPhase(jobGroup.Phase),
+				jobGroup.Phase,
+				jobGroup.PhaseCounts[sdk.JobPhaseSucceeded],
+				len(jobGroup.Jobs),
+			)
+		}
+	}
 	e.workerInfo.SetText(infoText)
 }
 
@@ -229,6 +243,7 @@ func (e *eventPage) fillJobsTable(event sdk.Event) {
 		startedCol
 		endedCol
 		durationCol
+		progressCol
 	)
 	e.jobsTable.Clear()
 	e.jobsTable.SetCell(
@@ -285,6 +300,14 @@ func (e *eventPage) fillJobsTable(event sdk.Event) {
 			Align: tview.AlignCenter,
 			Color: tcell.ColorYellow,
 		},
+	).SetCell(
+		0,
+		progressCol,
+		&tview.TableCell{
+			Text:  "Progress",
+			Align: tview.AlignCenter,
+			Color: tcell.ColorYellow,
+		},
 	)
 	for r, job := range event.Worker.Jobs {
 		row := r + 1
@@ -365,6 +388,17 @@ func (e *eventPage) fillJobsTable(event sdk.Event) {
 				},
 			)
 		}
+		if job.Status.Progress != nil {
+			e.jobsTable.SetCell(
+				row,
+				progressCol,
+				&tview.TableCell{
+					Text:  fmt.Sprintf("%d%%", job.Status.Progress.Percent),
+					Align: tview.AlignLeft,
+					Color: color,
+				},
+			)
+		}
 	}
 	e.jobsTable.SetSelectedFunc(func(row, _ int) {
 		if row > 0 { // Header row cells aren't selectable
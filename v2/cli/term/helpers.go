@@ -24,6 +24,7 @@ var colorsByWorkerPhase = map[sdk.WorkerPhase]tcell.Color{
 	sdk.WorkerPhaseSchedulingFailed: tcell.ColorRed,
 	sdk.WorkerPhaseStarting:         tcell.ColorYellow,
 	sdk.WorkerPhaseSucceeded:        tcell.ColorGreen,
+	sdk.WorkerPhaseSuspended:        tcell.ColorYellow,
 	sdk.WorkerPhaseTimedOut:         tcell.ColorRed,
 	sdk.WorkerPhaseUnknown:          tcell.ColorGrey,
 }
@@ -37,6 +38,7 @@ var textColorsByWorkerPhase = map[sdk.WorkerPhase]string{
 	sdk.WorkerPhaseSchedulingFailed: textRed,
 	sdk.WorkerPhaseStarting:         textYellow,
 	sdk.WorkerPhaseSucceeded:        textGreen,
+	sdk.WorkerPhaseSuspended:        textYellow,
 	sdk.WorkerPhaseTimedOut:         textRed,
 	sdk.WorkerPhaseUnknown:          textGrey,
 }
@@ -50,6 +52,7 @@ var iconsByWorkerPhase = map[sdk.WorkerPhase]string{
 	sdk.WorkerPhaseSchedulingFailed: "✖",
 	sdk.WorkerPhaseStarting:         "▶",
 	sdk.WorkerPhaseSucceeded:        "✔",
+	sdk.WorkerPhaseSuspended:        "⏸",
 	sdk.WorkerPhaseTimedOut:         "✖",
 	sdk.WorkerPhaseUnknown:          "?",
 }
@@ -67,6 +70,19 @@ var colorsByJobPhase = map[sdk.JobPhase]tcell.Color{
 	sdk.JobPhaseUnknown:          tcell.ColorGrey,
 }
 
+var textColorsByJobPhase = map[sdk.JobPhase]string{
+	sdk.JobPhaseAborted:          textGrey,
+	sdk.JobPhaseCanceled:         textGrey,
+	sdk.JobPhaseFailed:           textRed,
+	sdk.JobPhasePending:          textWhite,
+	sdk.JobPhaseRunning:          textYellow,
+	sdk.JobPhaseSchedulingFailed: textRed,
+	sdk.JobPhaseStarting:         textYellow,
+	sdk.JobPhaseSucceeded:        textGreen,
+	sdk.JobPhaseTimedOut:         textRed,
+	sdk.JobPhaseUnknown:          textGrey,
+}
+
 var iconsByJobPhase = map[sdk.JobPhase]string{
 	sdk.JobPhaseAborted:          "✖",
 	sdk.JobPhaseCanceled:         "✖",
@@ -108,6 +124,13 @@ func getColorFromJobPhase(phase sdk.JobPhase) tcell.Color {
 	return tcell.ColorGrey
 }
 
+func getTextColorFromJobPhase(phase sdk.JobPhase) string {
+	if color, ok := textColorsByJobPhase[phase]; ok {
+		return color
+	}
+	return "[grey]"
+}
+
 func getIconFromJobPhase(phase sdk.JobPhase) string {
 	if icon, ok := iconsByJobPhase[phase]; ok {
 		return icon
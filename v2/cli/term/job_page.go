@@ -3,6 +3,7 @@ package term
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/brigadecore/brigade/sdk/v3"
 	"github.com/gdamore/tcell/v2"
@@ -32,7 +33,7 @@ func newJobPage(
 		jobInfo:         tview.NewTextView().SetDynamicColors(true),
 		containersTable: tview.NewTable().SetSelectable(true, false),
 		usage: tview.NewTextView().SetDynamicColors(true).SetText(
-			"[yellow](F5 R) [white]Reload    [yellow](<-/Del) [white]Back    [yellow](L) [white]Logs    [yellow](ESC) [white]Home    [yellow](Q) [white]Quit", // nolint: lll
+			"[yellow](F5 R) [white]Reload    [yellow](<-/Del) [white]Back    [yellow](L) [white]Logs    [yellow](C) [white]Cancel    [yellow](ESC) [white]Home    [yellow](Q) [white]Quit", // nolint: lll
 		),
 	}
 	j.jobInfo.SetBorder(true).SetBorderColor(tcell.ColorYellow)
@@ -99,6 +100,16 @@ func (j *jobPage) refresh(ctx context.Context, eventID, jobName string) {
 					j.router.loadJobPage(eventID, jobName)
 				case 'l', 'L':
 					j.router.loadLogPage(eventID, jobName)
+				case 'c', 'C': // Cancel
+					j.router.confirm(
+						fmt.Sprintf(
+							"Cancel job %q? This action cannot be undone.",
+							jobName,
+						),
+						func() {
+							j.cancel(ctx, eventID, jobName)
+						},
+					)
 				case 'q', 'Q': // Exit
 					j.router.exit()
 				}
@@ -108,6 +119,23 @@ func (j *jobPage) refresh(ctx context.Context, eventID, jobName string) {
 	)
 }
 
+// cancel cancels the specified Job and reloads the page to reflect the Job's
+// new phase. If cancellation fails, the error is displayed instead.
+func (j *jobPage) cancel(ctx context.Context, eventID, jobName string) {
+	if err := j.apiClient.Core().Events().Workers().Jobs().Cancel(
+		ctx,
+		eventID,
+		jobName,
+		nil,
+	); err != nil {
+		j.router.alert(
+			fmt.Sprintf("Error canceling job %q: %s", jobName, err),
+		)
+		return
+	}
+	j.router.loadJobPage(eventID, jobName)
+}
+
 func (j *jobPage) fillJobInfo(eventID string, job sdk.Job) {
 	j.jobInfo.SetTitle(fmt.Sprintf(" %s: %s ", eventID, job.Name))
 	j.jobInfo.SetBorderColor(getColorFromJobPhase(job.Status.Phase))
@@ -129,6 +157,53 @@ func (j *jobPage) fillJobInfo(eventID string, job sdk.Job) {
 			job.Status.Ended.Sub(*job.Status.Started),
 		)
 	}
+	if job.Status.Progress != nil {
+		infoText = fmt.Sprintf(
+			"%s\n[grey]Progress: [white]%d%%",
+			infoText,
+			job.Status.Progress.Percent,
+		)
+		if job.Status.Progress.Message != "" {
+			infoText = fmt.Sprintf(
+				"%s [grey](%s)",
+				infoText,
+				job.Status.Progress.Message,
+			)
+		}
+	}
+	if job.Group != "" {
+		infoText =
+			fmt.Sprintf("%s\n[grey]Matrix Group: [white]%s", infoText, job.Group)
+		dimensions := make([]string, 0, len(job.MatrixValues))
+		for dimension := range job.MatrixValues {
+			dimensions = append(dimensions, dimension)
+		}
+		sort.Strings(dimensions)
+		for _, dimension := range dimensions {
+			infoText = fmt.Sprintf(
+				"%s\n  [grey]%s: [white]%s",
+				infoText,
+				dimension,
+				job.MatrixValues[dimension],
+			)
+		}
+	}
+	if len(job.Status.Outputs) > 0 {
+		keys := make([]string, 0, len(job.Status.Outputs))
+		for key := range job.Status.Outputs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		infoText = fmt.Sprintf("%s\n[grey]Outputs:", infoText)
+		for _, key := range keys {
+			infoText = fmt.Sprintf(
+				"%s\n  [grey]%s: [white]%s",
+				infoText,
+				key,
+				job.Status.Outputs[key],
+			)
+		}
+	}
 	j.jobInfo.SetText(infoText)
 }
 
@@ -195,6 +270,27 @@ func (j *jobPage) fillContainersTable(job sdk.Job) {
 		},
 	)
 
+	for _, initContainer := range job.Spec.InitContainers {
+		row++
+		j.containersTable.SetCell(
+			row,
+			nameCol,
+			&tview.TableCell{
+				Text:  initContainer.Name,
+				Align: tview.AlignLeft,
+				Color: tcell.ColorWhite,
+			},
+		).SetCell(
+			row,
+			imageCol,
+			&tview.TableCell{
+				Text:  initContainer.Image,
+				Align: tview.AlignLeft,
+				Color: tcell.ColorWhite,
+			},
+		)
+	}
+
 	for k, v := range job.Spec.SidecarContainers {
 		row++
 		j.containersTable.SetCell(
@@ -9,6 +9,8 @@ import (
 	"github.com/rivo/tview"
 )
 
+const modalPageName = "modal"
+
 // pageRouter is a custom UI component composed of tview.Pages which can be
 // refreshed and brought into focus on command.
 type pageRouter struct {
@@ -156,6 +158,45 @@ func (r *pageRouter) loadPage(
 	}()
 }
 
+// confirm displays a modal dialog on top of the current page that prompts the
+// user to confirm an action. The dialog is dismissed either way, but the
+// specified function is invoked only if the user confirms.
+func (r *pageRouter) confirm(message string, confirmedFn func()) {
+	r.showModal(
+		message,
+		[]string{"Yes", "No"},
+		func(buttonLabel string) {
+			if buttonLabel == "Yes" {
+				confirmedFn()
+			}
+		},
+	)
+}
+
+// alert displays a modal dialog on top of the current page that presents the
+// specified message until the user dismisses it.
+func (r *pageRouter) alert(message string) {
+	r.showModal(message, []string{"OK"}, func(string) {})
+}
+
+// showModal displays a modal dialog with the specified message and buttons on
+// top of the current page. When any button is pressed, the dialog is dismissed
+// and the specified function is invoked with the button's label.
+func (r *pageRouter) showModal(
+	message string,
+	buttonLabels []string,
+	doneFn func(buttonLabel string),
+) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons(buttonLabels).
+		SetDoneFunc(func(_ int, buttonLabel string) {
+			r.RemovePage(modalPageName) // Returns focus to the page beneath
+			doneFn(buttonLabel)
+		})
+	r.AddPage(modalPageName, modal, false, true)
+}
+
 // exit stops the associated tview.Application.
 func (r *pageRouter) exit() {
 	r.app.Stop()
@@ -10,6 +10,17 @@ import (
 
 // Client returns an implementation of kubernetes.Interface.
 func Client() (kubernetes.Interface, error) {
+	cfg, err := Config()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(cfg)
+}
+
+// Config returns the configuration for connecting to Kubernetes. This is useful
+// for operations, like executing commands within containers, that are not
+// supported by kubernetes.Interface.
+func Config() (*rest.Config, error) {
 	masterURL := os.GetEnvVar("KUBE_MASTER", "")
 	kubeConfigPath := os.GetEnvVar("KUBE_CONFIG", "")
 
@@ -20,11 +31,17 @@ func Client() (kubernetes.Interface, error) {
 	} else {
 		cfg, err = clientcmd.BuildConfigFromFlags(masterURL, kubeConfigPath)
 	}
-	if err != nil {
-		return nil, errors.Wrap(
-			err,
-			"error getting kubernetes configuration",
-		)
-	}
-	return kubernetes.NewForConfig(cfg)
+	return cfg, errors.Wrap(err, "error getting kubernetes configuration")
+}
+
+// ConfigFromFile returns the configuration for connecting to the Kubernetes
+// cluster described by the specified kubeconfig file. This is useful for
+// connecting to clusters other than the one Brigade itself is running in.
+func ConfigFromFile(kubeConfigPath string) (*rest.Config, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	return cfg, errors.Wrapf(
+		err,
+		"error getting kubernetes configuration from %q",
+		kubeConfigPath,
+	)
 }
@@ -7,7 +7,9 @@ import (
 )
 
 const (
-	AnnotationTimeoutDuration = "brigade.sh/timeoutDuration"
+	AnnotationClaimedNamespace = "brigade.sh/claimed"
+	AnnotationTimeoutDuration  = "brigade.sh/timeoutDuration"
+	AnnotationTimeoutDeadline  = "brigade.sh/timeoutDeadline"
 
 	LabelBrigadeID = "brigade.sh/id"
 	LabelComponent = "brigade.sh/component"
@@ -15,15 +17,18 @@ const (
 	LabelJob       = "brigade.sh/job"
 	LabelProject   = "brigade.sh/project"
 
-	LabelKeyWorker         = "worker"
-	LabelKeyJob            = "job"
-	LabelKeyEvent          = "event"
-	LabelKeyWorkspace      = "workspace"
-	LabelKeyProjectSecrets = "project-secrets"
+	LabelKeyWorker            = "worker"
+	LabelKeyJob               = "job"
+	LabelKeyEvent             = "event"
+	LabelKeyWorkspace         = "workspace"
+	LabelKeyProjectSecrets    = "project-secrets"
+	LabelKeyCacheVolume       = "cache-volume"
+	LabelKeyJobServiceAccount = "job-service-account"
 
 	SecretTypeProjectSecrets = "brigade.sh/project-secrets" // nolint: gosec
 	SecretTypeEvent          = "brigade.sh/event"           // nolint: gosec
 	SecretTypeJobSecrets     = "brigade.sh/job"             // nolint: gosec
+	SecretTypeJobToken       = "brigade.sh/job-token"       // nolint: gosec
 )
 
 func EventSecretName(eventID string) string {
@@ -34,6 +39,10 @@ func WorkspacePVCName(eventID string) string {
 	return eventID
 }
 
+func CacheVolumePVCName(cacheVolumeName string) string {
+	return fmt.Sprintf("cache-%s", cacheVolumeName)
+}
+
 func WorkerPodName(eventID string) string {
 	return eventID
 }
@@ -51,6 +60,10 @@ func JobSecretName(eventID, jobName string) string {
 	return fmt.Sprintf("%s-%s", eventID, jobName)
 }
 
+func JobTokenSecretName(eventID, jobName string) string {
+	return fmt.Sprintf("%s-%s-token", eventID, jobName)
+}
+
 func JobPodName(eventID, jobName string) string {
 	return fmt.Sprintf("%s-%s", eventID, jobName)
 }
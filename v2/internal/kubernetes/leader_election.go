@@ -0,0 +1,170 @@
+package kubernetes
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/brigadecore/brigade-foundations/os"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// LeaderElectionConfig encapsulates configuration for electing, from among
+// multiple replicas of a component, a single leader that is the only replica to
+// actively perform the component's function.
+type LeaderElectionConfig struct {
+	// Enabled indicates whether leader election is enabled. When it is not, the
+	// component assumes itself to be the only replica.
+	Enabled bool
+	// Namespace is the namespace of the Kubernetes Lease that replicas compete
+	// to hold.
+	Namespace string
+	// LeaseName is the name of the Kubernetes Lease that replicas compete to
+	// hold.
+	LeaseName string
+	// Identity uniquely identifies this replica among all candidates. This is
+	// typically the name of the replica's pod.
+	Identity string
+}
+
+// GetLeaderElectionConfig returns a LeaderElectionConfig based on environment
+// variables.
+func GetLeaderElectionConfig() (LeaderElectionConfig, error) {
+	config := LeaderElectionConfig{}
+	var err error
+	if config.Enabled, err =
+		os.GetBoolFromEnvVar("LEADER_ELECTION_ENABLED", false); err != nil {
+		return config, err
+	}
+	log.Println("LEADER_ELECTION_ENABLED: ", config.Enabled)
+	if !config.Enabled {
+		return config, nil
+	}
+	if config.Namespace, err =
+		os.GetRequiredEnvVar("LEADER_ELECTION_NAMESPACE"); err != nil {
+		return config, err
+	}
+	log.Println("LEADER_ELECTION_NAMESPACE: ", config.Namespace)
+	if config.LeaseName, err =
+		os.GetRequiredEnvVar("LEADER_ELECTION_LEASE_NAME"); err != nil {
+		return config, err
+	}
+	log.Println("LEADER_ELECTION_LEASE_NAME: ", config.LeaseName)
+	if config.Identity, err =
+		os.GetRequiredEnvVar("LEADER_ELECTION_IDENTITY"); err != nil {
+		return config, err
+	}
+	log.Println("LEADER_ELECTION_IDENTITY: ", config.Identity)
+	return config, nil
+}
+
+// InClusterClient returns an implementation of kubernetes.Interface for the
+// cluster the calling process is running in, regardless of any other
+// configuration. This is useful for coordinating replicas of a component that
+// otherwise connects to a different cluster.
+func InClusterClient() (kubernetes.Interface, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil,
+			errors.Wrap(err, "error getting in-cluster kubernetes configuration")
+	}
+	return kubernetes.NewForConfig(cfg)
+}
+
+// RunAsLeader invokes the provided function only once this replica has been
+// elected leader, as described by the provided LeaderElectionConfig, and then
+// blocks until the function returns. If leader election is not enabled, the
+// function is invoked immediately. If leadership is lost before the function
+// returns, the context passed to the function is canceled and an error is
+// returned. Since state held by a replica that is no longer the leader may be
+// stale, callers should exit when this happens and start over as a candidate.
+func RunAsLeader(
+	ctx context.Context,
+	kubeClient kubernetes.Interface,
+	config LeaderElectionConfig,
+	fn func(context.Context) error,
+) error {
+	if !config.Enabled {
+		return fn(ctx)
+	}
+
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var started int32
+	doneCh := make(chan struct{})
+	var runErr error
+	elector, err := leaderelection.NewLeaderElector(
+		leaderelection.LeaderElectionConfig{
+			Lock: &resourcelock.LeaseLock{
+				LeaseMeta: metav1.ObjectMeta{
+					Namespace: config.Namespace,
+					Name:      config.LeaseName,
+				},
+				Client: kubeClient.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{
+					Identity: config.Identity,
+				},
+			},
+			LeaseDuration: leaseDuration,
+			RenewDeadline: renewDeadline,
+			RetryPeriod:   retryPeriod,
+			// Give up the lease upon shutting down so another replica can take
+			// over right away
+			ReleaseOnCancel: true,
+			Name:            config.LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					atomic.StoreInt32(&started, 1)
+					defer close(doneCh)
+					log.Printf(
+						"%s acquired lease %q; running as leader",
+						config.Identity,
+						config.LeaseName,
+					)
+					runErr = fn(leaderCtx)
+					if leaderCtx.Err() != nil && parentCtx.Err() == nil {
+						runErr = errors.Errorf("lost lease %q", config.LeaseName)
+					}
+					// Relinquish leadership
+					cancel()
+				},
+				OnStoppedLeading: func() {},
+				OnNewLeader: func(identity string) {
+					if identity != config.Identity {
+						log.Printf("%s holds lease %q", identity, config.LeaseName)
+					}
+				},
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "error creating leader elector")
+	}
+
+	// This returns when the parent context is canceled, when fn returns, or when
+	// leadership is lost, whichever happens first.
+	elector.Run(ctx)
+
+	if atomic.LoadInt32(&started) == 0 {
+		if err = parentCtx.Err(); err != nil {
+			return err
+		}
+		return errors.Errorf("lost lease %q", config.LeaseName)
+	}
+	<-doneCh
+	return runErr
+}
@@ -0,0 +1,168 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetLeaderElectionConfig(t *testing.T) {
+	// Note that unit testing in Go does NOT clear environment variables between
+	// tests, which can sometimes be a pain, but it's fine here-- so each of these
+	// test cases builds on the previous case.
+	testCases := []struct {
+		name       string
+		setup      func()
+		assertions func(LeaderElectionConfig, error)
+	}{
+		{
+			name:  "leader election not enabled",
+			setup: func() {},
+			assertions: func(config LeaderElectionConfig, err error) {
+				require.NoError(t, err)
+				require.False(t, config.Enabled)
+			},
+		},
+		{
+			name: "LEADER_ELECTION_ENABLED not parsable as bool",
+			setup: func() {
+				t.Setenv("LEADER_ELECTION_ENABLED", "foo")
+			},
+			assertions: func(_ LeaderElectionConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a bool")
+				require.Contains(t, err.Error(), "LEADER_ELECTION_ENABLED")
+			},
+		},
+		{
+			name: "LEADER_ELECTION_NAMESPACE not set",
+			setup: func() {
+				t.Setenv("LEADER_ELECTION_ENABLED", "true")
+			},
+			assertions: func(_ LeaderElectionConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "LEADER_ELECTION_NAMESPACE")
+			},
+		},
+		{
+			name: "LEADER_ELECTION_LEASE_NAME not set",
+			setup: func() {
+				t.Setenv("LEADER_ELECTION_NAMESPACE", "brigade")
+			},
+			assertions: func(_ LeaderElectionConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "LEADER_ELECTION_LEASE_NAME")
+			},
+		},
+		{
+			name: "LEADER_ELECTION_IDENTITY not set",
+			setup: func() {
+				t.Setenv("LEADER_ELECTION_LEASE_NAME", "brigade-scheduler")
+			},
+			assertions: func(_ LeaderElectionConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "LEADER_ELECTION_IDENTITY")
+			},
+		},
+		{
+			name: "success",
+			setup: func() {
+				t.Setenv("LEADER_ELECTION_IDENTITY", "brigade-scheduler-abc12")
+			},
+			assertions: func(config LeaderElectionConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					LeaderElectionConfig{
+						Enabled:   true,
+						Namespace: "brigade",
+						LeaseName: "brigade-scheduler",
+						Identity:  "brigade-scheduler-abc12",
+					},
+					config,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.setup()
+			config, err := GetLeaderElectionConfig()
+			testCase.assertions(config, err)
+		})
+	}
+}
+
+func TestRunAsLeader(t *testing.T) {
+	config := LeaderElectionConfig{
+		Enabled:   true,
+		Namespace: "brigade",
+		LeaseName: "brigade-scheduler",
+		Identity:  "brigade-scheduler-abc12",
+	}
+	testCases := []struct {
+		name       string
+		config     LeaderElectionConfig
+		fn         func(context.Context) error
+		assertions func(kubeClient *fake.Clientset, err error)
+	}{
+		{
+			name:   "leader election not enabled",
+			config: LeaderElectionConfig{},
+			fn: func(context.Context) error {
+				return errors.New("something went wrong")
+			},
+			assertions: func(kubeClient *fake.Clientset, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Empty(t, kubeClient.Actions())
+			},
+		},
+		{
+			name:   "function returns while leading",
+			config: config,
+			fn: func(context.Context) error {
+				return errors.New("something went wrong")
+			},
+			assertions: func(kubeClient *fake.Clientset, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				_, err = kubeClient.CoordinationV1().Leases("brigade").Get(
+					context.Background(),
+					"brigade-scheduler",
+					metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "context canceled while leading",
+			config: config,
+			fn: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			assertions: func(_ *fake.Clientset, err error) {
+				require.Error(t, err)
+				require.Equal(t, context.DeadlineExceeded, err)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err := RunAsLeader(ctx, kubeClient, testCase.config, testCase.fn)
+			testCase.assertions(kubeClient, err)
+		})
+	}
+}
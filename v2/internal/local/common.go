@@ -0,0 +1,61 @@
+package local
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	// LabelContainer is the key of the label that records the name of the Worker
+	// or Job container that a Docker container represents.
+	LabelContainer = "brigade.sh/container"
+	// LabelPrimaryContainer is the key of the label that records the name of the
+	// primary container of the Worker or Job that a Docker container belongs to.
+	LabelPrimaryContainer = "brigade.sh/primary-container"
+	// LabelInitContainers is the key of the label that records, as a
+	// comma-delimited list, the names of the init containers of the Worker or
+	// Job that a Docker container belongs to, in the order they are executed.
+	LabelInitContainers = "brigade.sh/init-containers"
+)
+
+// WorkerContainerName returns the name of the Docker container that runs the
+// specified container of the specified Event's Worker.
+func WorkerContainerName(eventID, containerName string) string {
+	return fmt.Sprintf("%s.%s", eventID, containerName)
+}
+
+// JobContainerName returns the name of the Docker container that runs the
+// specified container of the specified Job.
+func JobContainerName(eventID, jobName, containerName string) string {
+	return fmt.Sprintf("%s.%s.%s", eventID, jobName, containerName)
+}
+
+// ProjectDirectory returns the path to the directory, beneath the specified
+// root directory, that holds the specified Project's Secrets and cache
+// volumes.
+func ProjectDirectory(rootDirectory, projectID string) string {
+	return filepath.Join(rootDirectory, "projects", projectID)
+}
+
+// EventDirectory returns the path to the directory, beneath the specified root
+// directory, that holds the specified Event's details, source code, and shared
+// workspace.
+func EventDirectory(rootDirectory, eventID string) string {
+	return filepath.Join(rootDirectory, "events", eventID)
+}
+
+// JobDirectory returns the path to the directory, beneath the specified root
+// directory, that holds the specified Job's environment, source code, and
+// termination log.
+func JobDirectory(rootDirectory, eventID, jobName string) string {
+	return filepath.Join(EventDirectory(rootDirectory, eventID), "jobs", jobName)
+}
+
+// JobTerminationLogPath returns the path to the file that is mounted to the
+// specified Job's primary container at /dev/termination-log.
+func JobTerminationLogPath(rootDirectory, eventID, jobName string) string {
+	return filepath.Join(
+		JobDirectory(rootDirectory, eventID, jobName),
+		"termination-log",
+	)
+}
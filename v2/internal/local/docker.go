@@ -0,0 +1,124 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DockerCommandFn is the signature of functions that prepare a command that
+// invokes the Docker CLI with the provided arguments.
+type DockerCommandFn func(ctx context.Context, args ...string) *exec.Cmd
+
+// DockerCommand prepares a command that invokes the Docker CLI with the
+// provided arguments. The command is killed if the provided context is
+// canceled before it completes.
+func DockerCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "docker", args...)
+}
+
+// RunDockerCommand runs the provided command and returns its standard output.
+// If the command fails, the returned error includes anything the command wrote
+// to standard error.
+func RunDockerCommand(cmd *exec.Cmd) ([]byte, error) {
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, errors.Wrap(err, msg)
+		}
+		return out, errors.Wrapf(err, "error running %q", cmd.String())
+	}
+	return out, nil
+}
+
+// ContainerState represents the state of a Docker container.
+type ContainerState struct {
+	// Status is one of "created", "running", "paused", "restarting", "removing",
+	// "exited", or "dead".
+	Status string `json:"Status"`
+	// Running indicates whether the container is running.
+	Running bool `json:"Running"`
+	// ExitCode is the exit code of the container's process. It is only
+	// meaningful if the container has exited.
+	ExitCode int `json:"ExitCode"`
+	// StartedAt is the time the container was started. It is the zero value of
+	// time.Time if the container has never been started.
+	StartedAt time.Time `json:"StartedAt"`
+	// FinishedAt is the time the container exited. It is the zero value of
+	// time.Time if the container has never exited.
+	FinishedAt time.Time `json:"FinishedAt"`
+}
+
+// Container represents a Docker container, as described by the output of
+// docker inspect.
+type Container struct {
+	// ID is the container's unique identifier.
+	ID string `json:"Id"`
+	// Name is the container's name.
+	Name string `json:"Name"`
+	// Created is the time the container was created.
+	Created time.Time `json:"Created"`
+	// Config is the container's configuration.
+	Config struct {
+		// Labels are the container's labels.
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	// State is the container's state.
+	State ContainerState `json:"State"`
+}
+
+// ListContainers returns all Docker containers, running or not, bearing ALL of
+// the specified labels. Containers are ordered lexically by name.
+func ListContainers(
+	ctx context.Context,
+	dockerCommandFn DockerCommandFn,
+	labels map[string]string,
+) ([]Container, error) {
+	args := []string{"ps", "--all", "--quiet", "--no-trunc"}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(
+			args,
+			"--filter",
+			fmt.Sprintf("label=%s=%s", key, labels[key]),
+		)
+	}
+	out, err := RunDockerCommand(dockerCommandFn(ctx, args...))
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing docker containers")
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if out, err = RunDockerCommand(
+		dockerCommandFn(ctx, append([]string{"inspect"}, ids...)...),
+	); err != nil {
+		return nil, errors.Wrap(err, "error inspecting docker containers")
+	}
+	containers := []Container{}
+	if err = json.Unmarshal(out, &containers); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling docker containers")
+	}
+	for i := range containers {
+		// Docker reports names with a leading slash
+		containers[i].Name = strings.TrimPrefix(containers[i].Name, "/")
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	return containers, nil
+}
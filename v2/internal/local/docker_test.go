@@ -0,0 +1,143 @@
+package local
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDockerCommand(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cmd        *exec.Cmd
+		assertions func([]byte, error)
+	}{
+		{
+			name: "command fails",
+			cmd:  exec.Command("sh", "-c", "echo something went wrong >&2; exit 1"),
+			assertions: func(_ []byte, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			name: "command succeeds",
+			cmd:  exec.Command("echo", "foo"),
+			assertions: func(out []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "foo\n", string(out))
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(RunDockerCommand(testCase.cmd))
+		})
+	}
+}
+
+func TestListContainers(t *testing.T) {
+	testLabels := map[string]string{
+		"brigade.sh/id":    "foo",
+		"brigade.sh/event": "bar",
+	}
+	testCases := []struct {
+		name            string
+		dockerCommandFn DockerCommandFn
+		assertions      func([]Container, error)
+	}{
+		{
+			name: "error listing containers",
+			dockerCommandFn: func(context.Context, ...string) *exec.Cmd {
+				return exec.Command("false")
+			},
+			assertions: func(_ []Container, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error listing docker containers")
+			},
+		},
+		{
+			name: "no containers found",
+			dockerCommandFn: func(_ context.Context, args ...string) *exec.Cmd {
+				require.Equal(
+					t,
+					[]string{
+						"ps", "--all", "--quiet", "--no-trunc",
+						"--filter", "label=brigade.sh/event=bar",
+						"--filter", "label=brigade.sh/id=foo",
+					},
+					args,
+				)
+				return exec.Command("true")
+			},
+			assertions: func(containers []Container, err error) {
+				require.NoError(t, err)
+				require.Empty(t, containers)
+			},
+		},
+		{
+			name: "error inspecting containers",
+			dockerCommandFn: func(_ context.Context, args ...string) *exec.Cmd {
+				if args[0] == "ps" {
+					return exec.Command("echo", "abc")
+				}
+				return exec.Command("false")
+			},
+			assertions: func(_ []Container, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error inspecting docker containers")
+			},
+		},
+		{
+			name: "success",
+			dockerCommandFn: func(_ context.Context, args ...string) *exec.Cmd {
+				if args[0] == "ps" {
+					return exec.Command("printf", "abc\ndef\n")
+				}
+				require.Equal(t, []string{"inspect", "abc", "def"}, args)
+				return exec.Command(
+					"echo",
+					`[
+						{
+							"Id": "abc",
+							"Name": "/bar.worker",
+							"Config": {"Labels": {"brigade.sh/id": "foo"}},
+							"State": {"Status": "running", "Running": true}
+						},
+						{
+							"Id": "def",
+							"Name": "/bar.vcs",
+							"State": {"Status": "exited", "ExitCode": 1}
+						}
+					]`,
+				)
+			},
+			assertions: func(containers []Container, err error) {
+				require.NoError(t, err)
+				require.Len(t, containers, 2)
+				require.Equal(t, "bar.vcs", containers[0].Name)
+				require.Equal(t, 1, containers[0].State.ExitCode)
+				require.Equal(t, "bar.worker", containers[1].Name)
+				require.True(t, containers[1].State.Running)
+				require.Equal(
+					t,
+					map[string]string{"brigade.sh/id": "foo"},
+					containers[1].Config.Labels,
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				ListContainers(
+					context.Background(),
+					testCase.dockerCommandFn,
+					testLabels,
+				),
+			)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// syncBatchJobs watches the batch/v1 Jobs that wrap Worker and Job pods when
+// the substrate is configured to use them. Kubernetes may replace a failed pod
+// belonging to a batch/v1 Job, so the failure of such a pod is not reported
+// until the batch/v1 Job itself has failed. Since that may occur after the
+// pod's final update was observed, the newest pod belonging to each batch/v1
+// Job is synced again when the batch/v1 Job fails.
+func (o *observer) syncBatchJobs(ctx context.Context) {
+	batchJobsSelector := labels.Set(
+		map[string]string{
+			myk8s.LabelBrigadeID: o.config.brigadeID,
+		},
+	).AsSelector().String()
+	batchJobsInformer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = batchJobsSelector
+				return o.kubeClient.BatchV1().Jobs("").List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = batchJobsSelector
+				return o.kubeClient.BatchV1().Jobs("").Watch(ctx, options)
+			},
+		},
+		&batchv1.Job{},
+		0,
+		cache.Indexers{},
+	)
+	batchJobsInformer.AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if batchJobFailed(obj.(*batchv1.Job)) { // nolint: forcetypeassert
+					o.syncBatchJobFn(obj)
+				}
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				// Only sync upon the batch/v1 Job's transition to failed
+				if !batchJobFailed(oldObj.(*batchv1.Job)) && // nolint: forcetypeassert
+					batchJobFailed(newObj.(*batchv1.Job)) { // nolint: forcetypeassert
+					o.syncBatchJobFn(newObj)
+				}
+			},
+		},
+	)
+	batchJobsInformer.Run(ctx.Done())
+}
+
+// syncBatchJob syncs the newest pod belonging to the provided batch/v1 Job as
+// a Worker pod or Job pod, as applicable.
+func (o *observer) syncBatchJob(obj interface{}) {
+	batchJob := obj.(*batchv1.Job) // nolint: forcetypeassert
+	selector, err := metav1.LabelSelectorAsSelector(batchJob.Spec.Selector)
+	if err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error parsing selector for batch job %q in namespace %q",
+				batchJob.Name,
+				batchJob.Namespace,
+			),
+		)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiRequestTimeout)
+	defer cancel()
+	pods, err := o.kubeClient.CoreV1().Pods(batchJob.Namespace).List(
+		ctx,
+		metav1.ListOptions{
+			LabelSelector: selector.String(),
+		},
+	)
+	if err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error listing pods for batch job %q in namespace %q",
+				batchJob.Name,
+				batchJob.Namespace,
+			),
+		)
+		return
+	}
+	var newestPod *corev1.Pod
+	for i, pod := range pods.Items {
+		if newestPod == nil ||
+			newestPod.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			newestPod = &pods.Items[i]
+		}
+	}
+	if newestPod == nil {
+		return
+	}
+	switch batchJob.Labels[myk8s.LabelComponent] {
+	case myk8s.LabelKeyWorker:
+		o.syncWorkerPodFn(newestPod)
+	case myk8s.LabelKeyJob:
+		o.syncJobPodFn(newestPod)
+	}
+}
+
+// batchJobRetryPending returns a bool indicating whether the provided pod
+// belongs to a batch/v1 Job that has not (yet) failed. If so, Kubernetes may
+// replace the pod if it has failed, so its failure should not be reported.
+func (o *observer) batchJobRetryPending(
+	ctx context.Context,
+	pod *corev1.Pod,
+) bool {
+	ownerRef := metav1.GetControllerOf(pod)
+	if ownerRef == nil || ownerRef.Kind != "Job" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+	defer cancel()
+	batchJob, err := o.kubeClient.BatchV1().Jobs(pod.Namespace).Get(
+		ctx,
+		ownerRef.Name,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		// Err on the side of reporting the failure
+		o.errFn(
+			fmt.Sprintf(
+				"error retrieving batch job %q in namespace %q: %s",
+				ownerRef.Name,
+				pod.Namespace,
+				err,
+			),
+		)
+		return false
+	}
+	return !batchJobFailed(batchJob)
+}
+
+// batchJobFailed returns a bool indicating whether the provided batch/v1 Job
+// has failed.
+func batchJobFailed(batchJob *batchv1.Job) bool {
+	for _, condition := range batchJob.Status.Conditions {
+		if condition.Type == batchv1.JobFailed &&
+			condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// podTimerKey returns the key under which the timeout clock for the provided
+// pod is tracked. Pods belonging to the same batch/v1 Job share a single
+// clock, so a Worker or Job's timeout applies across all of its attempts.
+func podTimerKey(pod *corev1.Pod) string {
+	if ownerRef := metav1.GetControllerOf(pod); ownerRef != nil &&
+		ownerRef.Kind == "Job" {
+		return namespacedPodName(pod.Namespace, ownerRef.Name)
+	}
+	return namespacedPodName(pod.Namespace, pod.Name)
+}
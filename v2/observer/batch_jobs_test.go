@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncBatchJobs(t *testing.T) {
+	const testNamespace = "foo"
+	const testBatchJobName = "bar"
+
+	var syncBatchJobFnCallCount int
+	mu := &sync.Mutex{}
+
+	kubeClient := fake.NewSimpleClientset()
+
+	observer := &observer{
+		config: observerConfig{
+			brigadeID: "4077th",
+		},
+		kubeClient: kubeClient,
+		syncBatchJobFn: func(_ interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			syncBatchJobFnCallCount++
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+	defer cancel()
+
+	go observer.syncBatchJobs(ctx)
+
+	// The informer needs a little time to get going. If we don't put a little
+	// delay here, we'll be adding and updating batch jobs before the informer
+	// gets cranking.
+	<-time.After(time.Second)
+
+	batchJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testBatchJobName,
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: "4077th",
+			},
+		},
+	}
+
+	// Creation of a batch job that hasn't failed should not be synced
+	_, err := kubeClient.BatchV1().Jobs(testNamespace).Create(
+		ctx,
+		batchJob,
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	// Neither should an update that doesn't fail the batch job
+	batchJob.Annotations = map[string]string{"foo": "bar"}
+	_, err = kubeClient.BatchV1().Jobs(testNamespace).Update(
+		ctx,
+		batchJob,
+		metav1.UpdateOptions{},
+	)
+	require.NoError(t, err)
+
+	// But the batch job's transition to failed should be
+	batchJob.Status.Conditions = []batchv1.JobCondition{
+		{
+			Type:   batchv1.JobFailed,
+			Status: corev1.ConditionTrue,
+		},
+	}
+	_, err = kubeClient.BatchV1().Jobs(testNamespace).Update(
+		ctx,
+		batchJob,
+		metav1.UpdateOptions{},
+	)
+	require.NoError(t, err)
+
+	// And subsequent updates to the failed batch job should not be
+	batchJob.Annotations = map[string]string{"bat": "baz"}
+	_, err = kubeClient.BatchV1().Jobs(testNamespace).Update(
+		ctx,
+		batchJob,
+		metav1.UpdateOptions{},
+	)
+	require.NoError(t, err)
+
+	<-ctx.Done()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, syncBatchJobFnCallCount)
+}
+
+func TestSyncBatchJob(t *testing.T) {
+	const testNamespace = "foo"
+	now := time.Now()
+	testBatchJob := func(component string) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "tunguska",
+				Labels: map[string]string{
+					myk8s.LabelComponent: component,
+				},
+			},
+			Spec: batchv1.JobSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"controller-uid": "abc",
+					},
+				},
+			},
+		}
+	}
+	testPod := func(name string, created time.Time, uid string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         testNamespace,
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+				Labels: map[string]string{
+					"controller-uid": uid,
+				},
+			},
+		}
+	}
+	testCases := []struct {
+		name       string
+		batchJob   *batchv1.Job
+		assertions func(workerPods, jobPods []*corev1.Pod)
+	}{
+		{
+			name:     "worker batch job",
+			batchJob: testBatchJob(myk8s.LabelKeyWorker),
+			assertions: func(workerPods, jobPods []*corev1.Pod) {
+				require.Len(t, workerPods, 1)
+				require.Equal(t, "tunguska-newer", workerPods[0].Name)
+				require.Empty(t, jobPods)
+			},
+		},
+		{
+			name:     "job batch job",
+			batchJob: testBatchJob(myk8s.LabelKeyJob),
+			assertions: func(workerPods, jobPods []*corev1.Pod) {
+				require.Empty(t, workerPods)
+				require.Len(t, jobPods, 1)
+				require.Equal(t, "tunguska-newer", jobPods[0].Name)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			workerPods := []*corev1.Pod{}
+			jobPods := []*corev1.Pod{}
+			observer := &observer{
+				kubeClient: fake.NewSimpleClientset(
+					testPod("tunguska-older", now.Add(-time.Minute), "abc"),
+					testPod("tunguska-newer", now, "abc"),
+					testPod("unrelated", now.Add(time.Minute), "xyz"),
+				),
+				syncWorkerPodFn: func(obj interface{}) {
+					pod := obj.(*corev1.Pod) // nolint: forcetypeassert
+					workerPods = append(workerPods, pod)
+				},
+				syncJobPodFn: func(obj interface{}) {
+					pod := obj.(*corev1.Pod) // nolint: forcetypeassert
+					jobPods = append(jobPods, pod)
+				},
+				errFn: func(i ...interface{}) {
+					require.Fail(t, "error func should not have been called", i...)
+				},
+			}
+			observer.syncBatchJob(testCase.batchJob)
+			testCase.assertions(workerPods, jobPods)
+		})
+	}
+}
+
+func TestBatchJobRetryPending(t *testing.T) {
+	const testNamespace = "foo"
+	testPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "tunguska-abcde",
+		},
+	}
+	controller := true
+	testPod.OwnerReferences = []metav1.OwnerReference{
+		{
+			Kind:       "Job",
+			Name:       "tunguska",
+			Controller: &controller,
+		},
+	}
+	testCases := []struct {
+		name       string
+		pod        *corev1.Pod
+		kubeClient *fake.Clientset
+		assertions func(pending bool, errFnCalled bool)
+	}{
+		{
+			name: "pod not owned by a batch job",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testNamespace,
+					Name:      "tunguska",
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(pending bool, errFnCalled bool) {
+				require.False(t, pending)
+				require.False(t, errFnCalled)
+			},
+		},
+		{
+			name:       "error retrieving batch job",
+			pod:        testPod,
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(pending bool, errFnCalled bool) {
+				require.False(t, pending)
+				require.True(t, errFnCalled)
+			},
+		},
+		{
+			name: "batch job has not failed",
+			pod:  testPod,
+			kubeClient: fake.NewSimpleClientset(
+				&batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: testNamespace,
+						Name:      "tunguska",
+					},
+				},
+			),
+			assertions: func(pending bool, errFnCalled bool) {
+				require.True(t, pending)
+				require.False(t, errFnCalled)
+			},
+		},
+		{
+			name: "batch job has failed",
+			pod:  testPod,
+			kubeClient: fake.NewSimpleClientset(
+				&batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: testNamespace,
+						Name:      "tunguska",
+					},
+					Status: batchv1.JobStatus{
+						Conditions: []batchv1.JobCondition{
+							{
+								Type:   batchv1.JobFailed,
+								Status: corev1.ConditionTrue,
+							},
+						},
+					},
+				},
+			),
+			assertions: func(pending bool, errFnCalled bool) {
+				require.False(t, pending)
+				require.False(t, errFnCalled)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var errFnCalled bool
+			observer := &observer{
+				kubeClient: testCase.kubeClient,
+				errFn: func(...interface{}) {
+					errFnCalled = true
+				},
+			}
+			pending :=
+				observer.batchJobRetryPending(context.Background(), testCase.pod)
+			testCase.assertions(pending, errFnCalled)
+		})
+	}
+}
+
+func TestPodTimerKey(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+			Name:      "tunguska-abcde",
+		},
+	}
+	require.Equal(t, "foo:tunguska-abcde", podTimerKey(pod))
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{
+			Kind:       "Job",
+			Name:       "tunguska",
+			Controller: &controller,
+		},
+	}
+	require.Equal(t, "foo:tunguska", podTimerKey(pod))
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const apiRequestTimeout = 30 * time.Second
@@ -49,3 +54,120 @@ func (o *observer) getPodTimeoutDuration(
 	}
 	return timeout
 }
+
+// getPodTimeoutDeadline returns the absolute time at which the provided pod
+// should be timed out. The deadline is calculated from the pod's timeout
+// duration and its creation time so that neither a restart nor a failover of
+// the observer extends or resets the clock. Pods belonging to the same
+// batch/v1 Job share a single deadline that is calculated from the batch/v1
+// Job's creation time instead. A deadline persisted as an annotation on the pod
+// or batch/v1 Job, for instance because a suspended Worker's deadline was
+// extended, overrides the calculated one.
+func (o *observer) getPodTimeoutDeadline(
+	ctx context.Context,
+	pod *corev1.Pod,
+	max time.Duration,
+) time.Time {
+	annotations := pod.Annotations
+	created := pod.CreationTimestamp.Time
+	if ownerRef := metav1.GetControllerOf(pod); ownerRef != nil &&
+		ownerRef.Kind == "Job" {
+		getCtx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+		defer cancel()
+		batchJob, err := o.kubeClient.BatchV1().Jobs(pod.Namespace).Get(
+			getCtx,
+			ownerRef.Name,
+			metav1.GetOptions{},
+		)
+		if err != nil {
+			o.errFn(
+				errors.Wrapf(
+					err,
+					"error retrieving batch job %q in namespace %q",
+					ownerRef.Name,
+					pod.Namespace,
+				),
+			)
+		} else {
+			annotations = batchJob.Annotations
+			created = batchJob.CreationTimestamp.Time
+		}
+	}
+	rawDeadline := annotations[myk8s.AnnotationTimeoutDeadline]
+	if rawDeadline != "" {
+		deadline, err := time.Parse(time.RFC3339, rawDeadline)
+		if err == nil {
+			return deadline
+		}
+		o.errFn(
+			fmt.Errorf(
+				"unable to parse timeout deadline %q for pod %q; "+
+					"calculating deadline from creation time",
+				rawDeadline,
+				pod.Name,
+			),
+		)
+	}
+	// This should never happen for anything that came from the API server, but
+	// it's better to start the clock now than to time out immediately
+	if created.IsZero() {
+		created = time.Now()
+	}
+	return created.Add(o.getPodTimeoutDuration(pod, max))
+}
+
+// persistTimeoutDeadline records the provided deadline as an annotation on the
+// provided pod or, if the pod belongs to a batch/v1 Job, on the batch/v1 Job.
+func (o *observer) persistTimeoutDeadline(
+	ctx context.Context,
+	pod *corev1.Pod,
+	deadline time.Time,
+) error {
+	patch, err := json.Marshal(
+		map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{
+					myk8s.AnnotationTimeoutDeadline: deadline.UTC().Format(time.RFC3339),
+				},
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling timeout deadline patch")
+	}
+	ctx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+	defer cancel()
+	if ownerRef := metav1.GetControllerOf(pod); ownerRef != nil &&
+		ownerRef.Kind == "Job" {
+		if _, err = o.kubeClient.BatchV1().Jobs(pod.Namespace).Patch(
+			ctx,
+			ownerRef.Name,
+			types.MergePatchType,
+			patch,
+			metav1.PatchOptions{},
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error persisting timeout deadline for batch job %q in namespace %q",
+				ownerRef.Name,
+				pod.Namespace,
+			)
+		}
+		return nil
+	}
+	if _, err = o.kubeClient.CoreV1().Pods(pod.Namespace).Patch(
+		ctx,
+		pod.Name,
+		types.MergePatchType,
+		patch,
+		metav1.PatchOptions{},
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error persisting timeout deadline for pod %q in namespace %q",
+			pod.Name,
+			pod.Namespace,
+		)
+	}
+	return nil
+}
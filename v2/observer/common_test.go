@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
 	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestGetPodTimeoutDuration(t *testing.T) {
@@ -101,3 +104,266 @@ func TestGetPodTimeoutDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPodTimeoutDeadline(t *testing.T) {
+	const maxTimeout = time.Hour
+	persistedDeadline := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	podCreated := time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC)
+	batchJobCreated := time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC)
+	controller := true
+	batchJobPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace:         "foo",
+			Name:              "tunguska-abcde",
+			CreationTimestamp: v1.NewTime(podCreated),
+			OwnerReferences: []v1.OwnerReference{
+				{
+					Kind:       "Job",
+					Name:       "tunguska",
+					Controller: &controller,
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		name       string
+		pod        *corev1.Pod
+		kubeClient *fake.Clientset
+		assertions func(deadline time.Time, errFnCalled bool)
+	}{
+		{
+			name: "deadline persisted on pod",
+			pod: &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					CreationTimestamp: v1.NewTime(podCreated),
+					Annotations: map[string]string{
+						myk8s.AnnotationTimeoutDeadline: "2022-03-01T12:00:00Z",
+					},
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.True(t, persistedDeadline.Equal(deadline))
+				require.False(t, errFnCalled)
+			},
+		},
+		{
+			name: "deadline persisted on batch job",
+			pod:  batchJobPod,
+			kubeClient: fake.NewSimpleClientset(
+				&batchv1.Job{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace:         "foo",
+						Name:              "tunguska",
+						CreationTimestamp: v1.NewTime(batchJobCreated),
+						Annotations: map[string]string{
+							myk8s.AnnotationTimeoutDeadline: "2022-03-01T12:00:00Z",
+						},
+					},
+				},
+			),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.True(t, persistedDeadline.Equal(deadline))
+				require.False(t, errFnCalled)
+			},
+		},
+		{
+			name: "deadline cannot be parsed",
+			pod: &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					CreationTimestamp: v1.NewTime(podCreated),
+					Annotations: map[string]string{
+						myk8s.AnnotationTimeoutDeadline: "tomorrow",
+					},
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.True(t, podCreated.Add(maxTimeout).Equal(deadline))
+				require.True(t, errFnCalled)
+			},
+		},
+		{
+			name:       "error retrieving batch job",
+			pod:        batchJobPod,
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.True(t, podCreated.Add(maxTimeout).Equal(deadline))
+				require.True(t, errFnCalled)
+			},
+		},
+		{
+			name: "deadline calculated from batch job creation time",
+			pod:  batchJobPod,
+			kubeClient: fake.NewSimpleClientset(
+				&batchv1.Job{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace:         "foo",
+						Name:              "tunguska",
+						CreationTimestamp: v1.NewTime(batchJobCreated),
+					},
+				},
+			),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.True(t, batchJobCreated.Add(maxTimeout).Equal(deadline))
+				require.False(t, errFnCalled)
+			},
+		},
+		{
+			name:       "no creation time",
+			pod:        &corev1.Pod{},
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.WithinDuration(
+					t,
+					time.Now().Add(maxTimeout),
+					deadline,
+					time.Minute,
+				)
+				require.False(t, errFnCalled)
+			},
+		},
+		{
+			name: "success",
+			pod: &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					CreationTimestamp: v1.NewTime(podCreated),
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(deadline time.Time, errFnCalled bool) {
+				require.True(t, podCreated.Add(maxTimeout).Equal(deadline))
+				require.False(t, errFnCalled)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var errFnCalled bool
+			observer := &observer{
+				kubeClient: testCase.kubeClient,
+				errFn: func(...interface{}) {
+					errFnCalled = true
+				},
+			}
+			deadline := observer.getPodTimeoutDeadline(
+				context.Background(),
+				testCase.pod,
+				maxTimeout,
+			)
+			testCase.assertions(deadline, errFnCalled)
+		})
+	}
+}
+
+func TestPersistTimeoutDeadline(t *testing.T) {
+	deadline := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	controller := true
+	testCases := []struct {
+		name       string
+		pod        *corev1.Pod
+		kubeClient *fake.Clientset
+		assertions func(kubeClient *fake.Clientset, err error)
+	}{
+		{
+			name: "error patching pod",
+			pod: &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "tunguska",
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(),
+			assertions: func(_ *fake.Clientset, err error) {
+				require.Error(t, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"error persisting timeout deadline for pod",
+				)
+			},
+		},
+		{
+			name: "deadline persisted on pod",
+			pod: &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "tunguska",
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(
+				&corev1.Pod{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace: "foo",
+						Name:      "tunguska",
+					},
+				},
+			),
+			assertions: func(kubeClient *fake.Clientset, err error) {
+				require.NoError(t, err)
+				pod, err := kubeClient.CoreV1().Pods("foo").Get(
+					context.Background(),
+					"tunguska",
+					v1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					"2022-03-01T12:00:00Z",
+					pod.Annotations[myk8s.AnnotationTimeoutDeadline],
+				)
+			},
+		},
+		{
+			name: "deadline persisted on batch job",
+			pod: &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "tunguska-abcde",
+					OwnerReferences: []v1.OwnerReference{
+						{
+							Kind:       "Job",
+							Name:       "tunguska",
+							Controller: &controller,
+						},
+					},
+				},
+			},
+			kubeClient: fake.NewSimpleClientset(
+				&batchv1.Job{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace: "foo",
+						Name:      "tunguska",
+					},
+				},
+			),
+			assertions: func(kubeClient *fake.Clientset, err error) {
+				require.NoError(t, err)
+				batchJob, err := kubeClient.BatchV1().Jobs("foo").Get(
+					context.Background(),
+					"tunguska",
+					v1.GetOptions{},
+				)
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					"2022-03-01T12:00:00Z",
+					batchJob.Annotations[myk8s.AnnotationTimeoutDeadline],
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			observer := &observer{
+				kubeClient: testCase.kubeClient,
+			}
+			err := observer.persistTimeoutDeadline(
+				context.Background(),
+				testCase.pod,
+				deadline,
+			)
+			testCase.assertions(testCase.kubeClient, err)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/brigadecore/brigade/sdk/v3"
@@ -49,6 +50,11 @@ func (o *observer) syncJobPod(obj interface{}) {
 	pod := obj.(*corev1.Pod) // nolint: forcetypeassert
 	// Map pod status to job status
 	status := o.getJobStatusFromPod(pod)
+	// If the pod failed, but Kubernetes may yet replace it, don't report it
+	if status.Phase == sdk.JobPhaseFailed && pod.DeletionTimestamp == nil &&
+		o.config.batchJobsEnabled && o.batchJobRetryPendingFn(ctx, pod) {
+		return
+	}
 	// Manage the timeout clock
 	o.manageJobTimeoutFn(ctx, pod, status.Phase)
 	// Use the API to update Job status
@@ -124,6 +130,15 @@ func (o *observer) getJobStatusFromPod(pod *corev1.Pod) sdk.JobStatus {
 					break
 				}
 			}
+			// The same applies to init containers.
+			for _, containerStatus := range pod.Status.InitContainerStatuses {
+				if containerStatus.State.Waiting != nil &&
+					(containerStatus.State.Waiting.Reason == "ImagePullBackOff" ||
+						containerStatus.State.Waiting.Reason == "ErrImagePull") {
+					status.Phase = sdk.JobPhaseFailed
+					break
+				}
+			}
 		case corev1.PodRunning:
 			status.Phase = sdk.JobPhaseRunning
 		case corev1.PodSucceeded:
@@ -154,18 +169,86 @@ func (o *observer) getJobStatusFromPod(pod *corev1.Pod) sdk.JobStatus {
 	if pod.Status.StartTime != nil {
 		status.Started = &pod.Status.StartTime.Time
 	}
-	// Determine the job's end time based on container[0] end time
+	// Determine the job's end time and outputs based on container[0]
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		if containerStatus.Name == pod.Spec.Containers[0].Name {
 			if containerStatus.State.Terminated != nil {
 				status.Ended = &containerStatus.State.Terminated.FinishedAt.Time
+				status.Outputs =
+					getJobOutputsFromMessage(containerStatus.State.Terminated.Message)
 			}
 			break
 		}
 	}
+	status.InitContainers = getJobInitContainerStatuses(pod)
 	return status
 }
 
+// getJobInitContainerStatuses maps the states of a job pod's init containers to
+// JobInitContainerStatuses, in the order that the init containers are
+// executed. If the pod has no init containers, nil is returned.
+func getJobInitContainerStatuses(
+	pod *corev1.Pod,
+) []sdk.JobInitContainerStatus {
+	if len(pod.Spec.InitContainers) == 0 {
+		return nil
+	}
+	containerStatuses :=
+		make(map[string]corev1.ContainerStatus, len(pod.Spec.InitContainers))
+	for _, containerStatus := range pod.Status.InitContainerStatuses {
+		containerStatuses[containerStatus.Name] = containerStatus
+	}
+	statuses := make([]sdk.JobInitContainerStatus, len(pod.Spec.InitContainers))
+	for i, initContainer := range pod.Spec.InitContainers {
+		statuses[i] = sdk.JobInitContainerStatus{
+			Name:  initContainer.Name,
+			Phase: sdk.JobPhasePending,
+		}
+		containerStatus, ok := containerStatuses[initContainer.Name]
+		if !ok {
+			continue
+		}
+		state := containerStatus.State
+		if state.Running != nil {
+			statuses[i].Phase = sdk.JobPhaseRunning
+			statuses[i].Started = &state.Running.StartedAt.Time
+		} else if state.Terminated != nil {
+			statuses[i].Started = &state.Terminated.StartedAt.Time
+			statuses[i].Ended = &state.Terminated.FinishedAt.Time
+			if state.Terminated.ExitCode == 0 {
+				statuses[i].Phase = sdk.JobPhaseSucceeded
+			} else {
+				statuses[i].Phase = sdk.JobPhaseFailed
+			}
+		}
+	}
+	return statuses
+}
+
+// getJobOutputsFromMessage parses the termination message of a job's primary
+// container into a map of job outputs. Job containers publish outputs by
+// writing KEY=VALUE lines to /dev/termination-log, which Kubernetes surfaces as
+// the container's termination message. Lines lacking a key are ignored. If no
+// outputs were found, nil is returned.
+func getJobOutputsFromMessage(message string) map[string]string {
+	var outputs map[string]string
+	for _, line := range strings.Split(message, "\n") {
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if outputs == nil {
+			outputs = map[string]string{}
+		}
+		outputs[key] = strings.TrimSpace(value)
+	}
+	return outputs
+}
+
 // manageJobTimeout takes a pod and job phase as input. If the phase is
 // terminal and the timeout clock is already running for the pod, the clock is
 // stopped. If the phase is NOT terminal and the timeout clock is NOT already
@@ -175,24 +258,23 @@ func (o *observer) manageJobTimeout(
 	pod *corev1.Pod,
 	phase sdk.JobPhase,
 ) {
-	namespacedPodName := namespacedPodName(pod.Namespace, pod.Name)
-	cancelFn, timed := o.timedPodsSet[namespacedPodName]
+	podTimerKey := podTimerKey(pod)
+	cancelFn, timed := o.timedPodsSet[podTimerKey]
 	if phase.IsTerminal() && timed {
 		cancelFn() // Stop the clock
 		return
 	}
 	if !phase.IsTerminal() && !timed {
 		// Start the clock
-		ctx, o.timedPodsSet[namespacedPodName] = context.WithCancel(ctx)
+		ctx, o.timedPodsSet[podTimerKey] = context.WithCancel(ctx)
 		go o.runJobTimerFn(ctx, pod)
 	}
 }
 
 func (o *observer) runJobTimer(ctx context.Context, pod *corev1.Pod) {
-	namespacedPodName := namespacedPodName(pod.Namespace, pod.Name)
-	defer delete(o.timedPodsSet, namespacedPodName)
+	defer delete(o.timedPodsSet, podTimerKey(pod))
 	timer := time.NewTimer(
-		o.getPodTimeoutDuration(pod, o.config.maxJobLifetime),
+		time.Until(o.getPodTimeoutDeadline(ctx, pod, o.config.maxJobLifetime)),
 	)
 	defer timer.Stop()
 	select {
@@ -155,6 +155,59 @@ func TestSyncJobPod(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "pod phase is pending and init container image can't be pulled",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{
+							Name: "foo",
+						},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: "foo",
+							State: corev1.ContainerState{
+								Waiting: &corev1.ContainerStateWaiting{
+									Reason: "ImagePullBackOff",
+								},
+							},
+						},
+					},
+				},
+			},
+			observer: &observer{
+				timedPodsSet: map[string]context.CancelFunc{},
+				manageJobTimeoutFn: func(
+					context.Context,
+					*corev1.Pod,
+					sdk.JobPhase,
+				) {
+				},
+				jobsClient: &coreTesting.MockJobsClient{
+					UpdateStatusFn: func(
+						ctx context.Context,
+						eventID string,
+						jobName string,
+						status sdk.JobStatus,
+						_ *sdk.JobStatusUpdateOptions,
+					) error {
+						require.Equal(t, sdk.JobPhaseFailed, status.Phase)
+						require.Len(t, status.InitContainers, 1)
+						require.Equal(
+							t,
+							sdk.JobPhasePending,
+							status.InitContainers[0].Phase,
+						)
+						return nil
+					},
+				},
+				cleanupJobFn: func(_, _ string) {},
+			},
+		},
 		{
 			name: "pod phase is running and container[0] is not finished",
 			pod: &corev1.Pod{
@@ -212,6 +265,7 @@ func TestSyncJobPod(t *testing.T) {
 									FinishedAt: metav1.Time{
 										Time: now,
 									},
+									Message: "tag=v1.2.3\n",
 								},
 							},
 						},
@@ -239,6 +293,11 @@ func TestSyncJobPod(t *testing.T) {
 						require.Equal(t, sdk.JobPhaseSucceeded, status.Phase)
 						require.NotNil(t, status.Ended)
 						require.Equal(t, now, *status.Ended)
+						require.Equal(
+							t,
+							map[string]string{"tag": "v1.2.3"},
+							status.Outputs,
+						)
 						return nil
 					},
 				},
@@ -455,6 +514,127 @@ func TestSyncJobPod(t *testing.T) {
 	}
 }
 
+func TestGetJobOutputsFromMessage(t *testing.T) {
+	testCases := []struct {
+		name            string
+		message         string
+		expectedOutputs map[string]string
+	}{
+		{
+			name:            "empty message",
+			message:         "",
+			expectedOutputs: nil,
+		},
+		{
+			name:            "no key/value pairs",
+			message:         "just some text\n\n",
+			expectedOutputs: nil,
+		},
+		{
+			name:    "key/value pairs",
+			message: "tag=v1.2.3\n  digest = sha256:abc=\nbogus\n=nokey\n",
+			expectedOutputs: map[string]string{
+				"tag":    "v1.2.3",
+				"digest": "sha256:abc=",
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.Equal(
+				t,
+				testCase.expectedOutputs,
+				getJobOutputsFromMessage(testCase.message),
+			)
+		})
+	}
+}
+
+func TestGetJobInitContainerStatuses(t *testing.T) {
+	started := time.Now().UTC()
+	ended := started.Add(time.Minute)
+	testCases := []struct {
+		name       string
+		pod        *corev1.Pod
+		assertions func([]sdk.JobInitContainerStatus)
+	}{
+		{
+			name: "no init containers",
+			pod:  &corev1.Pod{},
+			assertions: func(statuses []sdk.JobInitContainerStatus) {
+				require.Nil(t, statuses)
+			},
+		},
+		{
+			name: "init containers in various states",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "vcs"},
+						{Name: "foo"},
+						{Name: "bar"},
+						{Name: "bat"},
+					},
+				},
+				Status: corev1.PodStatus{
+					// Deliberately out of order
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: "foo",
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{
+									ExitCode:   1,
+									StartedAt:  metav1.NewTime(started),
+									FinishedAt: metav1.NewTime(ended),
+								},
+							},
+						},
+						{
+							Name: "vcs",
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{
+									ExitCode:   0,
+									StartedAt:  metav1.NewTime(started),
+									FinishedAt: metav1.NewTime(ended),
+								},
+							},
+						},
+						{
+							Name: "bar",
+							State: corev1.ContainerState{
+								Running: &corev1.ContainerStateRunning{
+									StartedAt: metav1.NewTime(started),
+								},
+							},
+						},
+					},
+				},
+			},
+			assertions: func(statuses []sdk.JobInitContainerStatus) {
+				require.Len(t, statuses, 4)
+				require.Equal(t, "vcs", statuses[0].Name)
+				require.Equal(t, sdk.JobPhaseSucceeded, statuses[0].Phase)
+				require.Equal(t, started, *statuses[0].Started)
+				require.Equal(t, ended, *statuses[0].Ended)
+				require.Equal(t, "foo", statuses[1].Name)
+				require.Equal(t, sdk.JobPhaseFailed, statuses[1].Phase)
+				require.Equal(t, "bar", statuses[2].Name)
+				require.Equal(t, sdk.JobPhaseRunning, statuses[2].Phase)
+				require.Equal(t, started, *statuses[2].Started)
+				require.Nil(t, statuses[2].Ended)
+				require.Equal(t, "bat", statuses[3].Name)
+				require.Equal(t, sdk.JobPhasePending, statuses[3].Phase)
+				require.Nil(t, statuses[3].Started)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(getJobInitContainerStatuses(testCase.pod))
+		})
+	}
+}
+
 func TestManageJobTimeout(t *testing.T) {
 	testPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -562,6 +742,13 @@ func TestRunJobTimer(t *testing.T) {
 				timedPodsSet: map[string]context.CancelFunc{
 					"ns:nombre": func() {},
 				},
+				persistTimeoutDeadlineFn: func(
+					context.Context,
+					*corev1.Pod,
+					time.Time,
+				) error {
+					return nil
+				},
 				jobsClient: &coreTesting.MockJobsClient{
 					TimeoutFn: func(
 						context.Context,
@@ -606,6 +793,13 @@ func TestRunJobTimer(t *testing.T) {
 				timedPodsSet: map[string]context.CancelFunc{
 					"ns:nombre": func() {},
 				},
+				persistTimeoutDeadlineFn: func(
+					context.Context,
+					*corev1.Pod,
+					time.Time,
+				) error {
+					return nil
+				},
 				jobsClient: &coreTesting.MockJobsClient{
 					TimeoutFn: func(
 						context.Context,
@@ -649,6 +843,13 @@ func TestRunJobTimer(t *testing.T) {
 				timedPodsSet: map[string]context.CancelFunc{
 					"ns:nombre": func() {},
 				},
+				persistTimeoutDeadlineFn: func(
+					context.Context,
+					*corev1.Pod,
+					time.Time,
+				) error {
+					return nil
+				},
 				jobsClient: &coreTesting.MockJobsClient{
 					TimeoutFn: func(
 						context.Context,
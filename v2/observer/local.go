@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newLocalObserver returns an observer for Workers and Jobs that a local
+// substrate runs as Docker containers instead of as Kubernetes pods. The
+// containers belonging to each Worker or Job are periodically translated into
+// an equivalent pod so that all of the observer's other logic can be reused
+// as-is.
+func newLocalObserver(
+	systemClient sdk.SystemClient,
+	eventsClient sdk.EventsClient,
+	projectsClient sdk.ProjectsClient,
+	config observerConfig,
+) *observer {
+	o := newObserver(systemClient, eventsClient, projectsClient, nil, config)
+	o.dockerCommandFn = local.DockerCommand
+	o.syncWorkerPodsFn = func(ctx context.Context) {
+		o.syncLocalPods(ctx, myk8s.LabelKeyWorker, o.syncWorkerPodFn)
+	}
+	o.syncJobPodsFn = func(ctx context.Context) {
+		o.syncLocalPods(ctx, myk8s.LabelKeyJob, o.syncJobPodFn)
+	}
+	o.getPodsFn = o.getLocalPods
+	o.deleteOrphansFn = o.deleteLocalOrphans
+	// There is nowhere to persist timeout deadlines for Docker containers, but
+	// there is also only ever one observer for a local substrate
+	o.persistTimeoutDeadlineFn =
+		func(context.Context, *corev1.Pod, time.Time) error { return nil }
+	o.checkK8sAPIServer = func(ctx context.Context) ([]byte, error) {
+		return local.RunDockerCommand(o.dockerCommandFn(ctx, "version"))
+	}
+	return o
+}
+
+// syncLocalPods periodically polls the Docker daemon for containers belonging
+// to the specified component (Worker or Job) until the provided context is
+// canceled. Each time the status of a Worker or Job's pod equivalent changes,
+// the pod is passed to the provided sync function. When all of a Worker or
+// Job's containers have been removed, a final copy of its pod is passed to the
+// sync function with its DeletionTimestamp set.
+func (o *observer) syncLocalPods(
+	ctx context.Context,
+	component string,
+	syncPodFn func(obj interface{}),
+) {
+	ticker := time.NewTicker(o.config.localPollInterval)
+	defer ticker.Stop()
+	knownPods := map[string]*corev1.Pod{}
+	for {
+		pods, err := o.getLocalPods(ctx, component)
+		if err != nil {
+			o.errFn(err)
+		} else {
+			currentPods := make(map[string]*corev1.Pod, len(pods))
+			for _, pod := range pods {
+				key := namespacedPodName(pod.Namespace, pod.Name)
+				currentPods[key] = pod
+				if knownPod, ok := knownPods[key]; !ok ||
+					!reflect.DeepEqual(knownPod.Status, pod.Status) {
+					syncPodFn(pod)
+				}
+			}
+			for key, pod := range knownPods {
+				if _, ok := currentPods[key]; !ok {
+					pod = pod.DeepCopy()
+					now := metav1.Now()
+					pod.DeletionTimestamp = &now
+					syncPodFn(pod)
+				}
+			}
+			knownPods = currentPods
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// getLocalPods returns a pod equivalent for every Worker or Job (depending on
+// the specified component) that currently has one or more Docker containers.
+func (o *observer) getLocalPods(
+	ctx context.Context,
+	component string,
+) ([]*corev1.Pod, error) {
+	containers, err := local.ListContainers(
+		ctx,
+		o.dockerCommandFn,
+		map[string]string{
+			myk8s.LabelBrigadeID: o.config.brigadeID,
+			myk8s.LabelComponent: component,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing %s containers", component)
+	}
+	podNames := []string{}
+	containersByPod := map[string][]local.Container{}
+	for _, container := range containers {
+		labels := container.Config.Labels
+		podName := myk8s.WorkerPodName(labels[myk8s.LabelEvent])
+		if component == myk8s.LabelKeyJob {
+			podName =
+				myk8s.JobPodName(labels[myk8s.LabelEvent], labels[myk8s.LabelJob])
+		}
+		podName = namespacedPodName(labels[myk8s.LabelProject], podName)
+		if _, ok := containersByPod[podName]; !ok {
+			podNames = append(podNames, podName)
+		}
+		containersByPod[podName] = append(containersByPod[podName], container)
+	}
+	pods := make([]*corev1.Pod, len(podNames))
+	for i, podName := range podNames {
+		pods[i] = o.getPodFromContainers(containersByPod[podName])
+	}
+	return pods, nil
+}
+
+// getPodFromContainers translates the Docker containers belonging to a single
+// Worker or Job into an equivalent pod, bearing the same labels, annotations,
+// and statuses that the observer would expect of a pod created by the
+// Kubernetes substrate.
+func (o *observer) getPodFromContainers(
+	containers []local.Container,
+) *corev1.Pod {
+	labels := containers[0].Config.Labels
+	eventID := labels[myk8s.LabelEvent]
+	jobName := labels[myk8s.LabelJob]
+	// The local substrate records the timeout as a label because Docker
+	// containers have no annotations
+	timeoutDuration := labels[myk8s.AnnotationTimeoutDuration]
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: labels[myk8s.LabelProject],
+			Name:      myk8s.WorkerPodName(eventID),
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: labels[myk8s.LabelBrigadeID],
+				myk8s.LabelComponent: labels[myk8s.LabelComponent],
+				myk8s.LabelProject:   labels[myk8s.LabelProject],
+				myk8s.LabelEvent:     eventID,
+			},
+			Annotations: map[string]string{
+				myk8s.AnnotationTimeoutDuration: timeoutDuration,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: labels[local.LabelPrimaryContainer],
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+		},
+	}
+	if labels[myk8s.LabelComponent] == myk8s.LabelKeyJob {
+		pod.Name = myk8s.JobPodName(eventID, jobName)
+		pod.Labels[myk8s.LabelJob] = jobName
+	}
+	initContainerNames := map[string]struct{}{}
+	if names := labels[local.LabelInitContainers]; names != "" {
+		for _, name := range strings.Split(names, ",") {
+			pod.Spec.InitContainers =
+				append(pod.Spec.InitContainers, corev1.Container{Name: name})
+			initContainerNames[name] = struct{}{}
+		}
+	}
+
+	var primaryState *corev1.ContainerState
+	for _, container := range containers {
+		// The pod's creation time is that of its earliest container. The observer
+		// calculates timeout deadlines from it.
+		if !container.Created.IsZero() && (pod.CreationTimestamp.IsZero() ||
+			container.Created.Before(pod.CreationTimestamp.Time)) {
+			pod.CreationTimestamp = metav1.NewTime(container.Created)
+		}
+		containerStatus := corev1.ContainerStatus{
+			Name:  container.Config.Labels[local.LabelContainer],
+			State: getContainerState(container.State),
+		}
+		if !container.State.StartedAt.IsZero() && (pod.Status.StartTime == nil ||
+			container.State.StartedAt.Before(pod.Status.StartTime.Time)) {
+			startTime := metav1.NewTime(container.State.StartedAt)
+			pod.Status.StartTime = &startTime
+		}
+		if _, ok := initContainerNames[containerStatus.Name]; ok {
+			pod.Status.InitContainerStatuses =
+				append(pod.Status.InitContainerStatuses, containerStatus)
+			if terminated := containerStatus.State.Terminated; terminated != nil &&
+				terminated.ExitCode != 0 {
+				pod.Status.Phase = corev1.PodFailed
+			}
+			continue
+		}
+		if containerStatus.Name == pod.Spec.Containers[0].Name {
+			// A Job's primary container publishes its outputs by writing to a
+			// termination log that the substrate bind mounted from the host.
+			if terminated := containerStatus.State.Terminated; terminated != nil &&
+				jobName != "" {
+				if message, err := os.ReadFile(
+					local.JobTerminationLogPath(
+						o.config.localRootDirectory,
+						eventID,
+						jobName,
+					),
+				); err == nil {
+					terminated.Message = string(message)
+				}
+			}
+			primaryState = &containerStatus.State
+		}
+		pod.Status.ContainerStatuses =
+			append(pod.Status.ContainerStatuses, containerStatus)
+	}
+
+	// Determine the pod's phase based on the state of the primary container,
+	// unless an init container has already failed
+	if pod.Status.Phase != corev1.PodFailed && primaryState != nil {
+		if primaryState.Running != nil {
+			pod.Status.Phase = corev1.PodRunning
+		} else if primaryState.Terminated != nil {
+			if primaryState.Terminated.ExitCode == 0 {
+				pod.Status.Phase = corev1.PodSucceeded
+			} else {
+				pod.Status.Phase = corev1.PodFailed
+			}
+		}
+	}
+	return pod
+}
+
+// getContainerState translates the state of a Docker container into the
+// equivalent corev1.ContainerState.
+func getContainerState(state local.ContainerState) corev1.ContainerState {
+	switch state.Status {
+	case "running", "paused", "restarting":
+		return corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{
+				StartedAt: metav1.NewTime(state.StartedAt),
+			},
+		}
+	case "exited", "dead", "removing":
+		return corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{
+				ExitCode:   int32(state.ExitCode),
+				StartedAt:  metav1.NewTime(state.StartedAt),
+				FinishedAt: metav1.NewTime(state.FinishedAt),
+			},
+		}
+	default:
+		return corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{
+				Reason: "ContainerCreating",
+			},
+		}
+	}
+}
+
+// deleteLocalOrphans removes all Docker containers and directories belonging to
+// Events that no longer exist. Such resources are normally removed along with
+// the Event, but can be left behind if the Docker daemon was unavailable at the
+// time.
+func (o *observer) deleteLocalOrphans(ctx context.Context) error {
+	containers, err := local.ListContainers(
+		ctx,
+		o.dockerCommandFn,
+		map[string]string{
+			myk8s.LabelBrigadeID: o.config.brigadeID,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "error listing containers")
+	}
+	// Maps Event IDs to the IDs of the Event's containers
+	eventContainers := map[string][]string{}
+	for _, container := range containers {
+		eventID, ok := container.Config.Labels[myk8s.LabelEvent]
+		if !ok {
+			continue
+		}
+		eventContainers[eventID] = append(eventContainers[eventID], container.ID)
+	}
+	eventIDs := make([]string, 0, len(eventContainers))
+	for eventID := range eventContainers {
+		eventIDs = append(eventIDs, eventID)
+	}
+	for _, eventID := range o.getOrphanedEventIDs(ctx, eventIDs) {
+		if _, err = local.RunDockerCommand(
+			o.dockerCommandFn(
+				ctx,
+				append(
+					[]string{"rm", "--force", "--volumes"},
+					eventContainers[eventID]...,
+				)...,
+			),
+		); err != nil {
+			o.errFn(
+				errors.Wrapf(
+					err,
+					"error removing orphaned event %q containers",
+					eventID,
+				),
+			)
+			continue
+		}
+		eventDir := local.EventDirectory(o.config.localRootDirectory, eventID)
+		if err = os.RemoveAll(eventDir); err != nil {
+			o.errFn(
+				errors.Wrapf(
+					err,
+					"error removing directory %q for orphaned event %q",
+					eventDir,
+					eventID,
+				),
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/brigadecore/brigade/sdk/v3/restmachinery"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewLocalObserver(t *testing.T) {
+	observer := newLocalObserver(
+		sdk.NewSystemClient("", "", &restmachinery.APIClientOptions{}),
+		sdk.NewEventsClient("", "", &restmachinery.APIClientOptions{}),
+		sdk.NewProjectsClient("", "", &restmachinery.APIClientOptions{}),
+		observerConfig{},
+	)
+	require.Nil(t, observer.kubeClient)
+	require.NotNil(t, observer.dockerCommandFn)
+	require.NotNil(t, observer.syncWorkerPodsFn)
+	require.NotNil(t, observer.syncJobPodsFn)
+	require.NotNil(t, observer.getPodsFn)
+	require.NotNil(t, observer.deleteOrphansFn)
+	require.NotNil(t, observer.checkK8sAPIServer)
+}
+
+func TestSyncLocalPods(t *testing.T) {
+	mu := &sync.Mutex{}
+	containers := []local.Container{
+		testLocalContainer(
+			"tunguska.worker",
+			myk8s.LabelKeyWorker,
+			local.ContainerState{Status: "running", Running: true},
+		),
+	}
+	syncedPods := []*corev1.Pod{}
+	observer := &observer{
+		config: observerConfig{
+			brigadeID:         "4077th",
+			localPollInterval: 100 * time.Millisecond,
+		},
+		dockerCommandFn: func(_ context.Context, args ...string) *exec.Cmd {
+			mu.Lock()
+			defer mu.Unlock()
+			var output []byte
+			if args[0] == "ps" {
+				for _, container := range containers {
+					output = append(output, []byte(container.ID+"\n")...)
+				}
+			} else {
+				var err error
+				output, err = json.Marshal(containers)
+				require.NoError(t, err)
+			}
+			return exec.Command("sh", "-c", `printf '%s' "$0"`, string(output))
+		},
+		errFn: func(i ...interface{}) {
+			require.Fail(t, "error func should not have been called", i...)
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go observer.syncLocalPods(
+		ctx,
+		myk8s.LabelKeyWorker,
+		func(obj interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			pod := obj.(*corev1.Pod) // nolint: forcetypeassert
+			syncedPods = append(syncedPods, pod)
+		},
+	)
+
+	// Several polls of an unchanged container should result in only one sync
+	<-time.After(350 * time.Millisecond)
+	mu.Lock()
+	require.Len(t, syncedPods, 1)
+	require.Equal(t, "tunguska", syncedPods[0].Name)
+	require.Equal(t, corev1.PodRunning, syncedPods[0].Status.Phase)
+	require.Nil(t, syncedPods[0].DeletionTimestamp)
+	// Change the container's state
+	containers[0].State = local.ContainerState{Status: "exited"}
+	mu.Unlock()
+
+	<-time.After(350 * time.Millisecond)
+	mu.Lock()
+	require.Len(t, syncedPods, 2)
+	require.Equal(t, corev1.PodSucceeded, syncedPods[1].Status.Phase)
+	// Remove the container
+	containers = nil
+	mu.Unlock()
+
+	<-time.After(350 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, syncedPods, 3)
+	require.Equal(t, "tunguska", syncedPods[2].Name)
+	require.NotNil(t, syncedPods[2].DeletionTimestamp)
+}
+
+func TestGetPodFromContainers(t *testing.T) {
+	createdTime := time.Date(2020, 12, 31, 23, 59, 0, 0, time.UTC)
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(time.Minute)
+	testCases := []struct {
+		name       string
+		containers []local.Container
+		setup      func(rootDir string)
+		assertions func(*corev1.Pod)
+	}{
+		{
+			name: "worker not yet started",
+			containers: []local.Container{
+				testLocalContainer(
+					"tunguska.vcs",
+					myk8s.LabelKeyWorker,
+					local.ContainerState{Status: "created"},
+				),
+				testLocalContainer(
+					"tunguska.worker",
+					myk8s.LabelKeyWorker,
+					local.ContainerState{Status: "created"},
+				),
+			},
+			assertions: func(pod *corev1.Pod) {
+				require.Equal(t, "italian", pod.Namespace)
+				require.Equal(t, "tunguska", pod.Name)
+				require.Equal(
+					t,
+					map[string]string{
+						myk8s.LabelBrigadeID: "4077th",
+						myk8s.LabelComponent: myk8s.LabelKeyWorker,
+						myk8s.LabelProject:   "italian",
+						myk8s.LabelEvent:     "tunguska",
+					},
+					pod.Labels,
+				)
+				require.Equal(
+					t,
+					"5m",
+					pod.Annotations[myk8s.AnnotationTimeoutDuration],
+				)
+				require.Equal(t, "vcs", pod.Spec.InitContainers[0].Name)
+				require.Equal(t, "worker", pod.Spec.Containers[0].Name)
+				require.Equal(t, corev1.PodPending, pod.Status.Phase)
+				require.Nil(t, pod.Status.StartTime)
+				require.Len(t, pod.Status.InitContainerStatuses, 1)
+				require.Len(t, pod.Status.ContainerStatuses, 1)
+				require.NotNil(t, pod.Status.ContainerStatuses[0].State.Waiting)
+			},
+		},
+		{
+			name: "worker init container failed",
+			containers: []local.Container{
+				testLocalContainer(
+					"tunguska.vcs",
+					myk8s.LabelKeyWorker,
+					local.ContainerState{
+						Status:     "exited",
+						ExitCode:   1,
+						StartedAt:  startTime,
+						FinishedAt: endTime,
+					},
+				),
+				testLocalContainer(
+					"tunguska.worker",
+					myk8s.LabelKeyWorker,
+					local.ContainerState{Status: "created"},
+				),
+			},
+			assertions: func(pod *corev1.Pod) {
+				require.Equal(t, corev1.PodFailed, pod.Status.Phase)
+				require.Equal(t, startTime, pod.Status.StartTime.Time)
+			},
+		},
+		{
+			name: "worker running",
+			containers: []local.Container{
+				testLocalContainer(
+					"tunguska.vcs",
+					myk8s.LabelKeyWorker,
+					local.ContainerState{
+						Status:     "exited",
+						StartedAt:  startTime,
+						FinishedAt: endTime,
+					},
+				),
+				func() local.Container {
+					container := testLocalContainer(
+						"tunguska.worker",
+						myk8s.LabelKeyWorker,
+						local.ContainerState{
+							Status:    "running",
+							Running:   true,
+							StartedAt: endTime,
+						},
+					)
+					container.Created = createdTime.Add(time.Second)
+					return container
+				}(),
+			},
+			assertions: func(pod *corev1.Pod) {
+				require.Equal(t, corev1.PodRunning, pod.Status.Phase)
+				require.Equal(t, createdTime, pod.CreationTimestamp.Time)
+				require.Equal(t, startTime, pod.Status.StartTime.Time)
+			},
+		},
+		{
+			name: "job failed",
+			containers: []local.Container{
+				testLocalContainer(
+					"tunguska.foo.bar",
+					myk8s.LabelKeyJob,
+					local.ContainerState{
+						Status:     "exited",
+						ExitCode:   42,
+						StartedAt:  startTime,
+						FinishedAt: endTime,
+					},
+				),
+			},
+			assertions: func(pod *corev1.Pod) {
+				require.Equal(t, "tunguska-foo", pod.Name)
+				require.Equal(t, "foo", pod.Labels[myk8s.LabelJob])
+				require.Equal(t, corev1.PodFailed, pod.Status.Phase)
+				require.Empty(
+					t,
+					pod.Status.ContainerStatuses[0].State.Terminated.Message,
+				)
+			},
+		},
+		{
+			name: "job succeeded with outputs",
+			containers: []local.Container{
+				testLocalContainer(
+					"tunguska.foo.bar",
+					myk8s.LabelKeyJob,
+					local.ContainerState{
+						Status:     "exited",
+						StartedAt:  startTime,
+						FinishedAt: endTime,
+					},
+				),
+			},
+			setup: func(rootDir string) {
+				path := local.JobTerminationLogPath(rootDir, "tunguska", "foo")
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+				require.NoError(t, os.WriteFile(path, []byte("foo=bar\n"), 0600))
+			},
+			assertions: func(pod *corev1.Pod) {
+				require.Equal(t, corev1.PodSucceeded, pod.Status.Phase)
+				terminated := pod.Status.ContainerStatuses[0].State.Terminated
+				require.Equal(t, "foo=bar\n", terminated.Message)
+				require.Equal(t, endTime, terminated.FinishedAt.Time)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			if testCase.setup != nil {
+				testCase.setup(rootDir)
+			}
+			observer := &observer{
+				config: observerConfig{
+					localRootDirectory: rootDir,
+				},
+			}
+			testCase.assertions(observer.getPodFromContainers(testCase.containers))
+		})
+	}
+}
+
+func TestGetContainerState(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(time.Minute)
+	state := getContainerState(local.ContainerState{Status: "created"})
+	require.NotNil(t, state.Waiting)
+	state = getContainerState(
+		local.ContainerState{
+			Status:    "running",
+			Running:   true,
+			StartedAt: startTime,
+		},
+	)
+	require.NotNil(t, state.Running)
+	require.Equal(t, startTime, state.Running.StartedAt.Time)
+	state = getContainerState(
+		local.ContainerState{
+			Status:     "exited",
+			ExitCode:   42,
+			StartedAt:  startTime,
+			FinishedAt: endTime,
+		},
+	)
+	require.NotNil(t, state.Terminated)
+	require.Equal(t, int32(42), state.Terminated.ExitCode)
+	require.Equal(t, startTime, state.Terminated.StartedAt.Time)
+	require.Equal(t, endTime, state.Terminated.FinishedAt.Time)
+}
+
+// testLocalContainer returns a local.Container with the specified name,
+// labeled as the local substrate would label it. Names are of the form
+// EVENT.CONTAINER for Worker containers and EVENT.JOB.CONTAINER for Job
+// containers.
+func testLocalContainer(
+	name string,
+	component string,
+	state local.ContainerState,
+) local.Container {
+	container := local.Container{
+		ID:      name + "-id",
+		Name:    name,
+		Created: time.Date(2020, 12, 31, 23, 59, 0, 0, time.UTC),
+		State:   state,
+	}
+	container.Config.Labels = map[string]string{
+		myk8s.LabelBrigadeID:            "4077th",
+		myk8s.LabelComponent:            component,
+		myk8s.LabelProject:              "italian",
+		myk8s.LabelEvent:                "tunguska",
+		myk8s.AnnotationTimeoutDuration: "5m",
+	}
+	if component == myk8s.LabelKeyJob {
+		container.Config.Labels[myk8s.LabelJob] = "foo"
+		container.Config.Labels[local.LabelPrimaryContainer] = "bar"
+		container.Config.Labels[local.LabelContainer] = "bar"
+	} else {
+		container.Config.Labels[local.LabelPrimaryContainer] = "worker"
+		container.Config.Labels[local.LabelInitContainers] = "vcs"
+		container.Config.Labels[local.LabelContainer] = "worker"
+		if name == "tunguska.vcs" {
+			container.Config.Labels[local.LabelContainer] = "vcs"
+		}
+	}
+	return container
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/brigadecore/brigade-foundations/version"
 	"github.com/brigadecore/brigade/sdk/v3"
 	"github.com/brigadecore/brigade/v2/internal/kubernetes"
+	k8s "k8s.io/client-go/kubernetes"
 )
 
 func main() {
@@ -21,9 +22,10 @@ func main() {
 
 	ctx := signals.Context()
 
-	// Brigade Healthcheck and Workers API clients
+	// Brigade Healthcheck, Events, and Projects API clients
 	var systemClient sdk.SystemClient
-	var workersClient sdk.WorkersClient
+	var eventsClient sdk.EventsClient
+	var projectsClient sdk.ProjectsClient
 	{
 		address, token, opts, err := apiClientConfig()
 		if err != nil {
@@ -39,12 +41,8 @@ func main() {
 			log.Fatal(err)
 		}
 		systemClient = client.System()
-		workersClient = client.Core().Events().Workers()
-	}
-
-	kubeClient, err := kubernetes.Client()
-	if err != nil {
-		log.Fatal(err)
+		eventsClient = client.Core().Events()
+		projectsClient = client.Core().Projects()
 	}
 
 	// Observer
@@ -54,16 +52,57 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		observer = newObserver(
-			systemClient,
-			workersClient,
-			kubeClient,
-			config,
-		)
+		switch config.substrateType {
+		case substrateTypeLocal:
+			observer = newLocalObserver(
+				systemClient,
+				eventsClient,
+				projectsClient,
+				config,
+			)
+		default:
+			kubeClient, err := kubernetes.Client()
+			if err != nil {
+				log.Fatal(err)
+			}
+			observer = newObserver(
+				systemClient,
+				eventsClient,
+				projectsClient,
+				kubeClient,
+				config,
+			)
+		}
+	}
+
+	// Leader election
+	var leaderElectionConfig kubernetes.LeaderElectionConfig
+	var leaseClient k8s.Interface
+	{
+		var err error
+		if leaderElectionConfig, err =
+			kubernetes.GetLeaderElectionConfig(); err != nil {
+			log.Fatal(err)
+		}
+		if leaderElectionConfig.Enabled {
+			// The lease always lives in the cluster the observer runs in, even if the
+			// observer watches workloads in another cluster.
+			if leaseClient, err = kubernetes.InClusterClient(); err != nil {
+				log.Fatal(err)
+			}
+		}
 	}
 
-	// Run it!
-	log.Println(observer.run(ctx))
+	// Run it! If leader election is enabled, this only happens once this replica
+	// becomes the leader.
+	log.Println(
+		kubernetes.RunAsLeader(
+			ctx,
+			leaseClient,
+			leaderElectionConfig,
+			observer.run,
+		),
+	)
 }
 
 func testClient(ctx context.Context, client sdk.APIClient) error {
@@ -9,16 +9,31 @@ import (
 
 	"github.com/brigadecore/brigade-foundations/os"
 	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	substrateTypeKubernetes = "kubernetes"
+	substrateTypeLocal      = "local"
+)
+
 type observerConfig struct {
-	delayBeforeCleanup  time.Duration
-	healthcheckInterval time.Duration
-	maxWorkerLifetime   time.Duration
-	maxJobLifetime      time.Duration
-	brigadeID           string
+	delayBeforeCleanup        time.Duration
+	healthcheckInterval       time.Duration
+	maxWorkerLifetime         time.Duration
+	maxJobLifetime            time.Duration
+	workerHeartbeatInterval   time.Duration
+	maxMissedWorkerHeartbeats int
+	reconciliationInterval    time.Duration
+	brigadeID                 string
+	cluster                   string
+	batchJobsEnabled          bool
+	substrateType             string
+	localRootDirectory        string
+	localPollInterval         time.Duration
 }
 
 func getObserverConfig() (observerConfig, error) {
@@ -44,60 +59,136 @@ func getObserverConfig() (observerConfig, error) {
 		return config, err
 	}
 	log.Println("MAX_JOB_LIFETIME: ", config.maxJobLifetime)
+	if config.workerHeartbeatInterval, err = os.GetDurationFromEnvVar(
+		"WORKER_HEARTBEAT_INTERVAL",
+		30*time.Second,
+	); err != nil {
+		return config, err
+	}
+	log.Println("WORKER_HEARTBEAT_INTERVAL: ", config.workerHeartbeatInterval)
+	if config.maxMissedWorkerHeartbeats, err =
+		os.GetIntFromEnvVar("MAX_MISSED_WORKER_HEARTBEATS", 4); err != nil {
+		return config, err
+	}
+	log.Println(
+		"MAX_MISSED_WORKER_HEARTBEATS: ",
+		config.maxMissedWorkerHeartbeats,
+	)
+	if config.reconciliationInterval, err = os.GetDurationFromEnvVar(
+		"RECONCILIATION_INTERVAL",
+		5*time.Minute,
+	); err != nil {
+		return config, err
+	}
+	log.Println("RECONCILIATION_INTERVAL: ", config.reconciliationInterval)
+	// The name of the cluster this observer watches. This is empty for the
+	// default cluster.
+	config.cluster = os.GetEnvVar("CLUSTER", "")
+	log.Println("CLUSTER: ", config.cluster)
+	if config.batchJobsEnabled, err =
+		os.GetBoolFromEnvVar("BATCH_JOBS_ENABLED", false); err != nil {
+		return config, err
+	}
+	log.Println("BATCH_JOBS_ENABLED: ", config.batchJobsEnabled)
+	config.substrateType = os.GetEnvVar("SUBSTRATE", substrateTypeKubernetes)
+	log.Println("SUBSTRATE: ", config.substrateType)
+	switch config.substrateType {
+	case substrateTypeKubernetes:
+	case substrateTypeLocal:
+		if config.localRootDirectory, err =
+			os.GetRequiredEnvVar("LOCAL_SUBSTRATE_ROOT_DIRECTORY"); err != nil {
+			return config, err
+		}
+		log.Println("LOCAL_SUBSTRATE_ROOT_DIRECTORY: ", config.localRootDirectory)
+		config.localPollInterval = 2 * time.Second
+	default:
+		return config, errors.Errorf(
+			"unrecognized SUBSTRATE %q",
+			config.substrateType,
+		)
+	}
 	return config, nil
 }
 
 type observer struct {
-	kubeClient    kubernetes.Interface
-	systemClient  sdk.SystemClient
-	workersClient sdk.WorkersClient
-	jobsClient    sdk.JobsClient
-	config        observerConfig
-	timedPodsSet  map[string]context.CancelFunc
+	kubeClient      kubernetes.Interface
+	dockerCommandFn local.DockerCommandFn
+	systemClient    sdk.SystemClient
+	eventsClient    sdk.EventsClient
+	projectsClient  sdk.ProjectsClient
+	workersClient   sdk.WorkersClient
+	jobsClient      sdk.JobsClient
+	config          observerConfig
+	timedPodsSet    map[string]context.CancelFunc
+	// orphanSuspects tracks Workers and Jobs that the API reports as
+	// non-terminal, but for which no pod was found during the most recent
+	// reconciliation
+	orphanSuspects map[string]struct{}
 	// All of the scheduler's goroutines will send fatal errors here
 	errCh chan error
 	// All of these internal functions are overridable for testing purposes
-	runHealthcheckLoopFn  func(context.Context)
-	syncWorkerPodsFn      func(context.Context)
-	syncWorkerPodFn       func(obj interface{})
-	manageWorkerTimeoutFn func(context.Context, *corev1.Pod, sdk.WorkerPhase)
-	runWorkerTimerFn      func(context.Context, *corev1.Pod)
-	cleanupWorkerFn       func(eventID string)
-	syncJobPodsFn         func(context.Context)
-	syncJobPodFn          func(obj interface{})
-	manageJobTimeoutFn    func(context.Context, *corev1.Pod, sdk.JobPhase)
-	runJobTimerFn         func(context.Context, *corev1.Pod)
-	cleanupJobFn          func(eventID, jobName string)
-	errFn                 func(...interface{})
-	checkK8sAPIServer     func(context.Context) ([]byte, error)
+	runHealthcheckLoopFn     func(context.Context)
+	syncWorkerPodsFn         func(context.Context)
+	syncWorkerPodFn          func(obj interface{})
+	manageWorkerTimeoutFn    func(context.Context, *corev1.Pod, sdk.WorkerPhase)
+	runWorkerTimerFn         func(context.Context, *corev1.Pod)
+	checkWorkerHeartbeatFn   func(context.Context, string, sdk.WorkerStatus) bool
+	persistTimeoutDeadlineFn func(context.Context, *corev1.Pod, time.Time) error
+	cleanupWorkerFn          func(eventID string)
+	syncJobPodsFn            func(context.Context)
+	syncJobPodFn             func(obj interface{})
+	manageJobTimeoutFn       func(context.Context, *corev1.Pod, sdk.JobPhase)
+	runJobTimerFn            func(context.Context, *corev1.Pod)
+	cleanupJobFn             func(eventID, jobName string)
+	syncBatchJobsFn          func(context.Context)
+	syncBatchJobFn           func(obj interface{})
+	batchJobRetryPendingFn   func(context.Context, *corev1.Pod) bool
+	reconcileFn              func(context.Context)
+	getPodsFn                func(context.Context, string) ([]*corev1.Pod, error)
+	deleteOrphansFn          func(context.Context) error
+	errFn                    func(...interface{})
+	checkK8sAPIServer        func(context.Context) ([]byte, error)
 }
 
 func newObserver(
 	systemClient sdk.SystemClient,
-	workersClient sdk.WorkersClient,
+	eventsClient sdk.EventsClient,
+	projectsClient sdk.ProjectsClient,
 	kubeClient kubernetes.Interface,
 	config observerConfig,
 ) *observer {
+	workersClient := eventsClient.Workers()
 	o := &observer{
-		kubeClient:    kubeClient,
-		systemClient:  systemClient,
-		workersClient: workersClient,
-		jobsClient:    workersClient.Jobs(),
-		config:        config,
-		timedPodsSet:  map[string]context.CancelFunc{},
-		errCh:         make(chan error),
+		kubeClient:     kubeClient,
+		systemClient:   systemClient,
+		eventsClient:   eventsClient,
+		projectsClient: projectsClient,
+		workersClient:  workersClient,
+		jobsClient:     workersClient.Jobs(),
+		config:         config,
+		timedPodsSet:   map[string]context.CancelFunc{},
+		orphanSuspects: map[string]struct{}{},
+		errCh:          make(chan error),
 	}
 	o.runHealthcheckLoopFn = o.runHealthcheckLoop
 	o.syncWorkerPodsFn = o.syncWorkerPods
 	o.syncWorkerPodFn = o.syncWorkerPod
 	o.manageWorkerTimeoutFn = o.manageWorkerTimeout
 	o.runWorkerTimerFn = o.runWorkerTimer
+	o.checkWorkerHeartbeatFn = o.checkWorkerHeartbeat
+	o.persistTimeoutDeadlineFn = o.persistTimeoutDeadline
 	o.cleanupWorkerFn = o.cleanupWorker
 	o.syncJobPodsFn = o.syncJobPods
 	o.syncJobPodFn = o.syncJobPod
 	o.manageJobTimeoutFn = o.manageJobTimeout
 	o.runJobTimerFn = o.runJobTimer
 	o.cleanupJobFn = o.cleanupJob
+	o.syncBatchJobsFn = o.syncBatchJobs
+	o.syncBatchJobFn = o.syncBatchJob
+	o.batchJobRetryPendingFn = o.batchJobRetryPending
+	o.reconcileFn = o.reconcile
+	o.getPodsFn = o.getPods
+	o.deleteOrphansFn = o.deleteOrphans
 	o.errFn = log.Println
 
 	// TODO: remove this type assertion once we figure out how to fake/mock
@@ -139,6 +230,25 @@ func (o *observer) run(ctx context.Context) error {
 		o.syncJobPodsFn(ctx)
 	}()
 
+	// Continuously sync batch/v1 Jobs, if applicable
+	if o.config.batchJobsEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.syncBatchJobsFn(ctx)
+		}()
+	}
+
+	// Periodically reconcile Worker and Job statuses with the substrate, if
+	// applicable
+	if o.config.reconciliationInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.reconcileFn(ctx)
+		}()
+	}
+
 	// Wait for an error or a completed context
 	var err error
 	select {
@@ -68,13 +68,101 @@ func TestGetObserverConfig(t *testing.T) {
 			},
 		},
 		{
-			name: "success",
+			name: "WORKER_HEARTBEAT_INTERVAL not parsable as duration",
 			setup: func() {
 				t.Setenv("MAX_JOB_LIFETIME", "2m")
+				t.Setenv("WORKER_HEARTBEAT_INTERVAL", "foo")
+			},
+			assertions: func(config observerConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a duration")
+				require.Contains(t, err.Error(), "WORKER_HEARTBEAT_INTERVAL")
+			},
+		},
+		{
+			name: "MAX_MISSED_WORKER_HEARTBEATS not parsable as int",
+			setup: func() {
+				t.Setenv("WORKER_HEARTBEAT_INTERVAL", "10s")
+				t.Setenv("MAX_MISSED_WORKER_HEARTBEATS", "foo")
+			},
+			assertions: func(config observerConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as an int")
+				require.Contains(t, err.Error(), "MAX_MISSED_WORKER_HEARTBEATS")
+			},
+		},
+		{
+			name: "RECONCILIATION_INTERVAL not parsable as duration",
+			setup: func() {
+				t.Setenv("MAX_MISSED_WORKER_HEARTBEATS", "5")
+				t.Setenv("RECONCILIATION_INTERVAL", "foo")
+			},
+			assertions: func(config observerConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a duration")
+				require.Contains(t, err.Error(), "RECONCILIATION_INTERVAL")
+			},
+		},
+		{
+			name: "BATCH_JOBS_ENABLED not parsable as bool",
+			setup: func() {
+				t.Setenv("RECONCILIATION_INTERVAL", "10m")
+				t.Setenv("CLUSTER", "gpu")
+				t.Setenv("BATCH_JOBS_ENABLED", "foo")
+			},
+			assertions: func(config observerConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "was not parsable as a bool")
+				require.Contains(t, err.Error(), "BATCH_JOBS_ENABLED")
+			},
+		},
+		{
+			name: "success",
+			setup: func() {
+				t.Setenv("BATCH_JOBS_ENABLED", "true")
 			},
 			assertions: func(config observerConfig, err error) {
 				require.Equal(t, testBrigadeID, config.brigadeID)
 				require.Equal(t, 2*time.Minute, config.delayBeforeCleanup)
+				require.Equal(t, 10*time.Second, config.workerHeartbeatInterval)
+				require.Equal(t, 5, config.maxMissedWorkerHeartbeats)
+				require.Equal(t, 10*time.Minute, config.reconciliationInterval)
+				require.Equal(t, "gpu", config.cluster)
+				require.True(t, config.batchJobsEnabled)
+				require.Equal(t, substrateTypeKubernetes, config.substrateType)
+			},
+		},
+		{
+			name: "SUBSTRATE unrecognized",
+			setup: func() {
+				t.Setenv("SUBSTRATE", "bogus")
+			},
+			assertions: func(config observerConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "unrecognized SUBSTRATE")
+			},
+		},
+		{
+			name: "LOCAL_SUBSTRATE_ROOT_DIRECTORY not set",
+			setup: func() {
+				t.Setenv("SUBSTRATE", substrateTypeLocal)
+			},
+			assertions: func(config observerConfig, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "value not found for")
+				require.Contains(t, err.Error(), "LOCAL_SUBSTRATE_ROOT_DIRECTORY")
+			},
+		},
+		{
+			name: "success with local substrate",
+			setup: func() {
+				t.Setenv("LOCAL_SUBSTRATE_ROOT_DIRECTORY", "/var/lib/brigade")
+			},
+			assertions: func(config observerConfig, err error) {
+				require.NoError(t, err)
+				require.Equal(t, substrateTypeLocal, config.substrateType)
+				require.Equal(t, "/var/lib/brigade", config.localRootDirectory)
+				require.Equal(t, 2*time.Second, config.localPollInterval)
 			},
 		},
 	}
@@ -97,7 +185,12 @@ func TestNewObserver(t *testing.T) {
 		apiToken,
 		apiClientOpts,
 	)
-	workersClient := sdk.NewWorkersClient(
+	eventsClient := sdk.NewEventsClient(
+		apiAddress,
+		apiToken,
+		apiClientOpts,
+	)
+	projectsClient := sdk.NewProjectsClient(
 		apiAddress,
 		apiToken,
 		apiClientOpts,
@@ -106,16 +199,32 @@ func TestNewObserver(t *testing.T) {
 	config := observerConfig{
 		delayBeforeCleanup: time.Minute,
 	}
-	observer := newObserver(systemClient, workersClient, kubeClient, config)
+	observer := newObserver(
+		systemClient,
+		eventsClient,
+		projectsClient,
+		kubeClient,
+		config,
+	)
 	require.Same(t, kubeClient, observer.kubeClient)
 	require.NotNil(t, observer.systemClient)
+	require.NotNil(t, observer.eventsClient)
+	require.NotNil(t, observer.projectsClient)
 	require.NotNil(t, observer.workersClient)
 	require.NotNil(t, observer.jobsClient)
 	require.NotNil(t, observer.errCh)
 	require.NotNil(t, observer.syncWorkerPodsFn)
 	require.NotNil(t, observer.syncWorkerPodFn)
+	require.NotNil(t, observer.checkWorkerHeartbeatFn)
 	require.NotNil(t, observer.syncJobPodsFn)
 	require.NotNil(t, observer.syncJobPodFn)
+	require.NotNil(t, observer.syncBatchJobsFn)
+	require.NotNil(t, observer.syncBatchJobFn)
+	require.NotNil(t, observer.batchJobRetryPendingFn)
+	require.NotNil(t, observer.orphanSuspects)
+	require.NotNil(t, observer.reconcileFn)
+	require.NotNil(t, observer.getPodsFn)
+	require.NotNil(t, observer.deleteOrphansFn)
 }
 
 func TestObserverRun(t *testing.T) {
@@ -178,6 +287,28 @@ func TestObserverRun(t *testing.T) {
 				require.Contains(t, err.Error(), "something went wrong")
 			},
 		},
+		{
+			name: "reconciliation produced error",
+			setup: func() *observer {
+				errCh := make(chan error)
+				return &observer{
+					config: observerConfig{
+						reconciliationInterval: time.Minute,
+					},
+					runHealthcheckLoopFn: func(context.Context) {},
+					syncWorkerPodsFn:     func(context.Context) {},
+					syncJobPodsFn:        func(context.Context) {},
+					reconcileFn: func(context.Context) {
+						errCh <- errors.New("something went wrong")
+					},
+					errCh: errCh,
+				}
+			},
+			assertions: func(_ context.Context, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+			},
+		},
 		{
 			name: "context gets canceled",
 			setup: func() *observer {
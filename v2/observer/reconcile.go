@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/brigadecore/brigade/sdk/v3/meta"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// reconcilableWorkerPhases enumerates the phases in which a Worker is expected
+// to have a pod on the substrate.
+var reconcilableWorkerPhases = []sdk.WorkerPhase{
+	sdk.WorkerPhaseStarting,
+	sdk.WorkerPhaseRunning,
+	sdk.WorkerPhaseSuspended,
+	sdk.WorkerPhaseUnknown,
+}
+
+var deletePropagationBackground = metav1.DeletePropagationBackground
+
+// reconcile periodically compares the status of every non-terminal Worker and
+// Job, as recorded by the API, with the pods that actually exist on the
+// substrate and repairs any drift. Drift can occur when the observer misses a
+// pod event or when the API server is unavailable at the time a pod completes.
+// Substrate resources belonging to Events that no longer exist are also deleted
+// during each pass.
+func (o *observer) reconcile(ctx context.Context) {
+	ticker := time.NewTicker(o.config.reconciliationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := o.deleteOrphansFn(ctx); err != nil {
+				o.errFn(err)
+			}
+			if err := o.reconcileStatuses(ctx); err != nil {
+				o.errFn(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcileStatuses compares the status of every non-terminal Worker and Job
+// that runs on the cluster this observer watches with the corresponding pod. If
+// the pod's status differs from the one recorded by the API, the pod is synced
+// exactly as if a pod event had been received. If no pod exists across two
+// consecutive passes, the Worker or Job is aborted and cleaned up, since its
+// outcome can no longer be determined. Waiting for a second pass allows for
+// pods that have yet to be created for Workers and Jobs that are just starting.
+func (o *observer) reconcileStatuses(ctx context.Context) error {
+	workerPods, err := o.getPodsFn(ctx, myk8s.LabelKeyWorker)
+	if err != nil {
+		return errors.Wrap(err, "error listing worker pods")
+	}
+	jobPods, err := o.getPodsFn(ctx, myk8s.LabelKeyJob)
+	if err != nil {
+		return errors.Wrap(err, "error listing job pods")
+	}
+	pods := indexPods(workerPods)
+	for key, pod := range indexPods(jobPods) {
+		pods[key] = pod
+	}
+
+	suspects := map[string]struct{}{}
+	// Maps Project IDs to the names of the clusters the Projects are pinned to
+	projectClusters := map[string]string{}
+	selector := &sdk.EventsSelector{
+		WorkerPhases: reconcilableWorkerPhases,
+	}
+	listOpts := &meta.ListOptions{}
+	for {
+		listCtx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+		events, err := o.eventsClient.List(listCtx, selector, listOpts)
+		cancel()
+		if err != nil {
+			return errors.Wrap(err, "error listing events")
+		}
+		for _, event := range events.Items {
+			if event.Worker == nil {
+				continue
+			}
+			cluster, ok := projectClusters[event.ProjectID]
+			if !ok {
+				getCtx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+				project, err := o.projectsClient.Get(getCtx, event.ProjectID, nil)
+				cancel()
+				if err != nil {
+					o.errFn(
+						errors.Wrapf(
+							err,
+							"error retrieving project %q for event %q",
+							event.ProjectID,
+							event.ID,
+						),
+					)
+					continue
+				}
+				if project.Kubernetes != nil {
+					cluster = project.Kubernetes.Cluster
+				}
+				projectClusters[event.ProjectID] = cluster
+			}
+			// Jobs are reconciled first, since their statuses can no longer be
+			// updated once the Worker has reached a terminal phase.
+			for _, job := range event.Worker.Jobs {
+				if job.Status == nil || !isReconcilableJobPhase(job.Status.Phase) {
+					continue
+				}
+				jobCluster := cluster
+				if job.Spec.Host != nil && job.Spec.Host.Cluster != "" {
+					jobCluster = job.Spec.Host.Cluster
+				}
+				if jobCluster != o.config.cluster {
+					continue
+				}
+				o.reconcileJob(event.ID, job, pods, suspects)
+			}
+			if cluster == o.config.cluster {
+				o.reconcileWorker(event, pods, suspects)
+			}
+		}
+		if listOpts.Continue = events.Continue; listOpts.Continue == "" {
+			break
+		}
+	}
+	o.orphanSuspects = suspects
+	return nil
+}
+
+// reconcileWorker reconciles the status of the provided Event's Worker with
+// that of its pod, if one is found among the provided pods. If the pod is not
+// found, the Worker is marked as suspect by adding it to the provided set of
+// suspects, or, if it was already suspect as of the previous reconciliation,
+// it is aborted.
+func (o *observer) reconcileWorker(
+	event sdk.Event,
+	pods map[string]*corev1.Pod,
+	suspects map[string]struct{},
+) {
+	key := reconciliationKey(event.ID, "")
+	if pod, ok := pods[key]; ok {
+		phase := o.getWorkerStatusFromPod(pod).Phase
+		// A suspended Worker's pod continues to run
+		if phase != event.Worker.Status.Phase &&
+			(event.Worker.Status.Phase != sdk.WorkerPhaseSuspended ||
+				phase != sdk.WorkerPhaseRunning) {
+			o.syncWorkerPodFn(pod)
+		}
+		return
+	}
+	if _, suspect := o.orphanSuspects[key]; !suspect {
+		suspects[key] = struct{}{}
+		return
+	}
+	now := time.Now().UTC()
+	status := event.Worker.Status
+	status.Phase = sdk.WorkerPhaseAborted
+	status.Ended = &now
+	status.Reason = "The worker's pod no longer exists, so the worker's " +
+		"outcome could not be determined."
+	ctx, cancel := context.WithTimeout(context.Background(), apiRequestTimeout)
+	defer cancel()
+	if err :=
+		o.workersClient.UpdateStatus(ctx, event.ID, status, nil); err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error aborting orphaned worker for event %q",
+				event.ID,
+			),
+		)
+		return
+	}
+	o.cleanupWorkerFn(event.ID)
+}
+
+// reconcileJob reconciles the status of the provided Job with that of its pod,
+// if one is found among the provided pods. If the pod is not found, the Job is
+// marked as suspect by adding it to the provided set of suspects, or, if it was
+// already suspect as of the previous reconciliation, it is aborted.
+func (o *observer) reconcileJob(
+	eventID string,
+	job sdk.Job,
+	pods map[string]*corev1.Pod,
+	suspects map[string]struct{},
+) {
+	key := reconciliationKey(eventID, job.Name)
+	if pod, ok := pods[key]; ok {
+		if o.getJobStatusFromPod(pod).Phase != job.Status.Phase {
+			o.syncJobPodFn(pod)
+		}
+		return
+	}
+	if _, suspect := o.orphanSuspects[key]; !suspect {
+		suspects[key] = struct{}{}
+		return
+	}
+	now := time.Now().UTC()
+	status := sdk.JobStatus{
+		Started: job.Status.Started,
+		Ended:   &now,
+		Phase:   sdk.JobPhaseAborted,
+		Reason: "The job's pod no longer exists, so the job's outcome could " +
+			"not be determined.",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiRequestTimeout)
+	defer cancel()
+	if err := o.jobsClient.UpdateStatus(
+		ctx,
+		eventID,
+		job.Name,
+		status,
+		nil,
+	); err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error aborting orphaned job %q for event %q",
+				job.Name,
+				eventID,
+			),
+		)
+		return
+	}
+	o.cleanupJobFn(eventID, job.Name)
+}
+
+// isReconcilableJobPhase returns a boolean indicating whether a Job in the
+// specified phase is expected to have a pod on the substrate.
+func isReconcilableJobPhase(phase sdk.JobPhase) bool {
+	switch phase {
+	case sdk.JobPhaseStarting, sdk.JobPhaseRunning, sdk.JobPhaseUnknown:
+		return true
+	}
+	return false
+}
+
+// indexPods indexes the provided Worker or Job pods by the Worker or Job they
+// belong to. If more than one pod belongs to the same Worker or Job, as can be
+// the case when pods are managed by a batch/v1 Job, the most recently created
+// pod is preferred.
+func indexPods(pods []*corev1.Pod) map[string]*corev1.Pod {
+	index := make(map[string]*corev1.Pod, len(pods))
+	for _, pod := range pods {
+		key := reconciliationKey(
+			pod.Labels[myk8s.LabelEvent],
+			pod.Labels[myk8s.LabelJob],
+		)
+		if existing, ok := index[key]; ok &&
+			!existing.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		index[key] = pod
+	}
+	return index
+}
+
+// reconciliationKey returns a key that uniquely identifies the specified
+// Event's Worker or, if a Job name is specified, the specified Job.
+func reconciliationKey(eventID, jobName string) string {
+	if jobName == "" {
+		return eventID
+	}
+	return fmt.Sprintf("%s:%s", eventID, jobName)
+}
+
+// getPods returns all pods belonging to the specified component (Worker or
+// Job).
+func (o *observer) getPods(
+	ctx context.Context,
+	component string,
+) ([]*corev1.Pod, error) {
+	listOpts := metav1.ListOptions{
+		LabelSelector: labels.Set(
+			map[string]string{
+				myk8s.LabelBrigadeID: o.config.brigadeID,
+				myk8s.LabelComponent: component,
+			},
+		).AsSelector().String(),
+	}
+	pods := []*corev1.Pod{}
+	for {
+		podList, err := o.kubeClient.CoreV1().Pods("").List(ctx, listOpts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing %s pods", component)
+		}
+		for i := range podList.Items {
+			pods = append(pods, &podList.Items[i])
+		}
+		if listOpts.Continue = podList.Continue; listOpts.Continue == "" {
+			return pods, nil
+		}
+	}
+}
+
+// deleteOrphans deletes all pods, persistent volume claims, secrets, and, if
+// applicable, batch/v1 Jobs belonging to Events that no longer exist. Such
+// resources are normally deleted along with the Event, but can be left behind
+// if the substrate was unavailable at the time.
+func (o *observer) deleteOrphans(ctx context.Context) error {
+	// Maps Event IDs to the namespaces containing their resources
+	eventNamespaces := map[string]map[string]struct{}{}
+	addObject := func(objMeta metav1.ObjectMeta) {
+		eventID, ok := objMeta.Labels[myk8s.LabelEvent]
+		if !ok {
+			return
+		}
+		if _, ok = eventNamespaces[eventID]; !ok {
+			eventNamespaces[eventID] = map[string]struct{}{}
+		}
+		eventNamespaces[eventID][objMeta.Namespace] = struct{}{}
+	}
+
+	listOpts := metav1.ListOptions{
+		LabelSelector: labels.Set(
+			map[string]string{
+				myk8s.LabelBrigadeID: o.config.brigadeID,
+			},
+		).AsSelector().String(),
+	}
+	for {
+		pods, err := o.kubeClient.CoreV1().Pods("").List(ctx, listOpts)
+		if err != nil {
+			return errors.Wrap(err, "error listing pods")
+		}
+		for _, pod := range pods.Items {
+			addObject(pod.ObjectMeta)
+		}
+		if listOpts.Continue = pods.Continue; listOpts.Continue == "" {
+			break
+		}
+	}
+	for {
+		pvcs, err :=
+			o.kubeClient.CoreV1().PersistentVolumeClaims("").List(ctx, listOpts)
+		if err != nil {
+			return errors.Wrap(err, "error listing persistent volume claims")
+		}
+		for _, pvc := range pvcs.Items {
+			addObject(pvc.ObjectMeta)
+		}
+		if listOpts.Continue = pvcs.Continue; listOpts.Continue == "" {
+			break
+		}
+	}
+	if o.config.batchJobsEnabled {
+		for {
+			jobs, err := o.kubeClient.BatchV1().Jobs("").List(ctx, listOpts)
+			if err != nil {
+				return errors.Wrap(err, "error listing batch jobs")
+			}
+			for _, job := range jobs.Items {
+				addObject(job.ObjectMeta)
+			}
+			if listOpts.Continue = jobs.Continue; listOpts.Continue == "" {
+				break
+			}
+		}
+	}
+
+	eventIDs := make([]string, 0, len(eventNamespaces))
+	for eventID := range eventNamespaces {
+		eventIDs = append(eventIDs, eventID)
+	}
+	for _, eventID := range o.getOrphanedEventIDs(ctx, eventIDs) {
+		for namespace := range eventNamespaces[eventID] {
+			if err := o.deleteEventResources(ctx, namespace, eventID); err != nil {
+				o.errFn(err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteEventResources deletes all pods, persistent volume claims, secrets,
+// and, if applicable, batch/v1 Jobs belonging to the specified Event from the
+// specified namespace.
+func (o *observer) deleteEventResources(
+	ctx context.Context,
+	namespace string,
+	eventID string,
+) error {
+	listOpts := metav1.ListOptions{
+		LabelSelector: labels.Set(
+			map[string]string{
+				myk8s.LabelBrigadeID: o.config.brigadeID,
+				myk8s.LabelEvent:     eventID,
+			},
+		).AsSelector().String(),
+	}
+	// batch/v1 Jobs must be deleted before pods so that Kubernetes does not
+	// replace them.
+	if o.config.batchJobsEnabled {
+		if err := o.kubeClient.BatchV1().Jobs(namespace).DeleteCollection(
+			ctx,
+			metav1.DeleteOptions{
+				PropagationPolicy: &deletePropagationBackground,
+			},
+			listOpts,
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error deleting orphaned event %q batch jobs in namespace %q",
+				eventID,
+				namespace,
+			)
+		}
+	}
+	if err := o.kubeClient.CoreV1().Pods(namespace).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting orphaned event %q pods in namespace %q",
+			eventID,
+			namespace,
+		)
+	}
+	if err := o.kubeClient.CoreV1().PersistentVolumeClaims(
+		namespace,
+	).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting orphaned event %q persistent volume claims in "+
+				"namespace %q",
+			eventID,
+			namespace,
+		)
+	}
+	if err := o.kubeClient.CoreV1().Secrets(namespace).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{},
+		listOpts,
+	); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting orphaned event %q secrets in namespace %q",
+			eventID,
+			namespace,
+		)
+	}
+	return nil
+}
+
+// getOrphanedEventIDs returns, in lexical order, those of the provided Event
+// IDs that the API reports no longer exist. Errors other than the Event not
+// being found are logged and the corresponding Event is presumed to still
+// exist.
+func (o *observer) getOrphanedEventIDs(
+	ctx context.Context,
+	eventIDs []string,
+) []string {
+	sort.Strings(eventIDs)
+	orphanedEventIDs := []string{}
+	for _, eventID := range eventIDs {
+		getCtx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+		_, err := o.eventsClient.Get(getCtx, eventID, nil)
+		cancel()
+		if err == nil {
+			continue
+		}
+		if _, notFound := err.(*meta.ErrNotFound); !notFound {
+			o.errFn(
+				errors.Wrapf(err, "error retrieving event %q", eventID),
+			)
+			continue
+		}
+		orphanedEventIDs = append(orphanedEventIDs, eventID)
+	}
+	return orphanedEventIDs
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/brigadecore/brigade/sdk/v3/meta"
+	coreTesting "github.com/brigadecore/brigade/sdk/v3/testing"
+	myk8s "github.com/brigadecore/brigade/v2/internal/kubernetes"
+	"github.com/brigadecore/brigade/v2/internal/local"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientTesting "k8s.io/client-go/testing"
+)
+
+func TestReconcileStatuses(t *testing.T) {
+	testEvents := []sdk.Event{
+		{
+			// This Worker's pod has succeeded, but the API didn't find out
+			ObjectMeta: meta.ObjectMeta{ID: "drifted"},
+			ProjectID:  "italian",
+			Worker: &sdk.Worker{
+				Status: sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning},
+				Jobs: []sdk.Job{
+					{
+						// This Job's pod is running, but the API didn't find out
+						Name:   "foo",
+						Status: &sdk.JobStatus{Phase: sdk.JobPhaseStarting},
+					},
+					{
+						// This Job runs on another cluster and should be ignored
+						Name: "bar",
+						Spec: sdk.JobSpec{
+							Host: &sdk.JobHost{Cluster: "gpu"},
+						},
+						Status: &sdk.JobStatus{Phase: sdk.JobPhaseRunning},
+					},
+				},
+			},
+		},
+		{
+			// This Worker's pod is still running, which is as expected
+			ObjectMeta: meta.ObjectMeta{ID: "suspended"},
+			ProjectID:  "italian",
+			Worker: &sdk.Worker{
+				Status: sdk.WorkerStatus{Phase: sdk.WorkerPhaseSuspended},
+			},
+		},
+		{
+			// This Worker has no pod
+			ObjectMeta: meta.ObjectMeta{ID: "orphaned"},
+			ProjectID:  "italian",
+			Worker: &sdk.Worker{
+				Status: sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning},
+				Jobs: []sdk.Job{
+					{
+						// This Job has no pod
+						Name:   "foo",
+						Status: &sdk.JobStatus{Phase: sdk.JobPhaseRunning},
+					},
+				},
+			},
+		},
+		{
+			// This Worker runs on another cluster and should be ignored
+			ObjectMeta: meta.ObjectMeta{ID: "elsewhere"},
+			ProjectID:  "french",
+			Worker: &sdk.Worker{
+				Status: sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning},
+			},
+		},
+	}
+	getPod := func(
+		eventID string,
+		jobName string,
+		phase corev1.PodPhase,
+	) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: myk8s.WorkerPodName(eventID),
+				Labels: map[string]string{
+					myk8s.LabelEvent: eventID,
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{}},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+			},
+		}
+		if jobName != "" {
+			pod.Name = myk8s.JobPodName(eventID, jobName)
+			pod.Labels[myk8s.LabelJob] = jobName
+		}
+		return pod
+	}
+
+	syncedWorkerPods := []string{}
+	syncedJobPods := []string{}
+	updatedWorkers := map[string]sdk.WorkerStatus{}
+	updatedJobs := map[string]sdk.JobStatus{}
+	cleanedUpWorkers := []string{}
+	cleanedUpJobs := []string{}
+	o := &observer{
+		config: observerConfig{
+			cluster: "",
+		},
+		eventsClient: &coreTesting.MockEventsClient{
+			ListFn: func(
+				_ context.Context,
+				selector *sdk.EventsSelector,
+				opts *meta.ListOptions,
+			) (sdk.EventList, error) {
+				require.Equal(t, reconcilableWorkerPhases, selector.WorkerPhases)
+				// Return the Events one page at a time
+				list := sdk.EventList{}
+				switch opts.Continue {
+				case "":
+					list.Items = testEvents[:2]
+					list.Continue = "next"
+				case "next":
+					list.Items = testEvents[2:]
+				}
+				return list, nil
+			},
+		},
+		projectsClient: &coreTesting.MockProjectsClient{
+			GetFn: func(
+				_ context.Context,
+				id string,
+				_ *sdk.ProjectGetOptions,
+			) (sdk.Project, error) {
+				project := sdk.Project{
+					ObjectMeta: meta.ObjectMeta{ID: id},
+				}
+				if id == "french" {
+					project.Kubernetes = &sdk.KubernetesDetails{Cluster: "gpu"}
+				}
+				return project, nil
+			},
+		},
+		workersClient: &coreTesting.MockWorkersClient{
+			UpdateStatusFn: func(
+				_ context.Context,
+				eventID string,
+				status sdk.WorkerStatus,
+				_ *sdk.WorkerStatusUpdateOptions,
+			) error {
+				updatedWorkers[eventID] = status
+				return nil
+			},
+		},
+		jobsClient: &coreTesting.MockJobsClient{
+			UpdateStatusFn: func(
+				_ context.Context,
+				eventID string,
+				jobName string,
+				status sdk.JobStatus,
+				_ *sdk.JobStatusUpdateOptions,
+			) error {
+				updatedJobs[reconciliationKey(eventID, jobName)] = status
+				return nil
+			},
+		},
+		orphanSuspects: map[string]struct{}{},
+		getPodsFn: func(
+			_ context.Context,
+			component string,
+		) ([]*corev1.Pod, error) {
+			if component == myk8s.LabelKeyWorker {
+				return []*corev1.Pod{
+					getPod("drifted", "", corev1.PodSucceeded),
+					getPod("suspended", "", corev1.PodRunning),
+				}, nil
+			}
+			return []*corev1.Pod{
+				getPod("drifted", "foo", corev1.PodRunning),
+			}, nil
+		},
+		syncWorkerPodFn: func(obj interface{}) {
+			pod := obj.(*corev1.Pod) // nolint: forcetypeassert
+			syncedWorkerPods = append(syncedWorkerPods, pod.Name)
+		},
+		syncJobPodFn: func(obj interface{}) {
+			pod := obj.(*corev1.Pod) // nolint: forcetypeassert
+			syncedJobPods = append(syncedJobPods, pod.Name)
+		},
+		cleanupWorkerFn: func(eventID string) {
+			cleanedUpWorkers = append(cleanedUpWorkers, eventID)
+		},
+		cleanupJobFn: func(eventID, jobName string) {
+			cleanedUpJobs =
+				append(cleanedUpJobs, reconciliationKey(eventID, jobName))
+		},
+		errFn: func(i ...interface{}) {
+			require.Fail(t, "error func should not have been called", i...)
+		},
+	}
+
+	// On the first pass, drifted statuses should be synced, but Workers and Jobs
+	// without pods should only be suspected of being orphaned
+	err := o.reconcileStatuses(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"drifted"}, syncedWorkerPods)
+	require.Equal(t, []string{"drifted-foo"}, syncedJobPods)
+	require.Empty(t, updatedWorkers)
+	require.Empty(t, updatedJobs)
+	require.Equal(
+		t,
+		map[string]struct{}{
+			"orphaned":     {},
+			"orphaned:foo": {},
+		},
+		o.orphanSuspects,
+	)
+
+	// On the second pass, Workers and Jobs still without pods should be aborted
+	// and cleaned up
+	err = o.reconcileStatuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, updatedWorkers, 1)
+	require.Equal(t, sdk.WorkerPhaseAborted, updatedWorkers["orphaned"].Phase)
+	require.NotNil(t, updatedWorkers["orphaned"].Ended)
+	require.Contains(t, updatedWorkers["orphaned"].Reason, "no longer exists")
+	require.Len(t, updatedJobs, 1)
+	require.Equal(t, sdk.JobPhaseAborted, updatedJobs["orphaned:foo"].Phase)
+	require.NotNil(t, updatedJobs["orphaned:foo"].Ended)
+	require.Contains(t, updatedJobs["orphaned:foo"].Reason, "no longer exists")
+	require.Equal(t, []string{"orphaned"}, cleanedUpWorkers)
+	require.Equal(t, []string{"orphaned:foo"}, cleanedUpJobs)
+}
+
+func TestIndexPods(t *testing.T) {
+	older := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "older",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Minute)),
+			Labels: map[string]string{
+				myk8s.LabelEvent: "tunguska",
+			},
+		},
+	}
+	newer := older.DeepCopy()
+	newer.Name = "newer"
+	newer.CreationTimestamp = metav1.Now()
+	job := older.DeepCopy()
+	job.Labels[myk8s.LabelJob] = "foo"
+	index := indexPods([]*corev1.Pod{newer, older, job})
+	require.Len(t, index, 2)
+	require.Same(t, newer, index["tunguska"])
+	require.Same(t, job, index["tunguska:foo"])
+}
+
+func TestDeleteOrphans(t *testing.T) {
+	getObjectMeta := func(namespace, eventID string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      eventID,
+			Namespace: namespace,
+			Labels: map[string]string{
+				myk8s.LabelBrigadeID: "4077th",
+				myk8s.LabelEvent:     eventID,
+			},
+		}
+	}
+	kubeClient := fake.NewSimpleClientset(
+		[]runtime.Object{
+			&corev1.Pod{ObjectMeta: getObjectMeta("italian", "tunguska")},
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: getObjectMeta("italian", "tunguska"),
+			},
+			&corev1.Pod{ObjectMeta: getObjectMeta("french", "orphaned")},
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: getObjectMeta("french", "orphaned"),
+			},
+			&corev1.Pod{ObjectMeta: getObjectMeta("german", "unknowable")},
+		}...,
+	)
+	o := &observer{
+		kubeClient: kubeClient,
+		config: observerConfig{
+			brigadeID: "4077th",
+		},
+		eventsClient: &coreTesting.MockEventsClient{
+			GetFn: func(
+				_ context.Context,
+				id string,
+				_ *sdk.EventGetOptions,
+			) (sdk.Event, error) {
+				switch id {
+				case "orphaned":
+					return sdk.Event{}, &meta.ErrNotFound{}
+				case "unknowable":
+					return sdk.Event{}, errors.New("something went wrong")
+				}
+				return sdk.Event{}, nil
+			},
+		},
+	}
+	var loggedErrs []interface{}
+	o.errFn = func(i ...interface{}) {
+		loggedErrs = append(loggedErrs, i...)
+	}
+	err := o.deleteOrphans(context.Background())
+	require.NoError(t, err)
+	// Only the error retrieving the Event of unknown status should have been
+	// logged
+	require.Len(t, loggedErrs, 1)
+	// Check that only the orphaned Event's resources were deleted
+	deletedCollections := map[string]string{}
+	for _, action := range kubeClient.Actions() {
+		if deleteAction, ok :=
+			action.(clientTesting.DeleteCollectionAction); ok {
+			deletedCollections[deleteAction.GetResource().Resource] =
+				deleteAction.GetNamespace() + ":" +
+					deleteAction.GetListRestrictions().Labels.String()
+		}
+	}
+	expected := "french:" + labels.Set(
+		map[string]string{
+			myk8s.LabelBrigadeID: "4077th",
+			myk8s.LabelEvent:     "orphaned",
+		},
+	).AsSelector().String()
+	require.Equal(
+		t,
+		map[string]string{
+			"pods":                   expected,
+			"persistentvolumeclaims": expected,
+			"secrets":                expected,
+		},
+		deletedCollections,
+	)
+}
+
+func TestDeleteLocalOrphans(t *testing.T) {
+	rootDir := t.TempDir()
+	orphanedDir := local.EventDirectory(rootDir, "orphaned")
+	require.NoError(t, os.MkdirAll(orphanedDir, 0700))
+	existingDir := local.EventDirectory(rootDir, "tunguska")
+	require.NoError(t, os.MkdirAll(existingDir, 0700))
+
+	getContainer := func(id string, eventID string) local.Container {
+		container := local.Container{ID: id, Name: id}
+		container.Config.Labels = map[string]string{
+			myk8s.LabelBrigadeID: "4077th",
+			myk8s.LabelEvent:     eventID,
+		}
+		return container
+	}
+	containers := []local.Container{
+		getContainer("orphaned-worker", "orphaned"),
+		getContainer("orphaned-job", "orphaned"),
+		getContainer("tunguska-worker", "tunguska"),
+	}
+	var removeArgs []string
+	o := &observer{
+		config: observerConfig{
+			brigadeID:          "4077th",
+			localRootDirectory: rootDir,
+		},
+		dockerCommandFn: func(_ context.Context, args ...string) *exec.Cmd {
+			var output []byte
+			switch args[0] {
+			case "ps":
+				for _, container := range containers {
+					output = append(output, []byte(container.ID+"\n")...)
+				}
+			case "inspect":
+				var err error
+				output, err = json.Marshal(containers)
+				require.NoError(t, err)
+			case "rm":
+				removeArgs = args
+			}
+			return exec.Command("sh", "-c", `printf '%s' "$0"`, string(output))
+		},
+		eventsClient: &coreTesting.MockEventsClient{
+			GetFn: func(
+				_ context.Context,
+				id string,
+				_ *sdk.EventGetOptions,
+			) (sdk.Event, error) {
+				if id == "orphaned" {
+					return sdk.Event{}, &meta.ErrNotFound{}
+				}
+				return sdk.Event{}, nil
+			},
+		},
+		errFn: func(i ...interface{}) {
+			require.Fail(t, "error func should not have been called", i...)
+		},
+	}
+	err := o.deleteLocalOrphans(context.Background())
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{"rm", "--force", "--volumes", "orphaned-job", "orphaned-worker"},
+		removeArgs,
+	)
+	_, err = os.Stat(orphanedDir)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(existingDir)
+	require.NoError(t, err)
+}
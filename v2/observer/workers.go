@@ -16,6 +16,10 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
+// workerStatusWatchRetryInterval is how long to wait before re-establishing a
+// failed watch of a Worker's status.
+const workerStatusWatchRetryInterval = 10 * time.Second
+
 func (o *observer) syncWorkerPods(ctx context.Context) {
 	workersSelector := myk8s.WorkerPodsSelector(o.config.brigadeID)
 	workerPodsInformer := cache.NewSharedIndexInformer(
@@ -49,6 +53,11 @@ func (o *observer) syncWorkerPod(obj interface{}) {
 	pod := obj.(*corev1.Pod) // nolint: forcetypeassert
 	// Map pod status to worker status
 	status := o.getWorkerStatusFromPod(pod)
+	// If the pod failed, but Kubernetes may yet replace it, don't report it
+	if status.Phase == sdk.WorkerPhaseFailed && pod.DeletionTimestamp == nil &&
+		o.config.batchJobsEnabled && o.batchJobRetryPendingFn(ctx, pod) {
+		return
+	}
 	// Manage the timeout clock
 	o.manageWorkerTimeoutFn(ctx, pod, status.Phase)
 	// Use the API to update Worker status
@@ -156,52 +165,253 @@ func (o *observer) manageWorkerTimeout(
 	pod *corev1.Pod,
 	phase sdk.WorkerPhase,
 ) {
-	namespacedPodName := namespacedPodName(pod.Namespace, pod.Name)
-	cancelFn, timed := o.timedPodsSet[namespacedPodName]
+	podTimerKey := podTimerKey(pod)
+	cancelFn, timed := o.timedPodsSet[podTimerKey]
 	if phase.IsTerminal() && timed {
 		cancelFn() // Stop the clock
 		return
 	}
 	if !phase.IsTerminal() && !timed {
 		// Start the clock
-		ctx, o.timedPodsSet[namespacedPodName] = context.WithCancel(ctx)
+		ctx, o.timedPodsSet[podTimerKey] = context.WithCancel(ctx)
 		go o.runWorkerTimerFn(ctx, pod)
 	}
 }
 
 func (o *observer) runWorkerTimer(ctx context.Context, pod *corev1.Pod) {
-	namespacedPodName := namespacedPodName(pod.Namespace, pod.Name)
-	defer delete(o.timedPodsSet, namespacedPodName)
-	timer := time.NewTimer(
-		o.getPodTimeoutDuration(pod, o.config.maxWorkerLifetime),
-	)
+	defer delete(o.timedPodsSet, podTimerKey(pod))
+	eventID := pod.Labels[myk8s.LabelEvent]
+	deadline := o.getPodTimeoutDeadline(ctx, pod, o.config.maxWorkerLifetime)
+	timer := time.NewTimer(time.Until(deadline))
 	defer timer.Stop()
-	select {
-	case <-timer.C:
-		eventID := pod.Labels[myk8s.LabelEvent]
-		// Create a new context for the timeout op. If we don't do this, the
-		// possibility exists that the call to o.workersClient.Timeout() succeeds in
-		// timing out the worker, but the worker is observed in its terminal,
-		// timed-out state, resulting in cancelation of the current context before
-		// the call to o.workersClient.Timeout() RETURNS, in which case we can end
-		// up with an error telling us the context timed out during the
-		// o.workersClient.Timeout(), when in fact, it has succeeded.
-		timeoutCtx, cancel :=
-			context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		if err := o.workersClient.Timeout(timeoutCtx, eventID, nil); err != nil {
+	// The timeout clock is stopped while the worker is suspended. pausedAt
+	// records when that happened.
+	var pausedAt *time.Time
+	// trackSuspension stops the timeout clock if the worker has become suspended
+	// and restarts it, with the deadline pushed back by however long the worker
+	// was suspended, if the worker has been resumed. The new deadline is
+	// persisted so that it survives a change of leader. It returns a bool
+	// indicating whether the worker is suspended.
+	trackSuspension := func(status sdk.WorkerStatus) bool {
+		now := time.Now()
+		suspended := status.Phase == sdk.WorkerPhaseSuspended
+		if suspended && pausedAt == nil {
+			pausedAt = &now
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		} else if !suspended && pausedAt != nil {
+			deadline = deadline.Add(now.Sub(*pausedAt))
+			pausedAt = nil
+			if err := o.persistTimeoutDeadlineFn(ctx, pod, deadline); err != nil {
+				o.errFn(err)
+			}
+			timer.Reset(time.Until(deadline))
+		}
+		return suspended
+	}
+	// When hung worker detection is enabled, the worker's status is polled, since
+	// a worker that has stopped sending heartbeats produces no status changes to
+	// watch for. Otherwise, the worker's status is only watched so that the
+	// timeout clock can be stopped while the worker is suspended.
+	var tickCh <-chan time.Time
+	var statusCh <-chan sdk.WorkerStatus
+	if o.config.maxMissedWorkerHeartbeats > 0 {
+		ticker := time.NewTicker(o.config.workerHeartbeatInterval)
+		defer ticker.Stop()
+		tickCh = ticker.C
+	} else {
+		statusCh = o.watchWorkerStatus(ctx, eventID)
+	}
+	for {
+		select {
+		case <-timer.C:
+			// Don't time out a worker that has been suspended
+			if status, ok := o.getWorkerStatus(ctx, eventID); ok &&
+				trackSuspension(status) {
+				continue
+			}
+			o.timeoutWorker(eventID)
+			return
+		case <-tickCh:
+			status, ok := o.getWorkerStatus(ctx, eventID)
+			if !ok || trackSuspension(status) {
+				continue
+			}
+			if o.checkWorkerHeartbeatFn(ctx, eventID, status) {
+				return
+			}
+		case status := <-statusCh:
+			trackSuspension(status)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watchWorkerStatus returns a channel over which changes to the status of the
+// specified Event's Worker are delivered until the provided context is
+// canceled. If the underlying watch fails, the error is reported and the watch
+// is re-established.
+func (o *observer) watchWorkerStatus(
+	ctx context.Context,
+	eventID string,
+) <-chan sdk.WorkerStatus {
+	statusCh := make(chan sdk.WorkerStatus)
+	go func() {
+		for {
+			o.forwardWorkerStatus(ctx, eventID, statusCh)
+			select {
+			case <-time.After(workerStatusWatchRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return statusCh
+}
+
+// forwardWorkerStatus watches the status of the specified Event's Worker and
+// forwards each status it receives to the provided channel. It returns when
+// the watch fails or the provided context is canceled.
+func (o *observer) forwardWorkerStatus(
+	ctx context.Context,
+	eventID string,
+	statusCh chan<- sdk.WorkerStatus,
+) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watchStatusCh, watchErrCh, err :=
+		o.workersClient.WatchStatus(watchCtx, eventID, nil)
+	if err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error watching status of worker for event %q",
+				eventID,
+			),
+		)
+		return
+	}
+	for {
+		select {
+		case status := <-watchStatusCh:
+			select {
+			case statusCh <- status:
+			case <-ctx.Done():
+				return
+			}
+		case err := <-watchErrCh:
 			o.errFn(
 				errors.Wrapf(
 					err,
-					"error timing out worker for event %q",
+					"error watching status of worker for event %q",
 					eventID,
 				),
 			)
+			return
+		case <-ctx.Done():
+			return
 		}
-	case <-ctx.Done():
 	}
 }
 
+// getWorkerStatus retrieves the status of the specified Event's Worker. It
+// returns a bool indicating whether the status was successfully retrieved.
+func (o *observer) getWorkerStatus(
+	ctx context.Context,
+	eventID string,
+) (sdk.WorkerStatus, bool) {
+	getCtx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+	defer cancel()
+	status, err := o.workersClient.GetStatus(getCtx, eventID, nil)
+	if err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error retrieving status of worker for event %q",
+				eventID,
+			),
+		)
+		return sdk.WorkerStatus{}, false
+	}
+	return status, true
+}
+
+// timeoutWorker uses the API to time out the specified Event's Worker.
+func (o *observer) timeoutWorker(eventID string) {
+	// Create a new context for the timeout op. If we don't do this, the
+	// possibility exists that the call to o.workersClient.Timeout() succeeds in
+	// timing out the worker, but the worker is observed in its terminal,
+	// timed-out state, resulting in cancelation of the current context before
+	// the call to o.workersClient.Timeout() RETURNS, in which case we can end
+	// up with an error telling us the context timed out during the
+	// o.workersClient.Timeout(), when in fact, it has succeeded.
+	timeoutCtx, cancel :=
+		context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := o.workersClient.Timeout(timeoutCtx, eventID, nil); err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error timing out worker for event %q",
+				eventID,
+			),
+		)
+	}
+}
+
+// checkWorkerHeartbeat examines the provided status of the specified Event's
+// Worker and, if the Worker has missed too many consecutive heartbeats, marks
+// it as failed and cleans up after it. It returns a bool indicating whether
+// the Worker was found to be hung. Workers that have never sent a heartbeat
+// are assumed not to support them and are never considered hung.
+func (o *observer) checkWorkerHeartbeat(
+	ctx context.Context,
+	eventID string,
+	status sdk.WorkerStatus,
+) bool {
+	if status.Phase.IsTerminal() || status.LastHeartbeat == nil {
+		return false
+	}
+	maxSilence := time.Duration(o.config.maxMissedWorkerHeartbeats) *
+		o.config.workerHeartbeatInterval
+	if time.Since(*status.LastHeartbeat) <= maxSilence {
+		return false
+	}
+	now := time.Now().UTC()
+	status.Phase = sdk.WorkerPhaseFailed
+	status.Ended = &now
+	status.Reason = fmt.Sprintf(
+		"Worker missed %d consecutive heartbeats. Its last heartbeat was "+
+			"received at %s.",
+		o.config.maxMissedWorkerHeartbeats,
+		status.LastHeartbeat.Format(time.RFC3339),
+	)
+	// As when timing out a worker, use a new context for the update. Once the
+	// worker is observed in its terminal phase, the current context will be
+	// canceled.
+	updateCtx, cancelUpdate :=
+		context.WithTimeout(context.Background(), apiRequestTimeout)
+	defer cancelUpdate()
+	if err :=
+		o.workersClient.UpdateStatus(updateCtx, eventID, status, nil); err != nil {
+		o.errFn(
+			errors.Wrapf(
+				err,
+				"error failing hung worker for event %q",
+				eventID,
+			),
+		)
+		return false
+	}
+	o.cleanupWorkerFn(eventID)
+	return true
+}
+
 func (o *observer) cleanupWorker(eventID string) {
 	ctx, cancel := context.WithTimeout(context.Background(), apiRequestTimeout)
 	defer cancel()
@@ -497,12 +497,35 @@ func TestRunWorkerTimer(t *testing.T) {
 			},
 			observer: &observer{
 				config: observerConfig{
-					maxWorkerLifetime: time.Minute,
+					maxWorkerLifetime:       time.Minute,
+					workerHeartbeatInterval: time.Minute,
 				},
 				timedPodsSet: map[string]context.CancelFunc{
 					"ns:nombre": func() {},
 				},
+				persistTimeoutDeadlineFn: func(
+					context.Context,
+					*corev1.Pod,
+					time.Time,
+				) error {
+					return nil
+				},
 				workersClient: &coreTesting.MockWorkersClient{
+					WatchStatusFn: watchNoWorkerStatusChanges,
+					GetStatusFn: func(
+						context.Context,
+						string,
+						*sdk.WorkerStatusGetOptions,
+					) (sdk.WorkerStatus, error) {
+						// With hung worker detection disabled, the worker's status should
+						// never be polled before the deadline
+						require.Fail(
+							t,
+							"get status should not have been called on workers client, "+
+								"but was",
+						)
+						return sdk.WorkerStatus{}, nil
+					},
 					TimeoutFn: func(
 						context.Context,
 						string,
@@ -539,12 +562,28 @@ func TestRunWorkerTimer(t *testing.T) {
 			},
 			observer: &observer{
 				config: observerConfig{
-					maxWorkerLifetime: time.Minute,
+					maxWorkerLifetime:       time.Minute,
+					workerHeartbeatInterval: time.Minute,
 				},
 				timedPodsSet: map[string]context.CancelFunc{
 					"ns:nombre": func() {},
 				},
+				persistTimeoutDeadlineFn: func(
+					context.Context,
+					*corev1.Pod,
+					time.Time,
+				) error {
+					return nil
+				},
 				workersClient: &coreTesting.MockWorkersClient{
+					WatchStatusFn: watchNoWorkerStatusChanges,
+					GetStatusFn: func(
+						context.Context,
+						string,
+						*sdk.WorkerStatusGetOptions,
+					) (sdk.WorkerStatus, error) {
+						return sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning}, nil
+					},
 					TimeoutFn: func(
 						context.Context,
 						string,
@@ -580,12 +619,28 @@ func TestRunWorkerTimer(t *testing.T) {
 			},
 			observer: &observer{
 				config: observerConfig{
-					maxWorkerLifetime: time.Minute,
+					maxWorkerLifetime:       time.Minute,
+					workerHeartbeatInterval: time.Minute,
 				},
 				timedPodsSet: map[string]context.CancelFunc{
 					"ns:nombre": func() {},
 				},
+				persistTimeoutDeadlineFn: func(
+					context.Context,
+					*corev1.Pod,
+					time.Time,
+				) error {
+					return nil
+				},
 				workersClient: &coreTesting.MockWorkersClient{
+					WatchStatusFn: watchNoWorkerStatusChanges,
+					GetStatusFn: func(
+						context.Context,
+						string,
+						*sdk.WorkerStatusGetOptions,
+					) (sdk.WorkerStatus, error) {
+						return sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning}, nil
+					},
 					TimeoutFn: func(
 						context.Context,
 						string,
@@ -616,6 +671,467 @@ func TestRunWorkerTimer(t *testing.T) {
 	}
 }
 
+func TestRunWorkerTimerWithHungWorker(t *testing.T) {
+	observer := &observer{
+		config: observerConfig{
+			maxWorkerLifetime:         time.Minute,
+			workerHeartbeatInterval:   100 * time.Millisecond,
+			maxMissedWorkerHeartbeats: 1,
+		},
+		timedPodsSet: map[string]context.CancelFunc{
+			"ns:nombre": func() {},
+		},
+		persistTimeoutDeadlineFn: func(
+			context.Context,
+			*corev1.Pod,
+			time.Time,
+		) error {
+			return nil
+		},
+		workersClient: &coreTesting.MockWorkersClient{
+			GetStatusFn: func(
+				context.Context,
+				string,
+				*sdk.WorkerStatusGetOptions,
+			) (sdk.WorkerStatus, error) {
+				return sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning}, nil
+			},
+			TimeoutFn: func(
+				context.Context,
+				string,
+				*sdk.WorkerTimeoutOptions,
+			) error {
+				require.Fail(
+					t,
+					"timeout should not have been called on workers client, but was",
+				)
+				return nil
+			},
+		},
+	}
+	var checks int
+	observer.checkWorkerHeartbeatFn = func(
+		context.Context,
+		string,
+		sdk.WorkerStatus,
+	) bool {
+		checks++
+		// Report the worker as hung on the second check
+		return checks == 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	observer.runWorkerTimer(
+		ctx,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nombre",
+				Namespace: "ns",
+				Labels: map[string]string{
+					myk8s.LabelEvent: "tunguska",
+				},
+			},
+		},
+	)
+	require.Equal(t, 2, checks)
+	require.NoError(t, ctx.Err())
+	require.Empty(t, observer.timedPodsSet)
+}
+
+func TestRunWorkerTimerWithSuspendedWorker(t *testing.T) {
+	const suspendedFor = time.Second
+	var timedOut time.Time
+	var persistedDeadline time.Time
+	start := time.Now()
+	observer := &observer{
+		config: observerConfig{
+			maxWorkerLifetime:         time.Minute,
+			workerHeartbeatInterval:   100 * time.Millisecond,
+			maxMissedWorkerHeartbeats: 5,
+		},
+		checkWorkerHeartbeatFn: func(
+			context.Context,
+			string,
+			sdk.WorkerStatus,
+		) bool {
+			return false
+		},
+		timedPodsSet: map[string]context.CancelFunc{
+			"ns:nombre": func() {},
+		},
+		persistTimeoutDeadlineFn: func(
+			_ context.Context,
+			_ *corev1.Pod,
+			deadline time.Time,
+		) error {
+			persistedDeadline = deadline
+			return nil
+		},
+		workersClient: &coreTesting.MockWorkersClient{
+			GetStatusFn: func(
+				context.Context,
+				string,
+				*sdk.WorkerStatusGetOptions,
+			) (sdk.WorkerStatus, error) {
+				// The worker is suspended for a while, then resumed
+				if time.Since(start) < suspendedFor {
+					return sdk.WorkerStatus{Phase: sdk.WorkerPhaseSuspended}, nil
+				}
+				return sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning}, nil
+			},
+			TimeoutFn: func(
+				context.Context,
+				string,
+				*sdk.WorkerTimeoutOptions,
+			) error {
+				timedOut = time.Now()
+				return nil
+			},
+		},
+		errFn: func(i ...interface{}) {
+			require.Fail(t, "errFn should not have been called, but was")
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	observer.runWorkerTimer(
+		ctx,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nombre",
+				Namespace: "ns",
+				Labels: map[string]string{
+					myk8s.LabelEvent: "tunguska",
+				},
+				Annotations: map[string]string{
+					myk8s.AnnotationTimeoutDuration: "1s",
+				},
+			},
+		},
+	)
+	require.NoError(t, ctx.Err())
+	// The timeout clock should have been stopped while the worker was
+	// suspended, so the worker should have been timed out no sooner than its
+	// timeout plus the time it spent suspended.
+	require.False(t, timedOut.IsZero())
+	require.GreaterOrEqual(t, timedOut.Sub(start), time.Second+suspendedFor/2)
+	// The extended deadline should have been persisted
+	require.False(t, persistedDeadline.IsZero())
+	require.Empty(t, observer.timedPodsSet)
+}
+
+func TestRunWorkerTimerWithWatchedSuspendedWorker(t *testing.T) {
+	const suspendedFor = time.Second
+	var timedOut time.Time
+	var persistedDeadline time.Time
+	start := time.Now()
+	observer := &observer{
+		config: observerConfig{
+			maxWorkerLifetime:       time.Minute,
+			workerHeartbeatInterval: 100 * time.Millisecond,
+		},
+		timedPodsSet: map[string]context.CancelFunc{
+			"ns:nombre": func() {},
+		},
+		persistTimeoutDeadlineFn: func(
+			_ context.Context,
+			_ *corev1.Pod,
+			deadline time.Time,
+		) error {
+			persistedDeadline = deadline
+			return nil
+		},
+		workersClient: &coreTesting.MockWorkersClient{
+			WatchStatusFn: func(
+				ctx context.Context,
+				_ string,
+				_ *sdk.WorkerStatusWatchOptions,
+			) (<-chan sdk.WorkerStatus, <-chan error, error) {
+				statusCh := make(chan sdk.WorkerStatus)
+				go func() {
+					// The worker is suspended for a while, then resumed
+					for _, phase := range []sdk.WorkerPhase{
+						sdk.WorkerPhaseSuspended,
+						sdk.WorkerPhaseRunning,
+					} {
+						select {
+						case statusCh <- sdk.WorkerStatus{Phase: phase}:
+						case <-ctx.Done():
+							return
+						}
+						time.Sleep(suspendedFor)
+					}
+				}()
+				return statusCh, make(chan error), nil
+			},
+			GetStatusFn: func(
+				context.Context,
+				string,
+				*sdk.WorkerStatusGetOptions,
+			) (sdk.WorkerStatus, error) {
+				return sdk.WorkerStatus{Phase: sdk.WorkerPhaseRunning}, nil
+			},
+			TimeoutFn: func(
+				context.Context,
+				string,
+				*sdk.WorkerTimeoutOptions,
+			) error {
+				timedOut = time.Now()
+				return nil
+			},
+		},
+		errFn: func(i ...interface{}) {
+			require.Fail(t, "errFn should not have been called, but was")
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	observer.runWorkerTimer(
+		ctx,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nombre",
+				Namespace: "ns",
+				Labels: map[string]string{
+					myk8s.LabelEvent: "tunguska",
+				},
+				Annotations: map[string]string{
+					myk8s.AnnotationTimeoutDuration: "1s",
+				},
+			},
+		},
+	)
+	require.NoError(t, ctx.Err())
+	// The timeout clock should have been stopped while the worker was
+	// suspended, so the worker should have been timed out no sooner than its
+	// timeout plus the time it spent suspended.
+	require.False(t, timedOut.IsZero())
+	require.GreaterOrEqual(t, timedOut.Sub(start), time.Second+suspendedFor/2)
+	// The extended deadline should have been persisted
+	require.False(t, persistedDeadline.IsZero())
+	require.Empty(t, observer.timedPodsSet)
+}
+
+// watchNoWorkerStatusChanges is a stand-in for a workers client's WatchStatus
+// method that never reports any changes.
+func watchNoWorkerStatusChanges(
+	context.Context,
+	string,
+	*sdk.WorkerStatusWatchOptions,
+) (<-chan sdk.WorkerStatus, <-chan error, error) {
+	return make(chan sdk.WorkerStatus), make(chan error), nil
+}
+
+func TestCheckWorkerHeartbeat(t *testing.T) {
+	const testEventID = "tunguska"
+	recentHeartbeat := time.Now().UTC()
+	staleHeartbeat := recentHeartbeat.Add(-time.Hour)
+	testCases := []struct {
+		name       string
+		status     sdk.WorkerStatus
+		updateErr  error
+		assertions func(
+			hung bool,
+			updatedStatus *sdk.WorkerStatus,
+			cleanedUp bool,
+			errs []interface{},
+		)
+	}{
+		{
+			name: "worker has never sent a heartbeat",
+			status: sdk.WorkerStatus{
+				Phase: sdk.WorkerPhaseRunning,
+			},
+			assertions: func(
+				hung bool,
+				updatedStatus *sdk.WorkerStatus,
+				cleanedUp bool,
+				errs []interface{},
+			) {
+				require.False(t, hung)
+				require.Nil(t, updatedStatus)
+				require.False(t, cleanedUp)
+				require.Empty(t, errs)
+			},
+		},
+		{
+			name: "worker is already in a terminal phase",
+			status: sdk.WorkerStatus{
+				Phase:         sdk.WorkerPhaseSucceeded,
+				LastHeartbeat: &staleHeartbeat,
+			},
+			assertions: func(
+				hung bool,
+				updatedStatus *sdk.WorkerStatus,
+				cleanedUp bool,
+				errs []interface{},
+			) {
+				require.False(t, hung)
+				require.Nil(t, updatedStatus)
+				require.False(t, cleanedUp)
+				require.Empty(t, errs)
+			},
+		},
+		{
+			name: "worker heartbeat is recent",
+			status: sdk.WorkerStatus{
+				Phase:         sdk.WorkerPhaseRunning,
+				LastHeartbeat: &recentHeartbeat,
+			},
+			assertions: func(
+				hung bool,
+				updatedStatus *sdk.WorkerStatus,
+				cleanedUp bool,
+				errs []interface{},
+			) {
+				require.False(t, hung)
+				require.Nil(t, updatedStatus)
+				require.False(t, cleanedUp)
+				require.Empty(t, errs)
+			},
+		},
+		{
+			name: "error failing hung worker",
+			status: sdk.WorkerStatus{
+				Phase:         sdk.WorkerPhaseRunning,
+				LastHeartbeat: &staleHeartbeat,
+			},
+			updateErr: errors.New("something went wrong"),
+			assertions: func(
+				hung bool,
+				updatedStatus *sdk.WorkerStatus,
+				cleanedUp bool,
+				errs []interface{},
+			) {
+				require.False(t, hung)
+				require.NotNil(t, updatedStatus)
+				require.False(t, cleanedUp)
+				require.Len(t, errs, 1)
+				require.Contains(
+					t,
+					errs[0].(error).Error(), // nolint: forcetypeassert
+					"error failing hung worker",
+				)
+			},
+		},
+		{
+			name: "worker is hung",
+			status: sdk.WorkerStatus{
+				Phase:         sdk.WorkerPhaseRunning,
+				LastHeartbeat: &staleHeartbeat,
+			},
+			assertions: func(
+				hung bool,
+				updatedStatus *sdk.WorkerStatus,
+				cleanedUp bool,
+				errs []interface{},
+			) {
+				require.True(t, hung)
+				require.NotNil(t, updatedStatus)
+				require.Equal(t, sdk.WorkerPhaseFailed, updatedStatus.Phase)
+				require.NotNil(t, updatedStatus.Ended)
+				require.Contains(t, updatedStatus.Reason, "missed 4 consecutive")
+				require.True(t, cleanedUp)
+				require.Empty(t, errs)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var updatedStatus *sdk.WorkerStatus
+			var cleanedUp bool
+			errs := []interface{}{}
+			observer := &observer{
+				config: observerConfig{
+					workerHeartbeatInterval:   30 * time.Second,
+					maxMissedWorkerHeartbeats: 4,
+				},
+				workersClient: &coreTesting.MockWorkersClient{
+					UpdateStatusFn: func(
+						_ context.Context,
+						_ string,
+						status sdk.WorkerStatus,
+						_ *sdk.WorkerStatusUpdateOptions,
+					) error {
+						updatedStatus = &status
+						return testCase.updateErr
+					},
+				},
+				cleanupWorkerFn: func(string) {
+					cleanedUp = true
+				},
+				errFn: func(i ...interface{}) {
+					errs = append(errs, i...)
+				},
+			}
+			hung := observer.checkWorkerHeartbeat(
+				context.Background(),
+				testEventID,
+				testCase.status,
+			)
+			testCase.assertions(hung, updatedStatus, cleanedUp, errs)
+		})
+	}
+}
+
+func TestGetWorkerStatus(t *testing.T) {
+	const testEventID = "tunguska"
+	testCases := []struct {
+		name       string
+		getErr     error
+		assertions func(status sdk.WorkerStatus, ok bool, errs []interface{})
+	}{
+		{
+			name:   "error getting worker status",
+			getErr: errors.New("something went wrong"),
+			assertions: func(_ sdk.WorkerStatus, ok bool, errs []interface{}) {
+				require.False(t, ok)
+				require.Len(t, errs, 1)
+				require.Contains(
+					t,
+					errs[0].(error).Error(), // nolint: forcetypeassert
+					"something went wrong",
+				)
+			},
+		},
+		{
+			name: "success",
+			assertions: func(
+				status sdk.WorkerStatus,
+				ok bool,
+				errs []interface{},
+			) {
+				require.True(t, ok)
+				require.Equal(t, sdk.WorkerPhaseSuspended, status.Phase)
+				require.Empty(t, errs)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			errs := []interface{}{}
+			observer := &observer{
+				workersClient: &coreTesting.MockWorkersClient{
+					GetStatusFn: func(
+						context.Context,
+						string,
+						*sdk.WorkerStatusGetOptions,
+					) (sdk.WorkerStatus, error) {
+						return sdk.WorkerStatus{
+							Phase: sdk.WorkerPhaseSuspended,
+						}, testCase.getErr
+					},
+				},
+				errFn: func(i ...interface{}) {
+					errs = append(errs, i...)
+				},
+			}
+			status, ok := observer.getWorkerStatus(context.Background(), testEventID)
+			testCase.assertions(status, ok, errs)
+		})
+	}
+}
+
 func TestCleanupWorker(t *testing.T) {
 	const testEventID = "123456789"
 	testCases := []struct {
@@ -180,6 +180,16 @@ outerLoop:
 				continue // Next message
 			}
 
+			// If the Event is suspended, hold the Job. It remains PENDING and will be
+			// rescheduled (i.e. a new message will be sent) when the Event is
+			// resumed.
+			if event.Worker.Status.Phase == sdk.WorkerPhaseSuspended {
+				if err := msg.Ack(ctx); err != nil {
+					s.jobLoopErrFn(err)
+				}
+				continue // Next message
+			}
+
 			// Wait for capacity
 			select {
 			case <-s.jobAvailabilityCh:
@@ -391,6 +391,76 @@ func TestRunJobLoop(t *testing.T) {
 			},
 		},
 
+		{
+			name: "event is suspended",
+			setup: func(_ context.Context, cancelFn func()) *scheduler {
+				return &scheduler{
+					queueReaderFactory: &mockQueueReaderFactory{
+						NewReaderFn: func(queueName string) (queue.Reader, error) {
+							return &mockQueueReader{
+								ReadFn: func(c context.Context) (*queue.Message, error) {
+									return &queue.Message{
+										Message: "foo:bar",
+										Ack: func(context.Context) error {
+											cancelFn()
+											return nil
+										},
+									}, nil
+								},
+								CloseFn: func(c context.Context) error {
+									return nil
+								},
+							}, nil
+						},
+					},
+					eventsClient: &coreTesting.MockEventsClient{
+						GetFn: func(
+							context.Context,
+							string,
+							*sdk.EventGetOptions,
+						) (sdk.Event, error) {
+							return sdk.Event{
+								Worker: &sdk.Worker{
+									Status: sdk.WorkerStatus{
+										Phase: sdk.WorkerPhaseSuspended,
+									},
+									Jobs: []sdk.Job{
+										{
+											Name: "bar",
+											Status: &sdk.JobStatus{
+												Phase: sdk.JobPhasePending,
+											},
+										},
+									},
+								},
+							}, nil
+						},
+					},
+					jobsClient: &coreTesting.MockJobsClient{
+						StartFn: func(
+							context.Context,
+							string,
+							string,
+							*sdk.JobStartOptions,
+						) error {
+							require.Fail(t, "job should not have been started")
+							return nil
+						},
+					},
+					jobLoopErrFn: func(i ...interface{}) {
+						require.Fail(
+							t,
+							"error logging function should not have been called",
+						)
+						cancelFn()
+					},
+				}
+			},
+			assertions: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+
 		{
 			name: "error starting job",
 			setup: func(ctx context.Context, cancelFn func()) *scheduler {
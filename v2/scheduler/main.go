@@ -9,8 +9,10 @@ import (
 	"github.com/brigadecore/brigade-foundations/signals"
 	"github.com/brigadecore/brigade-foundations/version"
 	"github.com/brigadecore/brigade/sdk/v3"
+	"github.com/brigadecore/brigade/v2/internal/kubernetes"
 	"github.com/brigadecore/brigade/v2/scheduler/internal/lib/queue"
 	"github.com/brigadecore/brigade/v2/scheduler/internal/lib/queue/amqp"
+	k8s "k8s.io/client-go/kubernetes"
 )
 
 func main() {
@@ -66,8 +68,32 @@ func main() {
 		)
 	}
 
-	// Run it!
-	log.Println(scheduler.run(ctx))
+	// Leader election
+	var leaderElectionConfig kubernetes.LeaderElectionConfig
+	var leaseClient k8s.Interface
+	{
+		var err error
+		if leaderElectionConfig, err =
+			kubernetes.GetLeaderElectionConfig(); err != nil {
+			log.Fatal(err)
+		}
+		if leaderElectionConfig.Enabled {
+			if leaseClient, err = kubernetes.InClusterClient(); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// Run it! If leader election is enabled, this only happens once this replica
+	// becomes the leader.
+	log.Println(
+		kubernetes.RunAsLeader(
+			ctx,
+			leaseClient,
+			leaderElectionConfig,
+			scheduler.run,
+		),
+	)
 }
 
 func testClient(ctx context.Context, client sdk.APIClient) error {